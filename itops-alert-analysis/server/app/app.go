@@ -60,10 +60,6 @@ func New(cfgManager *config.ConfigManager) (*App, error) {
 		return nil, errors.Wrap(err, "初始化 CorrelationService 失败")
 	}
 
-	apiServer, err := api.New(cfg, repoFactory, corr)
-	if err != nil {
-		return nil, errors.Wrap(err, "初始化 Api 失败")
-	}
 	// 初始化 RCA 服务
 	rcaSvc, err := rca.New(
 		*cfg,
@@ -76,6 +72,11 @@ func New(cfgManager *config.ConfigManager) (*App, error) {
 		return nil, errors.Wrap(err, "初始化 Rca 失败")
 	}
 
+	apiServer, err := api.New(cfg, repoFactory, corr, corr, corr, corr, corr, corr, rcaSvc)
+	if err != nil {
+		return nil, errors.Wrap(err, "初始化 Api 失败")
+	}
+
 	return &App{
 		API:         apiServer,
 		Correlation: corr,
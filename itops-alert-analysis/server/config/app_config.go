@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
 )
 
 // ========== 远程配置服务 ==========
@@ -23,6 +25,10 @@ type AppConfig struct {
 	Ingest           IngestConfig            `yaml:"ingest"`
 	FaultPoint       FaultPointExpirationCfg `yaml:"fault_point" json:"fault_point"`
 	Problem          ProblemExpirationCfg    `yaml:"problem" json:"problem"`
+	// MaintenanceWindows 维护窗口，由 alert-manager 维护窗口接口下发
+	MaintenanceWindows []MaintenanceWindow `yaml:"maintenance_windows" json:"maintenance_windows"`
+	// FailureModes 故障模式目录，由 alert-manager 故障模式接口下发
+	FailureModes []FailureModeEntry `yaml:"failure_modes" json:"failure_modes"`
 }
 
 // CredentialsConfig 认证凭据配置
@@ -37,24 +43,283 @@ type KnowledgeNetworkConfig struct {
 
 // IngestConfig 数据摄取配置
 type IngestConfig struct {
-	Source Source `yaml:"source"`
+	Source           Source                 `yaml:"source"`
+	Prometheus       PrometheusConfig       `yaml:"prometheus"`        // Prometheus Alertmanager 数据源配置
+	JSONMappings     []JSONMappingRule      `yaml:"json_mappings"`     // json_mapping 声明式映射规则，每条规则即一个数据源
+	UnresolvedEntity UnresolvedEntityConfig `yaml:"unresolved_entity"` // 未解析实体兜底配置
+	Dedup            DedupConfig            `yaml:"dedup"`             // 重复事件去重配置
+	Flapping         FlappingConfig         `yaml:"flapping"`          // 告警抖动抑制配置
 }
 
 // Source 数据源配置
+// Sources 为空时仅启用 Type 对应的单一数据源；
+// 非空时进入多数据源模式，按 Kafka header / HTTP 路径 / payload 嗅探路由，均无法识别时使用 Type 作为默认来源。
 type Source struct {
-	Type string `yaml:"type"`
+	Type    string   `yaml:"type"`
+	Sources []string `yaml:"sources"`
+}
+
+// PrometheusConfig Prometheus Alertmanager 数据源配置
+// 标签列表按顺序取第一个非空值，未配置时使用内置默认值
+type PrometheusConfig struct {
+	ClusterLabels   []string `yaml:"cluster_labels"`    // 集群标签（对应对象的 k8s_cluster）
+	NamespaceLabels []string `yaml:"namespace_labels"`  // 命名空间标签（对应对象的 namespace）
+	NameLabels      []string `yaml:"name_labels"`       // 对象名称标签（对应对象的 name）
+	EventTypeLabels []string `yaml:"event_type_labels"` // 与 alertname 一起组成 EventType 的标签
+}
+
+// JSONMappingRule json_mapping 数据源的声明式映射规则
+// 字段表达式支持两种写法：
+//   - 路径：如 "alert.labels.host"、"$.items[0].name"
+//   - 模板：包含 ${路径} 占位符的字符串，如 "${labels.alertname} on ${labels.instance}"
+type JSONMappingRule struct {
+	Name        string            `yaml:"name" json:"name"`                 // 数据源名称，写入 RawEvent.EventSource，也可作为 sources 中的数据源使用
+	Match       map[string]string `yaml:"match" json:"match"`               // 嗅探条件：路径 -> 期望值，期望值为空表示仅要求字段存在
+	ItemsPath   string            `yaml:"items_path" json:"items_path"`     // 告警数组路径（可选），配置后数组中每个元素生成一个事件，字段路径相对于元素
+	TimeLayout  string            `yaml:"time_layout" json:"time_layout"`   // 时间格式，为空时依次尝试 RFC3339 与 "2006-01-02 15:04:05"，数值按 Unix 时间戳解析
+	Fields      JSONMappingFields `yaml:"fields" json:"fields"`             // 字段映射
+	SeverityMap map[string]int    `yaml:"severity_map" json:"severity_map"` // 级别值映射：原始值 -> 1-5（紧急/严重/重要/警告/正常）
+	StatusMap   map[string]string `yaml:"status_map" json:"status_map"`     // 状态值映射：原始值 -> occurred / recovered
+}
+
+// JSONMappingFields json_mapping 字段表达式
+type JSONMappingFields struct {
+	ProviderID   string `yaml:"provider_id" json:"provider_id"`     // 上游事件 ID
+	Title        string `yaml:"title" json:"title"`                 // 事件标题
+	Content      string `yaml:"content" json:"content"`             // 事件内容
+	Severity     string `yaml:"severity" json:"severity"`           // 事件级别
+	Status       string `yaml:"status" json:"status"`               // 事件状态
+	OccurTime    string `yaml:"occur_time" json:"occur_time"`       // 发生时间
+	RecoveryTime string `yaml:"recovery_time" json:"recovery_time"` // 恢复时间
+	EntityName   string `yaml:"entity_name" json:"entity_name"`     // 实体对象名称（用于查询对象类缓存）
+	EntityIP     string `yaml:"entity_ip" json:"entity_ip"`         // 实体对象 IP
+	EntityMAC    string `yaml:"entity_mac" json:"entity_mac"`       // 实体对象 MAC
+	ItemKey      string `yaml:"item_key" json:"item_key"`           // 监控项，作为 EventType
+	RecoveryID   string `yaml:"recovery_id" json:"recovery_id"`     // 恢复事件关联的原始事件 ID
+}
+
+// UnresolvedEntityConfig 未解析实体兜底配置
+// 启用后，对象类缓存中查不到实体的事件不再被丢弃，而是关联到按主机名/IP 生成的占位对象，
+// 待对象类缓存刷新找到真实对象后自动重新关联。
+type UnresolvedEntityConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+}
+
+// DedupConfig 重复事件去重配置
+// 窗口内同一数据源、同一 EventProviderID、同一状态的事件视为上游重发，不再重复入库。
+type DedupConfig struct {
+	Enabled bool          `yaml:"enabled" json:"enabled"`
+	Window  time.Duration `yaml:"window" json:"window"` // 去重窗口，为 0 时使用默认值 10m
+}
+
+// FlappingConfig 告警抖动抑制配置
+// 同一实体、同一 EventType 在 Window 内发生/恢复状态切换达到 Threshold 次即判定为抖动，
+// 抖动期间故障点保持发生状态并标记为抖动，不再反复恢复、重开与触发 RCA；
+// 抖动停止（Window 内无新事件）后按最后一次状态收敛。
+type FlappingConfig struct {
+	Enabled   bool          `yaml:"enabled" json:"enabled"`
+	Threshold int           `yaml:"threshold" json:"threshold"` // 状态切换次数阈值，为 0 时使用默认值 4
+	Window    time.Duration `yaml:"window" json:"window"`       // 统计窗口，为 0 时使用默认值 30m
+}
+
+// ========== 维护窗口配置 ==========
+
+// 维护窗口重复周期
+const (
+	RecurrenceNone    = "none"
+	RecurrenceDaily   = "daily"
+	RecurrenceWeekly  = "weekly"
+	RecurrenceMonthly = "monthly"
+)
+
+// MaintenanceWindow 维护窗口
+// 窗口内命中选择器的事件照常入库但标记为已抑制，不参与故障点与问题收敛。
+// Start/End 为首次维护的起止时间，两者之差为每次维护时长，按 Recurrence 周期重复。
+type MaintenanceWindow struct {
+	ID         string                `yaml:"id" json:"id"`
+	Name       string                `yaml:"name" json:"name"`
+	Enabled    bool                  `yaml:"enabled" json:"enabled"`
+	Selector   MaintenanceSelector   `yaml:"selector" json:"selector"`
+	Start      time.Time             `yaml:"start" json:"start"`
+	End        time.Time             `yaml:"end" json:"end"`
+	Recurrence MaintenanceRecurrence `yaml:"recurrence" json:"recurrence"`
+}
+
+// MaintenanceSelector 维护对象选择器，任一条件命中即视为维护对象
+type MaintenanceSelector struct {
+	EntityIDs     []string           `yaml:"entity_ids" json:"entity_ids"`         // 对象 ID（s_id）
+	ObjectClasses []string           `yaml:"object_classes" json:"object_classes"` // 对象类
+	Subgraphs     []SubgraphSelector `yaml:"subgraphs" json:"subgraphs"`           // 知识网络子图
+}
+
+// SubgraphSelector 知识网络子图选择器：起点对象及其 PathLength 跳内的对象
+type SubgraphSelector struct {
+	ObjectClass string `yaml:"object_class" json:"object_class"`
+	EntityID    string `yaml:"entity_id" json:"entity_id"`
+	Direction   string `yaml:"direction" json:"direction"`     // forward/backward/bidirectional，默认 bidirectional
+	PathLength  int    `yaml:"path_length" json:"path_length"` // 默认 1
+}
+
+// MaintenanceRecurrence 维护窗口重复规则
+type MaintenanceRecurrence struct {
+	Type     string         `yaml:"type" json:"type"`         // none/daily/weekly/monthly，默认 none
+	Weekdays []time.Weekday `yaml:"weekdays" json:"weekdays"` // weekly 时生效，为空时取 Start 所在星期
+	Until    *time.Time     `yaml:"until" json:"until"`       // 重复截止时间，为空表示不截止
+}
+
+// ========== 故障模式目录 ==========
+
+// 故障模式规则匹配字段与方式
+const (
+	FailureModeFieldItemKey = "item_key"
+	FailureModeFieldTitle   = "title"
+	FailureModeMatchGlob    = "glob"
+	FailureModeMatchRegex   = "regex"
+)
+
+// FailureModeEntry 故障模式目录项
+// 将各监控工具命名不同的监控项 key / 告警标题归一为统一的故障模式（如 cpu_saturation、disk_full），
+// 使历史因果与 LLM 推理不受工具命名差异影响，并可在不同主机间迁移。
+type FailureModeEntry struct {
+	Mode        string            `yaml:"mode" json:"mode"` // 故障模式编码
+	Name        string            `yaml:"name" json:"name"`
+	Description string            `yaml:"description" json:"description"`
+	Rules       []FailureModeRule `yaml:"rules" json:"rules"`
+}
+
+// FailureModeRule 故障模式匹配规则，指定对象类的规则优先于不限对象类的规则
+type FailureModeRule struct {
+	ObjectClass string `yaml:"object_class" json:"object_class"` // 为空表示不限对象类
+	Field       string `yaml:"field" json:"field"`               // item_key/title，默认 item_key
+	Match       string `yaml:"match" json:"match"`               // glob/regex，默认 glob
+	Pattern     string `yaml:"pattern" json:"pattern"`
 }
 
 // ========== 失效策略配置 ==========
 
 // FaultPointExpirationCfg 故障点失效配置（AppConfig 使用）
 type FaultPointExpirationCfg struct {
-	Expiration LocalExpirationConfig `yaml:"expiration" json:"expiration"`
+	Expiration   LocalExpirationConfig `yaml:"expiration" json:"expiration"`
+	Convergence  ConvergenceConfig     `yaml:"convergence" json:"convergence"`   // 故障点收敛键配置
+	Deescalation DeescalationConfig    `yaml:"deescalation" json:"deescalation"` // 故障等级回落配置
+}
+
+// DeescalationConfig 故障等级回落配置
+// 故障点等级默认只升不降；启用后，Window 内未再收到当前等级（或更高等级）的事件时，
+// 故障点等级回落到最新事件的等级。
+type DeescalationConfig struct {
+	Enabled bool          `yaml:"enabled" json:"enabled"`
+	Window  time.Duration `yaml:"window" json:"window"` // 回落窗口，为 0 时使用默认 10m
+}
+
+// 故障点收敛键策略
+const (
+	ConvergenceItemKey         = "item_key"         // 按 EventType（监控项 key），默认策略
+	ConvergenceTriggerName     = "trigger_name"     // 按触发器名称（事件标题），忽略监控项 key
+	ConvergenceNormalizedTitle = "normalized_title" // 按去除数字后的事件标题
+	ConvergenceLabels          = "labels"           // 按指定标签子集
+)
+
+// ConvergenceConfig 故障点收敛键配置
+// 同一实体下收敛键相同的发生事件合并到同一故障点。Rules 按数据源、对象类匹配，
+// 同时指定两者的规则优先于只指定其一的规则，均未命中时使用 Default。
+type ConvergenceConfig struct {
+	Default ConvergenceRule   `yaml:"default" json:"default"`
+	Rules   []ConvergenceRule `yaml:"rules" json:"rules"`
+}
+
+// ConvergenceRule 收敛键规则，Source/ObjectClass 为空表示不限
+type ConvergenceRule struct {
+	Source      string   `yaml:"source" json:"source"`
+	ObjectClass string   `yaml:"object_class" json:"object_class"`
+	Strategy    string   `yaml:"strategy" json:"strategy"` // 为空时使用 item_key
+	Labels      []string `yaml:"labels" json:"labels"`     // labels 策略使用的标签名
 }
 
 // ProblemExpirationCfg 问题失效配置（AppConfig 使用）
 type ProblemExpirationCfg struct {
-	Expiration LocalExpirationConfig `yaml:"expiration" json:"expiration"`
+	Expiration  LocalExpirationConfig    `yaml:"expiration" json:"expiration"`
+	Spatial     SpatialCorrelationConfig `yaml:"spatial" json:"spatial"`         // 空间相关性配置
+	Correlation CorrelationConfig        `yaml:"correlation" json:"correlation"` // 问题合并策略组合
+	Reopen      ReopenConfig             `yaml:"reopen" json:"reopen"`           // 问题重开与复发关联
+}
+
+// ReopenConfig 问题重开与复发关联配置
+// 启用后，未命中打开问题的故障点按指纹（对象 + 故障模式）查找已关闭的问题：
+// 关闭时间在 Window 内的系统关闭问题直接重开，超出 Window 但在 Lookback 内时创建新问题并关联为其复发。
+type ReopenConfig struct {
+	Enabled  bool          `yaml:"enabled" json:"enabled"`
+	Window   time.Duration `yaml:"window" json:"window"`     // 重开窗口，为 0 时使用默认 30m
+	Lookback time.Duration `yaml:"lookback" json:"lookback"` // 复发回溯时间，为 0 时使用默认 168h
+}
+
+// 问题合并相关性策略
+const (
+	CorrelationSpatial  = "spatial"  // 空间相关：知识网络拓扑可达
+	CorrelationTemporal = "temporal" // 时间突发：同一对象组内的故障点在时间窗口内相继发生
+	CorrelationSemantic = "semantic" // 语义相关：故障名称与描述文本相似
+
+	CorrelationCombineAny = "any" // 任一策略命中即合并，默认
+	CorrelationCombineAll = "all" // 全部策略命中才合并
+)
+
+// CorrelationConfig 问题合并策略组合配置
+// Strategies 为空时只启用空间相关性，与未配置时的行为一致。
+type CorrelationConfig struct {
+	Strategies []string                  `yaml:"strategies" json:"strategies"`
+	Combine    string                    `yaml:"combine" json:"combine"` // any/all，默认 any
+	Temporal   TemporalCorrelationConfig `yaml:"temporal" json:"temporal"`
+	Semantic   SemanticCorrelationConfig `yaml:"semantic" json:"semantic"`
+}
+
+// TemporalCorrelationConfig 时间突发相关性配置
+// 故障点与问题中同一对象组的故障点发生时间相差不超过 Window 时相关，得分随时间差线性衰减。
+type TemporalCorrelationConfig struct {
+	Window   time.Duration `yaml:"window" json:"window"`       // 时间窗口，默认 60s
+	MinScore float64       `yaml:"min_score" json:"min_score"` // 合并所需的最低得分
+	Groups   []ObjectGroup `yaml:"groups" json:"groups"`       // 对象组，未配置时时间突发策略不生效
+}
+
+// ObjectGroup 对象组，按对象类或对象 ID 圈定
+type ObjectGroup struct {
+	Name          string   `yaml:"name" json:"name"`
+	ObjectClasses []string `yaml:"object_classes" json:"object_classes"`
+	EntityIDs     []string `yaml:"entity_ids" json:"entity_ids"`
+}
+
+// SemanticCorrelationConfig 语义相关性配置，按故障名称与描述的文本相似度判断
+type SemanticCorrelationConfig struct {
+	MinScore float64 `yaml:"min_score" json:"min_score"` // 合并所需的最低相似度，默认 0.6
+}
+
+// 空间关系传播方向
+const (
+	RelationForward       = "forward"       // 从关系源对象传播到目标对象
+	RelationBackward      = "backward"      // 从关系目标对象传播到源对象
+	RelationBidirectional = "bidirectional" // 双向传播，默认
+)
+
+// SpatialCorrelationConfig 空间相关性配置
+// 故障点对象沿允许的关系在 MaxHops 跳内可达的对象，若属于已有问题则按跳数权重计算得分，
+// 得分不低于 MinScore 时合并。Rules 按故障点对象类匹配，未命中时使用 Default。
+type SpatialCorrelationConfig struct {
+	Default SpatialRule   `yaml:"default" json:"default"`
+	Rules   []SpatialRule `yaml:"rules" json:"rules"`
+}
+
+// SpatialRule 空间相关性规则，ObjectClass 为空表示不限
+type SpatialRule struct {
+	ObjectClass string            `yaml:"object_class" json:"object_class"`
+	MaxHops     int               `yaml:"max_hops" json:"max_hops"`       // 最大跳数，默认 1
+	Relations   []SpatialRelation `yaml:"relations" json:"relations"`     // 允许传播的关系，为空表示不限关系类型
+	HopWeights  []float64         `yaml:"hop_weights" json:"hop_weights"` // 第 N 跳的权重，未配置的跳沿用最后一个权重，默认第 1 跳为 1、之后每跳减半（减半后 max_hops 跳低于 min_score 时放缓衰减）
+	MinScore    float64           `yaml:"min_score" json:"min_score"`     // 合并所需的最低得分，默认 0.5
+}
+
+// SpatialRelation 允许传播的关系类型与方向
+type SpatialRelation struct {
+	Type      string `yaml:"type" json:"type"`           // 关系类型 ID，如 runs_on、connects_to
+	Direction string `yaml:"direction" json:"direction"` // forward/backward/bidirectional，默认 bidirectional
 }
 
 // LocalExpirationConfig 本地失效配置（使用 Duration 格式如 "6h"）
@@ -93,6 +358,11 @@ type RemoteAppConfig struct {
 	KnowledgeNetwork RemoteKnowledgeNetwork `json:"knowledge_network"`
 	FaultPointPolicy RemotePolicyConfig     `json:"fault_point_policy"`
 	ProblemPolicy    RemotePolicyConfig     `json:"problem_policy"`
+	Ingest           RemoteIngestConfig     `json:"ingest"`
+	// MaintenanceWindows 维护窗口
+	MaintenanceWindows []RemoteMaintenanceWindow `json:"maintenance_windows"`
+	// FailureModes 故障模式目录
+	FailureModes []FailureModeEntry `json:"failure_modes"`
 }
 
 // RemotePlatformConfig 远程平台配置
@@ -105,9 +375,83 @@ type RemoteKnowledgeNetwork struct {
 	KnowledgeID string `json:"knowledge_id"` // 知识网络 ID
 }
 
+// RemoteIngestConfig 远程数据摄取配置
+type RemoteIngestConfig struct {
+	DefaultSource    string                 `json:"default_source"`    // 默认数据源
+	Sources          []string               `json:"sources"`           // 启用的数据源，多于一个时进入多数据源模式
+	JSONMappings     []JSONMappingRule      `json:"json_mappings"`     // json_mapping 映射规则
+	UnresolvedEntity UnresolvedEntityConfig `json:"unresolved_entity"` // 未解析实体兜底配置
+	Dedup            RemoteDedupConfig      `json:"dedup"`             // 重复事件去重配置
+	Flapping         RemoteFlappingConfig   `json:"flapping"`          // 告警抖动抑制配置
+}
+
+// RemoteDedupConfig 远程重复事件去重配置
+type RemoteDedupConfig struct {
+	Enabled       bool `json:"enabled"`
+	WindowSeconds int  `json:"window_seconds"` // 去重窗口（秒）
+}
+
+// RemoteFlappingConfig 远程告警抖动抑制配置
+type RemoteFlappingConfig struct {
+	Enabled       bool `json:"enabled"`
+	Threshold     int  `json:"threshold"`      // 状态切换次数阈值
+	WindowMinutes int  `json:"window_minutes"` // 统计窗口（分钟）
+}
+
+// RemoteMaintenanceWindow 远程维护窗口配置（时间为 RFC3339 字符串）
+type RemoteMaintenanceWindow struct {
+	ID         string                      `json:"id"`
+	Name       string                      `json:"name"`
+	Enabled    bool                        `json:"enabled"`
+	Selector   MaintenanceSelector         `json:"selector"`
+	StartTime  string                      `json:"start_time"`
+	EndTime    string                      `json:"end_time"`
+	Recurrence RemoteMaintenanceRecurrence `json:"recurrence"`
+}
+
+// RemoteMaintenanceRecurrence 远程维护窗口重复规则
+type RemoteMaintenanceRecurrence struct {
+	Type     string `json:"type"`
+	Weekdays []int  `json:"weekdays"` // 0-6 表示周日至周六
+	Until    string `json:"until"`
+}
+
 // RemotePolicyConfig 远程策略配置
 type RemotePolicyConfig struct {
-	Expiration RemoteExpirationConfig `json:"expiration"`
+	Expiration   RemoteExpirationConfig   `json:"expiration"`
+	Convergence  ConvergenceConfig        `json:"convergence"`  // 收敛键配置，仅故障点策略使用
+	Deescalation RemoteDeescalationConfig `json:"deescalation"` // 故障等级回落配置，仅故障点策略使用
+	Spatial      SpatialCorrelationConfig `json:"spatial"`      // 空间相关性配置，仅问题策略使用
+	Correlation  RemoteCorrelationConfig  `json:"correlation"`  // 问题合并策略组合，仅问题策略使用
+	Reopen       RemoteReopenConfig       `json:"reopen"`       // 问题重开与复发关联，仅问题策略使用
+}
+
+// RemoteReopenConfig 远程问题重开与复发关联配置
+type RemoteReopenConfig struct {
+	Enabled       bool `json:"enabled"`
+	WindowMinutes int  `json:"window_minutes"` // 重开窗口（分钟）
+	LookbackHours int  `json:"lookback_hours"` // 复发回溯时间（小时）
+}
+
+// RemoteCorrelationConfig 远程问题合并策略组合配置
+type RemoteCorrelationConfig struct {
+	Strategies []string                        `json:"strategies"`
+	Combine    string                          `json:"combine"`
+	Temporal   RemoteTemporalCorrelationConfig `json:"temporal"`
+	Semantic   SemanticCorrelationConfig       `json:"semantic"`
+}
+
+// RemoteTemporalCorrelationConfig 远程时间突发相关性配置
+type RemoteTemporalCorrelationConfig struct {
+	WindowSeconds int           `json:"window_seconds"` // 时间窗口（秒）
+	MinScore      float64       `json:"min_score"`
+	Groups        []ObjectGroup `json:"groups"`
+}
+
+// RemoteDeescalationConfig 远程故障等级回落配置
+type RemoteDeescalationConfig struct {
+	Enabled       bool `json:"enabled"`
+	WindowMinutes int  `json:"window_minutes"` // 回落窗口（分钟）
 }
 
 // RemoteExpirationConfig 远程失效配置（time_type + time_relativity）
@@ -119,6 +463,9 @@ type RemoteExpirationConfig struct {
 // defaultTimeRelativity 默认失效时间（小时）
 const defaultTimeRelativity = 1
 
+// defaultSourceType 未配置数据源时的默认数据源
+const defaultSourceType = "zabbix_webhook"
+
 // ToAppConfig 将远程配置转换为本地配置格式
 func (r *RemoteAppConfig) ToAppConfig() *AppConfig {
 	faultPointTime := r.FaultPointPolicy.Expiration.TimeRelativity
@@ -131,6 +478,19 @@ func (r *RemoteAppConfig) ToAppConfig() *AppConfig {
 		problemTime = defaultTimeRelativity
 	}
 
+	sourceType := r.Ingest.DefaultSource
+	if sourceType == "" {
+		sourceType = defaultSourceType
+		if len(r.Ingest.Sources) > 0 {
+			sourceType = r.Ingest.Sources[0]
+		}
+	}
+
+	var sources []string
+	if len(r.Ingest.Sources) > 1 {
+		sources = r.Ingest.Sources
+	}
+
 	return &AppConfig{
 		Credentials: CredentialsConfig{
 			Authorization: fmt.Sprintf("Bearer %s", r.Platform.AuthToken),
@@ -138,16 +498,93 @@ func (r *RemoteAppConfig) ToAppConfig() *AppConfig {
 		KnowledgeNetwork: KnowledgeNetworkConfig{
 			KnowledgeID: r.KnowledgeNetwork.KnowledgeID,
 		},
-		Ingest: IngestConfig{Source: Source{Type: "zabbix_webhook"}},
+		Ingest: IngestConfig{
+			Source:           Source{Type: sourceType, Sources: sources},
+			JSONMappings:     r.Ingest.JSONMappings,
+			UnresolvedEntity: r.Ingest.UnresolvedEntity,
+			Dedup: DedupConfig{
+				Enabled: r.Ingest.Dedup.Enabled,
+				Window:  time.Duration(r.Ingest.Dedup.WindowSeconds) * time.Second,
+			},
+			Flapping: FlappingConfig{
+				Enabled:   r.Ingest.Flapping.Enabled,
+				Threshold: r.Ingest.Flapping.Threshold,
+				Window:    time.Duration(r.Ingest.Flapping.WindowMinutes) * time.Minute,
+			},
+		},
 		FaultPoint: FaultPointExpirationCfg{
 			Expiration: LocalExpirationConfig{
 				ExpirationTime: time.Duration(faultPointTime) * time.Hour,
 			},
+			Convergence: r.FaultPointPolicy.Convergence,
+			Deescalation: DeescalationConfig{
+				Enabled: r.FaultPointPolicy.Deescalation.Enabled,
+				Window:  time.Duration(r.FaultPointPolicy.Deescalation.WindowMinutes) * time.Minute,
+			},
 		},
 		Problem: ProblemExpirationCfg{
 			Expiration: LocalExpirationConfig{
 				ExpirationTime: time.Duration(problemTime) * time.Hour,
 			},
+			Spatial: r.ProblemPolicy.Spatial,
+			Correlation: CorrelationConfig{
+				Strategies: r.ProblemPolicy.Correlation.Strategies,
+				Combine:    r.ProblemPolicy.Correlation.Combine,
+				Temporal: TemporalCorrelationConfig{
+					Window:   time.Duration(r.ProblemPolicy.Correlation.Temporal.WindowSeconds) * time.Second,
+					MinScore: r.ProblemPolicy.Correlation.Temporal.MinScore,
+					Groups:   r.ProblemPolicy.Correlation.Temporal.Groups,
+				},
+				Semantic: r.ProblemPolicy.Correlation.Semantic,
+			},
+			Reopen: ReopenConfig{
+				Enabled:  r.ProblemPolicy.Reopen.Enabled,
+				Window:   time.Duration(r.ProblemPolicy.Reopen.WindowMinutes) * time.Minute,
+				Lookback: time.Duration(r.ProblemPolicy.Reopen.LookbackHours) * time.Hour,
+			},
 		},
+		MaintenanceWindows: toMaintenanceWindows(r.MaintenanceWindows),
+		FailureModes:       r.FailureModes,
+	}
+}
+
+// toMaintenanceWindows 转换远程维护窗口，时间格式非法的窗口记录日志后忽略（alert-manager 保存时已校验）
+func toMaintenanceWindows(remote []RemoteMaintenanceWindow) []MaintenanceWindow {
+	var windows []MaintenanceWindow
+	for _, r := range remote {
+		start, err := time.Parse(time.RFC3339, r.StartTime)
+		if err != nil {
+			log.Warnf("维护窗口 %s(%s) 的 start_time 非法，忽略该窗口: %v", r.Name, r.ID, err)
+			continue
+		}
+		end, err := time.Parse(time.RFC3339, r.EndTime)
+		if err != nil {
+			log.Warnf("维护窗口 %s(%s) 的 end_time 非法，忽略该窗口: %v", r.Name, r.ID, err)
+			continue
+		}
+		w := MaintenanceWindow{
+			ID:       r.ID,
+			Name:     r.Name,
+			Enabled:  r.Enabled,
+			Selector: r.Selector,
+			Start:    start,
+			End:      end,
+			Recurrence: MaintenanceRecurrence{
+				Type: r.Recurrence.Type,
+			},
+		}
+		for _, d := range r.Recurrence.Weekdays {
+			w.Recurrence.Weekdays = append(w.Recurrence.Weekdays, time.Weekday(d))
+		}
+		if r.Recurrence.Until != "" {
+			until, err := time.Parse(time.RFC3339, r.Recurrence.Until)
+			if err != nil {
+				log.Warnf("维护窗口 %s(%s) 的 recurrence.until 非法，忽略该窗口: %v", r.Name, r.ID, err)
+				continue
+			}
+			w.Recurrence.Until = &until
+		}
+		windows = append(windows, w)
 	}
+	return windows
 }
@@ -18,6 +18,8 @@ type Config struct {
 	DepServices      DepServicesConfig      `yaml:"depServices"`        // 依赖服务配置
 	AppConfigService AppConfigServiceConfig `yaml:"app_config_service"` // 远程配置服务
 	AppConfig        AppConfig              `yaml:"app_config"`         // 业务配置（本地默认值 + 远程接口合并）
+	ObjectClass      ObjectClassConfig      `yaml:"object_class"`       // 对象类缓存配置
+	RCA              RCAConfig              `yaml:"rca"`                // 根因分析调度配置
 }
 
 // ========== API 配置 ==========
@@ -46,12 +48,94 @@ type LogConfig struct {
 type KafkaConfig struct {
 	RawEvents     KafkaStreamConfig `yaml:"raw_events"`     // 原始事件流（HTTP -> Correlation）
 	ProblemEvents KafkaStreamConfig `yaml:"problem_events"` // 问题事件流（Correlation -> RCA）
+	DeadLetters   KafkaStreamConfig `yaml:"dead_letters"`   // 死信流（Ingest 处理失败的消息，topic 为空时不启用）
 }
 
 // KafkaStreamConfig Kafka 流配置
 type KafkaStreamConfig struct {
-	Topic         string `yaml:"topic"`
-	ConsumerGroup string `yaml:"consumer_group"`
+	Topic         string                `yaml:"topic"`
+	ConsumerGroup string                `yaml:"consumer_group"`
+	Parallel      ParallelConsumeConfig `yaml:"parallel"` // 并行消费配置，目前仅原始事件流使用
+}
+
+// ParallelConsumeConfig 并行消费配置
+// Workers <= 1 时逐条顺序消费并逐条提交 offset；
+// 大于 1 时事件标准化后按实体哈希到 Workers 个通道并行处理，同一实体的事件保持顺序，offset 批量提交。
+type ParallelConsumeConfig struct {
+	Workers        int           `yaml:"workers"`         // 并行通道数
+	LaneCapacity   int           `yaml:"lane_capacity"`   // 每个通道缓冲的事件数，默认 100
+	CommitInterval time.Duration `yaml:"commit_interval"` // offset 批量提交间隔，默认 1s
+}
+
+// ========== 对象类缓存配置 ==========
+
+// ObjectClassConfig 对象类缓存配置
+type ObjectClassConfig struct {
+	// IdentityProperties 按对象类 ID 配置参与实体识别的属性（IP、FQDN、主机名、MAC 等），
+	// 键为 default 的列表作用于未单独配置的对象类，未配置时使用内置默认属性。
+	IdentityProperties map[string][]string `yaml:"identity_properties"`
+
+	RefreshInterval     time.Duration `yaml:"refresh_interval"`      // 增量刷新间隔（按 s_update_time 水位拉取变更），默认 30s
+	FullRefreshInterval time.Duration `yaml:"full_refresh_interval"` // 全量刷新间隔（删除检测、续期缓存），默认 10m
+	CacheTTL            time.Duration `yaml:"cache_ttl"`             // 缓存过期时间，默认 1h，需大于全量刷新间隔
+	PageLimit           int           `yaml:"page_limit"`            // 分页大小，默认 1000
+
+	Cache ObjectClassCacheConfig `yaml:"cache"` // 缓存存储配置
+}
+
+// 对象类缓存存储模式
+const (
+	CacheModeRedis  = "redis"  // 仅使用 Redis（默认），Redis 不可用时服务启动失败
+	CacheModeMemory = "memory" // 仅使用进程内缓存，适用于无 Redis 的小规模或离线部署
+	CacheModeTiered = "tiered" // 进程内 L1 + Redis L2，Redis 不可用时降级为仅使用进程内缓存
+)
+
+// ObjectClassCacheConfig 对象类缓存存储配置
+type ObjectClassCacheConfig struct {
+	Mode       string        `yaml:"mode"`        // redis / memory / tiered，默认 redis
+	MaxEntries int           `yaml:"max_entries"` // 进程内缓存最大条目数，默认 100000
+	L1TTL      time.Duration `yaml:"l1_ttl"`      // tiered 模式下从 Redis 回填进程内缓存的过期时间，默认 5m
+}
+
+// ========== 根因分析调度配置 ==========
+
+// RCAConfig 根因分析调度配置
+// 问题每次变化后重新计时，静默 QuietPeriod 后开始分析；持续变化的问题最迟在首次变化 MaxDelay 后分析。
+// 分析过的问题只有故障点集合或等级变化时才重新分析，就绪的问题按等级从高到低调度。
+// 分析失败按 RetryBackoff 指数退避重试，最多执行 MaxAttempts 次；服务启动时恢复 RecoveryWindow 内未完成的分析。
+type RCAConfig struct {
+	QuietPeriod     time.Duration `yaml:"quiet_period"`      // 静默时间，默认 30s
+	MaxDelay        time.Duration `yaml:"max_delay"`         // 最长等待时间，默认 5m
+	Concurrency     int           `yaml:"concurrency"`       // 最大并发分析数，默认 10
+	MaxAttempts     int           `yaml:"max_attempts"`      // 单个任务最大执行次数，默认 3
+	RetryBackoff    time.Duration `yaml:"retry_backoff"`     // 首次重试等待时间，之后每次翻倍，默认 1m
+	MaxRetryBackoff time.Duration `yaml:"max_retry_backoff"` // 重试等待时间上限，默认 30m
+	RecoveryWindow  time.Duration `yaml:"recovery_window"`   // 启动时恢复该时间内创建、RCA 未完成的问题，默认 24h
+
+	Inference CausalInferenceConfig `yaml:"inference"` // 故障点对因果推理引擎
+}
+
+// 因果推理引擎
+const (
+	CausalEngineTiered     = "tiered"     // 高优先级的故障点对使用大模型，失败或低优先级时使用本地规则，默认引擎
+	CausalEngineLLM        = "llm"        // 仅使用大模型 Agent
+	CausalEngineRules      = "rules"      // 仅使用本地规则评分
+	CausalEngineHistorical = "historical" // 仅依据历史因果关系统计
+	CausalEngineEnsemble   = "ensemble"   // 按权重对多个引擎的结果投票
+)
+
+// CausalInferenceConfig 因果推理引擎配置
+type CausalInferenceConfig struct {
+	Engine         string               `yaml:"engine"`           // 推理引擎，为空时使用 tiered
+	LLMMinPriority float64              `yaml:"llm_min_priority"` // tiered 引擎中优先级超过该值的故障点对使用大模型，默认 10
+	Ensemble       []CausalEngineWeight `yaml:"ensemble"`         // ensemble 引擎的成员，为空时 llm、rules、historical 等权投票
+	Batch          bool                 `yaml:"batch"`            // 先将整个问题一次交给整问题因果分析 Agent，失败的部分再按 Engine 逐对推理
+}
+
+// CausalEngineWeight ensemble 引擎成员及其权重，权重不大于 0 时按 1 计
+type CausalEngineWeight struct {
+	Engine string  `yaml:"engine"`
+	Weight float64 `yaml:"weight"`
 }
 
 // ========== 平台配置 ==========
@@ -68,6 +152,7 @@ type PlatformConfig struct {
 type AgentsConfig struct {
 	ProblemSummary AgentConfig `yaml:"problem_summary"` // 问题摘要 Agent
 	CausalAnalysis AgentConfig `yaml:"causal_analysis"` // 因果分析 Agent
+	CausalBatch    AgentConfig `yaml:"causal_batch"`    // 整问题因果分析 Agent，rca.inference.batch 启用时使用
 }
 
 // AgentConfig 单个 Agent 配置
@@ -0,0 +1,22 @@
+// Package configtest 提供测试用的配置提供者。
+package configtest
+
+import (
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+)
+
+// Provider 直接返回给定配置的 config.Provider（不读取文件、不启动 watch）。
+// 测试修改 cfg 指向的配置后，GetConfig 立即返回修改后的内容。
+type Provider struct {
+	cfg *config.Config
+}
+
+// NewProvider 基于给定配置创建测试用配置提供者
+func NewProvider(cfg *config.Config) *Provider {
+	return &Provider{cfg: cfg}
+}
+
+// GetConfig 返回给定的配置
+func (p *Provider) GetConfig() *config.Config {
+	return p.cfg
+}
@@ -17,6 +17,11 @@ import (
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
 )
 
+// Provider 提供当前生效的配置，*ConfigManager 实现了该接口。
+type Provider interface {
+	GetConfig() *Config
+}
+
 // ConfigManager 配置管理器
 type ConfigManager struct {
 	mu            sync.RWMutex
@@ -106,7 +111,7 @@ func defaultAppConfig() *AppConfig {
 			KnowledgeID: "",
 		},
 		Ingest: IngestConfig{
-			Source: Source{Type: "zabbix_webhook"},
+			Source: Source{Type: defaultSourceType},
 		},
 		FaultPoint: FaultPointExpirationCfg{
 			Expiration: LocalExpirationConfig{
@@ -697,6 +697,192 @@ func TestRemoteAppConfig_ToAppConfig(t *testing.T) {
 			So(local.FaultPoint.Expiration.ExpirationTime, ShouldEqual, 1*time.Hour)
 			So(local.Problem.Expiration.ExpirationTime, ShouldEqual, 1*time.Hour)
 		})
+
+		Convey("未配置数据源时默认使用 zabbix_webhook", func() {
+			remote := &RemoteAppConfig{}
+
+			local := remote.ToAppConfig()
+
+			So(local.Ingest.Source.Type, ShouldEqual, "zabbix_webhook")
+			So(local.Ingest.Source.Sources, ShouldBeEmpty)
+		})
+
+		Convey("配置多个数据源时进入多数据源模式", func() {
+			remote := &RemoteAppConfig{
+				Ingest: RemoteIngestConfig{
+					DefaultSource: "prometheus_alertmanager",
+					Sources:       []string{"zabbix_webhook", "prometheus_alertmanager"},
+				},
+			}
+
+			local := remote.ToAppConfig()
+
+			So(local.Ingest.Source.Type, ShouldEqual, "prometheus_alertmanager")
+			So(local.Ingest.Source.Sources, ShouldResemble, []string{"zabbix_webhook", "prometheus_alertmanager"})
+		})
+
+		Convey("仅配置一个数据源时为单数据源模式", func() {
+			remote := &RemoteAppConfig{
+				Ingest: RemoteIngestConfig{
+					Sources: []string{"prometheus_alertmanager"},
+				},
+			}
+
+			local := remote.ToAppConfig()
+
+			So(local.Ingest.Source.Type, ShouldEqual, "prometheus_alertmanager")
+			So(local.Ingest.Source.Sources, ShouldBeEmpty)
+		})
+
+		Convey("转换未解析实体兜底配置", func() {
+			remote := &RemoteAppConfig{
+				Ingest: RemoteIngestConfig{
+					UnresolvedEntity: UnresolvedEntityConfig{Enabled: true},
+				},
+			}
+
+			local := remote.ToAppConfig()
+
+			So(local.Ingest.UnresolvedEntity.Enabled, ShouldBeTrue)
+		})
+
+		Convey("转换去重与抖动抑制配置", func() {
+			remote := &RemoteAppConfig{
+				Ingest: RemoteIngestConfig{
+					Dedup:    RemoteDedupConfig{Enabled: true, WindowSeconds: 300},
+					Flapping: RemoteFlappingConfig{Enabled: true, Threshold: 5, WindowMinutes: 20},
+				},
+			}
+
+			local := remote.ToAppConfig()
+
+			So(local.Ingest.Dedup.Enabled, ShouldBeTrue)
+			So(local.Ingest.Dedup.Window, ShouldEqual, 5*time.Minute)
+			So(local.Ingest.Flapping.Enabled, ShouldBeTrue)
+			So(local.Ingest.Flapping.Threshold, ShouldEqual, 5)
+			So(local.Ingest.Flapping.Window, ShouldEqual, 20*time.Minute)
+		})
+
+		Convey("转换维护窗口，忽略时间格式非法的窗口", func() {
+			remote := &RemoteAppConfig{
+				MaintenanceWindows: []RemoteMaintenanceWindow{
+					{
+						ID:        "mw-1",
+						Name:      "周末变更",
+						Enabled:   true,
+						Selector:  MaintenanceSelector{ObjectClasses: []string{"host"}},
+						StartTime: "2024-01-06T22:00:00+08:00",
+						EndTime:   "2024-01-07T02:00:00+08:00",
+						Recurrence: RemoteMaintenanceRecurrence{
+							Type:     RecurrenceWeekly,
+							Weekdays: []int{0, 6},
+							Until:    "2024-06-30T00:00:00+08:00",
+						},
+					},
+					{ID: "mw-2", StartTime: "invalid", EndTime: "2024-01-07T02:00:00+08:00"},
+					{
+						ID:         "mw-3",
+						StartTime:  "2024-01-06T22:00:00+08:00",
+						EndTime:    "2024-01-07T02:00:00+08:00",
+						Recurrence: RemoteMaintenanceRecurrence{Type: RecurrenceDaily, Until: "invalid"},
+					},
+				},
+			}
+
+			local := remote.ToAppConfig()
+
+			So(local.MaintenanceWindows, ShouldHaveLength, 1)
+			w := local.MaintenanceWindows[0]
+			So(w.ID, ShouldEqual, "mw-1")
+			So(w.End.Sub(w.Start), ShouldEqual, 4*time.Hour)
+			So(w.Recurrence.Weekdays, ShouldResemble, []time.Weekday{time.Sunday, time.Saturday})
+			So(w.Recurrence.Until, ShouldNotBeNil)
+		})
+
+		Convey("转换故障等级回落配置", func() {
+			remote := &RemoteAppConfig{
+				FaultPointPolicy: RemotePolicyConfig{
+					Deescalation: RemoteDeescalationConfig{Enabled: true, WindowMinutes: 15},
+				},
+			}
+
+			local := remote.ToAppConfig()
+
+			So(local.FaultPoint.Deescalation.Enabled, ShouldBeTrue)
+			So(local.FaultPoint.Deescalation.Window, ShouldEqual, 15*time.Minute)
+		})
+
+		Convey("转换空间相关性配置", func() {
+			remote := &RemoteAppConfig{
+				ProblemPolicy: RemotePolicyConfig{
+					Spatial: SpatialCorrelationConfig{
+						Rules: []SpatialRule{{ObjectClass: "pod", MaxHops: 3, Relations: []SpatialRelation{{Type: "runs_on", Direction: RelationForward}}}},
+					},
+				},
+			}
+
+			local := remote.ToAppConfig()
+
+			So(local.Problem.Spatial.Rules, ShouldHaveLength, 1)
+			So(local.Problem.Spatial.Rules[0].MaxHops, ShouldEqual, 3)
+			So(local.Problem.Spatial.Rules[0].Relations[0].Direction, ShouldEqual, RelationForward)
+		})
+
+		Convey("转换问题合并策略组合", func() {
+			remote := &RemoteAppConfig{
+				ProblemPolicy: RemotePolicyConfig{
+					Correlation: RemoteCorrelationConfig{
+						Strategies: []string{CorrelationSpatial, CorrelationTemporal},
+						Combine:    CorrelationCombineAll,
+						Temporal: RemoteTemporalCorrelationConfig{
+							WindowSeconds: 90,
+							MinScore:      0.4,
+							Groups:        []ObjectGroup{{Name: "db", ObjectClasses: []string{"mysql"}}},
+						},
+						Semantic: SemanticCorrelationConfig{MinScore: 0.7},
+					},
+				},
+			}
+
+			local := remote.ToAppConfig()
+
+			So(local.Problem.Correlation.Strategies, ShouldResemble, []string{CorrelationSpatial, CorrelationTemporal})
+			So(local.Problem.Correlation.Combine, ShouldEqual, CorrelationCombineAll)
+			So(local.Problem.Correlation.Temporal.Window, ShouldEqual, 90*time.Second)
+			So(local.Problem.Correlation.Temporal.MinScore, ShouldEqual, 0.4)
+			So(local.Problem.Correlation.Temporal.Groups[0].ObjectClasses, ShouldResemble, []string{"mysql"})
+			So(local.Problem.Correlation.Semantic.MinScore, ShouldEqual, 0.7)
+		})
+
+		Convey("转换问题重开配置", func() {
+			remote := &RemoteAppConfig{
+				ProblemPolicy: RemotePolicyConfig{
+					Reopen: RemoteReopenConfig{Enabled: true, WindowMinutes: 45, LookbackHours: 24},
+				},
+			}
+
+			local := remote.ToAppConfig()
+
+			So(local.Problem.Reopen.Enabled, ShouldBeTrue)
+			So(local.Problem.Reopen.Window, ShouldEqual, 45*time.Minute)
+			So(local.Problem.Reopen.Lookback, ShouldEqual, 24*time.Hour)
+		})
+
+		Convey("转换故障模式目录", func() {
+			remote := &RemoteAppConfig{
+				FailureModes: []FailureModeEntry{
+					{Mode: "disk_full", Name: "磁盘满", Rules: []FailureModeRule{
+						{ObjectClass: "host", Field: FailureModeFieldItemKey, Match: FailureModeMatchGlob, Pattern: "vfs.fs.size[*,pused]"},
+					}},
+				},
+			}
+
+			local := remote.ToAppConfig()
+
+			So(local.FailureModes, ShouldHaveLength, 1)
+			So(local.FailureModes[0].Mode, ShouldEqual, "disk_full")
+			So(local.FailureModes[0].Rules[0].Pattern, ShouldEqual, "vfs.fs.size[*,pused]")
+		})
 	})
 }
 
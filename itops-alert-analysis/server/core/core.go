@@ -2,30 +2,57 @@ package core
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
 )
 
+// HeaderEventSource Kafka 消息头：显式指定原始事件的数据源（如 zabbix_webhook）。
+const HeaderEventSource = "event_source"
+
+// ErrUncommittable 消息处理失败且未能转入死信。handler 或通道任务返回该错误时，
+// 消费者不提交该消息的 offset 并停止消费，重启后从该消息重新拉取，避免消息丢失。
+var ErrUncommittable = errors.New("消息未能转入死信，不提交 offset")
+
 // KafkaProducer 生产 Kafka 消息。
 type KafkaProducer interface {
 	PublishRawEvent(ctx context.Context, key string, value []byte) error
+	PublishRawEventWithHeaders(ctx context.Context, key string, value []byte, headers map[string]string) error
 	Close() error
 }
 
 // KafkaConsumer 顺序消费 topic itops_alert_raw_event。
+// ConsumeParallel 将消息拆分为带通道键的任务并行处理，同一键的任务保持顺序。
 type KafkaConsumer interface {
 	ConsumeRawEvents(ctx context.Context, handler func(ctx context.Context, msg KafkaMessage) error) error
+	ConsumeParallel(ctx context.Context, opts ParallelConsumeOptions, dispatch func(ctx context.Context, msg KafkaMessage) []LaneTask) error
+	Stats() domain.ConsumerStats
 	Close() error
 }
 
+// ParallelConsumeOptions 并行消费参数。
+type ParallelConsumeOptions struct {
+	Workers        int           // 通道数，每个通道一个处理协程
+	LaneCapacity   int           // 每个通道缓冲的任务数，通道满时暂停拉取
+	CommitInterval time.Duration // offset 批量提交间隔
+}
+
+// LaneTask 并行消费的处理单元，Key 相同的任务哈希到同一通道，按消息拉取顺序执行。
+type LaneTask struct {
+	Key string
+	Run func(ctx context.Context) error
+}
+
 // KafkaMessage 表示消费到的 Kafka 消息。
 type KafkaMessage struct {
 	Key       string
+	Topic     string
 	Value     []byte
 	Partition int32
 	Offset    int64
 	Timestamp time.Time
+	Headers   map[string]string
 }
 
 // RawEventRepository 管理 itops_raw_event 索引。
@@ -33,21 +60,25 @@ type RawEventRepository interface {
 	Upsert(ctx context.Context, event domain.RawEvent) error
 	UpdateFaultID(ctx context.Context, eventIDs []uint64, faultID uint64) error
 	UpdateProblemID(ctx context.Context, eventIDs []uint64, problemID uint64) error
+	UpdateEntity(ctx context.Context, eventIDs []uint64, objectClass, objectID, objectName string) error
 	QueryByIDs(ctx context.Context, ids []uint64) ([]domain.RawEvent, error)
 	QueryByProviderID(ctx context.Context, providerIDs []string) ([]domain.RawEvent, error)
 }
 
 // FaultPointRepository 管理 itops_fault_point 索引。
 type FaultPointRepository interface {
-	FindOpenByEntityAndMode(ctx context.Context, entityObjectID, failureMode string, t time.Time) (*domain.FaultPointObject, error)
+	FindOpenByEntityAndKey(ctx context.Context, entityObjectID, faultKey string, t time.Time) (*domain.FaultPointObject, error)
 	Upsert(ctx context.Context, fp domain.FaultPointObject) error
 	MakeExpired(ctx context.Context, faultID uint64) error
 	UpdateProblemID(ctx context.Context, faultIDs []uint64, problemID uint64) error
 	MakeRecovered(ctx context.Context, faultID uint64, recoveryTime time.Time) error
 	QueryByIDs(ctx context.Context, ids []uint64) ([]domain.FaultPointObject, error)
-	FindInWindow(ctx context.Context, entityID string, faultMode string, start, end time.Time) ([]domain.FaultPointObject, error)
+	FindInWindow(ctx context.Context, entityID string, faultKey string, start, end time.Time) ([]domain.FaultPointObject, error)
 	FindByEventID(ctx context.Context, eventID uint64) (*domain.FaultPointObject, error)
 	FindExpiredOccurred(ctx context.Context, expirationTime time.Time) ([]domain.FaultPointObject, error)
+	FindByEntityClass(ctx context.Context, entityObjectClass string, afterFaultID uint64) ([]domain.FaultPointObject, error)
+	FindLatestByEntityAndKey(ctx context.Context, entityObjectID, faultKey string, t time.Time) (*domain.FaultPointObject, error)
+	FindFlappingQuiet(ctx context.Context, quietSince time.Time) ([]domain.FaultPointObject, error)
 }
 
 // FaultPointRelationRepository 管理 itops_fault_point_relation 索引。
@@ -60,14 +91,19 @@ type ProblemRepository interface {
 	FindCorrelated(ctx context.Context, fp domain.FaultPointObject, t time.Time) ([]domain.Problem, error)
 	FindPendingRCA(ctx context.Context, maxAge time.Duration) ([]domain.Problem, error)
 	FindExpiredOpen(ctx context.Context, expirationTime time.Time) ([]domain.Problem, error)
+	FindRecentClosed(ctx context.Context, fingerprints []string, since time.Time) ([]domain.Problem, error)
 	Upsert(ctx context.Context, p domain.Problem) error
 	UpdateRootCause(ctx context.Context, problemID uint64, cb domain.RCACallback) error
 	UpdateRootCauseObjectID(ctx context.Context, problemID uint64, objectID string, faultID uint64) error
 	UpdateRelationEventIDs(ctx context.Context, problemID uint64, eventIDs []uint64) error
+	UpdateLevel(ctx context.Context, problemID uint64, level domain.Severity) error
+	UpdateRCAStatus(ctx context.Context, problemID uint64, status domain.RcaStatus) error
+	UpdateRCAProgress(ctx context.Context, problemID uint64, progress domain.RCAProgress) error
 	MarkClosed(ctx context.Context, problemID uint64, closeType domain.ProblemCloseType, closeStatus domain.ProblemStatus, duration uint64, notes string, by string) error
 	MarkExpired(ctx context.Context, problemID uint64) error
 	QueryByIDs(ctx context.Context, ids []uint64) ([]domain.Problem, error)
-	ClearMergedProblemData(ctx context.Context, problemID uint64) error // 清空被合并问题的关联数据
+	CloseMerged(ctx context.Context, p domain.Problem, closeType domain.ProblemCloseType, notes string, by string) error // 按版本清空被合并问题的关联数据并关闭
+	Delete(ctx context.Context, problemID uint64) error
 }
 
 // FaultCausalRepository 管理 itops_fault_causal 索引。
@@ -85,6 +121,51 @@ type FaultCausalRelationRepository interface {
 	QueryByEntityPair(ctx context.Context, sourceID, targetID string) ([]domain.FaultCausalRelation, error)
 }
 
+// DeadLetterRepository 管理 itops_dead_letter 索引。
+type DeadLetterRepository interface {
+	Upsert(ctx context.Context, dl domain.DeadLetter) error
+	QueryByIDs(ctx context.Context, ids []uint64) ([]domain.DeadLetter, error)
+	List(ctx context.Context, q domain.DeadLetterQuery) ([]domain.DeadLetter, error)
+}
+
+// CorrelationRecordRepository 管理 itops_correlation_record 索引。
+type CorrelationRecordRepository interface {
+	Upsert(ctx context.Context, record domain.CorrelationRecord) error
+	ListByProblemIDs(ctx context.Context, problemIDs []uint64) ([]domain.CorrelationRecord, error)
+}
+
+// RCAJobRepository 管理 itops_rca_job 索引。
+type RCAJobRepository interface {
+	Upsert(ctx context.Context, job domain.RCAJob) error
+	List(ctx context.Context, q domain.RCAJobQuery) ([]domain.RCAJob, error)
+	FindActive(ctx context.Context) ([]domain.RCAJob, error)
+}
+
+// RCARunner 按需重新分析问题根因。
+type RCARunner interface {
+	RerunRCA(ctx context.Context, problemID uint64, by string) (domain.RCAJob, error)
+}
+
+// DeadLetterReplayer 将死信重新投递到 ingest 处理流程。
+type DeadLetterReplayer interface {
+	ReplayDeadLetter(ctx context.Context, dl domain.DeadLetter) error
+}
+
+// ObjectClassStatsProvider 提供对象类缓存运行指标。
+type ObjectClassStatsProvider interface {
+	ObjectClassStats() domain.ObjectClassCacheStats
+}
+
+// IngestStatsProvider 提供原始事件消费运行指标。
+type IngestStatsProvider interface {
+	IngestStats() domain.ConsumerStats
+}
+
+// FailureModeClassifier 按故障模式目录归一事件的故障模式。
+type FailureModeClassifier interface {
+	ClassifyFailureMode(event domain.RawEvent) (domain.FailureModeMatch, bool)
+}
+
 // FaultPointHandler 是 ingest 的下游处理器。
 type FaultPointHandler interface {
 	HandleEvent(ctx context.Context, event domain.RawEvent) error
@@ -97,6 +178,13 @@ type ProblemHandler interface {
 	HandleRCACallback(ctx context.Context, cb domain.RCACallback) error
 	CloseProblem(ctx context.Context, problemID uint64, closeType domain.ProblemCloseType, closeState domain.ProblemStatus, notes string, by string) error
 	HandleFaultPointRecovered(ctx context.Context, faultID uint64) error
+	HandleFaultPointLevelChanged(ctx context.Context, fp domain.FaultPointObject) error
+}
+
+// ProblemEditor 手动调整问题：拆分故障点到新问题、合并两个问题。
+type ProblemEditor interface {
+	SplitProblem(ctx context.Context, problemID uint64, faultIDs []uint64, by string) (*domain.Problem, error)
+	MergeProblems(ctx context.Context, targetID, sourceID uint64, by string) (*domain.Problem, error)
 }
 
 // RCAClient 异步调用 RCA 模块。
@@ -111,4 +199,5 @@ type RepositoryFactory interface {
 	Problem() ProblemRepository
 	FaultCausal() FaultCausalRepository
 	FaultCausalRelation() FaultCausalRelationRepository
+	DeadLetter() DeadLetterRepository
 }
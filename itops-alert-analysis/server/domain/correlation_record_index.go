@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// CorrelationRecordType 关联记录类型。
+type CorrelationRecordType string
+
+const (
+	CorrelationRecordCreated      CorrelationRecordType = "created"       // 故障点未命中任何问题，创建新问题
+	CorrelationRecordFaultPoint   CorrelationRecordType = "fault_point"   // 故障点关联到已有问题
+	CorrelationRecordProblemMerge CorrelationRecordType = "problem_merge" // 问题合并到主问题（自动或手动）
+	CorrelationRecordSplit        CorrelationRecordType = "split"         // 故障点被手动拆分到新问题
+	CorrelationRecordReopen       CorrelationRecordType = "reopen"        // 相同指纹的故障点在重开窗口内再次发生，重开已关闭的问题
+	CorrelationRecordRecurrence   CorrelationRecordType = "recurrence"    // 新问题是已关闭问题的复发
+	CorrelationRecordRelink       CorrelationRecordType = "relink"        // 占位对象故障点重新关联后并入真实对象上的故障点，移出原问题
+)
+
+// 非相关性策略产生的记录使用的策略名
+const (
+	CorrelationStrategyManual      = "manual"      // 手动拆分、合并
+	CorrelationStrategyFingerprint = "fingerprint" // 按对象 + 故障模式指纹重开或关联复发
+	CorrelationStrategyFaultKey    = "fault_key"   // 按收敛键合并故障点
+)
+
+// CorrelationRecord 对应索引 itops_correlation_record。
+// 记录故障点进入问题、问题合并的原因，用于解释“告警为什么在这个问题里”。
+type CorrelationRecord struct {
+	RecordID        uint64                `json:"record_id"`
+	RecordType      CorrelationRecordType `json:"record_type"`
+	ProblemID       uint64                `json:"problem_id"`                  // 关联到的问题（合并时为主问题）
+	FaultID         uint64                `json:"fault_id"`                    // 触发关联的故障点
+	SourceProblemID uint64                `json:"source_problem_id,omitempty"` // 被合并的问题（problem_merge）、被拆分的问题（split）或故障点移出的问题（relink）
+	Strategy        string                `json:"strategy,omitempty"`          // 命中策略中得分最高的策略
+	Score           float64               `json:"score"`                       // 命中策略的得分
+	EntityPath      []string              `json:"entity_path,omitempty"`       // 空间策略命中的对象路径
+	Combine         string                `json:"combine,omitempty"`           // 策略组合方式
+	Scores          []CorrelationScore    `json:"scores,omitempty"`            // 全部策略的得分
+	OperatedBy      string                `json:"operated_by,omitempty"`       // 手动操作人
+	CreateTime      time.Time             `json:"create_time"`
+}
@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// DeadLetterStage 死信产生的处理阶段。
+type DeadLetterStage string
+
+const (
+	DeadLetterStageStandardize DeadLetterStage = "standardize" // 标准化失败（解析失败、对象不存在等），重放时重新标准化原始 payload
+	DeadLetterStageProcess     DeadLetterStage = "process"     // 标准化成功但入库/下发失败，重放时直接处理已标准化的事件
+)
+
+// DeadLetterStatus 死信状态。
+type DeadLetterStatus string
+
+const (
+	DeadLetterStatusPending  DeadLetterStatus = "pending"  // 待处理
+	DeadLetterStatusReplayed DeadLetterStatus = "replayed" // 已重放成功
+)
+
+// DeadLetter 对应索引 itops_dead_letter。
+// 记录处理失败的原始消息，便于在知识网络修复后重放。
+type DeadLetter struct {
+	DeadLetterID   uint64            `json:"dead_letter_id"`
+	Stage          DeadLetterStage   `json:"stage"`
+	Reason         string            `json:"reason"`
+	Status         DeadLetterStatus  `json:"status"`
+	EventSource    string            `json:"event_source"` // 显式指定的数据源（Kafka header），为空表示由标准化器识别
+	Payload        string            `json:"payload"`      // 原始消息体
+	Headers        map[string]string `json:"headers,omitempty"`
+	RawEvent       *RawEvent         `json:"raw_event,omitempty"` // process 阶段失败时已标准化的事件
+	Topic          string            `json:"topic"`
+	Partition      int32             `json:"partition"`
+	Offset         int64             `json:"offset"`
+	FailedTime     time.Time         `json:"failed_time"`
+	ReplayCount    int               `json:"replay_count"`
+	LastReplayTime *time.Time        `json:"last_replay_time,omitempty"`
+	LastReplayErr  string            `json:"last_replay_error,omitempty"`
+}
+
+// DeadLetterQuery 死信列表查询条件。
+type DeadLetterQuery struct {
+	Stage       DeadLetterStage
+	Status      DeadLetterStatus
+	EventSource string
+	StartTime   *time.Time
+	EndTime     *time.Time
+	From        int
+	Size        int
+}
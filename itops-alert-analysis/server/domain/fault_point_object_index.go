@@ -20,6 +20,14 @@ const (
 	SeverityNormal                        // 正常
 )
 
+// SeverityChange 故障点等级变化记录。
+type SeverityChange struct {
+	Level         Severity  `json:"level"`
+	PreviousLevel Severity  `json:"previous_level,omitempty"` // 首条记录为 0
+	Timestamp     time.Time `json:"timestamp"`
+	EventID       uint64    `json:"event_id"` // 触发变化的事件
+}
+
 // FaultPointObject 对应索引 itops_fault_point。
 // problem_id 冗余便于 Problem 直接定位。
 type FaultPointObject struct {
@@ -37,7 +45,17 @@ type FaultPointObject struct {
 	EntityObjectID    string      `json:"entity_object_id"`
 	RelationEventIDs  []uint64    `json:"relation_event_ids"`
 	FaultMode         string      `json:"fault_mode"`
+	FaultKey          string      `json:"fault_key"` // 收敛键，由收敛策略根据事件生成，同一实体下收敛键相同的事件合并
 	FaultLevel        Severity    `json:"fault_level"`
-	FaultDescription  string      `json:"fault_description"`
-	ProblemID         uint64      `json:"problem_id"`
+	// FaultLevelTimeline 故障等级变化时间线，首条为创建时的等级
+	FaultLevelTimeline []SeverityChange `json:"fault_level_timeline,omitempty"`
+	// FaultLevelSeenTime 最近一次收到当前等级（或更高等级）事件的时间，用于判断等级能否回落。
+	// 升级前创建的故障点没有该字段，读取为零值，合并事件时以更新时间补齐
+	FaultLevelSeenTime time.Time `json:"fault_level_seen_time"`
+	FaultDescription   string    `json:"fault_description"`
+	ProblemID          uint64    `json:"problem_id"`
+	// 抖动期间故障点保持发生状态，FaultFlappingStatus 记录最后一次事件状态，抖动停止后据此收敛
+	FaultFlapping       bool        `json:"fault_flapping"`
+	FaultFlappingStatus EventStatus `json:"fault_flapping_status,omitempty"`
+	DocVersion
 }
@@ -1,9 +1,31 @@
 package domain
 
 import (
+	"errors"
 	"time"
 )
 
+// ErrVersionConflict 文档读取后已被其他写入修改，调用方应重新读取后重试。
+var ErrVersionConflict = errors.New("文档版本冲突")
+
+// DocVersion OpenSearch 文档版本（_seq_no、_primary_term）。
+// 存储层读取文档时填充，写入时据此做乐观并发控制；不写入文档本身。
+type DocVersion struct {
+	SeqNo       int64 `json:"-"`
+	PrimaryTerm int64 `json:"-"`
+}
+
+// SetVersion 记录读取到的文档版本。
+func (v *DocVersion) SetVersion(seqNo, primaryTerm int64) {
+	v.SeqNo = seqNo
+	v.PrimaryTerm = primaryTerm
+}
+
+// Versioned 是否携带读取时的文档版本，新建的文档没有版本。
+func (v DocVersion) Versioned() bool {
+	return v.PrimaryTerm > 0
+}
+
 // RCARequest 异步发送给 RCA 模块。
 type RCARequest struct {
 	ProblemID uint64 `json:"problem_id"` // 问题ID
@@ -11,16 +33,50 @@ type RCARequest struct {
 
 // RCACallback 携带 RCA 结果回传 Problem 模块。
 type RCACallback struct {
-	ProblemID          uint64    `json:"problem_id"`                     // 问题ID
-	RootCauseObjectID  string    `json:"root_cause_object_id,omitempty"` // 根因对象ID，可为空
-	RootCauseFaultID   uint64    `json:"root_cause_fault_id,omitempty"`  // 根因故障点ID，可为空
-	RcaResults         string    `json:"rca_results"`                    // 分析结果详情（原 RCAResults）
-	RcaStartTime       time.Time `json:"rca_start_time"`                 // 分析开始时间
-	RcaEndTime         time.Time `json:"rca_end_time"`                   // 分析结束时间
-	RcaStatus          RcaStatus `json:"rca_status"`                     // 分析状态
-	InProgress         bool      `json:"in_progress"`                    // 是否正在进行中
-	ProblemName        string    `json:"problem_name"`                   // 问题名称
-	ProblemDescription string    `json:"problem_description"`            // 问题详细描述
+	ProblemID          uint64       `json:"problem_id"`                     // 问题ID
+	RootCauseObjectID  string       `json:"root_cause_object_id,omitempty"` // 根因对象ID，可为空
+	RootCauseFaultID   uint64       `json:"root_cause_fault_id,omitempty"`  // 根因故障点ID，可为空
+	RcaResults         string       `json:"rca_results"`                    // 分析结果详情（原 RCAResults）
+	RcaStartTime       time.Time    `json:"rca_start_time"`                 // 分析开始时间
+	RcaEndTime         time.Time    `json:"rca_end_time"`                   // 分析结束时间
+	RcaStatus          RcaStatus    `json:"rca_status"`                     // 分析状态
+	InProgress         bool         `json:"in_progress"`                    // 是否正在进行中
+	Progress           *RCAProgress `json:"rca_progress,omitempty"`         // 分析进度，进行中与完成时携带
+	ProblemName        string       `json:"problem_name"`                   // 问题名称
+	ProblemDescription string       `json:"problem_description"`            // 问题详细描述
+}
+
+// ObjectClassCacheStats 对象类缓存运行指标，为当前实例的进程内统计，重启后清零。
+// 缓存规模为本实例刷新时维护的对象与缓存键数量，redis / tiered 模式下不代表 Redis 中的实际键数。
+type ObjectClassCacheStats struct {
+	ObjectTypes             int       `json:"object_types"`              // 已缓存的对象类数量
+	Objects                 int       `json:"objects"`                   // 已缓存的对象数量
+	CacheKeys               int       `json:"cache_keys"`                // 已写入的缓存键数量（组合键 + 识别键）
+	Hits                    int64     `json:"hits"`                      // 实体查询命中次数
+	Misses                  int64     `json:"misses"`                    // 实体查询未命中次数
+	HitRate                 float64   `json:"hit_rate"`                  // 命中率
+	FullRefreshCount        int64     `json:"full_refresh_count"`        // 全量刷新次数
+	IncrementalRefreshCount int64     `json:"incremental_refresh_count"` // 增量刷新次数
+	RefreshFailureCount     int64     `json:"refresh_failure_count"`     // 刷新失败次数
+	LastRefreshMode         string    `json:"last_refresh_mode"`         // 上次刷新方式：full / incremental
+	LastRefreshTime         time.Time `json:"last_refresh_time"`         // 上次刷新完成时间
+	LastRefreshDurationMs   int64     `json:"last_refresh_duration_ms"`  // 上次刷新耗时
+	LastRefreshUpdated      int       `json:"last_refresh_updated"`      // 上次刷新写入的对象数量
+	LastRefreshDeleted      int       `json:"last_refresh_deleted"`      // 上次刷新删除的对象数量
+	LastRefreshError        string    `json:"last_refresh_error,omitempty"`
+}
+
+// ConsumerStats Kafka 消费运行指标。
+type ConsumerStats struct {
+	Mode           string    `json:"mode"`             // sequential / parallel
+	Workers        int       `json:"workers"`          // 并行通道数，顺序消费时为 1
+	Lag            int64     `json:"lag"`              // 消费组积压消息数
+	Pending        int       `json:"pending"`          // 已拉取、尚未提交 offset 的消息数
+	LaneDepths     []int     `json:"lane_depths"`      // 各通道排队中的任务数
+	Processed      int64     `json:"processed"`        // 已处理的任务数
+	Failed         int64     `json:"failed"`           // 处理失败的任务数
+	Commits        int64     `json:"commits"`          // offset 提交次数
+	LastCommitTime time.Time `json:"last_commit_time"` // 上次提交 offset 的时间
 }
 
 // ProblemCreatedEvent 问题创建事件
@@ -29,7 +85,9 @@ type ProblemCreatedEvent struct {
 }
 
 const (
-	SourceZabbixWebhook = "zabbix_webhook"
+	SourceZabbixWebhook          = "zabbix_webhook"
+	SourcePrometheusAlertmanager = "prometheus_alertmanager"
+	SourceJSONMapping            = "json_mapping"
 )
 
 // RcaResults 分析结果详情
@@ -258,6 +316,12 @@ type AgentCausalPayload struct {
 	FaultCausal AgentCausalEdge `json:"fault_causal"` // 因果关系对象
 }
 
+// AgentCausalBatchPayload 整问题因果推理 Agent 返回的负载（内部使用）
+// 一次返回故障点时间线中全部的因果关系，没有因果关系时为空数组
+type AgentCausalBatchPayload struct {
+	FaultCausals []AgentCausalEdge `json:"fault_causals"` // 因果关系列表
+}
+
 // AgentCausalEdge Agent 返回的因果边
 // 表示 AI Agent 分析得出的两个故障点之间的因果关系
 type AgentCausalEdge struct {
@@ -321,3 +385,34 @@ type GraphRecallContext struct {
 	// 用于构建最终的分析结果
 	AnalysisNetwork []*RcaNetwork `json:"analysis_network"` // 完整的分析网络，包含所有对象节点、故障点节点及其关系
 }
+
+// FailureModeMatch 故障模式归一结果。
+type FailureModeMatch struct {
+	Mode        string `json:"mode"`         // 故障模式编码
+	Name        string `json:"name"`         // 故障模式名称
+	ObjectClass string `json:"object_class"` // 命中规则的对象类，为空表示不限对象类
+	Field       string `json:"field"`        // 命中规则的匹配字段：item_key/title
+	Match       string `json:"match"`        // 命中规则的匹配方式：glob/regex
+	Pattern     string `json:"pattern"`      // 命中规则的匹配模式
+}
+
+// CorrelationScore 单个相关性策略对候选问题的评分。
+type CorrelationScore struct {
+	Strategy  string  `json:"strategy"`         // 策略：spatial/temporal/semantic
+	Score     float64 `json:"score"`            // 得分，0-1
+	Threshold float64 `json:"threshold"`        // 命中所需的最低得分
+	Matched   bool    `json:"matched"`          // 是否命中
+	Detail    string  `json:"detail,omitempty"` // 命中依据，如匹配对象、时间差
+	// EntityPath 空间策略命中的对象路径（s_id），首个元素为故障点对象
+	EntityPath []string `json:"entity_path,omitempty"`
+}
+
+// CorrelationDecision 故障点与候选问题的合并决策。
+type CorrelationDecision struct {
+	FaultID      uint64             `json:"fault_id"`
+	ProblemID    uint64             `json:"problem_id"`
+	Combine      string             `json:"combine"` // 策略组合方式：any/all
+	Merged       bool               `json:"merged"`  // 是否合并到该问题
+	Scores       []CorrelationScore `json:"scores"`
+	DecisionTime time.Time          `json:"decision_time"`
+}
@@ -1,6 +1,16 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// 问题手动操作（拆分、合并）的校验错误，API 据此返回 4xx
+var (
+	ErrProblemNotFound  = errors.New("问题不存在")
+	ErrProblemNotOpen   = errors.New("问题未处于打开状态")
+	ErrInvalidOperation = errors.New("问题操作参数不合法")
+)
 
 type ProblemStatus string
 
@@ -19,6 +29,14 @@ const (
 	//ProblemCloseTypeUnknown ProblemCloseType = "unknown"
 )
 
+// ProblemEventType 问题事件类型，随问题事件发布到 Kafka
+type ProblemEventType string
+
+const (
+	ProblemEventUpserted     ProblemEventType = "upserted"      // 问题创建或合并了故障点，需要 RCA
+	ProblemEventLevelChanged ProblemEventType = "level_changed" // 问题等级升级或回落，故障点集合未变化
+)
+
 // RCAStatus RCA 分析状态枚举
 type RcaStatus int
 
@@ -30,6 +48,30 @@ const (
 	RcaStatusCancelled                      // 已取消：分析被取消
 )
 
+// RCAStep RCA 分析步骤，按执行顺序排列。
+type RCAStep string
+
+const (
+	RCAStepFaultPointsLoaded RCAStep = "fault_points_loaded" // 故障点已加载
+	RCAStepGraphRecalled     RCAStep = "graph_recalled"      // 拓扑与历史因果已召回
+	RCAStepPairsAnalyzing    RCAStep = "pairs_analyzing"     // 正在分析故障点对的因果关系
+	RCAStepRootCauseChosen   RCAStep = "root_cause_chosen"   // 根因已确定
+	RCAStepSummaryGenerated  RCAStep = "summary_generated"   // 分析摘要已生成，分析完成
+)
+
+// RCAProgress RCA 分析进度，分析过程中随回调写入问题，用于展示进度与阶段性结果。
+type RCAProgress struct {
+	Step              RCAStep   `json:"step"`
+	Percent           int       `json:"percent"`                        // 总体进度 0-100
+	FaultPointCount   int       `json:"fault_point_count"`              // 参与分析的故障点数
+	TotalPairs        int       `json:"total_pairs"`                    // 需要分析的故障点对数
+	AnalyzedPairs     int       `json:"analyzed_pairs"`                 // 已分析的故障点对数
+	CausalRelations   int       `json:"causal_relations"`               // 已发现的因果关系数
+	RootCauseObjectID string    `json:"root_cause_object_id,omitempty"` // 已确定的根因对象
+	RootCauseFaultID  uint64    `json:"root_cause_fault_id,omitempty"`  // 已确定的根因故障点
+	UpdateTime        time.Time `json:"update_time"`
+}
+
 // Problem 对应索引 itops_problem。
 type Problem struct {
 	ProblemID              uint64            `json:"problem_id"`
@@ -55,4 +97,10 @@ type Problem struct {
 	RcaStartTime           time.Time         `json:"rca_start_time"`
 	RcaEndTime             time.Time         `json:"rca_end_time"`
 	RcaStatus              RcaStatus         `json:"rca_status"`
+	RcaProgress            *RCAProgress      `json:"rca_progress,omitempty"`
+	// FaultFingerprints 故障点指纹（对象 + 故障模式），用于判断已关闭问题的重开与复发
+	FaultFingerprints []string `json:"fault_fingerprints"`
+	RecurrenceOf      uint64   `json:"recurrence_of,omitempty"` // 复发自的上一个问题
+	RecurrenceCount   int      `json:"recurrence_count"`        // 重开与复发的累计次数
+	DocVersion
 }
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type EventStatus string
 
@@ -31,6 +34,33 @@ type RawEvent struct {
 	EntityObjectPort  string      `json:"entity_object_port"`
 	EntityObjectMAC   string      `json:"entity_object_mac"`
 	RawEventMsg       string      `json:"raw_event_msg"`
-	ProblemID         uint64      `json:"problem_id"`
-	FaultID           uint64      `json:"fault_id"`
+	// EventLabels 上游标签（如 Prometheus labels），供按标签子集收敛故障点
+	EventLabels   map[string]string `json:"event_labels,omitempty"`
+	EventFlapping bool              `json:"event_flapping"` // 入库时实体+事件类型处于抖动状态
+	// 维护窗口抑制：命中维护窗口的事件照常入库，但不参与故障点与问题收敛
+	EventSuppressed       bool   `json:"event_suppressed"`
+	EventSuppressedReason string `json:"event_suppressed_reason,omitempty"` // 如 "suppressed by maintenance X"
+	MaintenanceID         string `json:"maintenance_id,omitempty"`
+	MaintenanceName       string `json:"maintenance_name,omitempty"`
+	ProblemID             uint64 `json:"problem_id"`
+	FaultID               uint64 `json:"fault_id"`
+}
+
+// UnresolvedObjectClass 知识网络中尚未找到对应对象时使用的占位对象类。
+const UnresolvedObjectClass = "unresolved"
+
+// unresolvedObjectIDPrefix 占位对象 ID 前缀，后接实体查询键（主机名/IP）
+const unresolvedObjectIDPrefix = "unresolved:"
+
+// UnresolvedObjectID 根据实体查询键生成占位对象 ID，同一主机的事件收敛到同一占位对象。
+func UnresolvedObjectID(key string) string {
+	return unresolvedObjectIDPrefix + key
+}
+
+// UnresolvedEntityKey 从占位对象 ID 中取回实体查询键，非占位对象返回 false。
+func UnresolvedEntityKey(objectID string) (string, bool) {
+	if !strings.HasPrefix(objectID, unresolvedObjectIDPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(objectID, unresolvedObjectIDPrefix), true
 }
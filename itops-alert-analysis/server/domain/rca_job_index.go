@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRCAJobRunning 问题的 RCA 任务正在运行，不能重复触发。
+var ErrRCAJobRunning = errors.New("RCA 任务正在运行")
+
+// RCAJobStatus RCA 任务状态。
+type RCAJobStatus string
+
+const (
+	RCAJobStatusQueued    RCAJobStatus = "queued"    // 等待调度（含失败后等待重试）
+	RCAJobStatusRunning   RCAJobStatus = "running"   // 分析中
+	RCAJobStatusSucceeded RCAJobStatus = "succeeded" // 分析成功
+	RCAJobStatusFailed    RCAJobStatus = "failed"    // 重试次数用尽仍失败
+	RCAJobStatusCancelled RCAJobStatus = "cancelled" // 调度时判定无需分析（问题不存在、故障点与等级未变化）
+)
+
+// RCAJobTrigger RCA 任务的触发来源。
+type RCAJobTrigger string
+
+const (
+	RCAJobTriggerEvent    RCAJobTrigger = "event"    // 问题事件
+	RCAJobTriggerRecovery RCAJobTrigger = "recovery" // 启动时恢复未完成的分析
+	RCAJobTriggerManual   RCAJobTrigger = "manual"   // 手动重新分析
+)
+
+// RCAJob 对应索引 itops_rca_job。
+// 记录一次问题根因分析的运行状态，服务重启后据此恢复未完成的分析；失败重试复用同一任务。
+type RCAJob struct {
+	JobID       uint64        `json:"job_id"`
+	ProblemID   uint64        `json:"problem_id"`
+	Status      RCAJobStatus  `json:"status"`
+	Trigger     RCAJobTrigger `json:"trigger"`
+	OperatedBy  string        `json:"operated_by,omitempty"` // 手动触发人
+	Attempt     int           `json:"attempt"`               // 已开始的执行次数
+	MaxAttempts int           `json:"max_attempts"`
+	Error       string        `json:"error,omitempty"` // 最近一次失败或取消的原因
+	CreateTime  time.Time     `json:"create_time"`
+	UpdateTime  time.Time     `json:"update_time"`
+	StartTime   *time.Time    `json:"start_time,omitempty"`    // 最近一次开始执行时间
+	EndTime     *time.Time    `json:"end_time,omitempty"`      // 结束时间（成功、失败、取消）
+	NextRunTime *time.Time    `json:"next_run_time,omitempty"` // 失败后下次重试时间
+}
+
+// Active 任务尚未结束。
+func (j RCAJob) Active() bool {
+	return j.Status == RCAJobStatusQueued || j.Status == RCAJobStatusRunning
+}
+
+// RCAJobQuery RCA 任务列表查询条件。
+type RCAJobQuery struct {
+	ProblemID uint64
+	Status    RCAJobStatus
+	From      int
+	Size      int
+}
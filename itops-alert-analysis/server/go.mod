@@ -3,6 +3,7 @@ module devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis
 go 1.24
 
 require (
+	github.com/agiledragon/gomonkey/v2 v2.14.0
 	github.com/fsnotify/fsnotify v1.4.9
 	github.com/gin-gonic/gin v1.10.1
 	github.com/go-redis/redis/v8 v8.11.5
@@ -19,7 +20,6 @@ require (
 )
 
 require (
-	github.com/agiledragon/gomonkey/v2 v2.14.0 // indirect
 	github.com/bytedance/sonic v1.14.0 // indirect
 	github.com/bytedance/sonic/loader v0.3.0 // indirect
 	github.com/cespare/xxhash/v2 v2.1.2 // indirect
@@ -3,8 +3,13 @@ package cache
 import (
 	"context"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
+// ErrNotFound 缓存键不存在或已过期，可用 errors.Is 判断。
+var ErrNotFound = errors.New("key not found")
+
 // Cache 缓存接口,将来可以适配多种缓存
 type Cache interface {
 	// Get 获取缓存值
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultMemoryMaxEntries 本地缓存默认最大条目数
+const defaultMemoryMaxEntries = 100000
+
+// MemoryCache 进程内 LRU 缓存，支持按键过期。
+// 超过最大条目数时淘汰最久未访问的键，过期键在访问时惰性删除。
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List               // 访问顺序，队首为最近访问
+	items      map[string]*list.Element // key -> 链表节点
+}
+
+// memoryEntry 本地缓存条目
+type memoryEntry struct {
+	key      string
+	value    string
+	expireAt time.Time // 零值表示永不过期
+}
+
+// NewMemoryCache 创建本地缓存，maxEntries <= 0 时使用默认值。
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultMemoryMaxEntries
+	}
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get 获取缓存值。
+func (m *MemoryCache) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.lookup(key)
+	if !ok {
+		return "", errors.Wrap(ErrNotFound, key)
+	}
+	return entry.value, nil
+}
+
+// Set 设置缓存值，expiration 为 0 表示永不过期。
+func (m *MemoryCache) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	var expireAt time.Time
+	if expiration > 0 {
+		expireAt = time.Now().Add(expiration)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.items[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.value = value
+		entry.expireAt = expireAt
+		m.ll.MoveToFront(elem)
+		return nil
+	}
+
+	m.items[key] = m.ll.PushFront(&memoryEntry{key: key, value: value, expireAt: expireAt})
+	for m.ll.Len() > m.maxEntries {
+		m.removeElement(m.ll.Back())
+	}
+	return nil
+}
+
+// Del 删除缓存键。
+func (m *MemoryCache) Del(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := m.items[key]; ok {
+			m.removeElement(elem)
+		}
+	}
+	return nil
+}
+
+// Exists 检查键是否存在。
+func (m *MemoryCache) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.lookup(key)
+	return ok, nil
+}
+
+// Len 返回当前条目数（含尚未惰性删除的过期键）。
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ll.Len()
+}
+
+// Close 清空本地缓存。
+func (m *MemoryCache) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ll.Init()
+	m.items = make(map[string]*list.Element)
+	return nil
+}
+
+// lookup 查找未过期的条目并标记为最近访问，调用方需持有 m.mu。
+func (m *MemoryCache) lookup(key string) (*memoryEntry, bool) {
+	elem, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*memoryEntry)
+	if !entry.expireAt.IsZero() && time.Now().After(entry.expireAt) {
+		m.removeElement(elem)
+		return nil, false
+	}
+	m.ll.MoveToFront(elem)
+	return entry, true
+}
+
+func (m *MemoryCache) removeElement(elem *list.Element) {
+	m.ll.Remove(elem)
+	delete(m.items, elem.Value.(*memoryEntry).key)
+}
+
+var _ Cache = (*MemoryCache)(nil)
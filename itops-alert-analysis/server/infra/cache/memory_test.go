@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMemoryCache(t *testing.T) {
+	Convey("TestMemoryCache", t, func() {
+		ctx := context.Background()
+		c := NewMemoryCache(2)
+
+		Convey("Set 后可以 Get", func() {
+			So(c.Set(ctx, "k1", "v1", 0), ShouldBeNil)
+
+			value, err := c.Get(ctx, "k1")
+
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "v1")
+		})
+
+		Convey("键不存在返回 ErrNotFound", func() {
+			_, err := c.Get(ctx, "missing")
+
+			So(errors.Is(err, ErrNotFound), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "key not found")
+		})
+
+		Convey("过期键不可读取", func() {
+			So(c.Set(ctx, "k1", "v1", time.Millisecond), ShouldBeNil)
+			time.Sleep(5 * time.Millisecond)
+
+			_, err := c.Get(ctx, "k1")
+			exists, _ := c.Exists(ctx, "k1")
+
+			So(errors.Is(err, ErrNotFound), ShouldBeTrue)
+			So(exists, ShouldBeFalse)
+			So(c.Len(), ShouldEqual, 0)
+		})
+
+		Convey("超过容量时淘汰最久未访问的键", func() {
+			_ = c.Set(ctx, "k1", "v1", 0)
+			_ = c.Set(ctx, "k2", "v2", 0)
+			_, _ = c.Get(ctx, "k1") // k1 变为最近访问
+			_ = c.Set(ctx, "k3", "v3", 0)
+
+			_, err := c.Get(ctx, "k2")
+			So(errors.Is(err, ErrNotFound), ShouldBeTrue)
+			value, err := c.Get(ctx, "k1")
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "v1")
+			So(c.Len(), ShouldEqual, 2)
+		})
+
+		Convey("覆盖写入更新值与过期时间", func() {
+			_ = c.Set(ctx, "k1", "v1", time.Millisecond)
+			_ = c.Set(ctx, "k1", "v2", 0)
+			time.Sleep(5 * time.Millisecond)
+
+			value, err := c.Get(ctx, "k1")
+
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "v2")
+		})
+
+		Convey("Del 删除键", func() {
+			_ = c.Set(ctx, "k1", "v1", 0)
+			_ = c.Set(ctx, "k2", "v2", 0)
+
+			So(c.Del(ctx, "k1", "missing"), ShouldBeNil)
+
+			exists, _ := c.Exists(ctx, "k1")
+			So(exists, ShouldBeFalse)
+			exists, _ = c.Exists(ctx, "k2")
+			So(exists, ShouldBeTrue)
+		})
+
+		Convey("Close 清空缓存", func() {
+			_ = c.Set(ctx, "k1", "v1", 0)
+
+			So(c.Close(), ShouldBeNil)
+			So(c.Len(), ShouldEqual, 0)
+		})
+	})
+}
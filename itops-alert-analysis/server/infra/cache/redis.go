@@ -100,7 +100,7 @@ func newStandaloneClient(cfg RedisConfig) *redis.Client {
 func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
 	value, err := r.client.Get(ctx, key).Result()
 	if errors.Is(err, redis.Nil) {
-		return "", errors.Errorf("key not found: %s", key)
+		return "", errors.Wrap(ErrNotFound, key)
 	}
 	if err != nil {
 		return "", errors.Wrap(err, "redis get")
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultL2RetryInterval L2 出错后暂停访问 L2 的时长，避免 Redis 故障期间每次查询都等待超时
+	defaultL2RetryInterval = 30 * time.Second
+	// defaultL1TTL L2 命中回填 L1 的默认过期时间
+	defaultL1TTL = 5 * time.Minute
+)
+
+// TieredCache 两级缓存：本地 L1 在前，Redis 等共享 L2 在后。
+// 写入同时写两级；读取先查 L1，未命中再查 L2 并回填 L1。
+// L2 不可用时降级为仅使用 L1，写入与查询不返回 L2 的错误，保证 Redis 故障期间 ingest 不中断。
+type TieredCache struct {
+	l1    Cache
+	l2    Cache
+	l1TTL time.Duration // L2 命中回填 L1 的过期时间
+
+	retryInterval time.Duration
+	l2DownUntil   atomic.Int64 // L2 暂停访问截止时间（UnixNano），0 表示可用
+}
+
+// NewTieredCache 创建两级缓存，l1TTL 为 L2 命中回填 L1 的过期时间，不大于 0 时使用默认值。
+func NewTieredCache(l1, l2 Cache, l1TTL time.Duration) *TieredCache {
+	if l1TTL <= 0 {
+		l1TTL = defaultL1TTL
+	}
+	return &TieredCache{
+		l1:            l1,
+		l2:            l2,
+		l1TTL:         l1TTL,
+		retryInterval: defaultL2RetryInterval,
+	}
+}
+
+// Get 获取缓存值：L1 > L2，L2 命中时回填 L1。
+func (t *TieredCache) Get(ctx context.Context, key string) (string, error) {
+	if value, err := t.l1.Get(ctx, key); err == nil {
+		return value, nil
+	}
+	if !t.l2Available() {
+		return "", errors.Wrap(ErrNotFound, key)
+	}
+
+	value, err := t.l2.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			t.markL2Down("get", err)
+		}
+		return "", errors.Wrap(ErrNotFound, key)
+	}
+
+	// 回填 L1 时不感知 L2 的剩余过期时间，使用 L1 过期时间，使其他实例写入 L2 的更新或删除在过期后可见
+	_ = t.l1.Set(ctx, key, value, t.l1TTL)
+	return value, nil
+}
+
+// Set 设置缓存值，L1 写入失败时返回错误，L2 写入失败仅记录日志。
+func (t *TieredCache) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	if err := t.l1.Set(ctx, key, value, expiration); err != nil {
+		return errors.Wrap(err, "写入本地缓存失败")
+	}
+	if t.l2Available() {
+		if err := t.l2.Set(ctx, key, value, expiration); err != nil {
+			t.markL2Down("set", err)
+		}
+	}
+	return nil
+}
+
+// Del 删除两级缓存中的键。
+func (t *TieredCache) Del(ctx context.Context, keys ...string) error {
+	if err := t.l1.Del(ctx, keys...); err != nil {
+		return errors.Wrap(err, "删除本地缓存失败")
+	}
+	if t.l2Available() {
+		if err := t.l2.Del(ctx, keys...); err != nil {
+			t.markL2Down("del", err)
+		}
+	}
+	return nil
+}
+
+// Exists 检查键是否存在于任一级缓存。
+func (t *TieredCache) Exists(ctx context.Context, key string) (bool, error) {
+	if ok, err := t.l1.Exists(ctx, key); err == nil && ok {
+		return true, nil
+	}
+	if !t.l2Available() {
+		return false, nil
+	}
+	ok, err := t.l2.Exists(ctx, key)
+	if err != nil {
+		t.markL2Down("exists", err)
+		return false, nil
+	}
+	return ok, nil
+}
+
+// Close 关闭两级缓存。
+func (t *TieredCache) Close() error {
+	l1Err := t.l1.Close()
+	if err := t.l2.Close(); err != nil {
+		return err
+	}
+	return l1Err
+}
+
+// l2Available L2 是否可访问，暂停期结束后自动恢复访问。
+func (t *TieredCache) l2Available() bool {
+	downUntil := t.l2DownUntil.Load()
+	return downUntil == 0 || time.Now().UnixNano() >= downUntil
+}
+
+func (t *TieredCache) markL2Down(op string, err error) {
+	// 仅在状态由可用变为不可用时记录日志，避免故障期间刷屏
+	prev := t.l2DownUntil.Swap(time.Now().Add(t.retryInterval).UnixNano())
+	if prev == 0 || time.Now().UnixNano() >= prev {
+		log.Warnf("二级缓存 %s 失败，%v 内仅使用本地缓存: %v", op, t.retryInterval, err)
+	}
+}
+
+var _ Cache = (*TieredCache)(nil)
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// failingCache 模拟不可用的 L2
+type failingCache struct {
+	calls int
+}
+
+func (f *failingCache) Get(ctx context.Context, key string) (string, error) {
+	f.calls++
+	return "", errors.New("connection refused")
+}
+
+func (f *failingCache) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	f.calls++
+	return errors.New("connection refused")
+}
+
+func (f *failingCache) Del(ctx context.Context, keys ...string) error {
+	f.calls++
+	return errors.New("connection refused")
+}
+
+func (f *failingCache) Exists(ctx context.Context, key string) (bool, error) {
+	f.calls++
+	return false, errors.New("connection refused")
+}
+
+func (f *failingCache) Close() error {
+	return nil
+}
+
+func TestTieredCache(t *testing.T) {
+	Convey("TestTieredCache", t, func() {
+		ctx := context.Background()
+
+		Convey("写入同时写两级缓存", func() {
+			l1, l2 := NewMemoryCache(0), NewMemoryCache(0)
+			c := NewTieredCache(l1, l2, 0)
+
+			So(c.Set(ctx, "k1", "v1", time.Minute), ShouldBeNil)
+
+			v1, _ := l1.Get(ctx, "k1")
+			v2, _ := l2.Get(ctx, "k1")
+			So(v1, ShouldEqual, "v1")
+			So(v2, ShouldEqual, "v1")
+		})
+
+		Convey("L1 未命中时读取 L2 并回填 L1", func() {
+			l1, l2 := NewMemoryCache(0), NewMemoryCache(0)
+			c := NewTieredCache(l1, l2, 0)
+			_ = l2.Set(ctx, "k1", "v1", time.Minute)
+
+			value, err := c.Get(ctx, "k1")
+
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "v1")
+			exists, _ := l1.Exists(ctx, "k1")
+			So(exists, ShouldBeTrue)
+		})
+
+		Convey("回填 L1 使用 L1 过期时间，过期后重新读取 L2", func() {
+			l1, l2 := NewMemoryCache(0), NewMemoryCache(0)
+			c := NewTieredCache(l1, l2, time.Millisecond)
+			_ = l2.Set(ctx, "k1", "v1", time.Minute)
+
+			_, _ = c.Get(ctx, "k1")
+			_ = l2.Set(ctx, "k1", "v2", time.Minute)
+			time.Sleep(5 * time.Millisecond)
+
+			exists, _ := l1.Exists(ctx, "k1")
+			So(exists, ShouldBeFalse)
+			value, err := c.Get(ctx, "k1")
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "v2")
+		})
+
+		Convey("两级均未命中返回 ErrNotFound", func() {
+			c := NewTieredCache(NewMemoryCache(0), NewMemoryCache(0), 0)
+
+			_, err := c.Get(ctx, "missing")
+
+			So(errors.Is(err, ErrNotFound), ShouldBeTrue)
+		})
+
+		Convey("Del 删除两级缓存", func() {
+			l1, l2 := NewMemoryCache(0), NewMemoryCache(0)
+			c := NewTieredCache(l1, l2, 0)
+			_ = c.Set(ctx, "k1", "v1", 0)
+
+			So(c.Del(ctx, "k1"), ShouldBeNil)
+
+			exists, _ := c.Exists(ctx, "k1")
+			So(exists, ShouldBeFalse)
+			exists, _ = l2.Exists(ctx, "k1")
+			So(exists, ShouldBeFalse)
+		})
+
+		Convey("L2 不可用时降级为仅使用 L1", func() {
+			l1, l2 := NewMemoryCache(0), &failingCache{}
+			c := NewTieredCache(l1, l2, 0)
+
+			So(c.Set(ctx, "k1", "v1", time.Minute), ShouldBeNil)
+			value, err := c.Get(ctx, "k1")
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "v1")
+
+			// 暂停期内不再访问 L2
+			_, err = c.Get(ctx, "missing")
+			So(errors.Is(err, ErrNotFound), ShouldBeTrue)
+			So(c.Del(ctx, "k1"), ShouldBeNil)
+			So(l2.calls, ShouldEqual, 1)
+		})
+
+		Convey("暂停期结束后恢复访问 L2", func() {
+			l2 := &failingCache{}
+			c := NewTieredCache(NewMemoryCache(0), l2, 0)
+			c.retryInterval = time.Millisecond
+
+			_ = c.Set(ctx, "k1", "v1", 0)
+			time.Sleep(5 * time.Millisecond)
+			_, _ = c.Get(ctx, "missing")
+
+			So(l2.calls, ShouldEqual, 2)
+		})
+	})
+}
@@ -0,0 +1,109 @@
+package dip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+)
+
+// agentQueryCausalBatch 整问题因果推理查询文本
+const agentQueryCausalBatch = "请输出全部因果关系"
+
+// causalEdgeObjectPattern 匹配包含 source_id 的因果边对象，用于 JSON 解析失败时逐个提取
+var causalEdgeObjectPattern = regexp.MustCompile(`\{[^{}]*"source_id"[^{}]*\}`)
+
+// CallCausalBatchAgent 调用整问题因果推理智能体，一次分析故障点时间线中的全部因果关系。
+// - customQuerys: 发送给智能体的上下文（fault_timeline、topology_relations）
+// 返回值为智能体输出中的 fault_causals 数组，没有因果关系时为空；返回格式不符合预期时给出错误。
+// 当 JSON 解析失败时，会尝试使用正则表达式逐个提取因果边。
+func (c *Client) CallCausalBatchAgent(ctx context.Context, causalConfig CausalConfig, customQuerys map[string]interface{}) ([]domain.AgentCausalEdge, error) {
+	// 参数验证
+	if c == nil {
+		return nil, errors.New("client 未初始化")
+	}
+	if c.httpClient == nil {
+		return nil, errors.New("http client 未初始化")
+	}
+	if ctx == nil {
+		return nil, errors.New("上下文不能为 nil")
+	}
+	if causalConfig.AppID == "" {
+		return nil, errors.New("app_id 不能为空")
+	}
+	if causalConfig.AgentKey == "" {
+		return nil, errors.New("agent_key 不能为空")
+	}
+
+	path := fmt.Sprintf(agentAPIPathTemplate, causalConfig.AppID)
+
+	// 构建请求体
+	reqBody := domain.AgentRequest{
+		AgentKey:     causalConfig.AgentKey,
+		CustomQuerys: customQuerys,
+		Query:        agentQueryCausalBatch,
+		Stream:       false,
+	}
+
+	headers := map[string]string{
+		"Authorization": causalConfig.Authorization,
+		"Content-Type":  "application/json",
+	}
+
+	resp, err := c.httpClient.Post(ctx, path, reqBody, headers)
+	if err != nil {
+		return nil, errors.Wrapf(err, "发送 agent 请求失败")
+	}
+	if resp == nil {
+		return nil, errors.New("agent 响应为空")
+	}
+	if err := resp.Error(); err != nil {
+		return nil, errors.Wrapf(err, "agent 请求失败")
+	}
+
+	var agentResp domain.AgentResponse
+	if err := resp.DecodeJSON(&agentResp); err != nil {
+		return nil, errors.Wrapf(err, "解析 agent 响应失败")
+	}
+
+	rawText, err := extractRawTextFromResponse(&agentResp)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCausalBatch(rawText)
+}
+
+// parseCausalBatch 解析整问题因果推理结果。
+// 返回 JSON 示例："{\n  \"fault_causals\": [{\"source_id\": 1, \"target_id\": 2, \"confidence\": 0.85, \"reason\": \"...\"}]\n}"
+// 模型输出可能包裹在 markdown 代码块中，先截取最外层的 JSON 对象再解析。
+func parseCausalBatch(rawText string) ([]domain.AgentCausalEdge, error) {
+	text := strings.TrimSpace(rawText)
+	if start, end := strings.Index(text, "{"), strings.LastIndex(text, "}"); start >= 0 && end > start {
+		text = text[start : end+1]
+	}
+
+	var payload domain.AgentCausalBatchPayload
+	if err := json.Unmarshal([]byte(text), &payload); err == nil && strings.Contains(text, `"fault_causals"`) {
+		return payload.FaultCausals, nil
+	}
+
+	// JSON 解析失败或缺少 fault_causals 字段，尝试逐个提取因果边
+	var edges []domain.AgentCausalEdge
+	for _, obj := range causalEdgeObjectPattern.FindAllString(rawText, -1) {
+		edge := extractCausalEdgeFromObject(obj)
+		if edge.Source == 0 || edge.Target == 0 {
+			continue
+		}
+		edges = append(edges, edge)
+	}
+	if len(edges) == 0 {
+		return nil, errors.New("解析 agent 返回的 fault_causals 失败")
+	}
+	return edges, nil
+}
@@ -0,0 +1,96 @@
+package dip
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newAgentTextServer(text string, captured *domain.AgentRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if captured != nil {
+			_ = json.NewDecoder(r.Body).Decode(captured)
+		}
+		var resp domain.AgentResponse
+		resp.Message.Content.FinalAnswer.Answer.Text = text
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestClient_CallCausalBatchAgent(t *testing.T) {
+	Convey("TestClient_CallCausalBatchAgent", t, func() {
+		causalConfig := CausalConfig{AppID: "app", AgentKey: "key", Authorization: "Bearer test-token"}
+
+		Convey("成功返回全部因果边", func() {
+			var captured domain.AgentRequest
+			server := newAgentTextServer(`{"fault_causals": [{"source_id": 1, "target_id": 2, "confidence": 0.8, "reason": "1 → 2"}]}`, &captured)
+			defer server.Close()
+
+			edges, err := newTestClient(server.URL).CallCausalBatchAgent(context.Background(), causalConfig, map[string]interface{}{
+				"fault_timeline": []interface{}{},
+			})
+
+			So(err, ShouldBeNil)
+			So(edges, ShouldHaveLength, 1)
+			So(edges[0].Target, ShouldEqual, 2)
+			So(captured.Query, ShouldEqual, agentQueryCausalBatch)
+			So(captured.AgentKey, ShouldEqual, "key")
+			So(captured.CustomQuerys, ShouldContainKey, "fault_timeline")
+		})
+
+		Convey("配置不完整返回错误", func() {
+			_, err := newTestClient("http://example.com").CallCausalBatchAgent(context.Background(), CausalConfig{AgentKey: "key"}, nil)
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "app_id")
+		})
+
+		Convey("返回格式不符合预期时返回错误", func() {
+			server := newAgentTextServer("无法分析", nil)
+			defer server.Close()
+
+			_, err := newTestClient(server.URL).CallCausalBatchAgent(context.Background(), causalConfig, nil)
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestParseCausalBatch(t *testing.T) {
+	Convey("TestParseCausalBatch", t, func() {
+		Convey("解析 markdown 代码块中的 JSON", func() {
+			edges, err := parseCausalBatch("```json\n{\"fault_causals\": [{\"source_id\": 1, \"target_id\": 2, \"confidence\": 0.8}, {\"source_id\": 2, \"target_id\": 3, \"confidence\": 0.6}]}\n```")
+
+			So(err, ShouldBeNil)
+			So(edges, ShouldHaveLength, 2)
+			So(edges[1].Source, ShouldEqual, 2)
+		})
+
+		Convey("没有因果关系时返回空列表", func() {
+			edges, err := parseCausalBatch(`{"fault_causals": []}`)
+
+			So(err, ShouldBeNil)
+			So(edges, ShouldBeEmpty)
+		})
+
+		Convey("JSON 不完整时逐个提取因果边", func() {
+			edges, err := parseCausalBatch(`{"fault_causals": [{"source_id": "1", "target_id": "2", "confidence": 0.7, "reason": "a"}, {"source_id": 0, "target_id": 3}, {"source_id": 3, "target_id": 4`)
+
+			So(err, ShouldBeNil)
+			So(edges, ShouldHaveLength, 1)
+			So(edges[0].Confidence, ShouldEqual, 0.7)
+		})
+
+		Convey("缺少 fault_causals 时返回错误", func() {
+			_, err := parseCausalBatch(`{"fault_causal": "none"}`)
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -19,19 +19,32 @@ type ObjectDataResponse struct {
 type QueryRequest struct {
 	NeedTotal   bool          `json:"need_total,omitempty"`
 	Limit       int           `json:"limit,omitempty"`
+	Sort        []QuerySort   `json:"sort,omitempty"`
 	SearchAfter []interface{} `json:"search_after,omitempty"`
 }
 
+// QuerySort 查询排序字段。
+type QuerySort struct {
+	Field     string `json:"field"`
+	Direction string `json:"direction"` // asc / desc
+}
+
+// updateTimeSort 按更新时间升序排序，s_id 保证同一时间戳内顺序稳定，search_after 可作为增量水位。
+var updateTimeSort = []QuerySort{
+	{Field: "s_update_time", Direction: "asc"},
+	{Field: "s_id", Direction: "asc"},
+}
+
 // QueryObjectData 查询指定对象类的对象详细数据
 func (c *Client) QueryObjectData(ctx context.Context, otID string, limit int, searchAfter []interface{}) (*ObjectDataResponse, error) {
+	return c.queryObjectData(ctx, otID, QueryRequest{Limit: limit, SearchAfter: searchAfter})
+}
+
+func (c *Client) queryObjectData(ctx context.Context, otID string, reqBody QueryRequest) (*ObjectDataResponse, error) {
 	path := fmt.Sprintf("/api/ontology-query/v1/knowledge-networks/%s/object-types/%s", c.KnID(), otID)
 
-	reqBody := QueryRequest{
-		Limit: limit,
-	}
-	if len(searchAfter) > 0 {
-		reqBody.SearchAfter = searchAfter
-	} else {
+	if len(reqBody.SearchAfter) == 0 {
+		reqBody.SearchAfter = nil
 		reqBody.NeedTotal = true
 	}
 	c.httpClient.SetHeader("X-HTTP-Method-Override", "GET")
@@ -75,3 +88,39 @@ func (c *Client) QueryAllObjectData(ctx context.Context, otID string, limit int)
 
 	return allData, nil
 }
+
+// QueryObjectDataSince 按 s_update_time 升序查询水位 watermark 之后更新的对象数据（自动处理分页），
+// 返回对象数据与新的水位；watermark 为空时查询全部对象。
+func (c *Client) QueryObjectDataSince(ctx context.Context, otID string, limit int, watermark []interface{}) ([]ObjectInstance, []interface{}, error) {
+	var allData []ObjectInstance
+	searchAfter := watermark
+
+	for {
+		resp, err := c.queryObjectData(ctx, otID, QueryRequest{
+			Limit:       limit,
+			Sort:        updateTimeSort,
+			SearchAfter: searchAfter,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		allData = append(allData, resp.Datas...)
+
+		if len(resp.Datas) == 0 {
+			break
+		}
+		// 最后一页可能不返回 search_after，按排序字段从最后一个对象推导水位
+		if len(resp.SearchAfter) == 0 {
+			last := resp.Datas[len(resp.Datas)-1]
+			if last["s_update_time"] != nil && last["s_id"] != nil {
+				searchAfter = []interface{}{last["s_update_time"], last["s_id"]}
+			}
+			break
+		}
+
+		searchAfter = resp.SearchAfter
+	}
+
+	return allData, searchAfter, nil
+}
@@ -238,3 +238,81 @@ func TestClient_QueryAllObjectData(t *testing.T) {
 		})
 	})
 }
+
+func TestClient_QueryObjectDataSince(t *testing.T) {
+	Convey("TestClient_QueryObjectDataSince", t, func() {
+		Convey("按更新时间排序并从水位继续分页", func() {
+			var requests []QueryRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req QueryRequest
+				json.NewDecoder(r.Body).Decode(&req)
+				requests = append(requests, req)
+
+				resp := ObjectDataResponse{}
+				if len(requests) == 1 {
+					resp.Datas = []ObjectInstance{{"s_id": "3", "s_update_time": 300.0}}
+					resp.SearchAfter = []interface{}{300.0, "3"}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(resp)
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			result, watermark, err := client.QueryObjectDataSince(context.Background(), "test-ot", 10, []interface{}{200.0, "2"})
+
+			So(err, ShouldBeNil)
+			So(len(result), ShouldEqual, 1)
+			So(watermark, ShouldResemble, []interface{}{300.0, "3"})
+			So(len(requests), ShouldEqual, 2)
+			So(requests[0].Sort, ShouldResemble, updateTimeSort)
+			So(requests[0].SearchAfter, ShouldResemble, []interface{}{200.0, "2"})
+			So(requests[0].NeedTotal, ShouldBeFalse)
+		})
+
+		Convey("没有新数据时保持原水位", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(ObjectDataResponse{})
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			result, watermark, err := client.QueryObjectDataSince(context.Background(), "test-ot", 10, []interface{}{200.0, "2"})
+
+			So(err, ShouldBeNil)
+			So(result, ShouldBeEmpty)
+			So(watermark, ShouldResemble, []interface{}{200.0, "2"})
+		})
+
+		Convey("最后一页未返回 search_after 时从最后一个对象推导水位", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				resp := ObjectDataResponse{
+					Datas: []ObjectInstance{{"s_id": "1", "s_update_time": 100.0}},
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(resp)
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			_, watermark, err := client.QueryObjectDataSince(context.Background(), "test-ot", 10, nil)
+
+			So(err, ShouldBeNil)
+			So(watermark, ShouldResemble, []interface{}{100.0, "1"})
+		})
+
+		Convey("查询失败", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			result, _, err := client.QueryObjectDataSince(context.Background(), "test-ot", 10, nil)
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+		})
+	})
+}
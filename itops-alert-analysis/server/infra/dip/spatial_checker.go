@@ -2,21 +2,43 @@ package dip
 
 import (
 	"context"
+	"math"
 
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
 	"github.com/pkg/errors"
 )
 
+const (
+	defaultSpatialHops     = 1
+	maxSpatialHops         = 5
+	defaultSpatialMinScore = 0.5 // 未配置 min_score 时的合并阈值
+	defaultHopDecay        = 0.5 // 未配置 hop_weights 时每多一跳得分的衰减比例，衰减到 max_hops 时低于 min_score 则放缓
+)
+
 // SpatialChecker 空间相关性检查
 type SpatialChecker struct {
-	client *Client
+	client    *Client
+	getConfig func() config.SpatialCorrelationConfig
+}
+
+// SpatialMatch 问题与故障点的空间相关结果。
+type SpatialMatch struct {
+	Problem  domain.Problem
+	EntityID string   // 命中的问题对象
+	Hops     int      // 故障点对象到命中对象的跳数，0 表示同一对象
+	Path     []string // 故障点对象到命中对象的路径（s_id），首个元素为故障点对象
+	Score    float64  // 按跳数权重计算的得分
+	MinScore float64  // 合并所需的最低得分
+	Matched  bool     // 是否可达且得分达到阈值
 }
 
-// NewSpatialChecker 创建空间相关性检查器实例。
-func NewSpatialChecker(client *Client) *SpatialChecker {
+// NewSpatialChecker 创建空间相关性检查器实例，getConfig 为 nil 时使用默认规则（1 跳、不限关系、阈值 0.5）。
+func NewSpatialChecker(client *Client, getConfig func() config.SpatialCorrelationConfig) *SpatialChecker {
 	return &SpatialChecker{
-		client: client,
+		client:    client,
+		getConfig: getConfig,
 	}
 }
 
@@ -26,7 +48,28 @@ func (s *SpatialChecker) FilterCorrelatedProblems(
 	fp domain.FaultPointObject,
 	problems []domain.Problem,
 ) ([]domain.Problem, error) {
-	if s.client == nil {
+	matches, err := s.ScoreProblems(ctx, fp, problems)
+	if err != nil {
+		return nil, err
+	}
+
+	var correlatedProblems []domain.Problem
+	for _, m := range matches {
+		if m.Matched {
+			correlatedProblems = append(correlatedProblems, m.Problem)
+		}
+	}
+	return correlatedProblems, nil
+}
+
+// ScoreProblems 按故障点对象类的空间规则计算每个问题的空间相关得分，Matched 标记得分达到阈值的问题。
+// 故障点对象沿允许的关系在最大跳数内可达的对象属于问题时，问题得分为该对象所在跳数的权重，多个对象取最高分。
+func (s *SpatialChecker) ScoreProblems(
+	ctx context.Context,
+	fp domain.FaultPointObject,
+	problems []domain.Problem,
+) ([]SpatialMatch, error) {
+	if s == nil || s.client == nil {
 		return nil, errors.New("DIP 客户端未配置")
 	}
 
@@ -40,7 +83,27 @@ func (s *SpatialChecker) FilterCorrelatedProblems(
 		return nil, errors.New("故障点缺少 EntityObjectID")
 	}
 
-	// 调用子图查询 API
+	// 占位对象不在知识网络中，无法查询子图，仅与包含同一占位对象的问题关联
+	if fp.EntityObjectClass == domain.UnresolvedObjectClass {
+		correlated := make(map[uint64]struct{})
+		for _, problem := range filterProblemsByEntity(fp.EntityObjectID, problems) {
+			correlated[problem.ProblemID] = struct{}{}
+		}
+		matches := make([]SpatialMatch, 0, len(problems))
+		for _, problem := range problems {
+			m := SpatialMatch{Problem: problem, Hops: -1}
+			if _, ok := correlated[problem.ProblemID]; ok {
+				m.EntityID, m.Hops, m.Score, m.Matched = fp.EntityObjectID, 0, 1, true
+				m.Path = []string{fp.EntityObjectID}
+			}
+			matches = append(matches, m)
+		}
+		return matches, nil
+	}
+
+	rule := s.rule(fp.EntityObjectClass)
+
+	// 调用子图查询 API，关系类型与方向在本地按规则过滤
 	subGraphReq := SubGraphQueryRequest{
 		SourceObjectTypeID: fp.EntityObjectClass,
 		Condition: &Condition{
@@ -48,8 +111,8 @@ func (s *SpatialChecker) FilterCorrelatedProblems(
 			Operation: "==",
 			Value:     fp.EntityObjectID,
 		},
-		Direction:  "bidirectional",
-		PathLength: 1,
+		Direction:  config.RelationBidirectional,
+		PathLength: rule.MaxHops,
 	}
 
 	subGraphResp, err := s.client.QuerySubGraph(ctx, subGraphReq)
@@ -57,34 +120,223 @@ func (s *SpatialChecker) FilterCorrelatedProblems(
 		return nil, errors.Wrap(err, "查询子图失败")
 	}
 
-	// 提取子图中所有对象的 s_id
-	spatialObjectIDs := make(map[string]struct{})
-	for _, obj := range subGraphResp.Objects {
+	// 计算子图中各对象（s_id）的可达路径，跳数为路径长度减 1
+	paths, err := reachablePaths(fp.EntityObjectID, rule, subGraphResp)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("子图查询返回 %d 个相关对象（最大跳数 %d）", len(paths), rule.MaxHops)
+
+	// 判断哪些问题与故障点空间相关
+	matches := make([]SpatialMatch, 0, len(problems))
+	matchedCount := 0
+	for _, problem := range problems {
+		best := SpatialMatch{Problem: problem, Hops: -1, MinScore: rule.MinScore}
+		for _, entityID := range problem.AffectedEntityIDs {
+			path, exists := paths[entityID]
+			if !exists {
+				continue
+			}
+			hop := len(path) - 1
+			if score := hopWeight(rule, hop); score > best.Score {
+				best.EntityID, best.Hops, best.Score, best.Path = entityID, hop, score, path
+			}
+		}
+		best.Matched = best.Hops >= 0 && best.Score >= rule.MinScore
+		if best.Matched {
+			matchedCount++
+			log.Infof("问题 %d 空间相关（匹配 entity_id: %s，跳数 %d，得分 %.2f）", problem.ProblemID, best.EntityID, best.Hops, best.Score)
+		}
+		matches = append(matches, best)
+	}
+
+	log.Infof("空间相关性判断: %d 个问题中，%d 个空间相关", len(problems), matchedCount)
+	return matches, nil
+}
+
+// rule 返回故障点对象类对应的空间规则，并补齐默认值。
+func (s *SpatialChecker) rule(objectClass string) config.SpatialRule {
+	var cfg config.SpatialCorrelationConfig
+	if s.getConfig != nil {
+		cfg = s.getConfig()
+	}
+	rule := cfg.Default
+	for _, r := range cfg.Rules {
+		if r.ObjectClass != "" && r.ObjectClass == objectClass {
+			rule = r
+			break
+		}
+	}
+	if rule.MaxHops <= 0 {
+		rule.MaxHops = defaultSpatialHops
+	}
+	if rule.MaxHops > maxSpatialHops {
+		rule.MaxHops = maxSpatialHops
+	}
+	if rule.MinScore <= 0 {
+		rule.MinScore = defaultSpatialMinScore
+	}
+	return rule
+}
+
+// reachablePaths 在子图 relation_paths 的关系上广度优先遍历，计算故障点对象沿允许的关系可达的对象及其最短路径，
+// key 为对象 s_id，路径由 s_id 组成且以起点对象开头。
+// 子图未返回关系路径（或找不到起点对象）时无法逐跳计算：1 跳且不限关系的规则视子图内对象均为 1 跳可达，
+// 其余规则返回错误，避免把多跳内的对象都当作 1 跳或丢弃全部对象。
+func reachablePaths(entityID string, rule config.SpatialRule, resp *SubGraphResponse) (map[string][]string, error) {
+	paths := make(map[string][]string)
+	sids := make(map[string]string, len(resp.Objects)) // 对象 ID -> s_id
+	var starts []string
+	for key, obj := range resp.Objects {
 		if len(obj.Properties.SID) == 0 {
 			continue
 		}
-		spatialObjectIDs[obj.Properties.SID] = struct{}{}
+		id := obj.ID
+		if id == "" {
+			id = key
+		}
+		sids[id] = obj.Properties.SID
+		if obj.Properties.SID == entityID {
+			starts = append(starts, id)
+		}
 	}
 
-	log.Infof("子图查询返回 %d 个相关对象", len(spatialObjectIDs))
+	if len(resp.RelationPaths) == 0 || len(starts) == 0 {
+		others := 0
+		for _, sid := range sids {
+			if sid != entityID {
+				others++
+			}
+		}
+		if others > 0 && (rule.MaxHops > 1 || len(rule.Relations) > 0) {
+			return nil, errors.Errorf("子图响应缺少起点对象 %s 的关系路径（relation_paths），无法按跳数与关系类型计算空间相关性", entityID)
+		}
+		for _, sid := range sids {
+			if sid == entityID {
+				paths[sid] = []string{entityID}
+			} else {
+				paths[sid] = []string{entityID, sid}
+			}
+		}
+		return paths, nil
+	}
 
-	// 判断哪些问题与故障点空间相关
+	// 按允许的关系类型与方向构建邻接表
+	adjacency := make(map[string][]string)
+	for _, path := range resp.RelationPaths {
+		for _, rel := range path.Relations {
+			if relationAllowed(rule, rel.RelationTypeID, true) {
+				adjacency[rel.SourceObjectID] = append(adjacency[rel.SourceObjectID], rel.TargetObjectID)
+			}
+			if relationAllowed(rule, rel.RelationTypeID, false) {
+				adjacency[rel.TargetObjectID] = append(adjacency[rel.TargetObjectID], rel.SourceObjectID)
+			}
+		}
+	}
+
+	// 广度优先遍历，记录最短跳数与前驱对象
+	visited := make(map[string]int)
+	parent := make(map[string]string)
+	queue := make([]string, 0, len(starts))
+	for _, id := range starts {
+		visited[id] = 0
+		queue = append(queue, id)
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if visited[id] >= rule.MaxHops {
+			continue
+		}
+		for _, next := range adjacency[id] {
+			if _, ok := visited[next]; ok {
+				continue
+			}
+			visited[next] = visited[id] + 1
+			parent[next] = id
+			queue = append(queue, next)
+		}
+	}
+
+	for id, hop := range visited {
+		sid, ok := sids[id]
+		if !ok {
+			continue
+		}
+		if prev, exists := paths[sid]; !exists || hop < len(prev)-1 {
+			paths[sid] = objectPath(id, parent, sids)
+		}
+	}
+	return paths, nil
+}
+
+// objectPath 沿前驱回溯出从起点到 id 的路径，对象没有 s_id 时使用对象 ID。
+func objectPath(id string, parent map[string]string, sids map[string]string) []string {
+	var path []string
+	for cur, ok := id, true; ok; cur, ok = parent[cur] {
+		name := sids[cur]
+		if name == "" {
+			name = cur
+		}
+		path = append([]string{name}, path...)
+	}
+	return path
+}
+
+// relationAllowed 判断关系能否按指定方向传播，forward 表示从关系源对象传播到目标对象。
+func relationAllowed(rule config.SpatialRule, relationType string, forward bool) bool {
+	if len(rule.Relations) == 0 {
+		return true
+	}
+	for _, r := range rule.Relations {
+		if r.Type != relationType {
+			continue
+		}
+		switch r.Direction {
+		case config.RelationForward:
+			return forward
+		case config.RelationBackward:
+			return !forward
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// hopWeight 返回第 hop 跳的权重：同一对象为 1，未配置的跳沿用最后一个权重。
+// 未配置权重时第 1 跳为 1，之后每跳按 defaultHopDecay 衰减；按该比例第 MaxHops 跳会低于 MinScore 时，
+// 改为按 MinScore 开 MaxHops-1 次方衰减，使 MaxHops 内的每一跳都能达到阈值，配置的跳数不会被默认权重架空。
+func hopWeight(rule config.SpatialRule, hop int) float64 {
+	if hop <= 0 {
+		return 1
+	}
+	if len(rule.HopWeights) == 0 {
+		weight := math.Pow(defaultHopDecay, float64(hop-1))
+		if rule.MaxHops > 1 && rule.MinScore > 0 {
+			if floor := math.Pow(rule.MinScore, float64(hop-1)/float64(rule.MaxHops-1)); floor > weight {
+				weight = floor
+			}
+		}
+		return weight
+	}
+	if hop > len(rule.HopWeights) {
+		return rule.HopWeights[len(rule.HopWeights)-1]
+	}
+	return rule.HopWeights[hop-1]
+}
+
+// filterProblemsByEntity 过滤出 affected_entity_ids 包含指定实体的问题。
+func filterProblemsByEntity(entityID string, problems []domain.Problem) []domain.Problem {
 	var correlatedProblems []domain.Problem
 	for _, problem := range problems {
-		// 检查问题的 affected_entity_ids 是否在子图结果中
-		isCorrelated := false
-		for _, entityID := range problem.AffectedEntityIDs {
-			if _, exists := spatialObjectIDs[entityID]; exists {
-				isCorrelated = true
-				log.Infof("问题 %d 空间相关（匹配 entity_id: %s）", problem.ProblemID, entityID)
+		for _, id := range problem.AffectedEntityIDs {
+			if id == entityID {
+				correlatedProblems = append(correlatedProblems, problem)
 				break
 			}
 		}
-		if isCorrelated {
-			correlatedProblems = append(correlatedProblems, problem)
-		}
 	}
-
-	log.Infof("空间相关性判断: %d 个问题中，%d 个空间相关", len(problems), len(correlatedProblems))
-	return correlatedProblems, nil
+	log.Infof("未解析实体 %s 空间相关性判断: %d 个问题中，%d 个包含同一实体", entityID, len(problems), len(correlatedProblems))
+	return correlatedProblems
 }
@@ -2,6 +2,7 @@ package dip
 
 import (
 	"context"
+	"math"
 	"testing"
 	"time"
 
@@ -31,14 +32,14 @@ func TestNewSpatialChecker(t *testing.T) {
 				Timeout: 10 * time.Second,
 			}
 			client := NewClient(cfg, mockGetAuth, mockGetKnID)
-			checker := NewSpatialChecker(client)
+			checker := NewSpatialChecker(client, nil)
 
 			So(checker, ShouldNotBeNil)
 			So(checker.client, ShouldEqual, client)
 		})
 
 		Convey("使用 nil client 创建检查器", func() {
-			checker := NewSpatialChecker(nil)
+			checker := NewSpatialChecker(nil, nil)
 
 			So(checker, ShouldNotBeNil)
 			So(checker.client, ShouldBeNil)
@@ -65,7 +66,7 @@ func TestSpatialChecker_FilterCorrelatedProblems(t *testing.T) {
 		Convey("problems 为空返回 nil", func() {
 			cfg := config.DIPConfig{Host: "https://example.com", KnID: "test-kn"}
 			client := NewClient(cfg, mockGetAuth, mockGetKnID)
-			checker := NewSpatialChecker(client)
+			checker := NewSpatialChecker(client, nil)
 			fp := domain.FaultPointObject{FaultID: 1}
 
 			result, err := checker.FilterCorrelatedProblems(ctx, fp, nil)
@@ -77,7 +78,7 @@ func TestSpatialChecker_FilterCorrelatedProblems(t *testing.T) {
 		Convey("故障点缺少 EntityObjectID 返回错误", func() {
 			cfg := config.DIPConfig{Host: "https://example.com", KnID: "test-kn"}
 			client := NewClient(cfg, mockGetAuth, mockGetKnID)
-			checker := NewSpatialChecker(client)
+			checker := NewSpatialChecker(client, nil)
 			fp := domain.FaultPointObject{
 				FaultID:        1,
 				EntityObjectID: "", // 缺少 EntityObjectID
@@ -91,10 +92,39 @@ func TestSpatialChecker_FilterCorrelatedProblems(t *testing.T) {
 			So(err.Error(), ShouldContainSubstring, "故障点缺少 EntityObjectID")
 		})
 
+		Convey("未解析实体仅关联包含同一占位对象的问题", func() {
+			cfg := config.DIPConfig{Host: "https://example.com", KnID: "test-kn"}
+			client := NewClient(cfg, mockGetAuth, mockGetKnID)
+			checker := NewSpatialChecker(client, nil)
+
+			// 占位对象不应查询子图
+			patches := gomonkey.ApplyMethod(client, "QuerySubGraph",
+				func(_ *Client, _ context.Context, _ SubGraphQueryRequest) (*SubGraphResponse, error) {
+					return nil, errors.New("should not be called")
+				})
+			defer patches.Reset()
+
+			fp := domain.FaultPointObject{
+				FaultID:           1,
+				EntityObjectID:    domain.UnresolvedObjectID("host-a"),
+				EntityObjectClass: domain.UnresolvedObjectClass,
+			}
+			problems := []domain.Problem{
+				{ProblemID: 1, AffectedEntityIDs: []string{domain.UnresolvedObjectID("host-a")}},
+				{ProblemID: 2, AffectedEntityIDs: []string{domain.UnresolvedObjectID("host-b")}},
+			}
+
+			result, err := checker.FilterCorrelatedProblems(ctx, fp, problems)
+
+			So(err, ShouldBeNil)
+			So(result, ShouldHaveLength, 1)
+			So(result[0].ProblemID, ShouldEqual, 1)
+		})
+
 		Convey("查询子图失败返回错误", func() {
 			cfg := config.DIPConfig{Host: "https://example.com", KnID: "test-kn"}
 			client := NewClient(cfg, mockGetAuth, mockGetKnID)
-			checker := NewSpatialChecker(client)
+			checker := NewSpatialChecker(client, nil)
 
 			// 打桩 QuerySubGraph 返回错误
 			patches := gomonkey.ApplyMethod(client, "QuerySubGraph",
@@ -120,7 +150,7 @@ func TestSpatialChecker_FilterCorrelatedProblems(t *testing.T) {
 		Convey("成功过滤相关问题", func() {
 			cfg := config.DIPConfig{Host: "https://example.com", KnID: "test-kn"}
 			client := NewClient(cfg, mockGetAuth, mockGetKnID)
-			checker := NewSpatialChecker(client)
+			checker := NewSpatialChecker(client, nil)
 
 			// 打桩 QuerySubGraph 返回子图数据
 			patches := gomonkey.ApplyMethod(client, "QuerySubGraph",
@@ -167,7 +197,7 @@ func TestSpatialChecker_FilterCorrelatedProblems(t *testing.T) {
 		Convey("没有空间相关的问题", func() {
 			cfg := config.DIPConfig{Host: "https://example.com", KnID: "test-kn"}
 			client := NewClient(cfg, mockGetAuth, mockGetKnID)
-			checker := NewSpatialChecker(client)
+			checker := NewSpatialChecker(client, nil)
 
 			// 打桩 QuerySubGraph 返回子图数据
 			patches := gomonkey.ApplyMethod(client, "QuerySubGraph",
@@ -203,7 +233,7 @@ func TestSpatialChecker_FilterCorrelatedProblems(t *testing.T) {
 		Convey("子图返回空对象", func() {
 			cfg := config.DIPConfig{Host: "https://example.com", KnID: "test-kn"}
 			client := NewClient(cfg, mockGetAuth, mockGetKnID)
-			checker := NewSpatialChecker(client)
+			checker := NewSpatialChecker(client, nil)
 
 			// 打桩 QuerySubGraph 返回空子图
 			patches := gomonkey.ApplyMethod(client, "QuerySubGraph",
@@ -232,7 +262,7 @@ func TestSpatialChecker_FilterCorrelatedProblems(t *testing.T) {
 		Convey("子图对象缺少 SID 属性", func() {
 			cfg := config.DIPConfig{Host: "https://example.com", KnID: "test-kn"}
 			client := NewClient(cfg, mockGetAuth, mockGetKnID)
-			checker := NewSpatialChecker(client)
+			checker := NewSpatialChecker(client, nil)
 
 			// 打桩 QuerySubGraph 返回缺少 SID 的对象
 			patches := gomonkey.ApplyMethod(client, "QuerySubGraph",
@@ -272,3 +302,194 @@ func TestSpatialChecker_FilterCorrelatedProblems(t *testing.T) {
 		})
 	})
 }
+
+func TestSpatialChecker_MultiHop(t *testing.T) {
+	Convey("TestSpatialChecker_MultiHop", t, func() {
+		ctx := context.Background()
+		client := NewClient(config.DIPConfig{Host: "https://example.com", KnID: "test-kn"}, mockGetAuth, mockGetKnID)
+
+		// pod-1 -runs_on-> node-1 -connects_to-> switch-1，pod-2 -runs_on-> node-1，node-1 -belongs_to-> cluster-1
+		object := func(id, class string) SubGraphObject {
+			return SubGraphObject{ID: id, ObjectTypeID: class, Properties: SubGraphObjectProperties{SID: id}}
+		}
+		relation := func(typ, src, dst string) SubGraphRelation {
+			return SubGraphRelation{RelationTypeID: typ, SourceObjectID: src, TargetObjectID: dst}
+		}
+		var captured SubGraphQueryRequest
+		patches := gomonkey.ApplyMethod(client, "QuerySubGraph",
+			func(_ *Client, _ context.Context, req SubGraphQueryRequest) (*SubGraphResponse, error) {
+				captured = req
+				return &SubGraphResponse{
+					Objects: map[string]SubGraphObject{
+						"pod-1":     object("pod-1", "pod"),
+						"pod-2":     object("pod-2", "pod"),
+						"node-1":    object("node-1", "node"),
+						"switch-1":  object("switch-1", "switch"),
+						"cluster-1": object("cluster-1", "cluster"),
+						"router-1":  object("router-1", "router"),
+					},
+					RelationPaths: []SubGraphRelationPath{
+						{Relations: []SubGraphRelation{relation("runs_on", "pod-1", "node-1"), relation("connects_to", "node-1", "switch-1")}, Length: 2},
+						{Relations: []SubGraphRelation{relation("runs_on", "pod-1", "node-1"), relation("belongs_to", "node-1", "cluster-1")}, Length: 2},
+						{Relations: []SubGraphRelation{relation("runs_on", "pod-1", "node-1"), relation("runs_on", "pod-2", "node-1")}, Length: 2},
+						{Relations: []SubGraphRelation{relation("runs_on", "pod-1", "node-1"), relation("connects_to", "node-1", "switch-1"), relation("connects_to", "switch-1", "router-1")}, Length: 3},
+					},
+				}, nil
+			})
+		defer patches.Reset()
+
+		spatial := config.SpatialCorrelationConfig{
+			Rules: []config.SpatialRule{{
+				ObjectClass: "pod",
+				MaxHops:     3,
+				Relations: []config.SpatialRelation{
+					{Type: "runs_on", Direction: config.RelationForward},
+					{Type: "connects_to"},
+				},
+				HopWeights: []float64{1, 0.6, 0.3},
+				MinScore:   0.5,
+			}},
+		}
+		checker := NewSpatialChecker(client, func() config.SpatialCorrelationConfig { return spatial })
+
+		fp := domain.FaultPointObject{FaultID: 1, EntityObjectID: "pod-1", EntityObjectClass: "pod"}
+		problems := []domain.Problem{
+			{ProblemID: 1, AffectedEntityIDs: []string{"node-1"}},
+			{ProblemID: 2, AffectedEntityIDs: []string{"switch-1"}},
+			{ProblemID: 3, AffectedEntityIDs: []string{"cluster-1"}},
+			{ProblemID: 4, AffectedEntityIDs: []string{"pod-2"}},
+		}
+
+		Convey("沿允许的关系多跳传播，按跳数权重与阈值过滤", func() {
+			matches, err := checker.ScoreProblems(ctx, fp, problems)
+
+			So(err, ShouldBeNil)
+			So(captured.PathLength, ShouldEqual, 3)
+			So(matches, ShouldHaveLength, 4)
+			So(matches[0].Matched, ShouldBeTrue)
+			So(matches[0].Hops, ShouldEqual, 1)
+			So(matches[0].Score, ShouldEqual, 1)
+			So(matches[1].Matched, ShouldBeTrue)
+			So(matches[1].Hops, ShouldEqual, 2)
+			So(matches[1].Score, ShouldEqual, 0.6)
+			So(matches[1].MinScore, ShouldEqual, 0.5)
+			So(matches[1].Path, ShouldResemble, []string{"pod-1", "node-1", "switch-1"})
+			So(matches[2].Matched, ShouldBeFalse)
+			So(matches[2].Hops, ShouldEqual, -1)
+			So(matches[3].Matched, ShouldBeFalse)
+		})
+
+		Convey("降低阈值后仍不沿未允许的关系和反向关系传播", func() {
+			spatial.Rules[0].MinScore = 0.1
+
+			result, err := checker.FilterCorrelatedProblems(ctx, fp, problems)
+
+			So(err, ShouldBeNil)
+			So(result, ShouldHaveLength, 2)
+		})
+
+		Convey("max_hops 为 3 且未配置 hop_weights 时第 3 跳仍能达到默认阈值", func() {
+			spatial.Rules[0].HopWeights = nil
+			spatial.Rules[0].MinScore = 0
+
+			matches, err := checker.ScoreProblems(ctx, fp, append(problems, domain.Problem{ProblemID: 5, AffectedEntityIDs: []string{"router-1"}}))
+
+			So(err, ShouldBeNil)
+			So(matches[4].Hops, ShouldEqual, 3)
+			So(matches[4].Score, ShouldAlmostEqual, defaultSpatialMinScore)
+			So(matches[4].MinScore, ShouldEqual, defaultSpatialMinScore)
+			So(matches[4].Matched, ShouldBeTrue)
+			So(matches[1].Score, ShouldAlmostEqual, math.Sqrt(defaultSpatialMinScore))
+		})
+
+		Convey("未命中对象类规则时使用默认规则：1 跳、不限关系", func() {
+			other := fp
+			other.EntityObjectClass = "vm"
+
+			result, err := checker.FilterCorrelatedProblems(ctx, other, problems)
+
+			So(err, ShouldBeNil)
+			So(captured.PathLength, ShouldEqual, 1)
+			So(result, ShouldHaveLength, 1)
+			So(result[0].ProblemID, ShouldEqual, 1)
+		})
+	})
+}
+
+func TestHopWeight(t *testing.T) {
+	Convey("TestHopWeight", t, func() {
+		rule := config.SpatialRule{HopWeights: []float64{0.8, 0.5}}
+
+		So(hopWeight(rule, 0), ShouldEqual, 1)
+		So(hopWeight(rule, 1), ShouldEqual, 0.8)
+		So(hopWeight(rule, 2), ShouldEqual, 0.5)
+		So(hopWeight(rule, 3), ShouldEqual, 0.5)
+		So(hopWeight(config.SpatialRule{}, 1), ShouldEqual, 1)
+		So(hopWeight(config.SpatialRule{}, 2), ShouldEqual, 0.5)
+		So(hopWeight(config.SpatialRule{}, 3), ShouldEqual, 0.25)
+		// 默认衰减使第 max_hops 跳低于阈值时放缓衰减，足够高时保持默认衰减
+		So(hopWeight(config.SpatialRule{MaxHops: 3, MinScore: 0.5}, 3), ShouldEqual, 0.5)
+		So(hopWeight(config.SpatialRule{MaxHops: 3, MinScore: 0.2}, 3), ShouldEqual, 0.25)
+	})
+}
+
+func TestSpatialChecker_Rule(t *testing.T) {
+	Convey("TestSpatialChecker_Rule", t, func() {
+		Convey("未配置时补齐默认跳数与阈值", func() {
+			rule := NewSpatialChecker(nil, nil).rule("pod")
+
+			So(rule.MaxHops, ShouldEqual, defaultSpatialHops)
+			So(rule.MinScore, ShouldEqual, defaultSpatialMinScore)
+		})
+
+		Convey("跳数不超过上限", func() {
+			checker := NewSpatialChecker(nil, func() config.SpatialCorrelationConfig {
+				return config.SpatialCorrelationConfig{Default: config.SpatialRule{MaxHops: 10, MinScore: 0.2}}
+			})
+
+			rule := checker.rule("pod")
+
+			So(rule.MaxHops, ShouldEqual, maxSpatialHops)
+			So(rule.MinScore, ShouldEqual, 0.2)
+		})
+	})
+}
+
+func TestReachablePaths(t *testing.T) {
+	Convey("TestReachablePaths", t, func() {
+		resp := &SubGraphResponse{Objects: map[string]SubGraphObject{
+			"pod-1":  {ID: "pod-1", Properties: SubGraphObjectProperties{SID: "pod-1"}},
+			"node-1": {ID: "node-1", Properties: SubGraphObjectProperties{SID: "node-1"}},
+		}}
+
+		Convey("缺少关系路径时 1 跳不限关系的规则视子图对象为 1 跳可达", func() {
+			paths, err := reachablePaths("pod-1", config.SpatialRule{MaxHops: 1}, resp)
+
+			So(err, ShouldBeNil)
+			So(paths, ShouldResemble, map[string][]string{
+				"pod-1":  {"pod-1"},
+				"node-1": {"pod-1", "node-1"},
+			})
+		})
+
+		Convey("缺少关系路径时多跳或限定关系的规则返回错误", func() {
+			_, err := reachablePaths("pod-1", config.SpatialRule{MaxHops: 2}, resp)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "relation_paths")
+
+			_, err = reachablePaths("pod-1", config.SpatialRule{MaxHops: 1, Relations: []config.SpatialRelation{{Type: "runs_on"}}}, resp)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("子图只有起点对象时不报错", func() {
+			only := &SubGraphResponse{Objects: map[string]SubGraphObject{
+				"pod-1": {ID: "pod-1", Properties: SubGraphObjectProperties{SID: "pod-1"}},
+			}}
+
+			paths, err := reachablePaths("pod-1", config.SpatialRule{MaxHops: 3}, only)
+
+			So(err, ShouldBeNil)
+			So(paths, ShouldHaveLength, 1)
+		})
+	})
+}
@@ -35,9 +35,23 @@ type SubGraphObject struct {
 	Properties     SubGraphObjectProperties `json:"properties"`
 }
 
+// SubGraphRelation 子图关系路径中的一条关系，源/目标为对象 ID（非 s_id）。
+type SubGraphRelation struct {
+	RelationTypeID string `json:"relation_type_id"`
+	SourceObjectID string `json:"source_object_id"`
+	TargetObjectID string `json:"target_object_id"`
+}
+
+// SubGraphRelationPath 子图中的一条关系路径。
+type SubGraphRelationPath struct {
+	Relations []SubGraphRelation `json:"relations"`
+	Length    int                `json:"length"`
+}
+
 // SubGraphResponse 子图查询响应。
 type SubGraphResponse struct {
-	Objects map[string]SubGraphObject `json:"objects"`
+	Objects       map[string]SubGraphObject `json:"objects"`
+	RelationPaths []SubGraphRelationPath    `json:"relation_paths"`
 }
 
 // QuerySubGraph 查询对象子图（基于起点、方向和路径长度）。
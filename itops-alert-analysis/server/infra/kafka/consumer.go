@@ -10,9 +10,10 @@ import (
 	"github.com/segmentio/kafka-go"
 )
 
-// Consumer 基于 kafka-go Reader 实现顺序消费。
+// Consumer 基于 kafka-go Reader 实现顺序消费与按键分通道的并行消费。
 type Consumer struct {
 	reader *kafka.Reader
+	stats  consumerStats
 }
 
 func NewConsumer(cfg Config) (core.KafkaConsumer, error) {
@@ -39,7 +40,7 @@ func NewConsumer(cfg Config) (core.KafkaConsumer, error) {
 		GroupID:       groupID,
 		MinBytes:      minBytes,
 		MaxBytes:      maxBytes,
-		QueueCapacity: 1,
+		QueueCapacity: queueCapacity(cfg.QueueCapacity),
 		Dialer:        dialer,
 	}
 	return &Consumer{
@@ -60,20 +61,54 @@ func (c *Consumer) ConsumeRawEvents(ctx context.Context, handler func(ctx contex
 			return err
 		}
 
-		if err := handler(ctx, core.KafkaMessage{
-			Key:       string(msg.Key),
-			Value:     msg.Value,
-			Partition: int32(msg.Partition),
-			Offset:    msg.Offset,
-			Timestamp: msg.Time,
-		}); err != nil {
+		if err := handler(ctx, toMessage(msg)); err != nil {
+			c.stats.failed.Add(1)
+			if errors.Is(err, core.ErrUncommittable) {
+				return errors.Wrapf(err, "partition=%d offset=%d", msg.Partition, msg.Offset)
+			}
 			log.Errorf("kafka handler 处理失败，partition=%d offset=%d err=%v,body=%+v", msg.Partition, msg.Offset, err, string(msg.Value))
 		}
 
+		c.stats.processed.Add(1)
+
 		if err := c.reader.CommitMessages(ctx, msg); err != nil {
 			return errors.Wrap(err, "commit kafka offset")
 		}
+		c.stats.committed(time.Now())
+	}
+}
+
+// queueCapacity 顺序消费只预取一条消息，并行消费按配置预取以填满各通道。
+func queueCapacity(capacity int) int {
+	if capacity <= 0 {
+		return 1
+	}
+	return capacity
+}
+
+// toMessage 将 kafka-go 消息转换为 core.KafkaMessage。
+func toMessage(msg kafka.Message) core.KafkaMessage {
+	return core.KafkaMessage{
+		Key:       string(msg.Key),
+		Topic:     msg.Topic,
+		Value:     msg.Value,
+		Partition: int32(msg.Partition),
+		Offset:    msg.Offset,
+		Timestamp: msg.Time,
+		Headers:   messageHeaders(msg.Headers),
+	}
+}
+
+// messageHeaders 将 Kafka 消息头转换为 map，同名 header 取最后一个。
+func messageHeaders(headers []kafka.Header) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(headers))
+	for _, h := range headers {
+		m[h.Key] = string(h.Value)
 	}
+	return m
 }
 
 func (c *Consumer) Close() error {
@@ -282,6 +282,37 @@ func TestConsumer_ConsumeRawEvents(t *testing.T) {
 			So(handlerCallCount, ShouldEqual, 2) // 即使 handler 失败也继续消费
 		})
 
+		Convey("handler 返回 ErrUncommittable 时不提交并停止消费", func() {
+			cfg := Config{
+				Brokers: []string{"localhost:9092"},
+				Topic:   "test-topic",
+			}
+			consumer, _ := NewConsumer(cfg)
+			c := consumer.(*Consumer)
+			defer c.Close()
+
+			patches := gomonkey.ApplyMethod(c.reader, "FetchMessage",
+				func(_ *kafka.Reader, ctx context.Context) (kafka.Message, error) {
+					return kafka.Message{Key: []byte("key"), Value: []byte("value"), Offset: 7}, nil
+				})
+			defer patches.Reset()
+
+			commitCount := 0
+			patches.ApplyMethod(c.reader, "CommitMessages",
+				func(_ *kafka.Reader, ctx context.Context, msgs ...kafka.Message) error {
+					commitCount++
+					return nil
+				})
+
+			err := consumer.ConsumeRawEvents(context.Background(), func(ctx context.Context, msg core.KafkaMessage) error {
+				return errors.Wrap(core.ErrUncommittable, "publish dead letter")
+			})
+
+			So(errors.Is(err, core.ErrUncommittable), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "offset=7")
+			So(commitCount, ShouldEqual, 0)
+		})
+
 		Convey("CommitMessages 失败返回错误", func() {
 			cfg := Config{
 				Brokers: []string{"localhost:9092"},
@@ -317,3 +348,20 @@ func TestConsumer_ConsumeRawEvents(t *testing.T) {
 		})
 	})
 }
+
+func TestMessageHeaders(t *testing.T) {
+	Convey("TestMessageHeaders", t, func() {
+		Convey("空 header 返回 nil", func() {
+			So(messageHeaders(nil), ShouldBeNil)
+		})
+
+		Convey("转换为 map，同名取最后一个", func() {
+			headers := messageHeaders([]kafka.Header{
+				{Key: "event_source", Value: []byte("zabbix_webhook")},
+				{Key: "event_source", Value: []byte("prometheus_alertmanager")},
+			})
+
+			So(headers["event_source"], ShouldEqual, "prometheus_alertmanager")
+		})
+	})
+}
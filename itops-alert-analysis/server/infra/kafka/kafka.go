@@ -21,6 +21,8 @@ type Config struct {
 	// 内部使用字段（用于创建 Kafka 客户端）
 	Topic   string `yaml:"-"` // 由代码根据 RawEvents/ProblemEvents 填充
 	GroupID string `yaml:"-"` // 由代码根据 RawEvents/ProblemEvents 填充
+	// QueueCapacity Reader 预取的消息数，默认 1（顺序消费），并行消费时按通道容量放大
+	QueueCapacity int `yaml:"-"`
 }
 
 type SASLConfig struct {
@@ -0,0 +1,356 @@
+package kafka
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/core"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	defaultLaneCapacity   = 100
+	defaultCommitInterval = time.Second
+	finalCommitTimeout    = 5 * time.Second
+	lagReportInterval     = time.Minute
+
+	consumeModeSequential = "sequential"
+	consumeModeParallel   = "parallel"
+)
+
+// consumerStats 消费运行指标，并行消费时由各通道协程并发更新。
+type consumerStats struct {
+	processed  atomic.Int64
+	failed     atomic.Int64
+	commits    atomic.Int64
+	lastCommit atomic.Int64 // UnixNano
+	rebalances atomic.Int64 // 消费组再均衡累计次数
+
+	mu      sync.Mutex
+	lanes   []chan laneItem
+	tracker *offsetTracker
+}
+
+func (s *consumerStats) committed(t time.Time) {
+	s.commits.Add(1)
+	s.lastCommit.Store(t.UnixNano())
+}
+
+// attach 记录并行消费的通道与 offset 跟踪器，供 Stats 读取队列深度。
+func (s *consumerStats) attach(lanes []chan laneItem, tracker *offsetTracker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lanes, s.tracker = lanes, tracker
+}
+
+// laneItem 通道中的任务及其所属消息。
+type laneItem struct {
+	task core.LaneTask
+	msg  *trackedMessage
+}
+
+// trackedMessage 已拉取的消息，remaining 为尚未完成的任务数。
+type trackedMessage struct {
+	msg       kafka.Message
+	remaining int
+}
+
+// offsetTracker 按分区记录已拉取消息的完成情况。
+// 同一分区内只有从最早消息开始连续完成的部分可以提交，避免跳过仍在处理中的消息。
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[int][]*trackedMessage
+	pending    int
+	generation int64 // 登记消息时的消费组再均衡次数
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[int][]*trackedMessage)}
+}
+
+// track 按拉取顺序登记消息，没有任务的消息直接视为已完成。
+func (t *offsetTracker) track(msg kafka.Message, tasks int) *trackedMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tm := &trackedMessage{msg: msg, remaining: tasks}
+	t.partitions[msg.Partition] = append(t.partitions[msg.Partition], tm)
+	t.pending++
+	return tm
+}
+
+// finish 标记消息的一个任务已完成。
+func (t *offsetTracker) finish(tm *trackedMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tm.remaining--
+}
+
+// committable 取出各分区连续完成的消息，返回每个分区最后一条用于提交。
+func (t *offsetTracker) committable() []kafka.Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var msgs []kafka.Message
+	for partition, queue := range t.partitions {
+		n := 0
+		for n < len(queue) && queue[n].remaining <= 0 {
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+		msgs = append(msgs, queue[n-1].msg)
+		t.partitions[partition] = queue[n:]
+		t.pending -= n
+	}
+	return msgs
+}
+
+// resetIfRebalanced 消费组再均衡后丢弃全部已登记的消息，返回丢弃的消息数。
+// 再均衡可能撤销分区，旧分配下的消息不能再提交，否则会覆盖新消费者提交的 offset；
+// 未提交的消息由 reader 从已提交的 offset 重新拉取，仍在处理中的任务完成后不再影响提交。
+func (t *offsetTracker) resetIfRebalanced(generation int64) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if generation == t.generation {
+		return 0, false
+	}
+	dropped := t.pending
+	t.generation = generation
+	t.partitions = make(map[int][]*trackedMessage)
+	t.pending = 0
+	return dropped, true
+}
+
+// size 已拉取、尚未提交的消息数。
+func (t *offsetTracker) size() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pending
+}
+
+// laneIndex 按通道键哈希选择通道，同一键总是落在同一通道。
+func laneIndex(key string, lanes int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(lanes))
+}
+
+func normalizeParallelOptions(opts core.ParallelConsumeOptions) core.ParallelConsumeOptions {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.LaneCapacity <= 0 {
+		opts.LaneCapacity = defaultLaneCapacity
+	}
+	if opts.CommitInterval <= 0 {
+		opts.CommitInterval = defaultCommitInterval
+	}
+	return opts
+}
+
+// ConsumeParallel 按通道并行消费。
+// dispatch 在拉取协程中把消息拆分为任务，任务按 Key 哈希到通道，同一 Key 的任务按拉取顺序执行；
+// 消息的全部任务完成后才允许提交其 offset，offset 按 CommitInterval 批量提交。
+// 与顺序消费一致，任务失败只记录日志（由 dispatch 负责转入死信），不阻塞提交；
+// 任务返回 core.ErrUncommittable 时不标记完成并停止消费，该消息及其后的 offset 不会被提交。
+// 消费组再均衡后丢弃旧分配下未提交的消息，不再提交其 offset。
+// 退出时提交已连续完成的 offset，处理中断的消息在重启后重新消费。
+func (c *Consumer) ConsumeParallel(ctx context.Context, opts core.ParallelConsumeOptions, dispatch func(ctx context.Context, msg core.KafkaMessage) []core.LaneTask) error {
+	if c.reader == nil {
+		return errors.New("kafka reader 未初始化")
+	}
+	opts = normalizeParallelOptions(opts)
+
+	tracker := newOffsetTracker()
+	lanes := make([]chan laneItem, opts.Workers)
+	for i := range lanes {
+		lanes[i] = make(chan laneItem, opts.LaneCapacity)
+	}
+	c.stats.attach(lanes, tracker)
+	defer c.stats.attach(nil, nil)
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	for _, lane := range lanes {
+		lane := lane
+		eg.Go(func() error {
+			return c.runLane(egCtx, lane, tracker)
+		})
+	}
+	eg.Go(func() error {
+		return c.commitLoop(egCtx, tracker, opts.CommitInterval)
+	})
+	eg.Go(func() error {
+		defer func() {
+			for _, lane := range lanes {
+				close(lane)
+			}
+		}()
+		return c.fetchLoop(egCtx, lanes, tracker, dispatch)
+	})
+	err := eg.Wait()
+
+	commitCtx, cancel := context.WithTimeout(context.Background(), finalCommitTimeout)
+	defer cancel()
+	if commitErr := c.commit(commitCtx, tracker); commitErr != nil {
+		log.Warnf("退出前提交 kafka offset 失败: %v", commitErr)
+	}
+	return err
+}
+
+// fetchLoop 拉取消息并分发到通道，通道已满时阻塞拉取形成背压。
+func (c *Consumer) fetchLoop(ctx context.Context, lanes []chan laneItem, tracker *offsetTracker, dispatch func(ctx context.Context, msg core.KafkaMessage) []core.LaneTask) error {
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		c.resetOnRebalance(tracker)
+		tasks := dispatch(ctx, toMessage(msg))
+		tracked := tracker.track(msg, len(tasks))
+		for _, task := range tasks {
+			select {
+			case lanes[laneIndex(task.Key, len(lanes))] <- laneItem{task: task, msg: tracked}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// runLane 顺序执行通道中的任务。
+// 因退出被中断或返回 core.ErrUncommittable 的任务不标记完成，保证其消息的 offset 不会被提交。
+func (c *Consumer) runLane(ctx context.Context, lane <-chan laneItem, tracker *offsetTracker) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case item, ok := <-lane:
+			if !ok {
+				return nil
+			}
+			err := item.task.Run(ctx)
+			if err != nil && ctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				c.stats.failed.Add(1)
+				if errors.Is(err, core.ErrUncommittable) {
+					return errors.Wrapf(err, "key=%s partition=%d offset=%d",
+						item.task.Key, item.msg.msg.Partition, item.msg.msg.Offset)
+				}
+				log.Errorf("kafka 任务处理失败，key=%s partition=%d offset=%d err=%v",
+					item.task.Key, item.msg.msg.Partition, item.msg.msg.Offset, err)
+			}
+			c.stats.processed.Add(1)
+			tracker.finish(item.msg)
+		}
+	}
+}
+
+// commitLoop 定期批量提交已完成的 offset，并周期性输出消费积压。
+func (c *Consumer) commitLoop(ctx context.Context, tracker *offsetTracker, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastReport := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := c.commit(ctx, tracker); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+			if time.Since(lastReport) >= lagReportInterval {
+				lastReport = time.Now()
+				c.reportLag()
+			}
+		}
+	}
+}
+
+func (c *Consumer) commit(ctx context.Context, tracker *offsetTracker) error {
+	c.resetOnRebalance(tracker)
+	msgs := tracker.committable()
+	if len(msgs) == 0 {
+		return nil
+	}
+	if err := c.reader.CommitMessages(ctx, msgs...); err != nil {
+		return errors.Wrap(err, "commit kafka offset")
+	}
+	c.stats.committed(time.Now())
+	return nil
+}
+
+// resetOnRebalance 消费组发生再均衡（分区可能被撤销或重新分配）时重置 offset 跟踪器。
+func (c *Consumer) resetOnRebalance(tracker *offsetTracker) {
+	c.readerStats()
+	if dropped, ok := tracker.resetIfRebalanced(c.stats.rebalances.Load()); ok && dropped > 0 {
+		log.Warnf("kafka 消费组再均衡，丢弃 %d 条未提交消息的 offset 跟踪，由新分配重新拉取", dropped)
+	}
+}
+
+// readerStats 读取 reader 指标并累计再均衡次数。
+// reader 的计数类指标在每次读取后清零，所有读取都需经过这里，避免漏计再均衡。
+func (c *Consumer) readerStats() kafka.ReaderStats {
+	if c.reader == nil {
+		return kafka.ReaderStats{}
+	}
+	stats := c.reader.Stats()
+	c.stats.rebalances.Add(stats.Rebalances)
+	return stats
+}
+
+// reportLag 存在积压时输出消费进度，便于告警风暴期间观察追赶情况。
+func (c *Consumer) reportLag() {
+	stats := c.Stats()
+	if stats.Lag <= 0 && stats.Pending == 0 {
+		return
+	}
+	log.Infof("kafka 消费积压: topic=%s, lag=%d, pending=%d, lane_depths=%v, processed=%d, failed=%d",
+		c.reader.Config().Topic, stats.Lag, stats.Pending, stats.LaneDepths, stats.Processed, stats.Failed)
+}
+
+// Stats 返回消费运行指标。
+func (c *Consumer) Stats() domain.ConsumerStats {
+	c.stats.mu.Lock()
+	lanes, tracker := c.stats.lanes, c.stats.tracker
+	c.stats.mu.Unlock()
+
+	stats := domain.ConsumerStats{
+		Mode:      consumeModeSequential,
+		Workers:   1,
+		Processed: c.stats.processed.Load(),
+		Failed:    c.stats.failed.Load(),
+		Commits:   c.stats.commits.Load(),
+	}
+	if last := c.stats.lastCommit.Load(); last > 0 {
+		stats.LastCommitTime = time.Unix(0, last)
+	}
+	if lanes != nil {
+		stats.Mode = consumeModeParallel
+		stats.Workers = len(lanes)
+		stats.LaneDepths = make([]int, len(lanes))
+		for i, lane := range lanes {
+			stats.LaneDepths[i] = len(lane)
+		}
+		stats.Pending = tracker.size()
+	}
+	if c.reader != nil {
+		stats.Lag = c.readerStats().Lag
+	}
+	return stats
+}
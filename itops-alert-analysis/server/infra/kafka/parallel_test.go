@@ -0,0 +1,284 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/core"
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOffsetTracker(t *testing.T) {
+	Convey("TestOffsetTracker", t, func() {
+		tracker := newOffsetTracker()
+		m0 := tracker.track(kafka.Message{Partition: 0, Offset: 10}, 1)
+		m1 := tracker.track(kafka.Message{Partition: 0, Offset: 11}, 2)
+		m2 := tracker.track(kafka.Message{Partition: 0, Offset: 12}, 1)
+		tracker.track(kafka.Message{Partition: 1, Offset: 5}, 0)
+
+		Convey("只提交从最早消息开始连续完成的部分", func() {
+			tracker.finish(m2)
+			msgs := tracker.committable()
+
+			// 分区 0 的 offset 10 未完成，不能越过；分区 1 无任务的消息直接可提交
+			So(msgs, ShouldHaveLength, 1)
+			So(msgs[0].Partition, ShouldEqual, 1)
+			So(msgs[0].Offset, ShouldEqual, 5)
+			So(tracker.size(), ShouldEqual, 3)
+
+			// offset 11 还有一个任务未完成，只能提交到 10
+			tracker.finish(m0)
+			tracker.finish(m1)
+			msgs = tracker.committable()
+			So(msgs, ShouldHaveLength, 1)
+			So(msgs[0].Offset, ShouldEqual, 10)
+			So(tracker.committable(), ShouldBeEmpty)
+
+			tracker.finish(m1)
+			msgs = tracker.committable()
+			So(msgs, ShouldHaveLength, 1)
+			So(msgs[0].Offset, ShouldEqual, 12)
+			So(tracker.size(), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestOffsetTracker_ResetIfRebalanced(t *testing.T) {
+	Convey("TestOffsetTracker_ResetIfRebalanced", t, func() {
+		tracker := newOffsetTracker()
+		m0 := tracker.track(kafka.Message{Partition: 0, Offset: 10}, 1)
+		tracker.track(kafka.Message{Partition: 1, Offset: 5}, 0)
+
+		dropped, ok := tracker.resetIfRebalanced(0)
+		So(ok, ShouldBeFalse)
+		So(dropped, ShouldEqual, 0)
+
+		dropped, ok = tracker.resetIfRebalanced(1)
+		So(ok, ShouldBeTrue)
+		So(dropped, ShouldEqual, 2)
+		So(tracker.size(), ShouldEqual, 0)
+
+		// 旧分配下的任务完成后不再产生提交
+		tracker.finish(m0)
+		So(tracker.committable(), ShouldBeEmpty)
+
+		tracker.track(kafka.Message{Partition: 0, Offset: 8}, 0)
+		msgs := tracker.committable()
+		So(msgs, ShouldHaveLength, 1)
+		So(msgs[0].Offset, ShouldEqual, 8)
+	})
+}
+
+func TestConsumer_ResetOnRebalance(t *testing.T) {
+	Convey("TestConsumer_ResetOnRebalance", t, func() {
+		consumer, _ := NewConsumer(Config{Brokers: []string{"localhost:9092"}, Topic: "test-topic"})
+		c := consumer.(*Consumer)
+		defer c.Close()
+
+		rebalances := int64(0)
+		patches := gomonkey.ApplyMethod(c.reader, "Stats", func(_ *kafka.Reader) kafka.ReaderStats {
+			// 与 reader 一致，计数在读取后清零
+			n := rebalances
+			rebalances = 0
+			return kafka.ReaderStats{Rebalances: n, Lag: 3}
+		})
+		defer patches.Reset()
+
+		tracker := newOffsetTracker()
+		tracker.track(kafka.Message{Partition: 0, Offset: 10}, 1)
+
+		c.resetOnRebalance(tracker)
+		So(tracker.size(), ShouldEqual, 1)
+
+		// Stats 读取的再均衡次数同样被累计
+		rebalances = 1
+		So(c.Stats().Lag, ShouldEqual, 3)
+		c.resetOnRebalance(tracker)
+		So(tracker.size(), ShouldEqual, 0)
+		So(c.stats.rebalances.Load(), ShouldEqual, 1)
+	})
+}
+
+func TestConsumer_RunLane(t *testing.T) {
+	Convey("TestConsumer_RunLane", t, func() {
+		c := &Consumer{}
+		tracker := newOffsetTracker()
+		failed := tracker.track(kafka.Message{Partition: 0, Offset: 10}, 1)
+		next := tracker.track(kafka.Message{Partition: 0, Offset: 11}, 1)
+		lane := make(chan laneItem, 2)
+		lane <- laneItem{msg: failed, task: core.LaneTask{Key: "host-1", Run: func(context.Context) error {
+			return errors.Wrap(core.ErrUncommittable, "publish dead letter")
+		}}}
+		lane <- laneItem{msg: next, task: core.LaneTask{Key: "host-1", Run: func(context.Context) error { return nil }}}
+
+		err := c.runLane(context.Background(), lane, tracker)
+
+		// 未能转入死信的任务不标记完成，其后的消息也不再处理，offset 不会越过它提交
+		So(errors.Is(err, core.ErrUncommittable), ShouldBeTrue)
+		So(tracker.committable(), ShouldBeEmpty)
+		So(c.stats.failed.Load(), ShouldEqual, 1)
+		So(lane, ShouldHaveLength, 1)
+	})
+}
+
+func TestLaneIndex(t *testing.T) {
+	Convey("TestLaneIndex", t, func() {
+		So(laneIndex("host-1", 8), ShouldEqual, laneIndex("host-1", 8))
+		for _, key := range []string{"", "host-1", "db-1", "pod-1"} {
+			idx := laneIndex(key, 8)
+			So(idx, ShouldBeGreaterThanOrEqualTo, 0)
+			So(idx, ShouldBeLessThan, 8)
+		}
+	})
+}
+
+func TestNormalizeParallelOptions(t *testing.T) {
+	Convey("TestNormalizeParallelOptions", t, func() {
+		opts := normalizeParallelOptions(core.ParallelConsumeOptions{})
+		So(opts.Workers, ShouldEqual, 1)
+		So(opts.LaneCapacity, ShouldEqual, defaultLaneCapacity)
+		So(opts.CommitInterval, ShouldEqual, defaultCommitInterval)
+	})
+}
+
+func TestConsumer_ConsumeParallel(t *testing.T) {
+	Convey("TestConsumer_ConsumeParallel", t, func() {
+		Convey("reader 为 nil 返回错误", func() {
+			consumer := &Consumer{}
+
+			err := consumer.ConsumeParallel(context.Background(), core.ParallelConsumeOptions{Workers: 2}, nil)
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "kafka reader 未初始化")
+		})
+
+		Convey("同一实体按顺序处理，全部完成后批量提交", func() {
+			consumer, _ := NewConsumer(Config{Brokers: []string{"localhost:9092"}, Topic: "test-topic"})
+			c := consumer.(*Consumer)
+			defer c.Close()
+
+			var messages []kafka.Message
+			for i := 0; i < 30; i++ {
+				messages = append(messages, kafka.Message{
+					Partition: i % 2,
+					Offset:    int64(i),
+					Key:       []byte(fmt.Sprintf("host-%d", i%3)),
+				})
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			fetched := 0
+			patches := gomonkey.ApplyMethod(c.reader, "FetchMessage",
+				func(_ *kafka.Reader, ctx context.Context) (kafka.Message, error) {
+					if fetched < len(messages) {
+						fetched++
+						return messages[fetched-1], nil
+					}
+					<-ctx.Done()
+					return kafka.Message{}, ctx.Err()
+				})
+			defer patches.Reset()
+
+			var mu sync.Mutex
+			committed := map[int]int64{}
+			patches.ApplyMethod(c.reader, "CommitMessages",
+				func(_ *kafka.Reader, _ context.Context, msgs ...kafka.Message) error {
+					mu.Lock()
+					defer mu.Unlock()
+					for _, m := range msgs {
+						committed[m.Partition] = m.Offset
+					}
+					return nil
+				})
+
+			order := map[string][]int64{}
+			var wg sync.WaitGroup
+			wg.Add(len(messages))
+			dispatch := func(_ context.Context, msg core.KafkaMessage) []core.LaneTask {
+				return []core.LaneTask{{
+					Key: msg.Key,
+					Run: func(_ context.Context) error {
+						defer wg.Done()
+						time.Sleep(time.Millisecond)
+						mu.Lock()
+						order[msg.Key] = append(order[msg.Key], msg.Offset)
+						mu.Unlock()
+						if msg.Offset == 7 {
+							return errors.New("process failed")
+						}
+						return nil
+					},
+				}}
+			}
+
+			done := make(chan error, 1)
+			go func() {
+				done <- c.ConsumeParallel(ctx, core.ParallelConsumeOptions{Workers: 3, LaneCapacity: 2, CommitInterval: 10 * time.Millisecond}, dispatch)
+			}()
+			wg.Wait()
+			// 任务返回后才计入处理数
+			for c.Stats().Processed < int64(len(messages)) {
+				time.Sleep(time.Millisecond)
+			}
+			stats := c.Stats()
+			cancel()
+			err := <-done
+
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+			So(stats.Mode, ShouldEqual, consumeModeParallel)
+			So(stats.Workers, ShouldEqual, 3)
+			So(stats.LaneDepths, ShouldHaveLength, 3)
+			So(stats.Processed, ShouldEqual, len(messages))
+			So(stats.Failed, ShouldEqual, 1)
+
+			mu.Lock()
+			defer mu.Unlock()
+			So(order, ShouldHaveLength, 3)
+			for _, offsets := range order {
+				So(offsets, ShouldHaveLength, 10)
+				for i := 1; i < len(offsets); i++ {
+					So(offsets[i], ShouldBeGreaterThan, offsets[i-1])
+				}
+			}
+			// 失败的任务不阻塞提交，退出前提交到各分区最后一条
+			So(committed, ShouldResemble, map[int]int64{0: 28, 1: 29})
+			So(c.Stats().Mode, ShouldEqual, consumeModeSequential)
+		})
+
+		Convey("提交失败返回错误", func() {
+			consumer, _ := NewConsumer(Config{Brokers: []string{"localhost:9092"}, Topic: "test-topic"})
+			c := consumer.(*Consumer)
+			defer c.Close()
+
+			fetched := false
+			patches := gomonkey.ApplyMethod(c.reader, "FetchMessage",
+				func(_ *kafka.Reader, ctx context.Context) (kafka.Message, error) {
+					if !fetched {
+						fetched = true
+						return kafka.Message{Offset: 1}, nil
+					}
+					<-ctx.Done()
+					return kafka.Message{}, ctx.Err()
+				})
+			defer patches.Reset()
+			patches.ApplyMethod(c.reader, "CommitMessages",
+				func(_ *kafka.Reader, _ context.Context, _ ...kafka.Message) error {
+					return errors.New("commit failed")
+				})
+
+			err := c.ConsumeParallel(context.Background(), core.ParallelConsumeOptions{Workers: 2, CommitInterval: 5 * time.Millisecond},
+				func(_ context.Context, _ core.KafkaMessage) []core.LaneTask { return nil })
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "commit kafka offset")
+		})
+	})
+}
@@ -51,6 +51,11 @@ func NewProducer(cfg Config) (core.KafkaProducer, error) {
 }
 
 func (p *Producer) PublishRawEvent(ctx context.Context, key string, value []byte) error {
+	return p.PublishRawEventWithHeaders(ctx, key, value, nil)
+}
+
+// PublishRawEventWithHeaders 发送带消息头的原始事件（如 event_source）。
+func (p *Producer) PublishRawEventWithHeaders(ctx context.Context, key string, value []byte, headers map[string]string) error {
 	if p.writer == nil {
 		return errors.New("kafka writer 未初始化")
 	}
@@ -59,6 +64,9 @@ func (p *Producer) PublishRawEvent(ctx context.Context, key string, value []byte
 		Value: value,
 		Time:  time.Now().Local(),
 	}
+	for k, v := range headers {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
 	return p.writer.WriteMessages(ctx, msg)
 }
 
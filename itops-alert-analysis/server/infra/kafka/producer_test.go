@@ -215,6 +215,33 @@ func TestProducer_PublishRawEvent(t *testing.T) {
 			So(string(capturedMessages[0].Value), ShouldEqual, `{"event": "test"}`)
 		})
 
+		Convey("发布带消息头的消息", func() {
+			cfg := Config{
+				Brokers: []string{"localhost:9092"},
+				Topic:   "test-topic",
+			}
+			producer, _ := NewProducer(cfg)
+			p := producer.(*Producer)
+			defer p.Close()
+
+			var capturedMessages []kafka.Message
+			patches := gomonkey.ApplyMethod(p.writer, "WriteMessages",
+				func(_ *kafka.Writer, ctx context.Context, msgs ...kafka.Message) error {
+					capturedMessages = append(capturedMessages, msgs...)
+					return nil
+				})
+			defer patches.Reset()
+
+			ctx := context.Background()
+			err := producer.PublishRawEventWithHeaders(ctx, "key", []byte("value"), map[string]string{"event_source": "zabbix_webhook"})
+
+			So(err, ShouldBeNil)
+			So(len(capturedMessages), ShouldEqual, 1)
+			So(len(capturedMessages[0].Headers), ShouldEqual, 1)
+			So(capturedMessages[0].Headers[0].Key, ShouldEqual, "event_source")
+			So(string(capturedMessages[0].Headers[0].Value), ShouldEqual, "zabbix_webhook")
+		})
+
 		Convey("发布空 key 的消息", func() {
 			cfg := Config{
 				Brokers: []string{"localhost:9092"},
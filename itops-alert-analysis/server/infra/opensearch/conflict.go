@@ -0,0 +1,41 @@
+package opensearch
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"github.com/pkg/errors"
+)
+
+const (
+	// conflictRetries 读-改-写遇到版本冲突时的最大尝试次数
+	conflictRetries = 5
+	// conflictBackoff 冲突重试的基础退避时间，按尝试次数线性增加并叠加随机抖动
+	conflictBackoff = 20 * time.Millisecond
+)
+
+// partialUpdateRetries 局部更新由 OpenSearch 在服务端读取合并，冲突时服务端重试的次数
+var partialUpdateRetries = 3
+
+// RetryOnConflict 执行一次完整的读-改-写，fn 返回 domain.ErrVersionConflict 时退避后重新执行。
+// fn 必须在内部重新读取文档，否则重试只会再次冲突。
+func RetryOnConflict(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= conflictRetries; attempt++ {
+		if err = fn(); !errors.Is(err, domain.ErrVersionConflict) {
+			return err
+		}
+		if attempt == conflictRetries {
+			break
+		}
+		backoff := time.Duration(attempt)*conflictBackoff + time.Duration(rand.Int63n(int64(conflictBackoff)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+	return errors.Wrapf(err, "重试 %d 次后仍然冲突", conflictRetries)
+}
@@ -0,0 +1,264 @@
+package opensearch
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch/opensearchtest"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/slice"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRetryOnConflict(t *testing.T) {
+	Convey("TestRetryOnConflict", t, func() {
+		ctx := context.Background()
+
+		Convey("首次成功不重试", func() {
+			calls := 0
+			err := RetryOnConflict(ctx, func() error {
+				calls++
+				return nil
+			})
+
+			So(err, ShouldBeNil)
+			So(calls, ShouldEqual, 1)
+		})
+
+		Convey("冲突后重试成功", func() {
+			calls := 0
+			err := RetryOnConflict(ctx, func() error {
+				calls++
+				if calls < 3 {
+					return errors.Wrap(domain.ErrVersionConflict, "problem/1")
+				}
+				return nil
+			})
+
+			So(err, ShouldBeNil)
+			So(calls, ShouldEqual, 3)
+		})
+
+		Convey("持续冲突达到上限后返回冲突错误", func() {
+			calls := 0
+			err := RetryOnConflict(ctx, func() error {
+				calls++
+				return domain.ErrVersionConflict
+			})
+
+			So(calls, ShouldEqual, conflictRetries)
+			So(errors.Is(err, domain.ErrVersionConflict), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "仍然冲突")
+		})
+
+		Convey("非冲突错误直接返回", func() {
+			calls := 0
+			failed := errors.New("index failed")
+			err := RetryOnConflict(ctx, func() error {
+				calls++
+				return failed
+			})
+
+			So(err, ShouldEqual, failed)
+			So(calls, ShouldEqual, 1)
+		})
+
+		Convey("上下文取消时停止重试", func() {
+			cancelCtx, cancel := context.WithCancel(ctx)
+			err := RetryOnConflict(cancelCtx, func() error {
+				cancel()
+				return domain.ErrVersionConflict
+			})
+
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+		})
+	})
+}
+
+func TestDocVersion_Decode(t *testing.T) {
+	Convey("TestDocVersion_Decode", t, func() {
+		Convey("mget 返回的文档携带版本", func() {
+			data := []byte(`{"docs":[{"_id":"1","found":true,"_seq_no":7,"_primary_term":2,"_source":{"problem_id":1}}]}`)
+
+			problems, err := decodeMGet[domain.Problem](data)
+
+			So(err, ShouldBeNil)
+			So(problems, ShouldHaveLength, 1)
+			So(problems[0].SeqNo, ShouldEqual, 7)
+			So(problems[0].PrimaryTerm, ShouldEqual, 2)
+			So(problems[0].Versioned(), ShouldBeTrue)
+		})
+
+		Convey("search 命中的文档携带版本", func() {
+			data := []byte(`{"hits":{"hits":[{"_id":"10","_seq_no":3,"_primary_term":1,"_source":{"fault_id":10}}]}}`)
+
+			faultPoints, err := decodeSearch[domain.FaultPointObject](data)
+
+			So(err, ShouldBeNil)
+			So(faultPoints, ShouldHaveLength, 1)
+			So(faultPoints[0].SeqNo, ShouldEqual, 3)
+			So(faultPoints[0].PrimaryTerm, ShouldEqual, 1)
+		})
+
+		Convey("新建文档没有版本", func() {
+			So(domain.Problem{ProblemID: 1}.Versioned(), ShouldBeFalse)
+		})
+	})
+}
+
+func TestProblemStore_OptimisticLocking(t *testing.T) {
+	Convey("TestProblemStore_OptimisticLocking", t, func() {
+		ctx := context.Background()
+		transport := opensearchtest.NewTransport()
+		store := NewProblemStore(opensearchtest.NewClient(transport))
+		So(store.Upsert(ctx, domain.Problem{ProblemID: 1, ProblemStatus: domain.ProblemStatusOpen}), ShouldBeNil)
+
+		read := func() domain.Problem {
+			problems, err := store.QueryByIDs(ctx, []uint64{1})
+			So(err, ShouldBeNil)
+			So(problems, ShouldHaveLength, 1)
+			return problems[0]
+		}
+
+		Convey("新建的问题 ID 已存在时返回版本冲突，不覆盖已有问题", func() {
+			err := store.Upsert(ctx, domain.Problem{ProblemID: 1, ProblemStatus: domain.ProblemStatusClosed})
+
+			So(errors.Is(err, domain.ErrVersionConflict), ShouldBeTrue)
+			So(read().ProblemStatus, ShouldEqual, domain.ProblemStatusOpen)
+		})
+
+		Convey("按版本局部更新时读取后被修改返回版本冲突", func() {
+			stale := read()
+			So(store.MarkClosed(ctx, 1, domain.ProblemCloseTypeSystem, domain.ProblemStatusClosed, 60, "所有故障点已恢复", "system"), ShouldBeNil)
+
+			err := store.update(ctx, 1, &stale.DocVersion, map[string]any{"rca_status": domain.RcaStatusRunning})
+
+			So(errors.Is(err, domain.ErrVersionConflict), ShouldBeTrue)
+			So(read().RcaStatus, ShouldNotEqual, domain.RcaStatusRunning)
+		})
+
+		Convey("基于过期版本的写入返回版本冲突", func() {
+			a, b := read(), read()
+			a.RelationEventIDs = []uint64{100}
+			b.RelationEventIDs = []uint64{200}
+
+			So(store.Upsert(ctx, a), ShouldBeNil)
+			err := store.Upsert(ctx, b)
+
+			So(errors.Is(err, domain.ErrVersionConflict), ShouldBeTrue)
+			So(transport.Conflicts(), ShouldEqual, 1)
+			So(read().RelationEventIDs, ShouldResemble, []uint64{100})
+		})
+
+		Convey("局部更新后基于旧版本的全量写入冲突，不会覆盖关闭状态", func() {
+			stale := read()
+			So(store.MarkClosed(ctx, 1, domain.ProblemCloseTypeSystem, domain.ProblemStatusClosed, 60, "所有故障点已恢复", "system"), ShouldBeNil)
+
+			stale.RelationEventIDs = []uint64{100}
+			err := store.Upsert(ctx, stale)
+
+			So(errors.Is(err, domain.ErrVersionConflict), ShouldBeTrue)
+			So(read().ProblemStatus, ShouldEqual, domain.ProblemStatusClosed)
+		})
+
+		Convey("并发追加事件经冲突重试后全部保留", func() {
+			transport.Latency = time.Millisecond
+			eventIDs := []uint64{101, 102, 103, 104, 105}
+
+			var wg sync.WaitGroup
+			errs := make([]error, len(eventIDs))
+			for i, eventID := range eventIDs {
+				wg.Add(1)
+				go func(i int, eventID uint64) {
+					defer wg.Done()
+					errs[i] = RetryOnConflict(ctx, func() error {
+						problems, err := store.QueryByIDs(ctx, []uint64{1})
+						if err != nil {
+							return err
+						}
+						p := problems[0]
+						p.RelationEventIDs = slice.AppendUniqueUint64(p.RelationEventIDs, eventID)
+						return store.Upsert(ctx, p)
+					})
+				}(i, eventID)
+			}
+			wg.Wait()
+
+			for _, err := range errs {
+				So(err, ShouldBeNil)
+			}
+			So(read().RelationEventIDs, ShouldHaveLength, len(eventIDs))
+			for _, eventID := range eventIDs {
+				So(read().RelationEventIDs, ShouldContain, eventID)
+			}
+		})
+	})
+}
+
+func TestFaultPointStore_OptimisticLocking(t *testing.T) {
+	Convey("TestFaultPointStore_OptimisticLocking", t, func() {
+		ctx := context.Background()
+		transport := opensearchtest.NewTransport()
+		store := NewFaultPointStore(opensearchtest.NewClient(transport))
+		So(store.Upsert(ctx, domain.FaultPointObject{FaultID: 10, FaultStatus: domain.FaultStatusOccurred}), ShouldBeNil)
+
+		read := func() domain.FaultPointObject {
+			faultPoints, err := store.QueryByIDs(ctx, []uint64{10})
+			So(err, ShouldBeNil)
+			So(faultPoints, ShouldHaveLength, 1)
+			return faultPoints[0]
+		}
+
+		Convey("新建的故障点 ID 已存在时返回版本冲突，不覆盖已有故障点", func() {
+			err := store.Upsert(ctx, domain.FaultPointObject{FaultID: 10, FaultStatus: domain.FaultStatusRecovered})
+
+			So(errors.Is(err, domain.ErrVersionConflict), ShouldBeTrue)
+			So(read().FaultStatus, ShouldEqual, domain.FaultStatusOccurred)
+		})
+
+		Convey("恢复后基于旧版本的写入冲突，不会覆盖恢复状态", func() {
+			stale := read()
+			So(store.MakeRecovered(ctx, 10, time.Now()), ShouldBeNil)
+
+			stale.RelationEventIDs = []uint64{100}
+			err := store.Upsert(ctx, stale)
+
+			So(errors.Is(err, domain.ErrVersionConflict), ShouldBeTrue)
+			So(read().FaultStatus, ShouldEqual, domain.FaultStatusRecovered)
+		})
+
+		Convey("并发合并事件经冲突重试后全部保留", func() {
+			transport.Latency = time.Millisecond
+			eventIDs := []uint64{101, 102, 103, 104, 105}
+
+			var wg sync.WaitGroup
+			errs := make([]error, len(eventIDs))
+			for i, eventID := range eventIDs {
+				wg.Add(1)
+				go func(i int, eventID uint64) {
+					defer wg.Done()
+					errs[i] = RetryOnConflict(ctx, func() error {
+						faultPoints, err := store.QueryByIDs(ctx, []uint64{10})
+						if err != nil {
+							return err
+						}
+						fp := faultPoints[0]
+						fp.RelationEventIDs = slice.AppendUniqueUint64(fp.RelationEventIDs, eventID)
+						return store.Upsert(ctx, fp)
+					})
+				}(i, eventID)
+			}
+			wg.Wait()
+
+			for _, err := range errs {
+				So(err, ShouldBeNil)
+			}
+			fp := read()
+			So(fp.RelationEventIDs, ShouldHaveLength, len(eventIDs))
+			So(transport.Conflicts(), ShouldBeGreaterThan, 0)
+		})
+	})
+}
@@ -8,9 +8,13 @@ const (
 	ProblemIndexBase             = "itops_problem"
 	faultCausalObjectIndexBase   = "itops_fault_causal"
 	faultCausalRelationIndexBase = "itops_fault_causal_relation"
+	DeadLetterIndexBase          = "itops_dead_letter"
+	CorrelationRecordIndexBase   = "itops_correlation_record"
+	RCAJobIndexBase              = "itops_rca_job"
 
-	maxQuerySize = 5000
-	indexPrefix  = "mdl-"
+	maxQuerySize        = 5000
+	entityClassPageSize = 1000 // 按对象类分页查询故障点的每页数量
+	indexPrefix         = "mdl-"
 )
 
 // 实际索引名称
@@ -21,4 +25,7 @@ var (
 	ProblemIndex             = indexPrefix + ProblemIndexBase
 	faultCausalObjectIndex   = indexPrefix + faultCausalObjectIndexBase
 	faultCausalRelationIndex = indexPrefix + faultCausalRelationIndexBase
+	DeadLetterIndex          = indexPrefix + DeadLetterIndexBase
+	CorrelationRecordIndex   = indexPrefix + CorrelationRecordIndexBase
+	RCAJobIndex              = indexPrefix + RCAJobIndexBase
 )
@@ -0,0 +1,149 @@
+package opensearch
+
+import (
+	"context"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/core"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	opensearchsdk "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// CorrelationRecordStore 负责 itops_correlation_record 索引的全部操作。
+type CorrelationRecordStore struct {
+	client *opensearchsdk.Client
+}
+
+// correlationRecordDocument 包装 CorrelationRecord 并补充索引所需的公共字段。
+type correlationRecordDocument struct {
+	domain.CorrelationRecord
+	Timestamp time.Time `json:"@timestamp"`
+	WriteTime time.Time `json:"__write_time"`
+	DataType  string    `json:"__data_type"`
+	IndexBase string    `json:"__index_base"`
+	Category  string    `json:"category"`
+	Type      string    `json:"type"`
+	ID        string    `json:"__id"`
+}
+
+// NewCorrelationRecordStore 创建关联记录存储实例。
+func NewCorrelationRecordStore(client *opensearchsdk.Client) *CorrelationRecordStore {
+	return &CorrelationRecordStore{client: client}
+}
+
+// Upsert 写入或覆盖关联记录。
+func (s *CorrelationRecordStore) Upsert(ctx context.Context, record domain.CorrelationRecord) error {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "CorrelationRecordStore.Upsert",
+			"index", CorrelationRecordIndex,
+			"document_id", record.RecordID,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	if s.client == nil {
+		return errors.New("opensearch client 未初始化")
+	}
+	if record.RecordID == 0 {
+		return errors.New("record_id 不能为空")
+	}
+
+	doc := correlationRecordDocument{
+		CorrelationRecord: record,
+		Timestamp:         record.CreateTime,
+		WriteTime:         time.Now().Local(),
+		DataType:          CorrelationRecordIndexBase,
+		IndexBase:         CorrelationRecordIndexBase,
+		Category:          "log",
+		Type:              CorrelationRecordIndexBase,
+		ID:                cast.ToString(record.RecordID),
+	}
+
+	body, err := encodeBody(doc)
+	if err != nil {
+		return err
+	}
+
+	req := opensearchapi.IndexRequest{
+		Index:      CorrelationRecordIndex,
+		DocumentID: cast.ToString(record.RecordID),
+		Body:       body,
+		Refresh:    "wait_for",
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return errors.Wrap(err, "写入 CorrelationRecord 失败")
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.IsError() {
+		data, _ := readResponseBody(res.Body)
+		return formatErrorMessage(data)
+	}
+	return nil
+}
+
+// ListByProblemIDs 查询关联到指定问题的记录，按创建时间正序。
+func (s *CorrelationRecordStore) ListByProblemIDs(ctx context.Context, problemIDs []uint64) ([]domain.CorrelationRecord, error) {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "CorrelationRecordStore.ListByProblemIDs",
+			"index", CorrelationRecordIndex,
+			"ids_count", len(problemIDs),
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	if s.client == nil {
+		return nil, errors.New("opensearch client 未初始化")
+	}
+	if len(problemIDs) == 0 {
+		return nil, nil
+	}
+
+	body, err := encodeBody(map[string]any{
+		"size": maxQuerySize,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": []any{
+					map[string]any{"terms": map[string]any{"problem_id": problemIDs}},
+				},
+			},
+		},
+		"sort": []any{
+			map[string]any{"create_time": map[string]any{"order": "asc", "unmapped_type": "date"}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req := opensearchapi.SearchRequest{
+		Index: []string{CorrelationRecordIndex},
+		Body:  body,
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询 CorrelationRecord 失败")
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.IsError() {
+		data, _ := readResponseBody(res.Body)
+		return nil, formatErrorMessage(data)
+	}
+	data, err := readResponseBody(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSearch[domain.CorrelationRecord](data)
+}
+
+var _ core.CorrelationRecordRepository = (*CorrelationRecordStore)(nil)
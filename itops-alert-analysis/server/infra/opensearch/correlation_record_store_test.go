@@ -0,0 +1,114 @@
+package opensearch
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCorrelationRecordStore_Upsert(t *testing.T) {
+	Convey("TestCorrelationRecordStore_Upsert", t, func() {
+		ctx := context.Background()
+
+		Convey("client 为 nil 返回错误", func() {
+			store := &CorrelationRecordStore{client: nil}
+
+			err := store.Upsert(ctx, domain.CorrelationRecord{RecordID: 1})
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "opensearch client 未初始化")
+		})
+
+		Convey("record_id 为空返回错误", func() {
+			store := NewCorrelationRecordStore(newMockClient(200, `{}`))
+
+			err := store.Upsert(ctx, domain.CorrelationRecord{})
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "record_id 不能为空")
+		})
+
+		Convey("成功写入关联记录", func() {
+			store := NewCorrelationRecordStore(newMockClient(201, `{"result": "created"}`))
+
+			err := store.Upsert(ctx, domain.CorrelationRecord{
+				RecordID:   1,
+				RecordType: domain.CorrelationRecordFaultPoint,
+				ProblemID:  100,
+				FaultID:    10,
+				Strategy:   "spatial",
+				Score:      0.6,
+				EntityPath: []string{"pod-1", "node-1"},
+				CreateTime: time.Now(),
+			})
+
+			So(err, ShouldBeNil)
+		})
+
+		Convey("写入失败返回错误", func() {
+			store := NewCorrelationRecordStore(newMockClientWithError(io.ErrUnexpectedEOF))
+
+			err := store.Upsert(ctx, domain.CorrelationRecord{RecordID: 1})
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "写入 CorrelationRecord 失败")
+		})
+	})
+}
+
+func TestCorrelationRecordStore_ListByProblemIDs(t *testing.T) {
+	Convey("TestCorrelationRecordStore_ListByProblemIDs", t, func() {
+		ctx := context.Background()
+
+		Convey("client 为 nil 返回错误", func() {
+			store := &CorrelationRecordStore{client: nil}
+
+			result, err := store.ListByProblemIDs(ctx, []uint64{1})
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+		})
+
+		Convey("problemIDs 为空返回 nil", func() {
+			store := NewCorrelationRecordStore(newMockClient(200, `{}`))
+
+			result, err := store.ListByProblemIDs(ctx, nil)
+
+			So(err, ShouldBeNil)
+			So(result, ShouldBeNil)
+		})
+
+		Convey("按问题查询关联记录", func() {
+			body := `{
+				"hits": {
+					"hits": [
+						{"_source": {"record_id": 1, "record_type": "problem_merge", "problem_id": 100, "source_problem_id": 200, "entity_path": ["pod-1", "node-1"]}}
+					]
+				}
+			}`
+			store := NewCorrelationRecordStore(newMockClient(200, body))
+
+			result, err := store.ListByProblemIDs(ctx, []uint64{100})
+
+			So(err, ShouldBeNil)
+			So(result, ShouldHaveLength, 1)
+			So(result[0].RecordType, ShouldEqual, domain.CorrelationRecordProblemMerge)
+			So(result[0].SourceProblemID, ShouldEqual, 200)
+			So(result[0].EntityPath, ShouldResemble, []string{"pod-1", "node-1"})
+		})
+
+		Convey("查询失败返回错误", func() {
+			store := NewCorrelationRecordStore(newMockClientWithError(io.ErrUnexpectedEOF))
+
+			result, err := store.ListByProblemIDs(ctx, []uint64{1})
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+			So(err.Error(), ShouldContainSubstring, "查询 CorrelationRecord 失败")
+		})
+	})
+}
@@ -0,0 +1,228 @@
+package opensearch
+
+import (
+	"context"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/core"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	opensearchsdk "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// defaultDeadLetterPageSize 死信列表默认分页大小
+const defaultDeadLetterPageSize = 20
+
+// DeadLetterStore 负责 itops_dead_letter 索引的全部操作。
+type DeadLetterStore struct {
+	client *opensearchsdk.Client
+}
+
+// deadLetterDocument 包装 DeadLetter 并补充索引所需的公共字段。
+type deadLetterDocument struct {
+	domain.DeadLetter
+	Timestamp time.Time `json:"@timestamp"`
+	WriteTime time.Time `json:"__write_time"`
+	DataType  string    `json:"__data_type"`
+	IndexBase string    `json:"__index_base"`
+	Category  string    `json:"category"`
+	Type      string    `json:"type"`
+	ID        string    `json:"__id"`
+}
+
+// NewDeadLetterStore 创建死信存储实例。
+func NewDeadLetterStore(client *opensearchsdk.Client) *DeadLetterStore {
+	return &DeadLetterStore{client: client}
+}
+
+// Upsert 写入或覆盖死信。
+func (s *DeadLetterStore) Upsert(ctx context.Context, dl domain.DeadLetter) error {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "DeadLetterStore.Upsert",
+			"index", DeadLetterIndex,
+			"document_id", dl.DeadLetterID,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	if s.client == nil {
+		return errors.New("opensearch client 未初始化")
+	}
+	if dl.DeadLetterID == 0 {
+		return errors.New("dead_letter_id 不能为空")
+	}
+
+	doc := deadLetterDocument{
+		DeadLetter: dl,
+		Timestamp:  dl.FailedTime,
+		WriteTime:  time.Now().Local(),
+		DataType:   DeadLetterIndexBase,
+		IndexBase:  DeadLetterIndexBase,
+		Category:   "log",
+		Type:       DeadLetterIndexBase,
+		ID:         cast.ToString(dl.DeadLetterID),
+	}
+
+	body, err := encodeBody(doc)
+	if err != nil {
+		return err
+	}
+
+	req := opensearchapi.IndexRequest{
+		Index:      DeadLetterIndex,
+		DocumentID: cast.ToString(dl.DeadLetterID),
+		Body:       body,
+		Refresh:    "wait_for",
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return errors.Wrap(err, "写入 DeadLetter 失败")
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.IsError() {
+		data, _ := readResponseBody(res.Body)
+		return formatErrorMessage(data)
+	}
+	return nil
+}
+
+// QueryByIDs 按死信 ID 批量查询。
+func (s *DeadLetterStore) QueryByIDs(ctx context.Context, ids []uint64) ([]domain.DeadLetter, error) {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "DeadLetterStore.QueryByIDs",
+			"index", DeadLetterIndex,
+			"ids_count", len(ids),
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	if s.client == nil {
+		return nil, errors.New("opensearch client 未初始化")
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	strIDs := make([]string, len(ids))
+	for i, id := range ids {
+		strIDs[i] = cast.ToString(id)
+	}
+
+	body, err := encodeBody(map[string]any{"ids": strIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	req := opensearchapi.MgetRequest{
+		Index: DeadLetterIndex,
+		Body:  body,
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询 DeadLetter 失败")
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.IsError() {
+		data, _ := readResponseBody(res.Body)
+		return nil, formatErrorMessage(data)
+	}
+	data, err := readResponseBody(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeMGet[domain.DeadLetter](data)
+}
+
+// List 按条件分页查询死信，按失败时间倒序。
+func (s *DeadLetterStore) List(ctx context.Context, q domain.DeadLetterQuery) ([]domain.DeadLetter, error) {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "DeadLetterStore.List",
+			"index", DeadLetterIndex,
+			"stage", q.Stage,
+			"status", q.Status,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	if s.client == nil {
+		return nil, errors.New("opensearch client 未初始化")
+	}
+
+	filters := []any{}
+	if q.Stage != "" {
+		filters = append(filters, map[string]any{"term": map[string]any{"stage.keyword": q.Stage}})
+	}
+	if q.Status != "" {
+		filters = append(filters, map[string]any{"term": map[string]any{"status.keyword": q.Status}})
+	}
+	if q.EventSource != "" {
+		filters = append(filters, map[string]any{"term": map[string]any{"event_source.keyword": q.EventSource}})
+	}
+	if q.StartTime != nil || q.EndTime != nil {
+		rng := map[string]any{}
+		if q.StartTime != nil {
+			rng["gte"] = q.StartTime.Local()
+		}
+		if q.EndTime != nil {
+			rng["lte"] = q.EndTime.Local()
+		}
+		filters = append(filters, map[string]any{"range": map[string]any{"failed_time": rng}})
+	}
+
+	size := q.Size
+	if size <= 0 {
+		size = defaultDeadLetterPageSize
+	}
+	if size > maxQuerySize {
+		size = maxQuerySize
+	}
+
+	body, err := encodeBody(map[string]any{
+		"from": q.From,
+		"size": size,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": filters,
+			},
+		},
+		"sort": []any{
+			map[string]any{"failed_time": map[string]any{"order": "desc", "unmapped_type": "date"}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req := opensearchapi.SearchRequest{
+		Index: []string{DeadLetterIndex},
+		Body:  body,
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询 DeadLetter 列表失败")
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.IsError() {
+		data, _ := readResponseBody(res.Body)
+		return nil, formatErrorMessage(data)
+	}
+	data, err := readResponseBody(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSearch[domain.DeadLetter](data)
+}
+
+var _ core.DeadLetterRepository = (*DeadLetterStore)(nil)
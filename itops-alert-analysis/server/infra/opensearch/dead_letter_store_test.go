@@ -0,0 +1,166 @@
+package opensearch
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewDeadLetterStore(t *testing.T) {
+	Convey("TestNewDeadLetterStore", t, func() {
+		Convey("成功创建 DeadLetterStore", func() {
+			client := newMockClient(200, `{}`)
+			store := NewDeadLetterStore(client)
+
+			So(store, ShouldNotBeNil)
+			So(store.client, ShouldEqual, client)
+		})
+	})
+}
+
+func TestDeadLetterStore_Upsert(t *testing.T) {
+	Convey("TestDeadLetterStore_Upsert", t, func() {
+		ctx := context.Background()
+
+		Convey("client 为 nil 返回错误", func() {
+			store := &DeadLetterStore{client: nil}
+
+			err := store.Upsert(ctx, domain.DeadLetter{DeadLetterID: 1})
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "opensearch client 未初始化")
+		})
+
+		Convey("dead_letter_id 为空返回错误", func() {
+			store := NewDeadLetterStore(newMockClient(200, `{}`))
+
+			err := store.Upsert(ctx, domain.DeadLetter{})
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "dead_letter_id 不能为空")
+		})
+
+		Convey("成功写入 DeadLetter", func() {
+			store := NewDeadLetterStore(newMockClient(201, `{"result": "created"}`))
+
+			err := store.Upsert(ctx, domain.DeadLetter{
+				DeadLetterID: 1,
+				Stage:        domain.DeadLetterStageStandardize,
+				Status:       domain.DeadLetterStatusPending,
+				FailedTime:   time.Now(),
+			})
+
+			So(err, ShouldBeNil)
+		})
+
+		Convey("写入失败返回错误", func() {
+			store := NewDeadLetterStore(newMockClientWithError(io.ErrUnexpectedEOF))
+
+			err := store.Upsert(ctx, domain.DeadLetter{DeadLetterID: 1})
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "写入 DeadLetter 失败")
+		})
+
+		Convey("OpenSearch 返回错误状态", func() {
+			store := NewDeadLetterStore(newMockClient(400, `{"error": {"reason": "bad request"}}`))
+
+			err := store.Upsert(ctx, domain.DeadLetter{DeadLetterID: 1})
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestDeadLetterStore_QueryByIDs(t *testing.T) {
+	Convey("TestDeadLetterStore_QueryByIDs", t, func() {
+		ctx := context.Background()
+
+		Convey("ids 为空返回 nil", func() {
+			store := NewDeadLetterStore(newMockClient(200, `{}`))
+
+			result, err := store.QueryByIDs(ctx, nil)
+
+			So(err, ShouldBeNil)
+			So(result, ShouldBeNil)
+		})
+
+		Convey("成功查询 DeadLetter", func() {
+			body := `{
+				"docs": [
+					{"found": true, "_source": {"dead_letter_id": 1, "stage": "standardize", "payload": "{}"}},
+					{"found": false}
+				]
+			}`
+			store := NewDeadLetterStore(newMockClient(200, body))
+
+			result, err := store.QueryByIDs(ctx, []uint64{1, 2})
+
+			So(err, ShouldBeNil)
+			So(result, ShouldHaveLength, 1)
+			So(result[0].Stage, ShouldEqual, domain.DeadLetterStageStandardize)
+		})
+
+		Convey("查询失败返回错误", func() {
+			store := NewDeadLetterStore(newMockClientWithError(io.ErrUnexpectedEOF))
+
+			result, err := store.QueryByIDs(ctx, []uint64{1})
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+			So(err.Error(), ShouldContainSubstring, "查询 DeadLetter 失败")
+		})
+	})
+}
+
+func TestDeadLetterStore_List(t *testing.T) {
+	Convey("TestDeadLetterStore_List", t, func() {
+		ctx := context.Background()
+
+		Convey("client 为 nil 返回错误", func() {
+			store := &DeadLetterStore{client: nil}
+
+			result, err := store.List(ctx, domain.DeadLetterQuery{})
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+		})
+
+		Convey("按条件查询死信", func() {
+			body := `{
+				"hits": {
+					"hits": [
+						{"_source": {"dead_letter_id": 1, "stage": "process", "status": "pending"}}
+					]
+				}
+			}`
+			store := NewDeadLetterStore(newMockClient(200, body))
+			start := time.Now().Add(-time.Hour)
+
+			result, err := store.List(ctx, domain.DeadLetterQuery{
+				Stage:     domain.DeadLetterStageProcess,
+				Status:    domain.DeadLetterStatusPending,
+				StartTime: &start,
+				Size:      100000,
+			})
+
+			So(err, ShouldBeNil)
+			So(result, ShouldHaveLength, 1)
+			So(result[0].Status, ShouldEqual, domain.DeadLetterStatusPending)
+		})
+
+		Convey("查询失败返回错误", func() {
+			store := NewDeadLetterStore(newMockClientWithError(io.ErrUnexpectedEOF))
+
+			result, err := store.List(ctx, domain.DeadLetterQuery{})
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+			So(err.Error(), ShouldContainSubstring, "查询 DeadLetter 列表失败")
+		})
+	})
+}
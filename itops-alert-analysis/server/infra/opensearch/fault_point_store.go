@@ -37,13 +37,14 @@ func NewFaultPointStore(client *opensearchsdk.Client) *FaultPointStore {
 	return &FaultPointStore{client: client}
 }
 
-func (s *FaultPointStore) FindOpenByEntityAndMode(ctx context.Context, entityObjectID, failureMode string, t time.Time) (*domain.FaultPointObject, error) {
+// FindOpenByEntityAndKey 查询同一实体、同一收敛键下未关闭的故障点。
+func (s *FaultPointStore) FindOpenByEntityAndKey(ctx context.Context, entityObjectID, faultKey string, t time.Time) (*domain.FaultPointObject, error) {
 	defer func(start time.Time) {
 		log.Debugw("OpenSearch",
-			"operation", "FaultPointStore.FindOpenByEntityAndMode",
+			"operation", "FaultPointStore.FindOpenByEntityAndKey",
 			"index", FaultPointIndexObject,
 			"entity_object_id", entityObjectID,
-			"failure_mode", failureMode,
+			"fault_key", faultKey,
 			"duration_ms", time.Since(start).Milliseconds(),
 		)
 	}(time.Now())
@@ -51,12 +52,12 @@ func (s *FaultPointStore) FindOpenByEntityAndMode(ctx context.Context, entityObj
 	if s.client == nil {
 		return nil, errors.New("opensearch client 未初始化")
 	}
-	if entityObjectID == "" || failureMode == "" {
-		return nil, errors.New("entityObjectID or failureMode is empty")
+	if entityObjectID == "" || faultKey == "" {
+		return nil, errors.New("entityObjectID or faultKey is empty")
 	}
 	filters := []any{
 		map[string]any{"term": map[string]any{"entity_object_id.keyword": entityObjectID}},
-		map[string]any{"term": map[string]any{"fault_mode.keyword": failureMode}},
+		faultKeyFilter(faultKey),
 		// 仅查询未关闭的故障点（occurred）。
 		map[string]any{"term": map[string]any{"fault_status.keyword": domain.FaultStatusOccurred}},
 		map[string]any{
@@ -67,7 +68,8 @@ func (s *FaultPointStore) FindOpenByEntityAndMode(ctx context.Context, entityObj
 	}
 
 	body, err := encodeBody(map[string]any{
-		"size": 1,
+		"seq_no_primary_term": true,
+		"size":                1,
 		"query": map[string]any{
 			"bool": map[string]any{
 				"filter": filters,
@@ -156,6 +158,15 @@ func (s *FaultPointStore) Upsert(ctx context.Context, fp domain.FaultPointObject
 		Body:       body,
 		Refresh:    "wait_for",
 	}
+	if fp.Versioned() {
+		// 乐观并发控制：读取后文档已被修改时写入失败，由调用方重新读取后重试
+		seqNo, primaryTerm := int(fp.SeqNo), int(fp.PrimaryTerm)
+		req.IfSeqNo = &seqNo
+		req.IfPrimaryTerm = &primaryTerm
+	} else {
+		// 新建文档：同 ID 文档已存在时写入失败，避免覆盖并发创建或未经读取的文档
+		req.OpType = "create"
+	}
 	res, err := req.Do(ctx, s.client)
 	if err != nil {
 		return errors.Wrap(err, "写入 FaultPointObject 失败")
@@ -163,6 +174,9 @@ func (s *FaultPointStore) Upsert(ctx context.Context, fp domain.FaultPointObject
 	defer func() {
 		_ = res.Body.Close()
 	}()
+	if err := versionConflict(res, FaultPointIndexObject, fp.FaultID); err != nil {
+		return err
+	}
 	if res.IsError() {
 		data, _ := readResponseBody(res.Body)
 		return formatErrorMessage(data)
@@ -290,10 +304,11 @@ func (s *FaultPointStore) partialUpdate(ctx context.Context, id uint64, doc map[
 		return err
 	}
 	req := opensearchapi.UpdateRequest{
-		Index:      FaultPointIndexObject,
-		DocumentID: cast.ToString(id),
-		Body:       body,
-		Refresh:    "wait_for",
+		Index:           FaultPointIndexObject,
+		DocumentID:      cast.ToString(id),
+		Body:            body,
+		Refresh:         "wait_for",
+		RetryOnConflict: &partialUpdateRetries,
 	}
 	res, err := req.Do(ctx, s.client)
 	if err != nil {
@@ -407,7 +422,8 @@ func (s *FaultPointStore) FindByEventID(ctx context.Context, eventID uint64) (*d
 
 	// 查询 related_event_ids 数组中包含 eventID 的故障点
 	body, err := encodeBody(map[string]any{
-		"size": 1,
+		"seq_no_primary_term": true,
+		"size":                1,
 		"query": map[string]any{
 			"term": map[string]any{
 				"relation_event_ids": eventID,
@@ -446,13 +462,14 @@ func (s *FaultPointStore) FindByEventID(ctx context.Context, eventID uint64) (*d
 	return &result[0], nil
 }
 
-func (s *FaultPointStore) FindInWindow(ctx context.Context, entityID string, faultMode string, start, end time.Time) ([]domain.FaultPointObject, error) {
+// FindInWindow 查询同一实体、同一收敛键下最新时间落在 [start, end] 内的故障点。
+func (s *FaultPointStore) FindInWindow(ctx context.Context, entityID string, faultKey string, start, end time.Time) ([]domain.FaultPointObject, error) {
 	defer func(t time.Time) {
 		log.Debugw("OpenSearch",
 			"operation", "FaultPointStore.FindInWindow",
 			"index", FaultPointIndexObject,
 			"entity_id", entityID,
-			"fault_mode", faultMode,
+			"fault_key", faultKey,
 			"duration_ms", time.Since(t).Milliseconds(),
 		)
 	}(time.Now())
@@ -464,16 +481,152 @@ func (s *FaultPointStore) FindInWindow(ctx context.Context, entityID string, fau
 		return nil, errors.New("entityID 不能为空")
 	}
 	filters := []any{
-		map[string]any{"term": map[string]any{"entity_object_id": entityID}},
-		map[string]any{"term": map[string]any{"fault_mode": faultMode}},
+		map[string]any{"term": map[string]any{"entity_object_id.keyword": entityID}},
+		faultKeyFilter(faultKey),
 		map[string]any{"range": map[string]any{"fault_latest_time": map[string]any{"gte": start, "lte": end}}},
 	}
 	body, err := encodeBody(map[string]any{
+		"seq_no_primary_term": true,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": filters,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req := opensearchapi.SearchRequest{
+		Index: []string{FaultPointIndexObject},
+		Body:  body,
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询 FaultPointObject 失败")
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.IsError() {
+		data, _ := readResponseBody(res.Body)
+		return nil, formatErrorMessage(data)
+	}
+	data, err := readResponseBody(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSearch[domain.FaultPointObject](data)
+}
+
+// FindByEntityClass 分页查询指定实体对象类下未失效的故障点，按 fault_id 升序返回 afterFaultID 之后的一页（最多 entityClassPageSize 个）。
+// 调用方以上一页最后一个故障点的 fault_id 继续查询，直到返回空页。
+func (s *FaultPointStore) FindByEntityClass(ctx context.Context, entityObjectClass string, afterFaultID uint64) ([]domain.FaultPointObject, error) {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "FaultPointStore.FindByEntityClass",
+			"index", FaultPointIndexObject,
+			"entity_object_class", entityObjectClass,
+			"after_fault_id", afterFaultID,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	if s.client == nil {
+		return nil, errors.New("opensearch client 未初始化")
+	}
+
+	query := map[string]any{
+		"seq_no_primary_term": true,
+		"size":                entityClassPageSize,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": []any{
+					map[string]any{"term": map[string]any{"entity_object_class.keyword": entityObjectClass}},
+				},
+				"must_not": []any{
+					map[string]any{"term": map[string]any{"fault_status.keyword": domain.FaultStatusExpired}},
+				},
+			},
+		},
+		"sort": []any{
+			map[string]any{"fault_id": map[string]any{"order": "asc"}},
+		},
+	}
+	if afterFaultID > 0 {
+		query["search_after"] = []any{afterFaultID}
+	}
+	body, err := encodeBody(query)
+	if err != nil {
+		return nil, err
+	}
+
+	req := opensearchapi.SearchRequest{
+		Index: []string{FaultPointIndexObject},
+		Body:  body,
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return nil, errors.Wrap(err, "按对象类查询故障点失败")
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.IsError() {
+		data, _ := readResponseBody(res.Body)
+		return nil, formatErrorMessage(data)
+	}
+	data, err := readResponseBody(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSearch[domain.FaultPointObject](data)
+}
+
+// FindLatestByEntityAndKey 查询同一实体、同一收敛键下最近的未失效故障点（含已恢复），用于抖动期间复用故障点。
+func (s *FaultPointStore) FindLatestByEntityAndKey(ctx context.Context, entityObjectID, faultKey string, t time.Time) (*domain.FaultPointObject, error) {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "FaultPointStore.FindLatestByEntityAndKey",
+			"index", FaultPointIndexObject,
+			"entity_object_id", entityObjectID,
+			"fault_key", faultKey,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	if s.client == nil {
+		return nil, errors.New("opensearch client 未初始化")
+	}
+	if entityObjectID == "" || faultKey == "" {
+		return nil, errors.New("entityObjectID or faultKey is empty")
+	}
+	filters := []any{
+		map[string]any{"term": map[string]any{"entity_object_id.keyword": entityObjectID}},
+		faultKeyFilter(faultKey),
+		map[string]any{"terms": map[string]any{"fault_status.keyword": []domain.FaultStatus{domain.FaultStatusOccurred, domain.FaultStatusRecovered}}},
+		map[string]any{
+			"range": map[string]any{
+				"fault_latest_time": map[string]any{"gte": t},
+			},
+		},
+	}
+
+	body, err := encodeBody(map[string]any{
+		"seq_no_primary_term": true,
+		"size":                1,
 		"query": map[string]any{
 			"bool": map[string]any{
 				"filter": filters,
 			},
 		},
+		"sort": []any{
+			map[string]any{
+				"fault_latest_time": map[string]any{
+					"order":         "desc",
+					"unmapped_type": "date",
+				},
+			},
+		},
 	})
 	if err != nil {
 		return nil, err
@@ -497,6 +650,71 @@ func (s *FaultPointStore) FindInWindow(ctx context.Context, entityID string, fau
 	if err != nil {
 		return nil, err
 	}
+	result, err := decodeSearch[domain.FaultPointObject](data)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return &result[0], nil
+}
+
+// FindFlappingQuiet 查找处于抖动状态、且自 quietSince 起没有新事件的故障点。
+func (s *FaultPointStore) FindFlappingQuiet(ctx context.Context, quietSince time.Time) ([]domain.FaultPointObject, error) {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "FaultPointStore.FindFlappingQuiet",
+			"index", FaultPointIndexObject,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	if s.client == nil {
+		return nil, errors.New("opensearch client 未初始化")
+	}
+	filters := []any{
+		map[string]any{"term": map[string]any{"fault_status.keyword": domain.FaultStatusOccurred}},
+		map[string]any{"term": map[string]any{"fault_flapping": true}},
+		map[string]any{
+			"range": map[string]any{
+				"fault_latest_time": map[string]any{"lt": quietSince},
+			},
+		},
+	}
+
+	body, err := encodeBody(map[string]any{
+		"seq_no_primary_term": true,
+		"size":                maxQuerySize,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": filters,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req := opensearchapi.SearchRequest{
+		Index: []string{FaultPointIndexObject},
+		Body:  body,
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询抖动故障点失败")
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.IsError() {
+		data, _ := readResponseBody(res.Body)
+		return nil, formatErrorMessage(data)
+	}
+	data, err := readResponseBody(res.Body)
+	if err != nil {
+		return nil, err
+	}
 	return decodeSearch[domain.FaultPointObject](data)
 }
 
@@ -523,7 +741,8 @@ func (s *FaultPointStore) FindExpiredOccurred(ctx context.Context, expirationTim
 	}
 
 	body, err := encodeBody(map[string]any{
-		"size": 1000, // 每次最多处理 1000 个过期故障点
+		"seq_no_primary_term": true,
+		"size":                1000, // 每次最多处理 1000 个过期故障点
 		"query": map[string]any{
 			"bool": map[string]any{
 				"filter": filters,
@@ -556,4 +775,23 @@ func (s *FaultPointStore) FindExpiredOccurred(ctx context.Context, expirationTim
 	return decodeSearch[domain.FaultPointObject](data)
 }
 
+// faultKeyFilter 按收敛键过滤故障点。
+// 升级前创建的故障点没有 fault_key，按 fault_mode 匹配（默认 item_key 策略的收敛键即 fault_mode）。
+func faultKeyFilter(faultKey string) map[string]any {
+	return map[string]any{
+		"bool": map[string]any{
+			"should": []any{
+				map[string]any{"term": map[string]any{"fault_key.keyword": faultKey}},
+				map[string]any{
+					"bool": map[string]any{
+						"filter":   []any{map[string]any{"term": map[string]any{"fault_mode.keyword": faultKey}}},
+						"must_not": []any{map[string]any{"exists": map[string]any{"field": "fault_key"}}},
+					},
+				},
+			},
+			"minimum_should_match": 1,
+		},
+	}
+}
+
 var _ core.FaultPointRepository = (*FaultPointStore)(nil)
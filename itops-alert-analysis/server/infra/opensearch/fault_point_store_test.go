@@ -158,14 +158,14 @@ func TestFaultPointStore_QueryByIDs(t *testing.T) {
 	})
 }
 
-func TestFaultPointStore_FindOpenByEntityAndMode(t *testing.T) {
-	Convey("TestFaultPointStore_FindOpenByEntityAndMode", t, func() {
+func TestFaultPointStore_FindOpenByEntityAndKey(t *testing.T) {
+	Convey("TestFaultPointStore_FindOpenByEntityAndKey", t, func() {
 		ctx := context.Background()
 
 		Convey("client 为 nil 返回错误", func() {
 			store := &FaultPointStore{client: nil}
 
-			result, err := store.FindOpenByEntityAndMode(ctx, "entity1", "mode1", time.Now())
+			result, err := store.FindOpenByEntityAndKey(ctx, "entity1", "mode1", time.Now())
 
 			So(err, ShouldNotBeNil)
 			So(result, ShouldBeNil)
@@ -176,22 +176,22 @@ func TestFaultPointStore_FindOpenByEntityAndMode(t *testing.T) {
 			client := newMockClient(200, `{}`)
 			store := NewFaultPointStore(client)
 
-			result, err := store.FindOpenByEntityAndMode(ctx, "", "mode1", time.Now())
+			result, err := store.FindOpenByEntityAndKey(ctx, "", "mode1", time.Now())
 
 			So(err, ShouldNotBeNil)
 			So(result, ShouldBeNil)
-			So(err.Error(), ShouldContainSubstring, "entityObjectID or failureMode is empty")
+			So(err.Error(), ShouldContainSubstring, "entityObjectID or faultKey is empty")
 		})
 
 		Convey("failureMode 为空返回错误", func() {
 			client := newMockClient(200, `{}`)
 			store := NewFaultPointStore(client)
 
-			result, err := store.FindOpenByEntityAndMode(ctx, "entity1", "", time.Now())
+			result, err := store.FindOpenByEntityAndKey(ctx, "entity1", "", time.Now())
 
 			So(err, ShouldNotBeNil)
 			So(result, ShouldBeNil)
-			So(err.Error(), ShouldContainSubstring, "entityObjectID or failureMode is empty")
+			So(err.Error(), ShouldContainSubstring, "entityObjectID or faultKey is empty")
 		})
 
 		Convey("成功找到故障点", func() {
@@ -205,7 +205,7 @@ func TestFaultPointStore_FindOpenByEntityAndMode(t *testing.T) {
 			client := newMockClient(200, body)
 			store := NewFaultPointStore(client)
 
-			result, err := store.FindOpenByEntityAndMode(ctx, "entity1", "mode1", time.Now())
+			result, err := store.FindOpenByEntityAndKey(ctx, "entity1", "mode1", time.Now())
 
 			So(err, ShouldBeNil)
 			So(result, ShouldNotBeNil)
@@ -217,7 +217,7 @@ func TestFaultPointStore_FindOpenByEntityAndMode(t *testing.T) {
 			client := newMockClient(200, body)
 			store := NewFaultPointStore(client)
 
-			result, err := store.FindOpenByEntityAndMode(ctx, "entity1", "mode1", time.Now())
+			result, err := store.FindOpenByEntityAndKey(ctx, "entity1", "mode1", time.Now())
 
 			So(err, ShouldBeNil)
 			So(result, ShouldBeNil)
@@ -227,7 +227,7 @@ func TestFaultPointStore_FindOpenByEntityAndMode(t *testing.T) {
 			client := newMockClientWithError(io.ErrUnexpectedEOF)
 			store := NewFaultPointStore(client)
 
-			result, err := store.FindOpenByEntityAndMode(ctx, "entity1", "mode1", time.Now())
+			result, err := store.FindOpenByEntityAndKey(ctx, "entity1", "mode1", time.Now())
 
 			So(err, ShouldNotBeNil)
 			So(result, ShouldBeNil)
@@ -438,13 +438,16 @@ func TestFaultPointStore_FindInWindow(t *testing.T) {
 					]
 				}
 			}`
-			client := newMockClient(200, body)
+			client, transport := newCapturingMockClient(200, body)
 			store := NewFaultPointStore(client)
 
 			result, err := store.FindInWindow(ctx, "entity1", "mode1", start, end)
 
 			So(err, ShouldBeNil)
 			So(len(result), ShouldEqual, 2)
+			// 按收敛键过滤，升级前没有 fault_key 的故障点按 fault_mode 匹配
+			So(string(transport.lastBody), ShouldContainSubstring, `{"term":{"fault_key.keyword":"mode1"}}`)
+			So(string(transport.lastBody), ShouldContainSubstring, `{"term":{"fault_mode.keyword":"mode1"}}`)
 		})
 
 		Convey("查询失败返回错误", func() {
@@ -515,6 +518,61 @@ func TestFaultPointStore_FindExpiredOccurred(t *testing.T) {
 	})
 }
 
+func TestFaultPointStore_FindByEntityClass(t *testing.T) {
+	Convey("TestFaultPointStore_FindByEntityClass", t, func() {
+		ctx := context.Background()
+
+		Convey("client 为 nil 返回错误", func() {
+			store := &FaultPointStore{client: nil}
+
+			result, err := store.FindByEntityClass(ctx, domain.UnresolvedObjectClass, 0)
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+			So(err.Error(), ShouldContainSubstring, "opensearch client 未初始化")
+		})
+
+		Convey("成功查询占位对象故障点", func() {
+			body := `{
+				"hits": {
+					"hits": [
+						{"_source": {"fault_id": 1, "entity_object_class": "unresolved", "entity_object_id": "unresolved:host-a"}}
+					]
+				}
+			}`
+			store := NewFaultPointStore(newMockClient(200, body))
+
+			result, err := store.FindByEntityClass(ctx, domain.UnresolvedObjectClass, 0)
+
+			So(err, ShouldBeNil)
+			So(result, ShouldHaveLength, 1)
+			So(result[0].EntityObjectID, ShouldEqual, "unresolved:host-a")
+		})
+
+		Convey("按 fault_id 分页，从上一页最后一个故障点之后继续", func() {
+			client, transport := newCapturingMockClient(200, `{"hits": {"hits": []}}`)
+			store := NewFaultPointStore(client)
+
+			result, err := store.FindByEntityClass(ctx, domain.UnresolvedObjectClass, 42)
+
+			So(err, ShouldBeNil)
+			So(result, ShouldBeEmpty)
+			So(string(transport.lastBody), ShouldContainSubstring, `"search_after":[42]`)
+			So(string(transport.lastBody), ShouldContainSubstring, `{"fault_id":{"order":"asc"}}`)
+		})
+
+		Convey("查询失败返回错误", func() {
+			store := NewFaultPointStore(newMockClientWithError(io.ErrUnexpectedEOF))
+
+			result, err := store.FindByEntityClass(ctx, domain.UnresolvedObjectClass, 0)
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+			So(err.Error(), ShouldContainSubstring, "按对象类查询故障点失败")
+		})
+	})
+}
+
 func TestFaultPointStore_bulkUpdate(t *testing.T) {
 	Convey("TestFaultPointStore_bulkUpdate", t, func() {
 		ctx := context.Background()
@@ -578,3 +636,114 @@ func TestFaultPointStore_bulkUpdate(t *testing.T) {
 		})
 	})
 }
+
+func TestFaultPointStore_FindLatestByEntityAndKey(t *testing.T) {
+	Convey("TestFaultPointStore_FindLatestByEntityAndKey", t, func() {
+		ctx := context.Background()
+
+		Convey("client 为 nil 返回错误", func() {
+			store := &FaultPointStore{client: nil}
+
+			result, err := store.FindLatestByEntityAndKey(ctx, "entity1", "mode1", time.Now())
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+			So(err.Error(), ShouldContainSubstring, "opensearch client 未初始化")
+		})
+
+		Convey("entityObjectID 为空返回错误", func() {
+			store := NewFaultPointStore(newMockClient(200, `{}`))
+
+			result, err := store.FindLatestByEntityAndKey(ctx, "", "mode1", time.Now())
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+		})
+
+		Convey("成功找到已恢复的故障点", func() {
+			body := `{
+				"hits": {
+					"hits": [
+						{"_source": {"fault_id": 1, "fault_status": "2", "entity_object_id": "entity1"}}
+					]
+				}
+			}`
+			store := NewFaultPointStore(newMockClient(200, body))
+
+			result, err := store.FindLatestByEntityAndKey(ctx, "entity1", "mode1", time.Now())
+
+			So(err, ShouldBeNil)
+			So(result, ShouldNotBeNil)
+			So(result.FaultID, ShouldEqual, 1)
+			So(result.FaultStatus, ShouldEqual, domain.FaultStatusRecovered)
+		})
+
+		Convey("未找到故障点返回 nil", func() {
+			store := NewFaultPointStore(newMockClient(200, `{"hits": {"hits": []}}`))
+
+			result, err := store.FindLatestByEntityAndKey(ctx, "entity1", "mode1", time.Now())
+
+			So(err, ShouldBeNil)
+			So(result, ShouldBeNil)
+		})
+	})
+}
+
+func TestFaultPointStore_FindFlappingQuiet(t *testing.T) {
+	Convey("TestFaultPointStore_FindFlappingQuiet", t, func() {
+		ctx := context.Background()
+		quietSince := time.Now().Add(-30 * time.Minute)
+
+		Convey("client 为 nil 返回错误", func() {
+			store := &FaultPointStore{client: nil}
+
+			result, err := store.FindFlappingQuiet(ctx, quietSince)
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+		})
+
+		Convey("成功查询抖动故障点", func() {
+			body := `{
+				"hits": {
+					"hits": [
+						{"_source": {"fault_id": 1, "fault_flapping": true, "fault_flapping_status": "2"}}
+					]
+				}
+			}`
+			store := NewFaultPointStore(newMockClient(200, body))
+
+			result, err := store.FindFlappingQuiet(ctx, quietSince)
+
+			So(err, ShouldBeNil)
+			So(result, ShouldHaveLength, 1)
+			So(result[0].FaultFlapping, ShouldBeTrue)
+			So(result[0].FaultFlappingStatus, ShouldEqual, domain.EventStatusRecovered)
+		})
+
+		Convey("查询失败返回错误", func() {
+			store := NewFaultPointStore(newMockClientWithError(io.ErrUnexpectedEOF))
+
+			result, err := store.FindFlappingQuiet(ctx, quietSince)
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+		})
+	})
+}
+
+func TestFaultKeyFilter(t *testing.T) {
+	Convey("TestFaultKeyFilter", t, func() {
+		Convey("按 fault_key 匹配，未写入 fault_key 的历史故障点按 fault_mode 匹配", func() {
+			filter := faultKeyFilter("trigger_name:磁盘空间不足")
+
+			body, err := encodeBody(filter)
+			So(err, ShouldBeNil)
+			data, _ := io.ReadAll(body)
+			So(string(data), ShouldContainSubstring, `{"term":{"fault_key.keyword":"trigger_name:磁盘空间不足"}}`)
+			So(string(data), ShouldContainSubstring, `{"term":{"fault_mode.keyword":"trigger_name:磁盘空间不足"}}`)
+			So(string(data), ShouldContainSubstring, `{"exists":{"field":"fault_key"}}`)
+			So(string(data), ShouldContainSubstring, `"minimum_should_match":1`)
+		})
+	})
+}
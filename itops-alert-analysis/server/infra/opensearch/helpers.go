@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
 	"github.com/pkg/errors"
 )
 
@@ -47,19 +50,43 @@ func (e *OpenSearchError) Error() string {
 // mgetResponse 与 searchResponse 仅用于解析 OpenSearch 响应。
 type mgetResponse struct {
 	Docs []struct {
-		Found  bool            `json:"found"`
-		Source json.RawMessage `json:"_source"`
+		Found       bool            `json:"found"`
+		Source      json.RawMessage `json:"_source"`
+		SeqNo       int64           `json:"_seq_no"`
+		PrimaryTerm int64           `json:"_primary_term"`
 	} `json:"docs"`
 }
 
 type searchResponse struct {
 	Hits struct {
 		Hits []struct {
-			Source json.RawMessage `json:"_source"`
+			Source      json.RawMessage `json:"_source"`
+			SeqNo       int64           `json:"_seq_no"`
+			PrimaryTerm int64           `json:"_primary_term"`
 		} `json:"hits"`
 	} `json:"hits"`
 }
 
+// versioned 由嵌入 domain.DocVersion 的文档实现，解码时填充文档版本。
+type versioned interface {
+	SetVersion(seqNo, primaryTerm int64)
+}
+
+// setVersion 文档支持版本且响应中带有版本时填充。
+func setVersion(item any, seqNo, primaryTerm int64) {
+	if v, ok := item.(versioned); ok && primaryTerm > 0 {
+		v.SetVersion(seqNo, primaryTerm)
+	}
+}
+
+// versionConflict 写入因 if_seq_no/if_primary_term 不匹配或新建的文档已存在被拒绝时返回 domain.ErrVersionConflict。
+func versionConflict(res *opensearchapi.Response, index string, id any) error {
+	if res.StatusCode != http.StatusConflict {
+		return nil
+	}
+	return errors.Wrapf(domain.ErrVersionConflict, "%s/%v", index, id)
+}
+
 func readResponseBody(body io.Reader) ([]byte, error) {
 	data, err := io.ReadAll(body)
 	if err != nil {
@@ -115,6 +142,7 @@ func decodeMGet[T any](data []byte) ([]T, error) {
 		if err := json.Unmarshal(doc.Source, &item); err != nil {
 			return nil, errors.Wrap(err, "解析文档失败")
 		}
+		setVersion(&item, doc.SeqNo, doc.PrimaryTerm)
 		items = append(items, item)
 	}
 	return items, nil
@@ -134,6 +162,7 @@ func decodeSearch[T any](data []byte) ([]T, error) {
 		if err := json.Unmarshal(hit.Source, &item); err != nil {
 			return nil, errors.Wrapf(err, "解析文档失败,Source:%+v", string(hit.Source))
 		}
+		setVersion(&item, hit.SeqNo, hit.PrimaryTerm)
 		items = append(items, item)
 	}
 	return items, nil
@@ -0,0 +1,221 @@
+// Package opensearchtest 提供内存版 OpenSearch 传输层，用于在测试中验证并发写入与乐观并发控制。
+//
+// 支持按 ID 写入（if_seq_no/if_primary_term、op_type=create）、局部更新（if_seq_no/if_primary_term）与 mget，其余请求返回 400。
+package opensearchtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	opensearchsdk "github.com/opensearch-project/opensearch-go/v2"
+)
+
+const primaryTerm = 1
+
+type document struct {
+	source map[string]json.RawMessage
+	seqNo  int64
+}
+
+// Transport 内存版 OpenSearch，实现 http.RoundTripper。
+type Transport struct {
+	// Latency 每个请求处理前的等待时间，用于放大并发读写的交错
+	Latency time.Duration
+
+	mu        sync.Mutex
+	indices   map[string]map[string]*document
+	seqNo     int64
+	conflicts int
+}
+
+// NewTransport 创建空的内存 OpenSearch。
+func NewTransport() *Transport {
+	return &Transport{indices: make(map[string]map[string]*document)}
+}
+
+// NewClient 创建使用内存传输层的 OpenSearch 客户端。
+func NewClient(t *Transport) *opensearchsdk.Client {
+	client, _ := opensearchsdk.NewClient(opensearchsdk.Config{
+		Transport: t,
+		Addresses: []string{"http://localhost:9200"},
+	})
+	return client
+}
+
+// Conflicts 返回因版本不匹配被拒绝的写入次数。
+func (t *Transport) Conflicts() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.conflicts
+}
+
+// Source 返回文档内容，文档不存在时返回 false。
+func (t *Transport) Source(index, id string) (json.RawMessage, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	doc, ok := t.indices[index][id]
+	if !ok {
+		return nil, false
+	}
+	data, _ := json.Marshal(doc.source)
+	return data, true
+}
+
+// RoundTrip 按请求路径分发到对应的文档操作。
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Latency > 0 {
+		time.Sleep(t.Latency)
+	}
+	var body []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case len(parts) == 3 && parts[1] == "_doc" && (req.Method == http.MethodPut || req.Method == http.MethodPost):
+		return t.index(parts[0], parts[2], req, body)
+	case len(parts) == 3 && parts[1] == "_update" && req.Method == http.MethodPost:
+		return t.update(parts[0], parts[2], req, body)
+	case len(parts) == 2 && parts[1] == "_mget":
+		return t.mget(parts[0], body)
+	}
+	return response(http.StatusBadRequest, errorBody(http.StatusBadRequest, "illegal_argument_exception", "opensearchtest 不支持的请求: "+req.Method+" "+req.URL.Path))
+}
+
+func (t *Transport) index(index, id string, req *http.Request, body []byte) (*http.Response, error) {
+	var source map[string]json.RawMessage
+	if err := json.Unmarshal(body, &source); err != nil {
+		return response(http.StatusBadRequest, errorBody(http.StatusBadRequest, "mapper_parsing_exception", err.Error()))
+	}
+
+	existing := t.indices[index][id]
+	if req.URL.Query().Get("op_type") == "create" && existing != nil {
+		return t.conflict(index, id, existing)
+	}
+	if t.versionMismatch(req, existing) {
+		return t.conflict(index, id, existing)
+	}
+
+	result, status := "created", http.StatusCreated
+	if existing != nil {
+		result, status = "updated", http.StatusOK
+	}
+	doc := t.put(index, id, source)
+	return response(status, map[string]any{
+		"_index": index, "_id": id, "result": result, "_seq_no": doc.seqNo, "_primary_term": primaryTerm,
+	})
+}
+
+func (t *Transport) update(index, id string, req *http.Request, body []byte) (*http.Response, error) {
+	var update struct {
+		Doc map[string]json.RawMessage `json:"doc"`
+	}
+	if err := json.Unmarshal(body, &update); err != nil {
+		return response(http.StatusBadRequest, errorBody(http.StatusBadRequest, "x_content_parse_exception", err.Error()))
+	}
+	existing := t.indices[index][id]
+	if existing == nil {
+		return response(http.StatusNotFound, errorBody(http.StatusNotFound, "document_missing_exception", fmt.Sprintf("[%s]: document missing", id)))
+	}
+	if t.versionMismatch(req, existing) {
+		return t.conflict(index, id, existing)
+	}
+
+	source := make(map[string]json.RawMessage, len(existing.source)+len(update.Doc))
+	for k, v := range existing.source {
+		source[k] = v
+	}
+	for k, v := range update.Doc {
+		source[k] = v
+	}
+	doc := t.put(index, id, source)
+	return response(http.StatusOK, map[string]any{
+		"_index": index, "_id": id, "result": "updated", "_seq_no": doc.seqNo, "_primary_term": primaryTerm,
+	})
+}
+
+func (t *Transport) mget(index string, body []byte) (*http.Response, error) {
+	var req struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return response(http.StatusBadRequest, errorBody(http.StatusBadRequest, "x_content_parse_exception", err.Error()))
+	}
+	docs := make([]map[string]any, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		doc, ok := t.indices[index][id]
+		if !ok {
+			docs = append(docs, map[string]any{"_index": index, "_id": id, "found": false})
+			continue
+		}
+		docs = append(docs, map[string]any{
+			"_index": index, "_id": id, "found": true, "_source": doc.source, "_seq_no": doc.seqNo, "_primary_term": primaryTerm,
+		})
+	}
+	return response(http.StatusOK, map[string]any{"docs": docs})
+}
+
+func (t *Transport) put(index, id string, source map[string]json.RawMessage) *document {
+	if t.indices[index] == nil {
+		t.indices[index] = make(map[string]*document)
+	}
+	doc := &document{source: source, seqNo: t.seqNo}
+	t.seqNo++
+	t.indices[index][id] = doc
+	return doc
+}
+
+// versionMismatch 请求带有 if_seq_no/if_primary_term 且与文档当前版本不一致。
+func (t *Transport) versionMismatch(req *http.Request, existing *document) bool {
+	ifSeqNo := req.URL.Query().Get("if_seq_no")
+	if ifSeqNo == "" {
+		return false
+	}
+	expected, _ := strconv.ParseInt(ifSeqNo, 10, 64)
+	term, _ := strconv.ParseInt(req.URL.Query().Get("if_primary_term"), 10, 64)
+	return existing == nil || existing.seqNo != expected || term != primaryTerm
+}
+
+func (t *Transport) conflict(index, id string, existing *document) (*http.Response, error) {
+	t.conflicts++
+	reason := fmt.Sprintf("[%s][%s]: version conflict, document does not exist", index, id)
+	if existing != nil {
+		reason = fmt.Sprintf("[%s][%s]: version conflict, current document has seqNo [%d] and primary term [%d]", index, id, existing.seqNo, primaryTerm)
+	}
+	return response(http.StatusConflict, errorBody(http.StatusConflict, "version_conflict_engine_exception", reason))
+}
+
+func errorBody(status int, errType, reason string) map[string]any {
+	return map[string]any{
+		"error":  map[string]any{"type": errType, "reason": reason},
+		"status": status,
+	}
+}
+
+func response(status int, payload any) (*http.Response, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	return &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(bytes.NewReader(data)),
+		Header:     header,
+	}, nil
+}
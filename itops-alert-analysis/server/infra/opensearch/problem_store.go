@@ -3,6 +3,7 @@ package opensearch
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/core"
@@ -60,7 +61,8 @@ func (s *ProblemStore) FindCorrelated(ctx context.Context, fp domain.FaultPointO
 	}
 
 	body, err := encodeBody(map[string]any{
-		"size": maxQuerySize,
+		"seq_no_primary_term": true,
+		"size":                maxQuerySize,
 		"query": map[string]any{
 			"bool": map[string]any{
 				"filter": filters,
@@ -124,21 +126,27 @@ func (s *ProblemStore) FindPendingRCA(ctx context.Context, maxAge time.Duration)
 		},
 	}
 
-	// RCA 未完成：root_cause_fault_id 为 0 或不存在
-	mustNot := []any{
+	// RCA 未完成：状态为等待或分析中；早期未记录状态的问题按 root_cause_fault_id 为 0 或不存在判断
+	should := []any{
+		map[string]any{"terms": map[string]any{"rca_status": []domain.RcaStatus{domain.RcaStatusPending, domain.RcaStatusRunning}}},
 		map[string]any{
-			"range": map[string]any{
-				"root_cause_fault_id": map[string]any{"gt": 0},
+			"bool": map[string]any{
+				"must_not": []any{
+					map[string]any{"terms": map[string]any{"rca_status": []domain.RcaStatus{domain.RcaStatusSuccess, domain.RcaStatusFailed, domain.RcaStatusCancelled}}},
+					map[string]any{"range": map[string]any{"root_cause_fault_id": map[string]any{"gt": 0}}},
+				},
 			},
 		},
 	}
 
 	body, err := encodeBody(map[string]any{
-		"size": maxQuerySize,
+		"seq_no_primary_term": true,
+		"size":                maxQuerySize,
 		"query": map[string]any{
 			"bool": map[string]any{
-				"filter":   filters,
-				"must_not": mustNot,
+				"filter":               filters,
+				"should":               should,
+				"minimum_should_match": 1,
 			},
 		},
 		"sort": []any{
@@ -196,7 +204,8 @@ func (s *ProblemStore) FindExpiredOpen(ctx context.Context, expirationTime time.
 	}
 
 	body, err := encodeBody(map[string]any{
-		"size": maxQuerySize,
+		"seq_no_primary_term": true,
+		"size":                maxQuerySize,
 		"query": map[string]any{
 			"bool": map[string]any{
 				"filter": filters,
@@ -232,6 +241,77 @@ func (s *ProblemStore) FindExpiredOpen(ctx context.Context, expirationTime time.
 	return decodeSearch[domain.Problem](data)
 }
 
+// FindRecentClosed 查询 since 之后关闭、且包含任一故障点指纹的问题，按关闭时间倒序。
+func (s *ProblemStore) FindRecentClosed(ctx context.Context, fingerprints []string, since time.Time) ([]domain.Problem, error) {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "ProblemStore.FindRecentClosed",
+			"index", ProblemIndex,
+			"fingerprints", fingerprints,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	if s.client == nil {
+		return nil, errors.New("opensearch client 未初始化")
+	}
+	if len(fingerprints) == 0 {
+		return nil, nil
+	}
+
+	filters := []any{
+		map[string]any{"term": map[string]any{"problem_status": domain.ProblemStatusClosed}},
+		map[string]any{"terms": map[string]any{"fault_fingerprints.keyword": fingerprints}},
+		map[string]any{
+			"range": map[string]any{
+				"problem_close_time": map[string]any{"gte": since.Local()},
+			},
+		},
+	}
+
+	body, err := encodeBody(map[string]any{
+		"seq_no_primary_term": true,
+		"size":                maxQuerySize,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": filters,
+			},
+		},
+		"sort": []any{
+			map[string]any{
+				"problem_close_time": map[string]any{
+					"order":         "desc",
+					"unmapped_type": "date",
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req := opensearchapi.SearchRequest{
+		Index: []string{ProblemIndex},
+		Body:  body,
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询已关闭问题失败")
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.IsError() {
+		data, _ := readResponseBody(res.Body)
+		return nil, formatErrorMessage(data)
+	}
+	data, err := readResponseBody(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSearch[domain.Problem](data)
+}
+
 func (s *ProblemStore) Upsert(ctx context.Context, p domain.Problem) error {
 	defer func(start time.Time) {
 		log.Debugw("OpenSearch",
@@ -274,6 +354,15 @@ func (s *ProblemStore) Upsert(ctx context.Context, p domain.Problem) error {
 		Body:       body,
 		Refresh:    "wait_for",
 	}
+	if p.Versioned() {
+		// 乐观并发控制：读取后文档已被修改时写入失败，由调用方重新读取后重试
+		seqNo, primaryTerm := int(p.SeqNo), int(p.PrimaryTerm)
+		req.IfSeqNo = &seqNo
+		req.IfPrimaryTerm = &primaryTerm
+	} else {
+		// 新建文档：同 ID 文档已存在时写入失败，避免覆盖并发创建或未经读取的文档
+		req.OpType = "create"
+	}
 	res, err := req.Do(ctx, s.client)
 	if err != nil {
 		return errors.Wrap(err, "写入 Problem 失败")
@@ -281,6 +370,9 @@ func (s *ProblemStore) Upsert(ctx context.Context, p domain.Problem) error {
 	defer func() {
 		_ = res.Body.Close()
 	}()
+	if err := versionConflict(res, ProblemIndex, p.ProblemID); err != nil {
+		return err
+	}
 	if res.IsError() {
 		data, _ := readResponseBody(res.Body)
 		return formatErrorMessage(data)
@@ -323,6 +415,9 @@ func (s *ProblemStore) UpdateRootCause(ctx context.Context, problemID uint64, cb
 		"problem_name":         problemName,
 		"problem_description":  problemDescription,
 	}
+	if cb.Progress != nil {
+		doc["rca_progress"] = cb.Progress
+	}
 
 	return s.partialUpdate(ctx, problemID, doc)
 }
@@ -360,6 +455,68 @@ func (s *ProblemStore) UpdateRelationEventIDs(ctx context.Context, problemID uin
 	}
 	return s.partialUpdate(ctx, problemID, doc)
 }
+
+// UpdateLevel 更新问题等级，由故障点等级变化触发。
+func (s *ProblemStore) UpdateLevel(ctx context.Context, problemID uint64, level domain.Severity) error {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "ProblemStore.UpdateLevel",
+			"index", ProblemIndex,
+			"document_id", problemID,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	doc := map[string]any{
+		"problem_level":       level,
+		"problem_update_time": timex.NowLocalTime().Local(),
+	}
+	return s.partialUpdate(ctx, problemID, doc)
+}
+
+// UpdateRCAProgress 写入分析中的进度，并将 RCA 状态置为分析中；问题不存在或已关闭、合并时不更新。
+func (s *ProblemStore) UpdateRCAProgress(ctx context.Context, problemID uint64, progress domain.RCAProgress) error {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "ProblemStore.UpdateRCAProgress",
+			"index", ProblemIndex,
+			"document_id", problemID,
+			"step", progress.Step,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	// 只更新打开的问题：按读取时的版本写入，读取后问题被关闭或合并时重新读取
+	return RetryOnConflict(ctx, func() error {
+		problems, err := s.QueryByIDs(ctx, []uint64{problemID})
+		if err != nil {
+			return err
+		}
+		if len(problems) == 0 || problems[0].ProblemStatus != domain.ProblemStatusOpen {
+			log.Debugf("问题 %d 不存在或已关闭，忽略 RCA 进度", problemID)
+			return nil
+		}
+		return s.update(ctx, problemID, &problems[0].DocVersion, map[string]any{
+			"rca_status":   domain.RcaStatusRunning,
+			"rca_progress": progress,
+		})
+	})
+}
+
+// UpdateRCAStatus 更新问题的 RCA 状态，分析成功时由 UpdateRootCause 一并写入。
+func (s *ProblemStore) UpdateRCAStatus(ctx context.Context, problemID uint64, status domain.RcaStatus) error {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "ProblemStore.UpdateRCAStatus",
+			"index", ProblemIndex,
+			"document_id", problemID,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	return s.partialUpdate(ctx, problemID, map[string]any{"rca_status": status})
+}
+
 func (s *ProblemStore) MarkClosed(ctx context.Context, problemID uint64, closeType domain.ProblemCloseType, closeStatus domain.ProblemStatus, duration uint64, notes string, by string) error {
 	defer func(start time.Time) {
 		log.Debugw("OpenSearch",
@@ -462,30 +619,72 @@ func (s *ProblemStore) QueryByIDs(ctx context.Context, ids []uint64) ([]domain.P
 	return decodeMGet[domain.Problem](data)
 }
 
-// ClearMergedProblemData 清空被合并问题的关联数据
-// 用于问题合并后，清除被合并问题的故障点、事件、RCA结果等数据
-func (s *ProblemStore) ClearMergedProblemData(ctx context.Context, problemID uint64) error {
+// CloseMerged 清空被合并问题的关联数据（故障点、事件、RCA结果等）并以 merged 状态关闭。
+// 按读取时的版本整体写入，读取后问题被修改（如并入新的故障点）时返回 domain.ErrVersionConflict，由调用方重新读取后重试。
+func (s *ProblemStore) CloseMerged(ctx context.Context, p domain.Problem, closeType domain.ProblemCloseType, notes string, by string) error {
 	defer func(start time.Time) {
 		log.Debugw("OpenSearch",
-			"operation", "ProblemStore.ClearMergedProblemData",
+			"operation", "ProblemStore.CloseMerged",
+			"index", ProblemIndex,
+			"document_id", p.ProblemID,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	if !p.Versioned() {
+		return errors.Errorf("被合并问题 %d 缺少文档版本", p.ProblemID)
+	}
+
+	now := timex.NowLocalTime().Local()
+	p.RelationIDs = []uint64{}
+	p.RelationEventIDs = []uint64{}
+	p.AffectedEntityIDs = []string{}
+	p.FaultFingerprints = []string{}
+	p.RootCauseObjectID = ""
+	p.RootCauseFaultID = 0
+	p.RcaResults = ""
+	p.RcaStatus = 0
+	p.RcaProgress = nil
+	p.ProblemStatus = domain.ProblemStatusMerged
+	p.ProblemCloseType = &closeType
+	p.ProblemCloseNotes = notes
+	p.ProblemClosedBy = by
+	p.ProblemCloseTime = &now
+	p.ProblemUpdateTime = now
+	return s.Upsert(ctx, p)
+}
+
+// Delete 删除问题，问题不存在时视为成功。用于撤销写入后未能完成的操作（如拆分时原问题保存失败）。
+func (s *ProblemStore) Delete(ctx context.Context, problemID uint64) error {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "ProblemStore.Delete",
 			"index", ProblemIndex,
 			"document_id", problemID,
 			"duration_ms", time.Since(start).Milliseconds(),
 		)
 	}(time.Now())
 
-	doc := map[string]any{
-		"relation_ids":         []uint64{},                   // 清空故障点列表
-		"relation_event_ids":   []uint64{},                   // 清空事件列表
-		"affected_entity_ids":  []string{},                   // 清空受影响实体列表
-		"root_cause_object_id": "",                           // 清空根因对象ID
-		"root_cause_fault_id":  0,                            // 清空根因故障ID
-		"rca_results":          "",                           // 清空RCA结果
-		"rca_status":           "",                           // 清空RCA状态
-		"problem_update_time":  timex.NowLocalTime().Local(), // 更新时间
+	if s.client == nil {
+		return errors.New("opensearch client 未初始化")
 	}
-
-	return s.partialUpdate(ctx, problemID, doc)
+	req := opensearchapi.DeleteRequest{
+		Index:      ProblemIndex,
+		DocumentID: cast.ToString(problemID),
+		Refresh:    "wait_for",
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return errors.Wrapf(err, "删除 Problem %d 失败", problemID)
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		data, _ := readResponseBody(res.Body)
+		return formatErrorMessage(data)
+	}
+	return nil
 }
 
 func (s *ProblemStore) partialUpdate(ctx context.Context, id uint64, doc map[string]any) error {
@@ -498,6 +697,11 @@ func (s *ProblemStore) partialUpdate(ctx context.Context, id uint64, doc map[str
 		)
 	}(time.Now())
 
+	return s.update(ctx, id, nil, doc)
+}
+
+// update 局部更新问题；version 不为空时按读取时的版本写入，版本不匹配返回 domain.ErrVersionConflict。
+func (s *ProblemStore) update(ctx context.Context, id uint64, version *domain.DocVersion, doc map[string]any) error {
 	if s.client == nil {
 		return errors.New("opensearch client 未初始化")
 	}
@@ -514,6 +718,13 @@ func (s *ProblemStore) partialUpdate(ctx context.Context, id uint64, doc map[str
 		Body:       body,
 		Refresh:    "wait_for",
 	}
+	if version != nil && version.Versioned() {
+		seqNo, primaryTerm := int(version.SeqNo), int(version.PrimaryTerm)
+		req.IfSeqNo = &seqNo
+		req.IfPrimaryTerm = &primaryTerm
+	} else {
+		req.RetryOnConflict = &partialUpdateRetries
+	}
 	res, err := req.Do(ctx, s.client)
 	if err != nil {
 		return errors.Wrapf(err, "更新 Problem %d 失败", id)
@@ -521,6 +732,9 @@ func (s *ProblemStore) partialUpdate(ctx context.Context, id uint64, doc map[str
 	defer func() {
 		_ = res.Body.Close()
 	}()
+	if err := versionConflict(res, ProblemIndex, id); err != nil {
+		return err
+	}
 	if res.IsError() {
 		data, _ := readResponseBody(res.Body)
 		return formatErrorMessage(data)
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch/opensearchtest"
+	"github.com/pkg/errors"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -280,6 +282,61 @@ func TestProblemStore_FindExpiredOpen(t *testing.T) {
 	})
 }
 
+func TestProblemStore_FindRecentClosed(t *testing.T) {
+	Convey("TestProblemStore_FindRecentClosed", t, func() {
+		ctx := context.Background()
+		since := time.Now().Add(-time.Hour)
+
+		Convey("client 为 nil 返回错误", func() {
+			store := &ProblemStore{client: nil}
+
+			result, err := store.FindRecentClosed(ctx, []string{"host-1/cpu"}, since)
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+		})
+
+		Convey("指纹为空返回 nil", func() {
+			store := NewProblemStore(newMockClient(200, `{}`))
+
+			result, err := store.FindRecentClosed(ctx, nil, since)
+
+			So(err, ShouldBeNil)
+			So(result, ShouldBeNil)
+		})
+
+		Convey("按指纹查询已关闭问题", func() {
+			body := `{
+				"hits": {
+					"hits": [
+						{"_source": {"problem_id": 1, "problem_status": "1", "fault_fingerprints": ["host-1/cpu"], "recurrence_count": 2}}
+					]
+				}
+			}`
+			client, transport := newCapturingMockClient(200, body)
+			store := NewProblemStore(client)
+
+			result, err := store.FindRecentClosed(ctx, []string{"host-1/cpu"}, since)
+
+			So(err, ShouldBeNil)
+			So(string(transport.lastBody), ShouldContainSubstring, `{"terms":{"fault_fingerprints.keyword":["host-1/cpu"]}}`)
+			So(result, ShouldHaveLength, 1)
+			So(result[0].FaultFingerprints, ShouldResemble, []string{"host-1/cpu"})
+			So(result[0].RecurrenceCount, ShouldEqual, 2)
+		})
+
+		Convey("查询失败返回错误", func() {
+			store := NewProblemStore(newMockClientWithError(io.ErrUnexpectedEOF))
+
+			result, err := store.FindRecentClosed(ctx, []string{"host-1/cpu"}, since)
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+			So(err.Error(), ShouldContainSubstring, "查询已关闭问题失败")
+		})
+	})
+}
+
 func TestProblemStore_UpdateRootCause(t *testing.T) {
 	Convey("TestProblemStore_UpdateRootCause", t, func() {
 		ctx := context.Background()
@@ -367,6 +424,77 @@ func TestProblemStore_UpdateRelationEventIDs(t *testing.T) {
 	})
 }
 
+func TestProblemStore_UpdateLevel(t *testing.T) {
+	Convey("TestProblemStore_UpdateLevel", t, func() {
+		ctx := context.Background()
+
+		Convey("client 为 nil 返回错误", func() {
+			store := &ProblemStore{client: nil}
+
+			err := store.UpdateLevel(ctx, 1, domain.SeverityCritical)
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "opensearch client 未初始化")
+		})
+
+		Convey("成功更新问题等级", func() {
+			client := newMockClient(200, `{"result": "updated"}`)
+			store := NewProblemStore(client)
+
+			err := store.UpdateLevel(ctx, 1, domain.SeverityCritical)
+
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
+func TestProblemStore_UpdateRCAStatus(t *testing.T) {
+	Convey("TestProblemStore_UpdateRCAStatus", t, func() {
+		ctx := context.Background()
+
+		Convey("client 为 nil 返回错误", func() {
+			store := &ProblemStore{client: nil}
+
+			err := store.UpdateRCAStatus(ctx, 1, domain.RcaStatusRunning)
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "opensearch client 未初始化")
+		})
+
+		Convey("成功更新 RCA 状态", func() {
+			store := NewProblemStore(newMockClient(200, `{"result": "updated"}`))
+
+			err := store.UpdateRCAStatus(ctx, 1, domain.RcaStatusRunning)
+
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
+func TestProblemStore_UpdateRCAProgress(t *testing.T) {
+	Convey("TestProblemStore_UpdateRCAProgress", t, func() {
+		ctx := context.Background()
+		progress := domain.RCAProgress{Step: domain.RCAStepPairsAnalyzing, Percent: 55, TotalPairs: 4, AnalyzedPairs: 2}
+
+		Convey("client 为 nil 返回错误", func() {
+			store := &ProblemStore{client: nil}
+
+			err := store.UpdateRCAProgress(ctx, 1, progress)
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "opensearch client 未初始化")
+		})
+
+		Convey("成功更新分析进度", func() {
+			store := NewProblemStore(newMockClient(200, `{"result": "updated"}`))
+
+			err := store.UpdateRCAProgress(ctx, 1, progress)
+
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
 func TestProblemStore_MarkClosed(t *testing.T) {
 	Convey("TestProblemStore_MarkClosed", t, func() {
 		ctx := context.Background()
@@ -426,26 +554,94 @@ func TestProblemStore_MarkExpired(t *testing.T) {
 	})
 }
 
-func TestProblemStore_ClearMergedProblemData(t *testing.T) {
-	Convey("TestProblemStore_ClearMergedProblemData", t, func() {
+func TestProblemStore_CloseMerged(t *testing.T) {
+	Convey("TestProblemStore_CloseMerged", t, func() {
+		ctx := context.Background()
+		transport := opensearchtest.NewTransport()
+		store := NewProblemStore(opensearchtest.NewClient(transport))
+		So(store.Upsert(ctx, domain.Problem{
+			ProblemID:         1,
+			ProblemStatus:     domain.ProblemStatusOpen,
+			RelationIDs:       []uint64{10},
+			RelationEventIDs:  []uint64{100},
+			RootCauseObjectID: "host-1",
+		}), ShouldBeNil)
+
+		read := func() domain.Problem {
+			problems, err := store.QueryByIDs(ctx, []uint64{1})
+			So(err, ShouldBeNil)
+			So(problems, ShouldHaveLength, 1)
+			return problems[0]
+		}
+
+		Convey("未读取版本的问题返回错误", func() {
+			err := store.CloseMerged(ctx, domain.Problem{ProblemID: 1}, domain.ProblemCloseTypeSystem, "合并到问题2", "system")
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "缺少文档版本")
+		})
+
+		Convey("清空关联数据并以 merged 状态关闭", func() {
+			err := store.CloseMerged(ctx, read(), domain.ProblemCloseTypeManual, "手动合并到问题2", "admin")
+
+			So(err, ShouldBeNil)
+			p := read()
+			So(p.ProblemStatus, ShouldEqual, domain.ProblemStatusMerged)
+			So(*p.ProblemCloseType, ShouldEqual, domain.ProblemCloseTypeManual)
+			So(p.ProblemClosedBy, ShouldEqual, "admin")
+			So(p.ProblemCloseTime, ShouldNotBeNil)
+			So(p.RelationIDs, ShouldBeEmpty)
+			So(p.RelationEventIDs, ShouldBeEmpty)
+			So(p.RootCauseObjectID, ShouldBeEmpty)
+		})
+
+		Convey("读取后问题并入新的故障点时返回版本冲突，不丢失故障点", func() {
+			stale := read()
+			latest := read()
+			latest.RelationIDs = append(latest.RelationIDs, 11)
+			So(store.Upsert(ctx, latest), ShouldBeNil)
+
+			err := store.CloseMerged(ctx, stale, domain.ProblemCloseTypeSystem, "合并到问题2", "system")
+
+			So(errors.Is(err, domain.ErrVersionConflict), ShouldBeTrue)
+			So(read().ProblemStatus, ShouldEqual, domain.ProblemStatusOpen)
+			So(read().RelationIDs, ShouldResemble, []uint64{10, 11})
+		})
+	})
+}
+
+func TestProblemStore_Delete(t *testing.T) {
+	Convey("TestProblemStore_Delete", t, func() {
 		ctx := context.Background()
 
 		Convey("client 为 nil 返回错误", func() {
 			store := &ProblemStore{client: nil}
 
-			err := store.ClearMergedProblemData(ctx, 1)
+			err := store.Delete(ctx, 1)
 
 			So(err, ShouldNotBeNil)
 			So(err.Error(), ShouldContainSubstring, "opensearch client 未初始化")
 		})
 
-		Convey("成功清空合并问题数据", func() {
-			client := newMockClient(200, `{"result": "updated"}`)
+		Convey("成功删除", func() {
+			client := newMockClient(200, `{"result": "deleted"}`)
+			store := NewProblemStore(client)
+
+			So(store.Delete(ctx, 1), ShouldBeNil)
+		})
+
+		Convey("问题不存在视为成功", func() {
+			client := newMockClient(404, `{"result": "not_found"}`)
 			store := NewProblemStore(client)
 
-			err := store.ClearMergedProblemData(ctx, 1)
+			So(store.Delete(ctx, 1), ShouldBeNil)
+		})
 
-			So(err, ShouldBeNil)
+		Convey("删除失败返回错误", func() {
+			client := newMockClient(500, `{"error": {"type": "internal", "reason": "boom"}}`)
+			store := NewProblemStore(client)
+
+			So(store.Delete(ctx, 1), ShouldNotBeNil)
 		})
 	})
 }
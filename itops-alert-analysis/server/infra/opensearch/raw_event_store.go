@@ -122,6 +122,25 @@ func (s *RawEventStore) UpdateProblemID(ctx context.Context, eventIDs []uint64,
 	return s.bulkUpdate(ctx, eventIDs, map[string]any{"problem_id": problemID})
 }
 
+// UpdateEntity 批量更新事件关联的实体对象（未解析实体重新关联到真实对象时使用）。
+func (s *RawEventStore) UpdateEntity(ctx context.Context, eventIDs []uint64, objectClass, objectID, objectName string) error {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "RawEventStore.UpdateEntity",
+			"index", RawEventIndex,
+			"entity_object_id", objectID,
+			"event_ids_count", len(eventIDs),
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	return s.bulkUpdate(ctx, eventIDs, map[string]any{
+		"entity_object_class": objectClass,
+		"entity_object_id":    objectID,
+		"entity_object_name":  objectName,
+	})
+}
+
 func (s *RawEventStore) QueryByIDs(ctx context.Context, ids []uint64) ([]domain.RawEvent, error) {
 	defer func(start time.Time) {
 		log.Debugw("OpenSearch",
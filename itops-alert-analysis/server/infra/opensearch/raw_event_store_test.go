@@ -17,9 +17,15 @@ import (
 type mockTransport struct {
 	response *http.Response
 	err      error
+	lastURL  string // 最近一次请求的 URL
+	lastBody []byte // 最近一次请求的请求体
 }
 
 func (m *mockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	m.lastURL = req.URL.String()
+	if req.Body != nil {
+		m.lastBody, _ = io.ReadAll(req.Body)
+	}
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -44,6 +50,22 @@ func newMockClient(statusCode int, body string) *opensearchsdk.Client {
 	return client
 }
 
+// newCapturingMockClient 创建 mock 客户端，并返回 transport 用于检查请求体
+func newCapturingMockClient(statusCode int, body string) (*opensearchsdk.Client, *mockTransport) {
+	transport := &mockTransport{
+		response: &http.Response{
+			StatusCode: statusCode,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		},
+	}
+	client, _ := opensearchsdk.NewClient(opensearchsdk.Config{
+		Transport: transport,
+		Addresses: []string{"http://localhost:9200"},
+	})
+	return client, transport
+}
+
 // newMockClientWithError 创建返回错误的 mock 客户端
 func newMockClientWithError(err error) *opensearchsdk.Client {
 	transport := &mockTransport{
@@ -326,3 +348,36 @@ func TestRawEventStore_UpdateProblemID(t *testing.T) {
 		})
 	})
 }
+
+func TestRawEventStore_UpdateEntity(t *testing.T) {
+	Convey("TestRawEventStore_UpdateEntity", t, func() {
+		ctx := context.Background()
+
+		Convey("client 为 nil 返回错误", func() {
+			store := &RawEventStore{client: nil}
+
+			err := store.UpdateEntity(ctx, []uint64{1}, "host", "obj-1", "host-a")
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "opensearch client 未初始化")
+		})
+
+		Convey("成功批量更新实体对象", func() {
+			body := `{"errors": false, "items": [{"update": {"status": 200}}]}`
+			store := NewRawEventStore(newMockClient(200, body))
+
+			err := store.UpdateEntity(ctx, []uint64{1, 2}, "host", "obj-1", "host-a")
+
+			So(err, ShouldBeNil)
+		})
+
+		Convey("批量更新部分失败返回错误", func() {
+			body := `{"errors": true, "items": [{"update": {"status": 404}}]}`
+			store := NewRawEventStore(newMockClient(200, body))
+
+			err := store.UpdateEntity(ctx, []uint64{1}, "host", "obj-1", "host-a")
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,200 @@
+package opensearch
+
+import (
+	"context"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/core"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	opensearchsdk "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// defaultRCAJobPageSize RCA 任务列表默认分页大小
+const defaultRCAJobPageSize = 20
+
+// RCAJobStore 负责 itops_rca_job 索引的全部操作。
+type RCAJobStore struct {
+	client *opensearchsdk.Client
+}
+
+// rcaJobDocument 包装 RCAJob 并补充索引所需的公共字段。
+type rcaJobDocument struct {
+	domain.RCAJob
+	Timestamp time.Time `json:"@timestamp"`
+	WriteTime time.Time `json:"__write_time"`
+	DataType  string    `json:"__data_type"`
+	IndexBase string    `json:"__index_base"`
+	Category  string    `json:"category"`
+	Type      string    `json:"type"`
+	ID        string    `json:"__id"`
+}
+
+// NewRCAJobStore 创建 RCA 任务存储实例。
+func NewRCAJobStore(client *opensearchsdk.Client) *RCAJobStore {
+	return &RCAJobStore{client: client}
+}
+
+// Upsert 写入或覆盖 RCA 任务。
+func (s *RCAJobStore) Upsert(ctx context.Context, job domain.RCAJob) error {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "RCAJobStore.Upsert",
+			"index", RCAJobIndex,
+			"document_id", job.JobID,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	if s.client == nil {
+		return errors.New("opensearch client 未初始化")
+	}
+	if job.JobID == 0 {
+		return errors.New("job_id 不能为空")
+	}
+
+	doc := rcaJobDocument{
+		RCAJob:    job,
+		Timestamp: job.CreateTime,
+		WriteTime: time.Now().Local(),
+		DataType:  RCAJobIndexBase,
+		IndexBase: RCAJobIndexBase,
+		Category:  "log",
+		Type:      RCAJobIndexBase,
+		ID:        cast.ToString(job.JobID),
+	}
+
+	body, err := encodeBody(doc)
+	if err != nil {
+		return err
+	}
+
+	req := opensearchapi.IndexRequest{
+		Index:      RCAJobIndex,
+		DocumentID: cast.ToString(job.JobID),
+		Body:       body,
+		Refresh:    "wait_for",
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return errors.Wrap(err, "写入 RCAJob 失败")
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.IsError() {
+		data, _ := readResponseBody(res.Body)
+		return formatErrorMessage(data)
+	}
+	return nil
+}
+
+// List 按条件分页查询 RCA 任务，按创建时间倒序。
+func (s *RCAJobStore) List(ctx context.Context, q domain.RCAJobQuery) ([]domain.RCAJob, error) {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "RCAJobStore.List",
+			"index", RCAJobIndex,
+			"problem_id", q.ProblemID,
+			"status", q.Status,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	if s.client == nil {
+		return nil, errors.New("opensearch client 未初始化")
+	}
+
+	filters := []any{}
+	if q.ProblemID != 0 {
+		filters = append(filters, map[string]any{"term": map[string]any{"problem_id": q.ProblemID}})
+	}
+	if q.Status != "" {
+		filters = append(filters, map[string]any{"term": map[string]any{"status.keyword": q.Status}})
+	}
+
+	size := q.Size
+	if size <= 0 {
+		size = defaultRCAJobPageSize
+	}
+	if size > maxQuerySize {
+		size = maxQuerySize
+	}
+
+	return s.search(ctx, map[string]any{
+		"from": q.From,
+		"size": size,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": filters,
+			},
+		},
+		"sort": []any{
+			map[string]any{"create_time": map[string]any{"order": "desc", "unmapped_type": "date"}},
+		},
+	})
+}
+
+// FindActive 查询尚未结束（排队中、运行中）的 RCA 任务，用于服务启动时恢复。
+func (s *RCAJobStore) FindActive(ctx context.Context) ([]domain.RCAJob, error) {
+	defer func(start time.Time) {
+		log.Debugw("OpenSearch",
+			"operation", "RCAJobStore.FindActive",
+			"index", RCAJobIndex,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}(time.Now())
+
+	if s.client == nil {
+		return nil, errors.New("opensearch client 未初始化")
+	}
+
+	return s.search(ctx, map[string]any{
+		"size": maxQuerySize,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": []any{
+					map[string]any{"terms": map[string]any{"status.keyword": []domain.RCAJobStatus{
+						domain.RCAJobStatusQueued, domain.RCAJobStatusRunning,
+					}}},
+				},
+			},
+		},
+		"sort": []any{
+			map[string]any{"create_time": map[string]any{"order": "asc", "unmapped_type": "date"}},
+		},
+	})
+}
+
+func (s *RCAJobStore) search(ctx context.Context, query map[string]any) ([]domain.RCAJob, error) {
+	body, err := encodeBody(query)
+	if err != nil {
+		return nil, err
+	}
+
+	req := opensearchapi.SearchRequest{
+		Index: []string{RCAJobIndex},
+		Body:  body,
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询 RCAJob 失败")
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.IsError() {
+		data, _ := readResponseBody(res.Body)
+		return nil, formatErrorMessage(data)
+	}
+	data, err := readResponseBody(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSearch[domain.RCAJob](data)
+}
+
+var _ core.RCAJobRepository = (*RCAJobStore)(nil)
@@ -0,0 +1,145 @@
+package opensearch
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRCAJobStore_Upsert(t *testing.T) {
+	Convey("TestRCAJobStore_Upsert", t, func() {
+		ctx := context.Background()
+
+		Convey("client 为 nil 返回错误", func() {
+			store := &RCAJobStore{client: nil}
+
+			err := store.Upsert(ctx, domain.RCAJob{JobID: 1})
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "opensearch client 未初始化")
+		})
+
+		Convey("job_id 为空返回错误", func() {
+			store := NewRCAJobStore(newMockClient(200, `{}`))
+
+			err := store.Upsert(ctx, domain.RCAJob{})
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "job_id 不能为空")
+		})
+
+		Convey("成功写入 RCAJob", func() {
+			store := NewRCAJobStore(newMockClient(201, `{"result": "created"}`))
+
+			err := store.Upsert(ctx, domain.RCAJob{
+				JobID:      1,
+				ProblemID:  10,
+				Status:     domain.RCAJobStatusQueued,
+				Trigger:    domain.RCAJobTriggerEvent,
+				CreateTime: time.Now(),
+			})
+
+			So(err, ShouldBeNil)
+		})
+
+		Convey("写入失败返回错误", func() {
+			store := NewRCAJobStore(newMockClientWithError(io.ErrUnexpectedEOF))
+
+			err := store.Upsert(ctx, domain.RCAJob{JobID: 1})
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "写入 RCAJob 失败")
+		})
+	})
+}
+
+func TestRCAJobStore_List(t *testing.T) {
+	Convey("TestRCAJobStore_List", t, func() {
+		ctx := context.Background()
+
+		Convey("client 为 nil 返回错误", func() {
+			store := &RCAJobStore{client: nil}
+
+			result, err := store.List(ctx, domain.RCAJobQuery{})
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+		})
+
+		Convey("按条件查询任务", func() {
+			body := `{
+				"hits": {
+					"hits": [
+						{"_source": {"job_id": 1, "problem_id": 10, "status": "failed", "attempt": 3}}
+					]
+				}
+			}`
+			store := NewRCAJobStore(newMockClient(200, body))
+
+			result, err := store.List(ctx, domain.RCAJobQuery{ProblemID: 10, Status: domain.RCAJobStatusFailed, Size: 100000})
+
+			So(err, ShouldBeNil)
+			So(result, ShouldHaveLength, 1)
+			So(result[0].Status, ShouldEqual, domain.RCAJobStatusFailed)
+			So(result[0].Attempt, ShouldEqual, 3)
+		})
+
+		Convey("查询失败返回错误", func() {
+			store := NewRCAJobStore(newMockClientWithError(io.ErrUnexpectedEOF))
+
+			result, err := store.List(ctx, domain.RCAJobQuery{})
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+			So(err.Error(), ShouldContainSubstring, "查询 RCAJob 失败")
+		})
+	})
+}
+
+func TestRCAJobStore_FindActive(t *testing.T) {
+	Convey("TestRCAJobStore_FindActive", t, func() {
+		ctx := context.Background()
+
+		Convey("client 为 nil 返回错误", func() {
+			store := &RCAJobStore{client: nil}
+
+			result, err := store.FindActive(ctx)
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+		})
+
+		Convey("返回未结束的任务", func() {
+			body := `{
+				"hits": {
+					"hits": [
+						{"_source": {"job_id": 1, "problem_id": 10, "status": "queued"}},
+						{"_source": {"job_id": 2, "problem_id": 11, "status": "running"}}
+					]
+				}
+			}`
+			store := NewRCAJobStore(newMockClient(200, body))
+
+			result, err := store.FindActive(ctx)
+
+			So(err, ShouldBeNil)
+			So(result, ShouldHaveLength, 2)
+			for _, job := range result {
+				So(job.Active(), ShouldBeTrue)
+			}
+		})
+
+		Convey("OpenSearch 返回错误状态", func() {
+			store := NewRCAJobStore(newMockClient(400, `{"error": {"reason": "bad request"}}`))
+
+			result, err := store.FindActive(ctx)
+
+			So(err, ShouldNotBeNil)
+			So(result, ShouldBeNil)
+		})
+	})
+}
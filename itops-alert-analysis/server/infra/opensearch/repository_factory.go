@@ -14,6 +14,9 @@ type RepositoryFactory struct {
 	problemStore             core.ProblemRepository
 	faultCausalStore         core.FaultCausalRepository
 	faultCausalRelationStore core.FaultCausalRelationRepository
+	deadLetterStore          core.DeadLetterRepository
+	correlationRecordStore   core.CorrelationRecordRepository
+	rcaJobStore              core.RCAJobRepository
 }
 
 func NewRepositoryFactory(client *opensearch.Client) *RepositoryFactory {
@@ -60,3 +63,24 @@ func (r *RepositoryFactory) FaultCausalRelations() core.FaultCausalRelationRepos
 	}
 	return r.faultCausalRelationStore
 }
+
+func (r *RepositoryFactory) DeadLetters() core.DeadLetterRepository {
+	if r.deadLetterStore == nil {
+		r.deadLetterStore = NewDeadLetterStore(r.client)
+	}
+	return r.deadLetterStore
+}
+
+func (r *RepositoryFactory) CorrelationRecords() core.CorrelationRecordRepository {
+	if r.correlationRecordStore == nil {
+		r.correlationRecordStore = NewCorrelationRecordStore(r.client)
+	}
+	return r.correlationRecordStore
+}
+
+func (r *RepositoryFactory) RCAJobs() core.RCAJobRepository {
+	if r.rcaJobStore == nil {
+		r.rcaJobStore = NewRCAJobStore(r.client)
+	}
+	return r.rcaJobStore
+}
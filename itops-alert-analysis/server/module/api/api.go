@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -25,11 +26,19 @@ type Server struct {
 	kafkaProducer  core.KafkaProducer
 	repoFactory    *opensearch.RepositoryFactory
 	problemHandler core.ProblemHandler
+	replayer       core.DeadLetterReplayer
+	objectClass    core.ObjectClassStatsProvider
+	ingest         core.IngestStatsProvider
+	failureModes   core.FailureModeClassifier
+	problemEditor  core.ProblemEditor
+	rcaRunner      core.RCARunner
 	router         *gin.Engine
 	httpServer     *http.Server
 }
 
-func New(cfg *config.Config, repoFactory *opensearch.RepositoryFactory, problemHandler core.ProblemHandler) (*Server, error) {
+func New(cfg *config.Config, repoFactory *opensearch.RepositoryFactory, problemHandler core.ProblemHandler,
+	replayer core.DeadLetterReplayer, objectClass core.ObjectClassStatsProvider, ingest core.IngestStatsProvider,
+	failureModes core.FailureModeClassifier, problemEditor core.ProblemEditor, rcaRunner core.RCARunner) (*Server, error) {
 	kafkaProducer, err := kafka.NewProducer(kafka.Config{
 		Brokers: []string{fmt.Sprintf("%s:%d", cfg.DepServices.MQ.MQHost, cfg.DepServices.MQ.MQPort)},
 		SASL: &kafka.SASLConfig{
@@ -48,6 +57,12 @@ func New(cfg *config.Config, repoFactory *opensearch.RepositoryFactory, problemH
 		kafkaProducer:  kafkaProducer,
 		repoFactory:    repoFactory,
 		problemHandler: problemHandler,
+		replayer:       replayer,
+		objectClass:    objectClass,
+		ingest:         ingest,
+		failureModes:   failureModes,
+		problemEditor:  problemEditor,
+		rcaRunner:      rcaRunner,
 	}, nil
 }
 
@@ -64,17 +79,30 @@ func (s *Server) Start(ctx context.Context) error {
 	v1 := api.Group("/v1")
 	{
 		v1.POST("/events", s.postEvent)
+		v1.POST("/events/:source", s.postEvent)
 		v1.GET("/events/info/:event_ids", s.queryEvents)
 		v1.GET("/fault-points/info/:fault_ids", s.queryFaultPoints)
 		v1.GET("/problems/info/:problem_ids", s.queryProblems)
 		v1.POST("/problems/:problem_id/close", s.closeProblem)
 		v1.POST("/problems/:problem_id/root-cause", s.setRootCause)
+		v1.GET("/problems/:problem_id/correlations", s.listCorrelationRecords)
+		v1.POST("/problems/:problem_id/split", s.splitProblem)
+		v1.POST("/problems/:problem_id/merge", s.mergeProblem)
+		v1.POST("/problems/:problem_id/rca", s.rerunRCA)
+		v1.GET("/rca/jobs", s.listRCAJobs)
+		v1.GET("/dead-letters", s.listDeadLetters)
+		v1.GET("/dead-letters/:dead_letter_id", s.getDeadLetter)
+		v1.POST("/dead-letters/:dead_letter_id/replay", s.replayDeadLetter)
+		v1.POST("/dead-letters/replay", s.replayDeadLetters)
+		v1.POST("/failure-modes/classify", s.classifyFailureMode)
 	}
 
 	// 调试接口
 	debug := v1.Group("/debug")
 	{
 		debug.GET("/problem/:problem_id/tree", s.problemTree)
+		debug.GET("/objectclass/stats", s.objectClassStats)
+		debug.GET("/ingest/stats", s.ingestStats)
 	}
 
 	addr := fmt.Sprintf(":%d", s.cfg.API.Port)
@@ -130,11 +158,18 @@ func (s *Server) postEvent(c *gin.Context) {
 		return
 	}
 
-	log.Debugf("收到Webhook发送数据，内容:%s", string(body))
+	// 路径中指定的数据源通过 Kafka header 传递，多数据源模式下优先于 payload 嗅探
+	source := strings.TrimSpace(strings.ToLower(c.Param("source")))
+	var headers map[string]string
+	if source != "" {
+		headers = map[string]string{core.HeaderEventSource: source}
+	}
+
+	log.Debugf("收到Webhook发送数据，source:%s，内容:%s", source, string(body))
 
 	key := fmt.Sprintf("%d", time.Now().UnixNano())
 
-	if err := s.kafkaProducer.PublishRawEvent(c.Request.Context(), key, body); err != nil {
+	if err := s.kafkaProducer.PublishRawEventWithHeaders(c.Request.Context(), key, body, headers); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("写入 Kafka 失败: %v", err)})
 		return
 	}
@@ -327,6 +362,12 @@ type closeProblemRequest struct {
 	ClosedBy string `json:"closed_by" binding:"required"`
 }
 
+type classifyFailureModeRequest struct {
+	ObjectClass string `json:"object_class"`
+	ItemKey     string `json:"item_key"`
+	Title       string `json:"title"`
+}
+
 type setRootCauseRequest struct {
 	RootCauseObjectID string `json:"root_cause_object_id" binding:"required"`
 	RootCauseFaultID  uint64 `json:"root_cause_fault_id" binding:"required"`
@@ -398,6 +439,59 @@ func (s *Server) problemTree(c *gin.Context) {
 	})
 }
 
+// objectClassStats 调试接口：查看对象类缓存规模、命中率与刷新耗时。
+// 服务未接入 Prometheus 等指标系统，这里只是当前实例的进程内计数：重启后清零，多副本部署时各实例分别统计，
+// 需逐个实例查询；不用于监控告警或跨实例汇总。
+// GET /api/itops-alert-analysis/v1/debug/objectclass/stats
+func (s *Server) objectClassStats(c *gin.Context) {
+	if s.objectClass == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "对象类缓存未启用"})
+		return
+	}
+	c.JSON(http.StatusOK, s.objectClass.ObjectClassStats())
+}
+
+// ingestStats 调试接口：查看原始事件消费积压、各通道队列深度与提交情况。
+// GET /api/itops-alert-analysis/v1/debug/ingest/stats
+func (s *Server) ingestStats(c *gin.Context) {
+	if s.ingest == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "原始事件消费未启用"})
+		return
+	}
+	c.JSON(http.StatusOK, s.ingest.IngestStats())
+}
+
+// classifyFailureMode 按当前故障模式目录归一监控项 key / 告警标题，用于验证目录规则。
+// POST /api/itops-alert-analysis/v1/failure-modes/classify
+func (s *Server) classifyFailureMode(c *gin.Context) {
+	if s.failureModes == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "故障模式目录未启用"})
+		return
+	}
+
+	var req classifyFailureModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("请求参数验证失败: %v", err)})
+		return
+	}
+	if req.ItemKey == "" && req.Title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "item_key 与 title 不能同时为空"})
+		return
+	}
+
+	match, ok := s.failureModes.ClassifyFailureMode(domain.RawEvent{
+		EntityObjectClass: req.ObjectClass,
+		EventType:         req.ItemKey,
+		EventTitle:        req.Title,
+	})
+	if !ok {
+		// 未命中时故障点沿用监控项 key 作为故障模式
+		c.JSON(http.StatusOK, gin.H{"matched": false, "fault_mode": req.ItemKey})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"matched": true, "fault_mode": match.Mode, "rule": match})
+}
+
 // buildTracePath 构建追踪路径，展示数据流转关系。
 func buildTracePath(problem domain.Problem, faultPoints []domain.FaultPointObject, events []domain.RawEvent) []gin.H {
 	var path []gin.H
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// maxMergeDepth 展开被合并问题关联记录的最大层数
+const maxMergeDepth = 10
+
+// listCorrelationRecords 查询问题的关联记录，包含被合并问题的历史记录。
+// GET /api/itops-alert-analysis/v1/problems/:problem_id/correlations
+func (s *Server) listCorrelationRecords(c *gin.Context) {
+	problemID := cast.ToUint64(c.Param("problem_id"))
+	if problemID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "problem_id 必须是有效的数字"})
+		return
+	}
+
+	var records []domain.CorrelationRecord
+	visited := map[uint64]bool{problemID: true}
+	pending := []uint64{problemID}
+	for depth := 0; len(pending) > 0 && depth < maxMergeDepth; depth++ {
+		items, err := s.repoFactory.CorrelationRecords().ListByProblemIDs(c.Request.Context(), pending)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		records = append(records, items...)
+
+		pending = nil
+		for _, item := range items {
+			if item.RecordType != domain.CorrelationRecordProblemMerge || visited[item.SourceProblemID] {
+				continue
+			}
+			visited[item.SourceProblemID] = true
+			pending = append(pending, item.SourceProblemID)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreateTime.Before(records[j].CreateTime)
+	})
+	c.JSON(http.StatusOK, gin.H{"problem_id": problemID, "items": records})
+}
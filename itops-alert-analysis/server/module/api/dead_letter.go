@@ -0,0 +1,160 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// maxReplayBatch 单次批量重放的最大死信数量
+const maxReplayBatch = 100
+
+// listDeadLetters 按条件分页查询死信。
+// GET /api/itops-alert-analysis/v1/dead-letters?stage=&status=&source=&start_time=&end_time=&from=&size=
+func (s *Server) listDeadLetters(c *gin.Context) {
+	q := domain.DeadLetterQuery{
+		Stage:       domain.DeadLetterStage(c.Query("stage")),
+		Status:      domain.DeadLetterStatus(c.Query("status")),
+		EventSource: c.Query("source"),
+		From:        cast.ToInt(c.Query("from")),
+		Size:        cast.ToInt(c.Query("size")),
+	}
+	for param, dst := range map[string]**time.Time{"start_time": &q.StartTime, "end_time": &q.EndTime} {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s 必须是 RFC3339 格式", param)})
+			return
+		}
+		*dst = &t
+	}
+
+	items, err := s.repoFactory.DeadLetters().List(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// getDeadLetter 查看单条死信详情（含原始 payload）。
+func (s *Server) getDeadLetter(c *gin.Context) {
+	deadLetterID := cast.ToUint64(c.Param("dead_letter_id"))
+	if deadLetterID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dead_letter_id 必须是有效的数字"})
+		return
+	}
+
+	items, err := s.repoFactory.DeadLetters().QueryByIDs(c.Request.Context(), []uint64{deadLetterID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "死信不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, items[0])
+}
+
+// replayDeadLetter 重放单条死信。
+func (s *Server) replayDeadLetter(c *gin.Context) {
+	if s.replayer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "dead letter replayer 未配置"})
+		return
+	}
+
+	deadLetterID := cast.ToUint64(c.Param("dead_letter_id"))
+	if deadLetterID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dead_letter_id 必须是有效的数字"})
+		return
+	}
+
+	items, err := s.repoFactory.DeadLetters().QueryByIDs(c.Request.Context(), []uint64{deadLetterID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "死信不存在"})
+		return
+	}
+	if items[0].Status == domain.DeadLetterStatusReplayed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "死信已重放"})
+		return
+	}
+
+	if err := s.replayer.ReplayDeadLetter(c.Request.Context(), items[0]); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("重放死信失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"dead_letter_id": deadLetterID, "status": domain.DeadLetterStatusReplayed})
+}
+
+// replayDeadLetters 批量重放死信，逐条返回结果。
+func (s *Server) replayDeadLetters(c *gin.Context) {
+	if s.replayer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "dead letter replayer 未配置"})
+		return
+	}
+
+	var req replayDeadLettersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("请求参数验证失败: %v", err)})
+		return
+	}
+	if len(req.DeadLetterIDs) > maxReplayBatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("单次最多重放 %d 条死信", maxReplayBatch)})
+		return
+	}
+
+	items, err := s.repoFactory.DeadLetters().QueryByIDs(c.Request.Context(), req.DeadLetterIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	found := make(map[uint64]domain.DeadLetter, len(items))
+	for _, item := range items {
+		found[item.DeadLetterID] = item
+	}
+
+	results := make([]replayDeadLetterResult, 0, len(req.DeadLetterIDs))
+	for _, id := range req.DeadLetterIDs {
+		result := replayDeadLetterResult{DeadLetterID: id, Status: domain.DeadLetterStatusReplayed}
+		dl, ok := found[id]
+		switch {
+		case !ok:
+			result.Error = "死信不存在"
+		case dl.Status == domain.DeadLetterStatusReplayed:
+			result.Error = "死信已重放"
+		default:
+			if err := s.replayer.ReplayDeadLetter(c.Request.Context(), dl); err != nil {
+				log.Warnf("重放死信失败: dead_letter_id=%d, err=%v", id, err)
+				result.Error = err.Error()
+			}
+		}
+		if result.Error != "" {
+			result.Status = dl.Status
+		}
+		results = append(results, result)
+	}
+	c.JSON(http.StatusOK, gin.H{"items": results})
+}
+
+type replayDeadLettersRequest struct {
+	DeadLetterIDs []uint64 `json:"dead_letter_ids" binding:"required,min=1"`
+}
+
+type replayDeadLetterResult struct {
+	DeadLetterID uint64                  `json:"dead_letter_id"`
+	Status       domain.DeadLetterStatus `json:"status"`
+	Error        string                  `json:"error,omitempty"`
+}
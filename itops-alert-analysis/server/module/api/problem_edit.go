@@ -0,0 +1,94 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+type splitProblemRequest struct {
+	FaultIDs   []uint64 `json:"fault_ids" binding:"required,min=1"`
+	OperatedBy string   `json:"operated_by" binding:"required"`
+}
+
+type mergeProblemRequest struct {
+	SourceProblemID uint64 `json:"source_problem_id" binding:"required"`
+	OperatedBy      string `json:"operated_by" binding:"required"`
+}
+
+// splitProblem 将选定的故障点从问题中拆分到新问题。
+// POST /api/itops-alert-analysis/v1/problems/:problem_id/split
+func (s *Server) splitProblem(c *gin.Context) {
+	if s.problemEditor == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "problem editor 未配置"})
+		return
+	}
+	problemID := cast.ToUint64(c.Param("problem_id"))
+	if problemID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "problem_id 必须是有效的数字"})
+		return
+	}
+	var req splitProblemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("请求参数验证失败: %v", err)})
+		return
+	}
+
+	problem, err := s.problemEditor.SplitProblem(c.Request.Context(), problemID, req.FaultIDs, req.OperatedBy)
+	if err != nil {
+		c.JSON(problemEditStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"problem_id":     problemID,
+		"new_problem_id": problem.ProblemID,
+		"fault_ids":      problem.RelationIDs,
+	})
+}
+
+// mergeProblem 将 source_problem_id 问题手动合并到当前问题。
+// POST /api/itops-alert-analysis/v1/problems/:problem_id/merge
+func (s *Server) mergeProblem(c *gin.Context) {
+	if s.problemEditor == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "problem editor 未配置"})
+		return
+	}
+	problemID := cast.ToUint64(c.Param("problem_id"))
+	if problemID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "problem_id 必须是有效的数字"})
+		return
+	}
+	var req mergeProblemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("请求参数验证失败: %v", err)})
+		return
+	}
+
+	problem, err := s.problemEditor.MergeProblems(c.Request.Context(), problemID, req.SourceProblemID, req.OperatedBy)
+	if err != nil {
+		c.JSON(problemEditStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"problem_id":        problemID,
+		"source_problem_id": req.SourceProblemID,
+		"fault_ids":         problem.RelationIDs,
+		"problem_level":     problem.ProblemLevel,
+	})
+}
+
+// problemEditStatus 将问题操作错误映射为 HTTP 状态码。
+func problemEditStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrProblemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrProblemNotOpen), errors.Is(err, domain.ErrInvalidOperation):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+type rerunRCARequest struct {
+	OperatedBy string `json:"operated_by" binding:"required"`
+}
+
+// listRCAJobs 按条件分页查询 RCA 任务。
+// GET /api/itops-alert-analysis/v1/rca/jobs?problem_id=&status=&from=&size=
+func (s *Server) listRCAJobs(c *gin.Context) {
+	q := domain.RCAJobQuery{
+		ProblemID: cast.ToUint64(c.Query("problem_id")),
+		Status:    domain.RCAJobStatus(c.Query("status")),
+		From:      cast.ToInt(c.Query("from")),
+		Size:      cast.ToInt(c.Query("size")),
+	}
+
+	items, err := s.repoFactory.RCAJobs().List(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// rerunRCA 手动重新分析问题根因。
+// POST /api/itops-alert-analysis/v1/problems/:problem_id/rca
+func (s *Server) rerunRCA(c *gin.Context) {
+	if s.rcaRunner == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "rca runner 未配置"})
+		return
+	}
+	problemID := cast.ToUint64(c.Param("problem_id"))
+	if problemID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "problem_id 必须是有效的数字"})
+		return
+	}
+	var req rerunRCARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("请求参数验证失败: %v", err)})
+		return
+	}
+
+	job, err := s.rcaRunner.RerunRCA(c.Request.Context(), problemID, req.OperatedBy)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, domain.ErrProblemNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, domain.ErrRCAJobRunning):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
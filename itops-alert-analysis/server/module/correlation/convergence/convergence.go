@@ -0,0 +1,126 @@
+// Package convergence 实现故障点收敛键策略：同一实体下收敛键相同的发生事件合并到同一故障点。
+package convergence
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+)
+
+// KeyFunc 根据事件与规则生成收敛键，无法生成时返回空字符串，由调用方回退到默认策略。
+type KeyFunc func(raw domain.RawEvent, rule config.ConvergenceRule) string
+
+// Registry 管理收敛键策略。
+type Registry struct {
+	strategies map[string]KeyFunc
+}
+
+// NewRegistry 创建空注册表。
+func NewRegistry() *Registry {
+	return &Registry{strategies: make(map[string]KeyFunc)}
+}
+
+// Register 注册收敛键策略。
+func (r *Registry) Register(name string, fn KeyFunc) {
+	key := strings.TrimSpace(strings.ToLower(name))
+	if key == "" || fn == nil {
+		return
+	}
+	r.strategies[key] = fn
+}
+
+// DefaultRegistry 返回包含内置策略的注册表。
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(config.ConvergenceItemKey, itemKey)
+	r.Register(config.ConvergenceTriggerName, triggerName)
+	r.Register(config.ConvergenceNormalizedTitle, normalizedTitle)
+	r.Register(config.ConvergenceLabels, labelSubset)
+	return r
+}
+
+// Key 按配置为事件选择规则并生成收敛键。
+// 策略未注册或无法生成收敛键时回退到 item_key，保证与未配置收敛策略时的行为一致。
+func (r *Registry) Key(cfg config.ConvergenceConfig, raw domain.RawEvent) string {
+	rule := SelectRule(cfg, raw)
+	strategy := strings.TrimSpace(strings.ToLower(rule.Strategy))
+	if fn, ok := r.strategies[strategy]; ok && strategy != config.ConvergenceItemKey {
+		if key := fn(raw, rule); key != "" {
+			return key
+		}
+	}
+	return itemKey(raw, rule)
+}
+
+// SelectRule 选择与事件最匹配的规则：同时匹配数据源与对象类 > 仅匹配对象类 > 仅匹配数据源 > Default。
+func SelectRule(cfg config.ConvergenceConfig, raw domain.RawEvent) config.ConvergenceRule {
+	best, bestScore := cfg.Default, 0
+	for _, rule := range cfg.Rules {
+		if rule.Source != "" && !strings.EqualFold(rule.Source, raw.EventSource) {
+			continue
+		}
+		if rule.ObjectClass != "" && rule.ObjectClass != raw.EntityObjectClass {
+			continue
+		}
+		score := 0
+		if rule.Source != "" {
+			score++
+		}
+		if rule.ObjectClass != "" {
+			score += 2
+		}
+		if score > bestScore {
+			best, bestScore = rule, score
+		}
+	}
+	return best
+}
+
+// itemKey 按 EventType（监控项 key）收敛，收敛键不带前缀，与升级前的 fault_mode 保持一致。
+func itemKey(raw domain.RawEvent, _ config.ConvergenceRule) string {
+	return raw.EventType
+}
+
+// triggerName 按触发器名称（事件标题）收敛，同一触发器的不同监控项合并。
+func triggerName(raw domain.RawEvent, _ config.ConvergenceRule) string {
+	title := strings.TrimSpace(raw.EventTitle)
+	if title == "" {
+		return ""
+	}
+	return config.ConvergenceTriggerName + ":" + title
+}
+
+var (
+	digitsPattern = regexp.MustCompile(`[0-9]+(\.[0-9]+)*`)
+	spacesPattern = regexp.MustCompile(`\s+`)
+)
+
+// normalizedTitle 按去除数字后的事件标题收敛，如 "磁盘使用率 91%" 与 "磁盘使用率 95%" 合并。
+func normalizedTitle(raw domain.RawEvent, _ config.ConvergenceRule) string {
+	title := digitsPattern.ReplaceAllString(strings.ToLower(raw.EventTitle), "#")
+	title = strings.TrimSpace(spacesPattern.ReplaceAllString(title, " "))
+	if title == "" || title == "#" {
+		return ""
+	}
+	return config.ConvergenceNormalizedTitle + ":" + title
+}
+
+// labelSubset 按规则指定的标签子集收敛，标签均缺失时无法生成收敛键。
+func labelSubset(raw domain.RawEvent, rule config.ConvergenceRule) string {
+	names := append([]string(nil), rule.Labels...)
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		if v, ok := raw.EventLabels[name]; ok && v != "" {
+			parts = append(parts, name+"="+v)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return config.ConvergenceLabels + ":" + strings.Join(parts, ",")
+}
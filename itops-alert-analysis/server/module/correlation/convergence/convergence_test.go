@@ -0,0 +1,94 @@
+package convergence
+
+import (
+	"testing"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSelectRule(t *testing.T) {
+	Convey("TestSelectRule", t, func() {
+		cfg := config.ConvergenceConfig{
+			Default: config.ConvergenceRule{Strategy: config.ConvergenceItemKey},
+			Rules: []config.ConvergenceRule{
+				{Source: "zabbix_webhook", Strategy: config.ConvergenceTriggerName},
+				{ObjectClass: "pod", Strategy: config.ConvergenceLabels},
+				{Source: "zabbix_webhook", ObjectClass: "pod", Strategy: config.ConvergenceNormalizedTitle},
+			},
+		}
+
+		Convey("同时匹配数据源与对象类的规则优先", func() {
+			rule := SelectRule(cfg, domain.RawEvent{EventSource: "zabbix_webhook", EntityObjectClass: "pod"})
+			So(rule.Strategy, ShouldEqual, config.ConvergenceNormalizedTitle)
+		})
+
+		Convey("对象类规则优先于数据源规则", func() {
+			rule := SelectRule(cfg, domain.RawEvent{EventSource: "prometheus_alertmanager", EntityObjectClass: "pod"})
+			So(rule.Strategy, ShouldEqual, config.ConvergenceLabels)
+
+			rule = SelectRule(cfg, domain.RawEvent{EventSource: "zabbix_webhook", EntityObjectClass: "host"})
+			So(rule.Strategy, ShouldEqual, config.ConvergenceTriggerName)
+		})
+
+		Convey("未命中时使用默认规则", func() {
+			rule := SelectRule(cfg, domain.RawEvent{EventSource: "json_mapping", EntityObjectClass: "host"})
+			So(rule.Strategy, ShouldEqual, config.ConvergenceItemKey)
+		})
+	})
+}
+
+func TestRegistry_Key(t *testing.T) {
+	Convey("TestRegistry_Key", t, func() {
+		r := DefaultRegistry()
+		raw := domain.RawEvent{
+			EventType:   "vfs.fs.size[/data,pused]",
+			EventTitle:  "Disk usage 91% on /data",
+			EventLabels: map[string]string{"alertname": "DiskFull", "namespace": "prod", "pod": "api-1"},
+		}
+		withDefault := func(rule config.ConvergenceRule) config.ConvergenceConfig {
+			return config.ConvergenceConfig{Default: rule}
+		}
+
+		Convey("默认按 item_key 收敛", func() {
+			So(r.Key(config.ConvergenceConfig{}, raw), ShouldEqual, "vfs.fs.size[/data,pused]")
+		})
+
+		Convey("按触发器名称收敛", func() {
+			key := r.Key(withDefault(config.ConvergenceRule{Strategy: config.ConvergenceTriggerName}), raw)
+			So(key, ShouldEqual, "trigger_name:Disk usage 91% on /data")
+		})
+
+		Convey("按去除数字后的标题收敛", func() {
+			rule := withDefault(config.ConvergenceRule{Strategy: config.ConvergenceNormalizedTitle})
+			other := raw
+			other.EventTitle = "Disk usage  95%  on /data"
+
+			So(r.Key(rule, raw), ShouldEqual, "normalized_title:disk usage #% on /data")
+			So(r.Key(rule, other), ShouldEqual, r.Key(rule, raw))
+		})
+
+		Convey("按标签子集收敛，标签顺序不影响收敛键", func() {
+			rule := withDefault(config.ConvergenceRule{Strategy: config.ConvergenceLabels, Labels: []string{"namespace", "alertname"}})
+			So(r.Key(rule, raw), ShouldEqual, "labels:alertname=DiskFull,namespace=prod")
+		})
+
+		Convey("无法生成收敛键或策略未注册时回退到 item_key", func() {
+			rule := withDefault(config.ConvergenceRule{Strategy: config.ConvergenceLabels, Labels: []string{"cluster"}})
+			So(r.Key(rule, raw), ShouldEqual, raw.EventType)
+
+			rule = withDefault(config.ConvergenceRule{Strategy: "unknown"})
+			So(r.Key(rule, raw), ShouldEqual, raw.EventType)
+		})
+
+		Convey("支持注册自定义策略", func() {
+			r.Register("entity_class", func(raw domain.RawEvent, _ config.ConvergenceRule) string {
+				return "entity_class:" + raw.EntityObjectClass
+			})
+			raw.EntityObjectClass = "host"
+
+			So(r.Key(withDefault(config.ConvergenceRule{Strategy: "entity_class"}), raw), ShouldEqual, "entity_class:host")
+		})
+	})
+}
@@ -11,13 +11,17 @@ import (
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/dip"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/kafka"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/maintenance"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/objectclass"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/standardizer"
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
 )
 
-const defaultCheckInterval = 5 * time.Minute //多长时间运行一次
+const (
+	defaultCheckInterval = 5 * time.Minute //多长时间运行一次
+	defaultLaneCapacity  = 100             // 并行消费每个通道默认缓冲的事件数
+)
 
 // Service
 type Service struct {
@@ -25,8 +29,11 @@ type Service struct {
 	faultPoint       *FaultPointStage
 	problem          *ProblemStage
 	objectClassCache *objectclass.ObjectClass
+	deadLetter       *DeadLetterStage
 	kafkaProducer    core.KafkaProducer
 	kafkaConsumer    core.KafkaConsumer
+	dlqProducer      core.KafkaProducer
+	dlqConsumer      core.KafkaConsumer
 }
 
 // New 初始化数据收敛
@@ -67,37 +74,101 @@ func New(
 			Username: cfg.DepServices.MQ.Auth.Username,
 			Password: cfg.DepServices.MQ.Auth.Password,
 		},
-		Topic:   cfg.Kafka.RawEvents.Topic,
-		GroupID: cfg.Kafka.RawEvents.ConsumerGroup,
+		Topic:         cfg.Kafka.RawEvents.Topic,
+		GroupID:       cfg.Kafka.RawEvents.ConsumerGroup,
+		QueueCapacity: rawEventsQueueCapacity(cfg.Kafka.RawEvents.Parallel),
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "创建kafka消费者失败")
 	}
 
+	// 创建死信流的 Producer/Consumer（未配置 topic 时不启用死信）
+	var dlqProducer core.KafkaProducer
+	var dlqConsumer core.KafkaConsumer
+	if cfg.Kafka.DeadLetters.Topic != "" {
+		dlqProducer, err = kafka.NewProducer(kafka.Config{
+			Brokers: []string{fmt.Sprintf("%s:%d", cfg.DepServices.MQ.MQHost, cfg.DepServices.MQ.MQPort)},
+			SASL: &kafka.SASLConfig{
+				Enabled:  true,
+				Username: cfg.DepServices.MQ.Auth.Username,
+				Password: cfg.DepServices.MQ.Auth.Password,
+			},
+			Topic: cfg.Kafka.DeadLetters.Topic,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "创建死信kafka生产者失败")
+		}
+
+		dlqConsumer, err = kafka.NewConsumer(kafka.Config{
+			Brokers: []string{fmt.Sprintf("%s:%d", cfg.DepServices.MQ.MQHost, cfg.DepServices.MQ.MQPort)},
+			SASL: &kafka.SASLConfig{
+				Enabled:  true,
+				Username: cfg.DepServices.MQ.Auth.Username,
+				Password: cfg.DepServices.MQ.Auth.Password,
+			},
+			Topic:   cfg.Kafka.DeadLetters.Topic,
+			GroupID: cfg.Kafka.DeadLetters.ConsumerGroup,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "创建死信kafka消费者失败")
+		}
+	}
+
 	// 创建标准化器（使用对象类缓存）
-	std, err := standardizer.Build(cfg, objectClassCache)
+	std, err := standardizer.BuildReloadable(cfgManager, objectClassCache)
 	if err != nil {
 		return nil, errors.Wrap(err, "初始化 standardizer 失败")
 	}
 
 	// 创建空间相关性检查器
-	spatialChecker := dip.NewSpatialChecker(dipClient)
+	spatialChecker := dip.NewSpatialChecker(dipClient, func() config.SpatialCorrelationConfig {
+		return cfgManager.GetConfig().AppConfig.Problem.Spatial
+	})
 
 	// 创建问题阶段
 	problemStage := NewProblemStage(cfgManager, repoFactory, kafkaProducer, spatialChecker)
 	faultStage := NewFaultPointStage(cfgManager, repoFactory, problemStage)
-	ingestStage := NewIngestStage(repoFactory, faultStage, std, kafkaConsumer)
+	// 创建维护窗口匹配器，子图选择器通过知识网络展开
+	var subgraphQuerier maintenance.SubGraphQuerier
+	if dipClient != nil {
+		subgraphQuerier = dipClient
+	}
+	maintenanceMatcher := maintenance.NewMatcher(cfgManager, subgraphQuerier)
+	ingestStage := NewIngestStage(cfgManager, repoFactory, faultStage, std, kafkaConsumer, dlqProducer, maintenanceMatcher)
+
+	// 对象类缓存刷新后，将未解析实体重新关联到真实对象
+	objectClassCache.OnWarmup(NewEntityRelinker(repoFactory, objectClassCache, faultStage).OnWarmup)
+
+	var deadLetterStage *DeadLetterStage
+	if dlqConsumer != nil {
+		deadLetterStage = NewDeadLetterStage(repoFactory.DeadLetters(), dlqConsumer)
+	}
 
 	return &Service{
 		ingest:           ingestStage,
 		faultPoint:       faultStage,
 		problem:          problemStage,
+		deadLetter:       deadLetterStage,
 		objectClassCache: objectClassCache,
 		kafkaProducer:    kafkaProducer,
 		kafkaConsumer:    kafkaConsumer,
+		dlqProducer:      dlqProducer,
+		dlqConsumer:      dlqConsumer,
 	}, nil
 }
 
+// rawEventsQueueCapacity 并行消费时 Reader 预取足够的消息以填满各通道，顺序消费时只预取一条。
+func rawEventsQueueCapacity(cfg config.ParallelConsumeConfig) int {
+	if cfg.Workers <= 1 {
+		return 0
+	}
+	capacity := cfg.LaneCapacity
+	if capacity <= 0 {
+		capacity = defaultLaneCapacity
+	}
+	return cfg.Workers * capacity
+}
+
 // Start 启动关联分析模块（启动 Ingest 消费）。
 func (c *Service) Start(ctx context.Context) error {
 	eg, egCtx := errgroup.WithContext(ctx)
@@ -120,6 +191,16 @@ func (c *Service) Start(ctx context.Context) error {
 		return nil
 	})
 
+	// 启动死信消费
+	if c.deadLetter != nil {
+		eg.Go(func() error {
+			if err := c.deadLetter.Start(egCtx); err != nil && !errors.Is(err, context.Canceled) {
+				return errors.Wrap(err, "dead letter stage 启动失败")
+			}
+			return nil
+		})
+	}
+
 	// 启动问题失效检查器（内置在 ProblemStage）
 	if c.problem != nil {
 		eg.Go(func() error {
@@ -162,6 +243,41 @@ func (c *Service) HandleFaultPointRecovered(ctx context.Context, faultID uint64)
 	return c.problem.HandleFaultPointRecovered(ctx, faultID)
 }
 
+// HandleFaultPointLevelChanged 实现 ProblemHandler 接口 - 处理故障点等级变化。
+func (c *Service) HandleFaultPointLevelChanged(ctx context.Context, fp domain.FaultPointObject) error {
+	return c.problem.HandleFaultPointLevelChanged(ctx, fp)
+}
+
+// SplitProblem 实现 ProblemEditor 接口 - 拆分问题。
+func (c *Service) SplitProblem(ctx context.Context, problemID uint64, faultIDs []uint64, by string) (*domain.Problem, error) {
+	return c.problem.SplitProblem(ctx, problemID, faultIDs, by)
+}
+
+// MergeProblems 实现 ProblemEditor 接口 - 手动合并问题。
+func (c *Service) MergeProblems(ctx context.Context, targetID, sourceID uint64, by string) (*domain.Problem, error) {
+	return c.problem.MergeProblems(ctx, targetID, sourceID, by)
+}
+
+// IngestStats 实现 IngestStatsProvider 接口 - 原始事件消费指标。
+func (c *Service) IngestStats() domain.ConsumerStats {
+	return c.ingest.IngestStats()
+}
+
+// ReplayDeadLetter 实现 DeadLetterReplayer 接口 - 重放死信。
+func (c *Service) ReplayDeadLetter(ctx context.Context, dl domain.DeadLetter) error {
+	return c.ingest.ReplayDeadLetter(ctx, dl)
+}
+
+// ObjectClassStats 实现 ObjectClassStatsProvider 接口 - 对象类缓存运行指标。
+func (c *Service) ObjectClassStats() domain.ObjectClassCacheStats {
+	return c.objectClassCache.Stats()
+}
+
+// ClassifyFailureMode 实现 FailureModeClassifier 接口 - 故障模式归一。
+func (c *Service) ClassifyFailureMode(event domain.RawEvent) (domain.FailureModeMatch, bool) {
+	return c.faultPoint.ClassifyFailureMode(event)
+}
+
 // Close 关闭 CorrelationService 持有的资源。
 func (c *Service) Close() error {
 	var errs []error
@@ -175,6 +291,16 @@ func (c *Service) Close() error {
 			errs = append(errs, errors.Wrap(err, "close kafkaProducer"))
 		}
 	}
+	if c.dlqConsumer != nil {
+		if err := c.dlqConsumer.Close(); err != nil {
+			errs = append(errs, errors.Wrap(err, "close dlqConsumer"))
+		}
+	}
+	if c.dlqProducer != nil {
+		if err := c.dlqProducer.Close(); err != nil {
+			errs = append(errs, errors.Wrap(err, "close dlqProducer"))
+		}
+	}
 
 	// 关闭对象类缓存
 	if c.objectClassCache != nil {
@@ -191,3 +317,18 @@ func (c *Service) Close() error {
 
 // 确保 CorrelationService 实现了 ProblemHandler 接口
 var _ core.ProblemHandler = (*Service)(nil)
+
+// 确保 CorrelationService 实现了 DeadLetterReplayer 接口
+var _ core.DeadLetterReplayer = (*Service)(nil)
+
+// 确保 CorrelationService 实现了 ObjectClassStatsProvider 接口
+var _ core.ObjectClassStatsProvider = (*Service)(nil)
+
+// 确保 CorrelationService 实现了 IngestStatsProvider 接口
+var _ core.IngestStatsProvider = (*Service)(nil)
+
+// 确保 CorrelationService 实现了 FailureModeClassifier 接口
+var _ core.FailureModeClassifier = (*Service)(nil)
+
+// 确保 CorrelationService 实现了 ProblemEditor 接口
+var _ core.ProblemEditor = (*Service)(nil)
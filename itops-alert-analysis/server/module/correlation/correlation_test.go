@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config/configtest"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/kafka"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
@@ -33,9 +34,9 @@ func newTestConfig() *config.Config {
 	}
 }
 
-// newTestConfigManager 创建测试用 ConfigManager
-func newTestConfigManager() *config.ConfigManager {
-	return config.NewTestConfigManager(newTestConfig())
+// newTestConfigManager 创建测试用配置提供者
+func newTestConfigManager() config.Provider {
+	return configtest.NewProvider(newTestConfig())
 }
 
 func TestService_Close(t *testing.T) {
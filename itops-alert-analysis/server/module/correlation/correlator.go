@@ -0,0 +1,423 @@
+package correlation
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/dip"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/timex"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTemporalWindow   = 60 * time.Second
+	defaultSemanticMinScore = 0.6
+)
+
+// Correlator 组合空间、时间突发、语义相关性策略，判断故障点可合并到哪些问题。
+// 每个候选问题都会生成一条合并决策，记录各策略的得分与阈值。
+type Correlator struct {
+	cfgManager     config.Provider
+	repoFactory    *opensearch.RepositoryFactory
+	spatialChecker *dip.SpatialChecker
+}
+
+// NewCorrelator 创建相关性判断器。
+func NewCorrelator(cfgManager config.Provider, repoFactory *opensearch.RepositoryFactory, spatialChecker *dip.SpatialChecker) *Correlator {
+	return &Correlator{
+		cfgManager:     cfgManager,
+		repoFactory:    repoFactory,
+		spatialChecker: spatialChecker,
+	}
+}
+
+// Correlate 按配置的策略组合判断故障点与候选问题的相关性，返回需要合并的问题及每个候选问题的决策。
+// 空间相关性判断失败时，若它是唯一策略或 combine=all 需要它命中则返回错误，交由摄取重试与死信处理；
+// 其他策略仍可决定合并时按未命中处理，失败原因记录在得分详情中。时间突发、语义策略失败时记录日志并视为未命中。
+func (c *Correlator) Correlate(
+	ctx context.Context,
+	fp domain.FaultPointObject,
+	problems []domain.Problem,
+) ([]domain.Problem, []domain.CorrelationDecision, error) {
+	if len(problems) == 0 {
+		return nil, nil, nil
+	}
+
+	cfg := c.cfgManager.GetConfig().AppConfig.Problem.Correlation
+	strategies := enabledStrategies(cfg.Strategies)
+	combine := cfg.Combine
+	if combine != config.CorrelationCombineAll {
+		combine = config.CorrelationCombineAny
+	}
+
+	// 时间突发与语义策略需要比较问题中已有的故障点，一次性加载
+	var members map[uint64][]domain.FaultPointObject
+	if containsStrategy(strategies, config.CorrelationTemporal) || containsStrategy(strategies, config.CorrelationSemantic) {
+		var err error
+		members, err = c.problemFaultPoints(ctx, problems)
+		if err != nil {
+			log.Warnf("加载问题故障点失败，时间突发与语义相关性按未命中处理: %v", err)
+		}
+	}
+
+	scores := make(map[uint64][]domain.CorrelationScore, len(problems))
+	for _, strategy := range strategies {
+		var byProblem map[uint64]domain.CorrelationScore
+		switch strategy {
+		case config.CorrelationSpatial:
+			var err error
+			byProblem, err = c.spatialScores(ctx, fp, problems)
+			if err != nil {
+				if len(strategies) == 1 || combine == config.CorrelationCombineAll {
+					return nil, nil, errors.Wrap(err, "空间相关性判断失败")
+				}
+				log.Warnf("故障点 %d 空间相关性判断失败，由其他策略决定是否合并: %v", fp.FaultID, err)
+				byProblem = spatialErrorScores(problems, err)
+			}
+		case config.CorrelationTemporal:
+			byProblem = temporalScores(cfg.Temporal, fp, problems, members)
+		case config.CorrelationSemantic:
+			byProblem = semanticScores(cfg.Semantic, fp, problems, members)
+		}
+		for _, problem := range problems {
+			score, ok := byProblem[problem.ProblemID]
+			if !ok {
+				score = domain.CorrelationScore{Strategy: strategy}
+			}
+			scores[problem.ProblemID] = append(scores[problem.ProblemID], score)
+		}
+	}
+
+	now := timex.NowLocalTime()
+	var targets []domain.Problem
+	decisions := make([]domain.CorrelationDecision, 0, len(problems))
+	for _, problem := range problems {
+		decision := domain.CorrelationDecision{
+			FaultID:      fp.FaultID,
+			ProblemID:    problem.ProblemID,
+			Combine:      combine,
+			Merged:       combineScores(combine, scores[problem.ProblemID]),
+			Scores:       scores[problem.ProblemID],
+			DecisionTime: now,
+		}
+		if decision.Merged {
+			targets = append(targets, problem)
+		}
+		log.Infof("故障点 %d 与问题 %d 相关性判断（%s）: merged=%t, scores=%s",
+			fp.FaultID, problem.ProblemID, combine, decision.Merged, formatScores(decision.Scores))
+		decisions = append(decisions, decision)
+	}
+	return targets, decisions, nil
+}
+
+// spatialScores 计算空间相关得分。
+func (c *Correlator) spatialScores(ctx context.Context, fp domain.FaultPointObject, problems []domain.Problem) (map[uint64]domain.CorrelationScore, error) {
+	matches, err := c.spatialChecker.ScoreProblems(ctx, fp, problems)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint64]domain.CorrelationScore, len(matches))
+	for _, m := range matches {
+		score := domain.CorrelationScore{
+			Strategy:  config.CorrelationSpatial,
+			Score:     m.Score,
+			Threshold: m.MinScore,
+			Matched:   m.Matched,
+		}
+		if m.Hops >= 0 {
+			score.Detail = fmt.Sprintf("entity_id=%s, hops=%d", m.EntityID, m.Hops)
+			score.EntityPath = m.Path
+		}
+		result[m.Problem.ProblemID] = score
+	}
+	return result, nil
+}
+
+// spatialErrorScores 空间相关性判断失败时全部候选问题按未命中处理，失败原因记录在得分详情中。
+func spatialErrorScores(problems []domain.Problem, err error) map[uint64]domain.CorrelationScore {
+	result := make(map[uint64]domain.CorrelationScore, len(problems))
+	for _, problem := range problems {
+		result[problem.ProblemID] = domain.CorrelationScore{
+			Strategy: config.CorrelationSpatial,
+			Detail:   fmt.Sprintf("error=%v", err),
+		}
+	}
+	return result
+}
+
+// problemFaultPoints 加载候选问题中的故障点，key 为问题 ID。
+func (c *Correlator) problemFaultPoints(ctx context.Context, problems []domain.Problem) (map[uint64][]domain.FaultPointObject, error) {
+	var ids []uint64
+	seen := make(map[uint64]struct{})
+	for _, problem := range problems {
+		for _, id := range problem.RelationIDs {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	faultPoints, err := c.repoFactory.FaultPoints().QueryByIDs(ctx, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询问题故障点失败")
+	}
+	byID := make(map[uint64]domain.FaultPointObject, len(faultPoints))
+	for _, item := range faultPoints {
+		byID[item.FaultID] = item
+	}
+
+	members := make(map[uint64][]domain.FaultPointObject, len(problems))
+	for _, problem := range problems {
+		for _, id := range problem.RelationIDs {
+			if item, ok := byID[id]; ok {
+				members[problem.ProblemID] = append(members[problem.ProblemID], item)
+			}
+		}
+	}
+	return members, nil
+}
+
+// temporalScores 计算时间突发得分：问题中与故障点属于同一对象组的故障点，发生时间差越小得分越高，超出窗口为 0。
+func temporalScores(
+	cfg config.TemporalCorrelationConfig,
+	fp domain.FaultPointObject,
+	problems []domain.Problem,
+	members map[uint64][]domain.FaultPointObject,
+) map[uint64]domain.CorrelationScore {
+	window := cfg.Window
+	if window <= 0 {
+		window = defaultTemporalWindow
+	}
+	groups := objectGroupsOf(cfg.Groups, fp)
+
+	result := make(map[uint64]domain.CorrelationScore, len(problems))
+	for _, problem := range problems {
+		score := domain.CorrelationScore{Strategy: config.CorrelationTemporal, Threshold: cfg.MinScore}
+		found := false
+		for _, other := range members[problem.ProblemID] {
+			group, ok := sharedGroup(groups, other)
+			if !ok {
+				continue
+			}
+			delta := fp.FaultOccurTime.Sub(other.FaultOccurTime)
+			if delta < 0 {
+				delta = -delta
+			}
+			if delta > window {
+				continue
+			}
+			s := 1 - float64(delta)/float64(window)
+			if !found || s > score.Score {
+				found = true
+				score.Score = s
+				score.Detail = fmt.Sprintf("fault_id=%d, group=%s, delta=%s", other.FaultID, group.Name, delta)
+			}
+		}
+		score.Matched = found && score.Score >= cfg.MinScore
+		result[problem.ProblemID] = score
+	}
+	return result
+}
+
+// objectGroupsOf 返回故障点对象所属的对象组。
+func objectGroupsOf(groups []config.ObjectGroup, fp domain.FaultPointObject) []config.ObjectGroup {
+	var result []config.ObjectGroup
+	for _, group := range groups {
+		if inObjectGroup(group, fp) {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+// sharedGroup 返回 other 所属的第一个组，groups 为故障点所属的对象组。
+func sharedGroup(groups []config.ObjectGroup, other domain.FaultPointObject) (config.ObjectGroup, bool) {
+	for _, group := range groups {
+		if inObjectGroup(group, other) {
+			return group, true
+		}
+	}
+	return config.ObjectGroup{}, false
+}
+
+// inObjectGroup 判断故障点对象是否属于对象组。
+func inObjectGroup(group config.ObjectGroup, fp domain.FaultPointObject) bool {
+	for _, class := range group.ObjectClasses {
+		if class == fp.EntityObjectClass {
+			return true
+		}
+	}
+	for _, id := range group.EntityIDs {
+		if id == fp.EntityObjectID {
+			return true
+		}
+	}
+	return false
+}
+
+// semanticScores 计算语义得分：故障点名称与描述和问题中各故障点（问题无故障点时为问题名称与描述）的最高余弦相似度。
+func semanticScores(
+	cfg config.SemanticCorrelationConfig,
+	fp domain.FaultPointObject,
+	problems []domain.Problem,
+	members map[uint64][]domain.FaultPointObject,
+) map[uint64]domain.CorrelationScore {
+	minScore := cfg.MinScore
+	if minScore <= 0 {
+		minScore = defaultSemanticMinScore
+	}
+	source := termVector(fp.FaultName + " " + fp.FaultDescription)
+
+	result := make(map[uint64]domain.CorrelationScore, len(problems))
+	for _, problem := range problems {
+		score := domain.CorrelationScore{Strategy: config.CorrelationSemantic, Threshold: minScore}
+		candidates := members[problem.ProblemID]
+		if len(candidates) == 0 {
+			if s := cosineSimilarity(source, termVector(problem.ProblemName+" "+problem.ProblemDescription)); s > 0 {
+				score.Score = s
+				score.Detail = fmt.Sprintf("problem_name=%s", problem.ProblemName)
+			}
+		}
+		for _, other := range candidates {
+			s := cosineSimilarity(source, termVector(other.FaultName+" "+other.FaultDescription))
+			if s > score.Score {
+				score.Score = s
+				score.Detail = fmt.Sprintf("fault_id=%d, fault_name=%s", other.FaultID, other.FaultName)
+			}
+		}
+		score.Matched = score.Score >= minScore
+		result[problem.ProblemID] = score
+	}
+	return result
+}
+
+// termVector 将文本切分为词频向量：统一小写、数字归一为 #，英文按词切分，中文按相邻二字切分。
+func termVector(text string) map[string]int {
+	terms := make(map[string]int)
+	var word []rune
+	var han []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			terms[string(word)]++
+			word = word[:0]
+		}
+	}
+	flushHan := func() {
+		switch {
+		case len(han) == 1:
+			terms[string(han)]++
+		case len(han) > 1:
+			for i := 0; i+1 < len(han); i++ {
+				terms[string(han[i:i+2])]++
+			}
+		}
+		han = han[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsDigit(r):
+			flushHan()
+			if len(word) == 0 || word[len(word)-1] != '#' {
+				word = append(word, '#')
+			}
+		case unicode.IsLetter(r) || r == '_':
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return terms
+}
+
+// cosineSimilarity 计算两个词频向量的余弦相似度。
+func cosineSimilarity(a, b map[string]int) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for term, x := range a {
+		normA += float64(x * x)
+		if y, ok := b[term]; ok {
+			dot += float64(x * y)
+		}
+	}
+	for _, y := range b {
+		normB += float64(y * y)
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// enabledStrategies 返回去重后的有效策略，未配置时只启用空间相关性。
+func enabledStrategies(configured []string) []string {
+	var strategies []string
+	for _, name := range configured {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case config.CorrelationSpatial, config.CorrelationTemporal, config.CorrelationSemantic:
+			if !containsStrategy(strategies, name) {
+				strategies = append(strategies, name)
+			}
+		default:
+			log.Warnf("未知的相关性策略 %q，已忽略", name)
+		}
+	}
+	if len(strategies) == 0 {
+		strategies = []string{config.CorrelationSpatial}
+	}
+	return strategies
+}
+
+func containsStrategy(strategies []string, name string) bool {
+	for _, s := range strategies {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// combineScores 按组合方式判断是否合并：any 任一策略命中，all 全部策略命中。
+func combineScores(combine string, scores []domain.CorrelationScore) bool {
+	if len(scores) == 0 {
+		return false
+	}
+	for _, s := range scores {
+		if combine == config.CorrelationCombineAll && !s.Matched {
+			return false
+		}
+		if combine != config.CorrelationCombineAll && s.Matched {
+			return true
+		}
+	}
+	return combine == config.CorrelationCombineAll
+}
+
+// formatScores 格式化策略得分用于日志。
+func formatScores(scores []domain.CorrelationScore) string {
+	parts := make([]string, 0, len(scores))
+	for _, s := range scores {
+		parts = append(parts, fmt.Sprintf("%s=%.2f/%.2f(%t)", s.Strategy, s.Score, s.Threshold, s.Matched))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,197 @@
+package correlation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config/configtest"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/dip"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
+	"github.com/agiledragon/gomonkey/v2"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCorrelator_Correlate(t *testing.T) {
+	Convey("TestCorrelator_Correlate", t, func() {
+		ctx := context.Background()
+		cfg := newTestConfig()
+		factory := opensearch.NewRepositoryFactory(nil)
+		checker := &dip.SpatialChecker{}
+		correlator := NewCorrelator(configtest.NewProvider(cfg), factory, checker)
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+
+		base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.Local)
+		fp := domain.FaultPointObject{
+			FaultID:           10,
+			FaultName:         "mysql 连接数过高",
+			EntityObjectClass: "mysql",
+			EntityObjectID:    "db-1",
+			FaultOccurTime:    base,
+		}
+		problems := []domain.Problem{
+			{ProblemID: 1, RelationIDs: []uint64{101}},
+			{ProblemID: 2, RelationIDs: []uint64{201}},
+		}
+
+		// 问题 1 空间相关，问题 2 不相关
+		patches.ApplyMethod(checker, "ScoreProblems", func(_ *dip.SpatialChecker, _ context.Context, _ domain.FaultPointObject, ps []domain.Problem) ([]dip.SpatialMatch, error) {
+			return []dip.SpatialMatch{
+				{Problem: ps[0], EntityID: "host-1", Hops: 1, Score: 0.8, MinScore: 0.5, Matched: true},
+				{Problem: ps[1], Hops: -1, MinScore: 0.5},
+			}, nil
+		})
+		queried := 0
+		patches.ApplyMethod(factory.FaultPoints(), "QueryByIDs", func(_ *opensearch.FaultPointStore, _ context.Context, ids []uint64) ([]domain.FaultPointObject, error) {
+			queried++
+			So(ids, ShouldResemble, []uint64{101, 201})
+			return []domain.FaultPointObject{
+				{FaultID: 101, FaultName: "磁盘 IO 延迟高", EntityObjectClass: "host", EntityObjectID: "host-1", FaultOccurTime: base.Add(-50 * time.Second)},
+				{FaultID: 201, FaultName: "MySQL 连接数过高", EntityObjectClass: "mysql", EntityObjectID: "db-2", FaultOccurTime: base.Add(15 * time.Second)},
+			}, nil
+		})
+
+		Convey("默认只使用空间相关性，且不加载问题故障点", func() {
+			targets, decisions, err := correlator.Correlate(ctx, fp, problems)
+			So(err, ShouldBeNil)
+			So(queried, ShouldEqual, 0)
+			So(targets, ShouldHaveLength, 1)
+			So(targets[0].ProblemID, ShouldEqual, 1)
+			So(decisions, ShouldHaveLength, 2)
+			So(decisions[0].Combine, ShouldEqual, config.CorrelationCombineAny)
+			So(decisions[0].Merged, ShouldBeTrue)
+			So(decisions[0].Scores, ShouldResemble, []domain.CorrelationScore{
+				{Strategy: config.CorrelationSpatial, Score: 0.8, Threshold: 0.5, Matched: true, Detail: "entity_id=host-1, hops=1"},
+			})
+			So(decisions[1].Merged, ShouldBeFalse)
+		})
+
+		Convey("时间突发：同一对象组内窗口期的故障点得分随时间差衰减", func() {
+			cfg.AppConfig.Problem.Correlation = config.CorrelationConfig{
+				Strategies: []string{config.CorrelationTemporal},
+				Temporal: config.TemporalCorrelationConfig{
+					Window:   time.Minute,
+					MinScore: 0.5,
+					Groups:   []config.ObjectGroup{{Name: "db", ObjectClasses: []string{"mysql"}}},
+				},
+			}
+
+			targets, decisions, err := correlator.Correlate(ctx, fp, problems)
+			So(err, ShouldBeNil)
+			So(queried, ShouldEqual, 1)
+			So(targets, ShouldHaveLength, 1)
+			So(targets[0].ProblemID, ShouldEqual, 2)
+
+			// 问题 1 的故障点不在对象组内
+			So(decisions[0].Scores[0].Matched, ShouldBeFalse)
+			So(decisions[0].Scores[0].Score, ShouldEqual, 0)
+			So(decisions[1].Scores[0].Score, ShouldAlmostEqual, 0.75)
+			So(decisions[1].Scores[0].Threshold, ShouldEqual, 0.5)
+			So(decisions[1].Scores[0].Detail, ShouldEqual, "fault_id=201, group=db, delta=15s")
+		})
+
+		Convey("语义：名称相似的问题命中", func() {
+			cfg.AppConfig.Problem.Correlation = config.CorrelationConfig{
+				Strategies: []string{config.CorrelationSemantic},
+			}
+
+			targets, decisions, err := correlator.Correlate(ctx, fp, problems)
+			So(err, ShouldBeNil)
+			So(targets, ShouldHaveLength, 1)
+			So(targets[0].ProblemID, ShouldEqual, 2)
+			So(decisions[0].Scores[0].Score, ShouldBeLessThan, defaultSemanticMinScore)
+			So(decisions[1].Scores[0].Score, ShouldAlmostEqual, 1)
+			So(decisions[1].Scores[0].Threshold, ShouldEqual, defaultSemanticMinScore)
+		})
+
+		Convey("all 要求全部策略命中，any 任一命中即可", func() {
+			cfg.AppConfig.Problem.Correlation = config.CorrelationConfig{
+				Strategies: []string{config.CorrelationSpatial, config.CorrelationSemantic},
+				Combine:    config.CorrelationCombineAll,
+			}
+			targets, decisions, err := correlator.Correlate(ctx, fp, problems)
+			So(err, ShouldBeNil)
+			So(targets, ShouldBeEmpty)
+			So(decisions[0].Scores, ShouldHaveLength, 2)
+			So(decisions[0].Merged, ShouldBeFalse)
+			So(decisions[1].Merged, ShouldBeFalse)
+
+			cfg.AppConfig.Problem.Correlation.Combine = config.CorrelationCombineAny
+			targets, _, err = correlator.Correlate(ctx, fp, problems)
+			So(err, ShouldBeNil)
+			So(targets, ShouldHaveLength, 2)
+		})
+
+		Convey("加载问题故障点失败时语义策略按未命中处理", func() {
+			patches.ApplyMethod(factory.FaultPoints(), "QueryByIDs", func(_ *opensearch.FaultPointStore, _ context.Context, _ []uint64) ([]domain.FaultPointObject, error) {
+				return nil, errors.New("opensearch down")
+			})
+			cfg.AppConfig.Problem.Correlation = config.CorrelationConfig{
+				Strategies: []string{config.CorrelationSpatial, config.CorrelationSemantic},
+			}
+			targets, _, err := correlator.Correlate(ctx, fp, problems)
+			So(err, ShouldBeNil)
+			So(targets, ShouldHaveLength, 1)
+			So(targets[0].ProblemID, ShouldEqual, 1)
+		})
+
+		Convey("空间相关性判断失败", func() {
+			patches.ApplyMethod(checker, "ScoreProblems", func(_ *dip.SpatialChecker, _ context.Context, _ domain.FaultPointObject, _ []domain.Problem) ([]dip.SpatialMatch, error) {
+				return nil, errors.New("subgraph failed")
+			})
+
+			Convey("只有空间策略时返回错误", func() {
+				_, _, err := correlator.Correlate(ctx, fp, problems)
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "空间相关性判断失败")
+			})
+
+			Convey("combine=all 需要空间策略命中时返回错误", func() {
+				cfg.AppConfig.Problem.Correlation = config.CorrelationConfig{
+					Strategies: []string{config.CorrelationSpatial, config.CorrelationSemantic},
+					Combine:    config.CorrelationCombineAll,
+				}
+				_, _, err := correlator.Correlate(ctx, fp, problems)
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "空间相关性判断失败")
+			})
+
+			Convey("其他策略仍可决定合并时按未命中处理并记录原因", func() {
+				cfg.AppConfig.Problem.Correlation = config.CorrelationConfig{
+					Strategies: []string{config.CorrelationSpatial, config.CorrelationSemantic},
+				}
+				targets, decisions, err := correlator.Correlate(ctx, fp, problems)
+				So(err, ShouldBeNil)
+				So(targets, ShouldHaveLength, 1)
+				So(targets[0].ProblemID, ShouldEqual, 2)
+				So(decisions, ShouldHaveLength, 2)
+				So(decisions[0].Merged, ShouldBeFalse)
+				So(decisions[0].Scores[0], ShouldResemble, domain.CorrelationScore{
+					Strategy: config.CorrelationSpatial, Detail: "error=subgraph failed",
+				})
+			})
+		})
+	})
+}
+
+func TestTermVector(t *testing.T) {
+	Convey("TestTermVector", t, func() {
+		So(termVector("CPU 使用率 95%"), ShouldResemble, map[string]int{"cpu": 1, "使用": 1, "用率": 1, "#": 1})
+		So(termVector("disk sda1 full"), ShouldResemble, map[string]int{"disk": 1, "sda#": 1, "full": 1})
+		So(cosineSimilarity(termVector("CPU 使用率 95%"), termVector("cpu 使用率 80%")), ShouldAlmostEqual, 1)
+		So(cosineSimilarity(termVector("磁盘满"), nil), ShouldEqual, 0)
+	})
+}
+
+func TestEnabledStrategies(t *testing.T) {
+	Convey("TestEnabledStrategies", t, func() {
+		So(enabledStrategies(nil), ShouldResemble, []string{config.CorrelationSpatial})
+		So(enabledStrategies([]string{"unknown"}), ShouldResemble, []string{config.CorrelationSpatial})
+		So(enabledStrategies([]string{" Temporal", "semantic", "temporal"}), ShouldResemble,
+			[]string{config.CorrelationTemporal, config.CorrelationSemantic})
+	})
+}
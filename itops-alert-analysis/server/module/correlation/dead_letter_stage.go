@@ -0,0 +1,65 @@
+package correlation
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/core"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	deadLetterPersistRetries = 3               // 死信落库最大尝试次数
+	deadLetterRetryInterval  = 2 * time.Second // 死信落库重试间隔
+)
+
+// DeadLetterStage 消费死信 topic 并持久化到 itops_dead_letter，供 API 查询与重放。
+type DeadLetterStage struct {
+	consumer      core.KafkaConsumer
+	repo          core.DeadLetterRepository
+	retryInterval time.Duration
+}
+
+func NewDeadLetterStage(repo core.DeadLetterRepository, consumer core.KafkaConsumer) *DeadLetterStage {
+	return &DeadLetterStage{
+		consumer:      consumer,
+		repo:          repo,
+		retryInterval: deadLetterRetryInterval,
+	}
+}
+
+// Start 启动死信消费。
+func (s *DeadLetterStage) Start(ctx context.Context) error {
+	if s.consumer == nil {
+		return errors.New("kafka deadLetterConsumer not configured")
+	}
+	return s.consumer.ConsumeRawEvents(ctx, s.handleKafkaMessage)
+}
+
+// handleKafkaMessage 解析死信并落库，写入失败时有限次重试，避免 OpenSearch 短暂不可用导致死信丢失。
+func (s *DeadLetterStage) handleKafkaMessage(ctx context.Context, msg core.KafkaMessage) error {
+	var dl domain.DeadLetter
+	if err := json.Unmarshal(msg.Value, &dl); err != nil {
+		return errors.Wrap(err, "解析死信失败")
+	}
+
+	var err error
+	for attempt := 1; attempt <= deadLetterPersistRetries; attempt++ {
+		if err = s.repo.Upsert(ctx, dl); err == nil {
+			return nil
+		}
+		log.Warnf("死信落库失败: dead_letter_id=%d, attempt=%d, err=%v", dl.DeadLetterID, attempt, err)
+		if attempt == deadLetterPersistRetries {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.retryInterval):
+		}
+	}
+	return errors.Wrapf(err, "死信落库失败: dead_letter_id=%d", dl.DeadLetterID)
+}
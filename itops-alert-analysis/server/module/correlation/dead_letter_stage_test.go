@@ -0,0 +1,83 @@
+package correlation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/core"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDeadLetterStage(t *testing.T) {
+	Convey("TestDeadLetterStage", t, func() {
+		Convey("consumer 未配置返回错误", func() {
+			stage := NewDeadLetterStage(&deadLetterRepoStub{}, nil)
+
+			err := stage.Start(context.Background())
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "deadLetterConsumer not configured")
+		})
+
+		Convey("解析并持久化死信", func() {
+			repo := &deadLetterRepoStub{}
+			stage := NewDeadLetterStage(repo, nil)
+
+			err := stage.handleKafkaMessage(context.Background(), core.KafkaMessage{
+				Value: []byte(`{"dead_letter_id":1,"stage":"standardize","status":"pending","payload":"{}"}`),
+			})
+
+			So(err, ShouldBeNil)
+			So(repo.saved, ShouldHaveLength, 1)
+			So(repo.saved[0].DeadLetterID, ShouldEqual, 1)
+			So(repo.saved[0].Stage, ShouldEqual, domain.DeadLetterStageStandardize)
+		})
+
+		Convey("落库失败重试后返回错误", func() {
+			repo := &deadLetterRepoStub{err: errors.New("opensearch unavailable")}
+			stage := NewDeadLetterStage(repo, nil)
+			stage.retryInterval = 0
+
+			err := stage.handleKafkaMessage(context.Background(), core.KafkaMessage{
+				Value: []byte(`{"dead_letter_id":1}`),
+			})
+
+			So(err, ShouldNotBeNil)
+			So(repo.calls, ShouldEqual, deadLetterPersistRetries)
+		})
+
+		Convey("消息格式错误返回错误", func() {
+			stage := NewDeadLetterStage(&deadLetterRepoStub{}, nil)
+
+			err := stage.handleKafkaMessage(context.Background(), core.KafkaMessage{Value: []byte("not json")})
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+// deadLetterRepoStub 内存实现的死信仓库
+type deadLetterRepoStub struct {
+	saved []domain.DeadLetter
+	calls int
+	err   error
+}
+
+func (r *deadLetterRepoStub) Upsert(ctx context.Context, dl domain.DeadLetter) error {
+	r.calls++
+	if r.err != nil {
+		return r.err
+	}
+	r.saved = append(r.saved, dl)
+	return nil
+}
+
+func (r *deadLetterRepoStub) QueryByIDs(ctx context.Context, ids []uint64) ([]domain.DeadLetter, error) {
+	return r.saved, nil
+}
+
+func (r *deadLetterRepoStub) List(ctx context.Context, q domain.DeadLetterQuery) ([]domain.DeadLetter, error) {
+	return r.saved, nil
+}
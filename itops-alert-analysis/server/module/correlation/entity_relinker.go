@@ -0,0 +1,400 @@
+package correlation
+
+import (
+	"context"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/objectclass"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/standardizer"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/idgen"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/slice"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/timex"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// EntityRelinker 在对象类缓存刷新后，将关联到占位对象的故障点、事件和问题重新关联到知识网络中的真实对象。
+type EntityRelinker struct {
+	repoFactory *opensearch.RepositoryFactory
+	querier     standardizer.ObjectClassQuerier
+	faultPoint  *FaultPointStage
+	genID       *idgen.Generator
+}
+
+func NewEntityRelinker(repoFactory *opensearch.RepositoryFactory, querier standardizer.ObjectClassQuerier, faultPoint *FaultPointStage) *EntityRelinker {
+	return &EntityRelinker{
+		repoFactory: repoFactory,
+		querier:     querier,
+		faultPoint:  faultPoint,
+		genID:       idgen.New(),
+	}
+}
+
+// OnWarmup 作为对象类缓存预热回调执行重新关联，失败只记录日志，下次预热后重试。
+func (r *EntityRelinker) OnWarmup(ctx context.Context) {
+	relinked, err := r.Relink(ctx)
+	if err != nil {
+		log.Warnf("未解析实体重新关联失败: %v", err)
+		return
+	}
+	if relinked > 0 {
+		log.Infof("未解析实体重新关联完成，共关联 %d 个故障点", relinked)
+	}
+}
+
+// Relink 按占位对象 ID 中保留的查询键重新查询对象类缓存，返回成功重新关联的故障点数量。
+// 占位对象故障点按 fault_id 分页读取，直到全部处理完。
+func (r *EntityRelinker) Relink(ctx context.Context) (int, error) {
+	resolved := make(map[string]*objectclass.EntityObjectInfo)
+	relinked := 0
+	var after uint64
+	for {
+		fps, err := r.repoFactory.FaultPoints().FindByEntityClass(ctx, domain.UnresolvedObjectClass, after)
+		if err != nil {
+			return relinked, errors.Wrap(err, "查询未解析实体故障点失败")
+		}
+		if len(fps) == 0 {
+			return relinked, nil
+		}
+		after = fps[len(fps)-1].FaultID
+
+		for _, fp := range fps {
+			key, ok := domain.UnresolvedEntityKey(fp.EntityObjectID)
+			if !ok {
+				continue
+			}
+			objInfo, cached := resolved[key]
+			if !cached {
+				// 未命中同样缓存结果，避免同一主机的多个故障点重复查询
+				objInfo, _ = r.querier.GetEntityObjectInfo(ctx, objectclass.EntityIdentity{Key: key})
+				resolved[key] = objInfo
+			}
+			if objInfo == nil {
+				continue
+			}
+
+			if err := r.relinkFaultPoint(ctx, fp, *objInfo); err != nil {
+				log.Warnf("故障点 %d 重新关联到对象 %s 失败: %v", fp.FaultID, objInfo.ObjectID, err)
+				continue
+			}
+			relinked++
+		}
+	}
+}
+
+// relinkFaultPoint 更新故障点、关联事件与所属问题中的实体对象。
+// 真实对象上已有收敛键相同的未关闭故障点时，将占位对象故障点合并到该故障点，避免同一故障出现两个故障点；
+// 被合并的故障点及其事件从原问题中移出。
+func (r *EntityRelinker) relinkFaultPoint(ctx context.Context, page domain.FaultPointObject, objInfo objectclass.EntityObjectInfo) error {
+	placeholderID := page.EntityObjectID
+
+	// 版本冲突时重新读取占位对象故障点与目标故障点后重试
+	var fp, target *domain.FaultPointObject
+	err := opensearch.RetryOnConflict(ctx, func() error {
+		var err error
+		fp, target, err = r.moveFaultPoint(ctx, page.FaultID, placeholderID, objInfo)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if fp == nil {
+		log.Infof("故障点 %d 已不再关联占位对象 %s，跳过", page.FaultID, placeholderID)
+		return nil
+	}
+	if target == nil {
+		target = fp
+	}
+
+	if err := r.repoFactory.RawEvents().UpdateEntity(ctx, fp.RelationEventIDs, objInfo.ObjectTypeID, objInfo.ObjectID, objInfo.Name); err != nil {
+		return errors.Wrap(err, "更新事件实体失败")
+	}
+	if target.FaultID != fp.FaultID {
+		if err := r.repoFactory.RawEvents().UpdateFaultID(ctx, fp.RelationEventIDs, target.FaultID); err != nil {
+			return errors.Wrap(err, "更新事件故障点失败")
+		}
+		if target.ProblemID != 0 {
+			if err := r.repoFactory.RawEvents().UpdateProblemID(ctx, fp.RelationEventIDs, target.ProblemID); err != nil {
+				return errors.Wrap(err, "更新事件问题失败")
+			}
+		}
+	}
+
+	// 占位对象不在知识网络中，重新关联后补写故障点关系
+	if r.faultPoint != nil {
+		if err := r.faultPoint.writeFaultPointRelation(ctx, *target); err != nil {
+			return errors.Wrap(err, "写入故障点关系失败")
+		}
+	}
+
+	if target.FaultID != fp.FaultID {
+		if err := r.moveProblemFaultPoint(ctx, *fp, *target); err != nil {
+			return err
+		}
+		log.Infof("故障点 %d 已从占位对象 %s 合并到对象 %s(%s) 的故障点 %d",
+			fp.FaultID, placeholderID, objInfo.ObjectID, objInfo.ObjectTypeID, target.FaultID)
+		return nil
+	}
+	if fp.ProblemID != 0 {
+		if err := r.relinkProblem(ctx, fp.ProblemID, placeholderID, objInfo.ObjectID); err != nil {
+			return err
+		}
+	}
+	log.Infof("故障点 %d 已从占位对象 %s 重新关联到对象 %s(%s)", fp.FaultID, placeholderID, objInfo.ObjectID, objInfo.ObjectTypeID)
+	return nil
+}
+
+// moveFaultPoint 重新读取占位对象故障点并按版本写回，返回写回后的故障点及其被并入的故障点（未合并时为 nil）；
+// 故障点已不存在或已不再关联占位对象时都返回 nil。
+// 真实对象上存在收敛键相同的未关闭故障点时，将占位对象故障点并入该故障点，占位对象故障点标记为失效。
+func (r *EntityRelinker) moveFaultPoint(ctx context.Context, faultID uint64, placeholderID string, objInfo objectclass.EntityObjectInfo) (*domain.FaultPointObject, *domain.FaultPointObject, error) {
+	fps, err := r.repoFactory.FaultPoints().QueryByIDs(ctx, []uint64{faultID})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "查询故障点失败")
+	}
+	if len(fps) == 0 || fps[0].EntityObjectID != placeholderID {
+		return nil, nil, nil
+	}
+	fp := fps[0]
+	now := timex.NowLocalTime()
+
+	target, err := r.findMergeTarget(ctx, fp, objInfo.ObjectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if target != nil {
+		mergeFaultPoint(target, fp)
+		target.FaultUpdateTime = now
+		if err := r.repoFactory.FaultPoints().Upsert(ctx, *target); err != nil {
+			return nil, nil, errors.Wrap(err, "合并故障点失败")
+		}
+		fp.FaultStatus = domain.FaultStatusExpired
+	}
+
+	fp.EntityObjectClass = objInfo.ObjectTypeID
+	fp.EntityObjectID = objInfo.ObjectID
+	fp.EntityObjectName = objInfo.Name
+	fp.FaultUpdateTime = now
+	if err := r.repoFactory.FaultPoints().Upsert(ctx, fp); err != nil {
+		return nil, nil, errors.Wrap(err, "更新故障点失败")
+	}
+	return &fp, target, nil
+}
+
+// findMergeTarget 查找真实对象上收敛键相同的未关闭故障点，只有未关闭的占位对象故障点参与合并。
+func (r *EntityRelinker) findMergeTarget(ctx context.Context, fp domain.FaultPointObject, objectID string) (*domain.FaultPointObject, error) {
+	faultKey := fp.FaultKey
+	if faultKey == "" {
+		// 升级前创建的故障点没有收敛键，与按监控项收敛时的 fault_mode 一致
+		faultKey = fp.FaultMode
+	}
+	if fp.FaultStatus != domain.FaultStatusOccurred || faultKey == "" {
+		return nil, nil
+	}
+	target, err := r.repoFactory.FaultPoints().FindOpenByEntityAndKey(ctx, objectID, faultKey, r.openSince())
+	if err != nil {
+		return nil, errors.Wrap(err, "查询真实对象故障点失败")
+	}
+	if target == nil || target.FaultID == fp.FaultID {
+		return nil, nil
+	}
+	return target, nil
+}
+
+// openSince 返回仍可收敛的故障点最早时间，与故障点阶段的失效时间一致，未配置时不限制。
+func (r *EntityRelinker) openSince() time.Time {
+	if r.faultPoint == nil || r.faultPoint.cfgManager == nil {
+		return time.Time{}
+	}
+	return time.Now().Add(-r.faultPoint.cfgManager.GetConfig().AppConfig.FaultPoint.Expiration.ExpirationTime)
+}
+
+// mergeFaultPoint 将 src 的关联事件、时间范围与等级并入 dst，等级取更严重的一方（值越小等级越高）。
+func mergeFaultPoint(dst *domain.FaultPointObject, src domain.FaultPointObject) {
+	for _, id := range src.RelationEventIDs {
+		dst.RelationEventIDs = slice.AppendUniqueUint64(dst.RelationEventIDs, id)
+	}
+	if !src.FaultOccurTime.IsZero() && (dst.FaultOccurTime.IsZero() || src.FaultOccurTime.Before(dst.FaultOccurTime)) {
+		dst.FaultOccurTime = src.FaultOccurTime
+	}
+	if src.FaultLatestTime.After(dst.FaultLatestTime) {
+		dst.FaultLatestTime = src.FaultLatestTime
+	}
+	if !dst.FaultOccurTime.IsZero() {
+		dst.FaultDurationTime = int64(dst.FaultLatestTime.Sub(dst.FaultOccurTime).Seconds())
+	}
+	if src.FaultLevel > 0 && (dst.FaultLevel <= 0 || src.FaultLevel < dst.FaultLevel) {
+		at := src.FaultLevelSeenTime
+		if at.IsZero() {
+			at = src.FaultLatestTime
+		}
+		dst.FaultLevelTimeline = append(dst.FaultLevelTimeline, domain.SeverityChange{
+			Level:         src.FaultLevel,
+			PreviousLevel: dst.FaultLevel,
+			Timestamp:     at,
+		})
+		if len(dst.FaultLevelTimeline) > maxSeverityTimeline {
+			dst.FaultLevelTimeline = dst.FaultLevelTimeline[len(dst.FaultLevelTimeline)-maxSeverityTimeline:]
+		}
+		dst.FaultLevel = src.FaultLevel
+		dst.FaultLevelSeenTime = at
+	}
+}
+
+// moveProblemFaultPoint 占位对象故障点并入 target 后，将其从原问题中移出，事件改为归属 target 所在问题，并记录关联记录。
+func (r *EntityRelinker) moveProblemFaultPoint(ctx context.Context, fp, target domain.FaultPointObject) error {
+	if fp.ProblemID != 0 {
+		err := opensearch.RetryOnConflict(ctx, func() error {
+			return r.detachFaultPoint(ctx, fp.ProblemID, fp.FaultID, target.ProblemID)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "从问题 %d 移出故障点失败", fp.ProblemID)
+		}
+	}
+	if target.ProblemID != 0 && target.ProblemID != fp.ProblemID {
+		err := opensearch.RetryOnConflict(ctx, func() error {
+			return r.appendProblemEvents(ctx, target.ProblemID, fp.RelationEventIDs)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "追加事件到问题 %d 失败", target.ProblemID)
+		}
+	}
+	if fp.ProblemID == target.ProblemID {
+		return nil
+	}
+
+	record := domain.CorrelationRecord{
+		RecordID:        r.genID.NextID(),
+		RecordType:      domain.CorrelationRecordRelink,
+		ProblemID:       target.ProblemID,
+		FaultID:         fp.FaultID,
+		SourceProblemID: fp.ProblemID,
+		Strategy:        domain.CorrelationStrategyFaultKey,
+		Score:           1,
+		CreateTime:      timex.NowLocalTime(),
+	}
+	if err := r.repoFactory.CorrelationRecords().Upsert(ctx, record); err != nil {
+		log.Warnf("写入关联记录失败 problem_id=%d fault_id=%d: %v", record.ProblemID, record.FaultID, err)
+	}
+	return nil
+}
+
+// detachFaultPoint 读取问题，移出故障点后按剩余故障点重新计算问题并按版本写回；
+// 问题不再有故障点时关闭：故障点并入的故障点属于其他问题时以 merged 状态关闭，否则以 closed 状态关闭。
+func (r *EntityRelinker) detachFaultPoint(ctx context.Context, problemID, faultID, targetProblemID uint64) error {
+	problems, err := r.repoFactory.Problems().QueryByIDs(ctx, []uint64{problemID})
+	if err != nil {
+		return errors.Wrap(err, "查询问题失败")
+	}
+	if len(problems) == 0 || !slice.ContainsUint64(problems[0].RelationIDs, faultID) {
+		return nil
+	}
+	problem := problems[0]
+
+	remainingIDs := make([]uint64, 0, len(problem.RelationIDs))
+	for _, id := range problem.RelationIDs {
+		if id != faultID {
+			remainingIDs = append(remainingIDs, id)
+		}
+	}
+	var remaining []domain.FaultPointObject
+	if len(remainingIDs) > 0 {
+		remaining, err = r.repoFactory.FaultPoints().QueryByIDs(ctx, remainingIDs)
+		if err != nil {
+			return errors.Wrap(err, "查询问题关联故障点失败")
+		}
+	}
+
+	if len(remaining) == 0 {
+		if targetProblemID != 0 && targetProblemID != problemID {
+			log.Infof("问题 %d 的故障点 %d 已并入问题 %d，关闭问题", problemID, faultID, targetProblemID)
+			return r.repoFactory.Problems().CloseMerged(ctx, problem, domain.ProblemCloseTypeSystem,
+				"故障点重新关联后合并到问题"+cast.ToString(targetProblemID), "system")
+		}
+		now := timex.NowLocalTime().Local()
+		closeType := domain.ProblemCloseTypeSystem
+		rebuildProblemFromFaultPoints(&problem, nil, now)
+		problem.RootCauseFaultID = 0
+		problem.RootCauseObjectID = ""
+		problem.ProblemStatus = domain.ProblemStatusClosed
+		problem.ProblemCloseType = &closeType
+		problem.ProblemCloseNotes = "故障点重新关联后已全部移出"
+		problem.ProblemClosedBy = "system"
+		problem.ProblemCloseTime = &now
+		log.Infof("问题 %d 的故障点 %d 已移出，问题不再有故障点，关闭问题", problemID, faultID)
+		return r.repoFactory.Problems().Upsert(ctx, problem)
+	}
+
+	rebuildProblemFromFaultPoints(&problem, remaining, timex.NowLocalTime().Local())
+	if problem.RootCauseFaultID == faultID {
+		problem.RootCauseFaultID = 0
+		problem.RootCauseObjectID = ""
+	}
+	if err := r.repoFactory.Problems().Upsert(ctx, problem); err != nil {
+		return errors.Wrap(err, "更新问题失败")
+	}
+	log.Infof("故障点 %d 已从问题 %d 移出，剩余 %d 个故障点", faultID, problemID, len(problem.RelationIDs))
+	return nil
+}
+
+// appendProblemEvents 读取问题并追加事件后按版本写回。
+func (r *EntityRelinker) appendProblemEvents(ctx context.Context, problemID uint64, eventIDs []uint64) error {
+	problems, err := r.repoFactory.Problems().QueryByIDs(ctx, []uint64{problemID})
+	if err != nil {
+		return errors.Wrap(err, "查询问题失败")
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	problem := problems[0]
+	for _, id := range eventIDs {
+		problem.RelationEventIDs = slice.AppendUniqueUint64(problem.RelationEventIDs, id)
+	}
+	problem.ProblemUpdateTime = timex.NowLocalTime().Local()
+	return r.repoFactory.Problems().Upsert(ctx, problem)
+}
+
+// relinkProblem 将问题影响实体与根因对象中的占位对象替换为真实对象，版本冲突时重新读取后重试。
+func (r *EntityRelinker) relinkProblem(ctx context.Context, problemID uint64, placeholderID, objectID string) error {
+	return opensearch.RetryOnConflict(ctx, func() error {
+		return r.replaceProblemEntity(ctx, problemID, placeholderID, objectID)
+	})
+}
+
+// replaceProblemEntity 读取问题并替换占位对象后按版本写回。
+func (r *EntityRelinker) replaceProblemEntity(ctx context.Context, problemID uint64, placeholderID, objectID string) error {
+	problems, err := r.repoFactory.Problems().QueryByIDs(ctx, []uint64{problemID})
+	if err != nil {
+		return errors.Wrap(err, "查询问题失败")
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	problem := problems[0]
+	affected := make([]string, 0, len(problem.AffectedEntityIDs))
+	seen := make(map[string]struct{}, len(problem.AffectedEntityIDs))
+	for _, id := range problem.AffectedEntityIDs {
+		if id == placeholderID {
+			id = objectID
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		affected = append(affected, id)
+	}
+	problem.AffectedEntityIDs = affected
+	if problem.RootCauseObjectID == placeholderID {
+		problem.RootCauseObjectID = objectID
+	}
+	problem.ProblemUpdateTime = timex.NowLocalTime()
+
+	if err := r.repoFactory.Problems().Upsert(ctx, problem); err != nil {
+		return errors.Wrap(err, "更新问题失败")
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package correlation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/objectclass"
+	"github.com/agiledragon/gomonkey/v2"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEntityRelinker_Relink(t *testing.T) {
+	Convey("TestEntityRelinker_Relink", t, func() {
+		ctx := context.Background()
+		factory := opensearch.NewRepositoryFactory(nil)
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+
+		placeholderA := domain.UnresolvedObjectID("host-a")
+		placeholderB := domain.UnresolvedObjectID("host-b")
+		stored := map[uint64]domain.FaultPointObject{
+			1: {FaultID: 1, EntityObjectClass: domain.UnresolvedObjectClass, EntityObjectID: placeholderA, RelationEventIDs: []uint64{11, 12}, ProblemID: 100},
+			2: {FaultID: 2, EntityObjectClass: domain.UnresolvedObjectClass, EntityObjectID: placeholderB, RelationEventIDs: []uint64{21}},
+			3: {FaultID: 3, EntityObjectClass: domain.UnresolvedObjectClass, EntityObjectID: placeholderA, RelationEventIDs: []uint64{31},
+				FaultStatus: domain.FaultStatusOccurred, FaultKey: "cpu", FaultLevel: 2},
+		}
+		// 每页两个故障点，按 fault_id 分页
+		var pages []uint64
+		patches.ApplyMethod(factory.FaultPoints(), "FindByEntityClass", func(_ *opensearch.FaultPointStore, _ context.Context, _ string, after uint64) ([]domain.FaultPointObject, error) {
+			pages = append(pages, after)
+			switch after {
+			case 0:
+				return []domain.FaultPointObject{stored[1], stored[2]}, nil
+			case 2:
+				return []domain.FaultPointObject{stored[3]}, nil
+			}
+			return nil, nil
+		})
+		patches.ApplyMethod(factory.FaultPoints(), "QueryByIDs", func(_ *opensearch.FaultPointStore, _ context.Context, ids []uint64) ([]domain.FaultPointObject, error) {
+			var fps []domain.FaultPointObject
+			for _, id := range ids {
+				if fp, ok := stored[id]; ok {
+					fps = append(fps, fp)
+				}
+			}
+			return fps, nil
+		})
+		patches.ApplyMethod(factory.FaultPoints(), "FindOpenByEntityAndKey", func(_ *opensearch.FaultPointStore, _ context.Context, _, _ string, _ time.Time) (*domain.FaultPointObject, error) {
+			return nil, nil
+		})
+
+		var savedFPs []domain.FaultPointObject
+		patches.ApplyMethod(factory.FaultPoints(), "Upsert", func(_ *opensearch.FaultPointStore, _ context.Context, fp domain.FaultPointObject) error {
+			savedFPs = append(savedFPs, fp)
+			return nil
+		})
+		var updatedEvents []uint64
+		patches.ApplyMethod(factory.RawEvents(), "UpdateEntity", func(_ *opensearch.RawEventStore, _ context.Context, ids []uint64, _, objectID, _ string) error {
+			updatedEvents = append(updatedEvents, ids...)
+			return nil
+		})
+		patches.ApplyMethod(factory.FaultPointRelations(), "Upsert", func(_ *opensearch.FaultPointRelationStore, _ context.Context, _ domain.FaultPointRelation) error {
+			return nil
+		})
+		patches.ApplyMethod(factory.Problems(), "QueryByIDs", func(_ *opensearch.ProblemStore, _ context.Context, ids []uint64) ([]domain.Problem, error) {
+			return []domain.Problem{{ProblemID: ids[0], AffectedEntityIDs: []string{placeholderA, "obj-1", "obj-2"}, RootCauseObjectID: placeholderA}}, nil
+		})
+		var savedProblem domain.Problem
+		patches.ApplyMethod(factory.Problems(), "Upsert", func(_ *opensearch.ProblemStore, _ context.Context, p domain.Problem) error {
+			savedProblem = p
+			return nil
+		})
+
+		// 只有 host-a 已在知识网络中出现
+		querier := &objectClassQuerierStub{objects: map[string]*objectclass.EntityObjectInfo{
+			"host-a": {ObjectTypeID: "host", ObjectID: "obj-1", Name: "host-a"},
+		}}
+		relinker := NewEntityRelinker(factory, querier, NewFaultPointStage(nil, factory, nil))
+
+		Convey("分页重新关联全部已能解析的占位对象", func() {
+			relinked, err := relinker.Relink(ctx)
+
+			So(err, ShouldBeNil)
+			So(relinked, ShouldEqual, 2)
+			So(pages, ShouldResemble, []uint64{0, 2, 3})
+			So(savedFPs, ShouldHaveLength, 2)
+			So(savedFPs[0].FaultID, ShouldEqual, 1)
+			So(savedFPs[0].EntityObjectClass, ShouldEqual, "host")
+			So(savedFPs[0].EntityObjectID, ShouldEqual, "obj-1")
+			So(savedFPs[1].FaultID, ShouldEqual, 3)
+			So(savedFPs[1].FaultStatus, ShouldEqual, domain.FaultStatusOccurred)
+			So(updatedEvents, ShouldResemble, []uint64{11, 12, 31})
+			So(savedProblem.AffectedEntityIDs, ShouldResemble, []string{"obj-1", "obj-2"})
+			So(savedProblem.RootCauseObjectID, ShouldEqual, "obj-1")
+		})
+
+		Convey("真实对象上已有同收敛键的未关闭故障点时合并，不创建重复故障点", func() {
+			patches.ApplyMethod(factory.FaultPoints(), "FindOpenByEntityAndKey", func(_ *opensearch.FaultPointStore, _ context.Context, objectID, faultKey string, _ time.Time) (*domain.FaultPointObject, error) {
+				if objectID != "obj-1" || faultKey != "cpu" {
+					return nil, nil
+				}
+				return &domain.FaultPointObject{FaultID: 9, EntityObjectID: "obj-1", FaultKey: "cpu", FaultStatus: domain.FaultStatusOccurred,
+					RelationEventIDs: []uint64{91}, FaultLevel: 3, ProblemID: 200}, nil
+			})
+			linkedFaults := map[uint64][]uint64{}
+			patches.ApplyMethod(factory.RawEvents(), "UpdateFaultID", func(_ *opensearch.RawEventStore, _ context.Context, ids []uint64, faultID uint64) error {
+				linkedFaults[faultID] = append(linkedFaults[faultID], ids...)
+				return nil
+			})
+			var linkedProblem uint64
+			patches.ApplyMethod(factory.RawEvents(), "UpdateProblemID", func(_ *opensearch.RawEventStore, _ context.Context, _ []uint64, problemID uint64) error {
+				linkedProblem = problemID
+				return nil
+			})
+
+			// 占位对象故障点 3 原属于问题 300，问题 300 还有故障点 4
+			placeholder := stored[3]
+			placeholder.ProblemID = 300
+			stored[3] = placeholder
+			stored[4] = domain.FaultPointObject{FaultID: 4, EntityObjectID: "obj-2", RelationEventIDs: []uint64{41},
+				FaultStatus: domain.FaultStatusOccurred, FaultLevel: 4, ProblemID: 300}
+			problems := map[uint64]domain.Problem{
+				100: {ProblemID: 100, AffectedEntityIDs: []string{placeholderA}},
+				200: {ProblemID: 200, RelationIDs: []uint64{9}, RelationEventIDs: []uint64{91}},
+				300: {ProblemID: 300, RelationIDs: []uint64{3, 4}, RelationEventIDs: []uint64{31, 41},
+					AffectedEntityIDs: []string{placeholderA, "obj-2"}, RootCauseFaultID: 3, ProblemStatus: domain.ProblemStatusOpen},
+			}
+			patches.ApplyMethod(factory.Problems(), "QueryByIDs", func(_ *opensearch.ProblemStore, _ context.Context, ids []uint64) ([]domain.Problem, error) {
+				return []domain.Problem{problems[ids[0]]}, nil
+			})
+			savedProblems := map[uint64]domain.Problem{}
+			patches.ApplyMethod(factory.Problems(), "Upsert", func(_ *opensearch.ProblemStore, _ context.Context, p domain.Problem) error {
+				savedProblems[p.ProblemID] = p
+				return nil
+			})
+			var closedMerged []uint64
+			patches.ApplyMethod(factory.Problems(), "CloseMerged", func(_ *opensearch.ProblemStore, _ context.Context, p domain.Problem, _ domain.ProblemCloseType, _, _ string) error {
+				closedMerged = append(closedMerged, p.ProblemID)
+				return nil
+			})
+			var records []domain.CorrelationRecord
+			patches.ApplyMethod(factory.CorrelationRecords(), "Upsert", func(_ *opensearch.CorrelationRecordStore, _ context.Context, record domain.CorrelationRecord) error {
+				records = append(records, record)
+				return nil
+			})
+
+			Convey("故障点与事件从原问题移出，原问题按剩余故障点重新计算", func() {
+				relinked, err := relinker.Relink(ctx)
+
+				So(err, ShouldBeNil)
+				So(relinked, ShouldEqual, 2)
+				So(savedFPs, ShouldHaveLength, 3)
+				target := savedFPs[1]
+				So(target.FaultID, ShouldEqual, 9)
+				So(target.RelationEventIDs, ShouldResemble, []uint64{91, 31})
+				So(target.FaultLevel, ShouldEqual, 2)
+				placeholder := savedFPs[2]
+				So(placeholder.FaultID, ShouldEqual, 3)
+				So(placeholder.FaultStatus, ShouldEqual, domain.FaultStatusExpired)
+				So(placeholder.EntityObjectID, ShouldEqual, "obj-1")
+				So(linkedFaults, ShouldResemble, map[uint64][]uint64{9: {31}})
+				So(linkedProblem, ShouldEqual, 200)
+
+				source := savedProblems[300]
+				So(source.RelationIDs, ShouldResemble, []uint64{4})
+				So(source.RelationEventIDs, ShouldResemble, []uint64{41})
+				So(source.AffectedEntityIDs, ShouldResemble, []string{"obj-2"})
+				So(source.ProblemLevel, ShouldEqual, 4)
+				So(source.RootCauseFaultID, ShouldEqual, 0)
+				So(savedProblems[200].RelationEventIDs, ShouldResemble, []uint64{91, 31})
+				So(closedMerged, ShouldBeEmpty)
+				So(records, ShouldHaveLength, 1)
+				So(records[0].RecordType, ShouldEqual, domain.CorrelationRecordRelink)
+				So(records[0].ProblemID, ShouldEqual, 200)
+				So(records[0].FaultID, ShouldEqual, 3)
+				So(records[0].SourceProblemID, ShouldEqual, 300)
+			})
+
+			Convey("原问题不再有故障点时以 merged 状态关闭", func() {
+				source := problems[300]
+				source.RelationIDs = []uint64{3}
+				problems[300] = source
+
+				_, err := relinker.Relink(ctx)
+
+				So(err, ShouldBeNil)
+				So(closedMerged, ShouldResemble, []uint64{300})
+				_, saved := savedProblems[300]
+				So(saved, ShouldBeFalse)
+				So(records, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("版本冲突时重新读取后重试", func() {
+			conflicts := 0
+			patches.ApplyMethod(factory.FaultPoints(), "Upsert", func(_ *opensearch.FaultPointStore, _ context.Context, fp domain.FaultPointObject) error {
+				if fp.FaultID == 1 && conflicts == 0 {
+					conflicts++
+					return domain.ErrVersionConflict
+				}
+				savedFPs = append(savedFPs, fp)
+				return nil
+			})
+
+			relinked, err := relinker.Relink(ctx)
+
+			So(err, ShouldBeNil)
+			So(relinked, ShouldEqual, 2)
+			So(conflicts, ShouldEqual, 1)
+			So(savedFPs[0].FaultID, ShouldEqual, 1)
+		})
+
+		Convey("已被其他实例重新关联的故障点不再处理", func() {
+			relinkedFP := stored[1]
+			relinkedFP.EntityObjectID = "obj-1"
+			stored[1] = relinkedFP
+
+			_, err := relinker.Relink(ctx)
+
+			So(err, ShouldBeNil)
+			So(savedFPs, ShouldHaveLength, 1)
+			So(savedFPs[0].FaultID, ShouldEqual, 3)
+		})
+
+		Convey("更新故障点失败时跳过该故障点", func() {
+			patches.ApplyMethod(factory.FaultPoints(), "Upsert", func(_ *opensearch.FaultPointStore, _ context.Context, _ domain.FaultPointObject) error {
+				return errors.New("opensearch unavailable")
+			})
+
+			relinked, err := relinker.Relink(ctx)
+
+			So(err, ShouldBeNil)
+			So(relinked, ShouldEqual, 0)
+		})
+
+		Convey("查询占位故障点失败返回错误", func() {
+			patches.ApplyMethod(factory.FaultPoints(), "FindByEntityClass", func(_ *opensearch.FaultPointStore, _ context.Context, _ string, _ uint64) ([]domain.FaultPointObject, error) {
+				return nil, errors.New("opensearch unavailable")
+			})
+
+			_, err := relinker.Relink(ctx)
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+// objectClassQuerierStub 按查询键返回对象信息的桩
+type objectClassQuerierStub struct {
+	objects map[string]*objectclass.EntityObjectInfo
+}
+
+func (q *objectClassQuerierStub) GetEntityObjectInfo(ctx context.Context, entity objectclass.EntityIdentity) (*objectclass.EntityObjectInfo, error) {
+	if objInfo, ok := q.objects[entity.Key]; ok {
+		return objInfo, nil
+	}
+	return nil, errors.New("not found")
+}
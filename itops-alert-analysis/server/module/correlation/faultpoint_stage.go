@@ -10,26 +10,38 @@ import (
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/convergence"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/taxonomy"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/idgen"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/slice"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/timex"
 	"github.com/pkg/errors"
 )
 
-// FaultPointStage 按 failure_mode 收敛事件。
+const (
+	defaultDeescalationWindow = 10 * time.Minute
+	// maxSeverityTimeline 等级时间线最多保留的记录数，超出时丢弃最早的记录
+	maxSeverityTimeline = 100
+)
+
+// FaultPointStage 按收敛键收敛事件，收敛键由按数据源、对象类配置的收敛策略生成，默认为 failure_mode。
 type FaultPointStage struct {
-	cfgManager     *config.ConfigManager
+	cfgManager     config.Provider
 	repoFactory    *opensearch.RepositoryFactory
 	problemHandler core.ProblemHandler
 	genID          *idgen.Generator
+	keys           *convergence.Registry
+	modes          *taxonomy.Classifier
 }
 
-func NewFaultPointStage(cfgManager *config.ConfigManager, repoFactory *opensearch.RepositoryFactory, problemHandler core.ProblemHandler) *FaultPointStage {
+func NewFaultPointStage(cfgManager config.Provider, repoFactory *opensearch.RepositoryFactory, problemHandler core.ProblemHandler) *FaultPointStage {
 	return &FaultPointStage{
 		cfgManager:     cfgManager,
 		repoFactory:    repoFactory,
 		problemHandler: problemHandler,
 		genID:          idgen.New(),
+		keys:           convergence.DefaultRegistry(),
+		modes:          taxonomy.NewClassifier(cfgManager),
 	}
 }
 
@@ -43,47 +55,57 @@ func (s *FaultPointStage) HandleEvent(ctx context.Context, event domain.RawEvent
 	return s.handleAlertEvent(ctx, event)
 }
 
+// failureMode 按故障模式目录归一事件的故障模式，未命中时沿用 EventType（监控项 key）。
+func (s *FaultPointStage) failureMode(event domain.RawEvent) string {
+	if match, ok := s.modes.Classify(event); ok {
+		return match.Mode
+	}
+	return event.EventType
+}
+
+// ClassifyFailureMode 按故障模式目录归一事件，供接口调试目录规则。
+func (s *FaultPointStage) ClassifyFailureMode(event domain.RawEvent) (domain.FailureModeMatch, bool) {
+	return s.modes.Classify(event)
+}
+
 // handleAlertEvent 处理告警事件（EventStatus != 2）
 func (s *FaultPointStage) handleAlertEvent(ctx context.Context, event domain.RawEvent) error {
-	failureMode := event.EventType // FaultMode 就是 EventType
+	failureMode := s.failureMode(event)
+	faultKey := s.keys.Key(s.cfgManager.GetConfig().AppConfig.FaultPoint.Convergence, event)
 
-	expirationTime := time.Now().Add(-s.cfgManager.GetConfig().AppConfig.FaultPoint.Expiration.ExpirationTime)
-	log.Debugf("执行存量故障点检查最早故障时间: %s", expirationTime.Format(time.DateTime))
+	latestTimestamp := event.EventTimestamp
 
-	existed, err := s.repoFactory.FaultPoints().FindOpenByEntityAndMode(ctx, event.EntityObjectID, failureMode, expirationTime)
+	// 命中已有故障点时读-改-写，读取后被其他事件修改（版本冲突）时重新查找并合并
+	var existed *domain.FaultPointObject
+	var wasFlapping, levelChanged bool
+	err := opensearch.RetryOnConflict(ctx, func() error {
+		var err error
+		existed, err = s.findConvergedFaultPoint(ctx, event, faultKey)
+		if err != nil || existed == nil {
+			return err
+		}
+		wasFlapping = existed.FaultFlapping
+		levelChanged = s.mergeEventIntoFaultPoint(existed, event, faultKey)
+		return errors.Wrap(s.repoFactory.FaultPoints().Upsert(ctx, *existed), "更新故障点失败")
+	})
 	if err != nil {
-		return errors.Wrap(err, "查询故障点失败")
+		return err
 	}
 
-	latestTimestamp := event.EventTimestamp
-
 	if existed != nil {
-		log.Infof("当前存在收敛故障点对象:%d", existed.FaultID)
-		// 命中未关闭的故障点，更新最新时间与关联事件列表。
-		//if latestTimestamp.After(existed.FaultLatestTime) {
-		//	existed.FaultLatestTime = latestTimestamp
-		//}
-
-		if event.EventOccurTime != nil && event.EventOccurTime.After(existed.FaultOccurTime) {
-			existed.FaultLatestTime = *event.EventOccurTime
-		}
-
-		existed.RelationEventIDs = slice.AppendUniqueUint64(existed.RelationEventIDs, event.EventID)
-		existed.FaultUpdateTime = timex.NowLocalTime()
-
-		if !existed.FaultOccurTime.IsZero() {
-			existed.FaultDurationTime = int64(existed.FaultLatestTime.Sub(existed.FaultOccurTime).Seconds())
+		if err := s.linkFaultToEvents(ctx, existed.FaultID, []uint64{event.EventID}); err != nil {
+			return err
 		}
-		// 值越小等级越高。如果存在的事件等级低于将要合并的事件等级将使用最高等级的事件
-		if event.EventLevel < existed.FaultLevel {
-			existed.FaultLevel = event.EventLevel
+		if levelChanged && existed.ProblemID != 0 {
+			if err := s.problemHandler.HandleFaultPointLevelChanged(ctx, *existed); err != nil {
+				log.Warnf("同步故障点 %d 等级到问题 %d 失败: %v", existed.FaultID, existed.ProblemID, err)
+			}
 		}
 
-		if err := s.repoFactory.FaultPoints().Upsert(ctx, *existed); err != nil {
-			return errors.Wrap(err, "更新故障点失败")
-		}
-		if err := s.linkFaultToEvents(ctx, existed.FaultID, []uint64{event.EventID}); err != nil {
-			return err
+		// 抖动中的故障点已关联问题，不再重复合并与触发 RCA
+		if wasFlapping && existed.ProblemID != 0 {
+			log.Infof("故障点 %d 抖动中，跳过问题关联: problem_id=%d", existed.FaultID, existed.ProblemID)
+			return s.linkProblemToEvents(ctx, existed.ProblemID, []uint64{event.EventID})
 		}
 
 		// 将故障点传递给 Problem 阶段进行关联和合并
@@ -110,8 +132,17 @@ func (s *FaultPointStage) handleAlertEvent(ctx context.Context, event domain.Raw
 		EntityObjectID:    event.EntityObjectID,
 		RelationEventIDs:  []uint64{event.EventID},
 		FaultMode:         failureMode,
+		FaultKey:          faultKey,
 		FaultLevel:        event.EventLevel,
-		FaultDescription:  event.EventContent,
+		FaultLevelTimeline: []domain.SeverityChange{
+			{Level: event.EventLevel, Timestamp: occurTime, EventID: event.EventID},
+		},
+		FaultLevelSeenTime: occurTime,
+		FaultDescription:   event.EventContent,
+		FaultFlapping:      event.EventFlapping,
+	}
+	if event.EventFlapping {
+		fp.FaultFlappingStatus = domain.EventStatusOccurred
 	}
 
 	if err := s.repoFactory.FaultPoints().Upsert(ctx, fp); err != nil {
@@ -119,7 +150,7 @@ func (s *FaultPointStage) handleAlertEvent(ctx context.Context, event domain.Raw
 	}
 
 	// 写入故障点关系
-	if err := s.writeFaultPointRelation(ctx, fp, event); err != nil {
+	if err := s.writeFaultPointRelation(ctx, fp); err != nil {
 		return errors.Wrap(err, "写入故障点关系失败")
 	}
 
@@ -131,6 +162,110 @@ func (s *FaultPointStage) handleAlertEvent(ctx context.Context, event domain.Raw
 	return s.problemHandler.HandleFaultPoint(ctx, fp)
 }
 
+// findConvergedFaultPoint 查找事件应收敛到的故障点：失效时间内未关闭的故障点，抖动事件还会复用窗口内最近的故障点。
+func (s *FaultPointStage) findConvergedFaultPoint(ctx context.Context, event domain.RawEvent, faultKey string) (*domain.FaultPointObject, error) {
+	expirationTime := time.Now().Add(-s.cfgManager.GetConfig().AppConfig.FaultPoint.Expiration.ExpirationTime)
+	log.Debugf("执行存量故障点检查最早故障时间: %s", expirationTime.Format(time.DateTime))
+
+	existed, err := s.repoFactory.FaultPoints().FindOpenByEntityAndKey(ctx, event.EntityObjectID, faultKey, expirationTime)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询故障点失败")
+	}
+	if existed == nil && event.EventFlapping {
+		// 抖动期间复用窗口内最近的故障点（通常是刚恢复的），避免每次重新发生都创建新故障点
+		since := time.Now().Add(-flappingWindow(s.cfgManager.GetConfig().AppConfig.Ingest.Flapping))
+		existed, err = s.repoFactory.FaultPoints().FindLatestByEntityAndKey(ctx, event.EntityObjectID, faultKey, since)
+		if err != nil {
+			return nil, errors.Wrap(err, "查询故障点失败")
+		}
+	}
+	return existed, nil
+}
+
+// mergeEventIntoFaultPoint 将发生事件合并到已有故障点，返回故障点等级是否变化。
+func (s *FaultPointStage) mergeEventIntoFaultPoint(existed *domain.FaultPointObject, event domain.RawEvent, faultKey string) bool {
+	log.Infof("当前存在收敛故障点对象:%d", existed.FaultID)
+	// 升级前创建的故障点没有收敛键，命中后补齐
+	existed.FaultKey = faultKey
+	// 命中未关闭的故障点，更新最新时间与关联事件列表。
+	//if latestTimestamp.After(existed.FaultLatestTime) {
+	//	existed.FaultLatestTime = latestTimestamp
+	//}
+
+	if event.EventOccurTime != nil && event.EventOccurTime.After(existed.FaultOccurTime) {
+		existed.FaultLatestTime = *event.EventOccurTime
+	}
+
+	existed.RelationEventIDs = slice.AppendUniqueUint64(existed.RelationEventIDs, event.EventID)
+	existed.FaultUpdateTime = timex.NowLocalTime()
+
+	if !existed.FaultOccurTime.IsZero() {
+		existed.FaultDurationTime = int64(existed.FaultLatestTime.Sub(existed.FaultOccurTime).Seconds())
+	}
+	// 值越小等级越高。更严重的事件立即升级；更轻的事件在启用回落且回落窗口内未再出现当前等级时回落
+	levelChanged := s.applySeverity(existed, event)
+	if event.EventFlapping {
+		if existed.FaultStatus != domain.FaultStatusOccurred {
+			log.Infof("故障点 %d 处于抖动状态，重新打开", existed.FaultID)
+		}
+		existed.FaultStatus = domain.FaultStatusOccurred
+		existed.FaultFlapping = true
+		existed.FaultFlappingStatus = domain.EventStatusOccurred
+	}
+	return levelChanged
+}
+
+// applySeverity 按发生事件更新故障点等级并记录等级时间线，返回等级是否变化。
+func (s *FaultPointStage) applySeverity(fp *domain.FaultPointObject, event domain.RawEvent) bool {
+	level := event.EventLevel
+	if level <= 0 {
+		return false
+	}
+	at := eventTime(event)
+	if len(fp.FaultLevelTimeline) == 0 {
+		// 升级前创建的故障点没有时间线，以当前等级作为起点
+		fp.FaultLevelTimeline = []domain.SeverityChange{{Level: fp.FaultLevel, Timestamp: fp.FaultOccurTime}}
+	}
+	if fp.FaultLevelSeenTime.IsZero() {
+		fp.FaultLevelSeenTime = fp.FaultUpdateTime
+	}
+
+	if fp.FaultLevel > 0 && level >= fp.FaultLevel {
+		if level == fp.FaultLevel {
+			if at.After(fp.FaultLevelSeenTime) {
+				fp.FaultLevelSeenTime = at
+			}
+			return false
+		}
+		deescalation := s.cfgManager.GetConfig().AppConfig.FaultPoint.Deescalation
+		if !deescalation.Enabled || at.Sub(fp.FaultLevelSeenTime) < deescalationWindow(deescalation) {
+			return false
+		}
+	}
+
+	fp.FaultLevelTimeline = append(fp.FaultLevelTimeline, domain.SeverityChange{
+		Level:         level,
+		PreviousLevel: fp.FaultLevel,
+		Timestamp:     at,
+		EventID:       event.EventID,
+	})
+	if len(fp.FaultLevelTimeline) > maxSeverityTimeline {
+		fp.FaultLevelTimeline = fp.FaultLevelTimeline[len(fp.FaultLevelTimeline)-maxSeverityTimeline:]
+	}
+	log.Infof("故障点 %d 等级变化: %d -> %d, event_id=%d", fp.FaultID, fp.FaultLevel, level, event.EventID)
+	fp.FaultLevel = level
+	fp.FaultLevelSeenTime = at
+	return true
+}
+
+// deescalationWindow 返回等级回落窗口，未配置时使用默认值。
+func deescalationWindow(cfg config.DeescalationConfig) time.Duration {
+	if cfg.Window <= 0 {
+		return defaultDeescalationWindow
+	}
+	return cfg.Window
+}
+
 // handleRecoveryEvents 处理恢复事件（EventStatus == 2）
 // 恢复事件已写入 raw_event_index，有新的 event_id
 func (s *FaultPointStage) handleRecoveryEvents(ctx context.Context, event domain.RawEvent) error {
@@ -150,32 +285,35 @@ func (s *FaultPointStage) handleRecoveryEvents(ctx context.Context, event domain
 	for _, rawEvent := range rawEvents {
 		log.Infof("恢复事件匹配到告警事件: event_id=%d", rawEvent.EventID)
 
-		//通过告警事件的 event_id 找到关联的故障点
-		faultPoint, err := s.repoFactory.FaultPoints().FindByEventID(ctx, rawEvent.EventID)
+		//通过告警事件的 event_id 找到关联的故障点，读取后被并发修改时重新读取再合并恢复事件
+		var faultPoint *domain.FaultPointObject
+		err := opensearch.RetryOnConflict(ctx, func() error {
+			var err error
+			faultPoint, err = s.repoFactory.FaultPoints().FindByEventID(ctx, rawEvent.EventID)
+			if err != nil {
+				return errors.Wrap(err, "查询故障点失败")
+			}
+			if faultPoint == nil {
+				return nil
+			}
+			applyRecoveryEvent(faultPoint, event)
+			return errors.Wrap(s.repoFactory.FaultPoints().Upsert(ctx, *faultPoint), "更新故障点失败")
+		})
 		if err != nil {
-			return errors.Wrap(err, "查询故障点失败")
+			return err
 		}
 		if faultPoint == nil {
 			log.Infof("告警事件 %d 未关联到故障点，跳过", rawEvent.EventID)
 			continue
 		}
-
 		log.Infof("告警事件 %d 关联到故障点: fault_id=%d", rawEvent.EventID, faultPoint.FaultID)
 
-		//将恢复事件的 event_id 写入故障点的 RelationEventIDs
-		faultPoint.RelationEventIDs = slice.AppendUniqueUint64(faultPoint.RelationEventIDs, event.EventID)
-		faultPoint.FaultUpdateTime = timex.NowLocalTime()
-
-		if event.EventRecoveryTime != nil && event.EventRecoveryTime.After(faultPoint.FaultLatestTime) {
-			faultPoint.FaultLatestTime = *event.EventRecoveryTime
-		}
-
-		if event.EventOccurTime != nil {
-			faultPoint.FaultDurationTime = int64(faultPoint.FaultLatestTime.Sub(*event.EventOccurTime).Seconds())
-		}
-
-		if err := s.repoFactory.FaultPoints().Upsert(ctx, *faultPoint); err != nil {
-			return errors.Wrap(err, "更新故障点失败")
+		if faultPoint.FaultFlapping {
+			log.Infof("故障点 %d 抖动中，暂不标记恢复", faultPoint.FaultID)
+			if err := s.linkRecoveryEvent(ctx, *faultPoint, event.EventID); err != nil {
+				return err
+			}
+			continue
 		}
 
 		//最新事件是恢复事件，标记故障点为 已恢复
@@ -188,20 +326,10 @@ func (s *FaultPointStage) handleRecoveryEvents(ctx context.Context, event domain
 			return errors.Wrap(err, "更新故障点状态失败")
 		}
 
-		// 将 fault_id 回写到恢复事件
-		if err := s.linkFaultToEvents(ctx, faultPoint.FaultID, []uint64{event.EventID}); err != nil {
+		if err := s.linkRecoveryEvent(ctx, *faultPoint, event.EventID); err != nil {
 			return err
 		}
 
-		// 如果故障点已关联问题，也回写 problem_id 到恢复事件
-		if faultPoint.ProblemID != 0 {
-			if err := s.linkProblemToEvents(ctx, faultPoint.ProblemID, []uint64{event.EventID}); err != nil {
-				log.Infof("回写 problem_id=%d 到恢复事件 event_id=%d 失败: %v", faultPoint.ProblemID, event.EventID, err)
-			} else {
-				log.Infof("已回写 problem_id=%d 到恢复事件 event_id=%d", faultPoint.ProblemID, event.EventID)
-			}
-		}
-
 		//通知 ProblemStage 检查问题是否可以恢复
 		if err := s.problemHandler.HandleFaultPointRecovered(ctx, faultPoint.FaultID); err != nil {
 			return err
@@ -210,6 +338,48 @@ func (s *FaultPointStage) handleRecoveryEvents(ctx context.Context, event domain
 	return nil
 }
 
+// applyRecoveryEvent 将恢复事件合并到故障点：记录关联事件、最新时间与抖动状态。
+func applyRecoveryEvent(faultPoint *domain.FaultPointObject, event domain.RawEvent) {
+	//将恢复事件的 event_id 写入故障点的 RelationEventIDs
+	faultPoint.RelationEventIDs = slice.AppendUniqueUint64(faultPoint.RelationEventIDs, event.EventID)
+	faultPoint.FaultUpdateTime = timex.NowLocalTime()
+
+	if event.EventRecoveryTime != nil && event.EventRecoveryTime.After(faultPoint.FaultLatestTime) {
+		faultPoint.FaultLatestTime = *event.EventRecoveryTime
+	}
+
+	if event.EventOccurTime != nil {
+		faultPoint.FaultDurationTime = int64(faultPoint.FaultLatestTime.Sub(*event.EventOccurTime).Seconds())
+	}
+
+	// 抖动期间仅记录恢复状态，故障点保持发生，待抖动停止后由 settleFlappingFaultPoints 收敛
+	if event.EventFlapping {
+		faultPoint.FaultFlapping = true
+		faultPoint.FaultFlappingStatus = domain.EventStatusRecovered
+	} else {
+		faultPoint.FaultFlapping = false
+		faultPoint.FaultFlappingStatus = ""
+	}
+}
+
+// linkRecoveryEvent 将故障点的 fault_id 与 problem_id 回写到恢复事件。
+func (s *FaultPointStage) linkRecoveryEvent(ctx context.Context, faultPoint domain.FaultPointObject, eventID uint64) error {
+	// 将 fault_id 回写到恢复事件
+	if err := s.linkFaultToEvents(ctx, faultPoint.FaultID, []uint64{eventID}); err != nil {
+		return err
+	}
+
+	// 如果故障点已关联问题，也回写 problem_id 到恢复事件
+	if faultPoint.ProblemID != 0 {
+		if err := s.linkProblemToEvents(ctx, faultPoint.ProblemID, []uint64{eventID}); err != nil {
+			log.Infof("回写 problem_id=%d 到恢复事件 event_id=%d 失败: %v", faultPoint.ProblemID, eventID, err)
+		} else {
+			log.Infof("已回写 problem_id=%d 到恢复事件 event_id=%d", faultPoint.ProblemID, eventID)
+		}
+	}
+	return nil
+}
+
 // OnProblemLinked 冗余回写 problem_id 到故障点。
 func (s *FaultPointStage) OnProblemLinked(ctx context.Context, problemID uint64, faultIDs []uint64) error {
 	return s.repoFactory.FaultPoints().UpdateProblemID(ctx, faultIDs, problemID)
@@ -226,14 +396,14 @@ func (s *FaultPointStage) linkProblemToEvents(ctx context.Context, problemID uin
 }
 
 // writeFaultPointRelation 写入故障点关系。
-func (s *FaultPointStage) writeFaultPointRelation(ctx context.Context, fp domain.FaultPointObject, event domain.RawEvent) error {
+func (s *FaultPointStage) writeFaultPointRelation(ctx context.Context, fp domain.FaultPointObject) error {
 	if s.repoFactory.FaultPointRelations() == nil {
 		return nil // 如果未配置关系存储，跳过
 	}
 
 	relation := domain.FaultPointRelation{
 		RelationId:         s.genID.NextID(),
-		RelationClass:      event.EntityObjectClass,
+		RelationClass:      fp.EntityObjectClass,
 		RelationCreateTime: timex.NowLocalTime(),
 		RelationUpdateTime: timex.NowLocalTime(),
 		SourceObjectId:     fp.EntityObjectID,
@@ -263,6 +433,9 @@ func (s *FaultPointStage) Run(ctx context.Context) error {
 			log.Info("故障点失效检查器收到停止信号")
 			return ctx.Err()
 		case <-ticker.C:
+			if err := s.settleFlappingFaultPoints(ctx); err != nil {
+				log.Errorf("执行抖动故障点收敛失败: %v", err)
+			}
 			if err := s.checkAndExpireFaultPoints(ctx); err != nil {
 				log.Errorf("执行故障点失效检查失败: %v", err)
 			}
@@ -270,6 +443,66 @@ func (s *FaultPointStage) Run(ctx context.Context) error {
 	}
 }
 
+// settleFlappingFaultPoints 收敛已停止抖动的故障点：统计窗口内无新事件时，
+// 最后状态为恢复的故障点标记为已恢复并通知问题恢复检查，最后状态为发生的故障点取消抖动标记。
+func (s *FaultPointStage) settleFlappingFaultPoints(ctx context.Context) error {
+	flapping := s.cfgManager.GetConfig().AppConfig.Ingest.Flapping
+	if !flapping.Enabled {
+		return nil
+	}
+
+	quietSince := time.Now().Add(-flappingWindow(flapping))
+	fps, err := s.repoFactory.FaultPoints().FindFlappingQuiet(ctx, quietSince)
+	if err != nil {
+		return errors.Wrap(err, "查询抖动故障点失败")
+	}
+
+	for _, quiet := range fps {
+		// 查询后故障点被新事件修改时重新读取，仍在抖动且已静默才取消抖动标记
+		var fp domain.FaultPointObject
+		var settled, recovered bool
+		err := opensearch.RetryOnConflict(ctx, func() error {
+			faultPoints, err := s.repoFactory.FaultPoints().QueryByIDs(ctx, []uint64{quiet.FaultID})
+			if err != nil {
+				return errors.Wrap(err, "查询故障点失败")
+			}
+			settled = len(faultPoints) > 0 && faultPoints[0].FaultStatus == domain.FaultStatusOccurred &&
+				faultPoints[0].FaultFlapping && faultPoints[0].FaultLatestTime.Before(quietSince)
+			if !settled {
+				return nil
+			}
+			fp = faultPoints[0]
+			recovered = fp.FaultFlappingStatus == domain.EventStatusRecovered
+			fp.FaultFlapping = false
+			fp.FaultFlappingStatus = ""
+			fp.FaultUpdateTime = timex.NowLocalTime()
+			return s.repoFactory.FaultPoints().Upsert(ctx, fp)
+		})
+		if err != nil {
+			log.Errorf("取消故障点 %d 抖动标记失败: %v", quiet.FaultID, err)
+			continue
+		}
+		if !settled {
+			log.Infof("故障点 %d 已不再静默抖动，跳过收敛", quiet.FaultID)
+			continue
+		}
+		if !recovered {
+			log.Infof("故障点 %d 抖动停止，保持发生状态", fp.FaultID)
+			continue
+		}
+
+		log.Infof("故障点 %d 抖动停止，最后状态为恢复，标记为 recovered", fp.FaultID)
+		if err := s.repoFactory.FaultPoints().MakeRecovered(ctx, fp.FaultID, fp.FaultLatestTime); err != nil {
+			log.Errorf("标记故障点 %d 为已恢复失败: %v", fp.FaultID, err)
+			continue
+		}
+		if err := s.problemHandler.HandleFaultPointRecovered(ctx, fp.FaultID); err != nil {
+			log.Errorf("故障点 %d 恢复后检查问题失败: %v", fp.FaultID, err)
+		}
+	}
+	return nil
+}
+
 // checkAndExpireFaultPoints 检查并标记过期的故障点。
 func (s *FaultPointStage) checkAndExpireFaultPoints(ctx context.Context) error {
 	expirationTime := time.Now().Add(-s.cfgManager.GetConfig().AppConfig.FaultPoint.Expiration.ExpirationTime)
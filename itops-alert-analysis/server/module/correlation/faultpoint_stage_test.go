@@ -3,10 +3,13 @@ package correlation
 import (
 	"context"
 	"testing"
+	"time"
 
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config/configtest"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
+	"github.com/agiledragon/gomonkey/v2"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -29,6 +32,10 @@ func (s *problemHandlerStub) HandleFaultPointRecovered(ctx context.Context, faul
 	return nil
 }
 
+func (s *problemHandlerStub) HandleFaultPointLevelChanged(ctx context.Context, fp domain.FaultPointObject) error {
+	return nil
+}
+
 func TestNewFaultPointStage(t *testing.T) {
 	Convey("TestNewFaultPointStage", t, func() {
 		Convey("成功创建 FaultPointStage", func() {
@@ -75,7 +82,7 @@ func TestFaultPointStage_Run(t *testing.T) {
 		Convey("失效检查未启用时直接返回", func() {
 			cfg := newTestConfig()
 			cfg.AppConfig.FaultPoint.Expiration.Enabled = false
-			cfgManager := config.NewTestConfigManager(cfg)
+			cfgManager := configtest.NewProvider(cfg)
 			factory := opensearch.NewRepositoryFactory(nil)
 			stage := NewFaultPointStage(cfgManager, factory, nil)
 
@@ -85,3 +92,327 @@ func TestFaultPointStage_Run(t *testing.T) {
 		})
 	})
 }
+
+// recordingProblemHandler 记录下游调用的问题处理器桩
+type recordingProblemHandler struct {
+	problemHandlerStub
+	handled      []uint64
+	recovered    []uint64
+	levelChanged []domain.FaultPointObject
+}
+
+func (s *recordingProblemHandler) HandleFaultPoint(ctx context.Context, fp domain.FaultPointObject) error {
+	s.handled = append(s.handled, fp.FaultID)
+	return nil
+}
+
+func (s *recordingProblemHandler) HandleFaultPointRecovered(ctx context.Context, faultID uint64) error {
+	s.recovered = append(s.recovered, faultID)
+	return nil
+}
+
+func (s *recordingProblemHandler) HandleFaultPointLevelChanged(ctx context.Context, fp domain.FaultPointObject) error {
+	s.levelChanged = append(s.levelChanged, fp)
+	return nil
+}
+
+func TestFaultPointStage_Flapping(t *testing.T) {
+	Convey("TestFaultPointStage_Flapping", t, func() {
+		ctx := context.Background()
+		factory := opensearch.NewRepositoryFactory(nil)
+		cfg := newTestConfig()
+		cfg.AppConfig.Ingest.Flapping = config.FlappingConfig{Enabled: true, Threshold: 2, Window: 10 * time.Minute}
+		handler := &recordingProblemHandler{}
+		stage := NewFaultPointStage(configtest.NewProvider(cfg), factory, handler)
+
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+
+		var saved []domain.FaultPointObject
+		var recoveredIDs []uint64
+		patches.ApplyMethod(factory.FaultPoints(), "Upsert", func(_ *opensearch.FaultPointStore, _ context.Context, fp domain.FaultPointObject) error {
+			saved = append(saved, fp)
+			return nil
+		})
+		patches.ApplyMethod(factory.FaultPoints(), "MakeRecovered", func(_ *opensearch.FaultPointStore, _ context.Context, faultID uint64, _ time.Time) error {
+			recoveredIDs = append(recoveredIDs, faultID)
+			return nil
+		})
+		patches.ApplyMethod(factory.RawEvents(), "UpdateFaultID", func(_ *opensearch.RawEventStore, _ context.Context, _ []uint64, _ uint64) error {
+			return nil
+		})
+		patches.ApplyMethod(factory.RawEvents(), "UpdateProblemID", func(_ *opensearch.RawEventStore, _ context.Context, _ []uint64, _ uint64) error {
+			return nil
+		})
+
+		Convey("抖动中的恢复事件不标记故障点恢复", func() {
+			patches.ApplyMethod(factory.RawEvents(), "QueryByProviderID", func(_ *opensearch.RawEventStore, _ context.Context, _ []string) ([]domain.RawEvent, error) {
+				return []domain.RawEvent{{EventID: 1}}, nil
+			})
+			patches.ApplyMethod(factory.FaultPoints(), "FindByEventID", func(_ *opensearch.FaultPointStore, _ context.Context, _ uint64) (*domain.FaultPointObject, error) {
+				return &domain.FaultPointObject{FaultID: 10, FaultStatus: domain.FaultStatusOccurred, ProblemID: 20}, nil
+			})
+
+			err := stage.HandleEvent(ctx, domain.RawEvent{EventID: 2, RecoveryId: 1, EventStatus: domain.EventStatusRecovered, EventFlapping: true})
+
+			So(err, ShouldBeNil)
+			So(saved, ShouldHaveLength, 1)
+			So(saved[0].FaultFlapping, ShouldBeTrue)
+			So(saved[0].FaultFlappingStatus, ShouldEqual, domain.EventStatusRecovered)
+			So(saved[0].RelationEventIDs, ShouldContain, uint64(2))
+			So(recoveredIDs, ShouldBeEmpty)
+			So(handler.recovered, ShouldBeEmpty)
+		})
+
+		Convey("抖动中重新发生时复用已恢复的故障点", func() {
+			patches.ApplyMethod(factory.FaultPoints(), "FindOpenByEntityAndKey", func(_ *opensearch.FaultPointStore, _ context.Context, _, _ string, _ time.Time) (*domain.FaultPointObject, error) {
+				return nil, nil
+			})
+			patches.ApplyMethod(factory.FaultPoints(), "FindLatestByEntityAndKey", func(_ *opensearch.FaultPointStore, _ context.Context, _, _ string, _ time.Time) (*domain.FaultPointObject, error) {
+				return &domain.FaultPointObject{FaultID: 10, FaultStatus: domain.FaultStatusRecovered, ProblemID: 20}, nil
+			})
+
+			err := stage.HandleEvent(ctx, domain.RawEvent{EventID: 3, EntityObjectID: "host-1", EventType: "cpu.load", EventStatus: domain.EventStatusOccurred, EventFlapping: true})
+
+			So(err, ShouldBeNil)
+			So(saved, ShouldHaveLength, 1)
+			So(saved[0].FaultID, ShouldEqual, 10)
+			So(saved[0].FaultStatus, ShouldEqual, domain.FaultStatusOccurred)
+			So(saved[0].FaultFlapping, ShouldBeTrue)
+			So(handler.handled, ShouldResemble, []uint64{10})
+		})
+
+		Convey("已处于抖动的故障点再次发生时不重复触发问题关联", func() {
+			patches.ApplyMethod(factory.FaultPoints(), "FindOpenByEntityAndKey", func(_ *opensearch.FaultPointStore, _ context.Context, _, _ string, _ time.Time) (*domain.FaultPointObject, error) {
+				return &domain.FaultPointObject{FaultID: 10, FaultStatus: domain.FaultStatusOccurred, FaultFlapping: true, ProblemID: 20}, nil
+			})
+
+			err := stage.HandleEvent(ctx, domain.RawEvent{EventID: 4, EntityObjectID: "host-1", EventType: "cpu.load", EventStatus: domain.EventStatusOccurred, EventFlapping: true})
+
+			So(err, ShouldBeNil)
+			So(saved, ShouldHaveLength, 1)
+			So(handler.handled, ShouldBeEmpty)
+		})
+
+		Convey("抖动停止后按最后状态收敛", func() {
+			quietTime := time.Now().Add(-time.Hour)
+			stored := map[uint64]domain.FaultPointObject{
+				10: {FaultID: 10, FaultStatus: domain.FaultStatusOccurred, FaultFlapping: true, FaultFlappingStatus: domain.EventStatusRecovered, FaultLatestTime: quietTime},
+				11: {FaultID: 11, FaultStatus: domain.FaultStatusOccurred, FaultFlapping: true, FaultFlappingStatus: domain.EventStatusOccurred, FaultLatestTime: quietTime},
+			}
+			patches.ApplyMethod(factory.FaultPoints(), "FindFlappingQuiet", func(_ *opensearch.FaultPointStore, _ context.Context, _ time.Time) ([]domain.FaultPointObject, error) {
+				return []domain.FaultPointObject{stored[10], stored[11]}, nil
+			})
+			patches.ApplyMethod(factory.FaultPoints(), "QueryByIDs", func(_ *opensearch.FaultPointStore, _ context.Context, ids []uint64) ([]domain.FaultPointObject, error) {
+				return []domain.FaultPointObject{stored[ids[0]]}, nil
+			})
+
+			Convey("按最后状态取消抖动标记", func() {
+				err := stage.settleFlappingFaultPoints(ctx)
+
+				So(err, ShouldBeNil)
+				So(saved, ShouldHaveLength, 2)
+				So(saved[0].FaultFlapping, ShouldBeFalse)
+				So(saved[1].FaultFlapping, ShouldBeFalse)
+				So(recoveredIDs, ShouldResemble, []uint64{10})
+				So(handler.recovered, ShouldResemble, []uint64{10})
+			})
+
+			Convey("版本冲突时重新读取，查询后收到新事件的故障点不收敛", func() {
+				conflicts := 0
+				patches.ApplyMethod(factory.FaultPoints(), "Upsert", func(_ *opensearch.FaultPointStore, _ context.Context, fp domain.FaultPointObject) error {
+					if fp.FaultID == 10 && conflicts == 0 {
+						// 写入前故障点 10 收到新的发生事件
+						conflicts++
+						latest := stored[10]
+						latest.FaultFlappingStatus = domain.EventStatusOccurred
+						latest.FaultLatestTime = time.Now()
+						stored[10] = latest
+						return domain.ErrVersionConflict
+					}
+					saved = append(saved, fp)
+					return nil
+				})
+
+				err := stage.settleFlappingFaultPoints(ctx)
+
+				So(err, ShouldBeNil)
+				So(conflicts, ShouldEqual, 1)
+				So(saved, ShouldHaveLength, 1)
+				So(saved[0].FaultID, ShouldEqual, 11)
+				So(recoveredIDs, ShouldBeEmpty)
+			})
+		})
+
+		Convey("未启用抖动抑制时不收敛", func() {
+			cfg.AppConfig.Ingest.Flapping.Enabled = false
+
+			So(stage.settleFlappingFaultPoints(ctx), ShouldBeNil)
+			So(saved, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestFaultPointStage_ConvergenceKey(t *testing.T) {
+	Convey("TestFaultPointStage_ConvergenceKey", t, func() {
+		ctx := context.Background()
+		factory := opensearch.NewRepositoryFactory(nil)
+		cfg := newTestConfig()
+		cfg.AppConfig.FaultPoint.Convergence = config.ConvergenceConfig{
+			Rules: []config.ConvergenceRule{{Source: domain.SourceZabbixWebhook, Strategy: config.ConvergenceTriggerName}},
+		}
+		stage := NewFaultPointStage(configtest.NewProvider(cfg), factory, &recordingProblemHandler{})
+
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+
+		var lookupKeys []string
+		var saved []domain.FaultPointObject
+		patches.ApplyMethod(factory.FaultPoints(), "FindOpenByEntityAndKey", func(_ *opensearch.FaultPointStore, _ context.Context, _, faultKey string, _ time.Time) (*domain.FaultPointObject, error) {
+			lookupKeys = append(lookupKeys, faultKey)
+			return nil, nil
+		})
+		patches.ApplyMethod(factory.FaultPoints(), "Upsert", func(_ *opensearch.FaultPointStore, _ context.Context, fp domain.FaultPointObject) error {
+			saved = append(saved, fp)
+			return nil
+		})
+		patches.ApplyMethod(factory.FaultPointRelations(), "Upsert", func(_ *opensearch.FaultPointRelationStore, _ context.Context, _ domain.FaultPointRelation) error {
+			return nil
+		})
+		patches.ApplyMethod(factory.RawEvents(), "UpdateFaultID", func(_ *opensearch.RawEventStore, _ context.Context, _ []uint64, _ uint64) error {
+			return nil
+		})
+
+		Convey("按数据源选择的策略生成收敛键并写入故障点", func() {
+			event := domain.RawEvent{EventID: 1, EventSource: domain.SourceZabbixWebhook, EntityObjectID: "host-1",
+				EventType: "vfs.fs.size[/,pused]", EventTitle: "磁盘空间不足", EventStatus: domain.EventStatusOccurred}
+
+			So(stage.HandleEvent(ctx, event), ShouldBeNil)
+
+			So(lookupKeys, ShouldResemble, []string{"trigger_name:磁盘空间不足"})
+			So(saved, ShouldHaveLength, 1)
+			So(saved[0].FaultKey, ShouldEqual, "trigger_name:磁盘空间不足")
+			So(saved[0].FaultMode, ShouldEqual, "vfs.fs.size[/,pused]")
+		})
+
+		Convey("未命中规则时按 item_key 收敛", func() {
+			event := domain.RawEvent{EventID: 2, EventSource: domain.SourcePrometheusAlertmanager, EntityObjectID: "host-1",
+				EventType: "HighCPU", EventTitle: "CPU 使用率过高", EventStatus: domain.EventStatusOccurred}
+
+			So(stage.HandleEvent(ctx, event), ShouldBeNil)
+
+			So(lookupKeys, ShouldResemble, []string{"HighCPU"})
+			So(saved[0].FaultKey, ShouldEqual, "HighCPU")
+		})
+
+		Convey("按故障模式目录归一故障模式，收敛键不受影响", func() {
+			cfg.AppConfig.FailureModes = []config.FailureModeEntry{
+				{Mode: "disk_full", Rules: []config.FailureModeRule{{Pattern: "vfs.fs.size[*,pused]"}}},
+			}
+			event := domain.RawEvent{EventID: 3, EventSource: domain.SourcePrometheusAlertmanager, EntityObjectID: "host-1",
+				EventType: "vfs.fs.size[/data,pused]", EventStatus: domain.EventStatusOccurred}
+
+			So(stage.HandleEvent(ctx, event), ShouldBeNil)
+
+			So(lookupKeys, ShouldResemble, []string{"vfs.fs.size[/data,pused]"})
+			So(saved[0].FaultMode, ShouldEqual, "disk_full")
+			So(saved[0].FaultKey, ShouldEqual, "vfs.fs.size[/data,pused]")
+		})
+	})
+}
+
+func TestFaultPointStage_Severity(t *testing.T) {
+	Convey("TestFaultPointStage_Severity", t, func() {
+		ctx := context.Background()
+		factory := opensearch.NewRepositoryFactory(nil)
+		cfg := newTestConfig()
+		handler := &recordingProblemHandler{}
+		stage := NewFaultPointStage(configtest.NewProvider(cfg), factory, handler)
+
+		occur := time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)
+		existed := &domain.FaultPointObject{
+			FaultID:            10,
+			FaultStatus:        domain.FaultStatusOccurred,
+			FaultOccurTime:     occur,
+			FaultLevel:         domain.SeverityMajor,
+			FaultLevelTimeline: []domain.SeverityChange{{Level: domain.SeverityMajor, Timestamp: occur, EventID: 1}},
+			FaultLevelSeenTime: occur,
+			ProblemID:          20,
+		}
+
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+
+		var saved []domain.FaultPointObject
+		patches.ApplyMethod(factory.FaultPoints(), "FindOpenByEntityAndKey", func(_ *opensearch.FaultPointStore, _ context.Context, _, _ string, _ time.Time) (*domain.FaultPointObject, error) {
+			return existed, nil
+		})
+		patches.ApplyMethod(factory.FaultPoints(), "Upsert", func(_ *opensearch.FaultPointStore, _ context.Context, fp domain.FaultPointObject) error {
+			saved = append(saved, fp)
+			return nil
+		})
+		patches.ApplyMethod(factory.RawEvents(), "UpdateFaultID", func(_ *opensearch.RawEventStore, _ context.Context, _ []uint64, _ uint64) error {
+			return nil
+		})
+
+		newEvent := func(id uint64, level domain.Severity, at time.Time) domain.RawEvent {
+			return domain.RawEvent{EventID: id, EntityObjectID: "host-1", EventType: "cpu.load", EventLevel: level,
+				EventStatus: domain.EventStatusOccurred, EventOccurTime: &at}
+		}
+
+		Convey("更严重的事件立即升级并同步到问题", func() {
+			So(stage.HandleEvent(ctx, newEvent(2, domain.SeverityCritical, occur.Add(time.Minute))), ShouldBeNil)
+
+			So(saved, ShouldHaveLength, 1)
+			So(saved[0].FaultLevel, ShouldEqual, domain.SeverityCritical)
+			So(saved[0].FaultLevelTimeline, ShouldHaveLength, 2)
+			So(saved[0].FaultLevelTimeline[1], ShouldResemble, domain.SeverityChange{
+				Level: domain.SeverityCritical, PreviousLevel: domain.SeverityMajor, Timestamp: occur.Add(time.Minute), EventID: 2,
+			})
+			So(handler.levelChanged, ShouldHaveLength, 1)
+			So(handler.handled, ShouldResemble, []uint64{10})
+		})
+
+		Convey("未启用回落时更轻的事件不改变等级", func() {
+			So(stage.HandleEvent(ctx, newEvent(2, domain.SeverityWarning, occur.Add(time.Hour))), ShouldBeNil)
+
+			So(saved[0].FaultLevel, ShouldEqual, domain.SeverityMajor)
+			So(saved[0].FaultLevelTimeline, ShouldHaveLength, 1)
+			So(handler.levelChanged, ShouldBeEmpty)
+		})
+
+		Convey("启用回落后，窗口内仍出现当前等级时不回落", func() {
+			cfg.AppConfig.FaultPoint.Deescalation = config.DeescalationConfig{Enabled: true, Window: 10 * time.Minute}
+
+			So(stage.HandleEvent(ctx, newEvent(2, domain.SeverityMajor, occur.Add(8*time.Minute))), ShouldBeNil)
+			So(stage.HandleEvent(ctx, newEvent(3, domain.SeverityWarning, occur.Add(12*time.Minute))), ShouldBeNil)
+
+			So(existed.FaultLevel, ShouldEqual, domain.SeverityMajor)
+			So(existed.FaultLevelSeenTime, ShouldEqual, occur.Add(8*time.Minute))
+			So(handler.levelChanged, ShouldBeEmpty)
+		})
+
+		Convey("启用回落后，超过窗口未出现当前等级时回落到最新事件的等级", func() {
+			cfg.AppConfig.FaultPoint.Deescalation = config.DeescalationConfig{Enabled: true, Window: 10 * time.Minute}
+
+			So(stage.HandleEvent(ctx, newEvent(2, domain.SeverityWarning, occur.Add(11*time.Minute))), ShouldBeNil)
+
+			So(saved[0].FaultLevel, ShouldEqual, domain.SeverityWarning)
+			So(saved[0].FaultLevelTimeline[1].PreviousLevel, ShouldEqual, domain.SeverityMajor)
+			So(handler.levelChanged, ShouldHaveLength, 1)
+			So(handler.levelChanged[0].FaultLevel, ShouldEqual, domain.SeverityWarning)
+		})
+
+		Convey("新建故障点记录初始等级", func() {
+			existed = nil
+			patches.ApplyMethod(factory.FaultPointRelations(), "Upsert", func(_ *opensearch.FaultPointRelationStore, _ context.Context, _ domain.FaultPointRelation) error {
+				return nil
+			})
+
+			So(stage.HandleEvent(ctx, newEvent(5, domain.SeverityWarning, occur)), ShouldBeNil)
+
+			So(saved[0].FaultLevelTimeline, ShouldResemble, []domain.SeverityChange{{Level: domain.SeverityWarning, Timestamp: occur, EventID: 5}})
+			So(saved[0].FaultLevelSeenTime, ShouldEqual, occur)
+		})
+	})
+}
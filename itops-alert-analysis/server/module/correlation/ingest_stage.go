@@ -2,31 +2,53 @@ package correlation
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/core"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/maintenance"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/standardizer"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/idgen"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/timex"
 	"github.com/pkg/errors"
+	"github.com/spf13/cast"
 )
 
 // IngestStage 负责消费 Kafka、标准化并写入原始事件。
+// 配置并行通道后，事件按实体哈希到多个通道并行处理，同一实体内保持顺序。
+// 处理失败的消息转发到死信 topic，修复后可通过 ReplayDeadLetter 重放。
+// 入库前丢弃窗口内的重复事件，并标记处于抖动状态的事件，由 fault_point 模块据此抑制反复恢复与重开。
+// 命中维护窗口的发生事件入库时标记为已抑制，不再转发给 fault_point 模块。
 type IngestStage struct {
-	rawEventsConsumer core.KafkaConsumer
-	repoFactory       *opensearch.RepositoryFactory
-	fpHandler         core.FaultPointHandler
-	std               standardizer.Standardizer
+	cfgManager         config.Provider
+	rawEventsConsumer  core.KafkaConsumer
+	repoFactory        *opensearch.RepositoryFactory
+	fpHandler          core.FaultPointHandler
+	std                standardizer.Standardizer
+	deadLetterProducer core.KafkaProducer // 为 nil 时不启用死信
+	genID              *idgen.Generator
+	dedup              *eventDeduplicator
+	flapping           *flappingDetector
+	maintenance        *maintenance.Matcher // 为 nil 时不启用维护窗口
 }
 
-func NewIngestStage(repoFactory *opensearch.RepositoryFactory, fpHandler core.FaultPointHandler, std standardizer.Standardizer, kafkaConsumer core.KafkaConsumer) *IngestStage {
+func NewIngestStage(cfgManager config.Provider, repoFactory *opensearch.RepositoryFactory, fpHandler core.FaultPointHandler, std standardizer.Standardizer, kafkaConsumer core.KafkaConsumer, deadLetterProducer core.KafkaProducer, maintenanceMatcher *maintenance.Matcher) *IngestStage {
 	return &IngestStage{
-		rawEventsConsumer: kafkaConsumer,
-		repoFactory:       repoFactory,
-		fpHandler:         fpHandler,
-		std:               std,
+		cfgManager:         cfgManager,
+		rawEventsConsumer:  kafkaConsumer,
+		repoFactory:        repoFactory,
+		fpHandler:          fpHandler,
+		std:                std,
+		deadLetterProducer: deadLetterProducer,
+		genID:              idgen.New(),
+		dedup:              newEventDeduplicator(),
+		flapping:           newFlappingDetector(),
+		maintenance:        maintenanceMatcher,
 	}
 }
 
@@ -38,16 +60,257 @@ func (s *IngestStage) Start(ctx context.Context) error {
 	if s.std == nil {
 		return errors.New("standardizer not configured")
 	}
+	if parallel := s.parallelConfig(); parallel.Workers > 1 {
+		log.Infof("原始事件并行消费: workers=%d, lane_capacity=%d, commit_interval=%s",
+			parallel.Workers, parallel.LaneCapacity, parallel.CommitInterval)
+		return s.rawEventsConsumer.ConsumeParallel(ctx, core.ParallelConsumeOptions{
+			Workers:        parallel.Workers,
+			LaneCapacity:   parallel.LaneCapacity,
+			CommitInterval: parallel.CommitInterval,
+		}, s.dispatchKafkaMessage)
+	}
 	return s.rawEventsConsumer.ConsumeRawEvents(ctx, s.handleKafkaMessage)
 }
 
+// parallelConfig 返回原始事件流的并行消费配置，未配置 ConfigManager 时顺序消费。
+func (s *IngestStage) parallelConfig() config.ParallelConsumeConfig {
+	if s.cfgManager == nil {
+		return config.ParallelConsumeConfig{}
+	}
+	return s.cfgManager.GetConfig().Kafka.RawEvents.Parallel
+}
+
+// IngestStats 返回原始事件消费运行指标。
+func (s *IngestStage) IngestStats() domain.ConsumerStats {
+	if s.rawEventsConsumer == nil {
+		return domain.ConsumerStats{}
+	}
+	return s.rawEventsConsumer.Stats()
+}
+
 // handleKafkaMessage 处理 Kafka 消息：标准化、入库、下发。
+// 一条消息可能被标准化为多个事件（如 Alertmanager 分组推送），逐个处理，单个失败不影响其余事件。
+// 标准化失败的消息与处理失败的事件分别以 standardize / process 阶段写入死信，
+// 批量 payload 中部分告警标准化失败时只将失败的告警写入死信，其余事件照常处理。
+// 写入死信失败时返回 core.ErrUncommittable，不提交 offset。
 func (s *IngestStage) handleKafkaMessage(ctx context.Context, msg core.KafkaMessage) error {
-	raw, err := s.std.Standardize(ctx, msg.Value)
+	// 多数据源模式下，由 header 显式指定的数据源优先于 payload 嗅探
+	ctx = standardizer.WithSource(ctx, msg.Headers[core.HeaderEventSource])
+	raws, err := standardizer.StandardizeAll(ctx, s.std, msg.Value)
+	if err != nil {
+		if dlErr := s.deadLetterStandardizeFailure(ctx, msg, err); dlErr != nil {
+			return dlErr
+		}
+		if !isBatchError(err) {
+			return errors.Wrap(err, "standardize raw event")
+		}
+	}
+	if processErr := s.processRawEvents(ctx, msg, raws); processErr != nil {
+		return processErr
+	}
+	return errors.Wrap(err, "standardize raw event")
+}
+
+// deadLetterStandardizeFailure 将标准化失败写入 standardize 阶段死信。
+// 批量 payload 中部分告警标准化失败（*standardizer.BatchError）时每条失败的告警单独写入，否则写入整条消息。
+func (s *IngestStage) deadLetterStandardizeFailure(ctx context.Context, msg core.KafkaMessage, err error) error {
+	var batchErr *standardizer.BatchError
+	if !errors.As(err, &batchErr) {
+		return s.publishDeadLetter(ctx, msg, domain.DeadLetterStageStandardize, errors.Wrap(err, "standardize raw event"), nil)
+	}
+	for _, item := range batchErr.Failed {
+		itemMsg := msg
+		itemMsg.Value = item.Payload
+		if dlErr := s.publishDeadLetter(ctx, itemMsg, domain.DeadLetterStageStandardize, errors.Wrap(item, "standardize raw event"), nil); dlErr != nil {
+			return dlErr
+		}
+	}
+	return nil
+}
+
+// isBatchError 判断标准化错误是否为批量 payload 中部分告警失败，此时其余事件照常处理。
+func isBatchError(err error) bool {
+	var batchErr *standardizer.BatchError
+	return errors.As(err, &batchErr)
+}
+
+// dispatchKafkaMessage 并行消费时标准化消息，并将事件按实体拆分为通道任务。
+// 标准化失败的消息（批量 payload 中为每条失败的告警）写入 standardize 阶段死信，不产生任务；单个事件处理失败写入 process 阶段死信。
+// 写入死信失败时返回一个以 core.ErrUncommittable 失败的任务，使该消息的 offset 不被提交。
+func (s *IngestStage) dispatchKafkaMessage(ctx context.Context, msg core.KafkaMessage) []core.LaneTask {
+	sourceCtx := standardizer.WithSource(ctx, msg.Headers[core.HeaderEventSource])
+	raws, err := standardizer.StandardizeAll(sourceCtx, s.std, msg.Value)
+	if err != nil {
+		partial := isBatchError(err)
+		if !partial {
+			log.Errorf("标准化消息失败: partition=%d, offset=%d, err=%v", msg.Partition, msg.Offset, err)
+		}
+		if dlErr := s.deadLetterStandardizeFailure(ctx, msg, err); dlErr != nil {
+			return []core.LaneTask{{
+				Key: msg.Key,
+				Run: func(context.Context) error { return dlErr },
+			}}
+		}
+		if !partial {
+			return nil
+		}
+	}
+
+	tasks := make([]core.LaneTask, 0, len(raws))
+	for _, raw := range raws {
+		raw := raw
+		tasks = append(tasks, core.LaneTask{
+			Key: laneKey(msg, raw),
+			Run: func(ctx context.Context) error {
+				return s.processOrDeadLetter(ctx, msg, raw)
+			},
+		})
+	}
+	return tasks
+}
+
+// laneKey 并行消费的通道键：同一实体的发生与恢复事件落在同一通道，保证按顺序收敛到故障点。
+// 未解析出实体的事件按消息 key 分配。
+func laneKey(msg core.KafkaMessage, raw domain.RawEvent) string {
+	if raw.EntityObjectID != "" {
+		return raw.EntityObjectID
+	}
+	return msg.Key
+}
+
+// processRawEvents 逐个处理标准化后的事件，失败的事件写入 process 阶段死信。
+// 写入死信失败时立即返回 core.ErrUncommittable，整条消息在重启后重新消费。
+func (s *IngestStage) processRawEvents(ctx context.Context, msg core.KafkaMessage, raws []domain.RawEvent) error {
+	var firstErr error
+	for _, raw := range raws {
+		err := s.processOrDeadLetter(ctx, msg, raw)
+		if errors.Is(err, core.ErrUncommittable) {
+			return err
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// processOrDeadLetter 处理单个事件，失败时写入 process 阶段死信。
+// 因服务退出被中断的事件不写入死信，offset 未提交，重启后重新消费。
+func (s *IngestStage) processOrDeadLetter(ctx context.Context, msg core.KafkaMessage, raw domain.RawEvent) error {
+	err := s.processRawEvent(ctx, msg, raw)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	log.Errorf("处理原始事件失败: event_id=%d, err=%v", raw.EventID, err)
+	if dlErr := s.publishDeadLetter(ctx, msg, domain.DeadLetterStageProcess, err, &raw); dlErr != nil {
+		return dlErr
+	}
+	return err
+}
+
+// publishDeadLetter 将失败消息连同原因、阶段、原始 payload 发送到死信 topic。
+// 发送失败时返回 core.ErrUncommittable；未启用死信时返回 nil，与此前一致只记录失败。
+func (s *IngestStage) publishDeadLetter(ctx context.Context, msg core.KafkaMessage, stage domain.DeadLetterStage, cause error, raw *domain.RawEvent) error {
+	if s.deadLetterProducer == nil {
+		return nil
+	}
+
+	dl := domain.DeadLetter{
+		DeadLetterID: s.genID.NextID(),
+		Stage:        stage,
+		Reason:       cause.Error(),
+		Status:       domain.DeadLetterStatusPending,
+		EventSource:  msg.Headers[core.HeaderEventSource],
+		Payload:      string(msg.Value),
+		Headers:      msg.Headers,
+		RawEvent:     raw,
+		Topic:        msg.Topic,
+		Partition:    msg.Partition,
+		Offset:       msg.Offset,
+		FailedTime:   timex.NowLocalTime(),
+	}
+	value, err := json.Marshal(dl)
 	if err != nil {
-		return errors.Wrap(err, "standardize raw event")
+		log.Errorf("序列化死信失败: partition=%d, offset=%d, err=%v", msg.Partition, msg.Offset, err)
+		return errors.Wrapf(core.ErrUncommittable, "marshal dead letter: %v", err)
+	}
+	if err := s.deadLetterProducer.PublishRawEvent(ctx, cast.ToString(dl.DeadLetterID), value); err != nil {
+		log.Errorf("发送死信失败: partition=%d, offset=%d, err=%v", msg.Partition, msg.Offset, err)
+		return errors.Wrapf(core.ErrUncommittable, "publish dead letter: %v", err)
+	}
+	log.Warnf("消息处理失败，已转入死信: dead_letter_id=%d, stage=%s, partition=%d, offset=%d, reason=%s",
+		dl.DeadLetterID, stage, msg.Partition, msg.Offset, dl.Reason)
+	return nil
+}
+
+// ReplayDeadLetter 重放死信并记录重放结果。
+// standardize 阶段的死信重新标准化原始 payload，其中处理失败的事件会转入新的 process 阶段死信；
+// process 阶段的死信直接处理已标准化的事件。
+func (s *IngestStage) ReplayDeadLetter(ctx context.Context, dl domain.DeadLetter) error {
+	if dl.Status == domain.DeadLetterStatusReplayed {
+		return errors.Errorf("dead letter %d 已重放", dl.DeadLetterID)
+	}
+
+	replayErr := s.replay(ctx, dl)
+
+	now := timex.NowLocalTime()
+	dl.ReplayCount++
+	dl.LastReplayTime = &now
+	dl.LastReplayErr = ""
+	if replayErr != nil {
+		dl.LastReplayErr = replayErr.Error()
+	} else {
+		dl.Status = domain.DeadLetterStatusReplayed
+	}
+	if err := s.repoFactory.DeadLetters().Upsert(ctx, dl); err != nil {
+		log.Errorf("更新死信重放结果失败: dead_letter_id=%d, err=%v", dl.DeadLetterID, err)
+		if replayErr == nil {
+			return errors.Wrap(err, "update dead letter")
+		}
+	}
+	return replayErr
+}
+
+func (s *IngestStage) replay(ctx context.Context, dl domain.DeadLetter) error {
+	msg := core.KafkaMessage{
+		Key:       cast.ToString(dl.DeadLetterID),
+		Value:     []byte(dl.Payload),
+		Topic:     dl.Topic,
+		Partition: dl.Partition,
+		Offset:    dl.Offset,
+		Headers:   dl.Headers,
 	}
 
+	if dl.Stage == domain.DeadLetterStageProcess {
+		if dl.RawEvent == nil {
+			return errors.New("process 阶段死信缺少已标准化的事件")
+		}
+		return s.processRawEvent(ctx, msg, *dl.RawEvent)
+	}
+
+	if s.std == nil {
+		return errors.New("standardizer not configured")
+	}
+	ctx = standardizer.WithSource(ctx, dl.EventSource)
+	raws, err := standardizer.StandardizeAll(ctx, s.std, msg.Value)
+	if err != nil {
+		if !isBatchError(err) || len(raws) == 0 {
+			return errors.Wrap(err, "standardize raw event")
+		}
+		// 部分告警仍标准化失败时单独转入新的死信
+		if dlErr := s.deadLetterStandardizeFailure(ctx, msg, err); dlErr != nil {
+			return dlErr
+		}
+	}
+	// 单个事件处理失败已转入新的死信，原死信视为重放成功；未能转入新死信时原死信保持待重放
+	if err := s.processRawEvents(ctx, msg, raws); errors.Is(err, core.ErrUncommittable) {
+		return err
+	}
+	return nil
+}
+
+// processRawEvent 处理单个标准化后的事件：入库并转发给 fault_point 模块。
+func (s *IngestStage) processRawEvent(ctx context.Context, msg core.KafkaMessage, raw domain.RawEvent) error {
 	// 在 defer 中记录处理耗时
 	defer func(t time.Time) {
 		duration := time.Since(t)
@@ -70,18 +333,56 @@ func (s *IngestStage) handleKafkaMessage(ctx context.Context, msg core.KafkaMess
 		}
 	}(timex.NowLocalTime())
 
+	ingestCfg := s.ingestConfig()
+	if ingestCfg.Dedup.Enabled && s.dedup.isDuplicate(ctx, raw) {
+		log.Infof("重复事件，忽略: event_source=%s, event_provider_id=%d, event_status=%s",
+			raw.EventSource, raw.EventProviderID, raw.EventStatus)
+		return nil
+	}
+	if ingestCfg.Flapping.Enabled {
+		raw.EventFlapping = s.flapping.observe(raw, flappingThreshold(ingestCfg.Flapping), flappingWindow(ingestCfg.Flapping))
+		if raw.EventFlapping {
+			log.Infof("事件处于抖动状态: event_id=%d, entity_object_id=%s, event_type=%s, event_status=%s",
+				raw.EventID, raw.EntityObjectID, raw.EventType, raw.EventStatus)
+		}
+	}
+
+	if w := s.maintenance.Match(ctx, raw, eventTime(raw)); w != nil {
+		raw.EventSuppressed = true
+		raw.MaintenanceID = w.ID
+		raw.MaintenanceName = w.Name
+		raw.EventSuppressedReason = fmt.Sprintf("suppressed by maintenance %s", w.Name)
+		log.Infof("事件命中维护窗口: event_id=%d, entity_object_id=%s, maintenance=%s",
+			raw.EventID, raw.EntityObjectID, w.Name)
+	}
+
 	// 事件均做标准化的处理
 	if err := s.repoFactory.RawEvents().Upsert(ctx, raw); err != nil {
 		return errors.Wrap(err, "persist raw event")
 	}
 
-	// 转发给 fault_point 模块处理
-	if s.fpHandler != nil {
-		return s.fpHandler.HandleEvent(ctx, raw)
+	// 转发给 fault_point 模块处理；被抑制的恢复事件仍需转发，使维护开始前已存在的故障点能够恢复
+	suppressed := raw.EventSuppressed && raw.EventStatus != domain.EventStatusRecovered
+	if s.fpHandler != nil && !suppressed {
+		if err := s.fpHandler.HandleEvent(ctx, raw); err != nil {
+			return err
+		}
+	}
+
+	if ingestCfg.Dedup.Enabled {
+		s.dedup.remember(ctx, raw, dedupWindow(ingestCfg.Dedup))
 	}
 	return nil
 }
 
+// ingestConfig 返回当前数据摄取配置，未配置 ConfigManager 时不启用去重与抖动抑制。
+func (s *IngestStage) ingestConfig() config.IngestConfig {
+	if s.cfgManager == nil {
+		return config.IngestConfig{}
+	}
+	return s.cfgManager.GetConfig().AppConfig.Ingest
+}
+
 // OnFaultPointLinked 将生成的 fault_id 回写到相关事件。
 func (s *IngestStage) OnFaultPointLinked(ctx context.Context, faultID uint64, RelationEventIDs []uint64) error {
 	return s.repoFactory.RawEvents().UpdateFaultID(ctx, RelationEventIDs, faultID)
@@ -2,13 +2,18 @@ package correlation
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config/configtest"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/core"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/kafka"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/maintenance"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/standardizer"
 	"github.com/agiledragon/gomonkey/v2"
 	. "github.com/smartystreets/goconvey/convey"
@@ -19,7 +24,7 @@ func TestNewIngestStage(t *testing.T) {
 		Convey("成功创建 IngestStage", func() {
 			factory := opensearch.NewRepositoryFactory(nil)
 
-			stage := NewIngestStage(factory, nil, nil, nil)
+			stage := NewIngestStage(nil, factory, nil, nil, nil, nil, nil)
 
 			So(stage, ShouldNotBeNil)
 			So(stage.repoFactory, ShouldEqual, factory)
@@ -40,7 +45,7 @@ func TestIngestStage_Start(t *testing.T) {
 				return &zabbixStandardizerStub{}, nil
 			})
 
-			stage := NewIngestStage(factory, nil, &zabbixStandardizerStub{}, nil)
+			stage := NewIngestStage(nil, factory, nil, &zabbixStandardizerStub{}, nil, nil, nil)
 
 			err := stage.Start(context.Background())
 
@@ -50,7 +55,7 @@ func TestIngestStage_Start(t *testing.T) {
 
 		Convey("standardizer 未配置返回错误", func() {
 			consumer := &kafka.Consumer{}
-			stage := NewIngestStage(factory, nil, nil, consumer)
+			stage := NewIngestStage(nil, factory, nil, nil, consumer, nil, nil)
 
 			err := stage.Start(context.Background())
 
@@ -60,9 +65,464 @@ func TestIngestStage_Start(t *testing.T) {
 	})
 }
 
+func TestIngestStage_DeadLetter(t *testing.T) {
+	Convey("TestIngestStage_DeadLetter", t, func() {
+		factory := opensearch.NewRepositoryFactory(nil)
+		producer := &deadLetterProducerStub{}
+		msg := core.KafkaMessage{
+			Topic:     "itops_alert_raw_event",
+			Partition: 1,
+			Offset:    42,
+			Value:     []byte(`{"bad":true}`),
+			Headers:   map[string]string{core.HeaderEventSource: "zabbix"},
+		}
+
+		Convey("标准化失败写入 standardize 阶段死信", func() {
+			stage := NewIngestStage(nil, factory, nil, &zabbixStandardizerStub{}, nil, producer, nil)
+
+			err := stage.handleKafkaMessage(context.Background(), msg)
+
+			So(err, ShouldNotBeNil)
+			So(producer.letters, ShouldHaveLength, 1)
+			dl := producer.letters[0]
+			So(dl.DeadLetterID, ShouldNotEqual, 0)
+			So(dl.Stage, ShouldEqual, domain.DeadLetterStageStandardize)
+			So(dl.Status, ShouldEqual, domain.DeadLetterStatusPending)
+			So(dl.Reason, ShouldContainSubstring, "stub")
+			So(dl.Payload, ShouldEqual, `{"bad":true}`)
+			So(dl.EventSource, ShouldEqual, "zabbix")
+			So(dl.Topic, ShouldEqual, "itops_alert_raw_event")
+			So(dl.Offset, ShouldEqual, 42)
+			So(dl.RawEvent, ShouldBeNil)
+		})
+
+		Convey("事件入库失败写入 process 阶段死信", func() {
+			std := &rawEventStandardizerStub{raw: domain.RawEvent{EventID: 1001}}
+			stage := NewIngestStage(nil, factory, nil, std, nil, producer, nil)
+
+			err := stage.handleKafkaMessage(context.Background(), msg)
+
+			So(err, ShouldNotBeNil)
+			So(producer.letters, ShouldHaveLength, 1)
+			So(producer.letters[0].Stage, ShouldEqual, domain.DeadLetterStageProcess)
+			So(producer.letters[0].RawEvent, ShouldNotBeNil)
+			So(producer.letters[0].RawEvent.EventID, ShouldEqual, 1001)
+		})
+
+		Convey("未配置死信 producer 时仅返回错误", func() {
+			stage := NewIngestStage(nil, factory, nil, &zabbixStandardizerStub{}, nil, nil, nil)
+
+			err := stage.handleKafkaMessage(context.Background(), msg)
+
+			So(err, ShouldNotBeNil)
+			So(errors.Is(err, core.ErrUncommittable), ShouldBeFalse)
+		})
+
+		Convey("死信发送失败时返回 ErrUncommittable，不提交 offset", func() {
+			producer.err = errors.New("broker unavailable")
+
+			stage := NewIngestStage(nil, factory, nil, &zabbixStandardizerStub{}, nil, producer, nil)
+			err := stage.handleKafkaMessage(context.Background(), msg)
+			So(errors.Is(err, core.ErrUncommittable), ShouldBeTrue)
+
+			std := &rawEventStandardizerStub{raw: domain.RawEvent{EventID: 1001}}
+			stage = NewIngestStage(nil, factory, nil, std, nil, producer, nil)
+			err = stage.handleKafkaMessage(context.Background(), msg)
+			So(errors.Is(err, core.ErrUncommittable), ShouldBeTrue)
+			So(producer.letters, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestIngestStage_DispatchKafkaMessage(t *testing.T) {
+	Convey("TestIngestStage_DispatchKafkaMessage", t, func() {
+		factory := opensearch.NewRepositoryFactory(nil)
+		producer := &deadLetterProducerStub{}
+		msg := core.KafkaMessage{Key: "msg-key", Partition: 1, Offset: 42, Value: []byte(`{}`)}
+
+		Convey("按实体拆分为通道任务，未解析实体的事件使用消息 key", func() {
+			std := &batchStandardizerStub{raws: []domain.RawEvent{
+				{EventID: 1, EntityObjectID: "host-1"},
+				{EventID: 2, EntityObjectID: "host-2"},
+				{EventID: 3},
+			}}
+			stage := NewIngestStage(nil, factory, nil, std, nil, producer, nil)
+
+			tasks := stage.dispatchKafkaMessage(context.Background(), msg)
+
+			So(tasks, ShouldHaveLength, 3)
+			So(tasks[0].Key, ShouldEqual, "host-1")
+			So(tasks[1].Key, ShouldEqual, "host-2")
+			So(tasks[2].Key, ShouldEqual, "msg-key")
+		})
+
+		Convey("任务执行失败写入 process 阶段死信", func() {
+			std := &batchStandardizerStub{raws: []domain.RawEvent{{EventID: 1001, EntityObjectID: "host-1"}}}
+			stage := NewIngestStage(nil, factory, nil, std, nil, producer, nil)
+
+			tasks := stage.dispatchKafkaMessage(context.Background(), msg)
+			err := tasks[0].Run(context.Background())
+
+			So(err, ShouldNotBeNil)
+			So(producer.letters, ShouldHaveLength, 1)
+			So(producer.letters[0].Stage, ShouldEqual, domain.DeadLetterStageProcess)
+			So(producer.letters[0].RawEvent.EventID, ShouldEqual, 1001)
+		})
+
+		Convey("服务退出中断的任务不写入死信", func() {
+			std := &batchStandardizerStub{raws: []domain.RawEvent{{EventID: 1001, EntityObjectID: "host-1"}}}
+			stage := NewIngestStage(nil, factory, nil, std, nil, producer, nil)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			tasks := stage.dispatchKafkaMessage(context.Background(), msg)
+			err := tasks[0].Run(ctx)
+
+			So(err, ShouldNotBeNil)
+			So(producer.letters, ShouldBeEmpty)
+		})
+
+		Convey("部分告警标准化失败时失败的告警逐条写入死信，其余事件产生任务", func() {
+			std := &batchStandardizerStub{
+				raws: []domain.RawEvent{{EventID: 1, EntityObjectID: "host-1"}},
+				err: &standardizer.BatchError{Total: 3, Failed: []standardizer.ItemError{
+					{Index: 1, Payload: []byte(`{"alerts":[1]}`), Err: errors.New("对象不存在")},
+					{Index: 2, Payload: []byte(`{"alerts":[2]}`), Err: errors.New("对象不存在")},
+				}},
+			}
+			stage := NewIngestStage(nil, factory, nil, std, nil, producer, nil)
+
+			tasks := stage.dispatchKafkaMessage(context.Background(), msg)
+
+			So(tasks, ShouldHaveLength, 1)
+			So(producer.letters, ShouldHaveLength, 2)
+			So(producer.letters[0].Stage, ShouldEqual, domain.DeadLetterStageStandardize)
+			So(producer.letters[0].Payload, ShouldEqual, `{"alerts":[1]}`)
+			So(producer.letters[0].Reason, ShouldContainSubstring, "第 1 条告警")
+			So(producer.letters[1].Payload, ShouldEqual, `{"alerts":[2]}`)
+			So(producer.letters[1].Offset, ShouldEqual, 42)
+		})
+
+		Convey("标准化失败写入死信，不产生任务", func() {
+			stage := NewIngestStage(nil, factory, nil, &zabbixStandardizerStub{}, nil, producer, nil)
+
+			tasks := stage.dispatchKafkaMessage(context.Background(), msg)
+
+			So(tasks, ShouldBeEmpty)
+			So(producer.letters, ShouldHaveLength, 1)
+			So(producer.letters[0].Stage, ShouldEqual, domain.DeadLetterStageStandardize)
+		})
+
+		Convey("标准化失败且死信发送失败时产生以 ErrUncommittable 失败的任务", func() {
+			producer.err = errors.New("broker unavailable")
+			stage := NewIngestStage(nil, factory, nil, &zabbixStandardizerStub{}, nil, producer, nil)
+
+			tasks := stage.dispatchKafkaMessage(context.Background(), msg)
+
+			So(tasks, ShouldHaveLength, 1)
+			So(tasks[0].Key, ShouldEqual, "msg-key")
+			So(errors.Is(tasks[0].Run(context.Background()), core.ErrUncommittable), ShouldBeTrue)
+		})
+	})
+}
+
+func TestIngestStage_StartParallel(t *testing.T) {
+	Convey("TestIngestStage_StartParallel", t, func() {
+		cfg := newTestConfig()
+		cfg.Kafka.RawEvents.Parallel = config.ParallelConsumeConfig{Workers: 4, LaneCapacity: 10, CommitInterval: time.Second}
+		consumer := &kafka.Consumer{}
+		stage := NewIngestStage(configtest.NewProvider(cfg), opensearch.NewRepositoryFactory(nil), nil, &zabbixStandardizerStub{}, consumer, nil, nil)
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+
+		var opts core.ParallelConsumeOptions
+		patches.ApplyMethod(consumer, "ConsumeParallel", func(_ *kafka.Consumer, _ context.Context, o core.ParallelConsumeOptions, _ func(context.Context, core.KafkaMessage) []core.LaneTask) error {
+			opts = o
+			return nil
+		})
+		patches.ApplyMethod(consumer, "ConsumeRawEvents", func(_ *kafka.Consumer, _ context.Context, _ func(context.Context, core.KafkaMessage) error) error {
+			return errors.New("不应顺序消费")
+		})
+
+		err := stage.Start(context.Background())
+
+		So(err, ShouldBeNil)
+		So(opts, ShouldResemble, core.ParallelConsumeOptions{Workers: 4, LaneCapacity: 10, CommitInterval: time.Second})
+	})
+}
+
+func TestIngestStage_ReplayDeadLetter(t *testing.T) {
+	Convey("TestIngestStage_ReplayDeadLetter", t, func() {
+		factory := opensearch.NewRepositoryFactory(nil)
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+
+		var saved domain.DeadLetter
+		patches.ApplyMethod(factory.DeadLetters(), "Upsert", func(_ *opensearch.DeadLetterStore, _ context.Context, dl domain.DeadLetter) error {
+			saved = dl
+			return nil
+		})
+		patches.ApplyMethod(factory.RawEvents(), "Upsert", func(_ *opensearch.RawEventStore, _ context.Context, _ domain.RawEvent) error {
+			return nil
+		})
+
+		Convey("standardize 阶段重放成功标记为已重放", func() {
+			std := &rawEventStandardizerStub{raw: domain.RawEvent{EventID: 1001}}
+			stage := NewIngestStage(nil, factory, nil, std, nil, nil, nil)
+
+			err := stage.ReplayDeadLetter(context.Background(), domain.DeadLetter{
+				DeadLetterID: 1,
+				Stage:        domain.DeadLetterStageStandardize,
+				Status:       domain.DeadLetterStatusPending,
+				Payload:      `{}`,
+			})
+
+			So(err, ShouldBeNil)
+			So(saved.Status, ShouldEqual, domain.DeadLetterStatusReplayed)
+			So(saved.ReplayCount, ShouldEqual, 1)
+			So(saved.LastReplayTime, ShouldNotBeNil)
+		})
+
+		Convey("重放失败保留待处理状态并记录错误", func() {
+			stage := NewIngestStage(nil, factory, nil, &zabbixStandardizerStub{}, nil, nil, nil)
+
+			err := stage.ReplayDeadLetter(context.Background(), domain.DeadLetter{
+				DeadLetterID: 2,
+				Stage:        domain.DeadLetterStageStandardize,
+				Status:       domain.DeadLetterStatusPending,
+				ReplayCount:  1,
+			})
+
+			So(err, ShouldNotBeNil)
+			So(saved.Status, ShouldEqual, domain.DeadLetterStatusPending)
+			So(saved.ReplayCount, ShouldEqual, 2)
+			So(saved.LastReplayErr, ShouldContainSubstring, "stub")
+		})
+
+		Convey("process 阶段直接处理已标准化事件", func() {
+			stage := NewIngestStage(nil, factory, nil, nil, nil, nil, nil)
+
+			err := stage.ReplayDeadLetter(context.Background(), domain.DeadLetter{
+				DeadLetterID: 3,
+				Stage:        domain.DeadLetterStageProcess,
+				Status:       domain.DeadLetterStatusPending,
+				RawEvent:     &domain.RawEvent{EventID: 1001},
+			})
+
+			So(err, ShouldBeNil)
+			So(saved.Status, ShouldEqual, domain.DeadLetterStatusReplayed)
+		})
+
+		Convey("process 阶段缺少事件返回错误", func() {
+			stage := NewIngestStage(nil, factory, nil, nil, nil, nil, nil)
+
+			err := stage.ReplayDeadLetter(context.Background(), domain.DeadLetter{
+				DeadLetterID: 4,
+				Stage:        domain.DeadLetterStageProcess,
+			})
+
+			So(err, ShouldNotBeNil)
+			So(saved.LastReplayErr, ShouldContainSubstring, "缺少")
+		})
+
+		Convey("已重放的死信不允许重复重放", func() {
+			stage := NewIngestStage(nil, factory, nil, nil, nil, nil, nil)
+
+			err := stage.ReplayDeadLetter(context.Background(), domain.DeadLetter{
+				DeadLetterID: 5,
+				Status:       domain.DeadLetterStatusReplayed,
+			})
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "已重放")
+		})
+	})
+}
+
+// deadLetterProducerStub 记录发送到死信 topic 的消息，err 不为 nil 时发送失败
+type deadLetterProducerStub struct {
+	letters []domain.DeadLetter
+	err     error
+}
+
+func (p *deadLetterProducerStub) PublishRawEvent(ctx context.Context, key string, value []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	var dl domain.DeadLetter
+	if err := json.Unmarshal(value, &dl); err != nil {
+		return err
+	}
+	p.letters = append(p.letters, dl)
+	return nil
+}
+
+func (p *deadLetterProducerStub) PublishRawEventWithHeaders(ctx context.Context, key string, value []byte, headers map[string]string) error {
+	return p.PublishRawEvent(ctx, key, value)
+}
+
+func (p *deadLetterProducerStub) Close() error {
+	return nil
+}
+
+// rawEventStandardizerStub 返回固定事件的桩
+type rawEventStandardizerStub struct {
+	raw domain.RawEvent
+}
+
+func (s *rawEventStandardizerStub) Standardize(ctx context.Context, payload []byte) (domain.RawEvent, error) {
+	return s.raw, nil
+}
+
+// batchStandardizerStub 将 payload 拆分为固定多条事件的桩
+type batchStandardizerStub struct {
+	raws []domain.RawEvent
+	err  error
+}
+
+func (s *batchStandardizerStub) Standardize(ctx context.Context, payload []byte) (domain.RawEvent, error) {
+	return s.raws[0], nil
+}
+
+func (s *batchStandardizerStub) StandardizeBatch(ctx context.Context, payload []byte) ([]domain.RawEvent, error) {
+	return s.raws, s.err
+}
+
 // zabbixStandardizerStub 用于测试的桩
 type zabbixStandardizerStub struct{}
 
 func (s *zabbixStandardizerStub) Standardize(ctx context.Context, payload []byte) (domain.RawEvent, error) {
 	return domain.RawEvent{}, errors.New("stub")
 }
+
+func TestIngestStage_Suppression(t *testing.T) {
+	Convey("TestIngestStage_Suppression", t, func() {
+		factory := opensearch.NewRepositoryFactory(nil)
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+
+		var persisted []domain.RawEvent
+		patches.ApplyMethod(factory.RawEvents(), "Upsert", func(_ *opensearch.RawEventStore, _ context.Context, raw domain.RawEvent) error {
+			persisted = append(persisted, raw)
+			return nil
+		})
+
+		cfg := newTestConfig()
+		cfg.AppConfig.Ingest.Dedup = config.DedupConfig{Enabled: true, Window: time.Minute}
+		cfg.AppConfig.Ingest.Flapping = config.FlappingConfig{Enabled: true, Threshold: 2, Window: 10 * time.Minute}
+		stage := NewIngestStage(configtest.NewProvider(cfg), factory, nil, nil, nil, nil, nil)
+		now := time.Now()
+
+		Convey("窗口内重复投递的事件只入库一次", func() {
+			raw := domain.RawEvent{EventID: 1, EventProviderID: 100, EventSource: "zabbix_webhook", EventStatus: domain.EventStatusOccurred}
+
+			So(stage.processRawEvent(context.Background(), core.KafkaMessage{}, raw), ShouldBeNil)
+			raw.EventID = 2
+			So(stage.processRawEvent(context.Background(), core.KafkaMessage{}, raw), ShouldBeNil)
+
+			So(persisted, ShouldHaveLength, 1)
+			So(persisted[0].EventID, ShouldEqual, 1)
+		})
+
+		Convey("频繁发生/恢复的事件标记为抖动", func() {
+			statuses := []domain.EventStatus{domain.EventStatusOccurred, domain.EventStatusRecovered, domain.EventStatusOccurred}
+			for i, status := range statuses {
+				raw := domain.RawEvent{
+					EventID:         uint64(i + 1),
+					EventProviderID: uint64(i + 100),
+					EventStatus:     status,
+					EventType:       "cpu.load",
+					EntityObjectID:  "host-1",
+					EventTimestamp:  now.Add(time.Duration(i) * time.Minute),
+				}
+				So(stage.processRawEvent(context.Background(), core.KafkaMessage{}, raw), ShouldBeNil)
+			}
+
+			So(persisted, ShouldHaveLength, 3)
+			So(persisted[1].EventFlapping, ShouldBeFalse)
+			So(persisted[2].EventFlapping, ShouldBeTrue)
+		})
+
+		Convey("未启用时不去重", func() {
+			stage := NewIngestStage(nil, factory, nil, nil, nil, nil, nil)
+			raw := domain.RawEvent{EventID: 1, EventProviderID: 100, EventSource: "zabbix_webhook"}
+
+			So(stage.processRawEvent(context.Background(), core.KafkaMessage{}, raw), ShouldBeNil)
+			So(stage.processRawEvent(context.Background(), core.KafkaMessage{}, raw), ShouldBeNil)
+
+			So(persisted, ShouldHaveLength, 2)
+		})
+	})
+}
+
+// recordingFaultPointHandler 记录转发给 fault_point 模块的事件。
+type recordingFaultPointHandler struct {
+	events []domain.RawEvent
+}
+
+func (h *recordingFaultPointHandler) HandleEvent(_ context.Context, event domain.RawEvent) error {
+	h.events = append(h.events, event)
+	return nil
+}
+
+func (h *recordingFaultPointHandler) OnProblemLinked(_ context.Context, _ uint64, _ []uint64) error {
+	return nil
+}
+
+func TestIngestStage_Maintenance(t *testing.T) {
+	Convey("TestIngestStage_Maintenance", t, func() {
+		factory := opensearch.NewRepositoryFactory(nil)
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+
+		var persisted []domain.RawEvent
+		patches.ApplyMethod(factory.RawEvents(), "Upsert", func(_ *opensearch.RawEventStore, _ context.Context, raw domain.RawEvent) error {
+			persisted = append(persisted, raw)
+			return nil
+		})
+
+		now := time.Now()
+		cfg := newTestConfig()
+		cfg.AppConfig.MaintenanceWindows = []config.MaintenanceWindow{{
+			ID:       "mw-1",
+			Name:     "db-upgrade",
+			Enabled:  true,
+			Selector: config.MaintenanceSelector{EntityIDs: []string{"host-1"}},
+			Start:    now.Add(-time.Hour),
+			End:      now.Add(time.Hour),
+		}}
+		cfgManager := configtest.NewProvider(cfg)
+		handler := &recordingFaultPointHandler{}
+		stage := NewIngestStage(cfgManager, factory, handler, nil, nil, nil, maintenance.NewMatcher(cfgManager, nil))
+
+		Convey("命中维护窗口的发生事件入库并标记，不转发给 fault_point 模块", func() {
+			raw := domain.RawEvent{EventID: 1, EntityObjectID: "host-1", EventStatus: domain.EventStatusOccurred, EventTimestamp: now}
+
+			So(stage.processRawEvent(context.Background(), core.KafkaMessage{}, raw), ShouldBeNil)
+
+			So(persisted, ShouldHaveLength, 1)
+			So(persisted[0].EventSuppressed, ShouldBeTrue)
+			So(persisted[0].MaintenanceID, ShouldEqual, "mw-1")
+			So(persisted[0].EventSuppressedReason, ShouldEqual, "suppressed by maintenance db-upgrade")
+			So(handler.events, ShouldBeEmpty)
+		})
+
+		Convey("命中维护窗口的恢复事件仍转发，使已有故障点恢复", func() {
+			raw := domain.RawEvent{EventID: 2, EntityObjectID: "host-1", EventStatus: domain.EventStatusRecovered, EventTimestamp: now}
+
+			So(stage.processRawEvent(context.Background(), core.KafkaMessage{}, raw), ShouldBeNil)
+
+			So(persisted[0].EventSuppressed, ShouldBeTrue)
+			So(handler.events, ShouldHaveLength, 1)
+		})
+
+		Convey("未命中维护窗口的事件正常转发", func() {
+			raw := domain.RawEvent{EventID: 3, EntityObjectID: "host-2", EventStatus: domain.EventStatusOccurred, EventTimestamp: now}
+
+			So(stage.processRawEvent(context.Background(), core.KafkaMessage{}, raw), ShouldBeNil)
+
+			So(persisted[0].EventSuppressed, ShouldBeFalse)
+			So(handler.events, ShouldHaveLength, 1)
+		})
+	})
+}
@@ -0,0 +1,213 @@
+// Package maintenance 实现维护窗口匹配：判断事件是否落在计划维护的对象与时间范围内。
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/dip"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+)
+
+const (
+	defaultDirection  = "bidirectional"
+	defaultPathLength = 1
+
+	// subgraphTTL 子图成员缓存时长，避免每个事件都查询知识网络
+	subgraphTTL = 5 * time.Minute
+)
+
+// SubGraphQuerier 知识网络子图查询接口，由 dip.Client 实现。
+type SubGraphQuerier interface {
+	QuerySubGraph(ctx context.Context, req dip.SubGraphQueryRequest) (*dip.SubGraphResponse, error)
+}
+
+// Matcher 按当前配置的维护窗口匹配事件。
+type Matcher struct {
+	cfgManager config.Provider
+	querier    SubGraphQuerier // 为 nil 时子图选择器只匹配起点对象
+
+	mu        sync.Mutex
+	subgraphs map[string]subgraphMembers
+	now       func() time.Time
+}
+
+// subgraphMembers 子图选择器展开后的对象 ID 集合。
+type subgraphMembers struct {
+	ids      map[string]struct{}
+	expireAt time.Time
+}
+
+// NewMatcher 创建维护窗口匹配器。
+func NewMatcher(cfgManager config.Provider, querier SubGraphQuerier) *Matcher {
+	return &Matcher{
+		cfgManager: cfgManager,
+		querier:    querier,
+		subgraphs:  make(map[string]subgraphMembers),
+		now:        time.Now,
+	}
+}
+
+// Match 返回 at 时刻命中事件对象的第一个启用的维护窗口，未命中返回 nil。
+func (m *Matcher) Match(ctx context.Context, raw domain.RawEvent, at time.Time) *config.MaintenanceWindow {
+	if m == nil || m.cfgManager == nil {
+		return nil
+	}
+	windows := m.cfgManager.GetConfig().AppConfig.MaintenanceWindows
+	for i := range windows {
+		w := windows[i]
+		if !w.Enabled || !Active(w, at) {
+			continue
+		}
+		if m.selects(ctx, w.Selector, raw) {
+			return &w
+		}
+	}
+	return nil
+}
+
+// selects 判断事件对象是否命中选择器。
+func (m *Matcher) selects(ctx context.Context, sel config.MaintenanceSelector, raw domain.RawEvent) bool {
+	for _, id := range sel.EntityIDs {
+		if id != "" && id == raw.EntityObjectID {
+			return true
+		}
+	}
+	for _, class := range sel.ObjectClasses {
+		if class != "" && class == raw.EntityObjectClass {
+			return true
+		}
+	}
+	if raw.EntityObjectID == "" {
+		return false
+	}
+	for _, sub := range sel.Subgraphs {
+		if sub.EntityID == raw.EntityObjectID {
+			return true
+		}
+		if _, ok := m.members(ctx, sub)[raw.EntityObjectID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// members 返回子图选择器覆盖的对象 ID，查询失败时视为空集合，下次事件到达时重试。
+func (m *Matcher) members(ctx context.Context, sub config.SubgraphSelector) map[string]struct{} {
+	if m.querier == nil || sub.ObjectClass == "" || sub.EntityID == "" {
+		return nil
+	}
+	direction := sub.Direction
+	if direction == "" {
+		direction = defaultDirection
+	}
+	pathLength := sub.PathLength
+	if pathLength <= 0 {
+		pathLength = defaultPathLength
+	}
+	key := fmt.Sprintf("%s|%s|%s|%d", sub.ObjectClass, sub.EntityID, direction, pathLength)
+
+	now := m.now()
+	m.mu.Lock()
+	cached, ok := m.subgraphs[key]
+	m.mu.Unlock()
+	if ok && now.Before(cached.expireAt) {
+		return cached.ids
+	}
+
+	resp, err := m.querier.QuerySubGraph(ctx, dip.SubGraphQueryRequest{
+		SourceObjectTypeID: sub.ObjectClass,
+		Condition: &dip.Condition{
+			Field:     "s_id",
+			Operation: "==",
+			Value:     sub.EntityID,
+		},
+		Direction:  direction,
+		PathLength: pathLength,
+	})
+	if err != nil {
+		log.Warnf("查询维护窗口子图失败: object_class=%s, entity_id=%s, err=%v", sub.ObjectClass, sub.EntityID, err)
+		return nil
+	}
+
+	ids := make(map[string]struct{})
+	if resp != nil {
+		for _, obj := range resp.Objects {
+			if obj.Properties.SID != "" {
+				ids[obj.Properties.SID] = struct{}{}
+			}
+		}
+	}
+	m.mu.Lock()
+	m.subgraphs[key] = subgraphMembers{ids: ids, expireAt: now.Add(subgraphTTL)}
+	m.mu.Unlock()
+	return ids
+}
+
+// Active 判断维护窗口在 t 时刻是否生效。
+// 每次维护时长为 End - Start，重复窗口从 t 往前回溯可能覆盖 t 的各个周期起点逐一判断。
+func Active(w config.MaintenanceWindow, t time.Time) bool {
+	duration := w.End.Sub(w.Start)
+	if duration <= 0 || t.Before(w.Start) {
+		return false
+	}
+
+	recurrence := w.Recurrence.Type
+	if recurrence == "" || recurrence == config.RecurrenceNone {
+		return t.Before(w.End)
+	}
+
+	local := t.In(w.Start.Location())
+	lookback := int(duration/(24*time.Hour)) + 1
+	for i := 0; i <= lookback; i++ {
+		day := local.AddDate(0, 0, -i)
+		occStart := time.Date(day.Year(), day.Month(), day.Day(),
+			w.Start.Hour(), w.Start.Minute(), w.Start.Second(), w.Start.Nanosecond(), w.Start.Location())
+		if occStart.Before(w.Start) || occStart.After(local) {
+			continue
+		}
+		if w.Recurrence.Until != nil && occStart.After(*w.Recurrence.Until) {
+			continue
+		}
+		if !local.Before(occStart.Add(duration)) {
+			continue
+		}
+		if recurs(w, occStart) {
+			return true
+		}
+	}
+	return false
+}
+
+// recurs 判断某天是否为重复窗口的维护日。
+func recurs(w config.MaintenanceWindow, day time.Time) bool {
+	switch w.Recurrence.Type {
+	case config.RecurrenceDaily:
+		return true
+	case config.RecurrenceWeekly:
+		weekdays := w.Recurrence.Weekdays
+		if len(weekdays) == 0 {
+			return day.Weekday() == w.Start.Weekday()
+		}
+		for _, wd := range weekdays {
+			if wd == day.Weekday() {
+				return true
+			}
+		}
+		return false
+	case config.RecurrenceMonthly:
+		// 开始日期为 29-31 日时，没有该日期的月份在当月最后一天维护
+		return day.Day() == min(w.Start.Day(), daysInMonth(day))
+	default:
+		return false
+	}
+}
+
+// daysInMonth 返回 t 所在月份的天数。
+func daysInMonth(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
@@ -0,0 +1,169 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config/configtest"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/dip"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeQuerier 记录查询次数并返回固定子图。
+type fakeQuerier struct {
+	calls int
+	sids  []string
+	err   error
+}
+
+func (f *fakeQuerier) QuerySubGraph(_ context.Context, _ dip.SubGraphQueryRequest) (*dip.SubGraphResponse, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	resp := &dip.SubGraphResponse{Objects: make(map[string]dip.SubGraphObject)}
+	for _, sid := range f.sids {
+		resp.Objects[sid] = dip.SubGraphObject{Properties: dip.SubGraphObjectProperties{SID: sid}}
+	}
+	return resp, nil
+}
+
+func newTestMatcher(querier SubGraphQuerier, windows ...config.MaintenanceWindow) *Matcher {
+	cfg := &config.Config{AppConfig: config.AppConfig{MaintenanceWindows: windows}}
+	return NewMatcher(configtest.NewProvider(cfg), querier)
+}
+
+func TestActive(t *testing.T) {
+	Convey("TestActive", t, func() {
+		// 2024-01-06 为周六
+		start := time.Date(2024, 1, 6, 22, 0, 0, 0, time.Local)
+		w := config.MaintenanceWindow{Start: start, End: start.Add(4 * time.Hour)}
+
+		Convey("单次窗口仅在起止时间内生效", func() {
+			So(Active(w, start), ShouldBeTrue)
+			So(Active(w, start.Add(3*time.Hour)), ShouldBeTrue)
+			So(Active(w, start.Add(4*time.Hour)), ShouldBeFalse)
+			So(Active(w, start.Add(-time.Minute)), ShouldBeFalse)
+			So(Active(w, start.AddDate(0, 0, 1)), ShouldBeFalse)
+		})
+
+		Convey("每日窗口每天同一时段生效，包括跨零点部分", func() {
+			w.Recurrence.Type = config.RecurrenceDaily
+
+			So(Active(w, start.AddDate(0, 0, 3).Add(time.Hour)), ShouldBeTrue)
+			So(Active(w, start.AddDate(0, 0, 3).Add(3*time.Hour)), ShouldBeTrue)
+			So(Active(w, start.AddDate(0, 0, 3).Add(-time.Hour)), ShouldBeFalse)
+		})
+
+		Convey("每周窗口按指定星期生效，未指定时取开始时间所在星期", func() {
+			w.Recurrence.Type = config.RecurrenceWeekly
+
+			So(Active(w, start.AddDate(0, 0, 7).Add(time.Hour)), ShouldBeTrue)
+			So(Active(w, start.AddDate(0, 0, 1).Add(time.Hour)), ShouldBeFalse)
+
+			w.Recurrence.Weekdays = []time.Weekday{time.Sunday}
+			So(Active(w, start.AddDate(0, 0, 1).Add(time.Hour)), ShouldBeTrue)
+			So(Active(w, start.AddDate(0, 0, 7).Add(time.Hour)), ShouldBeFalse)
+		})
+
+		Convey("每月窗口在同一日期生效", func() {
+			w.Recurrence.Type = config.RecurrenceMonthly
+
+			So(Active(w, start.AddDate(0, 1, 0).Add(time.Hour)), ShouldBeTrue)
+			So(Active(w, start.AddDate(0, 1, 1).Add(time.Hour)), ShouldBeFalse)
+		})
+
+		Convey("每月窗口开始日期超过当月天数时在当月最后一天生效", func() {
+			monthEnd := time.Date(2024, 1, 31, 22, 0, 0, 0, time.Local)
+			w := config.MaintenanceWindow{Start: monthEnd, End: monthEnd.Add(time.Hour), Recurrence: config.MaintenanceRecurrence{Type: config.RecurrenceMonthly}}
+
+			// 2024 年 2 月只有 29 天
+			So(Active(w, time.Date(2024, 2, 29, 22, 30, 0, 0, time.Local)), ShouldBeTrue)
+			So(Active(w, time.Date(2024, 2, 28, 22, 30, 0, 0, time.Local)), ShouldBeFalse)
+			So(Active(w, time.Date(2024, 4, 30, 22, 30, 0, 0, time.Local)), ShouldBeTrue)
+			So(Active(w, time.Date(2024, 3, 31, 22, 30, 0, 0, time.Local)), ShouldBeTrue)
+			So(Active(w, time.Date(2024, 3, 30, 22, 30, 0, 0, time.Local)), ShouldBeFalse)
+		})
+
+		Convey("超过重复截止时间后不再生效", func() {
+			until := start.AddDate(0, 0, 2)
+			w.Recurrence = config.MaintenanceRecurrence{Type: config.RecurrenceDaily, Until: &until}
+
+			So(Active(w, start.AddDate(0, 0, 2).Add(time.Hour)), ShouldBeTrue)
+			So(Active(w, start.AddDate(0, 0, 3).Add(time.Hour)), ShouldBeFalse)
+		})
+
+		Convey("起止时间非法时不生效", func() {
+			w.End = w.Start
+
+			So(Active(w, start), ShouldBeFalse)
+		})
+	})
+}
+
+func TestMatcher_Match(t *testing.T) {
+	Convey("TestMatcher_Match", t, func() {
+		ctx := context.Background()
+		start := time.Date(2024, 1, 6, 22, 0, 0, 0, time.Local)
+		at := start.Add(time.Hour)
+		window := config.MaintenanceWindow{ID: "mw-1", Name: "变更", Enabled: true, Start: start, End: start.Add(4 * time.Hour)}
+		raw := domain.RawEvent{EntityObjectID: "host-1", EntityObjectClass: "host"}
+
+		Convey("按对象 ID 命中", func() {
+			window.Selector.EntityIDs = []string{"host-1"}
+			m := newTestMatcher(nil, window)
+
+			matched := m.Match(ctx, raw, at)
+			So(matched, ShouldNotBeNil)
+			So(matched.ID, ShouldEqual, "mw-1")
+		})
+
+		Convey("按对象类命中", func() {
+			window.Selector.ObjectClasses = []string{"host"}
+			m := newTestMatcher(nil, window)
+
+			So(m.Match(ctx, raw, at), ShouldNotBeNil)
+		})
+
+		Convey("窗口未生效或未启用时不命中", func() {
+			window.Selector.EntityIDs = []string{"host-1"}
+			m := newTestMatcher(nil, window)
+			So(m.Match(ctx, raw, start.Add(-time.Hour)), ShouldBeNil)
+
+			window.Enabled = false
+			m = newTestMatcher(nil, window)
+			So(m.Match(ctx, raw, at), ShouldBeNil)
+		})
+
+		Convey("按子图命中并缓存子图成员", func() {
+			window.Selector.Subgraphs = []config.SubgraphSelector{{ObjectClass: "service", EntityID: "svc-1"}}
+			querier := &fakeQuerier{sids: []string{"svc-1", "host-1"}}
+			m := newTestMatcher(querier, window)
+
+			So(m.Match(ctx, raw, at), ShouldNotBeNil)
+			other := raw
+			other.EntityObjectID = "host-2"
+			So(m.Match(ctx, other, at), ShouldBeNil)
+			So(querier.calls, ShouldEqual, 1)
+		})
+
+		Convey("子图查询失败时视为未命中，但起点对象仍命中", func() {
+			window.Selector.Subgraphs = []config.SubgraphSelector{{ObjectClass: "host", EntityID: "host-9"}}
+			m := newTestMatcher(&fakeQuerier{err: errors.New("boom")}, window)
+
+			So(m.Match(ctx, raw, at), ShouldBeNil)
+			start := raw
+			start.EntityObjectID = "host-9"
+			So(m.Match(ctx, start, at), ShouldNotBeNil)
+		})
+
+		Convey("nil 匹配器不命中", func() {
+			var m *Matcher
+			So(m.Match(ctx, raw, at), ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,146 @@
+package objectclass
+
+import (
+	"net"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+const (
+	// identityKeyPrefix 实体识别键前缀：objectclass:identity:<kind>:<value> -> EntityObjectInfo JSON
+	identityKeyPrefix = "objectclass:identity:"
+	// defaultIdentityPropertiesKey identity_properties 中作用于所有对象类的配置键
+	defaultIdentityPropertiesKey = "default"
+)
+
+// 实体识别键类型
+const (
+	identityKindIP   = "ip"
+	identityKindMAC  = "mac"
+	identityKindFQDN = "fqdn"
+	identityKindHost = "host"
+)
+
+// defaultIdentityProperties 未配置 identity_properties 时参与实体识别的对象属性。
+var defaultIdentityProperties = []string{
+	"name", "hostname", "host_name", "fqdn", "ip", "ip_address", "manage_ip", "mac", "mac_address",
+}
+
+// EntityIdentity 实体查询条件，GetEntityObjectInfo 按 Key、Name、IP、MAC 的顺序依次匹配。
+type EntityIdentity struct {
+	Key  string // 预热时的组合键（k8s_cluster:xxx,namespace:xxx,name:xxx）或上游原始实体名称
+	Name string // 主机名 / FQDN，为空时从 Key 中解析
+	IP   string // 实体 IP（RawEvent.EntityObjectIP）
+	MAC  string // 实体 MAC（RawEvent.EntityObjectMAC）
+}
+
+// IsEmpty 查询条件是否为空。
+func (e EntityIdentity) IsEmpty() bool {
+	return e.Key == "" && e.Name == "" && e.IP == "" && e.MAC == ""
+}
+
+// String 返回用于日志和错误信息的描述。
+func (e EntityIdentity) String() string {
+	parts := make([]string, 0, 4)
+	for _, kv := range [][2]string{{"key", e.Key}, {"name", e.Name}, {"ip", e.IP}, {"mac", e.MAC}} {
+		if kv[1] != "" {
+			parts = append(parts, kv[0]+"="+kv[1])
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// lookupKeys 按匹配优先级返回查询的缓存键：组合键 > 名称（IP/MAC/FQDN/短主机名）> IP > MAC。
+func (e EntityIdentity) lookupKeys() []string {
+	var keys []string
+	if e.Key != "" {
+		keys = append(keys, cacheKeyPrefix+e.Key)
+	}
+	name := e.Name
+	if name == "" {
+		name = entityNameFromKey(e.Key)
+	}
+	keys = append(keys, identityKeys(name)...)
+	keys = append(keys, identityKeys(e.IP)...)
+	keys = append(keys, identityKeys(e.MAC)...)
+	return dedupStrings(keys)
+}
+
+// entityNameFromKey 从组合键中解析 name 字段，非组合键原样返回。
+func entityNameFromKey(key string) string {
+	if !strings.HasPrefix(key, "k8s_cluster:") {
+		return key
+	}
+	if idx := strings.LastIndex(key, ",name:"); idx >= 0 {
+		return key[idx+len(",name:"):]
+	}
+	return ""
+}
+
+// identityKeys 将属性值识别为 IP / MAC / FQDN / 短主机名，返回对应的识别键（按匹配优先级排序）。
+// FQDN 同时生成短主机名键，使上游只上报短主机名时也能匹配。
+func identityKeys(value string) []string {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if v == "" {
+		return nil
+	}
+	// 去掉端口（如 Prometheus instance 标签 node1:9100）
+	if host, _, err := net.SplitHostPort(v); err == nil {
+		v = host
+	}
+	if ip := net.ParseIP(v); ip != nil {
+		return []string{identityKey(identityKindIP, ip.String())}
+	}
+	if mac, err := net.ParseMAC(v); err == nil {
+		return []string{identityKey(identityKindMAC, mac.String())}
+	}
+
+	v = strings.TrimSuffix(v, ".")
+	if idx := strings.IndexByte(v, '.'); idx > 0 {
+		return []string{identityKey(identityKindFQDN, v), identityKey(identityKindHost, v[:idx])}
+	}
+	return []string{identityKey(identityKindHost, v)}
+}
+
+func identityKey(kind, value string) string {
+	return identityKeyPrefix + kind + ":" + value
+}
+
+// identityValues 展开属性值，支持数组与逗号分隔的多值属性（如多网卡 IP）。
+func identityValues(raw interface{}) []string {
+	var values []string
+	switch v := raw.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		for _, item := range v {
+			values = append(values, identityValues(item)...)
+		}
+		return values
+	case []string:
+		for _, item := range v {
+			values = append(values, identityValues(item)...)
+		}
+		return values
+	}
+	for _, item := range strings.Split(cast.ToString(raw), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+func dedupStrings(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	result := values[:0]
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		result = append(result, v)
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package objectclass
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestIdentityKeys(t *testing.T) {
+	Convey("TestIdentityKeys", t, func() {
+		Convey("空值不生成识别键", func() {
+			So(identityKeys("  "), ShouldBeEmpty)
+		})
+
+		Convey("IP 去掉端口并规范化", func() {
+			So(identityKeys("10.0.0.1:9100"), ShouldResemble, []string{identityKeyPrefix + "ip:10.0.0.1"})
+			So(identityKeys("FE80::1"), ShouldResemble, []string{identityKeyPrefix + "ip:fe80::1"})
+		})
+
+		Convey("MAC 统一为小写冒号分隔", func() {
+			So(identityKeys("AA-BB-CC-DD-EE-FF"), ShouldResemble, []string{identityKeyPrefix + "mac:aa:bb:cc:dd:ee:ff"})
+		})
+
+		Convey("FQDN 同时生成短主机名键", func() {
+			So(identityKeys("Web-01.Example.com."), ShouldResemble, []string{
+				identityKeyPrefix + "fqdn:web-01.example.com",
+				identityKeyPrefix + "host:web-01",
+			})
+		})
+
+		Convey("短主机名", func() {
+			So(identityKeys("node1:9100"), ShouldResemble, []string{identityKeyPrefix + "host:node1"})
+		})
+	})
+}
+
+func TestEntityIdentity_lookupKeys(t *testing.T) {
+	Convey("TestEntityIdentity_lookupKeys", t, func() {
+		Convey("按组合键、名称、IP、MAC 的顺序匹配", func() {
+			key := BuildEntityKey("c1", "ns1", "node1")
+			keys := EntityIdentity{Key: key, IP: "10.0.0.1", MAC: "aa:bb:cc:dd:ee:ff"}.lookupKeys()
+
+			So(keys, ShouldResemble, []string{
+				cacheKeyPrefix + key,
+				identityKeyPrefix + "host:node1",
+				identityKeyPrefix + "ip:10.0.0.1",
+				identityKeyPrefix + "mac:aa:bb:cc:dd:ee:ff",
+			})
+		})
+
+		Convey("名称与 IP 相同时去重", func() {
+			keys := EntityIdentity{Name: "10.0.0.1", IP: "10.0.0.1"}.lookupKeys()
+
+			So(keys, ShouldResemble, []string{identityKeyPrefix + "ip:10.0.0.1"})
+		})
+	})
+}
+
+func TestIdentityValues(t *testing.T) {
+	Convey("TestIdentityValues", t, func() {
+		So(identityValues(nil), ShouldBeEmpty)
+		So(identityValues("10.0.0.1, 10.0.0.2"), ShouldResemble, []string{"10.0.0.1", "10.0.0.2"})
+		So(identityValues([]interface{}{"a", "b,c"}), ShouldResemble, []string{"a", "b", "c"})
+	})
+}
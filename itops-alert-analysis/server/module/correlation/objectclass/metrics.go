@@ -0,0 +1,88 @@
+package objectclass
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+)
+
+// 刷新方式
+const (
+	refreshModeFull        = "full"
+	refreshModeIncremental = "incremental"
+)
+
+// refreshResult 一次刷新的结果统计。
+type refreshResult struct {
+	updated int // 写入的对象数量
+	deleted int // 删除的对象数量
+}
+
+func (r *refreshResult) add(other refreshResult) {
+	r.updated += other.updated
+	r.deleted += other.deleted
+}
+
+// cacheSize 缓存规模。
+type cacheSize struct {
+	objectTypes int
+	objects     int
+	keys        int
+}
+
+// cacheMetrics 对象类缓存指标：查询命中率、缓存规模与刷新耗时。
+// 仅在进程内累计，供调试接口查看，不对外暴露为监控指标。
+type cacheMetrics struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+
+	mu    sync.Mutex
+	stats domain.ObjectClassCacheStats // 刷新相关指标
+}
+
+func (m *cacheMetrics) recordLookup(hit bool) {
+	if hit {
+		m.hits.Add(1)
+	} else {
+		m.misses.Add(1)
+	}
+}
+
+func (m *cacheMetrics) recordRefresh(mode string, start time.Time, result refreshResult, size cacheSize, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if mode == refreshModeFull {
+		m.stats.FullRefreshCount++
+	} else {
+		m.stats.IncrementalRefreshCount++
+	}
+	m.stats.LastRefreshMode = mode
+	m.stats.LastRefreshTime = time.Now()
+	m.stats.LastRefreshDurationMs = time.Since(start).Milliseconds()
+	m.stats.LastRefreshUpdated = result.updated
+	m.stats.LastRefreshDeleted = result.deleted
+	m.stats.LastRefreshError = ""
+	if err != nil {
+		m.stats.RefreshFailureCount++
+		m.stats.LastRefreshError = err.Error()
+	}
+	m.stats.ObjectTypes = size.objectTypes
+	m.stats.Objects = size.objects
+	m.stats.CacheKeys = size.keys
+}
+
+func (m *cacheMetrics) snapshot() domain.ObjectClassCacheStats {
+	m.mu.Lock()
+	stats := m.stats
+	m.mu.Unlock()
+
+	stats.Hits = m.hits.Load()
+	stats.Misses = m.misses.Load()
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
 	"github.com/pkg/errors"
 
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/cache"
@@ -20,13 +21,14 @@ import (
 const (
 	// 缓存键前缀
 	cacheKeyPrefix = "objectclass:hostname:" // hostname -> EntityObjectInfo JSON
-	// 分页大小
-	pageLimit = 1000
-	// 缓存过期时间：1小时
-	cacheTTL = 1 * time.Hour
-	// 刷新间隔：10分钟
-	//TODO 可配置
-	refreshInterval = 30 * time.Second
+	// 默认分页大小
+	defaultPageLimit = 1000
+	// 默认缓存过期时间：1小时
+	defaultCacheTTL = 1 * time.Hour
+	// 默认增量刷新间隔：30秒
+	defaultRefreshInterval = 30 * time.Second
+	// 默认全量刷新间隔：10分钟
+	defaultFullRefreshInterval = 10 * time.Minute
 )
 
 // EntityObjectInfo 实体对象信息
@@ -36,17 +38,89 @@ type EntityObjectInfo struct {
 	Name         string `json:"name"`           // 对象名称
 }
 
-// ObjectClass 对象类缓存，负责维护 hostname -> object_type_id 的映射。
+// objectTypeState 单个对象类的刷新状态。
+type objectTypeState struct {
+	watermark []interface{}       // 增量水位：上次拉取到的最后一个对象的 search_after（s_update_time, s_id）
+	objects   map[string][]string // 对象 ID -> 该对象写入的缓存键，用于删除检测与属性变更后的旧键清理
+}
+
+// ObjectClass 对象类缓存，负责维护 hostname / IP / MAC 等实体识别键 -> object_type_id 的映射。
+// 启动时全量预热，之后按 s_update_time 水位增量刷新，并定期全量刷新以检测已删除的对象、续期缓存。
 type ObjectClass struct {
 	dipClient *dip.Client //dip客户端用于拉取 对象缓存
 	cache     cache.Cache //具体的cache 实现
 	mu        sync.RWMutex
+
+	warmupHooks []func(ctx context.Context) // 每次预热成功后执行的回调
+
+	identityProperties  map[string][]string // 对象类 ID -> 参与实体识别的属性
+	refreshInterval     time.Duration       // 增量刷新间隔
+	fullRefreshInterval time.Duration       // 全量刷新间隔
+	cacheTTL            time.Duration       // 缓存过期时间
+	pageLimit           int                 // 分页大小
+
+	states  map[string]*objectTypeState // 对象类 ID -> 刷新状态
+	owners  map[string]string           // 缓存键 -> 写入者（对象类 ID/对象 ID）；识别键冲突时先写入者优先，组合键后写入者覆盖
+	metrics cacheMetrics
 }
 
 // New 创建对象类缓存实例。
 func New(cfg *config.Config, dipClient *dip.Client) (*ObjectClass, error) {
-	// 初始化 Redis 缓存
-	redisCache, err := cache.NewRedisCache(cache.RedisConfig{
+	objectCache, err := newCache(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	occ := cfg.ObjectClass
+	fullRefreshInterval := durationOr(occ.FullRefreshInterval, defaultFullRefreshInterval)
+	cacheTTL := durationOr(occ.CacheTTL, defaultCacheTTL)
+	if cacheTTL <= fullRefreshInterval {
+		// 增量刷新只续期变更的对象，未变更对象依赖全量刷新续期，TTL 过短会导致缓存过期
+		log.Warnf("对象类缓存 cache_ttl(%v) 不大于 full_refresh_interval(%v)，调整为 %v", cacheTTL, fullRefreshInterval, 2*fullRefreshInterval)
+		cacheTTL = 2 * fullRefreshInterval
+	}
+
+	return &ObjectClass{
+		dipClient:           dipClient,
+		cache:               objectCache,
+		identityProperties:  occ.IdentityProperties,
+		refreshInterval:     occ.RefreshInterval,
+		fullRefreshInterval: fullRefreshInterval,
+		cacheTTL:            cacheTTL,
+		pageLimit:           occ.PageLimit,
+	}, nil
+}
+
+// newCache 按 object_class.cache.mode 创建缓存：redis（默认）、memory 或 tiered。
+func newCache(cfg *config.Config) (cache.Cache, error) {
+	cacheCfg := cfg.ObjectClass.Cache
+	switch cacheCfg.Mode {
+	case config.CacheModeMemory:
+		log.Info("对象类缓存使用进程内缓存")
+		return cache.NewMemoryCache(cacheCfg.MaxEntries), nil
+	case config.CacheModeTiered:
+		local := cache.NewMemoryCache(cacheCfg.MaxEntries)
+		redisCache, err := newRedisCache(cfg)
+		if err != nil {
+			log.Warnf("初始化 Redis 缓存失败，对象类缓存仅使用进程内缓存: %v", err)
+			return local, nil
+		}
+		log.Info("对象类缓存使用进程内 + Redis 两级缓存")
+		return cache.NewTieredCache(local, redisCache, cacheCfg.L1TTL), nil
+	case "", config.CacheModeRedis:
+		redisCache, err := newRedisCache(cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "初始化 Redis 缓存失败")
+		}
+		return redisCache, nil
+	default:
+		return nil, errors.Errorf("不支持的对象类缓存模式: %s", cacheCfg.Mode)
+	}
+}
+
+// newRedisCache 初始化 Redis 缓存
+func newRedisCache(cfg *config.Config) (cache.Cache, error) {
+	return cache.NewRedisCache(cache.RedisConfig{
 		MasterName: cfg.DepServices.Redis.ConnectInfo.MasterGroupName,
 		SentinelAddrs: []string{
 			fmt.Sprintf("%s:%d", cfg.DepServices.Redis.ConnectInfo.SentinelHost, cfg.DepServices.Redis.ConnectInfo.SentinelPort),
@@ -57,50 +131,77 @@ func New(cfg *config.Config, dipClient *dip.Client) (*ObjectClass, error) {
 		Username: cfg.DepServices.Redis.ConnectInfo.Username,
 		Password: cfg.DepServices.Redis.ConnectInfo.Password,
 	})
-	if err != nil {
-		return nil, errors.Wrap(err, "初始化 Redis 缓存失败")
-	}
-	return &ObjectClass{
-		dipClient: dipClient,
-		cache:     redisCache,
-	}, nil
 }
 
-// Run 在 errgroup 中运行缓存刷新器，初始预热后定期刷新。
+// Run 在 errgroup 中运行缓存刷新器，初始预热后定期增量刷新，并按全量刷新间隔执行全量刷新。
 func (c *ObjectClass) Run(ctx context.Context) error {
 	// 初始预热
 	if err := c.Warmup(ctx); err != nil {
 		log.Warnf("对象类缓存初始预热失败: %v，服务将继续启动并在后续定时刷新中重试", err)
 	} else {
 		log.Info("对象类缓存预热完成")
+		c.runWarmupHooks(ctx)
 	}
 
-	log.Infof("启动对象类缓存定时刷新（间隔: %v）", refreshInterval)
+	refreshInterval := durationOr(c.refreshInterval, defaultRefreshInterval)
+	fullRefreshInterval := durationOr(c.fullRefreshInterval, defaultFullRefreshInterval)
+	log.Infof("启动对象类缓存定时刷新（增量间隔: %v，全量间隔: %v）", refreshInterval, fullRefreshInterval)
 
 	ticker := time.NewTicker(refreshInterval)
 	defer ticker.Stop()
+	fullTicker := time.NewTicker(fullRefreshInterval)
+	defer fullTicker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			log.Info("对象类缓存刷新器收到停止信号")
 			return ctx.Err()
-		case <-ticker.C:
-			log.Info("开始定时刷新对象类缓存...")
+		case <-fullTicker.C:
+			log.Info("开始全量刷新对象类缓存...")
 			if err := c.Warmup(ctx); err != nil {
-				log.Warnf("定时刷新失败: %v", err)
+				log.Warnf("全量刷新失败: %v", err)
 			} else {
-				log.Info("定时刷新完成")
+				log.Info("全量刷新完成")
+				c.runWarmupHooks(ctx)
+			}
+		case <-ticker.C:
+			updated, err := c.Refresh(ctx)
+			if err != nil {
+				log.Warnf("增量刷新失败: %v", err)
+				continue
+			}
+			// 没有对象变更时无需重新关联未解析实体
+			if updated > 0 {
+				log.Infof("增量刷新完成，更新 %d 个对象", updated)
+				c.runWarmupHooks(ctx)
 			}
 		}
 	}
 }
 
-// Warmup 预热缓存，拉取所有对象类及其对象数据，构建 hostname -> ot_id 映射。
-func (c *ObjectClass) Warmup(ctx context.Context) error {
+// OnWarmup 注册预热成功后的回调（如未解析实体重新关联），需在 Run 之前注册。
+func (c *ObjectClass) OnWarmup(hook func(ctx context.Context)) {
+	c.warmupHooks = append(c.warmupHooks, hook)
+}
+
+func (c *ObjectClass) runWarmupHooks(ctx context.Context) {
+	for _, hook := range c.warmupHooks {
+		hook(ctx)
+	}
+}
+
+// Warmup 全量刷新缓存，拉取所有对象类及其对象数据，构建 hostname -> ot_id 映射，并清理已删除的对象。
+func (c *ObjectClass) Warmup(ctx context.Context) (err error) {
+	start := time.Now()
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	var result refreshResult
+	defer func() {
+		c.metrics.recordRefresh(refreshModeFull, start, result, c.size(), err)
+	}()
+
 	// 第一步：获取所有对象类列表
 	objectTypes, err := c.dipClient.GetObjectTypes(ctx)
 	if err != nil {
@@ -111,13 +212,27 @@ func (c *ObjectClass) Warmup(ctx context.Context) error {
 
 	// 第二步：遍历每个对象类，查询对象数据
 	var failedCount int
+	seen := make(map[string]struct{}, len(objectTypes))
 	for _, ot := range objectTypes {
-		if err := c.warmupObjectType(ctx, ot.ID); err != nil {
+		seen[ot.ID] = struct{}{}
+		r, err := c.warmupObjectType(ctx, ot.ID)
+		if err != nil {
 			failedCount++
 			log.Warnf("预热对象类 %s 失败: %v", ot.ID, err)
 			// 继续处理其他对象类
 			continue
 		}
+		result.add(r)
+	}
+
+	// 第三步：清理已删除的对象类（对象类列表为空时视为异常，不清理）
+	if len(objectTypes) > 0 {
+		for otID := range c.states {
+			if _, ok := seen[otID]; !ok {
+				log.Infof("对象类 %s 已不存在，清理其缓存", otID)
+				result.deleted += c.removeObjectType(ctx, otID)
+			}
+		}
 	}
 
 	if failedCount > 0 {
@@ -127,70 +242,291 @@ func (c *ObjectClass) Warmup(ctx context.Context) error {
 	return nil
 }
 
-// warmupObjectType 预热单个对象类的数据。
-func (c *ObjectClass) warmupObjectType(ctx context.Context, otID string) error {
-	// 第三步：查询对象类的所有对象数据（自动处理分页）
-	objectData, err := c.dipClient.QueryAllObjectData(ctx, otID, pageLimit)
+// Refresh 增量刷新缓存：按 s_update_time 水位只拉取上次刷新后变更的对象，返回写入的对象数量。
+// 新出现或尚无水位的对象类按全量方式加载；已删除的对象由全量刷新检测。
+func (c *ObjectClass) Refresh(ctx context.Context) (updated int, err error) {
+	start := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var result refreshResult
+	defer func() {
+		c.metrics.recordRefresh(refreshModeIncremental, start, result, c.size(), err)
+	}()
+
+	objectTypes, err := c.dipClient.GetObjectTypes(ctx)
 	if err != nil {
-		return errors.Wrap(err, "查询对象数据失败")
+		return 0, errors.Wrap(err, "获取对象类列表失败")
+	}
+
+	for _, ot := range objectTypes {
+		var r refreshResult
+		if state := c.states[ot.ID]; state != nil && len(state.watermark) > 0 {
+			r, err = c.refreshObjectType(ctx, ot.ID, state)
+		} else {
+			r, err = c.warmupObjectType(ctx, ot.ID)
+		}
+		if err != nil {
+			log.Warnf("增量刷新对象类 %s 失败: %v", ot.ID, err)
+			continue
+		}
+		result.add(r)
+	}
+
+	return result.updated, nil
+}
+
+// warmupObjectType 全量加载单个对象类的数据，清理本轮结果中已不存在的对象。
+func (c *ObjectClass) warmupObjectType(ctx context.Context, otID string) (refreshResult, error) {
+	// 查询对象类的所有对象数据（自动处理分页），同时得到增量水位
+	objectData, watermark, err := c.dipClient.QueryObjectDataSince(ctx, otID, c.limit(), nil)
+	if err != nil {
+		return refreshResult{}, errors.Wrap(err, "查询对象数据失败")
 	}
 
 	log.Debugf("对象类 %s 有 %d 个对象实例", otID, len(objectData))
 
-	// 第四步：遍历对象数据，提取字段并缓存
+	var result refreshResult
+	state := c.state(otID)
+	seen := make(map[string]struct{}, len(objectData))
 	for _, obj := range objectData {
-		// 统一格式：k8s_cluster:xxx,namespace:xxx,name:xxx（不存在的字段使用空字符串）
-		k8sCluster := cast.ToString(obj["k8s_cluster"])
-		namespace := cast.ToString(obj["namespace"])
-		name := cast.ToString(obj["name"])
-		cacheKeySuffix := "k8s_cluster:" + k8sCluster + ",namespace:" + namespace + ",name:" + name
-
-		// 提取 id 字段（对象实例 ID）
-		objIDStr := cast.ToString(obj["s_id"])
-		if objIDStr == "" {
-			continue
+		if objID := c.cacheObject(ctx, otID, state, obj); objID != "" {
+			seen[objID] = struct{}{}
+			result.updated++
 		}
+	}
 
-		// 构建对象信息
-		objInfo := EntityObjectInfo{
-			ObjectTypeID: otID,
-			ObjectID:     objIDStr,
-			Name:         name,
+	// 删除检测：上一轮存在、本轮全量结果中已不存在的对象
+	for objID := range state.objects {
+		if _, ok := seen[objID]; !ok {
+			c.removeObject(ctx, otID, state, objID)
+			result.deleted++
 		}
+	}
 
-		// 序列化为 JSON
-		jsonData := utils.JsonEncode(objInfo)
+	state.watermark = watermark
+	return result, nil
+}
+
+// refreshObjectType 增量加载单个对象类在水位之后变更的对象。
+func (c *ObjectClass) refreshObjectType(ctx context.Context, otID string, state *objectTypeState) (refreshResult, error) {
+	objectData, watermark, err := c.dipClient.QueryObjectDataSince(ctx, otID, c.limit(), state.watermark)
+	if err != nil {
+		return refreshResult{}, errors.Wrap(err, "增量查询对象数据失败")
+	}
 
-		// 缓存 hostname -> EntityObjectInfo JSON（1小时过期）
-		cacheKey := cacheKeyPrefix + cacheKeySuffix
-		if err := c.cache.Set(ctx, cacheKey, jsonData, cacheTTL); err != nil {
-			log.Warnf("缓存对象信息失败, key=%s, objectTypeID=%s, objectID=%s, 原因: %v", cacheKey, otID, objIDStr, err)
+	var result refreshResult
+	for _, obj := range objectData {
+		if c.cacheObject(ctx, otID, state, obj) != "" {
+			result.updated++
+		}
+	}
+	if result.updated > 0 {
+		log.Debugf("对象类 %s 增量更新 %d 个对象实例", otID, result.updated)
+	}
+
+	state.watermark = watermark
+	return result, nil
+}
+
+// cacheObject 写入对象的组合键与识别键，并清理对象属性变更后不再使用的旧键，返回对象 ID（无 s_id 时返回空）。
+func (c *ObjectClass) cacheObject(ctx context.Context, otID string, state *objectTypeState, obj dip.ObjectInstance) string {
+	// 提取 id 字段（对象实例 ID）
+	objIDStr := cast.ToString(obj["s_id"])
+	if objIDStr == "" {
+		return ""
+	}
+
+	// 构建对象信息
+	name := cast.ToString(obj["name"])
+	objInfo := EntityObjectInfo{
+		ObjectTypeID: otID,
+		ObjectID:     objIDStr,
+		Name:         name,
+	}
+
+	// 序列化为 JSON
+	jsonData := utils.JsonEncode(objInfo)
+
+	// 组合键统一格式：k8s_cluster:xxx,namespace:xxx,name:xxx（不存在的字段使用空字符串）
+	owner := ownerOf(otID, objIDStr)
+	cacheKey := cacheKeyPrefix + BuildEntityKey(cast.ToString(obj["k8s_cluster"]), cast.ToString(obj["namespace"]), name)
+	c.owners[cacheKey] = owner
+	keys := []string{cacheKey}
+
+	// 按 IP / FQDN / 短主机名 / MAC 建立识别键，已被其他对象占用时跳过，避免不同对象类的同名对象相互覆盖
+	for _, key := range c.identityKeysOf(otID, obj) {
+		if existing, ok := c.owners[key]; ok && existing != owner {
+			log.Debugf("识别键已被其他对象占用，跳过: key=%s, owner=%s, object=%s", key, existing, owner)
+			continue
+		}
+		c.owners[key] = owner
+		keys = append(keys, key)
+	}
+
+	ttl := durationOr(c.cacheTTL, defaultCacheTTL)
+	for _, key := range keys {
+		if err := c.cache.Set(ctx, key, jsonData, ttl); err != nil {
+			log.Warnf("缓存对象信息失败, key=%s, objectTypeID=%s, objectID=%s, 原因: %v", key, otID, objIDStr, err)
 			// 继续处理其他数据
 		}
 	}
 
-	return nil
+	// 对象名称、IP 等属性变更后清理旧键
+	current := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		current[key] = struct{}{}
+	}
+	var stale []string
+	for _, key := range state.objects[objIDStr] {
+		if _, ok := current[key]; !ok {
+			stale = append(stale, key)
+		}
+	}
+	c.deleteKeys(ctx, owner, stale)
+
+	state.objects[objIDStr] = keys
+	return objIDStr
 }
 
-// GetEntityObjectInfo 根据 hostname 查询对应的对象信息（包含 object_type_id、object_id、name）。
-func (c *ObjectClass) GetEntityObjectInfo(ctx context.Context, hostname string) (*EntityObjectInfo, error) {
-	if hostname == "" {
-		return nil, errors.New("hostname 不能为空")
+// identityKeysOf 返回对象的识别键。
+func (c *ObjectClass) identityKeysOf(otID string, obj dip.ObjectInstance) []string {
+	var keys []string
+	for _, prop := range c.identityPropertiesOf(otID) {
+		for _, value := range identityValues(obj[prop]) {
+			keys = append(keys, identityKeys(value)...)
+		}
 	}
+	return dedupStrings(keys)
+}
 
-	cacheKey := cacheKeyPrefix + hostname
-	jsonData, err := c.cache.Get(ctx, cacheKey)
-	if err != nil {
-		// 缓存未命中
-		return nil, errors.Errorf("未找到 hostname 对应的对象信息: %s", hostname)
+// identityPropertiesOf 返回对象类参与实体识别的属性：对象类配置 > default 配置 > 内置默认属性。
+func (c *ObjectClass) identityPropertiesOf(otID string) []string {
+	if props, ok := c.identityProperties[otID]; ok {
+		return props
+	}
+	if props, ok := c.identityProperties[defaultIdentityPropertiesKey]; ok {
+		return props
+	}
+	return defaultIdentityProperties
+}
+
+// removeObject 删除对象写入的缓存键。
+func (c *ObjectClass) removeObject(ctx context.Context, otID string, state *objectTypeState, objID string) {
+	c.deleteKeys(ctx, ownerOf(otID, objID), state.objects[objID])
+	delete(state.objects, objID)
+}
+
+// removeObjectType 删除对象类下所有对象的缓存键，返回删除的对象数量。
+func (c *ObjectClass) removeObjectType(ctx context.Context, otID string) int {
+	state := c.states[otID]
+	if state == nil {
+		return 0
+	}
+	count := len(state.objects)
+	for objID := range state.objects {
+		c.removeObject(ctx, otID, state, objID)
+	}
+	delete(c.states, otID)
+	return count
+}
+
+// deleteKeys 删除仍归属于该对象的缓存键，已被其他对象覆盖的键保留。
+func (c *ObjectClass) deleteKeys(ctx context.Context, owner string, keys []string) {
+	owned := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if c.owners[key] == owner {
+			delete(c.owners, key)
+			owned = append(owned, key)
+		}
+	}
+	if len(owned) == 0 {
+		return
+	}
+	if err := c.cache.Del(ctx, owned...); err != nil {
+		log.Warnf("删除对象缓存失败, object=%s, keys=%v, 原因: %v", owner, owned, err)
+	}
+}
+
+// ownerOf 返回缓存键写入者标识，对象 ID 仅在对象类内唯一。
+func ownerOf(otID, objID string) string {
+	return otID + "/" + objID
+}
+
+// state 返回对象类的刷新状态，不存在时创建。
+func (c *ObjectClass) state(otID string) *objectTypeState {
+	if c.states == nil {
+		c.states = make(map[string]*objectTypeState)
+	}
+	if c.owners == nil {
+		c.owners = make(map[string]string)
+	}
+	state, ok := c.states[otID]
+	if !ok {
+		state = &objectTypeState{objects: make(map[string][]string)}
+		c.states[otID] = state
+	}
+	return state
+}
+
+// size 统计当前缓存规模，调用方需持有 c.mu。
+func (c *ObjectClass) size() cacheSize {
+	size := cacheSize{objectTypes: len(c.states), keys: len(c.owners)}
+	for _, state := range c.states {
+		size.objects += len(state.objects)
 	}
+	return size
+}
 
-	var objInfo EntityObjectInfo
-	if err := json.Unmarshal([]byte(jsonData), &objInfo); err != nil {
-		return nil, errors.Wrap(err, "反序列化对象信息失败")
+func (c *ObjectClass) limit() int {
+	if c.pageLimit > 0 {
+		return c.pageLimit
 	}
+	return defaultPageLimit
+}
+
+func durationOr(d, def time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return def
+}
+
+// BuildEntityKey 构建对象缓存键：k8s_cluster:xxx,namespace:xxx,name:xxx（不存在的字段使用空字符串）。
+// 标准化器按同样格式拼接查询键，保证与预热写入的键一致。
+func BuildEntityKey(k8sCluster, namespace, name string) string {
+	return "k8s_cluster:" + k8sCluster + ",namespace:" + namespace + ",name:" + name
+}
+
+// GetEntityObjectInfo 按识别条件查询对应的对象信息（包含 object_type_id、object_id、name）。
+// 依次尝试组合键、名称（IP/MAC/FQDN/短主机名）、IP、MAC，返回第一个命中的对象。
+func (c *ObjectClass) GetEntityObjectInfo(ctx context.Context, entity EntityIdentity) (*EntityObjectInfo, error) {
+	if entity.IsEmpty() {
+		return nil, errors.New("实体名称、IP、MAC 不能同时为空")
+	}
+
+	for _, cacheKey := range entity.lookupKeys() {
+		jsonData, err := c.cache.Get(ctx, cacheKey)
+		if err != nil {
+			// 缓存未命中，尝试下一个识别键
+			continue
+		}
+
+		var objInfo EntityObjectInfo
+		if err := json.Unmarshal([]byte(jsonData), &objInfo); err != nil {
+			return nil, errors.Wrap(err, "反序列化对象信息失败")
+		}
+		c.metrics.recordLookup(true)
+		return &objInfo, nil
+	}
+
+	c.metrics.recordLookup(false)
+	return nil, errors.Errorf("未找到实体对应的对象信息: %s", entity)
+}
 
-	return &objInfo, nil
+// Stats 返回缓存运行指标：缓存规模、查询命中率与刷新耗时。
+func (c *ObjectClass) Stats() domain.ObjectClassCacheStats {
+	return c.metrics.snapshot()
 }
 
 // Close 关闭缓存资源。
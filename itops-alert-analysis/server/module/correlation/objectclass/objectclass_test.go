@@ -3,6 +3,7 @@ package objectclass
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -70,6 +71,17 @@ func (m *mockCache) Close() error {
 	return m.closeErr
 }
 
+// countKeys 统计指定前缀的缓存键数量
+func countKeys(data map[string]string, prefix string) int {
+	count := 0
+	for key := range data {
+		if strings.HasPrefix(key, prefix) {
+			count++
+		}
+	}
+	return count
+}
+
 // 确保 mockCache 实现了 cache.Cache 接口
 var _ cache.Cache = (*mockCache)(nil)
 
@@ -120,6 +132,54 @@ func TestNew(t *testing.T) {
 			So(oc, ShouldBeNil)
 			So(err.Error(), ShouldContainSubstring, "初始化 Redis 缓存失败")
 		})
+
+		Convey("memory 模式不依赖 Redis", func() {
+			cfg.ObjectClass.Cache.Mode = config.CacheModeMemory
+			patches := gomonkey.ApplyFunc(cache.NewRedisCache, func(cfg cache.RedisConfig) (cache.Cache, error) {
+				return nil, errors.New("redis connection failed")
+			})
+			defer patches.Reset()
+
+			oc, err := New(cfg, dipClient)
+
+			So(err, ShouldBeNil)
+			So(oc.cache, ShouldHaveSameTypeAs, &cache.MemoryCache{})
+		})
+
+		Convey("tiered 模式使用两级缓存", func() {
+			cfg.ObjectClass.Cache.Mode = config.CacheModeTiered
+			patches := gomonkey.ApplyFunc(cache.NewRedisCache, func(cfg cache.RedisConfig) (cache.Cache, error) {
+				return newMockCache(), nil
+			})
+			defer patches.Reset()
+
+			oc, err := New(cfg, dipClient)
+
+			So(err, ShouldBeNil)
+			So(oc.cache, ShouldHaveSameTypeAs, &cache.TieredCache{})
+		})
+
+		Convey("tiered 模式 Redis 不可用时降级为进程内缓存", func() {
+			cfg.ObjectClass.Cache.Mode = config.CacheModeTiered
+			patches := gomonkey.ApplyFunc(cache.NewRedisCache, func(cfg cache.RedisConfig) (cache.Cache, error) {
+				return nil, errors.New("redis connection failed")
+			})
+			defer patches.Reset()
+
+			oc, err := New(cfg, dipClient)
+
+			So(err, ShouldBeNil)
+			So(oc.cache, ShouldHaveSameTypeAs, &cache.MemoryCache{})
+		})
+
+		Convey("不支持的缓存模式返回错误", func() {
+			cfg.ObjectClass.Cache.Mode = "unknown"
+
+			oc, err := New(cfg, dipClient)
+
+			So(err, ShouldNotBeNil)
+			So(oc, ShouldBeNil)
+		})
 	})
 }
 
@@ -145,25 +205,54 @@ func TestObjectClass_Warmup(t *testing.T) {
 				})
 			defer patches.Reset()
 
-			// 打桩 QueryAllObjectData
-			patches.ApplyMethod(dipClient, "QueryAllObjectData",
-				func(_ *dip.Client, ctx context.Context, otID string, limit int) ([]dip.ObjectInstance, error) {
+			// 打桩 QueryObjectDataSince
+			patches.ApplyMethod(dipClient, "QueryObjectDataSince",
+				func(_ *dip.Client, ctx context.Context, otID string, limit int, watermark []interface{}) ([]dip.ObjectInstance, []interface{}, error) {
 					if otID == "ot-1" {
 						return []dip.ObjectInstance{
 							{"s_id": "obj-1", "k8s_cluster": "cluster1", "namespace": "ns1", "name": "pod1"},
 							{"s_id": "obj-2", "k8s_cluster": "cluster1", "namespace": "ns2", "name": "pod2"},
-						}, nil
+						}, nil, nil
 					}
 					return []dip.ObjectInstance{
 						{"s_id": "obj-3", "k8s_cluster": "cluster1", "namespace": "", "name": "node1"},
-					}, nil
+					}, nil, nil
 				})
 
 			err := oc.Warmup(ctx)
 
 			So(err, ShouldBeNil)
 			// 验证缓存数据
-			So(len(mockCacheInstance.data), ShouldEqual, 3)
+			So(countKeys(mockCacheInstance.data, cacheKeyPrefix), ShouldEqual, 3)
+			So(mockCacheInstance.data[identityKeyPrefix+"host:node1"], ShouldNotBeEmpty)
+		})
+
+		Convey("识别键冲突时先写入的对象优先", func() {
+			patches := gomonkey.ApplyMethod(dipClient, "GetObjectTypes",
+				func(_ *dip.Client, ctx context.Context) ([]dip.ObjectType, error) {
+					return []dip.ObjectType{
+						{ID: "ot-1", Name: "Host"},
+						{ID: "ot-2", Name: "Pod"},
+					}, nil
+				})
+			defer patches.Reset()
+
+			patches.ApplyMethod(dipClient, "QueryObjectDataSince",
+				func(_ *dip.Client, ctx context.Context, otID string, limit int, watermark []interface{}) ([]dip.ObjectInstance, []interface{}, error) {
+					if otID == "ot-1" {
+						return []dip.ObjectInstance{{"s_id": "host-1", "name": "web"}}, nil, nil
+					}
+					return []dip.ObjectInstance{{"s_id": "pod-1", "namespace": "ns1", "name": "web"}}, nil, nil
+				})
+
+			err := oc.Warmup(ctx)
+
+			So(err, ShouldBeNil)
+			var objInfo EntityObjectInfo
+			json.Unmarshal([]byte(mockCacheInstance.data[identityKeyPrefix+"host:web"]), &objInfo)
+			So(objInfo.ObjectID, ShouldEqual, "host-1")
+			// 组合键不受影响
+			So(mockCacheInstance.data[cacheKeyPrefix+"k8s_cluster:,namespace:ns1,name:web"], ShouldContainSubstring, "pod-1")
 		})
 
 		Convey("获取对象类列表失败返回错误", func() {
@@ -190,22 +279,124 @@ func TestObjectClass_Warmup(t *testing.T) {
 			defer patches.Reset()
 
 			callCount := 0
-			patches.ApplyMethod(dipClient, "QueryAllObjectData",
-				func(_ *dip.Client, ctx context.Context, otID string, limit int) ([]dip.ObjectInstance, error) {
+			patches.ApplyMethod(dipClient, "QueryObjectDataSince",
+				func(_ *dip.Client, ctx context.Context, otID string, limit int, watermark []interface{}) ([]dip.ObjectInstance, []interface{}, error) {
 					callCount++
 					if otID == "ot-1" {
-						return nil, errors.New("query failed")
+						return nil, nil, errors.New("query failed")
 					}
 					return []dip.ObjectInstance{
 						{"s_id": "obj-1", "k8s_cluster": "c1", "namespace": "ns1", "name": "node1"},
-					}, nil
+					}, nil, nil
 				})
 
 			err := oc.Warmup(ctx)
 
 			So(err, ShouldBeNil)
-			So(callCount, ShouldEqual, 2)                   // 两个对象类都被调用
-			So(len(mockCacheInstance.data), ShouldEqual, 1) // 只有 ot-2 成功
+			So(callCount, ShouldEqual, 2) // 两个对象类都被调用
+			// 只有 ot-2 成功
+			So(countKeys(mockCacheInstance.data, cacheKeyPrefix), ShouldEqual, 1)
+		})
+	})
+}
+
+func TestObjectClass_Refresh(t *testing.T) {
+	Convey("TestObjectClass_Refresh", t, func() {
+		ctx := context.Background()
+		mockCacheInstance := newMockCache()
+		dipClient := &dip.Client{}
+
+		oc := &ObjectClass{
+			dipClient: dipClient,
+			cache:     mockCacheInstance,
+		}
+
+		patches := gomonkey.ApplyMethod(dipClient, "GetObjectTypes",
+			func(_ *dip.Client, ctx context.Context) ([]dip.ObjectType, error) {
+				return []dip.ObjectType{{ID: "ot-1", Name: "Host"}}, nil
+			})
+		defer patches.Reset()
+
+		// objects 模拟 DIP 中的对象数据，按 watermark 返回之后更新的对象
+		objects := []dip.ObjectInstance{
+			{"s_id": "obj-1", "name": "host1", "ip": "10.0.0.1", "s_update_time": 100.0},
+			{"s_id": "obj-2", "name": "host2", "ip": "10.0.0.2", "s_update_time": 200.0},
+		}
+		var watermarks [][]interface{}
+		patches.ApplyMethod(dipClient, "QueryObjectDataSince",
+			func(_ *dip.Client, ctx context.Context, otID string, limit int, watermark []interface{}) ([]dip.ObjectInstance, []interface{}, error) {
+				if otID != "ot-1" {
+					return nil, nil, nil
+				}
+				watermarks = append(watermarks, watermark)
+				var since float64
+				if len(watermark) > 0 {
+					since = watermark[0].(float64)
+				}
+				var result []dip.ObjectInstance
+				for _, obj := range objects {
+					if obj["s_update_time"].(float64) > since {
+						result = append(result, obj)
+					}
+				}
+				if len(result) == 0 {
+					return nil, watermark, nil
+				}
+				last := result[len(result)-1]
+				return result, []interface{}{last["s_update_time"], last["s_id"]}, nil
+			})
+
+		So(oc.Warmup(ctx), ShouldBeNil)
+		So(mockCacheInstance.data[identityKeyPrefix+"ip:10.0.0.2"], ShouldContainSubstring, "obj-2")
+
+		Convey("没有变更时不写入对象", func() {
+			updated, err := oc.Refresh(ctx)
+
+			So(err, ShouldBeNil)
+			So(updated, ShouldEqual, 0)
+			So(watermarks[len(watermarks)-1], ShouldResemble, []interface{}{200.0, "obj-2"})
+		})
+
+		Convey("只写入水位之后变更的对象，并清理属性变更后的旧键", func() {
+			objects[0] = dip.ObjectInstance{"s_id": "obj-1", "name": "host1", "ip": "10.0.0.11", "s_update_time": 300.0}
+
+			updated, err := oc.Refresh(ctx)
+
+			So(err, ShouldBeNil)
+			So(updated, ShouldEqual, 1)
+			So(mockCacheInstance.data[identityKeyPrefix+"ip:10.0.0.11"], ShouldContainSubstring, "obj-1")
+			_, stale := mockCacheInstance.data[identityKeyPrefix+"ip:10.0.0.1"]
+			So(stale, ShouldBeFalse)
+
+			stats := oc.Stats()
+			So(stats.IncrementalRefreshCount, ShouldEqual, 1)
+			So(stats.LastRefreshMode, ShouldEqual, refreshModeIncremental)
+			So(stats.LastRefreshUpdated, ShouldEqual, 1)
+			So(stats.Objects, ShouldEqual, 2)
+		})
+
+		Convey("全量刷新删除已不存在的对象", func() {
+			objects = objects[:1]
+
+			So(oc.Warmup(ctx), ShouldBeNil)
+
+			So(countKeys(mockCacheInstance.data, cacheKeyPrefix), ShouldEqual, 1)
+			_, exists := mockCacheInstance.data[identityKeyPrefix+"ip:10.0.0.2"]
+			So(exists, ShouldBeFalse)
+			So(oc.Stats().LastRefreshDeleted, ShouldEqual, 1)
+		})
+
+		Convey("对象类被删除时清理其缓存", func() {
+			patches.ApplyMethod(dipClient, "GetObjectTypes",
+				func(_ *dip.Client, ctx context.Context) ([]dip.ObjectType, error) {
+					return []dip.ObjectType{{ID: "ot-2", Name: "Switch"}}, nil
+				})
+
+			So(oc.Warmup(ctx), ShouldBeNil)
+
+			So(oc.states, ShouldNotContainKey, "ot-1")
+			_, exists := mockCacheInstance.data[identityKeyPrefix+"host:host1"]
+			So(exists, ShouldBeFalse)
 		})
 	})
 }
@@ -222,15 +413,15 @@ func TestObjectClass_warmupObjectType(t *testing.T) {
 		}
 
 		Convey("成功预热单个对象类", func() {
-			patches := gomonkey.ApplyMethod(dipClient, "QueryAllObjectData",
-				func(_ *dip.Client, ctx context.Context, otID string, limit int) ([]dip.ObjectInstance, error) {
+			patches := gomonkey.ApplyMethod(dipClient, "QueryObjectDataSince",
+				func(_ *dip.Client, ctx context.Context, otID string, limit int, watermark []interface{}) ([]dip.ObjectInstance, []interface{}, error) {
 					return []dip.ObjectInstance{
 						{"s_id": "obj-1", "k8s_cluster": "cluster1", "namespace": "ns1", "name": "pod1"},
-					}, nil
+					}, nil, nil
 				})
 			defer patches.Reset()
 
-			err := oc.warmupObjectType(ctx, "ot-1")
+			_, err := oc.warmupObjectType(ctx, "ot-1")
 
 			So(err, ShouldBeNil)
 			// 验证缓存键
@@ -245,31 +436,80 @@ func TestObjectClass_warmupObjectType(t *testing.T) {
 			So(objInfo.Name, ShouldEqual, "pod1")
 		})
 
+		Convey("按 IP、FQDN、短主机名、MAC 建立识别键", func() {
+			patches := gomonkey.ApplyMethod(dipClient, "QueryObjectDataSince",
+				func(_ *dip.Client, ctx context.Context, otID string, limit int, watermark []interface{}) ([]dip.ObjectInstance, []interface{}, error) {
+					return []dip.ObjectInstance{
+						{
+							"s_id":        "obj-1",
+							"name":        "Web-01.Example.com",
+							"ip":          []interface{}{"10.0.0.1", "10.0.0.2"},
+							"mac_address": "AA-BB-CC-DD-EE-FF",
+						},
+					}, nil, nil
+				})
+			defer patches.Reset()
+
+			_, err := oc.warmupObjectType(ctx, "ot-1")
+
+			So(err, ShouldBeNil)
+			for _, key := range []string{
+				"fqdn:web-01.example.com",
+				"host:web-01",
+				"ip:10.0.0.1",
+				"ip:10.0.0.2",
+				"mac:aa:bb:cc:dd:ee:ff",
+			} {
+				So(mockCacheInstance.data[identityKeyPrefix+key], ShouldContainSubstring, "obj-1")
+			}
+		})
+
+		Convey("按对象类配置的属性建立识别键", func() {
+			oc.identityProperties = map[string][]string{
+				"ot-1":    {"pod_ip"},
+				"default": {"name"},
+			}
+			patches := gomonkey.ApplyMethod(dipClient, "QueryObjectDataSince",
+				func(_ *dip.Client, ctx context.Context, otID string, limit int, watermark []interface{}) ([]dip.ObjectInstance, []interface{}, error) {
+					return []dip.ObjectInstance{
+						{"s_id": "obj-1", "name": "pod1", "pod_ip": "10.1.0.5,10.1.0.6"},
+					}, nil, nil
+				})
+			defer patches.Reset()
+
+			_, err := oc.warmupObjectType(ctx, "ot-1")
+
+			So(err, ShouldBeNil)
+			So(mockCacheInstance.data[identityKeyPrefix+"ip:10.1.0.5"], ShouldNotBeEmpty)
+			So(mockCacheInstance.data[identityKeyPrefix+"ip:10.1.0.6"], ShouldNotBeEmpty)
+			So(mockCacheInstance.data[identityKeyPrefix+"host:pod1"], ShouldBeEmpty)
+		})
+
 		Convey("跳过没有 s_id 的对象", func() {
-			patches := gomonkey.ApplyMethod(dipClient, "QueryAllObjectData",
-				func(_ *dip.Client, ctx context.Context, otID string, limit int) ([]dip.ObjectInstance, error) {
+			patches := gomonkey.ApplyMethod(dipClient, "QueryObjectDataSince",
+				func(_ *dip.Client, ctx context.Context, otID string, limit int, watermark []interface{}) ([]dip.ObjectInstance, []interface{}, error) {
 					return []dip.ObjectInstance{
 						{"k8s_cluster": "cluster1", "namespace": "ns1", "name": "pod1"},             // 没有 s_id
 						{"s_id": "", "k8s_cluster": "cluster1", "namespace": "ns2", "name": "pod2"}, // s_id 为空
 						{"s_id": "obj-3", "k8s_cluster": "cluster1", "namespace": "ns3", "name": "pod3"},
-					}, nil
+					}, nil, nil
 				})
 			defer patches.Reset()
 
-			err := oc.warmupObjectType(ctx, "ot-1")
+			_, err := oc.warmupObjectType(ctx, "ot-1")
 
 			So(err, ShouldBeNil)
-			So(len(mockCacheInstance.data), ShouldEqual, 1) // 只有一个有效对象
+			So(countKeys(mockCacheInstance.data, cacheKeyPrefix), ShouldEqual, 1) // 只有一个有效对象
 		})
 
 		Convey("查询对象数据失败返回错误", func() {
-			patches := gomonkey.ApplyMethod(dipClient, "QueryAllObjectData",
-				func(_ *dip.Client, ctx context.Context, otID string, limit int) ([]dip.ObjectInstance, error) {
-					return nil, errors.New("query failed")
+			patches := gomonkey.ApplyMethod(dipClient, "QueryObjectDataSince",
+				func(_ *dip.Client, ctx context.Context, otID string, limit int, watermark []interface{}) ([]dip.ObjectInstance, []interface{}, error) {
+					return nil, nil, errors.New("query failed")
 				})
 			defer patches.Reset()
 
-			err := oc.warmupObjectType(ctx, "ot-1")
+			_, err := oc.warmupObjectType(ctx, "ot-1")
 
 			So(err, ShouldNotBeNil)
 			So(err.Error(), ShouldContainSubstring, "查询对象数据失败")
@@ -278,29 +518,29 @@ func TestObjectClass_warmupObjectType(t *testing.T) {
 		Convey("缓存写入失败继续处理", func() {
 			mockCacheInstance.setErr = errors.New("cache set failed")
 
-			patches := gomonkey.ApplyMethod(dipClient, "QueryAllObjectData",
-				func(_ *dip.Client, ctx context.Context, otID string, limit int) ([]dip.ObjectInstance, error) {
+			patches := gomonkey.ApplyMethod(dipClient, "QueryObjectDataSince",
+				func(_ *dip.Client, ctx context.Context, otID string, limit int, watermark []interface{}) ([]dip.ObjectInstance, []interface{}, error) {
 					return []dip.ObjectInstance{
 						{"s_id": "obj-1", "k8s_cluster": "cluster1", "namespace": "ns1", "name": "pod1"},
-					}, nil
+					}, nil, nil
 				})
 			defer patches.Reset()
 
-			err := oc.warmupObjectType(ctx, "ot-1")
+			_, err := oc.warmupObjectType(ctx, "ot-1")
 
 			So(err, ShouldBeNil) // 缓存失败不影响返回结果
 		})
 
 		Convey("处理空字段值", func() {
-			patches := gomonkey.ApplyMethod(dipClient, "QueryAllObjectData",
-				func(_ *dip.Client, ctx context.Context, otID string, limit int) ([]dip.ObjectInstance, error) {
+			patches := gomonkey.ApplyMethod(dipClient, "QueryObjectDataSince",
+				func(_ *dip.Client, ctx context.Context, otID string, limit int, watermark []interface{}) ([]dip.ObjectInstance, []interface{}, error) {
 					return []dip.ObjectInstance{
 						{"s_id": "obj-1", "name": "pod1"}, // k8s_cluster 和 namespace 不存在
-					}, nil
+					}, nil, nil
 				})
 			defer patches.Reset()
 
-			err := oc.warmupObjectType(ctx, "ot-1")
+			_, err := oc.warmupObjectType(ctx, "ot-1")
 
 			So(err, ShouldBeNil)
 			// 验证缓存键包含空值
@@ -319,12 +559,12 @@ func TestObjectClass_GetEntityObjectInfo(t *testing.T) {
 			cache: mockCacheInstance,
 		}
 
-		Convey("hostname 为空返回错误", func() {
-			result, err := oc.GetEntityObjectInfo(ctx, "")
+		Convey("查询条件为空返回错误", func() {
+			result, err := oc.GetEntityObjectInfo(ctx, EntityIdentity{})
 
 			So(err, ShouldNotBeNil)
 			So(result, ShouldBeNil)
-			So(err.Error(), ShouldContainSubstring, "hostname 不能为空")
+			So(err.Error(), ShouldContainSubstring, "不能同时为空")
 		})
 
 		Convey("成功获取对象信息", func() {
@@ -338,7 +578,7 @@ func TestObjectClass_GetEntityObjectInfo(t *testing.T) {
 			hostname := "k8s_cluster:cluster1,namespace:ns1,name:pod1"
 			mockCacheInstance.data[cacheKeyPrefix+hostname] = string(jsonData)
 
-			result, err := oc.GetEntityObjectInfo(ctx, hostname)
+			result, err := oc.GetEntityObjectInfo(ctx, EntityIdentity{Key: hostname})
 
 			So(err, ShouldBeNil)
 			So(result, ShouldNotBeNil)
@@ -348,18 +588,52 @@ func TestObjectClass_GetEntityObjectInfo(t *testing.T) {
 		})
 
 		Convey("缓存未命中返回错误", func() {
-			result, err := oc.GetEntityObjectInfo(ctx, "nonexistent-hostname")
+			result, err := oc.GetEntityObjectInfo(ctx, EntityIdentity{Key: "nonexistent-hostname", IP: "10.9.9.9"})
 
 			So(err, ShouldNotBeNil)
 			So(result, ShouldBeNil)
-			So(err.Error(), ShouldContainSubstring, "未找到 hostname 对应的对象信息")
+			So(err.Error(), ShouldContainSubstring, "未找到实体对应的对象信息")
+			So(oc.Stats().Misses, ShouldEqual, 1)
+		})
+
+		Convey("组合键未命中时按名称识别键匹配", func() {
+			objInfo := EntityObjectInfo{ObjectTypeID: "host", ObjectID: "host-1", Name: "web-01.example.com"}
+			jsonData, _ := json.Marshal(objInfo)
+			mockCacheInstance.data[identityKeyPrefix+"host:web-01"] = string(jsonData)
+
+			result, err := oc.GetEntityObjectInfo(ctx, EntityIdentity{Key: BuildEntityKey("", "", "WEB-01")})
+
+			So(err, ShouldBeNil)
+			So(result.ObjectID, ShouldEqual, "host-1")
+		})
+
+		Convey("名称未命中时按 IP 匹配", func() {
+			objInfo := EntityObjectInfo{ObjectTypeID: "host", ObjectID: "host-2", Name: "db-01"}
+			jsonData, _ := json.Marshal(objInfo)
+			mockCacheInstance.data[identityKeyPrefix+"ip:10.0.0.8"] = string(jsonData)
+
+			result, err := oc.GetEntityObjectInfo(ctx, EntityIdentity{Key: "db-01-alias", IP: "10.0.0.8"})
+
+			So(err, ShouldBeNil)
+			So(result.ObjectID, ShouldEqual, "host-2")
+		})
+
+		Convey("名称与 IP 未命中时按 MAC 匹配", func() {
+			objInfo := EntityObjectInfo{ObjectTypeID: "switch", ObjectID: "sw-1", Name: "sw-1"}
+			jsonData, _ := json.Marshal(objInfo)
+			mockCacheInstance.data[identityKeyPrefix+"mac:aa:bb:cc:dd:ee:ff"] = string(jsonData)
+
+			result, err := oc.GetEntityObjectInfo(ctx, EntityIdentity{IP: "10.0.0.9", MAC: "AA:BB:CC:DD:EE:FF"})
+
+			So(err, ShouldBeNil)
+			So(result.ObjectID, ShouldEqual, "sw-1")
 		})
 
 		Convey("缓存数据反序列化失败返回错误", func() {
 			hostname := "invalid-json-hostname"
 			mockCacheInstance.data[cacheKeyPrefix+hostname] = "invalid-json"
 
-			result, err := oc.GetEntityObjectInfo(ctx, hostname)
+			result, err := oc.GetEntityObjectInfo(ctx, EntityIdentity{Key: hostname})
 
 			So(err, ShouldNotBeNil)
 			So(result, ShouldBeNil)
@@ -439,6 +713,33 @@ func TestObjectClass_Run(t *testing.T) {
 			}
 		})
 
+		Convey("预热成功后执行回调", func() {
+			patches := gomonkey.ApplyMethod(dipClient, "GetObjectTypes",
+				func(_ *dip.Client, ctx context.Context) ([]dip.ObjectType, error) {
+					return []dip.ObjectType{}, nil
+				})
+			defer patches.Reset()
+
+			hooked := make(chan struct{}, 1)
+			oc.OnWarmup(func(ctx context.Context) {
+				hooked <- struct{}{}
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				done <- oc.Run(ctx)
+			}()
+
+			select {
+			case <-hooked:
+			case <-time.After(2 * time.Second):
+				t.Fatal("预热回调没有执行")
+			}
+			cancel()
+			So(<-done, ShouldEqual, context.Canceled)
+		})
+
 		Convey("初始预热失败不阻止运行", func() {
 			callCount := 0
 			patches := gomonkey.ApplyMethod(dipClient, "GetObjectTypes",
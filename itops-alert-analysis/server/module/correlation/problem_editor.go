@@ -0,0 +1,243 @@
+package correlation
+
+import (
+	"context"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/slice"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/timex"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// SplitProblem 将问题中选定的故障点拆分到新问题，原问题与新问题按各自的故障点重新计算等级与时间范围，并重新触发 RCA。
+func (s *ProblemStage) SplitProblem(ctx context.Context, problemID uint64, faultIDs []uint64, by string) (*domain.Problem, error) {
+	var newProblem *domain.Problem
+	err := opensearch.RetryOnConflict(ctx, func() error {
+		var err error
+		newProblem, err = s.splitProblem(ctx, problemID, faultIDs, by)
+		return err
+	})
+	return newProblem, err
+}
+
+// splitProblem 执行一次拆分：先以新建方式写入新问题，再按版本保存原问题；
+// 原问题保存失败（含版本冲突）时删除新问题，故障点仍只属于原问题，冲突时可整体重试。
+func (s *ProblemStage) splitProblem(ctx context.Context, problemID uint64, faultIDs []uint64, by string) (*domain.Problem, error) {
+	problem, err := s.openProblem(ctx, problemID)
+	if err != nil {
+		return nil, err
+	}
+
+	split := make(map[uint64]bool, len(faultIDs))
+	for _, id := range faultIDs {
+		if !slice.ContainsUint64(problem.RelationIDs, id) {
+			return nil, errors.Wrapf(domain.ErrInvalidOperation, "故障点 %d 不属于问题 %d", id, problemID)
+		}
+		split[id] = true
+	}
+	if len(split) == 0 || len(split) == len(problem.RelationIDs) {
+		return nil, errors.Wrap(domain.ErrInvalidOperation, "拆分的故障点不能为空，且问题至少保留一个故障点")
+	}
+
+	faultPoints, err := s.repoFactory.FaultPoints().QueryByIDs(ctx, problem.RelationIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询问题关联故障点失败")
+	}
+	if missing := missingFaultPoints(problem.RelationIDs, faultPoints); len(missing) > 0 {
+		return nil, errors.Wrapf(domain.ErrInvalidOperation, "问题 %d 关联的故障点 %v 不存在", problemID, missing)
+	}
+	var moved, kept []domain.FaultPointObject
+	for _, fp := range faultPoints {
+		if split[fp.FaultID] {
+			moved = append(moved, fp)
+		} else {
+			kept = append(kept, fp)
+		}
+	}
+
+	now := timex.NowLocalTime().Local()
+	newProblem := domain.Problem{
+		ProblemID:              s.genID.NextID(),
+		ProblemName:            moved[0].FaultName,
+		ProblemCreateTimestamp: now,
+		ProblemStatus:          domain.ProblemStatusOpen,
+		RcaStatus:              domain.RcaStatusPending,
+	}
+	rebuildProblemFromFaultPoints(&newProblem, moved, now)
+	remaining := problem
+	rebuildProblemFromFaultPoints(&remaining, kept, now)
+	// 根因故障点被拆走时清空原问题根因，等待重新分析
+	if split[remaining.RootCauseFaultID] {
+		remaining.RootCauseFaultID = 0
+		remaining.RootCauseObjectID = ""
+	}
+
+	if err := s.repoFactory.Problems().Upsert(ctx, newProblem); err != nil {
+		return nil, errors.Wrap(err, "创建拆分问题失败")
+	}
+	if err := s.repoFactory.Problems().Upsert(ctx, remaining); err != nil {
+		if delErr := s.repoFactory.Problems().Delete(ctx, newProblem.ProblemID); delErr != nil {
+			log.Errorf("原问题 %d 保存失败，删除拆分问题 %d 失败: %v", problemID, newProblem.ProblemID, delErr)
+		}
+		return nil, errors.Wrap(err, "保存原问题失败")
+	}
+	if err := s.repoFactory.FaultPoints().UpdateProblemID(ctx, newProblem.RelationIDs, newProblem.ProblemID); err != nil {
+		return nil, errors.Wrap(err, "回写 problem_id 到故障点失败")
+	}
+	if err := s.repoFactory.RawEvents().UpdateProblemID(ctx, newProblem.RelationEventIDs, newProblem.ProblemID); err != nil {
+		return nil, errors.Wrap(err, "回写 problem_id 到事件失败")
+	}
+	log.Infof("问题 %d 拆分出 %d 个故障点到新问题 %d（操作人: %s）", problemID, len(moved), newProblem.ProblemID, by)
+
+	records := make([]domain.CorrelationRecord, 0, len(moved))
+	for _, fp := range moved {
+		records = append(records, domain.CorrelationRecord{
+			RecordType:      domain.CorrelationRecordSplit,
+			ProblemID:       newProblem.ProblemID,
+			FaultID:         fp.FaultID,
+			SourceProblemID: problemID,
+			Strategy:        domain.CorrelationStrategyManual,
+			Score:           1,
+			OperatedBy:      by,
+			CreateTime:      now,
+		})
+	}
+	s.saveCorrelationRecords(ctx, records)
+	s.retriggerRCA(ctx, problemID, newProblem.ProblemID)
+	return &newProblem, nil
+}
+
+// MergeProblems 手动将 sourceID 问题合并到 targetID 问题，被合并问题以 merged 状态关闭，并对主问题重新触发 RCA。
+func (s *ProblemStage) MergeProblems(ctx context.Context, targetID, sourceID uint64, by string) (*domain.Problem, error) {
+	var target *domain.Problem
+	err := opensearch.RetryOnConflict(ctx, func() error {
+		var err error
+		target, err = s.mergeProblems(ctx, targetID, sourceID, by)
+		return err
+	})
+	return target, err
+}
+
+// mergeProblems 执行一次手动合并，主问题按版本保存，冲突时可整体重试。
+func (s *ProblemStage) mergeProblems(ctx context.Context, targetID, sourceID uint64, by string) (*domain.Problem, error) {
+	if targetID == sourceID {
+		return nil, errors.Wrap(domain.ErrInvalidOperation, "不能将问题合并到自身")
+	}
+	target, err := s.openProblem(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := s.openProblem(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	absorbProblem(&target, source)
+	target.ProblemDuration = timex.AbsSecondsBetween(target.ProblemLatestTime, target.ProblemOccurTime)
+	target.ProblemUpdateTime = timex.NowLocalTime().Local()
+	if err := s.repoFactory.Problems().Upsert(ctx, target); err != nil {
+		return nil, errors.Wrap(err, "保存问题失败")
+	}
+	s.retireMergedProblem(ctx, source, targetID, domain.ProblemCloseTypeManual, "手动合并到问题"+cast.ToString(targetID), by)
+	log.Infof("问题 %d 已手动合并到问题 %d（操作人: %s）", sourceID, targetID, by)
+
+	s.saveCorrelationRecords(ctx, []domain.CorrelationRecord{{
+		RecordType:      domain.CorrelationRecordProblemMerge,
+		ProblemID:       targetID,
+		SourceProblemID: sourceID,
+		Strategy:        domain.CorrelationStrategyManual,
+		Score:           1,
+		OperatedBy:      by,
+		CreateTime:      timex.NowLocalTime(),
+	}})
+	s.retriggerRCA(ctx, targetID)
+	return &target, nil
+}
+
+// missingFaultPoints 返回 ids 中未查询到的故障点 ID。
+func missingFaultPoints(ids []uint64, faultPoints []domain.FaultPointObject) []uint64 {
+	found := make(map[uint64]bool, len(faultPoints))
+	for _, fp := range faultPoints {
+		found[fp.FaultID] = true
+	}
+	var missing []uint64
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// openProblem 查询处于打开状态的问题。
+func (s *ProblemStage) openProblem(ctx context.Context, problemID uint64) (domain.Problem, error) {
+	problems, err := s.repoFactory.Problems().QueryByIDs(ctx, []uint64{problemID})
+	if err != nil {
+		return domain.Problem{}, errors.Wrap(err, "查询问题失败")
+	}
+	if len(problems) == 0 {
+		return domain.Problem{}, errors.Wrapf(domain.ErrProblemNotFound, "问题 %d", problemID)
+	}
+	if problems[0].ProblemStatus != domain.ProblemStatusOpen {
+		return domain.Problem{}, errors.Wrapf(domain.ErrProblemNotOpen, "问题 %d 状态为 %s", problemID, problems[0].ProblemStatus)
+	}
+	return problems[0], nil
+}
+
+// retriggerRCA 发布问题事件，由 RCA 模块重新分析。
+func (s *ProblemStage) retriggerRCA(ctx context.Context, problemIDs ...uint64) {
+	for _, problemID := range problemIDs {
+		if err := s.publishProblemEvent(ctx, problemID); err != nil {
+			log.Infof("发布问题事件失败 problem_id=%d: %v", problemID, err)
+		}
+	}
+}
+
+// rebuildProblemFromFaultPoints 按故障点重新计算问题的关联关系、时间范围与等级。
+// 等级取发生中故障点的最高等级，全部已恢复时取所有故障点的最高等级（值越小等级越高）。
+func rebuildProblemFromFaultPoints(problem *domain.Problem, faultPoints []domain.FaultPointObject, now time.Time) {
+	problem.RelationIDs = nil
+	problem.RelationEventIDs = nil
+	problem.AffectedEntityIDs = nil
+	problem.FaultFingerprints = nil
+	problem.ProblemOccurTime = time.Time{}
+	problem.ProblemLatestTime = time.Time{}
+
+	var activeLevel, anyLevel domain.Severity
+	for _, fp := range faultPoints {
+		problem.RelationIDs = slice.AppendUniqueUint64(problem.RelationIDs, fp.FaultID)
+		for _, eventID := range fp.RelationEventIDs {
+			problem.RelationEventIDs = slice.AppendUniqueUint64(problem.RelationEventIDs, eventID)
+		}
+		problem.AffectedEntityIDs = slice.AppendUniqueString(problem.AffectedEntityIDs, fp.EntityObjectID)
+		problem.FaultFingerprints = slice.AppendUniqueString(problem.FaultFingerprints, faultFingerprint(fp))
+
+		if problem.ProblemOccurTime.IsZero() || fp.FaultOccurTime.Before(problem.ProblemOccurTime) {
+			problem.ProblemOccurTime = fp.FaultOccurTime
+		}
+		if fp.FaultLatestTime.After(problem.ProblemLatestTime) {
+			problem.ProblemLatestTime = fp.FaultLatestTime
+		}
+
+		if fp.FaultLevel == 0 {
+			continue
+		}
+		if anyLevel == 0 || fp.FaultLevel < anyLevel {
+			anyLevel = fp.FaultLevel
+		}
+		if fp.FaultStatus == domain.FaultStatusOccurred && (activeLevel == 0 || fp.FaultLevel < activeLevel) {
+			activeLevel = fp.FaultLevel
+		}
+	}
+
+	problem.ProblemLevel = activeLevel
+	if problem.ProblemLevel == 0 {
+		problem.ProblemLevel = anyLevel
+	}
+	problem.ProblemDuration = timex.AbsSecondsBetween(problem.ProblemLatestTime, problem.ProblemOccurTime)
+	problem.ProblemUpdateTime = now
+}
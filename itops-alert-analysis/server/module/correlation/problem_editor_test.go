@@ -0,0 +1,277 @@
+package correlation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/kafka"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
+	"github.com/agiledragon/gomonkey/v2"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProblemStage_SplitAndMerge(t *testing.T) {
+	Convey("TestProblemStage_SplitAndMerge", t, func() {
+		ctx := context.Background()
+		factory := opensearch.NewRepositoryFactory(nil)
+		producer := &kafka.Producer{}
+		stage := NewProblemStage(newTestConfigManager(), factory, producer, nil)
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+
+		base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.Local)
+		problems := map[uint64]domain.Problem{
+			1: {
+				ProblemID: 1, ProblemStatus: domain.ProblemStatusOpen, ProblemLevel: domain.SeverityCritical,
+				RelationIDs: []uint64{10, 11, 12}, RelationEventIDs: []uint64{100, 110, 120},
+				AffectedEntityIDs: []string{"host-1", "db-1"}, RootCauseFaultID: 12, RootCauseObjectID: "db-1",
+				ProblemOccurTime: base, ProblemLatestTime: base.Add(30 * time.Minute),
+			},
+			2: {
+				ProblemID: 2, ProblemStatus: domain.ProblemStatusOpen, ProblemLevel: domain.SeverityMajor,
+				RelationIDs: []uint64{20}, RelationEventIDs: []uint64{200}, AffectedEntityIDs: []string{"pod-1"},
+				ProblemOccurTime: base.Add(-10 * time.Minute), ProblemLatestTime: base.Add(5 * time.Minute),
+			},
+			3: {ProblemID: 3, ProblemStatus: domain.ProblemStatusClosed},
+		}
+		faultPoints := []domain.FaultPointObject{
+			{FaultID: 10, FaultName: "CPU 高", EntityObjectID: "host-1", RelationEventIDs: []uint64{100}, FaultStatus: domain.FaultStatusOccurred, FaultLevel: domain.SeverityMajor, FaultOccurTime: base, FaultLatestTime: base.Add(10 * time.Minute)},
+			{FaultID: 11, FaultName: "内存高", EntityObjectID: "host-1", RelationEventIDs: []uint64{110}, FaultStatus: domain.FaultStatusRecovered, FaultLevel: domain.SeverityCritical, FaultOccurTime: base.Add(5 * time.Minute), FaultLatestTime: base.Add(20 * time.Minute)},
+			{FaultID: 12, FaultName: "连接数高", EntityObjectID: "db-1", RelationEventIDs: []uint64{120}, FaultStatus: domain.FaultStatusOccurred, FaultLevel: domain.SeverityWarning, FaultOccurTime: base.Add(8 * time.Minute), FaultLatestTime: base.Add(30 * time.Minute)},
+		}
+
+		var upserted []domain.Problem
+		fpProblemIDs := map[uint64]uint64{}
+		eventProblemIDs := map[uint64]uint64{}
+		var closed []uint64
+		var records []domain.CorrelationRecord
+		var published []uint64
+		patches.ApplyMethod(factory.Problems(), "QueryByIDs", func(_ *opensearch.ProblemStore, _ context.Context, ids []uint64) ([]domain.Problem, error) {
+			if p, ok := problems[ids[0]]; ok {
+				return []domain.Problem{p}, nil
+			}
+			return nil, nil
+		})
+		patches.ApplyMethod(factory.Problems(), "Upsert", func(_ *opensearch.ProblemStore, _ context.Context, p domain.Problem) error {
+			upserted = append(upserted, p)
+			return nil
+		})
+		patches.ApplyMethod(factory.Problems(), "CloseMerged", func(_ *opensearch.ProblemStore, _ context.Context, p domain.Problem, closeType domain.ProblemCloseType, _ string, _ string) error {
+			So(closeType, ShouldEqual, domain.ProblemCloseTypeManual)
+			closed = append(closed, p.ProblemID)
+			return nil
+		})
+		patches.ApplyMethod(factory.FaultPoints(), "QueryByIDs", func(_ *opensearch.FaultPointStore, _ context.Context, _ []uint64) ([]domain.FaultPointObject, error) {
+			return faultPoints, nil
+		})
+		patches.ApplyMethod(factory.FaultPoints(), "UpdateProblemID", func(_ *opensearch.FaultPointStore, _ context.Context, ids []uint64, problemID uint64) error {
+			for _, id := range ids {
+				fpProblemIDs[id] = problemID
+			}
+			return nil
+		})
+		patches.ApplyMethod(factory.RawEvents(), "UpdateProblemID", func(_ *opensearch.RawEventStore, _ context.Context, ids []uint64, problemID uint64) error {
+			for _, id := range ids {
+				eventProblemIDs[id] = problemID
+			}
+			return nil
+		})
+		patches.ApplyMethod(factory.CorrelationRecords(), "Upsert", func(_ *opensearch.CorrelationRecordStore, _ context.Context, record domain.CorrelationRecord) error {
+			records = append(records, record)
+			return nil
+		})
+		patches.ApplyMethod(producer, "PublishRawEvent", func(_ *kafka.Producer, _ context.Context, _ string, value []byte) error {
+			var msg ProblemEventMessage
+			_ = json.Unmarshal(value, &msg)
+			published = append(published, msg.ProblemID)
+			return nil
+		})
+
+		Convey("拆分故障点到新问题，两个问题分别重算并重新触发 RCA", func() {
+			newProblem, err := stage.SplitProblem(ctx, 1, []uint64{11, 12}, "admin")
+
+			So(err, ShouldBeNil)
+			So(newProblem.RelationIDs, ShouldResemble, []uint64{11, 12})
+			So(newProblem.RelationEventIDs, ShouldResemble, []uint64{110, 120})
+			So(newProblem.AffectedEntityIDs, ShouldResemble, []string{"host-1", "db-1"})
+			So(newProblem.ProblemName, ShouldEqual, "内存高")
+			// 发生中的故障点只有 12（warning），恢复的 11 不参与等级计算
+			So(newProblem.ProblemLevel, ShouldEqual, domain.SeverityWarning)
+			So(newProblem.ProblemOccurTime, ShouldEqual, base.Add(5*time.Minute))
+			So(newProblem.ProblemDuration, ShouldEqual, 25*60)
+
+			// 先创建新问题，再按版本保存原问题
+			So(upserted, ShouldHaveLength, 2)
+			So(upserted[0].ProblemID, ShouldEqual, newProblem.ProblemID)
+			So(upserted[0].Versioned(), ShouldBeFalse)
+			remaining := upserted[1]
+			So(remaining.ProblemID, ShouldEqual, 1)
+			So(remaining.RelationIDs, ShouldResemble, []uint64{10})
+			So(remaining.RelationEventIDs, ShouldResemble, []uint64{100})
+			So(remaining.AffectedEntityIDs, ShouldResemble, []string{"host-1"})
+			So(remaining.ProblemLevel, ShouldEqual, domain.SeverityMajor)
+			So(remaining.ProblemDuration, ShouldEqual, 10*60)
+			So(remaining.RootCauseFaultID, ShouldEqual, 0)
+
+			So(fpProblemIDs, ShouldResemble, map[uint64]uint64{11: newProblem.ProblemID, 12: newProblem.ProblemID})
+			So(eventProblemIDs, ShouldResemble, map[uint64]uint64{110: newProblem.ProblemID, 120: newProblem.ProblemID})
+			So(records, ShouldHaveLength, 2)
+			So(records[0].RecordType, ShouldEqual, domain.CorrelationRecordSplit)
+			So(records[0].SourceProblemID, ShouldEqual, 1)
+			So(records[0].OperatedBy, ShouldEqual, "admin")
+			So(published, ShouldResemble, []uint64{1, newProblem.ProblemID})
+		})
+
+		Convey("创建拆分问题失败时不修改原问题与故障点", func() {
+			patches.ApplyMethod(factory.Problems(), "Upsert", func(_ *opensearch.ProblemStore, _ context.Context, p domain.Problem) error {
+				if p.ProblemID != 1 {
+					return errors.New("opensearch unavailable")
+				}
+				upserted = append(upserted, p)
+				return nil
+			})
+
+			_, err := stage.SplitProblem(ctx, 1, []uint64{11, 12}, "admin")
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "创建拆分问题失败")
+			So(upserted, ShouldBeEmpty)
+			So(fpProblemIDs, ShouldBeEmpty)
+			So(eventProblemIDs, ShouldBeEmpty)
+			So(records, ShouldBeEmpty)
+		})
+
+		Convey("原问题保存失败时删除已创建的拆分问题", func() {
+			var deleted []uint64
+			patches.ApplyMethod(factory.Problems(), "Delete", func(_ *opensearch.ProblemStore, _ context.Context, problemID uint64) error {
+				deleted = append(deleted, problemID)
+				return nil
+			})
+
+			Convey("保存失败返回错误", func() {
+				patches.ApplyMethod(factory.Problems(), "Upsert", func(_ *opensearch.ProblemStore, _ context.Context, p domain.Problem) error {
+					upserted = append(upserted, p)
+					if p.ProblemID == 1 {
+						return errors.New("opensearch unavailable")
+					}
+					return nil
+				})
+
+				_, err := stage.SplitProblem(ctx, 1, []uint64{11, 12}, "admin")
+
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "保存原问题失败")
+				So(upserted, ShouldHaveLength, 2)
+				So(deleted, ShouldResemble, []uint64{upserted[0].ProblemID})
+				So(fpProblemIDs, ShouldBeEmpty)
+				So(eventProblemIDs, ShouldBeEmpty)
+			})
+
+			Convey("版本冲突时删除后整体重试", func() {
+				conflicts := 0
+				patches.ApplyMethod(factory.Problems(), "Upsert", func(_ *opensearch.ProblemStore, _ context.Context, p domain.Problem) error {
+					upserted = append(upserted, p)
+					if p.ProblemID == 1 && conflicts == 0 {
+						conflicts++
+						return domain.ErrVersionConflict
+					}
+					return nil
+				})
+
+				newProblem, err := stage.SplitProblem(ctx, 1, []uint64{11, 12}, "admin")
+
+				So(err, ShouldBeNil)
+				So(deleted, ShouldResemble, []uint64{upserted[0].ProblemID})
+				So(newProblem.ProblemID, ShouldNotEqual, upserted[0].ProblemID)
+				So(fpProblemIDs, ShouldResemble, map[uint64]uint64{11: newProblem.ProblemID, 12: newProblem.ProblemID})
+			})
+		})
+
+		Convey("拆分参数不合法", func() {
+			_, err := stage.SplitProblem(ctx, 1, []uint64{99}, "admin")
+			So(errors.Is(err, domain.ErrInvalidOperation), ShouldBeTrue)
+
+			_, err = stage.SplitProblem(ctx, 1, []uint64{10, 11, 12}, "admin")
+			So(errors.Is(err, domain.ErrInvalidOperation), ShouldBeTrue)
+
+			_, err = stage.SplitProblem(ctx, 3, []uint64{30}, "admin")
+			So(errors.Is(err, domain.ErrProblemNotOpen), ShouldBeTrue)
+
+			_, err = stage.SplitProblem(ctx, 404, []uint64{1}, "admin")
+			So(errors.Is(err, domain.ErrProblemNotFound), ShouldBeTrue)
+			So(upserted, ShouldBeEmpty)
+		})
+
+		Convey("问题关联的故障点不存在时拒绝拆分并列出缺失的故障点", func() {
+			p := problems[1]
+			p.RelationIDs = []uint64{10, 11, 12, 13}
+			problems[1] = p
+
+			_, err := stage.SplitProblem(ctx, 1, []uint64{11}, "admin")
+
+			So(errors.Is(err, domain.ErrInvalidOperation), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "[13]")
+			So(upserted, ShouldBeEmpty)
+		})
+
+		Convey("手动合并问题，被合并问题改写关联并关闭", func() {
+			target, err := stage.MergeProblems(ctx, 1, 2, "admin")
+
+			So(err, ShouldBeNil)
+			So(target.RelationIDs, ShouldResemble, []uint64{10, 11, 12, 20})
+			So(target.AffectedEntityIDs, ShouldResemble, []string{"host-1", "db-1", "pod-1"})
+			So(target.ProblemLevel, ShouldEqual, domain.SeverityCritical)
+			So(target.ProblemOccurTime, ShouldEqual, base.Add(-10*time.Minute))
+			So(target.ProblemDuration, ShouldEqual, 40*60)
+
+			So(fpProblemIDs, ShouldResemble, map[uint64]uint64{20: 1})
+			So(eventProblemIDs, ShouldResemble, map[uint64]uint64{200: 1})
+			So(closed, ShouldResemble, []uint64{2})
+			So(records, ShouldHaveLength, 1)
+			So(records[0].RecordType, ShouldEqual, domain.CorrelationRecordProblemMerge)
+			So(records[0].Strategy, ShouldEqual, domain.CorrelationStrategyManual)
+			So(records[0].SourceProblemID, ShouldEqual, 2)
+			So(published, ShouldResemble, []uint64{1})
+		})
+
+		Convey("主问题保存后并入被合并问题的故障点追加到主问题", func() {
+			patches.ApplyMethod(factory.Problems(), "Upsert", func(_ *opensearch.ProblemStore, _ context.Context, p domain.Problem) error {
+				upserted = append(upserted, p)
+				problems[p.ProblemID] = p
+				if len(upserted) == 1 {
+					// 主问题保存后、被合并问题关闭前，故障点 21 并入被合并问题
+					source := problems[2]
+					source.RelationIDs = []uint64{20, 21}
+					source.RelationEventIDs = []uint64{200, 210}
+					problems[2] = source
+				}
+				return nil
+			})
+
+			_, err := stage.MergeProblems(ctx, 1, 2, "admin")
+
+			So(err, ShouldBeNil)
+			So(closed, ShouldResemble, []uint64{2})
+			So(fpProblemIDs, ShouldResemble, map[uint64]uint64{20: 1, 21: 1})
+			So(eventProblemIDs, ShouldResemble, map[uint64]uint64{200: 1, 210: 1})
+			So(upserted, ShouldHaveLength, 2)
+			So(upserted[1].ProblemID, ShouldEqual, 1)
+			So(upserted[1].RelationIDs, ShouldResemble, []uint64{10, 11, 12, 20, 21})
+			So(upserted[1].RelationEventIDs, ShouldResemble, []uint64{100, 110, 120, 200, 210})
+		})
+
+		Convey("合并到自身或合并已关闭的问题返回错误", func() {
+			_, err := stage.MergeProblems(ctx, 1, 1, "admin")
+			So(errors.Is(err, domain.ErrInvalidOperation), ShouldBeTrue)
+
+			_, err = stage.MergeProblems(ctx, 1, 3, "admin")
+			So(errors.Is(err, domain.ErrProblemNotOpen), ShouldBeTrue)
+			So(upserted, ShouldBeEmpty)
+			So(closed, ShouldBeEmpty)
+		})
+	})
+}
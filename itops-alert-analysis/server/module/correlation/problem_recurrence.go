@@ -0,0 +1,111 @@
+package correlation
+
+import (
+	"context"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/slice"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/timex"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultReopenWindow   = 30 * time.Minute
+	defaultReopenLookback = 7 * 24 * time.Hour
+)
+
+// faultFingerprint 故障点指纹：对象 + 故障模式，未归一故障模式时依次使用收敛键、故障名称。
+func faultFingerprint(fp domain.FaultPointObject) string {
+	mode := fp.FaultMode
+	if mode == "" {
+		mode = fp.FaultKey
+	}
+	if mode == "" {
+		mode = fp.FaultName
+	}
+	return fp.EntityObjectID + "/" + mode
+}
+
+// reopenSettings 返回生效的重开窗口与复发回溯时间，未配置时使用默认值。
+func reopenSettings(cfg config.ReopenConfig) (window, lookback time.Duration) {
+	window, lookback = cfg.Window, cfg.Lookback
+	if window <= 0 {
+		window = defaultReopenWindow
+	}
+	if lookback <= 0 {
+		lookback = defaultReopenLookback
+	}
+	if lookback < window {
+		lookback = window
+	}
+	return window, lookback
+}
+
+// findRecurrence 按指纹查找故障点复发的已关闭问题。
+// 最近关闭的问题为系统关闭且关闭时间在重开窗口内时返回 reopen，否则返回 previous 用于关联复发。
+// 查询失败只记录日志，按未复发处理，不阻塞故障点入问题。
+func (s *ProblemStage) findRecurrence(ctx context.Context, fp domain.FaultPointObject) (reopen, previous *domain.Problem) {
+	cfg := s.cfgManager.GetConfig().AppConfig.Problem.Reopen
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	window, lookback := reopenSettings(cfg)
+
+	problems, err := s.repoFactory.Problems().FindRecentClosed(ctx, []string{faultFingerprint(fp)}, fp.FaultOccurTime.Add(-lookback))
+	if err != nil {
+		log.Warnf("查询故障点 %d 复发的问题失败: %v", fp.FaultID, err)
+		return nil, nil
+	}
+	if len(problems) == 0 {
+		return nil, nil
+	}
+
+	latest := problems[0]
+	if latest.ProblemCloseTime != nil && latest.ProblemCloseType != nil &&
+		*latest.ProblemCloseType == domain.ProblemCloseTypeSystem &&
+		fp.FaultOccurTime.Sub(*latest.ProblemCloseTime) <= window {
+		return &latest, nil
+	}
+	return nil, &latest
+}
+
+// reopenProblem 重开已关闭的问题并关联故障点，问题等级取复发故障点的等级。
+// 上一轮根因分析结果清空，问题重新进入待分析状态。
+func (s *ProblemStage) reopenProblem(ctx context.Context, problem domain.Problem, fp domain.FaultPointObject) (*domain.Problem, error) {
+	problem.ProblemStatus = domain.ProblemStatusOpen
+	problem.ProblemCloseType = nil
+	problem.ProblemCloseNotes = ""
+	problem.ProblemClosedBy = ""
+	problem.ProblemCloseTime = nil
+	problem.RecurrenceCount++
+
+	problem.RootCauseObjectID = ""
+	problem.RootCauseFaultID = 0
+	problem.RcaResults = ""
+	problem.RcaStartTime = time.Time{}
+	problem.RcaEndTime = time.Time{}
+	problem.RcaStatus = domain.RcaStatusPending
+	problem.RcaProgress = nil
+
+	problem.RelationIDs = slice.AppendUniqueUint64(problem.RelationIDs, fp.FaultID)
+	for _, eventID := range fp.RelationEventIDs {
+		problem.RelationEventIDs = slice.AppendUniqueUint64(problem.RelationEventIDs, eventID)
+	}
+	problem.AffectedEntityIDs = slice.AppendUniqueString(problem.AffectedEntityIDs, fp.EntityObjectID)
+	problem.FaultFingerprints = slice.AppendUniqueString(problem.FaultFingerprints, faultFingerprint(fp))
+	if fp.FaultLatestTime.After(problem.ProblemLatestTime) {
+		problem.ProblemLatestTime = fp.FaultLatestTime
+	}
+	// 原问题的故障点均已恢复，等级以复发的故障点为准
+	problem.ProblemLevel = fp.FaultLevel
+	problem.ProblemDuration = timex.AbsSecondsBetween(problem.ProblemLatestTime, problem.ProblemOccurTime)
+	problem.ProblemUpdateTime = timex.NowLocalTime().Local()
+
+	if err := s.repoFactory.Problems().Upsert(ctx, problem); err != nil {
+		return nil, errors.Wrap(err, "重开问题失败")
+	}
+	return &problem, nil
+}
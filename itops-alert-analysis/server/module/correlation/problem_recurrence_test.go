@@ -0,0 +1,208 @@
+package correlation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config/configtest"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
+	"github.com/agiledragon/gomonkey/v2"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFaultFingerprint(t *testing.T) {
+	Convey("TestFaultFingerprint", t, func() {
+		So(faultFingerprint(domain.FaultPointObject{EntityObjectID: "host-1", FaultMode: "cpu_saturation", FaultKey: "cpu.util"}), ShouldEqual, "host-1/cpu_saturation")
+		So(faultFingerprint(domain.FaultPointObject{EntityObjectID: "host-1", FaultKey: "cpu.util", FaultName: "CPU 高"}), ShouldEqual, "host-1/cpu.util")
+		So(faultFingerprint(domain.FaultPointObject{EntityObjectID: "host-1", FaultName: "CPU 高"}), ShouldEqual, "host-1/CPU 高")
+	})
+}
+
+func TestReopenSettings(t *testing.T) {
+	Convey("TestReopenSettings", t, func() {
+		window, lookback := reopenSettings(config.ReopenConfig{})
+		So(window, ShouldEqual, defaultReopenWindow)
+		So(lookback, ShouldEqual, defaultReopenLookback)
+
+		window, lookback = reopenSettings(config.ReopenConfig{Window: 2 * time.Hour, Lookback: time.Hour})
+		So(window, ShouldEqual, 2*time.Hour)
+		So(lookback, ShouldEqual, 2*time.Hour)
+	})
+}
+
+func TestProblemStage_findRecurrence(t *testing.T) {
+	Convey("TestProblemStage_findRecurrence", t, func() {
+		ctx := context.Background()
+		cfg := newTestConfig()
+		cfg.AppConfig.Problem.Reopen = config.ReopenConfig{Enabled: true, Window: 30 * time.Minute, Lookback: 24 * time.Hour}
+		factory := opensearch.NewRepositoryFactory(nil)
+		stage := NewProblemStage(configtest.NewProvider(cfg), factory, nil, nil)
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+
+		occur := time.Date(2026, 1, 1, 10, 0, 0, 0, time.Local)
+		fp := domain.FaultPointObject{FaultID: 10, EntityObjectID: "host-1", FaultMode: "cpu_saturation", FaultOccurTime: occur}
+		systemClose := domain.ProblemCloseTypeSystem
+		manualClose := domain.ProblemCloseTypeManual
+		closedAt := func(d time.Duration) *time.Time {
+			t := occur.Add(-d)
+			return &t
+		}
+
+		var closed []domain.Problem
+		var queried []string
+		var since time.Time
+		patches.ApplyMethod(factory.Problems(), "FindRecentClosed", func(_ *opensearch.ProblemStore, _ context.Context, fingerprints []string, t time.Time) ([]domain.Problem, error) {
+			queried = fingerprints
+			since = t
+			return closed, nil
+		})
+
+		Convey("未启用时不查询", func() {
+			stage.cfgManager = newTestConfigManager()
+
+			reopen, previous := stage.findRecurrence(ctx, fp)
+
+			So(reopen, ShouldBeNil)
+			So(previous, ShouldBeNil)
+			So(queried, ShouldBeNil)
+		})
+
+		Convey("窗口内系统关闭的问题重开", func() {
+			closed = []domain.Problem{{ProblemID: 1, ProblemCloseType: &systemClose, ProblemCloseTime: closedAt(10 * time.Minute)}}
+
+			reopen, previous := stage.findRecurrence(ctx, fp)
+
+			So(queried, ShouldResemble, []string{"host-1/cpu_saturation"})
+			So(since, ShouldEqual, occur.Add(-24*time.Hour))
+			So(reopen.ProblemID, ShouldEqual, 1)
+			So(previous, ShouldBeNil)
+		})
+
+		Convey("超出窗口关联为复发", func() {
+			closed = []domain.Problem{{ProblemID: 1, ProblemCloseType: &systemClose, ProblemCloseTime: closedAt(2 * time.Hour)}}
+
+			reopen, previous := stage.findRecurrence(ctx, fp)
+
+			So(reopen, ShouldBeNil)
+			So(previous.ProblemID, ShouldEqual, 1)
+		})
+
+		Convey("手动关闭的问题不重开，只关联复发", func() {
+			closed = []domain.Problem{{ProblemID: 1, ProblemCloseType: &manualClose, ProblemCloseTime: closedAt(time.Minute)}}
+
+			reopen, previous := stage.findRecurrence(ctx, fp)
+
+			So(reopen, ShouldBeNil)
+			So(previous.ProblemID, ShouldEqual, 1)
+		})
+
+		Convey("查询失败按未复发处理", func() {
+			patches.ApplyMethod(factory.Problems(), "FindRecentClosed", func(_ *opensearch.ProblemStore, _ context.Context, _ []string, _ time.Time) ([]domain.Problem, error) {
+				return nil, errors.New("search failed")
+			})
+
+			reopen, previous := stage.findRecurrence(ctx, fp)
+
+			So(reopen, ShouldBeNil)
+			So(previous, ShouldBeNil)
+		})
+	})
+}
+
+func TestProblemStage_reopenProblem(t *testing.T) {
+	Convey("TestProblemStage_reopenProblem", t, func() {
+		ctx := context.Background()
+		factory := opensearch.NewRepositoryFactory(nil)
+		stage := NewProblemStage(newTestConfigManager(), factory, nil, nil)
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+
+		base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.Local)
+		closeType := domain.ProblemCloseTypeSystem
+		closeTime := base.Add(20 * time.Minute)
+		problem := domain.Problem{
+			ProblemID:         1,
+			ProblemStatus:     domain.ProblemStatusClosed,
+			ProblemCloseType:  &closeType,
+			ProblemCloseNotes: "所有故障点已恢复",
+			ProblemClosedBy:   "system",
+			ProblemCloseTime:  &closeTime,
+			ProblemLevel:      domain.SeverityCritical,
+			ProblemOccurTime:  base,
+			ProblemLatestTime: base.Add(15 * time.Minute),
+			RelationIDs:       []uint64{10},
+			RelationEventIDs:  []uint64{100},
+			AffectedEntityIDs: []string{"host-1"},
+			FaultFingerprints: []string{"host-1/cpu_saturation"},
+			RecurrenceCount:   1,
+			RootCauseObjectID: "host-1",
+			RootCauseFaultID:  10,
+			RcaResults:        `{"root_cause":"cpu"}`,
+			RcaStartTime:      base.Add(time.Minute),
+			RcaEndTime:        base.Add(2 * time.Minute),
+			RcaStatus:         domain.RcaStatusSuccess,
+			RcaProgress:       &domain.RCAProgress{},
+		}
+		fp := domain.FaultPointObject{
+			FaultID: 11, EntityObjectID: "host-1", FaultMode: "cpu_saturation", RelationEventIDs: []uint64{110},
+			FaultLevel: domain.SeverityWarning, FaultOccurTime: base.Add(30 * time.Minute), FaultLatestTime: base.Add(40 * time.Minute),
+		}
+
+		Convey("重开问题并关联故障点", func() {
+			var saved domain.Problem
+			patches.ApplyMethod(factory.Problems(), "Upsert", func(_ *opensearch.ProblemStore, _ context.Context, p domain.Problem) error {
+				saved = p
+				return nil
+			})
+
+			reopened, err := stage.reopenProblem(ctx, problem, fp)
+
+			So(err, ShouldBeNil)
+			So(saved.ProblemID, ShouldEqual, 1)
+			So(reopened.ProblemStatus, ShouldEqual, domain.ProblemStatusOpen)
+			So(reopened.ProblemCloseType, ShouldBeNil)
+			So(reopened.ProblemCloseTime, ShouldBeNil)
+			So(reopened.ProblemClosedBy, ShouldBeEmpty)
+			So(reopened.RecurrenceCount, ShouldEqual, 2)
+			So(reopened.RelationIDs, ShouldResemble, []uint64{10, 11})
+			So(reopened.RelationEventIDs, ShouldResemble, []uint64{100, 110})
+			So(reopened.FaultFingerprints, ShouldResemble, []string{"host-1/cpu_saturation"})
+			So(reopened.ProblemLevel, ShouldEqual, domain.SeverityWarning)
+			So(reopened.ProblemDuration, ShouldEqual, 40*60)
+		})
+
+		Convey("重开时清空上一轮根因分析结果", func() {
+			patches.ApplyMethod(factory.Problems(), "Upsert", func(_ *opensearch.ProblemStore, _ context.Context, _ domain.Problem) error {
+				return nil
+			})
+
+			reopened, err := stage.reopenProblem(ctx, problem, fp)
+
+			So(err, ShouldBeNil)
+			So(reopened.RcaStatus, ShouldEqual, domain.RcaStatusPending)
+			So(reopened.RootCauseObjectID, ShouldBeEmpty)
+			So(reopened.RootCauseFaultID, ShouldEqual, 0)
+			So(reopened.RcaResults, ShouldBeEmpty)
+			So(reopened.RcaStartTime.IsZero(), ShouldBeTrue)
+			So(reopened.RcaEndTime.IsZero(), ShouldBeTrue)
+			So(reopened.RcaProgress, ShouldBeNil)
+		})
+
+		Convey("保存失败返回错误", func() {
+			patches.ApplyMethod(factory.Problems(), "Upsert", func(_ *opensearch.ProblemStore, _ context.Context, _ domain.Problem) error {
+				return errors.New("index failed")
+			})
+
+			reopened, err := stage.reopenProblem(ctx, problem, fp)
+
+			So(err, ShouldNotBeNil)
+			So(reopened, ShouldBeNil)
+			So(err.Error(), ShouldContainSubstring, "重开问题失败")
+		})
+	})
+}
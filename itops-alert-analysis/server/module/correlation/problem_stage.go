@@ -3,6 +3,7 @@ package correlation
 import (
 	"context"
 	"encoding/json"
+	"sync"
 	"time"
 
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
@@ -26,30 +27,50 @@ const (
 
 // ProblemEventMessage 问题事件消息
 type ProblemEventMessage struct {
-	ProblemID uint64 `json:"problem_id"`
+	ProblemID     uint64                  `json:"problem_id"`
+	EventType     domain.ProblemEventType `json:"event_type,omitempty"`
+	ProblemLevel  domain.Severity         `json:"problem_level,omitempty"`
+	PreviousLevel domain.Severity         `json:"previous_level,omitempty"` // 仅 level_changed 事件
 }
 
 // ProblemStage 负责故障点合并、问题生命周期与 RCA。
 type ProblemStage struct {
-	cfgManager     *config.ConfigManager
+	cfgManager     config.Provider
 	repoFactory    *opensearch.RepositoryFactory
 	kafkaProducer  core.KafkaProducer
 	genID          *idgen.Generator
 	spatialChecker *dip.SpatialChecker
+	correlator     *Correlator
+
+	// aggregateMu 串行执行问题聚合。并行消费按实体划分通道，同一新问题的多个实体的故障点
+	// 可能同时查找候选问题、都未找到而各自创建问题，查找与创建需在同一临界区内完成。
+	aggregateMu sync.Mutex
 }
 
-func NewProblemStage(cfgManager *config.ConfigManager, repoFactory *opensearch.RepositoryFactory, kafkaProducer core.KafkaProducer, spatialChecker *dip.SpatialChecker) *ProblemStage {
+func NewProblemStage(cfgManager config.Provider, repoFactory *opensearch.RepositoryFactory, kafkaProducer core.KafkaProducer, spatialChecker *dip.SpatialChecker) *ProblemStage {
 	return &ProblemStage{
 		cfgManager:     cfgManager,
 		repoFactory:    repoFactory,
 		kafkaProducer:  kafkaProducer,
 		genID:          idgen.New(),
 		spatialChecker: spatialChecker,
+		correlator:     NewCorrelator(cfgManager, repoFactory, spatialChecker),
 	}
 }
 
-// HandleFaultPoint 接收故障点，合并/创建问题。
+// HandleFaultPoint 接收故障点，合并/创建问题，同一时刻只处理一个故障点。
+// 候选问题在读取后被其他写入修改时（版本冲突），重新查找候选问题并重试。
 func (s *ProblemStage) HandleFaultPoint(ctx context.Context, fp domain.FaultPointObject) error {
+	s.aggregateMu.Lock()
+	defer s.aggregateMu.Unlock()
+
+	return opensearch.RetryOnConflict(ctx, func() error {
+		return s.handleFaultPoint(ctx, fp)
+	})
+}
+
+// handleFaultPoint 执行一次故障点合并/创建问题，首次写入问题前不产生副作用，冲突时可整体重试。
+func (s *ProblemStage) handleFaultPoint(ctx context.Context, fp domain.FaultPointObject) error {
 	// 1. 计算时间窗口
 	windowEnd := fp.FaultLatestTime
 	expirationStartTime := windowEnd.Add(-s.cfgManager.GetConfig().AppConfig.Problem.Expiration.ExpirationTime)
@@ -63,23 +84,20 @@ func (s *ProblemStage) HandleFaultPoint(ctx context.Context, fp domain.FaultPoin
 	log.Infof("为故障点:%d，查询到问题数量:%d", fp.FaultID, len(correlatedProblems))
 
 	var targetProblems []domain.Problem
+	var decisions []domain.CorrelationDecision
 	if len(correlatedProblems) > 0 {
-		// 3.1 空间相关性判断
-		//spatiallyCorrelatedProblems 表示该故障点已经关联了哪些问题？
-		spatiallyCorrelatedProblems, err := s.spatialChecker.FilterCorrelatedProblems(ctx, fp, correlatedProblems)
+		// 3. 按配置的策略组合（空间/时间突发/语义）判断相关性
+		targetProblems, decisions, err = s.correlator.Correlate(ctx, fp, correlatedProblems)
 		if err != nil {
-			return errors.Wrap(err, "空间相关性判断失败")
-		}
-		// 存在空间相关性的并且开启了语义相关性的那么就开启语义相关性的处理
-		if len(spatiallyCorrelatedProblems) > 0 {
-			// 未开启语义相关性，直接使用空间相关的问题
-			targetProblems = spatiallyCorrelatedProblems
-			log.Infof("语义相关性未启用，直接使用 %d 个空间相关的问题", len(targetProblems))
+			return err
 		}
+		log.Infof("%d 个候选问题中，%d 个与故障点 %d 相关", len(correlatedProblems), len(targetProblems), fp.FaultID)
 	}
 
 	var problemID uint64
 	var eventsToUpdate []uint64 // 需要回写 problem_id 的事件列表
+	var records []domain.CorrelationRecord
+	now := timex.NowLocalTime()
 
 	if len(targetProblems) > 0 {
 		// 4. 合并到已有问题
@@ -91,8 +109,26 @@ func (s *ProblemStage) HandleFaultPoint(ctx context.Context, fp domain.FaultPoin
 		problemID = mergedProblem.ProblemID
 		// 合并场景：需要更新合并后问题的所有事件（因为主问题原有的事件也需要更新 problem_id）
 		eventsToUpdate = mergedProblem.RelationEventIDs
+		records = buildCorrelationRecords(fp, problemID, targetProblems, decisions, now)
+	} else if reopen, previous := s.findRecurrence(ctx, fp); reopen != nil {
+		// 5. 相同指纹在重开窗口内再次发生，重开已关闭的问题
+		log.Infof("故障点 %d 复发，重开问题 %d", fp.FaultID, reopen.ProblemID)
+		reopened, err := s.reopenProblem(ctx, *reopen, fp)
+		if err != nil {
+			return err
+		}
+		problemID = reopened.ProblemID
+		eventsToUpdate = fp.RelationEventIDs
+		records = []domain.CorrelationRecord{{
+			RecordType: domain.CorrelationRecordReopen,
+			ProblemID:  problemID,
+			FaultID:    fp.FaultID,
+			Strategy:   domain.CorrelationStrategyFingerprint,
+			Score:      1,
+			CreateTime: now,
+		}}
 	} else {
-		// 5. 创建新问题
+		// 6. 创建新问题，复发时关联上一个问题
 		problemID = s.genID.NextID()
 		log.Infof("为故障点 %d 创建新问题 %d", fp.FaultID, problemID)
 
@@ -109,6 +145,13 @@ func (s *ProblemStage) HandleFaultPoint(ctx context.Context, fp domain.FaultPoin
 			AffectedEntityIDs:      []string{fp.EntityObjectID},
 			RelationIDs:            []uint64{fp.FaultID},
 			RelationEventIDs:       fp.RelationEventIDs,
+			FaultFingerprints:      []string{faultFingerprint(fp)},
+			RcaStatus:              domain.RcaStatusPending,
+		}
+		if previous != nil {
+			log.Infof("问题 %d 为问题 %d 的复发", problemID, previous.ProblemID)
+			newProblem.RecurrenceOf = previous.ProblemID
+			newProblem.RecurrenceCount = previous.RecurrenceCount + 1
 		}
 
 		if err := s.repoFactory.Problems().Upsert(ctx, newProblem); err != nil {
@@ -116,19 +159,34 @@ func (s *ProblemStage) HandleFaultPoint(ctx context.Context, fp domain.FaultPoin
 		}
 		// 创建场景：只需更新当前故障点的事件
 		eventsToUpdate = fp.RelationEventIDs
+		records = buildCorrelationRecords(fp, problemID, nil, nil, now)
+		if previous != nil {
+			records = append(records, domain.CorrelationRecord{
+				RecordType:      domain.CorrelationRecordRecurrence,
+				ProblemID:       problemID,
+				FaultID:         fp.FaultID,
+				SourceProblemID: previous.ProblemID,
+				Strategy:        domain.CorrelationStrategyFingerprint,
+				Score:           1,
+				CreateTime:      now,
+			})
+		}
 	}
+	// 记录故障点关联、问题合并、重开与复发的依据，失败不影响主流程
+	s.saveCorrelationRecords(ctx, records)
+
 	// 发布问题创建事件到 Kafka，由 RCA 模块订阅处理
 	if err := s.publishProblemEvent(ctx, problemID); err != nil {
 		log.Infof("发布问题事件失败 problem_id=%d: %v", problemID, err)
 	}
 
-	// 6. 将 problem_id 回写到故障点
+	// 7. 将 problem_id 回写到故障点
 	log.Debugf("回写故障点索引:%d,问题id:%d", fp.FaultID, problemID)
 	if err := s.repoFactory.FaultPoints().UpdateProblemID(ctx, []uint64{fp.FaultID}, problemID); err != nil {
 		return errors.Wrap(err, "回写 problem_id 到故障点失败")
 	}
 
-	// 7. 将 problem_id 回写到所有关联的事件
+	// 8. 将 problem_id 回写到所有关联的事件
 	log.Debugf("回写原始事件索引:%+v,问题id:%d", eventsToUpdate, problemID)
 	if err := s.repoFactory.RawEvents().UpdateProblemID(ctx, eventsToUpdate, problemID); err != nil {
 		return errors.Wrapf(err, "回写 problem_id 到事件失败,事件ID：%+v", fp.RelationEventIDs)
@@ -154,34 +212,7 @@ func (s *ProblemStage) mergeFaultPointIntoProblems(ctx context.Context, fp domai
 	// 合并其他问题到主问题
 	for _, problem := range otherProblems {
 		log.Infof("合并问题 %d 到主问题 %d", problem.ProblemID, mainProblem.ProblemID)
-
-		// 合并故障点列表
-		for _, fpID := range problem.RelationIDs {
-			mainProblem.RelationIDs = slice.AppendUniqueUint64(mainProblem.RelationIDs, fpID)
-		}
-
-		// 合并事件列表
-		for _, eventID := range problem.RelationEventIDs {
-			mainProblem.RelationEventIDs = slice.AppendUniqueUint64(mainProblem.RelationEventIDs, eventID)
-		}
-
-		// 合并受影响的实体列表
-		for _, entityID := range problem.AffectedEntityIDs {
-			mainProblem.AffectedEntityIDs = slice.AppendUniqueString(mainProblem.AffectedEntityIDs, entityID)
-		}
-
-		// 更新时间范围
-		if problem.ProblemOccurTime.Before(mainProblem.ProblemOccurTime) {
-			mainProblem.ProblemOccurTime = problem.ProblemOccurTime
-		}
-		if problem.ProblemLatestTime.After(mainProblem.ProblemLatestTime) {
-			mainProblem.ProblemLatestTime = problem.ProblemLatestTime
-		}
-
-		// 更新问题等级（值越小等级越高）
-		if problem.ProblemLevel < mainProblem.ProblemLevel {
-			mainProblem.ProblemLevel = problem.ProblemLevel
-		}
+		absorbProblem(&mainProblem, problem)
 	}
 
 	// 将当前故障点也加入主问题
@@ -190,6 +221,7 @@ func (s *ProblemStage) mergeFaultPointIntoProblems(ctx context.Context, fp domai
 		mainProblem.RelationEventIDs = slice.AppendUniqueUint64(mainProblem.RelationEventIDs, eventID)
 	}
 	mainProblem.AffectedEntityIDs = slice.AppendUniqueString(mainProblem.AffectedEntityIDs, fp.EntityObjectID)
+	mainProblem.FaultFingerprints = slice.AppendUniqueString(mainProblem.FaultFingerprints, faultFingerprint(fp))
 
 	if fp.FaultLatestTime.After(mainProblem.ProblemLatestTime) {
 		mainProblem.ProblemLatestTime = fp.FaultLatestTime
@@ -211,38 +243,213 @@ func (s *ProblemStage) mergeFaultPointIntoProblems(ctx context.Context, fp domai
 
 	// 处理被合并的问题（更新关联关系并关闭）
 	for _, problem := range otherProblems {
-		if err := s.repoFactory.FaultPoints().UpdateProblemID(ctx, problem.RelationIDs, mainProblem.ProblemID); err != nil {
-			log.Infof("更新故障点 problem_id 失败（问题 %d）: %v", problem.ProblemID, err)
-		}
-		if err := s.repoFactory.RawEvents().UpdateProblemID(ctx, problem.RelationEventIDs, mainProblem.ProblemID); err != nil {
-			log.Infof("更新事件 problem_id 失败（问题 %d）: %v", problem.ProblemID, err)
+		s.retireMergedProblem(ctx, problem, mainProblem.ProblemID, domain.ProblemCloseTypeSystem, "合并到问题"+cast.ToString(mainProblem.ProblemID), "system")
+	}
+
+	if len(otherProblems) > 0 {
+		log.Infof("已将 %d 个问题合并为问题 %d", len(problems), mainProblem.ProblemID)
+	}
+	return &mainProblem, nil
+}
+
+// saveCorrelationRecords 写入关联记录。
+func (s *ProblemStage) saveCorrelationRecords(ctx context.Context, records []domain.CorrelationRecord) {
+	for _, record := range records {
+		record.RecordID = s.genID.NextID()
+		if err := s.repoFactory.CorrelationRecords().Upsert(ctx, record); err != nil {
+			log.Warnf("写入关联记录失败 problem_id=%d fault_id=%d: %v", record.ProblemID, record.FaultID, err)
 		}
+	}
+}
 
-		//清空被合并问题的关联数据（故障点、事件、RCA结果等）
-		if err := s.repoFactory.Problems().ClearMergedProblemData(ctx, problem.ProblemID); err != nil {
-			log.Infof("清空被合并问题 %d 的关联数据失败: %v", problem.ProblemID, err)
+// buildCorrelationRecords 根据合并决策生成关联记录：
+// 未命中任何问题时记录创建；命中时记录故障点关联到主问题，其余命中的问题记录为合并到主问题。
+// 故障点已关联在主问题上时不重复记录。
+func buildCorrelationRecords(
+	fp domain.FaultPointObject,
+	problemID uint64,
+	targets []domain.Problem,
+	decisions []domain.CorrelationDecision,
+	now time.Time,
+) []domain.CorrelationRecord {
+	if len(targets) == 0 {
+		return []domain.CorrelationRecord{{
+			RecordType: domain.CorrelationRecordCreated,
+			ProblemID:  problemID,
+			FaultID:    fp.FaultID,
+			CreateTime: now,
+		}}
+	}
+
+	byProblem := make(map[uint64]domain.CorrelationDecision, len(decisions))
+	for _, d := range decisions {
+		byProblem[d.ProblemID] = d
+	}
+
+	var records []domain.CorrelationRecord
+	for _, target := range targets {
+		record := correlationRecordFromDecision(byProblem[target.ProblemID])
+		record.ProblemID = problemID
+		record.FaultID = fp.FaultID
+		record.CreateTime = now
+		if target.ProblemID == problemID {
+			if fp.ProblemID == problemID {
+				continue
+			}
+			record.RecordType = domain.CorrelationRecordFaultPoint
 		} else {
-			log.Infof("已清空被合并问题 %d 的关联数据", problem.ProblemID)
+			record.RecordType = domain.CorrelationRecordProblemMerge
+			record.SourceProblemID = target.ProblemID
 		}
+		records = append(records, record)
+	}
+	return records
+}
 
-		//关闭被合并的问题
-		if err := s.repoFactory.Problems().MarkClosed(ctx, problem.ProblemID, domain.ProblemCloseTypeSystem, domain.ProblemStatusMerged, 0, "合并到问题"+cast.ToString(mainProblem.ProblemID), "system"); err != nil {
-			log.Infof("关闭被合并问题 %d 失败: %v", problem.ProblemID, err)
+// correlationRecordFromDecision 从合并决策中提取得分最高的命中策略与空间路径。
+func correlationRecordFromDecision(d domain.CorrelationDecision) domain.CorrelationRecord {
+	record := domain.CorrelationRecord{Combine: d.Combine, Scores: d.Scores}
+	for _, score := range d.Scores {
+		if len(score.EntityPath) > 0 {
+			record.EntityPath = score.EntityPath
+		}
+		if score.Matched && (record.Strategy == "" || score.Score > record.Score) {
+			record.Strategy = score.Strategy
+			record.Score = score.Score
 		}
 	}
+	return record
+}
 
-	if len(otherProblems) > 0 {
-		log.Infof("已将 %d 个问题合并为问题 %d", len(problems), mainProblem.ProblemID)
+// absorbProblem 将 other 的故障点、事件、受影响对象、时间范围与等级并入 main。
+func absorbProblem(main *domain.Problem, other domain.Problem) {
+	// 合并故障点列表
+	for _, fpID := range other.RelationIDs {
+		main.RelationIDs = slice.AppendUniqueUint64(main.RelationIDs, fpID)
 	}
-	return &mainProblem, nil
+
+	// 合并事件列表
+	for _, eventID := range other.RelationEventIDs {
+		main.RelationEventIDs = slice.AppendUniqueUint64(main.RelationEventIDs, eventID)
+	}
+
+	// 合并受影响的实体列表
+	for _, entityID := range other.AffectedEntityIDs {
+		main.AffectedEntityIDs = slice.AppendUniqueString(main.AffectedEntityIDs, entityID)
+	}
+	for _, fingerprint := range other.FaultFingerprints {
+		main.FaultFingerprints = slice.AppendUniqueString(main.FaultFingerprints, fingerprint)
+	}
+	if other.RecurrenceCount > main.RecurrenceCount {
+		main.RecurrenceCount = other.RecurrenceCount
+	}
+
+	// 更新时间范围
+	if other.ProblemOccurTime.Before(main.ProblemOccurTime) {
+		main.ProblemOccurTime = other.ProblemOccurTime
+	}
+	if other.ProblemLatestTime.After(main.ProblemLatestTime) {
+		main.ProblemLatestTime = other.ProblemLatestTime
+	}
+
+	// 更新问题等级（值越小等级越高）
+	if other.ProblemLevel < main.ProblemLevel {
+		main.ProblemLevel = other.ProblemLevel
+	}
+}
+
+// retireMergedProblem 按版本清空被合并问题的关联数据并以 merged 状态关闭，读取后被并发修改时重新读取再关闭；
+// 关闭时问题上的故障点与事件改写到主问题，主问题保存后才并入被合并问题的部分同时追加到主问题。
+// 各步骤失败只记录日志，主问题已保存，不回滚。
+func (s *ProblemStage) retireMergedProblem(ctx context.Context, problem domain.Problem, mainProblemID uint64, closeType domain.ProblemCloseType, notes, by string) {
+	var retired domain.Problem
+	err := opensearch.RetryOnConflict(ctx, func() error {
+		problems, err := s.repoFactory.Problems().QueryByIDs(ctx, []uint64{problem.ProblemID})
+		if err != nil {
+			return errors.Wrap(err, "查询被合并问题失败")
+		}
+		if len(problems) == 0 {
+			return errors.Wrapf(domain.ErrProblemNotFound, "问题 %d", problem.ProblemID)
+		}
+		retired = problems[0]
+		return s.repoFactory.Problems().CloseMerged(ctx, retired, closeType, notes, by)
+	})
+	if err != nil {
+		log.Infof("关闭被合并问题 %d 失败: %v", problem.ProblemID, err)
+		return
+	}
+	log.Infof("已清空并关闭被合并问题 %d", problem.ProblemID)
+
+	if err := s.repoFactory.FaultPoints().UpdateProblemID(ctx, retired.RelationIDs, mainProblemID); err != nil {
+		log.Infof("更新故障点 problem_id 失败（问题 %d）: %v", problem.ProblemID, err)
+	}
+	if err := s.repoFactory.RawEvents().UpdateProblemID(ctx, retired.RelationEventIDs, mainProblemID); err != nil {
+		log.Infof("更新事件 problem_id 失败（问题 %d）: %v", problem.ProblemID, err)
+	}
+
+	late := lateAdditions(problem, retired)
+	if len(late.RelationIDs) == 0 && len(late.RelationEventIDs) == 0 {
+		return
+	}
+	err = opensearch.RetryOnConflict(ctx, func() error {
+		problems, err := s.repoFactory.Problems().QueryByIDs(ctx, []uint64{mainProblemID})
+		if err != nil {
+			return errors.Wrap(err, "查询主问题失败")
+		}
+		if len(problems) == 0 {
+			return errors.Wrapf(domain.ErrProblemNotFound, "问题 %d", mainProblemID)
+		}
+		mainProblem := problems[0]
+		absorbProblem(&mainProblem, late)
+		mainProblem.ProblemDuration = timex.AbsSecondsBetween(mainProblem.ProblemLatestTime, mainProblem.ProblemOccurTime)
+		mainProblem.ProblemUpdateTime = timex.NowLocalTime().Local()
+		return s.repoFactory.Problems().Upsert(ctx, mainProblem)
+	})
+	if err != nil {
+		log.Infof("追加问题 %d 新并入的 %d 个故障点到主问题 %d 失败: %v", problem.ProblemID, len(late.RelationIDs), mainProblemID, err)
+		return
+	}
+	log.Infof("问题 %d 在合并期间新并入的 %d 个故障点已追加到主问题 %d", problem.ProblemID, len(late.RelationIDs), mainProblemID)
+}
+
+// lateAdditions 返回 current 中有而 absorbed（主问题合并时读取的版本）中没有的故障点与事件，
+// 以及 current 的时间范围、等级等可并入主问题的信息。
+func lateAdditions(absorbed, current domain.Problem) domain.Problem {
+	late := current
+	late.RelationIDs = nil
+	late.RelationEventIDs = nil
+	late.AffectedEntityIDs = nil
+	late.FaultFingerprints = nil
+	for _, id := range current.RelationIDs {
+		if !slice.ContainsUint64(absorbed.RelationIDs, id) {
+			late.RelationIDs = append(late.RelationIDs, id)
+		}
+	}
+	for _, id := range current.RelationEventIDs {
+		if !slice.ContainsUint64(absorbed.RelationEventIDs, id) {
+			late.RelationEventIDs = append(late.RelationEventIDs, id)
+		}
+	}
+	if len(late.RelationIDs) > 0 || len(late.RelationEventIDs) > 0 {
+		late.AffectedEntityIDs = current.AffectedEntityIDs
+		late.FaultFingerprints = current.FaultFingerprints
+	}
+	return late
 }
 
-// HandleRCACallback 处理 RCA 模块的异步回调，更新问题根因。
+// HandleRCACallback 处理 RCA 模块的异步回调：分析中记录进度，失败时记录失败状态，完成后更新问题根因。
 func (s *ProblemStage) HandleRCACallback(ctx context.Context, cb domain.RCACallback) error {
 	log.Debugf("收到rca回调,问题id:%d,内容:%s", cb.ProblemID, utils.JsonEncode(cb))
 	if cb.InProgress {
-		// RCA 仍在运行，无需更新。
-		return nil
+		// RCA 仍在运行，只记录进度
+		if cb.Progress == nil {
+			return nil
+		}
+		return s.repoFactory.Problems().UpdateRCAProgress(ctx, cb.ProblemID, *cb.Progress)
+	}
+	if cb.RcaStatus == domain.RcaStatusFailed {
+		// 分析失败只记录状态，保留上一次分析的根因与问题名称
+		return s.repoFactory.Problems().UpdateRCAStatus(ctx, cb.ProblemID, domain.RcaStatusFailed)
 	}
 	return s.repoFactory.Problems().UpdateRootCause(ctx, cb.ProblemID, cb)
 }
@@ -253,7 +460,15 @@ func (s *ProblemStage) CloseProblem(ctx context.Context, problemID uint64, close
 }
 
 // HandleFaultPointRecovered 处理故障点恢复，检查问题是否可以恢复
+// 问题在读取后被其他写入修改时（版本冲突），重新读取问题并重试。
 func (s *ProblemStage) HandleFaultPointRecovered(ctx context.Context, faultID uint64) error {
+	return opensearch.RetryOnConflict(ctx, func() error {
+		return s.handleFaultPointRecovered(ctx, faultID)
+	})
+}
+
+// handleFaultPointRecovered 执行一次问题恢复检查。
+func (s *ProblemStage) handleFaultPointRecovered(ctx context.Context, faultID uint64) error {
 	//查询故障点获取关联的 problem_id
 	faultPoints, err := s.repoFactory.FaultPoints().QueryByIDs(ctx, []uint64{faultID})
 	if err != nil {
@@ -287,15 +502,15 @@ func (s *ProblemStage) HandleFaultPointRecovered(ctx context.Context, faultID ui
 		log.Infof("问题 %d 状态不是 open (%s)，跳过恢复检查", problem.ProblemID, problem.ProblemStatus)
 		return nil
 	}
-	// 更新问题的 RelationEventIDs
-	allEventIDs := problem.RelationEventIDs
+	// 更新问题的 RelationEventIDs，按读取时的版本写入，避免覆盖并发追加的事件
 	for _, d := range fp.RelationEventIDs {
-		allEventIDs = slice.AppendUniqueUint64(allEventIDs, d)
+		problem.RelationEventIDs = slice.AppendUniqueUint64(problem.RelationEventIDs, d)
 	}
-	if err := s.repoFactory.Problems().UpdateRelationEventIDs(ctx, problem.ProblemID, allEventIDs); err != nil {
+	problem.ProblemUpdateTime = timex.NowLocalTime().Local()
+	if err := s.repoFactory.Problems().Upsert(ctx, problem); err != nil {
 		return errors.Wrap(err, "更新问题 RelationEventIDs 失败")
 	}
-	log.Infof("已更新问题 %d 的 RelationEventIDs，总事件数: %d", problem.ProblemID, len(allEventIDs))
+	log.Infof("已更新问题 %d 的 RelationEventIDs，总事件数: %d", problem.ProblemID, len(problem.RelationEventIDs))
 
 	isRecovered, err := s.checkFaultPointIsRecovered(ctx, problem.RelationIDs)
 	if err != nil {
@@ -314,6 +529,75 @@ func (s *ProblemStage) HandleFaultPointRecovered(ctx context.Context, faultID ui
 	return nil
 }
 
+// HandleFaultPointLevelChanged 故障点等级变化后同步问题等级，变化时发布 level_changed 问题事件。
+// 升级时问题等级取两者中较高的等级；回落时按问题下仍处于发生状态的故障点重新计算。
+func (s *ProblemStage) HandleFaultPointLevelChanged(ctx context.Context, fp domain.FaultPointObject) error {
+	if fp.ProblemID == 0 {
+		return nil
+	}
+	problems, err := s.repoFactory.Problems().QueryByIDs(ctx, []uint64{fp.ProblemID})
+	if err != nil {
+		return errors.Wrap(err, "查询问题失败")
+	}
+	if len(problems) == 0 || problems[0].ProblemStatus != domain.ProblemStatusOpen {
+		log.Infof("问题 %d 不存在或未打开，跳过等级同步", fp.ProblemID)
+		return nil
+	}
+	problem := problems[0]
+
+	level := problem.ProblemLevel
+	if level == 0 || fp.FaultLevel < level {
+		level = fp.FaultLevel
+	} else if fp.FaultLevel > level {
+		level, err = s.activeProblemLevel(ctx, problem.RelationIDs, fp)
+		if err != nil {
+			return err
+		}
+	}
+	if level == problem.ProblemLevel {
+		return nil
+	}
+
+	log.Infof("故障点 %d 等级变化，问题 %d 等级 %d -> %d", fp.FaultID, problem.ProblemID, problem.ProblemLevel, level)
+	if err := s.repoFactory.Problems().UpdateLevel(ctx, problem.ProblemID, level); err != nil {
+		return errors.Wrap(err, "更新问题等级失败")
+	}
+	msg := ProblemEventMessage{
+		ProblemID:     problem.ProblemID,
+		EventType:     domain.ProblemEventLevelChanged,
+		ProblemLevel:  level,
+		PreviousLevel: problem.ProblemLevel,
+	}
+	if err := s.publishProblemMessage(ctx, msg); err != nil {
+		log.Infof("发布问题等级变化事件失败 problem_id=%d: %v", problem.ProblemID, err)
+	}
+	return nil
+}
+
+// activeProblemLevel 计算问题下仍处于发生状态的故障点的最高等级（值越小等级越高），fp 使用最新等级。
+func (s *ProblemStage) activeProblemLevel(ctx context.Context, faultIDs []uint64, fp domain.FaultPointObject) (domain.Severity, error) {
+	level := fp.FaultLevel
+	others := make([]uint64, 0, len(faultIDs))
+	for _, id := range faultIDs {
+		if id != fp.FaultID {
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 {
+		return level, nil
+	}
+	faultPoints, err := s.repoFactory.FaultPoints().QueryByIDs(ctx, others)
+	if err != nil {
+		return 0, errors.Wrap(err, "查询问题关联故障点失败")
+	}
+	for _, other := range faultPoints {
+		if other.FaultStatus == domain.FaultStatusOccurred && other.FaultLevel > 0 && other.FaultLevel < level {
+			level = other.FaultLevel
+		}
+	}
+	return level, nil
+}
+
 // checkFaultPointIsRecovered 检查故障点是否完全关闭
 func (s *ProblemStage) checkFaultPointIsRecovered(ctx context.Context, relatedFpIDs []uint64) (bool, error) {
 	//查询问题关联的所有故障点
@@ -397,15 +681,20 @@ func (s *ProblemStage) checkAndExpireProblems(ctx context.Context) error {
 
 // publishProblemEvent 发布问题事件到 Kafka
 func (s *ProblemStage) publishProblemEvent(ctx context.Context, problemID uint64) error {
+	return s.publishProblemMessage(ctx, ProblemEventMessage{
+		ProblemID: problemID,
+		EventType: domain.ProblemEventUpserted,
+	})
+}
+
+// publishProblemMessage 发布指定类型的问题事件到 Kafka
+func (s *ProblemStage) publishProblemMessage(ctx context.Context, msg ProblemEventMessage) error {
+	problemID := msg.ProblemID
 	if s.kafkaProducer == nil {
 		log.Infof("Kafka Producer 未配置，跳过发布问题事件 problem_id=%d", problemID)
 		return nil
 	}
 
-	msg := ProblemEventMessage{
-		ProblemID: problemID,
-	}
-
 	body, err := json.Marshal(msg)
 	if err != nil {
 		return errors.Wrap(err, "序列化问题事件失败")
@@ -416,7 +705,7 @@ func (s *ProblemStage) publishProblemEvent(ctx context.Context, problemID uint64
 		return errors.Wrap(err, "发送 Kafka 消息失败")
 	}
 
-	log.Infof("已发布问题事件到 Kafka，problem_id=%d, topic=%s", problemID, ProblemEventTopic)
+	log.Infof("已发布问题事件到 Kafka，problem_id=%d, event_type=%s, topic=%s", problemID, msg.EventType, ProblemEventTopic)
 	return nil
 }
 
@@ -2,12 +2,20 @@ package correlation
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config/configtest"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/kafka"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch/opensearchtest"
 	"github.com/agiledragon/gomonkey/v2"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -67,6 +75,70 @@ func TestProblemStage_HandleRCACallback(t *testing.T) {
 
 			So(err, ShouldBeNil)
 		})
+
+		Convey("RCA 进行中时记录分析进度", func() {
+			ctx := context.Background()
+			factory := opensearch.NewRepositoryFactory(opensearchtest.NewClient(opensearchtest.NewTransport()))
+			stage := NewProblemStage(newTestConfigManager(), factory, nil, nil)
+			So(factory.Problems().Upsert(ctx, domain.Problem{ProblemID: 12345, ProblemStatus: domain.ProblemStatusOpen, RcaStatus: domain.RcaStatusPending}), ShouldBeNil)
+
+			cb := domain.RCACallback{
+				ProblemID:  12345,
+				InProgress: true,
+				Progress: &domain.RCAProgress{
+					Step:          domain.RCAStepPairsAnalyzing,
+					Percent:       55,
+					TotalPairs:    4,
+					AnalyzedPairs: 2,
+				},
+			}
+
+			err := stage.HandleRCACallback(ctx, cb)
+
+			So(err, ShouldBeNil)
+			problems, err := factory.Problems().QueryByIDs(ctx, []uint64{12345})
+			So(err, ShouldBeNil)
+			So(problems, ShouldHaveLength, 1)
+			So(problems[0].RcaStatus, ShouldEqual, domain.RcaStatusRunning)
+			So(problems[0].RcaProgress, ShouldNotBeNil)
+			So(problems[0].RcaProgress.AnalyzedPairs, ShouldEqual, 2)
+			So(problems[0].RcaProgress.TotalPairs, ShouldEqual, 4)
+		})
+
+		Convey("问题已合并或关闭时不记录分析进度", func() {
+			ctx := context.Background()
+			factory := opensearch.NewRepositoryFactory(opensearchtest.NewClient(opensearchtest.NewTransport()))
+			stage := NewProblemStage(newTestConfigManager(), factory, nil, nil)
+			So(factory.Problems().Upsert(ctx, domain.Problem{ProblemID: 12345, ProblemStatus: domain.ProblemStatusMerged}), ShouldBeNil)
+
+			err := stage.HandleRCACallback(ctx, domain.RCACallback{
+				ProblemID:  12345,
+				InProgress: true,
+				Progress:   &domain.RCAProgress{Step: domain.RCAStepGraphRecalled, Percent: 20},
+			})
+
+			So(err, ShouldBeNil)
+			problems, err := factory.Problems().QueryByIDs(ctx, []uint64{12345})
+			So(err, ShouldBeNil)
+			So(problems[0].RcaStatus, ShouldNotEqual, domain.RcaStatusRunning)
+			So(problems[0].RcaProgress, ShouldBeNil)
+		})
+
+		Convey("RCA 失败时只记录失败状态，保留已有根因", func() {
+			ctx := context.Background()
+			factory := opensearch.NewRepositoryFactory(opensearchtest.NewClient(opensearchtest.NewTransport()))
+			stage := NewProblemStage(newTestConfigManager(), factory, nil, nil)
+			So(factory.Problems().Upsert(ctx, domain.Problem{ProblemID: 12345, ProblemName: "CPU 高", RootCauseObjectID: "host-1", RcaStatus: domain.RcaStatusRunning}), ShouldBeNil)
+
+			err := stage.HandleRCACallback(ctx, domain.RCACallback{ProblemID: 12345, ProblemName: "未知问题", RcaStatus: domain.RcaStatusFailed})
+
+			So(err, ShouldBeNil)
+			problems, err := factory.Problems().QueryByIDs(ctx, []uint64{12345})
+			So(err, ShouldBeNil)
+			So(problems[0].RcaStatus, ShouldEqual, domain.RcaStatusFailed)
+			So(problems[0].ProblemName, ShouldEqual, "CPU 高")
+			So(problems[0].RootCauseObjectID, ShouldEqual, "host-1")
+		})
 	})
 }
 
@@ -88,7 +160,7 @@ func TestProblemStage_Run(t *testing.T) {
 		Convey("失效检查未启用时直接返回", func() {
 			cfg := newTestConfig()
 			cfg.AppConfig.Problem.Expiration.Enabled = false
-			cfgManager := config.NewTestConfigManager(cfg)
+			cfgManager := configtest.NewProvider(cfg)
 			factory := opensearch.NewRepositoryFactory(nil)
 			stage := NewProblemStage(cfgManager, factory, nil, nil)
 
@@ -150,3 +222,190 @@ func TestProblemStage_publishProblemEvent(t *testing.T) {
 		})
 	})
 }
+
+func TestProblemStage_HandleFaultPointLevelChanged(t *testing.T) {
+	Convey("TestProblemStage_HandleFaultPointLevelChanged", t, func() {
+		ctx := context.Background()
+		factory := opensearch.NewRepositoryFactory(nil)
+		producer := &kafka.Producer{}
+		stage := NewProblemStage(newTestConfigManager(), factory, producer, nil)
+
+		problem := domain.Problem{ProblemID: 20, ProblemStatus: domain.ProblemStatusOpen, ProblemLevel: domain.SeverityMajor, RelationIDs: []uint64{10, 11}}
+
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+
+		var levels []domain.Severity
+		var published []ProblemEventMessage
+		patches.ApplyMethod(factory.Problems(), "QueryByIDs", func(_ *opensearch.ProblemStore, _ context.Context, _ []uint64) ([]domain.Problem, error) {
+			return []domain.Problem{problem}, nil
+		})
+		patches.ApplyMethod(factory.Problems(), "UpdateLevel", func(_ *opensearch.ProblemStore, _ context.Context, _ uint64, level domain.Severity) error {
+			levels = append(levels, level)
+			return nil
+		})
+		patches.ApplyMethod(factory.FaultPoints(), "QueryByIDs", func(_ *opensearch.FaultPointStore, _ context.Context, _ []uint64) ([]domain.FaultPointObject, error) {
+			return []domain.FaultPointObject{
+				{FaultID: 11, FaultStatus: domain.FaultStatusOccurred, FaultLevel: domain.SeverityMajor},
+			}, nil
+		})
+		patches.ApplyMethod(producer, "PublishRawEvent", func(_ *kafka.Producer, _ context.Context, _ string, value []byte) error {
+			var msg ProblemEventMessage
+			_ = json.Unmarshal(value, &msg)
+			published = append(published, msg)
+			return nil
+		})
+
+		Convey("故障点升级时问题随之升级并发布等级变化事件", func() {
+			fp := domain.FaultPointObject{FaultID: 10, ProblemID: 20, FaultLevel: domain.SeverityCritical}
+
+			So(stage.HandleFaultPointLevelChanged(ctx, fp), ShouldBeNil)
+
+			So(levels, ShouldResemble, []domain.Severity{domain.SeverityCritical})
+			So(published, ShouldResemble, []ProblemEventMessage{{
+				ProblemID: 20, EventType: domain.ProblemEventLevelChanged,
+				ProblemLevel: domain.SeverityCritical, PreviousLevel: domain.SeverityMajor,
+			}})
+		})
+
+		Convey("故障点回落时按其他发生中的故障点计算问题等级", func() {
+			problem.ProblemLevel = domain.SeverityCritical
+			fp := domain.FaultPointObject{FaultID: 10, ProblemID: 20, FaultLevel: domain.SeverityWarning}
+
+			So(stage.HandleFaultPointLevelChanged(ctx, fp), ShouldBeNil)
+
+			So(levels, ShouldResemble, []domain.Severity{domain.SeverityMajor})
+			So(published, ShouldHaveLength, 1)
+			So(published[0].PreviousLevel, ShouldEqual, domain.SeverityCritical)
+		})
+
+		Convey("问题等级未变化时不更新", func() {
+			fp := domain.FaultPointObject{FaultID: 10, ProblemID: 20, FaultLevel: domain.SeverityWarning}
+
+			So(stage.HandleFaultPointLevelChanged(ctx, fp), ShouldBeNil)
+
+			So(levels, ShouldBeEmpty)
+			So(published, ShouldBeEmpty)
+		})
+
+		Convey("问题已关闭或故障点未关联问题时跳过", func() {
+			problem.ProblemStatus = domain.ProblemStatusClosed
+			So(stage.HandleFaultPointLevelChanged(ctx, domain.FaultPointObject{FaultID: 10, ProblemID: 20, FaultLevel: domain.SeverityEmergency}), ShouldBeNil)
+			So(stage.HandleFaultPointLevelChanged(ctx, domain.FaultPointObject{FaultID: 10, FaultLevel: domain.SeverityEmergency}), ShouldBeNil)
+
+			So(levels, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestProblemStage_HandleFaultPointSerialized(t *testing.T) {
+	Convey("TestProblemStage_HandleFaultPointSerialized", t, func() {
+		factory := opensearch.NewRepositoryFactory(nil)
+		stage := NewProblemStage(newTestConfigManager(), factory, nil, nil)
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+
+		var active, maxActive int32
+		patches.ApplyMethod(factory.Problems(), "FindCorrelated", func(_ *opensearch.ProblemStore, _ context.Context, _ domain.FaultPointObject, _ time.Time) ([]domain.Problem, error) {
+			n := atomic.AddInt32(&active, 1)
+			for {
+				m := atomic.LoadInt32(&maxActive)
+				if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&active, -1)
+			return nil, errors.New("search failed")
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(id uint64) {
+				defer wg.Done()
+				_ = stage.HandleFaultPoint(context.Background(), domain.FaultPointObject{FaultID: id, EntityObjectID: fmt.Sprintf("host-%d", id)})
+			}(uint64(i))
+		}
+		wg.Wait()
+
+		// 不同实体的故障点也不会同时查找候选问题
+		So(atomic.LoadInt32(&maxActive), ShouldEqual, 1)
+	})
+}
+
+func TestBuildCorrelationRecords(t *testing.T) {
+	Convey("TestBuildCorrelationRecords", t, func() {
+		now := time.Now()
+		fp := domain.FaultPointObject{FaultID: 10}
+		spatial := domain.CorrelationScore{Strategy: config.CorrelationSpatial, Score: 0.6, Threshold: 0.5, Matched: true, EntityPath: []string{"pod-1", "node-1"}}
+		semantic := domain.CorrelationScore{Strategy: config.CorrelationSemantic, Score: 0.9, Threshold: 0.6, Matched: true}
+		temporal := domain.CorrelationScore{Strategy: config.CorrelationTemporal, Score: 0.95, Threshold: 0.5}
+		decisions := []domain.CorrelationDecision{
+			{ProblemID: 1, Combine: config.CorrelationCombineAny, Merged: true, Scores: []domain.CorrelationScore{spatial, temporal}},
+			{ProblemID: 2, Combine: config.CorrelationCombineAny, Merged: true, Scores: []domain.CorrelationScore{spatial, semantic}},
+			{ProblemID: 3, Combine: config.CorrelationCombineAny},
+		}
+		targets := []domain.Problem{{ProblemID: 1}, {ProblemID: 2}}
+
+		Convey("未命中问题时记录创建", func() {
+			records := buildCorrelationRecords(fp, 99, nil, decisions, now)
+
+			So(records, ShouldHaveLength, 1)
+			So(records[0].RecordType, ShouldEqual, domain.CorrelationRecordCreated)
+			So(records[0].ProblemID, ShouldEqual, 99)
+			So(records[0].FaultID, ShouldEqual, 10)
+		})
+
+		Convey("命中多个问题时记录故障点关联与问题合并，策略取命中得分最高者", func() {
+			records := buildCorrelationRecords(fp, 1, targets, decisions, now)
+
+			So(records, ShouldHaveLength, 2)
+			So(records[0].RecordType, ShouldEqual, domain.CorrelationRecordFaultPoint)
+			So(records[0].ProblemID, ShouldEqual, 1)
+			So(records[0].Strategy, ShouldEqual, config.CorrelationSpatial)
+			So(records[0].Score, ShouldEqual, 0.6)
+			So(records[0].EntityPath, ShouldResemble, []string{"pod-1", "node-1"})
+			So(records[0].Scores, ShouldHaveLength, 2)
+
+			So(records[1].RecordType, ShouldEqual, domain.CorrelationRecordProblemMerge)
+			So(records[1].ProblemID, ShouldEqual, 1)
+			So(records[1].SourceProblemID, ShouldEqual, 2)
+			So(records[1].Strategy, ShouldEqual, config.CorrelationSemantic)
+			So(records[1].Score, ShouldEqual, 0.9)
+			So(records[1].CreateTime, ShouldEqual, now)
+		})
+
+		Convey("故障点已关联在主问题上时不重复记录", func() {
+			fp.ProblemID = 1
+			records := buildCorrelationRecords(fp, 1, targets[:1], decisions, now)
+
+			So(records, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestProblemStage_saveCorrelationRecords(t *testing.T) {
+	Convey("TestProblemStage_saveCorrelationRecords", t, func() {
+		ctx := context.Background()
+		factory := opensearch.NewRepositoryFactory(nil)
+		stage := NewProblemStage(newTestConfigManager(), factory, nil, nil)
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+
+		var saved []domain.CorrelationRecord
+		patches.ApplyMethod(factory.CorrelationRecords(), "Upsert", func(_ *opensearch.CorrelationRecordStore, _ context.Context, record domain.CorrelationRecord) error {
+			saved = append(saved, record)
+			if record.FaultID == 11 {
+				return errors.New("write failed")
+			}
+			return nil
+		})
+
+		stage.saveCorrelationRecords(ctx, []domain.CorrelationRecord{{FaultID: 11}, {FaultID: 12}})
+
+		So(saved, ShouldHaveLength, 2)
+		So(saved[0].RecordID, ShouldNotEqual, 0)
+		So(saved[1].RecordID, ShouldNotEqual, saved[0].RecordID)
+	})
+}
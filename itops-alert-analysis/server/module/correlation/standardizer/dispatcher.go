@@ -0,0 +1,117 @@
+package standardizer
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"github.com/pkg/errors"
+)
+
+// Sniffer 根据 payload 内容判断是否属于某个数据源。
+type Sniffer func(payload []byte) bool
+
+type sourceCtxKey struct{}
+
+// WithSource 将显式指定的数据源（Kafka header / HTTP 路径）写入 context，供分发标准化器路由。
+func WithSource(ctx context.Context, source string) context.Context {
+	source = normalizeSource(source)
+	if source == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, sourceCtxKey{}, source)
+}
+
+// SourceFromContext 读取 context 中显式指定的数据源。
+func SourceFromContext(ctx context.Context) string {
+	source, _ := ctx.Value(sourceCtxKey{}).(string)
+	return source
+}
+
+func normalizeSource(source string) string {
+	return strings.TrimSpace(strings.ToLower(source))
+}
+
+// dispatchStandardizer 多数据源模式：按显式来源 > payload 嗅探 > 默认来源的顺序选择标准化器，
+// 并将最终选中的数据源记录到 RawEvent.EventSource。
+type dispatchStandardizer struct {
+	sources       []string // 嗅探顺序，与配置中 sources 一致
+	standardizers map[string]Standardizer
+	sniffers      map[string]Sniffer
+	defaultSource string
+}
+
+// Standardize 实现 Standardizer，返回第一条事件。
+func (d *dispatchStandardizer) Standardize(ctx context.Context, payload []byte) (domain.RawEvent, error) {
+	return firstEvent(d.StandardizeBatch(ctx, payload))
+}
+
+// StandardizeBatch 实现 BatchStandardizer，目标标准化器支持批量时返回全部事件。
+func (d *dispatchStandardizer) StandardizeBatch(ctx context.Context, payload []byte) ([]domain.RawEvent, error) {
+	source, err := d.route(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := StandardizeAll(ctx, d.standardizers[source], payload)
+	var batchErr *BatchError
+	if err != nil && !errors.As(err, &batchErr) {
+		return nil, errors.Wrapf(err, "standardize %s event", source)
+	}
+	if len(events) == 0 && batchErr == nil {
+		return nil, errors.Errorf("standardize %s event: no event", source)
+	}
+	for i := range events {
+		events[i].EventSource = source
+	}
+	if batchErr != nil {
+		return events, batchErr
+	}
+	return events, nil
+}
+
+// route 选择数据源：显式来源必须已启用，否则报错；未指定时嗅探 payload，都不匹配时使用默认来源。
+func (d *dispatchStandardizer) route(ctx context.Context, payload []byte) (string, error) {
+	if source := SourceFromContext(ctx); source != "" {
+		if _, ok := d.standardizers[source]; !ok {
+			return "", errors.Errorf("event source not enabled: %s", source)
+		}
+		return source, nil
+	}
+
+	for _, source := range d.sources {
+		if sniff, ok := d.sniffers[source]; ok && sniff(payload) {
+			return source, nil
+		}
+	}
+
+	if _, ok := d.standardizers[d.defaultSource]; ok {
+		return d.defaultSource, nil
+	}
+	return "", errors.New("unable to identify event source")
+}
+
+// jsonHasKeys 判断 payload 是否为包含全部指定字段的 JSON 对象。
+func jsonHasKeys(payload []byte, keys ...string) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return false
+	}
+	for _, key := range keys {
+		if _, ok := fields[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// sniffZabbixWebhook Zabbix webhook 模板固定包含 event_id 与 event_status。
+func sniffZabbixWebhook(payload []byte) bool {
+	return jsonHasKeys(payload, "event_id", "event_status")
+}
+
+// sniffPrometheusAlertmanager Alertmanager webhook 包含 alerts 数组与 groupKey。
+func sniffPrometheusAlertmanager(payload []byte) bool {
+	return jsonHasKeys(payload, "alerts", "groupKey")
+}
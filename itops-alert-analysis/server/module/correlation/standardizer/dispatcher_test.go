@@ -0,0 +1,157 @@
+package standardizer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newTestDispatchConfig(defaultSource string, sources ...string) *config.Config {
+	return &config.Config{
+		AppConfig: config.AppConfig{
+			Ingest: config.IngestConfig{
+				Source: config.Source{Type: defaultSource, Sources: sources},
+			},
+		},
+	}
+}
+
+func newTestDispatchRegistry() *Registry {
+	r := NewRegistry()
+	r.Register("source_a", func(cfg *config.Config, querier ObjectClassQuerier) (Standardizer, error) {
+		return &mockStandardizer{name: "a"}, nil
+	})
+	r.RegisterSniffer("source_a", func(payload []byte) bool { return jsonHasKeys(payload, "a") })
+	r.Register("source_b", func(cfg *config.Config, querier ObjectClassQuerier) (Standardizer, error) {
+		return &mockBatchStandardizer{mockStandardizer: mockStandardizer{name: "b"}, count: 2}, nil
+	})
+	r.RegisterSniffer("source_b", func(payload []byte) bool { return jsonHasKeys(payload, "b") })
+	return r
+}
+
+func TestRegistry_ResolveMulti(t *testing.T) {
+	Convey("TestRegistry_ResolveMulti", t, func() {
+		Convey("配置 sources 时返回分发标准化器", func() {
+			std, err := newTestDispatchRegistry().Resolve(newTestDispatchConfig("source_a", "source_a", "source_b"), nil)
+
+			So(err, ShouldBeNil)
+			d, ok := std.(*dispatchStandardizer)
+			So(ok, ShouldBeTrue)
+			So(d.sources, ShouldResemble, []string{"source_a", "source_b"})
+		})
+
+		Convey("sources 中包含未注册的数据源返回错误", func() {
+			_, err := newTestDispatchRegistry().Resolve(newTestDispatchConfig("source_a", "source_a", "unknown"), nil)
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "unsupported source type")
+		})
+
+		Convey("默认数据源不在 sources 中返回错误", func() {
+			_, err := newTestDispatchRegistry().Resolve(newTestDispatchConfig("source_c", "source_a", "source_b"), nil)
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestDispatchStandardizer_StandardizeBatch(t *testing.T) {
+	Convey("TestDispatchStandardizer_StandardizeBatch", t, func() {
+		std, err := newTestDispatchRegistry().Resolve(newTestDispatchConfig("source_a", "source_a", "source_b"), nil)
+		So(err, ShouldBeNil)
+		d := std.(*dispatchStandardizer)
+
+		Convey("显式指定数据源优先", func() {
+			ctx := WithSource(context.Background(), " SOURCE_B ")
+
+			events, err := d.StandardizeBatch(ctx, []byte(`{"a":1}`))
+
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 2)
+			So(events[0].EventSource, ShouldEqual, "source_b")
+		})
+
+		Convey("显式指定未启用的数据源返回错误", func() {
+			ctx := WithSource(context.Background(), "source_c")
+
+			_, err := d.StandardizeBatch(ctx, []byte(`{"a":1}`))
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "not enabled")
+		})
+
+		Convey("未指定时按 payload 嗅探", func() {
+			events, err := d.StandardizeBatch(context.Background(), []byte(`{"b":1}`))
+
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 2)
+			So(events[1].EventSource, ShouldEqual, "source_b")
+		})
+
+		Convey("嗅探不到时使用默认数据源", func() {
+			event, err := d.Standardize(context.Background(), []byte(`{"c":1}`))
+
+			So(err, ShouldBeNil)
+			So(event.EventTitle, ShouldEqual, "a")
+			So(event.EventSource, ShouldEqual, "source_a")
+		})
+
+		Convey("部分告警失败时返回成功的事件与 BatchError", func() {
+			batchErr := &BatchError{Total: 3, Failed: []ItemError{{Index: 2, Err: errors.New("对象不存在")}}}
+			d.standardizers["source_b"] = &mockBatchStandardizer{mockStandardizer: mockStandardizer{name: "b"}, count: 2, err: batchErr}
+
+			events, err := d.StandardizeBatch(context.Background(), []byte(`{"b":1}`))
+
+			So(err, ShouldEqual, batchErr)
+			So(len(events), ShouldEqual, 2)
+			So(events[0].EventSource, ShouldEqual, "source_b")
+
+			event, err := d.Standardize(context.Background(), []byte(`{"b":1}`))
+
+			So(err, ShouldBeNil)
+			So(event.EventSource, ShouldEqual, "source_b")
+		})
+
+		Convey("无默认数据源且无法识别时返回错误", func() {
+			d.defaultSource = ""
+
+			_, err := d.StandardizeBatch(context.Background(), []byte(`{"c":1}`))
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestBuiltinSniffers(t *testing.T) {
+	Convey("TestBuiltinSniffers", t, func() {
+		Convey("识别 Zabbix webhook", func() {
+			So(sniffZabbixWebhook([]byte(`{"event_id":"1","event_status":"1"}`)), ShouldBeTrue)
+			So(sniffZabbixWebhook([]byte(alertmanagerPayload)), ShouldBeFalse)
+		})
+
+		Convey("识别 Alertmanager webhook", func() {
+			So(sniffPrometheusAlertmanager([]byte(alertmanagerPayload)), ShouldBeTrue)
+			So(sniffPrometheusAlertmanager([]byte(`{"event_id":"1","event_status":"1"}`)), ShouldBeFalse)
+		})
+
+		Convey("非 JSON 对象不匹配", func() {
+			So(sniffZabbixWebhook([]byte(`[1,2]`)), ShouldBeFalse)
+		})
+	})
+}
+
+func TestBuild_MultiSource(t *testing.T) {
+	Convey("TestBuild_MultiSource", t, func() {
+		cfg := newTestDispatchConfig(domain.SourceZabbixWebhook, domain.SourceZabbixWebhook, domain.SourcePrometheusAlertmanager)
+
+		std, err := Build(cfg, &mockObjectClassQuerier{})
+
+		So(err, ShouldBeNil)
+		_, ok := std.(BatchStandardizer)
+		So(ok, ShouldBeTrue)
+	})
+}
@@ -0,0 +1,42 @@
+package standardizer
+
+import (
+	"context"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/objectclass"
+	"github.com/pkg/errors"
+)
+
+// resolveEntity 按识别条件从对象类缓存获取实体对象信息（名称未命中时依次按 IP、MAC 匹配）。
+// 启用未解析实体兜底时，缓存未命中的事件关联到按主机名（主机名为空时按 IP、MAC）生成的占位对象，
+// 占位对象 ID 中保留查询键，对象类缓存刷新后据此重新关联到真实对象。
+func resolveEntity(ctx context.Context, querier ObjectClassQuerier, fallback bool, entity objectclass.EntityIdentity) (*objectclass.EntityObjectInfo, error) {
+	objInfo, err := querier.GetEntityObjectInfo(ctx, entity)
+	if err == nil {
+		return objInfo, nil
+	}
+	err = errors.Wrap(err, "获取对象信息失败")
+	if !fallback {
+		return nil, err
+	}
+
+	key, name := entity.Key, entity.Name
+	switch {
+	case name != "":
+	case entity.IP != "":
+		key, name = entity.IP, entity.IP
+	case entity.MAC != "":
+		key, name = entity.MAC, entity.MAC
+	default:
+		return nil, err
+	}
+
+	log.Debugf("实体对象未解析，关联到占位对象: key=%s, name=%s", key, name)
+	return &objectclass.EntityObjectInfo{
+		ObjectTypeID: domain.UnresolvedObjectClass,
+		ObjectID:     domain.UnresolvedObjectID(key),
+		Name:         name,
+	}, nil
+}
@@ -0,0 +1,70 @@
+package standardizer
+
+import (
+	"context"
+	"testing"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/objectclass"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResolveEntity(t *testing.T) {
+	Convey("TestResolveEntity", t, func() {
+		ctx := context.Background()
+		missing := &mockObjectClassQuerier{err: errors.New("object not found")}
+
+		Convey("缓存命中返回真实对象", func() {
+			querier := &mockObjectClassQuerier{
+				result: &objectclass.EntityObjectInfo{ObjectTypeID: "host", ObjectID: "obj-1", Name: "host-a"},
+			}
+
+			objInfo, err := resolveEntity(ctx, querier, true, objectclass.EntityIdentity{Key: "host-a", Name: "host-a", IP: "10.0.0.1"})
+
+			So(err, ShouldBeNil)
+			So(objInfo.ObjectID, ShouldEqual, "obj-1")
+		})
+
+		Convey("未启用兜底时返回错误", func() {
+			objInfo, err := resolveEntity(ctx, missing, false, objectclass.EntityIdentity{Key: "host-a", Name: "host-a", IP: "10.0.0.1"})
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "获取对象信息失败")
+			So(objInfo, ShouldBeNil)
+		})
+
+		Convey("启用兜底时按查询键生成占位对象", func() {
+			key := objectclass.BuildEntityKey("c1", "ns", "host-a")
+
+			objInfo, err := resolveEntity(ctx, missing, true, objectclass.EntityIdentity{Key: key, Name: "host-a", IP: "10.0.0.1"})
+
+			So(err, ShouldBeNil)
+			So(objInfo.ObjectTypeID, ShouldEqual, domain.UnresolvedObjectClass)
+			So(objInfo.ObjectID, ShouldEqual, domain.UnresolvedObjectID(key))
+			So(objInfo.Name, ShouldEqual, "host-a")
+		})
+
+		Convey("主机名为空时按 IP 生成占位对象", func() {
+			objInfo, err := resolveEntity(ctx, missing, true, objectclass.EntityIdentity{IP: "10.0.0.1"})
+
+			So(err, ShouldBeNil)
+			So(objInfo.ObjectID, ShouldEqual, domain.UnresolvedObjectID("10.0.0.1"))
+			So(objInfo.Name, ShouldEqual, "10.0.0.1")
+		})
+
+		Convey("主机名与 IP 为空时按 MAC 生成占位对象", func() {
+			objInfo, err := resolveEntity(ctx, missing, true, objectclass.EntityIdentity{MAC: "aa:bb:cc:dd:ee:ff"})
+
+			So(err, ShouldBeNil)
+			So(objInfo.ObjectID, ShouldEqual, domain.UnresolvedObjectID("aa:bb:cc:dd:ee:ff"))
+		})
+
+		Convey("主机名与 IP 均为空时返回错误", func() {
+			objInfo, err := resolveEntity(ctx, missing, true, objectclass.EntityIdentity{})
+
+			So(err, ShouldNotBeNil)
+			So(objInfo, ShouldBeNil)
+		})
+	})
+}
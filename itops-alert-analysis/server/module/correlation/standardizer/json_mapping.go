@@ -0,0 +1,363 @@
+package standardizer
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/objectclass"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/idgen"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/timex"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// templatePattern 匹配模板表达式中的 ${路径} 占位符
+var templatePattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// jsonMappingStandardizer 按配置中的映射规则将任意 JSON webhook 转换为 RawEvent。
+// 配置多条规则时，按顺序选择第一条 match 条件满足的规则。
+type jsonMappingStandardizer struct {
+	rules              []config.JSONMappingRule
+	genID              *idgen.Generator
+	objectClassQuerier ObjectClassQuerier
+	unresolvedFallback bool // 未解析实体兜底
+}
+
+// NewJSONMappingStandardizer 基于映射规则创建 json_mapping 标准化器。
+func NewJSONMappingStandardizer(cfg config.IngestConfig, rules []config.JSONMappingRule, querier ObjectClassQuerier) Standardizer {
+	return &jsonMappingStandardizer{
+		rules:              rules,
+		genID:              eventIDGen,
+		objectClassQuerier: querier,
+		unresolvedFallback: cfg.UnresolvedEntity.Enabled,
+	}
+}
+
+// Standardize 返回第一条事件，配置了 items_path 时完整拆分请使用 StandardizeBatch。
+func (s *jsonMappingStandardizer) Standardize(ctx context.Context, payload []byte) (domain.RawEvent, error) {
+	return firstEvent(s.StandardizeBatch(ctx, payload))
+}
+
+// StandardizeBatch 按匹配的规则转换 payload，配置了 items_path 时数组中每个元素生成一个事件。
+// 单个元素标准化失败时返回成功的事件和 *BatchError，失败元素的 Payload 为数组只保留该元素的 payload。
+func (s *jsonMappingStandardizer) StandardizeBatch(ctx context.Context, payload []byte) ([]domain.RawEvent, error) {
+	var doc interface{}
+	if err := json.Unmarshal(payload, &doc); err != nil {
+		return nil, errors.Wrap(err, "解析JSON数据失败")
+	}
+
+	rule, ok := s.matchRule(doc)
+	if !ok {
+		return nil, errors.New("没有匹配的 json_mapping 规则")
+	}
+
+	if rule.ItemsPath == "" {
+		rawEvent, err := s.standardizeItem(ctx, rule, doc, string(payload))
+		if err != nil {
+			return nil, err
+		}
+		return []domain.RawEvent{rawEvent}, nil
+	}
+
+	items, ok := lookupPath(doc, rule.ItemsPath).([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, errors.Errorf("items_path %s 不是非空数组", rule.ItemsPath)
+	}
+
+	events := make([]domain.RawEvent, 0, len(items))
+	batchErr := &BatchError{Total: len(items)}
+	for i, item := range items {
+		itemMsg, _ := json.Marshal(item)
+		rawEvent, err := s.standardizeItem(ctx, rule, item, string(itemMsg))
+		if err != nil {
+			log.Warnf("json_mapping 标准化告警失败: rule=%s, index=%d, err=%v", rule.Name, i, err)
+			batchErr.Failed = append(batchErr.Failed, ItemError{Index: i, Payload: singleItemPayload(payload, rule.ItemsPath, item), Err: err})
+			continue
+		}
+		events = append(events, rawEvent)
+	}
+	if len(batchErr.Failed) > 0 {
+		return events, batchErr
+	}
+	return events, nil
+}
+
+// singleItemPayload 将 payload 中 itemsPath 指向的数组替换为只包含 item 的数组。
+// itemsPath 的最后一段带下标等无法替换的情况返回原 payload。
+func singleItemPayload(payload []byte, itemsPath string, item interface{}) []byte {
+	var doc interface{}
+	if err := json.Unmarshal(payload, &doc); err != nil {
+		return payload
+	}
+	path := strings.TrimPrefix(strings.TrimSpace(itemsPath), "$")
+	path = strings.TrimPrefix(path, ".")
+	parentPath, name := "", path
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		parentPath, name = path[:i], path[i+1:]
+	}
+	parent, ok := lookupPath(doc, parentPath).(map[string]interface{})
+	if !ok || name == "" || strings.Contains(name, "[") {
+		return payload
+	}
+	parent[name] = []interface{}{item}
+	single, err := json.Marshal(doc)
+	if err != nil {
+		return payload
+	}
+	return single
+}
+
+// matchRule 选择第一条 match 条件满足的规则，未配置 match 的规则视为匹配。
+func (s *jsonMappingStandardizer) matchRule(doc interface{}) (config.JSONMappingRule, bool) {
+	for _, rule := range s.rules {
+		if ruleMatches(rule, doc) {
+			return rule, true
+		}
+	}
+	return config.JSONMappingRule{}, false
+}
+
+func (s *jsonMappingStandardizer) standardizeItem(ctx context.Context, rule config.JSONMappingRule, item interface{}, rawMsg string) (domain.RawEvent, error) {
+	fields := rule.Fields
+
+	entityName := evalExpr(item, fields.EntityName)
+	entityIP := evalExpr(item, fields.EntityIP)
+	entityMAC := evalExpr(item, fields.EntityMAC)
+	if entityName == "" && (!s.unresolvedFallback || (entityIP == "" && entityMAC == "")) {
+		return domain.RawEvent{}, errors.New("实体对象名称为空")
+	}
+
+	// 从缓存获取实体对象信息（包含 object_type_id、object_id、name），名称未命中时按 IP、MAC 匹配
+	objInfo, err := resolveEntity(ctx, s.objectClassQuerier, s.unresolvedFallback, objectclass.EntityIdentity{
+		Key:  entityName,
+		Name: entityName,
+		IP:   entityIP,
+		MAC:  entityMAC,
+	})
+	if err != nil {
+		return domain.RawEvent{}, err
+	}
+
+	source := rule.Name
+	if source == "" {
+		source = domain.SourceJSONMapping
+	}
+
+	rawEvent := domain.RawEvent{
+		EventID:           s.genID.NextID(),
+		RecoveryId:        toEventID(evalExpr(item, fields.RecoveryID)),
+		EventProviderID:   toEventID(evalExpr(item, fields.ProviderID)),
+		EventTimestamp:    timex.NowLocalTime(),
+		EventTitle:        evalExpr(item, fields.Title),
+		EventContent:      evalExpr(item, fields.Content),
+		EventType:         evalExpr(item, fields.ItemKey),
+		EventStatus:       mapMappingStatus(rule.StatusMap, evalExpr(item, fields.Status)),
+		EventLevel:        mapMappingSeverity(rule.SeverityMap, evalExpr(item, fields.Severity)),
+		EventSource:       source,
+		EntityObjectName:  objInfo.Name,
+		EntityObjectClass: objInfo.ObjectTypeID,
+		EntityObjectID:    objInfo.ObjectID,
+		EntityObjectIP:    entityIP,
+		EntityObjectMAC:   entityMAC,
+		RawEventMsg:       rawMsg,
+	}
+
+	// 恢复事件未配置 recovery_id 时，使用上游事件 ID 关联原始事件
+	if rawEvent.EventStatus == domain.EventStatusRecovered && rawEvent.RecoveryId == 0 {
+		rawEvent.RecoveryId = rawEvent.EventProviderID
+	}
+
+	if occurTime, ok := parseMappingTime(evalExpr(item, fields.OccurTime), rule.TimeLayout); ok {
+		rawEvent.EventOccurTime = &occurTime
+	}
+	if recoveryTime, ok := parseMappingTime(evalExpr(item, fields.RecoveryTime), rule.TimeLayout); ok {
+		rawEvent.EventRecoveryTime = &recoveryTime
+	}
+
+	return rawEvent, nil
+}
+
+// ruleMatches 判断 payload 是否满足规则的全部 match 条件。
+func ruleMatches(rule config.JSONMappingRule, doc interface{}) bool {
+	for path, expected := range rule.Match {
+		v := lookupPath(doc, path)
+		if v == nil {
+			return false
+		}
+		if expected != "" && stringify(v) != expected {
+			return false
+		}
+	}
+	return true
+}
+
+// sniffJSONMapping 返回规则对应的嗅探函数，未配置 match 的规则不参与嗅探。
+func sniffJSONMapping(rule config.JSONMappingRule) Sniffer {
+	if len(rule.Match) == 0 {
+		return nil
+	}
+	return func(payload []byte) bool {
+		var doc interface{}
+		if err := json.Unmarshal(payload, &doc); err != nil {
+			return false
+		}
+		return ruleMatches(rule, doc)
+	}
+}
+
+// evalExpr 计算字段表达式：包含 ${路径} 时按模板渲染，否则按路径取值。
+func evalExpr(doc interface{}, expr string) string {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return ""
+	}
+	if !strings.Contains(expr, "${") {
+		return stringify(lookupPath(doc, expr))
+	}
+	return templatePattern.ReplaceAllStringFunc(expr, func(m string) string {
+		return stringify(lookupPath(doc, templatePattern.FindStringSubmatch(m)[1]))
+	})
+}
+
+// lookupPath 按点分路径取值，支持 "$." 前缀与数组下标，如 "$.alerts[0].labels.host"。
+func lookupPath(doc interface{}, path string) interface{} {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return doc
+	}
+
+	cur := doc
+	for _, segment := range strings.Split(path, ".") {
+		name, indexes := splitIndexes(segment)
+		if name != "" {
+			obj, ok := cur.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			cur = obj[name]
+		}
+		for _, idx := range indexes {
+			arr, ok := cur.([]interface{})
+			if !ok || idx < 0 || idx >= len(arr) {
+				return nil
+			}
+			cur = arr[idx]
+		}
+		if cur == nil {
+			return nil
+		}
+	}
+	return cur
+}
+
+// splitIndexes 拆分路径段中的字段名与数组下标，如 "items[0][1]" -> ("items", [0, 1])。
+func splitIndexes(segment string) (string, []int) {
+	open := strings.Index(segment, "[")
+	if open < 0 {
+		return segment, nil
+	}
+	name := segment[:open]
+	var indexes []int
+	for _, part := range strings.Split(segment[open:], "[")[1:] {
+		idx, err := strconv.Atoi(strings.TrimSuffix(part, "]"))
+		if err != nil {
+			return name, []int{-1}
+		}
+		indexes = append(indexes, idx)
+	}
+	return name, indexes
+}
+
+// stringify 将 JSON 值转换为字符串，对象与数组按 JSON 编码。
+func stringify(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
+
+// toEventID 将上游事件 ID 转换为 uint64，非数字 ID 使用 FNV-64a 哈希。
+func toEventID(v string) uint64 {
+	if v == "" {
+		return 0
+	}
+	if id, err := cast.ToUint64E(v); err == nil {
+		return id
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(v))
+	return h.Sum64()
+}
+
+// parseMappingTime 解析时间：数值按 Unix 时间戳（秒或毫秒），否则按配置格式或默认格式解析。
+// 零值及早于 Unix 纪元的时间（如 Alertmanager 未恢复告警的 endsAt 0001-01-01T00:00:00Z）视为未设置。
+func parseMappingTime(v, layout string) (time.Time, bool) {
+	if v == "" {
+		return time.Time{}, false
+	}
+	if ts, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if ts <= 0 {
+			return time.Time{}, false
+		}
+		if ts > 1e12 {
+			return time.UnixMilli(ts).Local(), true
+		}
+		return time.Unix(ts, 0).Local(), true
+	}
+
+	layouts := []string{time.RFC3339, time.DateTime}
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	for _, l := range layouts {
+		if t, err := timex.ParseTime(v, l); err == nil {
+			if t.Unix() <= 0 {
+				return time.Time{}, false
+			}
+			return t.Local(), true
+		}
+	}
+	log.Warnf("json_mapping 解析时间失败: value=%s, layout=%s", v, layout)
+	return time.Time{}, false
+}
+
+func mapMappingStatus(statusMap map[string]string, v string) domain.EventStatus {
+	if mapped, ok := statusMap[v]; ok {
+		v = mapped
+	}
+	switch strings.ToLower(v) {
+	case "recovered", string(domain.EventStatusRecovered):
+		return domain.EventStatusRecovered
+	default:
+		return domain.EventStatusOccurred
+	}
+}
+
+func mapMappingSeverity(severityMap map[string]int, v string) domain.Severity {
+	level, ok := severityMap[v]
+	if !ok {
+		level = cast.ToInt(v)
+	}
+	if level < int(domain.SeverityEmergency) || level > int(domain.SeverityNormal) {
+		return domain.SeverityNormal
+	}
+	return domain.Severity(level)
+}
@@ -0,0 +1,256 @@
+package standardizer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/objectclass"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// zabbixMappingRule 与 zabbixStandardizer 等价的映射规则
+var zabbixMappingRule = config.JSONMappingRule{
+	Name:       "zabbix_mapping",
+	Match:      map[string]string{"event_id": "", "event_status": ""},
+	TimeLayout: "2006-01-02 15:04:05",
+	Fields: config.JSONMappingFields{
+		ProviderID:   "event_id",
+		Title:        "event_name",
+		Content:      "description",
+		Severity:     "event_severity",
+		Status:       "event_status",
+		OccurTime:    "occur_time",
+		RecoveryTime: "recovery_time",
+		EntityName:   "entity_object_name",
+		EntityIP:     "ip",
+		ItemKey:      "item_key",
+		RecoveryID:   "recovery_id",
+	},
+	SeverityMap: map[string]int{"Disaster": 1, "High": 2, "Average": 3, "Warning": 4},
+	StatusMap:   map[string]string{"发生": "occurred", "恢复": "recovered"},
+}
+
+const zabbixMappingPayload = `{
+	"description": "CPU使用率过高",
+	"event_id": "100001",
+	"recovery_id": "100002",
+	"event_name": "CPU告警",
+	"occur_time": "2025-01-01 12:00:00",
+	"recovery_time": "2025-01-01 12:30:00",
+	"event_severity": "High",
+	"event_status": "恢复",
+	"entity_object_name": "test-host",
+	"ip": "192.168.1.100",
+	"item_key": "system.cpu.util"
+}`
+
+func TestJSONMappingStandardizer_Standardize(t *testing.T) {
+	Convey("TestJSONMappingStandardizer_Standardize", t, func() {
+		ctx := context.Background()
+		querier := &mockObjectClassQuerier{
+			result: &objectclass.EntityObjectInfo{
+				ObjectTypeID: "ot-host-001",
+				ObjectID:     "obj-12345",
+				Name:         "test-host",
+			},
+		}
+
+		Convey("与 zabbixStandardizer 生成相同的 RawEvent", func() {
+			expected, err := NewZabbixWebhookStandardizer(config.IngestConfig{}, querier).Standardize(ctx, []byte(zabbixMappingPayload))
+			So(err, ShouldBeNil)
+
+			rawEvent, err := NewJSONMappingStandardizer(config.IngestConfig{}, []config.JSONMappingRule{zabbixMappingRule}, querier).Standardize(ctx, []byte(zabbixMappingPayload))
+			So(err, ShouldBeNil)
+
+			So(rawEvent.EventSource, ShouldEqual, "zabbix_mapping")
+			So(rawEvent.EventID, ShouldNotEqual, expected.EventID)
+			// 忽略每次生成的字段后应完全一致
+			rawEvent.EventID, rawEvent.EventTimestamp, rawEvent.EventSource = expected.EventID, expected.EventTimestamp, expected.EventSource
+			So(rawEvent, ShouldResemble, expected)
+		})
+
+		Convey("支持模板表达式与 items_path 拆分", func() {
+			rule := config.JSONMappingRule{
+				Name:      "custom",
+				ItemsPath: "$.data.alerts",
+				Fields: config.JSONMappingFields{
+					ProviderID: "id",
+					Title:      "${rule} on ${target.host}",
+					Severity:   "level",
+					Status:     "state",
+					OccurTime:  "ts",
+					EntityName: "target.host",
+					ItemKey:    "tags[0]",
+				},
+				StatusMap: map[string]string{"ok": "recovered"},
+			}
+			payload := []byte(`{"data":{"alerts":[
+				{"id":"a-1","rule":"HighLoad","target":{"host":"web-1"},"level":2,"state":"alerting","ts":1735689600,"tags":["load"]},
+				{"id":"a-2","rule":"DiskFull","target":{"host":"web-2"},"level":9,"state":"ok","ts":1735689600000}
+			]}}`)
+
+			events, err := NewJSONMappingStandardizer(config.IngestConfig{}, []config.JSONMappingRule{rule}, querier).(BatchStandardizer).StandardizeBatch(ctx, payload)
+
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 2)
+			So(events[0].EventTitle, ShouldEqual, "HighLoad on web-1")
+			So(events[0].EventType, ShouldEqual, "load")
+			So(events[0].EventLevel, ShouldEqual, domain.SeverityCritical)
+			So(events[0].EventStatus, ShouldEqual, domain.EventStatusOccurred)
+			So(events[0].EventProviderID, ShouldEqual, toEventID("a-1"))
+			So(events[0].EventOccurTime.Unix(), ShouldEqual, 1735689600)
+			So(events[1].EventLevel, ShouldEqual, domain.SeverityNormal)
+			So(events[1].EventStatus, ShouldEqual, domain.EventStatusRecovered)
+			So(events[1].RecoveryId, ShouldEqual, events[1].EventProviderID)
+			So(events[1].EventOccurTime.Unix(), ShouldEqual, 1735689600)
+		})
+
+		Convey("items_path 中部分元素失败时返回成功的事件与失败的元素", func() {
+			rule := config.JSONMappingRule{
+				Name:      "custom",
+				ItemsPath: "$.data.alerts",
+				Fields:    config.JSONMappingFields{ProviderID: "id", EntityName: "host"},
+			}
+			payload := []byte(`{"kind":"custom","data":{"alerts":[{"id":"a-1","host":"web-1"},{"id":"a-2"}]}}`)
+
+			events, err := NewJSONMappingStandardizer(config.IngestConfig{}, []config.JSONMappingRule{rule}, querier).(BatchStandardizer).StandardizeBatch(ctx, payload)
+
+			So(events, ShouldHaveLength, 1)
+			var batchErr *BatchError
+			So(errors.As(err, &batchErr), ShouldBeTrue)
+			So(batchErr.Failed, ShouldHaveLength, 1)
+			So(batchErr.Failed[0].Index, ShouldEqual, 1)
+			So(string(batchErr.Failed[0].Payload), ShouldEqual, `{"data":{"alerts":[{"id":"a-2"}]},"kind":"custom"}`)
+		})
+
+		Convey("零值与早于 Unix 纪元的时间视为未设置", func() {
+			rule := config.JSONMappingRule{
+				Name:   "alertmanager",
+				Fields: config.JSONMappingFields{ProviderID: "fingerprint", EntityName: "host", OccurTime: "startsAt", RecoveryTime: "endsAt"},
+			}
+			payload := []byte(`{"fingerprint":"f-1","host":"web-1","startsAt":"2026-01-01T08:00:00Z","endsAt":"0001-01-01T00:00:00Z"}`)
+
+			rawEvent, err := NewJSONMappingStandardizer(config.IngestConfig{}, []config.JSONMappingRule{rule}, querier).Standardize(ctx, payload)
+
+			So(err, ShouldBeNil)
+			So(rawEvent.EventOccurTime, ShouldNotBeNil)
+			So(rawEvent.EventRecoveryTime, ShouldBeNil)
+		})
+
+		Convey("按 match 条件选择规则", func() {
+			other := config.JSONMappingRule{
+				Name:   "other",
+				Match:  map[string]string{"kind": "other"},
+				Fields: config.JSONMappingFields{EntityName: "host"},
+			}
+			std := NewJSONMappingStandardizer(config.IngestConfig{}, []config.JSONMappingRule{other, zabbixMappingRule}, querier)
+
+			rawEvent, err := std.Standardize(ctx, []byte(zabbixMappingPayload))
+
+			So(err, ShouldBeNil)
+			So(rawEvent.EventSource, ShouldEqual, "zabbix_mapping")
+		})
+
+		Convey("没有匹配的规则返回错误", func() {
+			std := NewJSONMappingStandardizer(config.IngestConfig{}, []config.JSONMappingRule{zabbixMappingRule}, querier)
+
+			_, err := std.Standardize(ctx, []byte(`{"foo":"bar"}`))
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "没有匹配的 json_mapping 规则")
+		})
+
+		Convey("实体名称为空返回错误", func() {
+			rule := zabbixMappingRule
+			rule.Fields.EntityName = "missing"
+			std := NewJSONMappingStandardizer(config.IngestConfig{}, []config.JSONMappingRule{rule}, querier)
+
+			_, err := std.Standardize(ctx, []byte(zabbixMappingPayload))
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestParseMappingTime(t *testing.T) {
+	Convey("TestParseMappingTime", t, func() {
+		t1, ok := parseMappingTime("2026-01-01T08:00:00Z", "")
+		So(ok, ShouldBeTrue)
+		So(t1.Unix(), ShouldEqual, 1767254400)
+
+		t2, ok := parseMappingTime("1767254400000", "")
+		So(ok, ShouldBeTrue)
+		So(t2.Unix(), ShouldEqual, 1767254400)
+
+		for _, v := range []string{"", "0", "-1", "0001-01-01T00:00:00Z", "1960-01-01T00:00:00Z", "not a time"} {
+			_, ok = parseMappingTime(v, "")
+			So(ok, ShouldBeFalse)
+		}
+	})
+}
+
+func TestSingleItemPayload(t *testing.T) {
+	Convey("TestSingleItemPayload", t, func() {
+		payload := []byte(`{"alerts":[1,2]}`)
+
+		So(string(singleItemPayload(payload, "$.alerts", 2.0)), ShouldEqual, `{"alerts":[2]}`)
+		// 最后一段带下标时无法替换，返回原 payload
+		So(string(singleItemPayload(payload, "alerts[0]", 2.0)), ShouldEqual, string(payload))
+		So(string(singleItemPayload(payload, "missing.alerts", 2.0)), ShouldEqual, string(payload))
+	})
+}
+
+func TestLookupPath(t *testing.T) {
+	Convey("TestLookupPath", t, func() {
+		doc := map[string]interface{}{
+			"a": map[string]interface{}{
+				"b": []interface{}{"x", map[string]interface{}{"c": 1.0}},
+			},
+		}
+
+		So(lookupPath(doc, "$.a.b[0]"), ShouldEqual, "x")
+		So(lookupPath(doc, "a.b[1].c"), ShouldEqual, 1.0)
+		So(lookupPath(doc, "a.b[5]"), ShouldBeNil)
+		So(lookupPath(doc, "a.x.y"), ShouldBeNil)
+		So(lookupPath(doc, "a.b[x]"), ShouldBeNil)
+		So(stringify(lookupPath(doc, "a.b[1]")), ShouldEqual, `{"c":1}`)
+	})
+}
+
+func TestRegistry_ResolveJSONMapping(t *testing.T) {
+	Convey("TestRegistry_ResolveJSONMapping", t, func() {
+		Convey("json_mapping 数据源使用全部规则", func() {
+			cfg := newTestDispatchConfig(domain.SourceJSONMapping)
+			cfg.AppConfig.Ingest.JSONMappings = []config.JSONMappingRule{zabbixMappingRule}
+
+			std, err := Build(cfg, &mockObjectClassQuerier{})
+
+			So(err, ShouldBeNil)
+			So(std, ShouldNotBeNil)
+		})
+
+		Convey("json_mapping 未配置规则返回错误", func() {
+			_, err := Build(newTestDispatchConfig(domain.SourceJSONMapping), &mockObjectClassQuerier{})
+
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("多数据源模式下规则名称可作为数据源并参与嗅探", func() {
+			cfg := newTestDispatchConfig(domain.SourcePrometheusAlertmanager, domain.SourcePrometheusAlertmanager, "zabbix_mapping")
+			cfg.AppConfig.Ingest.JSONMappings = []config.JSONMappingRule{zabbixMappingRule}
+			querier := &mockObjectClassQuerier{result: &objectclass.EntityObjectInfo{ObjectID: "obj-1"}}
+
+			std, err := Build(cfg, querier)
+			So(err, ShouldBeNil)
+
+			rawEvent, err := std.Standardize(context.Background(), []byte(zabbixMappingPayload))
+
+			So(err, ShouldBeNil)
+			So(rawEvent.EventSource, ShouldEqual, "zabbix_mapping")
+			So(rawEvent.EntityObjectID, ShouldEqual, "obj-1")
+		})
+	})
+}
@@ -0,0 +1,285 @@
+package standardizer
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/objectclass"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/idgen"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/timex"
+	"github.com/pkg/errors"
+)
+
+const (
+	alertmanagerStatusResolved = "resolved"
+)
+
+// 未配置时使用的默认标签，按顺序取第一个非空值
+var (
+	defaultClusterLabels   = []string{"k8s_cluster", "cluster"}
+	defaultNamespaceLabels = []string{"namespace"}
+	defaultNameLabels      = []string{"pod", "deployment", "statefulset", "daemonset", "service", "node", "instance"}
+)
+
+// prometheusSeverityMapping severity 标签（小写）到告警级别的映射，未列出的取值视为 Normal
+var prometheusSeverityMapping = map[string]domain.Severity{
+	"emergency": domain.SeverityEmergency,
+	"disaster":  domain.SeverityEmergency,
+	"critical":  domain.SeverityCritical,
+	"major":     domain.SeverityMajor,
+	"error":     domain.SeverityMajor,
+	"warning":   domain.SeverityWarning,
+	"warn":      domain.SeverityWarning,
+}
+
+// prometheusStandardizer 将 Alertmanager webhook 分组推送拆分为多个 RawEvent。
+type prometheusStandardizer struct {
+	cfg                config.PrometheusConfig
+	genID              *idgen.Generator
+	objectClassQuerier ObjectClassQuerier
+	unresolvedFallback bool // 未解析实体兜底
+}
+
+// AlertmanagerWebhook Alertmanager webhook 推送结构（version 4）
+type AlertmanagerWebhook struct {
+	Version           string              `json:"version"`
+	GroupKey          string              `json:"groupKey"`
+	Status            string              `json:"status"`
+	Receiver          string              `json:"receiver"`
+	GroupLabels       map[string]string   `json:"groupLabels"`
+	CommonLabels      map[string]string   `json:"commonLabels"`
+	CommonAnnotations map[string]string   `json:"commonAnnotations"`
+	ExternalURL       string              `json:"externalURL"`
+	Alerts            []AlertmanagerAlert `json:"alerts"`
+}
+
+// AlertmanagerAlert 分组中的单条告警
+type AlertmanagerAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// NewPrometheusAlertmanagerStandardizer 基于 ingest 配置创建 Alertmanager webhook 标准化器。
+func NewPrometheusAlertmanagerStandardizer(cfg config.IngestConfig, querier ObjectClassQuerier) Standardizer {
+	return &prometheusStandardizer{
+		cfg:                cfg.Prometheus,
+		genID:              eventIDGen,
+		objectClassQuerier: querier,
+		unresolvedFallback: cfg.UnresolvedEntity.Enabled,
+	}
+}
+
+// Standardize 仅返回分组中第一条可标准化的告警，完整拆分请使用 StandardizeBatch。
+func (s *prometheusStandardizer) Standardize(ctx context.Context, payload []byte) (domain.RawEvent, error) {
+	return firstEvent(s.StandardizeBatch(ctx, payload))
+}
+
+// StandardizeBatch 将 alerts[] 中的每条告警转换为一个 RawEvent。
+// 单条告警标准化失败时返回成功的事件和 *BatchError，失败告警的 Payload 为只包含该告警的分组推送。
+func (s *prometheusStandardizer) StandardizeBatch(ctx context.Context, payload []byte) ([]domain.RawEvent, error) {
+	var webhook AlertmanagerWebhook
+	if err := json.Unmarshal(payload, &webhook); err != nil {
+		return nil, errors.Wrap(err, "解析AlertmanagerWebhook数据失败")
+	}
+	if len(webhook.Alerts) == 0 {
+		return nil, errors.New("AlertmanagerWebhook 中没有告警")
+	}
+
+	events := make([]domain.RawEvent, 0, len(webhook.Alerts))
+	batchErr := &BatchError{Total: len(webhook.Alerts)}
+	for i, alert := range webhook.Alerts {
+		if alert.Status == "" {
+			alert.Status = webhook.Status
+		}
+		rawEvent, err := s.standardizeAlert(ctx, alert)
+		if err != nil {
+			log.Warnf("标准化 Alertmanager 告警失败: fingerprint=%s, alertname=%s, err=%v",
+				alert.Fingerprint, alert.Labels["alertname"], err)
+			batchErr.Failed = append(batchErr.Failed, ItemError{Index: i, Payload: singleAlertPayload(webhook, alert), Err: err})
+			continue
+		}
+		events = append(events, rawEvent)
+	}
+
+	if len(batchErr.Failed) > 0 {
+		return events, batchErr
+	}
+	return events, nil
+}
+
+// singleAlertPayload 构造只包含一条告警的分组推送，保留分组的其余字段。
+func singleAlertPayload(webhook AlertmanagerWebhook, alert AlertmanagerAlert) []byte {
+	webhook.Alerts = []AlertmanagerAlert{alert}
+	payload, _ := json.Marshal(webhook)
+	return payload
+}
+
+func (s *prometheusStandardizer) standardizeAlert(ctx context.Context, alert AlertmanagerAlert) (domain.RawEvent, error) {
+	if alert.Fingerprint == "" {
+		return domain.RawEvent{}, errors.New("告警 fingerprint 不能为空")
+	}
+
+	// 按 objectclass 预热时的键格式拼接查询键
+	entityName := hostOnly(firstLabel(alert.Labels, s.nameLabels()))
+	entityKey := objectclass.BuildEntityKey(
+		firstLabel(alert.Labels, s.clusterLabels()),
+		firstLabel(alert.Labels, s.namespaceLabels()),
+		entityName,
+	)
+	entityIP := instanceIP(alert.Labels["instance"])
+	objInfo, err := resolveEntity(ctx, s.objectClassQuerier, s.unresolvedFallback, objectclass.EntityIdentity{
+		Key:  entityKey,
+		Name: entityName,
+		IP:   entityIP,
+	})
+	if err != nil {
+		return domain.RawEvent{}, err
+	}
+
+	// fingerprint 在告警生命周期内保持不变，作为发生/恢复事件的关联 ID
+	providerID := fingerprintID(alert.Fingerprint)
+	payload, _ := json.Marshal(alert)
+
+	rawEvent := domain.RawEvent{
+		EventID:           s.genID.NextID(),
+		EventProviderID:   providerID,
+		EventTimestamp:    timex.NowLocalTime(),
+		EventTitle:        alertTitle(alert),
+		EventContent:      alertContent(alert),
+		EventType:         s.eventType(alert.Labels),
+		EventStatus:       domain.EventStatusOccurred,
+		EventLevel:        mapPrometheusSeverity(alert.Labels["severity"]),
+		EventSource:       domain.SourcePrometheusAlertmanager,
+		EntityObjectName:  objInfo.Name,
+		EntityObjectClass: objInfo.ObjectTypeID,
+		EntityObjectID:    objInfo.ObjectID,
+		EntityObjectIP:    entityIP,
+		RawEventMsg:       string(payload),
+		EventLabels:       alert.Labels,
+	}
+
+	if !alert.StartsAt.IsZero() {
+		occurTime := alert.StartsAt.Local()
+		rawEvent.EventOccurTime = &occurTime
+	}
+
+	if strings.EqualFold(alert.Status, alertmanagerStatusResolved) {
+		rawEvent.EventStatus = domain.EventStatusRecovered
+		rawEvent.RecoveryId = providerID
+		if !alert.EndsAt.IsZero() {
+			recoveryTime := alert.EndsAt.Local()
+			rawEvent.EventRecoveryTime = &recoveryTime
+		}
+	}
+
+	return rawEvent, nil
+}
+
+// eventType 由 alertname 与配置的标签组成，如 KubePodCrashLooping[container=nginx]
+func (s *prometheusStandardizer) eventType(labels map[string]string) string {
+	var parts []string
+	for _, key := range s.cfg.EventTypeLabels {
+		if v := labels[key]; v != "" {
+			parts = append(parts, key+"="+v)
+		}
+	}
+	if len(parts) == 0 {
+		return labels["alertname"]
+	}
+	return labels["alertname"] + "[" + strings.Join(parts, ",") + "]"
+}
+
+func (s *prometheusStandardizer) clusterLabels() []string {
+	if len(s.cfg.ClusterLabels) > 0 {
+		return s.cfg.ClusterLabels
+	}
+	return defaultClusterLabels
+}
+
+func (s *prometheusStandardizer) namespaceLabels() []string {
+	if len(s.cfg.NamespaceLabels) > 0 {
+		return s.cfg.NamespaceLabels
+	}
+	return defaultNamespaceLabels
+}
+
+func (s *prometheusStandardizer) nameLabels() []string {
+	if len(s.cfg.NameLabels) > 0 {
+		return s.cfg.NameLabels
+	}
+	return defaultNameLabels
+}
+
+func alertTitle(alert AlertmanagerAlert) string {
+	if summary := alert.Annotations["summary"]; summary != "" {
+		return summary
+	}
+	return alert.Labels["alertname"]
+}
+
+func alertContent(alert AlertmanagerAlert) string {
+	for _, key := range []string{"description", "message", "summary"} {
+		if v := alert.Annotations[key]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// firstLabel 按顺序返回第一个非空的标签值
+func firstLabel(labels map[string]string, keys []string) string {
+	for _, key := range keys {
+		if v := labels[key]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// hostOnly 去掉 host:port 中的端口（instance 标签通常带端口）
+func hostOnly(v string) string {
+	if host, _, err := net.SplitHostPort(v); err == nil {
+		return host
+	}
+	return v
+}
+
+// instanceIP 当 instance 标签为 IP（可带端口）时返回 IP，否则返回空
+func instanceIP(instance string) string {
+	host := hostOnly(instance)
+	if net.ParseIP(host) == nil {
+		return ""
+	}
+	return host
+}
+
+// fingerprintID 将 Alertmanager 的十六进制 fingerprint 转为 uint64，
+// 非标准格式时使用 FNV-64a 哈希，保证同一 fingerprint 得到同一 ID。
+func fingerprintID(fingerprint string) uint64 {
+	if id, err := strconv.ParseUint(fingerprint, 16, 64); err == nil {
+		return id
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(fingerprint))
+	return h.Sum64()
+}
+
+func mapPrometheusSeverity(severity string) domain.Severity {
+	if level, ok := prometheusSeverityMapping[strings.ToLower(severity)]; ok {
+		return level
+	}
+	return domain.SeverityNormal
+}
@@ -0,0 +1,199 @@
+package standardizer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/module/correlation/objectclass"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// recordingObjectClassQuerier 记录查询键的 ObjectClassQuerier mock
+type recordingObjectClassQuerier struct {
+	keys    []string
+	results map[string]*objectclass.EntityObjectInfo
+}
+
+func (m *recordingObjectClassQuerier) GetEntityObjectInfo(ctx context.Context, entity objectclass.EntityIdentity) (*objectclass.EntityObjectInfo, error) {
+	m.keys = append(m.keys, entity.Key)
+	if info, ok := m.results[entity.Key]; ok {
+		return info, nil
+	}
+	return nil, errors.New("对象不存在")
+}
+
+const alertmanagerPayload = `{
+	"version": "4",
+	"groupKey": "{}:{alertname=\"KubePodCrashLooping\"}",
+	"status": "firing",
+	"receiver": "itops",
+	"alerts": [
+		{
+			"status": "firing",
+			"labels": {"alertname": "KubePodCrashLooping", "severity": "critical", "k8s_cluster": "prod", "namespace": "default", "pod": "nginx-0", "container": "nginx"},
+			"annotations": {"summary": "Pod 频繁重启", "description": "nginx-0 在 5 分钟内重启 3 次"},
+			"startsAt": "2026-01-01T08:00:00Z",
+			"endsAt": "0001-01-01T00:00:00Z",
+			"fingerprint": "0a1b2c3d4e5f6789"
+		},
+		{
+			"status": "resolved",
+			"labels": {"alertname": "NodeDown", "severity": "warning", "cluster": "prod", "instance": "10.0.0.1:9100"},
+			"annotations": {},
+			"startsAt": "2026-01-01T07:00:00Z",
+			"endsAt": "2026-01-01T07:30:00Z",
+			"fingerprint": "ffff000011112222"
+		}
+	]
+}`
+
+func TestNewPrometheusAlertmanagerStandardizer(t *testing.T) {
+	Convey("TestNewPrometheusAlertmanagerStandardizer", t, func() {
+		Convey("成功创建标准化器", func() {
+			standardizer := NewPrometheusAlertmanagerStandardizer(config.IngestConfig{}, &mockObjectClassQuerier{})
+
+			So(standardizer, ShouldNotBeNil)
+			_, ok := standardizer.(BatchStandardizer)
+			So(ok, ShouldBeTrue)
+		})
+	})
+}
+
+func TestPrometheusStandardizer_StandardizeBatch(t *testing.T) {
+	Convey("TestPrometheusStandardizer_StandardizeBatch", t, func() {
+		ctx := context.Background()
+		podKey := objectclass.BuildEntityKey("prod", "default", "nginx-0")
+		nodeKey := objectclass.BuildEntityKey("prod", "", "10.0.0.1")
+
+		Convey("按告警拆分为多个事件", func() {
+			querier := &recordingObjectClassQuerier{results: map[string]*objectclass.EntityObjectInfo{
+				podKey:  {ObjectTypeID: "ot-pod", ObjectID: "obj-pod", Name: "nginx-0"},
+				nodeKey: {ObjectTypeID: "ot-node", ObjectID: "obj-node", Name: "node-1"},
+			}}
+			cfg := config.IngestConfig{Prometheus: config.PrometheusConfig{EventTypeLabels: []string{"container"}}}
+			standardizer := NewPrometheusAlertmanagerStandardizer(cfg, querier).(BatchStandardizer)
+
+			events, err := standardizer.StandardizeBatch(ctx, []byte(alertmanagerPayload))
+
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 2)
+			So(querier.keys, ShouldResemble, []string{podKey, nodeKey})
+
+			firing := events[0]
+			So(firing.EventStatus, ShouldEqual, domain.EventStatusOccurred)
+			So(firing.EventProviderID, ShouldEqual, uint64(0x0a1b2c3d4e5f6789))
+			So(firing.RecoveryId, ShouldEqual, 0)
+			So(firing.EventLevel, ShouldEqual, domain.SeverityCritical)
+			So(firing.EventTitle, ShouldEqual, "Pod 频繁重启")
+			So(firing.EventContent, ShouldEqual, "nginx-0 在 5 分钟内重启 3 次")
+			So(firing.EventType, ShouldEqual, "KubePodCrashLooping[container=nginx]")
+			So(firing.EventSource, ShouldEqual, domain.SourcePrometheusAlertmanager)
+			So(firing.EntityObjectClass, ShouldEqual, "ot-pod")
+			So(firing.EntityObjectID, ShouldEqual, "obj-pod")
+			So(firing.EventOccurTime, ShouldNotBeNil)
+			So(firing.EventRecoveryTime, ShouldBeNil)
+
+			resolved := events[1]
+			So(resolved.EventStatus, ShouldEqual, domain.EventStatusRecovered)
+			So(resolved.EventProviderID, ShouldEqual, uint64(0xffff000011112222))
+			So(resolved.RecoveryId, ShouldEqual, resolved.EventProviderID)
+			So(resolved.EventLevel, ShouldEqual, domain.SeverityWarning)
+			So(resolved.EventTitle, ShouldEqual, "NodeDown")
+			So(resolved.EventType, ShouldEqual, "NodeDown")
+			So(resolved.EntityObjectIP, ShouldEqual, "10.0.0.1")
+			So(resolved.EventRecoveryTime, ShouldNotBeNil)
+		})
+
+		Convey("部分告警实体解析失败时返回成功的事件与失败的告警", func() {
+			querier := &recordingObjectClassQuerier{results: map[string]*objectclass.EntityObjectInfo{
+				nodeKey: {ObjectTypeID: "ot-node", ObjectID: "obj-node", Name: "node-1"},
+			}}
+			standardizer := NewPrometheusAlertmanagerStandardizer(config.IngestConfig{}, querier).(BatchStandardizer)
+
+			events, err := standardizer.StandardizeBatch(ctx, []byte(alertmanagerPayload))
+
+			So(len(events), ShouldEqual, 1)
+			So(events[0].EntityObjectID, ShouldEqual, "obj-node")
+			var batchErr *BatchError
+			So(errors.As(err, &batchErr), ShouldBeTrue)
+			So(batchErr.Total, ShouldEqual, 2)
+			So(batchErr.Failed, ShouldHaveLength, 1)
+			So(batchErr.Failed[0].Index, ShouldEqual, 0)
+
+			// 失败告警的 payload 只包含该告警，可单独重新标准化
+			var single AlertmanagerWebhook
+			So(json.Unmarshal(batchErr.Failed[0].Payload, &single), ShouldBeNil)
+			So(single.Alerts, ShouldHaveLength, 1)
+			So(single.Alerts[0].Fingerprint, ShouldEqual, "0a1b2c3d4e5f6789")
+			So(single.Status, ShouldEqual, "firing")
+		})
+
+		Convey("全部告警解析失败返回全部失败的告警", func() {
+			querier := &recordingObjectClassQuerier{}
+			standardizer := NewPrometheusAlertmanagerStandardizer(config.IngestConfig{}, querier).(BatchStandardizer)
+
+			events, err := standardizer.StandardizeBatch(ctx, []byte(alertmanagerPayload))
+
+			So(events, ShouldBeEmpty)
+			var batchErr *BatchError
+			So(errors.As(err, &batchErr), ShouldBeTrue)
+			So(batchErr.Failed, ShouldHaveLength, 2)
+		})
+
+		Convey("无效 JSON 返回错误", func() {
+			standardizer := NewPrometheusAlertmanagerStandardizer(config.IngestConfig{}, &mockObjectClassQuerier{}).(BatchStandardizer)
+
+			_, err := standardizer.StandardizeBatch(ctx, []byte("invalid"))
+
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("alerts 为空返回错误", func() {
+			standardizer := NewPrometheusAlertmanagerStandardizer(config.IngestConfig{}, &mockObjectClassQuerier{}).(BatchStandardizer)
+
+			_, err := standardizer.StandardizeBatch(ctx, []byte(`{"status":"firing","alerts":[]}`))
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestPrometheusStandardizer_Standardize(t *testing.T) {
+	Convey("TestPrometheusStandardizer_Standardize", t, func() {
+		Convey("返回第一条告警", func() {
+			querier := &mockObjectClassQuerier{result: &objectclass.EntityObjectInfo{ObjectID: "obj-1"}}
+			standardizer := NewPrometheusAlertmanagerStandardizer(config.IngestConfig{}, querier)
+
+			rawEvent, err := standardizer.Standardize(context.Background(), []byte(alertmanagerPayload))
+
+			So(err, ShouldBeNil)
+			So(rawEvent.EventType, ShouldEqual, "KubePodCrashLooping")
+		})
+	})
+}
+
+func TestFingerprintID(t *testing.T) {
+	Convey("TestFingerprintID", t, func() {
+		Convey("十六进制 fingerprint 直接转换", func() {
+			So(fingerprintID("00000000000000ff"), ShouldEqual, uint64(255))
+		})
+
+		Convey("非十六进制 fingerprint 哈希且结果稳定", func() {
+			So(fingerprintID("not-hex"), ShouldEqual, fingerprintID("not-hex"))
+			So(fingerprintID("not-hex"), ShouldNotEqual, fingerprintID("not-hex-2"))
+		})
+	})
+}
+
+func TestMapPrometheusSeverity(t *testing.T) {
+	Convey("TestMapPrometheusSeverity", t, func() {
+		So(mapPrometheusSeverity("Critical"), ShouldEqual, domain.SeverityCritical)
+		So(mapPrometheusSeverity("error"), ShouldEqual, domain.SeverityMajor)
+		So(mapPrometheusSeverity("warn"), ShouldEqual, domain.SeverityWarning)
+		So(mapPrometheusSeverity("info"), ShouldEqual, domain.SeverityNormal)
+	})
+}
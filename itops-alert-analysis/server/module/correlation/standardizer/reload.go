@@ -0,0 +1,89 @@
+package standardizer
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+)
+
+// ConfigProvider 提供当前生效的配置，*config.ConfigManager 实现了该接口。
+type ConfigProvider interface {
+	GetConfig() *config.Config
+}
+
+// reloadingStandardizer 跟随 ConfigManager 热加载：ingest 配置变化时重新构建内部标准化器，
+// 数据源切换、json_mapping 规则修改无需重启服务。
+type reloadingStandardizer struct {
+	provider ConfigProvider
+	querier  ObjectClassQuerier
+	build    func(cfg *config.Config, querier ObjectClassQuerier) (Standardizer, error)
+
+	mu     sync.Mutex
+	cfg    *config.Config      // 上次检查的配置（ConfigManager 每次重载都会替换指针）
+	ingest config.IngestConfig // 当前标准化器对应的 ingest 配置
+	std    Standardizer
+}
+
+// BuildReloadable 根据当前配置创建标准化器，并在后续配置变更时自动重建。
+func BuildReloadable(provider ConfigProvider, querier ObjectClassQuerier) (Standardizer, error) {
+	return newReloadingStandardizer(provider, querier, Build)
+}
+
+func newReloadingStandardizer(provider ConfigProvider, querier ObjectClassQuerier,
+	build func(cfg *config.Config, querier ObjectClassQuerier) (Standardizer, error)) (*reloadingStandardizer, error) {
+	cfg := provider.GetConfig()
+	std, err := build(cfg, querier)
+	if err != nil {
+		return nil, err
+	}
+	return &reloadingStandardizer{
+		provider: provider,
+		querier:  querier,
+		build:    build,
+		cfg:      cfg,
+		ingest:   cfg.AppConfig.Ingest,
+		std:      std,
+	}, nil
+}
+
+// Standardize 使用当前配置对应的标准化器处理 payload。
+func (r *reloadingStandardizer) Standardize(ctx context.Context, payload []byte) (domain.RawEvent, error) {
+	return r.current().Standardize(ctx, payload)
+}
+
+// StandardizeBatch 使用当前配置对应的标准化器处理 payload，支持一条消息拆分为多个事件。
+func (r *reloadingStandardizer) StandardizeBatch(ctx context.Context, payload []byte) ([]domain.RawEvent, error) {
+	return StandardizeAll(ctx, r.current(), payload)
+}
+
+// current 返回当前标准化器；ingest 配置变化时重建，重建失败则保留原标准化器。
+func (r *reloadingStandardizer) current() Standardizer {
+	cfg := r.provider.GetConfig()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cfg == r.cfg {
+		return r.std
+	}
+	r.cfg = cfg
+
+	if reflect.DeepEqual(cfg.AppConfig.Ingest, r.ingest) {
+		return r.std
+	}
+
+	std, err := r.build(cfg, r.querier)
+	if err != nil {
+		log.Errorf("ingest 配置变更后重建标准化器失败，继续使用原配置: %v", err)
+		return r.std
+	}
+	log.Infof("ingest 配置已变更，标准化器已重建: source=%s, sources=%v",
+		cfg.AppConfig.Ingest.Source.Type, cfg.AppConfig.Ingest.Source.Sources)
+	r.ingest = cfg.AppConfig.Ingest
+	r.std = std
+	return std
+}
@@ -0,0 +1,73 @@
+package standardizer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// mockConfigProvider 用于测试的配置提供者
+type mockConfigProvider struct {
+	cfg *config.Config
+}
+
+func (m *mockConfigProvider) GetConfig() *config.Config {
+	return m.cfg
+}
+
+func TestReloadingStandardizer(t *testing.T) {
+	Convey("TestReloadingStandardizer", t, func() {
+		ctx := context.Background()
+		builds := 0
+		build := func(cfg *config.Config, querier ObjectClassQuerier) (Standardizer, error) {
+			if cfg.AppConfig.Ingest.Source.Type == "broken" {
+				return nil, errors.New("build failed")
+			}
+			builds++
+			return &mockStandardizer{name: cfg.AppConfig.Ingest.Source.Type}, nil
+		}
+		provider := &mockConfigProvider{cfg: newTestDispatchConfig("source_a")}
+
+		r, err := newReloadingStandardizer(provider, nil, build)
+		So(err, ShouldBeNil)
+		So(builds, ShouldEqual, 1)
+
+		Convey("配置未变化时不重建", func() {
+			provider.cfg = newTestDispatchConfig("source_a")
+
+			rawEvent, err := r.Standardize(ctx, nil)
+
+			So(err, ShouldBeNil)
+			So(rawEvent.EventTitle, ShouldEqual, "source_a")
+			So(builds, ShouldEqual, 1)
+		})
+
+		Convey("ingest 配置变化时重建", func() {
+			provider.cfg = newTestDispatchConfig("source_b")
+
+			events, err := r.StandardizeBatch(ctx, nil)
+
+			So(err, ShouldBeNil)
+			So(events[0].EventTitle, ShouldEqual, "source_b")
+			So(builds, ShouldEqual, 2)
+		})
+
+		Convey("重建失败时保留原标准化器", func() {
+			provider.cfg = newTestDispatchConfig("broken")
+
+			rawEvent, err := r.Standardize(ctx, nil)
+
+			So(err, ShouldBeNil)
+			So(rawEvent.EventTitle, ShouldEqual, "source_a")
+		})
+
+		Convey("初始构建失败返回错误", func() {
+			_, err := newReloadingStandardizer(&mockConfigProvider{cfg: newTestDispatchConfig("broken")}, nil, build)
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -2,19 +2,84 @@ package standardizer
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/idgen"
 	"github.com/pkg/errors"
 )
 
+// eventIDGen 所有标准化器共享的事件 ID 生成器。
+// 生成器按秒内序列号分配 ID，多个实例并存（多数据源、热加载重建）会产生重复 ID。
+var eventIDGen = idgen.New()
+
 // Standardizer 负责将上游原始 payload 转换为标准化 RawEvent。
 // 不同来源独立实现，避免与具体业务耦合。
 type Standardizer interface {
 	Standardize(ctx context.Context, payload []byte) (domain.RawEvent, error)
 }
 
+// BatchStandardizer 可选接口：一个 payload 中包含多条告警的数据源（如 Alertmanager 分组推送）
+// 实现该接口，将 payload 拆分为多个 RawEvent。
+type BatchStandardizer interface {
+	StandardizeBatch(ctx context.Context, payload []byte) ([]domain.RawEvent, error)
+}
+
+// ItemError 批量 payload 中单条告警标准化失败的原因，Payload 为只包含该条告警的 payload，可单独重放。
+type ItemError struct {
+	Index   int
+	Payload []byte
+	Err     error
+}
+
+func (e ItemError) Error() string {
+	return fmt.Sprintf("第 %d 条告警: %v", e.Index, e.Err)
+}
+
+func (e ItemError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError 批量 payload 中部分或全部告警标准化失败。
+// StandardizeBatch 返回 BatchError 时，同时返回的事件为标准化成功的告警，调用方应逐条处理失败的告警。
+type BatchError struct {
+	Total  int
+	Failed []ItemError
+}
+
+func (e *BatchError) Error() string {
+	if len(e.Failed) == 0 {
+		return fmt.Sprintf("%d 条告警均标准化成功", e.Total)
+	}
+	return fmt.Sprintf("%d 条告警中 %d 条标准化失败: %v", e.Total, len(e.Failed), e.Failed[0])
+}
+
+// firstEvent 返回批量标准化的第一条事件，部分告警失败时忽略失败的告警。
+func firstEvent(events []domain.RawEvent, err error) (domain.RawEvent, error) {
+	if len(events) == 0 {
+		if err == nil {
+			err = errors.New("没有可标准化的事件")
+		}
+		return domain.RawEvent{}, err
+	}
+	return events[0], nil
+}
+
+// StandardizeAll 标准化 payload：实现了 BatchStandardizer 的按批拆分，否则按单条处理。
+// 批量 payload 中部分告警失败时返回成功的事件和 *BatchError。
+func StandardizeAll(ctx context.Context, std Standardizer, payload []byte) ([]domain.RawEvent, error) {
+	if batch, ok := std.(BatchStandardizer); ok {
+		return batch.StandardizeBatch(ctx, payload)
+	}
+	raw, err := std.Standardize(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	return []domain.RawEvent{raw}, nil
+}
+
 // Build 根据数据源类型创建对应的 Standardizer。
 func Build(cfg *config.Config, querier ObjectClassQuerier) (Standardizer, error) {
 	return defaultRegistry().Resolve(cfg, querier)
@@ -26,11 +91,15 @@ type Factory func(cfg *config.Config, querier ObjectClassQuerier) (Standardizer,
 // Registry 管理不同数据源的标准化器。
 type Registry struct {
 	factories map[string]Factory
+	sniffers  map[string]Sniffer
 }
 
 // NewRegistry 创建空注册表。
 func NewRegistry() *Registry {
-	return &Registry{factories: make(map[string]Factory)}
+	return &Registry{
+		factories: make(map[string]Factory),
+		sniffers:  make(map[string]Sniffer),
+	}
 }
 
 // Register 注册数据源对应的标准化器。
@@ -42,21 +111,98 @@ func (r *Registry) Register(source string, factory Factory) {
 	r.factories[key] = factory
 }
 
+// RegisterSniffer 注册数据源的 payload 嗅探函数，多数据源模式下用于识别未显式指定来源的消息。
+func (r *Registry) RegisterSniffer(source string, sniffer Sniffer) {
+	key := normalizeSource(source)
+	if key == "" || sniffer == nil {
+		return
+	}
+	r.sniffers[key] = sniffer
+}
+
 // Resolve 根据数据源类型返回标准化器。
+// 配置了 ingest.source.sources 时进入多数据源模式，返回分发标准化器，source.type 作为默认来源。
 func (r *Registry) Resolve(cfg *config.Config, querier ObjectClassQuerier) (Standardizer, error) {
+	if len(cfg.AppConfig.Ingest.Source.Sources) > 0 {
+		return r.resolveMulti(cfg, querier)
+	}
+
 	key := strings.TrimSpace(strings.ToLower(cfg.AppConfig.Ingest.Source.Type))
-	factory, ok := r.factories[key]
+	factory, _, ok := r.lookup(cfg, key)
 	if !ok {
 		return nil, errors.Errorf("unsupported source type: %s", cfg.AppConfig.Ingest.Source.Type)
 	}
 	return factory(cfg, querier)
 }
 
+// lookup 查找数据源的工厂与嗅探函数：优先内置注册的数据源，其次是同名的 json_mapping 规则。
+func (r *Registry) lookup(cfg *config.Config, key string) (Factory, Sniffer, bool) {
+	if factory, ok := r.factories[key]; ok {
+		return factory, r.sniffers[key], true
+	}
+	for _, rule := range cfg.AppConfig.Ingest.JSONMappings {
+		if normalizeSource(rule.Name) != key {
+			continue
+		}
+		rule := rule
+		factory := func(cfg *config.Config, querier ObjectClassQuerier) (Standardizer, error) {
+			return NewJSONMappingStandardizer(cfg.AppConfig.Ingest, []config.JSONMappingRule{rule}, querier), nil
+		}
+		return factory, sniffJSONMapping(rule), true
+	}
+	return nil, nil, false
+}
+
+// resolveMulti 为每个启用的数据源创建标准化器并组装为分发标准化器。
+func (r *Registry) resolveMulti(cfg *config.Config, querier ObjectClassQuerier) (Standardizer, error) {
+	d := &dispatchStandardizer{
+		standardizers: make(map[string]Standardizer),
+		sniffers:      make(map[string]Sniffer),
+		defaultSource: normalizeSource(cfg.AppConfig.Ingest.Source.Type),
+	}
+
+	for _, source := range cfg.AppConfig.Ingest.Source.Sources {
+		key := normalizeSource(source)
+		if _, ok := d.standardizers[key]; ok {
+			continue
+		}
+		factory, sniffer, ok := r.lookup(cfg, key)
+		if !ok {
+			return nil, errors.Errorf("unsupported source type: %s", source)
+		}
+		std, err := factory(cfg, querier)
+		if err != nil {
+			return nil, errors.Wrapf(err, "build %s standardizer", key)
+		}
+		d.sources = append(d.sources, key)
+		d.standardizers[key] = std
+		if sniffer != nil {
+			d.sniffers[key] = sniffer
+		}
+	}
+
+	if _, ok := d.standardizers[d.defaultSource]; d.defaultSource != "" && !ok {
+		return nil, errors.Errorf("default source type %s not in sources", d.defaultSource)
+	}
+	return d, nil
+}
+
 // 默认注册表，包含内置标准化器。
 func defaultRegistry() *Registry {
 	r := NewRegistry()
-	r.Register("zabbix_webhook", func(cfg *config.Config, querier ObjectClassQuerier) (Standardizer, error) {
+	r.Register(domain.SourceZabbixWebhook, func(cfg *config.Config, querier ObjectClassQuerier) (Standardizer, error) {
 		return NewZabbixWebhookStandardizer(cfg.AppConfig.Ingest, querier), nil
 	})
+	r.RegisterSniffer(domain.SourceZabbixWebhook, sniffZabbixWebhook)
+	r.Register(domain.SourcePrometheusAlertmanager, func(cfg *config.Config, querier ObjectClassQuerier) (Standardizer, error) {
+		return NewPrometheusAlertmanagerStandardizer(cfg.AppConfig.Ingest, querier), nil
+	})
+	r.RegisterSniffer(domain.SourcePrometheusAlertmanager, sniffPrometheusAlertmanager)
+	r.Register(domain.SourceJSONMapping, func(cfg *config.Config, querier ObjectClassQuerier) (Standardizer, error) {
+		if len(cfg.AppConfig.Ingest.JSONMappings) == 0 {
+			return nil, errors.New("json_mapping 数据源未配置映射规则")
+		}
+		return NewJSONMappingStandardizer(cfg.AppConfig.Ingest, cfg.AppConfig.Ingest.JSONMappings, querier), nil
+	})
 	return r
 }
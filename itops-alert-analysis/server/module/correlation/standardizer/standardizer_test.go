@@ -280,3 +280,53 @@ func TestBuild(t *testing.T) {
 		})
 	})
 }
+
+// mockBatchStandardizer 用于测试的批量标准化器
+type mockBatchStandardizer struct {
+	mockStandardizer
+	count int
+	err   error
+}
+
+func (m *mockBatchStandardizer) StandardizeBatch(ctx context.Context, payload []byte) ([]domain.RawEvent, error) {
+	return make([]domain.RawEvent, m.count), m.err
+}
+
+func TestStandardizeAll(t *testing.T) {
+	Convey("TestStandardizeAll", t, func() {
+		ctx := context.Background()
+
+		Convey("普通标准化器返回单个事件", func() {
+			events, err := StandardizeAll(ctx, &mockStandardizer{name: "single"}, nil)
+
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 1)
+			So(events[0].EventTitle, ShouldEqual, "single")
+		})
+
+		Convey("批量标准化器返回全部事件", func() {
+			events, err := StandardizeAll(ctx, &mockBatchStandardizer{count: 3}, nil)
+
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 3)
+		})
+	})
+}
+
+func TestBuild_PrometheusAlertmanager(t *testing.T) {
+	Convey("TestBuild_PrometheusAlertmanager", t, func() {
+		cfg := &config.Config{
+			AppConfig: config.AppConfig{
+				Ingest: config.IngestConfig{
+					Source: config.Source{Type: "prometheus_alertmanager"},
+				},
+			},
+		}
+
+		standardizer, err := Build(cfg, &mockObjectClassQuerier{})
+
+		So(err, ShouldBeNil)
+		_, ok := standardizer.(BatchStandardizer)
+		So(ok, ShouldBeTrue)
+	})
+}
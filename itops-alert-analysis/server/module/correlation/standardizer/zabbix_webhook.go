@@ -18,7 +18,7 @@ import (
 
 // ObjectClassQuerier 对象类查询接口。
 type ObjectClassQuerier interface {
-	GetEntityObjectInfo(ctx context.Context, entityObjectName string) (*objectclass.EntityObjectInfo, error)
+	GetEntityObjectInfo(ctx context.Context, entity objectclass.EntityIdentity) (*objectclass.EntityObjectInfo, error)
 }
 
 // zabbixStandardizer 占位实现：仅声明接口，不做实际字段映射。
@@ -48,7 +48,7 @@ type ZabbixWebhook struct {
 func NewZabbixWebhookStandardizer(cfg config.IngestConfig, querier ObjectClassQuerier) Standardizer {
 	return &zabbixStandardizer{
 		cfg:                cfg,
-		genID:              idgen.New(),
+		genID:              eventIDGen,
 		objectClassQuerier: querier,
 	}
 }
@@ -78,9 +78,13 @@ func (s *zabbixStandardizer) Standardize(ctx context.Context, payload []byte) (d
 	}
 
 	// 从缓存获取实体对象信息（包含 object_type_id、object_id、name）
-	objInfo, err := s.objectClassQuerier.GetEntityObjectInfo(ctx, zabbixWebhook.EntityObjectName)
+	objInfo, err := resolveEntity(ctx, s.objectClassQuerier, s.cfg.UnresolvedEntity.Enabled, objectclass.EntityIdentity{
+		Key:  zabbixWebhook.EntityObjectName,
+		Name: zabbixWebhook.EntityObjectName,
+		IP:   zabbixWebhook.Ip,
+	})
 	if err != nil {
-		return domain.RawEvent{}, errors.Wrap(err, "获取对象信息失败")
+		return domain.RawEvent{}, err
 	}
 
 	var rawEvent = domain.RawEvent{
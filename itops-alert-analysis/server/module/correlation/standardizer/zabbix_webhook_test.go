@@ -17,7 +17,7 @@ type mockObjectClassQuerier struct {
 	err    error
 }
 
-func (m *mockObjectClassQuerier) GetEntityObjectInfo(ctx context.Context, entityObjectName string) (*objectclass.EntityObjectInfo, error) {
+func (m *mockObjectClassQuerier) GetEntityObjectInfo(ctx context.Context, entity objectclass.EntityIdentity) (*objectclass.EntityObjectInfo, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -127,6 +127,28 @@ func TestZabbixStandardizer_Standardize(t *testing.T) {
 			So(err.Error(), ShouldContainSubstring, "获取对象信息失败")
 		})
 
+		Convey("启用未解析实体兜底时关联到占位对象", func() {
+			querier := &mockObjectClassQuerier{
+				err: errors.New("object not found"),
+			}
+			cfg := config.IngestConfig{UnresolvedEntity: config.UnresolvedEntityConfig{Enabled: true}}
+			standardizer := NewZabbixWebhookStandardizer(cfg, querier)
+
+			payload := []byte(`{
+				"event_id": "100001",
+				"entity_object_name": "unknown-host",
+				"ip": "10.0.0.8"
+			}`)
+
+			rawEvent, err := standardizer.Standardize(ctx, payload)
+
+			So(err, ShouldBeNil)
+			So(rawEvent.EntityObjectClass, ShouldEqual, domain.UnresolvedObjectClass)
+			So(rawEvent.EntityObjectID, ShouldEqual, domain.UnresolvedObjectID("unknown-host"))
+			So(rawEvent.EntityObjectName, ShouldEqual, "unknown-host")
+			So(rawEvent.EntityObjectIP, ShouldEqual, "10.0.0.8")
+		})
+
 		Convey("时间解析失败不影响整体解析", func() {
 			querier := &mockObjectClassQuerier{
 				result: &objectclass.EntityObjectInfo{
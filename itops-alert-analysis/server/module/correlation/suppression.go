@@ -0,0 +1,183 @@
+package correlation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/cache"
+)
+
+const (
+	defaultDedupWindow       = 10 * time.Minute
+	defaultFlappingThreshold = 4
+	defaultFlappingWindow    = 30 * time.Minute
+
+	// dedupMaxEntries 去重记录上限，超出后按 LRU 淘汰
+	dedupMaxEntries = 100000
+	// flappingSweepEvery 每观察多少个事件清理一次过期的抖动统计
+	flappingSweepEvery = 1024
+)
+
+// dedupWindow 返回去重窗口，未配置时使用默认值。
+func dedupWindow(cfg config.DedupConfig) time.Duration {
+	if cfg.Window <= 0 {
+		return defaultDedupWindow
+	}
+	return cfg.Window
+}
+
+// flappingThreshold 返回抖动判定的状态切换次数阈值，未配置时使用默认值。
+func flappingThreshold(cfg config.FlappingConfig) int {
+	if cfg.Threshold <= 0 {
+		return defaultFlappingThreshold
+	}
+	return cfg.Threshold
+}
+
+// flappingWindow 返回抖动统计窗口，未配置时使用默认值。
+func flappingWindow(cfg config.FlappingConfig) time.Duration {
+	if cfg.Window <= 0 {
+		return defaultFlappingWindow
+	}
+	return cfg.Window
+}
+
+// eventTime 返回事件状态对应的发生时间：恢复事件取恢复时间，发生事件取发生时间，缺失时使用事件时间戳。
+func eventTime(raw domain.RawEvent) time.Time {
+	if raw.EventStatus == domain.EventStatusRecovered && raw.EventRecoveryTime != nil {
+		return *raw.EventRecoveryTime
+	}
+	if raw.EventStatus != domain.EventStatusRecovered && raw.EventOccurTime != nil {
+		return *raw.EventOccurTime
+	}
+	if !raw.EventTimestamp.IsZero() {
+		return raw.EventTimestamp
+	}
+	return time.Now()
+}
+
+// eventDeduplicator 按 数据源 + EventProviderID + 状态 识别窗口内的重复事件（如 Zabbix webhook 重发）。
+// Prometheus 等数据源同一告警反复触发时 EventProviderID 不变，记录某一状态时会清除相反状态的记录，
+// 发生 → 恢复 → 发生 中的第二次发生不视为重复，仍交给抖动检测与故障点处理。
+type eventDeduplicator struct {
+	seen *cache.MemoryCache
+}
+
+func newEventDeduplicator() *eventDeduplicator {
+	return &eventDeduplicator{seen: cache.NewMemoryCache(dedupMaxEntries)}
+}
+
+// dedupKey 生成去重键，上游未提供事件 ID 时不参与去重。
+func dedupKey(raw domain.RawEvent) (string, bool) {
+	if raw.EventProviderID == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("%s:%d:%s", raw.EventSource, raw.EventProviderID, raw.EventStatus), true
+}
+
+// oppositeStatus 返回发生/恢复的相反状态。
+func oppositeStatus(status domain.EventStatus) domain.EventStatus {
+	if status == domain.EventStatusRecovered {
+		return domain.EventStatusOccurred
+	}
+	return domain.EventStatusRecovered
+}
+
+// isDuplicate 判断事件是否已在窗口内处理过。
+func (d *eventDeduplicator) isDuplicate(ctx context.Context, raw domain.RawEvent) bool {
+	key, ok := dedupKey(raw)
+	if !ok {
+		return false
+	}
+	exists, _ := d.seen.Exists(ctx, key)
+	return exists
+}
+
+// remember 记录已成功处理的事件，处理失败的事件不记录，便于上游重发或死信重放。
+// 同时清除相反状态的记录，状态变化后再次出现的事件不会被误判为重复。
+func (d *eventDeduplicator) remember(ctx context.Context, raw domain.RawEvent, window time.Duration) {
+	key, ok := dedupKey(raw)
+	if !ok {
+		return
+	}
+	opposite := raw
+	opposite.EventStatus = oppositeStatus(raw.EventStatus)
+	if oppositeKey, ok := dedupKey(opposite); ok {
+		_ = d.seen.Del(ctx, oppositeKey)
+	}
+	_ = d.seen.Set(ctx, key, "1", window)
+}
+
+// flappingDetector 按 实体 + EventType 统计窗口内的发生/恢复状态切换次数，达到阈值即判定为抖动。
+type flappingDetector struct {
+	mu       sync.Mutex
+	states   map[string]*flappingState
+	observed int
+}
+
+// flappingState 单个实体 + EventType 的状态切换记录。
+type flappingState struct {
+	lastStatus domain.EventStatus
+	lastSeen   time.Time
+	changes    []time.Time // 窗口内状态切换时间
+}
+
+func newFlappingDetector() *flappingDetector {
+	return &flappingDetector{states: make(map[string]*flappingState)}
+}
+
+// observe 记录事件状态并返回该实体 + EventType 当前是否处于抖动。
+func (d *flappingDetector) observe(raw domain.RawEvent, threshold int, window time.Duration) bool {
+	if raw.EntityObjectID == "" || raw.EventType == "" {
+		return false
+	}
+	key := raw.EntityObjectID + "|" + raw.EventType
+	at := eventTime(raw)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.observed++
+	if d.observed%flappingSweepEvery == 0 {
+		d.sweep(at.Add(-window))
+	}
+
+	state, ok := d.states[key]
+	if !ok {
+		d.states[key] = &flappingState{lastStatus: raw.EventStatus, lastSeen: at}
+		return false
+	}
+
+	if state.lastStatus != raw.EventStatus {
+		state.changes = append(state.changes, at)
+		state.lastStatus = raw.EventStatus
+	}
+	if at.After(state.lastSeen) {
+		state.lastSeen = at
+	}
+
+	// 丢弃窗口外的状态切换
+	since := at.Add(-window)
+	kept := state.changes[:0]
+	for _, t := range state.changes {
+		if !t.Before(since) {
+			kept = append(kept, t)
+		}
+	}
+	state.changes = kept
+
+	return len(state.changes) >= threshold
+}
+
+// sweep 清理 before 之前已无事件的统计，限制内存占用。
+func (d *flappingDetector) sweep(before time.Time) {
+	for key, state := range d.states {
+		if state.lastSeen.Before(before) {
+			delete(d.states, key)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package correlation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEventDeduplicator(t *testing.T) {
+	Convey("TestEventDeduplicator", t, func() {
+		ctx := context.Background()
+		d := newEventDeduplicator()
+		raw := domain.RawEvent{EventProviderID: 100, EventSource: "zabbix_webhook", EventStatus: domain.EventStatusOccurred}
+
+		Convey("未记录的事件不是重复事件", func() {
+			So(d.isDuplicate(ctx, raw), ShouldBeFalse)
+		})
+
+		Convey("窗口内相同数据源、事件 ID、状态的事件为重复事件", func() {
+			d.remember(ctx, raw, time.Minute)
+
+			So(d.isDuplicate(ctx, raw), ShouldBeTrue)
+		})
+
+		Convey("状态或数据源不同不视为重复", func() {
+			d.remember(ctx, raw, time.Minute)
+
+			recovered := raw
+			recovered.EventStatus = domain.EventStatusRecovered
+			other := raw
+			other.EventSource = "prometheus_alertmanager"
+
+			So(d.isDuplicate(ctx, recovered), ShouldBeFalse)
+			So(d.isDuplicate(ctx, other), ShouldBeFalse)
+		})
+
+		Convey("同一告警 发生 → 恢复 → 发生 时再次发生不视为重复", func() {
+			fired := domain.RawEvent{EventProviderID: 200, EventSource: "prometheus_alertmanager", EventStatus: domain.EventStatusOccurred}
+			resolved := fired
+			resolved.EventStatus = domain.EventStatusRecovered
+
+			So(d.isDuplicate(ctx, fired), ShouldBeFalse)
+			d.remember(ctx, fired, time.Minute)
+			So(d.isDuplicate(ctx, resolved), ShouldBeFalse)
+			d.remember(ctx, resolved, time.Minute)
+
+			So(d.isDuplicate(ctx, fired), ShouldBeFalse)
+			d.remember(ctx, fired, time.Minute)
+			So(d.isDuplicate(ctx, fired), ShouldBeTrue)
+			So(d.isDuplicate(ctx, resolved), ShouldBeFalse)
+		})
+
+		Convey("窗口过期后不再视为重复", func() {
+			d.remember(ctx, raw, time.Millisecond)
+			time.Sleep(5 * time.Millisecond)
+
+			So(d.isDuplicate(ctx, raw), ShouldBeFalse)
+		})
+
+		Convey("未提供事件 ID 时不参与去重", func() {
+			noID := raw
+			noID.EventProviderID = 0
+			d.remember(ctx, noID, time.Minute)
+
+			So(d.isDuplicate(ctx, noID), ShouldBeFalse)
+		})
+	})
+}
+
+func TestFlappingDetector(t *testing.T) {
+	Convey("TestFlappingDetector", t, func() {
+		d := newFlappingDetector()
+		base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)
+		event := func(status domain.EventStatus, offset time.Duration) domain.RawEvent {
+			return domain.RawEvent{
+				EntityObjectID: "host-1",
+				EventType:      "cpu.load",
+				EventStatus:    status,
+				EventTimestamp: base.Add(offset),
+			}
+		}
+
+		Convey("状态切换达到阈值判定为抖动", func() {
+			So(d.observe(event(domain.EventStatusOccurred, 0), 3, 10*time.Minute), ShouldBeFalse)
+			So(d.observe(event(domain.EventStatusRecovered, time.Minute), 3, 10*time.Minute), ShouldBeFalse)
+			So(d.observe(event(domain.EventStatusOccurred, 2*time.Minute), 3, 10*time.Minute), ShouldBeFalse)
+			So(d.observe(event(domain.EventStatusRecovered, 3*time.Minute), 3, 10*time.Minute), ShouldBeTrue)
+		})
+
+		Convey("相同状态的重复事件不计为状态切换", func() {
+			for i := 0; i < 5; i++ {
+				So(d.observe(event(domain.EventStatusOccurred, time.Duration(i)*time.Minute), 2, 10*time.Minute), ShouldBeFalse)
+			}
+		})
+
+		Convey("窗口外的状态切换不计入", func() {
+			d.observe(event(domain.EventStatusOccurred, 0), 2, 10*time.Minute)
+			d.observe(event(domain.EventStatusRecovered, time.Minute), 2, 10*time.Minute)
+
+			So(d.observe(event(domain.EventStatusOccurred, 30*time.Minute), 2, 10*time.Minute), ShouldBeFalse)
+		})
+
+		Convey("不同实体分别统计", func() {
+			d.observe(event(domain.EventStatusOccurred, 0), 2, 10*time.Minute)
+			d.observe(event(domain.EventStatusRecovered, time.Minute), 2, 10*time.Minute)
+
+			other := event(domain.EventStatusOccurred, 2*time.Minute)
+			other.EntityObjectID = "host-2"
+
+			So(d.observe(other, 2, 10*time.Minute), ShouldBeFalse)
+			So(d.observe(event(domain.EventStatusOccurred, 2*time.Minute), 2, 10*time.Minute), ShouldBeTrue)
+		})
+
+		Convey("清理长时间无事件的统计", func() {
+			d.observe(event(domain.EventStatusOccurred, 0), 2, 10*time.Minute)
+
+			d.sweep(base.Add(time.Hour))
+
+			So(d.states, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestSuppressionDefaults(t *testing.T) {
+	Convey("TestSuppressionDefaults", t, func() {
+		So(dedupWindow(config.DedupConfig{}), ShouldEqual, defaultDedupWindow)
+		So(dedupWindow(config.DedupConfig{Window: time.Minute}), ShouldEqual, time.Minute)
+		So(flappingThreshold(config.FlappingConfig{}), ShouldEqual, defaultFlappingThreshold)
+		So(flappingWindow(config.FlappingConfig{Window: time.Hour}), ShouldEqual, time.Hour)
+	})
+}
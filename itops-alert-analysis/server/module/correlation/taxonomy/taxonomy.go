@@ -0,0 +1,141 @@
+// Package taxonomy 实现故障模式归一：按故障模式目录将各监控工具的监控项 key / 告警标题映射为统一的故障模式。
+package taxonomy
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	"github.com/pkg/errors"
+)
+
+var errEmptyPattern = errors.New("pattern 为空")
+
+// Classifier 按当前配置的故障模式目录归一事件。
+type Classifier struct {
+	cfgManager config.Provider
+
+	mu       sync.Mutex
+	source   []config.FailureModeEntry // 已编译的目录，配置重载后切片会被整体替换
+	compiled []compiledRule
+}
+
+// compiledRule 编译后的匹配规则。
+type compiledRule struct {
+	match domain.FailureModeMatch
+	re    *regexp.Regexp
+}
+
+// NewClassifier 创建故障模式归一器。
+func NewClassifier(cfgManager config.Provider) *Classifier {
+	return &Classifier{cfgManager: cfgManager}
+}
+
+// Classify 返回事件命中的故障模式。
+// 指定对象类的规则优先于不限对象类的规则，同一优先级按目录顺序取第一个命中的规则。
+func (c *Classifier) Classify(raw domain.RawEvent) (domain.FailureModeMatch, bool) {
+	if c == nil || c.cfgManager == nil {
+		return domain.FailureModeMatch{}, false
+	}
+	rules := c.rules()
+
+	for _, classSpecific := range []bool{true, false} {
+		for _, rule := range rules {
+			if (rule.match.ObjectClass != "") != classSpecific {
+				continue
+			}
+			if classSpecific && rule.match.ObjectClass != raw.EntityObjectClass {
+				continue
+			}
+			if rule.re.MatchString(fieldValue(raw, rule.match.Field)) {
+				return rule.match, true
+			}
+		}
+	}
+	return domain.FailureModeMatch{}, false
+}
+
+// rules 返回当前目录的编译结果，目录变化时重新编译。
+func (c *Classifier) rules() []compiledRule {
+	modes := c.cfgManager.GetConfig().AppConfig.FailureModes
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if sameSlice(c.source, modes) {
+		return c.compiled
+	}
+	c.source = modes
+	c.compiled = compile(modes)
+	return c.compiled
+}
+
+// compile 编译故障模式目录，非法规则记录日志后跳过。
+func compile(modes []config.FailureModeEntry) []compiledRule {
+	var rules []compiledRule
+	for _, mode := range modes {
+		if mode.Mode == "" {
+			continue
+		}
+		for _, rule := range mode.Rules {
+			match := domain.FailureModeMatch{
+				Mode:        mode.Mode,
+				Name:        mode.Name,
+				ObjectClass: rule.ObjectClass,
+				Field:       rule.Field,
+				Match:       rule.Match,
+				Pattern:     rule.Pattern,
+			}
+			if match.Field == "" {
+				match.Field = config.FailureModeFieldItemKey
+			}
+			if match.Match == "" {
+				match.Match = config.FailureModeMatchGlob
+			}
+			re, err := compilePattern(match.Match, rule.Pattern)
+			if err != nil {
+				log.Warnf("故障模式 %s 的规则 %q 无法编译，已忽略: %v", mode.Mode, rule.Pattern, err)
+				continue
+			}
+			rules = append(rules, compiledRule{match: match, re: re})
+		}
+	}
+	return rules
+}
+
+// compilePattern 编译匹配模式。glob 整体匹配且忽略大小写，regex 按原样部分匹配。
+func compilePattern(match, pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, errEmptyPattern
+	}
+	if match == config.FailureModeMatchRegex {
+		return regexp.Compile(pattern)
+	}
+	return regexp.Compile(globToRegexp(pattern))
+}
+
+// globToRegexp 将 glob 转换为正则：* 匹配任意字符，? 匹配单个字符。
+func globToRegexp(pattern string) string {
+	quoted := regexp.QuoteMeta(pattern)
+	quoted = strings.ReplaceAll(quoted, `\*`, `.*`)
+	quoted = strings.ReplaceAll(quoted, `\?`, `.`)
+	return `(?i)^` + quoted + `$`
+}
+
+// fieldValue 返回规则匹配的事件字段。
+func fieldValue(raw domain.RawEvent, field string) string {
+	if field == config.FailureModeFieldTitle {
+		return raw.EventTitle
+	}
+	return raw.EventType
+}
+
+// sameSlice 判断两个目录切片是否为同一份配置。
+func sameSlice(a, b []config.FailureModeEntry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	return len(a) == 0 || &a[0] == &b[0]
+}
@@ -0,0 +1,123 @@
+package taxonomy
+
+import (
+	"testing"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config/configtest"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newTestClassifier(modes ...config.FailureModeEntry) (*Classifier, *config.Config) {
+	cfg := &config.Config{AppConfig: config.AppConfig{FailureModes: modes}}
+	return NewClassifier(configtest.NewProvider(cfg)), cfg
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	Convey("TestGlobToRegexp", t, func() {
+		So(globToRegexp("system.cpu.util*"), ShouldEqual, `(?i)^system\.cpu\.util.*$`)
+		So(globToRegexp("vfs.fs.size[?,pused]"), ShouldEqual, `(?i)^vfs\.fs\.size\[.,pused\]$`)
+	})
+}
+
+func TestClassifier_Classify(t *testing.T) {
+	Convey("TestClassifier_Classify", t, func() {
+		cpu := config.FailureModeEntry{
+			Mode: "cpu_saturation",
+			Name: "CPU 饱和",
+			Rules: []config.FailureModeRule{
+				{Pattern: "system.cpu.util*"},
+				{Field: config.FailureModeFieldTitle, Match: config.FailureModeMatchRegex, Pattern: `(?i)high cpu`},
+			},
+		}
+		disk := config.FailureModeEntry{
+			Mode: "disk_full",
+			Name: "磁盘满",
+			Rules: []config.FailureModeRule{
+				{Pattern: "vfs.fs.size[*,pused]"},
+			},
+		}
+		podDisk := config.FailureModeEntry{
+			Mode: "pod_ephemeral_storage_full",
+			Rules: []config.FailureModeRule{
+				{ObjectClass: "pod", Pattern: "vfs.fs.size[*"},
+			},
+		}
+
+		Convey("glob 默认匹配 item_key 且忽略大小写", func() {
+			c, _ := newTestClassifier(cpu, disk)
+
+			match, ok := c.Classify(domain.RawEvent{EventType: "System.CPU.Util[,user]"})
+			So(ok, ShouldBeTrue)
+			So(match.Mode, ShouldEqual, "cpu_saturation")
+			So(match.Name, ShouldEqual, "CPU 饱和")
+			So(match.Field, ShouldEqual, config.FailureModeFieldItemKey)
+			So(match.Match, ShouldEqual, config.FailureModeMatchGlob)
+
+			match, ok = c.Classify(domain.RawEvent{EventType: "vfs.fs.size[/data,pused]"})
+			So(ok, ShouldBeTrue)
+			So(match.Mode, ShouldEqual, "disk_full")
+		})
+
+		Convey("regex 按告警标题匹配", func() {
+			c, _ := newTestClassifier(cpu)
+
+			match, ok := c.Classify(domain.RawEvent{EventType: "node_cpu_seconds_total", EventTitle: "High CPU usage on node-1"})
+			So(ok, ShouldBeTrue)
+			So(match.Mode, ShouldEqual, "cpu_saturation")
+			So(match.Field, ShouldEqual, config.FailureModeFieldTitle)
+		})
+
+		Convey("指定对象类的规则优先于不限对象类的规则", func() {
+			c, _ := newTestClassifier(disk, podDisk)
+			raw := domain.RawEvent{EventType: "vfs.fs.size[/data,pused]", EntityObjectClass: "pod"}
+
+			match, ok := c.Classify(raw)
+			So(ok, ShouldBeTrue)
+			So(match.Mode, ShouldEqual, "pod_ephemeral_storage_full")
+
+			raw.EntityObjectClass = "host"
+			match, _ = c.Classify(raw)
+			So(match.Mode, ShouldEqual, "disk_full")
+		})
+
+		Convey("未命中或目录为空时返回 false", func() {
+			c, _ := newTestClassifier(cpu)
+			_, ok := c.Classify(domain.RawEvent{EventType: "net.if.in[eth0]"})
+			So(ok, ShouldBeFalse)
+
+			c, _ = newTestClassifier()
+			_, ok = c.Classify(domain.RawEvent{EventType: "system.cpu.util"})
+			So(ok, ShouldBeFalse)
+
+			var nilClassifier *Classifier
+			_, ok = nilClassifier.Classify(domain.RawEvent{EventType: "system.cpu.util"})
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("非法正则被忽略，不影响其他规则", func() {
+			broken := config.FailureModeEntry{
+				Mode:  "broken",
+				Rules: []config.FailureModeRule{{Match: config.FailureModeMatchRegex, Pattern: "("}, {Pattern: ""}},
+			}
+			c, _ := newTestClassifier(broken, cpu)
+
+			match, ok := c.Classify(domain.RawEvent{EventType: "system.cpu.util"})
+			So(ok, ShouldBeTrue)
+			So(match.Mode, ShouldEqual, "cpu_saturation")
+		})
+
+		Convey("目录重载后重新编译", func() {
+			c, cfg := newTestClassifier(cpu)
+			raw := domain.RawEvent{EventType: "vfs.fs.size[/,pused]"}
+			_, ok := c.Classify(raw)
+			So(ok, ShouldBeFalse)
+
+			cfg.AppConfig.FailureModes = []config.FailureModeEntry{cpu, disk}
+			match, ok := c.Classify(raw)
+			So(ok, ShouldBeTrue)
+			So(match.Mode, ShouldEqual, "disk_full")
+		})
+	})
+}
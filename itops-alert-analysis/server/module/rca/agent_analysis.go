@@ -26,7 +26,7 @@ type FaultPointPair struct {
 
 // 使用 Agent 进行因果推理
 // 策略：1) 只过滤明显不相关的对 2) 提高并发数 3) 智能优先级排序 4) 保证所有相关对都被分析
-func (s *Service) findCausalCandidates(ctx context.Context, faultPointInfos []domain.FaultPointObject, recallCtx *domain.GraphRecallContext) []domain.CausalCandidate {
+func (s *Service) findCausalCandidates(ctx context.Context, faultPointInfos []domain.FaultPointObject, recallCtx *domain.GraphRecallContext, progress *progressReporter) []domain.CausalCandidate {
 	totalPairs := len(faultPointInfos) * (len(faultPointInfos) - 1) / 2
 	startTime := time.Now()
 	log.Infof("开始使用 Agent 进行因果推理，故障点数量: %d, 预计分析对数: %d", len(faultPointInfos), totalPairs, startTime.Format(time.RFC3339))
@@ -38,9 +38,18 @@ func (s *Service) findCausalCandidates(ctx context.Context, faultPointInfos []do
 
 	// 步骤2：按优先级排序，优先分析重要的故障点对
 	sortedPairs := s.sortPairsByPriority(filteredPairs)
+	progress.pairsStarted(ctx, len(sortedPairs))
 
-	// 步骤3：并发处理所有故障点对（保证准确性：所有对都分析）
-	candidates := s.processAllPairsConcurrently(ctx, sortedPairs, recallCtx)
+	// 步骤3：启用整问题推理时先整体分析，失败的部分再逐对推理
+	var candidates []domain.CausalCandidate
+	pendingPairs := sortedPairs
+	if s.config.RCA.Inference.Batch {
+		candidates, pendingPairs = s.batchCausalAnalysis(ctx, sortedPairs, recallCtx)
+		progress.pairsAnalyzed(ctx, len(sortedPairs)-len(pendingPairs), len(candidates))
+	}
+
+	// 步骤4：并发处理剩余故障点对（保证准确性：所有对都分析）
+	candidates = append(candidates, s.processAllPairsConcurrently(ctx, pendingPairs, recallCtx, progress)...)
 
 	log.Infof("因果推理完成: 分析 %d 对故障点, 发现 %d 个因果关系", len(sortedPairs), len(candidates), time.Since(startTime))
 
@@ -146,7 +155,7 @@ func (s *Service) calculatePairPriorityScore(fpA, fpB *domain.FaultPointObject)
 
 // processAllPairsConcurrently 并发处理所有故障点对
 // 优化：修复错误处理和边界情况
-func (s *Service) processAllPairsConcurrently(ctx context.Context, pairs []FaultPointPair, recallCtx *domain.GraphRecallContext) []domain.CausalCandidate {
+func (s *Service) processAllPairsConcurrently(ctx context.Context, pairs []FaultPointPair, recallCtx *domain.GraphRecallContext, progress *progressReporter) []domain.CausalCandidate {
 	if len(pairs) == 0 {
 		return []domain.CausalCandidate{}
 	}
@@ -160,16 +169,13 @@ func (s *Service) processAllPairsConcurrently(ctx context.Context, pairs []Fault
 		candidatesMu sync.Mutex
 		candidates   []domain.CausalCandidate
 		processed    int64
-		agentSuccess int64
-		localSuccess int64
+		matched      int64
 	)
 
 	// 并发处理所有故障点对
 	for _, pair := range pairs {
 		// 捕获循环变量（重要：避免闭包问题）
-		fpA := pair.FpA
-		fpB := pair.FpB
-		priority := pair.Priority
+		pair := pair
 
 		g.Go(func() error {
 			// 检查上下文是否已取消
@@ -179,39 +185,10 @@ func (s *Service) processAllPairsConcurrently(ctx context.Context, pairs []Fault
 
 			atomic.AddInt64(&processed, 1)
 
-			// 策略：根据优先级决定是否优先使用Agent
-			// 高优先级的对使用Agent，低优先级的对直接使用本地规则（更快）
-			// 但保证所有对都被分析
-			useAgent := priority > 10.0 // 优先级超过10的对使用Agent
-
-			var pairCandidates []domain.CausalCandidate
-			if useAgent {
-				// 尝试使用 Agent 进行因果推理
-				agentCandidates := s.tryAgentCausalAnalysis(gctx, fpA, fpB, recallCtx)
-
-				if len(agentCandidates) > 0 {
-					pairCandidates = agentCandidates
-					atomic.AddInt64(&agentSuccess, 1)
-					log.Infof("Agent 因果推理成功: 故障点A ID=%d, 故障点B ID=%d, 发现 %d 个因果关系, 优先级: %.2f",
-						fpA.FaultID, fpB.FaultID, len(agentCandidates), priority)
-				} else {
-					// Agent失败，使用本地规则作为兜底（保证准确性）
-					fallbackCandidate := s.calculateCausalCandidate(fpA, fpB, recallCtx)
-					if fallbackCandidate != nil {
-						pairCandidates = []domain.CausalCandidate{*fallbackCandidate}
-						log.Infof("Agent失败，使用本地规则: 故障点A ID=%d, 故障点B ID=%d, 置信度: %.2f, 优先级: %.2f",
-							fpA.FaultID, fpB.FaultID, fallbackCandidate.Confidence, priority)
-					}
-				}
-			} else {
-				// 低优先级的对直接使用本地规则（更快，但准确性由本地规则保证）
-				fallbackCandidate := s.calculateCausalCandidate(fpA, fpB, recallCtx)
-				if fallbackCandidate != nil {
-					pairCandidates = []domain.CausalCandidate{*fallbackCandidate}
-					atomic.AddInt64(&localSuccess, 1)
-					log.Infof("本地规则分析: 故障点A ID=%d, 故障点B ID=%d, 置信度: %.2f, 优先级: %.2f",
-						fpA.FaultID, fpB.FaultID, fallbackCandidate.Confidence, priority)
-				}
+			// 由配置的推理引擎分析，保证所有对都被分析
+			pairCandidates := s.inferencer.Infer(gctx, pair, recallCtx)
+			if len(pairCandidates) > 0 {
+				atomic.AddInt64(&matched, 1)
 			}
 
 			// 添加结果（线程安全）
@@ -220,6 +197,7 @@ func (s *Service) processAllPairsConcurrently(ctx context.Context, pairs []Fault
 				candidates = append(candidates, pairCandidates...)
 				candidatesMu.Unlock()
 			}
+			progress.pairsAnalyzed(gctx, 1, len(pairCandidates))
 
 			return nil
 		})
@@ -231,8 +209,8 @@ func (s *Service) processAllPairsConcurrently(ctx context.Context, pairs []Fault
 		// 即使有错误，也返回已收集的结果
 	}
 
-	log.Infof("分析完成: 处理 %d 对, Agent成功 %d 对, 本地规则 %d 对, 发现 %d 个因果关系",
-		atomic.LoadInt64(&processed), atomic.LoadInt64(&agentSuccess), atomic.LoadInt64(&localSuccess), len(candidates))
+	log.Infof("分析完成: 推理引擎 %s, 处理 %d 对, 其中 %d 对存在因果关系, 发现 %d 个因果关系",
+		s.inferencer.Name(), atomic.LoadInt64(&processed), atomic.LoadInt64(&matched), len(candidates))
 
 	return candidates
 }
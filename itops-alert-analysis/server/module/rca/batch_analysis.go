@@ -0,0 +1,286 @@
+package rca
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/dip"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	"golang.org/x/sync/errgroup"
+)
+
+// causalChunk 一次整问题因果推理请求覆盖的故障点对，pairs 为在全部故障点对中的下标
+type causalChunk struct {
+	pairs       []int
+	faultPoints []*domain.FaultPointObject
+}
+
+// batchCausalAnalysis 整问题因果推理：将故障点时间线与精简拓扑一次发送给 Agent，解析完整的因果边列表。
+// 请求超过 maxInputTokens 时按故障点对构成的连通分量分块；单个连通分量仍超限或调用失败时，
+// 其故障点对留给逐对推理。Agent 成功返回的分块中未给出因果边的故障点对视为不存在因果关系。
+// 返回批量得到的因果候选和需要逐对推理的故障点对（保持原有优先级顺序）。
+func (s *Service) batchCausalAnalysis(ctx context.Context, pairs []FaultPointPair, recallCtx *domain.GraphRecallContext) ([]domain.CausalCandidate, []FaultPointPair) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	causalConfig, err := s.buildBatchCausalConfig()
+	if err != nil {
+		log.Warnf("整问题因果分析 Agent 配置无效，全部故障点对逐对推理: %v", err)
+		return nil, pairs
+	}
+
+	chunks, oversized := s.packCausalChunks(pairs, recallCtx)
+	resolved := make([]bool, len(pairs))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxConcurrentAnalysis)
+	var (
+		mu         sync.Mutex
+		candidates []domain.CausalCandidate
+	)
+	for _, chunk := range chunks {
+		chunk := chunk
+		g.Go(func() error {
+			agentCtx, cancel := context.WithTimeout(gctx, agentCallTimeout)
+			defer cancel()
+
+			edges, err := s.dipClient.CallCausalBatchAgent(agentCtx, causalConfig, s.buildBatchCustomQuerys(chunk, recallCtx))
+			if err != nil {
+				log.Warnf("整问题因果推理失败，%d 个故障点、%d 对故障点改为逐对推理: %v",
+					len(chunk.faultPoints), len(chunk.pairs), err)
+				return nil
+			}
+			chunkCandidates := s.mapBatchEdgesToCandidates(edges, chunk, pairs, recallCtx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			candidates = append(candidates, chunkCandidates...)
+			for _, i := range chunk.pairs {
+				resolved[i] = true
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	remaining := make([]FaultPointPair, 0, len(pairs))
+	for i, pair := range pairs {
+		if !resolved[i] {
+			remaining = append(remaining, pair)
+		}
+	}
+	log.Infof("整问题因果推理完成: %d 个分块 (%d 个连通分量超出 token 上限), 覆盖 %d 对, 发现 %d 个因果关系, %d 对改为逐对推理",
+		len(chunks), oversized, len(pairs)-len(remaining), len(candidates), len(remaining))
+	return candidates, remaining
+}
+
+// buildBatchCausalConfig 从配置构建整问题因果分析 Agent 的 CausalConfig
+func (s *Service) buildBatchCausalConfig() (dip.CausalConfig, error) {
+	config := dip.CausalConfig{
+		AppID:         s.config.Platform.Agents.CausalBatch.AppID,
+		AgentKey:      s.config.Platform.Agents.CausalBatch.AgentKey,
+		Authorization: s.config.AppConfig.Credentials.Authorization,
+	}
+	if config.AppID == "" {
+		return config, fmt.Errorf("AppID 不能为空")
+	}
+	if config.AgentKey == "" {
+		return config, fmt.Errorf("AgentKey 不能为空")
+	}
+	if config.Authorization == "" {
+		return config, fmt.Errorf("Authorization 不能为空")
+	}
+	return config, nil
+}
+
+// packCausalChunks 将故障点对按连通分量合并为不超过 maxInputTokens 的分块，整个问题不超限时只有一个分块。
+// 每个连通分量只估算一次 token 数，分块的 token 数取其中各连通分量估算值之和；
+// 不同连通分量可能涉及相同的拓扑关系，求和会略微高估，分块因此偏保守。
+// 返回分块及单独超限的连通分量数，超限连通分量的故障点对不在任何分块中。
+func (s *Service) packCausalChunks(pairs []FaultPointPair, recallCtx *domain.GraphRecallContext) ([]causalChunk, int) {
+	var (
+		chunks        []causalChunk
+		current       causalChunk
+		currentTokens int
+		oversized     int
+	)
+	for _, component := range connectedComponents(pairs) {
+		tokens := s.estimateTokenCount(s.buildBatchCustomQuerys(component, recallCtx))
+		if tokens > maxInputTokens {
+			oversized++
+			log.Infof("连通分量超出 token 上限，%d 个故障点、%d 对故障点改为逐对推理", len(component.faultPoints), len(component.pairs))
+			continue
+		}
+		if len(current.pairs) > 0 && currentTokens+tokens > maxInputTokens {
+			chunks = append(chunks, current)
+			current, currentTokens = causalChunk{}, 0
+		}
+		current.pairs = append(current.pairs, component.pairs...)
+		current.faultPoints = append(current.faultPoints, component.faultPoints...)
+		currentTokens += tokens
+	}
+	if len(current.pairs) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks, oversized
+}
+
+// connectedComponents 以故障点为节点、故障点对为边划分连通分量，分量内故障点按 FaultID 排序。
+// 分量按其中第一个故障点对在 pairs 中的下标排序，不重新比较优先级：
+// 调用方传入按优先级降序排列的 pairs 时，分量即按其中最高优先级的故障点对排序。
+func connectedComponents(pairs []FaultPointPair) []causalChunk {
+	parent := make(map[uint64]uint64)
+	var find func(id uint64) uint64
+	find = func(id uint64) uint64 {
+		if parent[id] != id {
+			parent[id] = find(parent[id])
+		}
+		return parent[id]
+	}
+	faultPoints := make(map[uint64]*domain.FaultPointObject)
+	for _, pair := range pairs {
+		for _, fp := range []*domain.FaultPointObject{pair.FpA, pair.FpB} {
+			if _, ok := parent[fp.FaultID]; !ok {
+				parent[fp.FaultID] = fp.FaultID
+				faultPoints[fp.FaultID] = fp
+			}
+		}
+		if a, b := find(pair.FpA.FaultID), find(pair.FpB.FaultID); a != b {
+			parent[a] = b
+		}
+	}
+
+	index := make(map[uint64]int)
+	var components []causalChunk
+	for i, pair := range pairs {
+		root := find(pair.FpA.FaultID)
+		n, ok := index[root]
+		if !ok {
+			n = len(components)
+			index[root] = n
+			components = append(components, causalChunk{})
+		}
+		components[n].pairs = append(components[n].pairs, i)
+	}
+	for id, fp := range faultPoints {
+		n := index[find(id)]
+		components[n].faultPoints = append(components[n].faultPoints, fp)
+	}
+	for _, component := range components {
+		sort.Slice(component.faultPoints, func(i, j int) bool {
+			return component.faultPoints[i].FaultID < component.faultPoints[j].FaultID
+		})
+	}
+	return components
+}
+
+// buildBatchCustomQuerys 构建整问题因果推理请求：按发生时间排序的故障点时间线，以及与这些故障点实体相关的拓扑关系（只保留边）
+func (s *Service) buildBatchCustomQuerys(chunk causalChunk, recallCtx *domain.GraphRecallContext) map[string]interface{} {
+	timeline := make([]*domain.FaultPointObject, len(chunk.faultPoints))
+	copy(timeline, chunk.faultPoints)
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].FaultOccurTime.Before(timeline[j].FaultOccurTime)
+	})
+
+	faultTimeline := make([]map[string]interface{}, 0, len(timeline))
+	entities := make(map[string]bool)
+	for _, fp := range timeline {
+		faultTimeline = append(faultTimeline, s.buildAgentFaultPointPayloadOptimized(fp))
+		if fp.EntityObjectID != "" {
+			entities[fp.EntityObjectID] = true
+		}
+	}
+
+	relations := make([]domain.Relation, 0)
+	if recallCtx != nil {
+		// 按子图 key 排序，保证请求内容稳定
+		keys := make([]string, 0, len(recallCtx.TopologySubgraphs))
+		for key := range recallCtx.TopologySubgraphs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		edgeSet := make(map[string]bool)
+		for _, key := range keys {
+			topology := recallCtx.TopologySubgraphs[key]
+			if topology == nil {
+				continue
+			}
+			for _, edge := range topology.Edges {
+				if !entities[edge.SourceSID] && !entities[edge.TargetSID] {
+					continue
+				}
+				edgeKey := fmt.Sprintf("%s->%s:%s", edge.SourceSID, edge.TargetSID, edge.RelationID)
+				if edgeSet[edgeKey] {
+					continue
+				}
+				edgeSet[edgeKey] = true
+				relations = append(relations, domain.Relation{
+					RelationID:    edge.RelationID,
+					RelationClass: edge.RelationClass,
+					SourceSID:     edge.SourceSID,
+					TargetSID:     edge.TargetSID,
+				})
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		agentRequestFieldFaultTimeline:     faultTimeline,
+		agentRequestFieldTopologyRelations: relations,
+	}
+}
+
+// mapBatchEdgesToCandidates 将整问题推理返回的因果边映射为 CausalCandidate。
+// 只接受分块内故障点对之间的因果边，同一方向重复时取置信度最高的一条。
+func (s *Service) mapBatchEdgesToCandidates(edges []domain.AgentCausalEdge, chunk causalChunk, pairs []FaultPointPair, recallCtx *domain.GraphRecallContext) []domain.CausalCandidate {
+	idToFP := make(map[uint64]*domain.FaultPointObject, len(chunk.faultPoints))
+	for _, fp := range chunk.faultPoints {
+		idToFP[fp.FaultID] = fp
+	}
+	allowed := make(map[[2]uint64]bool, len(chunk.pairs))
+	for _, i := range chunk.pairs {
+		allowed[[2]uint64{pairs[i].FpA.FaultID, pairs[i].FpB.FaultID}] = true
+		allowed[[2]uint64{pairs[i].FpB.FaultID, pairs[i].FpA.FaultID}] = true
+	}
+
+	best := make(map[[2]uint64]int)
+	var candidates []domain.CausalCandidate
+	for _, e := range edges {
+		key := [2]uint64{e.Source, e.Target}
+		causeFP, effectFP := idToFP[e.Source], idToFP[e.Target]
+		if causeFP == nil || effectFP == nil || e.Source == e.Target || !allowed[key] {
+			log.Debugf("跳过无效的因果边: source=%d, target=%d", e.Source, e.Target)
+			continue
+		}
+
+		confidence := e.Confidence
+		if confidence < 0 {
+			confidence = 0
+		}
+		if confidence > 1 {
+			confidence = 1
+		}
+
+		if i, ok := best[key]; ok {
+			if confidence > candidates[i].Confidence {
+				candidates[i].Confidence = confidence
+				candidates[i].Reason = e.Reason
+			}
+			continue
+		}
+		best[key] = len(candidates)
+		candidates = append(candidates, domain.CausalCandidate{
+			Cause:      causeFP,
+			Effect:     effectFP,
+			Confidence: confidence,
+			Reason:     e.Reason,
+			IsNew:      s.isNewCausalRelation(causeFP.EntityObjectID, effectFP.EntityObjectID, recallCtx),
+		})
+	}
+	return candidates
+}
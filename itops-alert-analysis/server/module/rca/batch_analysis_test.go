@@ -0,0 +1,196 @@
+package rca
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/dip"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newBatchTestService(serverURL string) *Service {
+	cfg := config.Config{}
+	cfg.Platform.Agents.CausalBatch = config.AgentConfig{AppID: "app", AgentKey: "key"}
+	cfg.AppConfig.Credentials.Authorization = "Bearer test-token"
+	return &Service{
+		config:    cfg,
+		dipClient: dip.NewClient(config.DIPConfig{Host: serverURL, Timeout: 5 * time.Second}, func() string { return "" }, nil),
+	}
+}
+
+// newCausalBatchServer 模拟整问题因果分析 Agent，返回固定文本并记录调用次数。
+func newCausalBatchServer(calls *int32, text string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		var resp domain.AgentResponse
+		resp.Message.Content.FinalAnswer.Answer.Text = text
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func testPair(a, b *domain.FaultPointObject) FaultPointPair {
+	return FaultPointPair{FpA: a, FpB: b}
+}
+
+func TestConnectedComponents(t *testing.T) {
+	Convey("TestConnectedComponents", t, func() {
+		fp1 := &domain.FaultPointObject{FaultID: 1}
+		fp2 := &domain.FaultPointObject{FaultID: 2}
+		fp3 := &domain.FaultPointObject{FaultID: 3}
+		fp4 := &domain.FaultPointObject{FaultID: 4}
+		fp5 := &domain.FaultPointObject{FaultID: 5}
+		pairs := []FaultPointPair{testPair(fp4, fp5), testPair(fp1, fp2), testPair(fp2, fp3), testPair(fp3, fp1)}
+
+		components := connectedComponents(pairs)
+
+		So(components, ShouldHaveLength, 2)
+		So(components[0].pairs, ShouldResemble, []int{0})
+		So(components[0].faultPoints, ShouldResemble, []*domain.FaultPointObject{fp4, fp5})
+		So(components[1].pairs, ShouldResemble, []int{1, 2, 3})
+		So(components[1].faultPoints, ShouldHaveLength, 3)
+	})
+}
+
+func TestService_PackCausalChunks(t *testing.T) {
+	Convey("TestService_PackCausalChunks", t, func() {
+		s := &Service{}
+		// 单个故障点负载略超过 maxInputTokens 的一半，两个不能放入同一分块
+		longName := strings.Repeat("x", maxInputTokens/estimatedTokenSize/2)
+		fp := func(id uint64, name string) *domain.FaultPointObject {
+			return &domain.FaultPointObject{FaultID: id, FaultName: name}
+		}
+
+		Convey("整个问题不超限时只有一个分块", func() {
+			pairs := []FaultPointPair{testPair(fp(1, "a"), fp(2, "b")), testPair(fp(3, "c"), fp(4, "d"))}
+
+			chunks, oversized := s.packCausalChunks(pairs, nil)
+
+			So(oversized, ShouldEqual, 0)
+			So(chunks, ShouldHaveLength, 1)
+			So(chunks[0].pairs, ShouldResemble, []int{0, 1})
+			So(chunks[0].faultPoints, ShouldHaveLength, 4)
+		})
+
+		Convey("超限时按连通分量分块，单独超限的连通分量不参与", func() {
+			pairs := []FaultPointPair{
+				testPair(fp(1, longName), fp(2, "b")),
+				testPair(fp(3, longName), fp(4, "d")),
+				testPair(fp(5, longName), fp(6, longName+longName)),
+			}
+
+			chunks, oversized := s.packCausalChunks(pairs, nil)
+
+			So(oversized, ShouldEqual, 1)
+			So(chunks, ShouldHaveLength, 2)
+			So(chunks[0].pairs, ShouldResemble, []int{0})
+			So(chunks[1].pairs, ShouldResemble, []int{1})
+		})
+	})
+}
+
+func TestService_BuildBatchCustomQuerys(t *testing.T) {
+	Convey("TestService_BuildBatchCustomQuerys", t, func() {
+		s := &Service{}
+		now := time.Now()
+		fp1 := &domain.FaultPointObject{FaultID: 1, EntityObjectID: "host-1", FaultOccurTime: now}
+		fp2 := &domain.FaultPointObject{FaultID: 2, EntityObjectID: "svc-1", FaultOccurTime: now.Add(-time.Minute)}
+		recallCtx := &domain.GraphRecallContext{TopologySubgraphs: map[string]*domain.Topology{
+			"host-1": {Nodes: []domain.Node{{}}, Edges: []domain.Relation{
+				{RelationID: "r1", SourceSID: "svc-1", TargetSID: "host-1"},
+				{RelationID: "r2", SourceSID: "other", TargetSID: "unrelated"},
+			}},
+			"svc-1": {Edges: []domain.Relation{{RelationID: "r1", SourceSID: "svc-1", TargetSID: "host-1"}}},
+		}}
+
+		querys := s.buildBatchCustomQuerys(causalChunk{faultPoints: []*domain.FaultPointObject{fp1, fp2}}, recallCtx)
+
+		timeline := querys[agentRequestFieldFaultTimeline].([]map[string]interface{})
+		So(timeline, ShouldHaveLength, 2)
+		So(timeline[0]["fault_id"], ShouldEqual, 2)
+		relations := querys[agentRequestFieldTopologyRelations].([]domain.Relation)
+		So(relations, ShouldHaveLength, 1)
+		So(relations[0].RelationID, ShouldEqual, "r1")
+	})
+}
+
+func TestService_MapBatchEdgesToCandidates(t *testing.T) {
+	Convey("TestService_MapBatchEdgesToCandidates", t, func() {
+		s := &Service{}
+		fp1 := &domain.FaultPointObject{FaultID: 1}
+		fp2 := &domain.FaultPointObject{FaultID: 2}
+		fp3 := &domain.FaultPointObject{FaultID: 3}
+		pairs := []FaultPointPair{testPair(fp1, fp2), testPair(fp2, fp3)}
+		chunk := causalChunk{pairs: []int{0, 1}, faultPoints: []*domain.FaultPointObject{fp1, fp2, fp3}}
+
+		candidates := s.mapBatchEdgesToCandidates([]domain.AgentCausalEdge{
+			{Source: 2, Target: 1, Confidence: 0.5, Reason: "weak"},
+			{Source: 2, Target: 1, Confidence: 0.9, Reason: "strong"},
+			{Source: 3, Target: 2, Confidence: 1.5},
+			{Source: 1, Target: 3, Confidence: 0.8}, // 不在故障点对中
+			{Source: 1, Target: 1, Confidence: 0.8},
+			{Source: 9, Target: 1, Confidence: 0.8},
+		}, chunk, pairs, nil)
+
+		So(candidates, ShouldHaveLength, 2)
+		So(candidates[0].Cause, ShouldEqual, fp2)
+		So(candidates[0].Confidence, ShouldEqual, 0.9)
+		So(candidates[0].Reason, ShouldEqual, "strong")
+		So(candidates[0].IsNew, ShouldBeTrue)
+		So(candidates[1].Confidence, ShouldEqual, 1)
+	})
+}
+
+func TestService_BatchCausalAnalysis(t *testing.T) {
+	Convey("TestService_BatchCausalAnalysis", t, func() {
+		ctx := context.Background()
+		fp1 := &domain.FaultPointObject{FaultID: 1}
+		fp2 := &domain.FaultPointObject{FaultID: 2}
+		fp3 := &domain.FaultPointObject{FaultID: 3}
+		pairs := []FaultPointPair{testPair(fp1, fp2), testPair(fp1, fp3), testPair(fp2, fp3)}
+
+		Convey("一次调用分析整个问题", func() {
+			var calls int32
+			server := newCausalBatchServer(&calls, `{"fault_causals": [{"source_id": 1, "target_id": 2, "confidence": 0.8}, {"source_id": 2, "target_id": 3, "confidence": 0.7}]}`)
+			defer server.Close()
+
+			candidates, remaining := newBatchTestService(server.URL).batchCausalAnalysis(ctx, pairs, nil)
+
+			So(calls, ShouldEqual, 1)
+			So(candidates, ShouldHaveLength, 2)
+			So(remaining, ShouldBeEmpty)
+		})
+
+		Convey("调用失败时全部故障点对逐对推理", func() {
+			var calls int32
+			server := newCausalBatchServer(&calls, "无法分析")
+			defer server.Close()
+
+			candidates, remaining := newBatchTestService(server.URL).batchCausalAnalysis(ctx, pairs, nil)
+
+			So(candidates, ShouldBeEmpty)
+			So(remaining, ShouldResemble, pairs)
+		})
+
+		Convey("未配置整问题 Agent 时不调用", func() {
+			var calls int32
+			server := newCausalBatchServer(&calls, `{"fault_causals": []}`)
+			defer server.Close()
+			s := newBatchTestService(server.URL)
+			s.config.Platform.Agents.CausalBatch = config.AgentConfig{}
+
+			_, remaining := s.batchCausalAnalysis(ctx, pairs, nil)
+
+			So(calls, ShouldEqual, 0)
+			So(remaining, ShouldResemble, pairs)
+		})
+	})
+}
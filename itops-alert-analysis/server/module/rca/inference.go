@@ -0,0 +1,310 @@
+package rca
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	"github.com/pkg/errors"
+)
+
+// defaultLLMMinPriority tiered 引擎默认使用大模型的优先级阈值
+const defaultLLMMinPriority = 10.0
+
+// CausalInferencer 因果推理引擎：分析一对故障点之间的因果关系。
+// 无法判断时返回空列表；实现需并发安全，同一次分析中会被多个故障点对同时调用。
+type CausalInferencer interface {
+	Name() string
+	Infer(ctx context.Context, pair FaultPointPair, recallCtx *domain.GraphRecallContext) []domain.CausalCandidate
+}
+
+// InferencerFactory 创建因果推理引擎。
+type InferencerFactory func(s *Service, cfg config.CausalInferenceConfig) (CausalInferencer, error)
+
+// InferencerRegistry 管理因果推理引擎。
+type InferencerRegistry struct {
+	factories map[string]InferencerFactory
+}
+
+// NewInferencerRegistry 创建空注册表。
+func NewInferencerRegistry() *InferencerRegistry {
+	return &InferencerRegistry{factories: make(map[string]InferencerFactory)}
+}
+
+// Register 注册因果推理引擎。
+func (r *InferencerRegistry) Register(name string, factory InferencerFactory) {
+	key := normalizeEngine(name)
+	if key == "" || factory == nil {
+		return
+	}
+	r.factories[key] = factory
+}
+
+// Build 按配置创建因果推理引擎，未配置时使用 tiered。
+func (r *InferencerRegistry) Build(s *Service, cfg config.CausalInferenceConfig) (CausalInferencer, error) {
+	name := normalizeEngine(cfg.Engine)
+	if name == "" {
+		name = config.CausalEngineTiered
+	}
+	return r.build(s, name, cfg)
+}
+
+func (r *InferencerRegistry) build(s *Service, name string, cfg config.CausalInferenceConfig) (CausalInferencer, error) {
+	factory, ok := r.factories[name]
+	if !ok {
+		return nil, errors.Errorf("unsupported causal inference engine: %s", name)
+	}
+	return factory(s, cfg)
+}
+
+// defaultInferencerRegistry 返回包含内置引擎的注册表。
+func defaultInferencerRegistry() *InferencerRegistry {
+	r := NewInferencerRegistry()
+	r.Register(config.CausalEngineLLM, func(s *Service, _ config.CausalInferenceConfig) (CausalInferencer, error) {
+		return llmInferencer{s: s}, nil
+	})
+	r.Register(config.CausalEngineRules, func(s *Service, _ config.CausalInferenceConfig) (CausalInferencer, error) {
+		return rulesInferencer{s: s}, nil
+	})
+	r.Register(config.CausalEngineHistorical, func(_ *Service, _ config.CausalInferenceConfig) (CausalInferencer, error) {
+		return historicalInferencer{}, nil
+	})
+	r.Register(config.CausalEngineTiered, func(s *Service, cfg config.CausalInferenceConfig) (CausalInferencer, error) {
+		minPriority := cfg.LLMMinPriority
+		if minPriority <= 0 {
+			minPriority = defaultLLMMinPriority
+		}
+		return tieredInferencer{primary: llmInferencer{s: s}, fallback: rulesInferencer{s: s}, minPriority: minPriority}, nil
+	})
+	r.Register(config.CausalEngineEnsemble, func(s *Service, cfg config.CausalInferenceConfig) (CausalInferencer, error) {
+		return r.buildEnsemble(s, cfg)
+	})
+	return r
+}
+
+// buildEnsemble 创建 ensemble 引擎的全部成员，成员不能是 ensemble 本身。
+func (r *InferencerRegistry) buildEnsemble(s *Service, cfg config.CausalInferenceConfig) (CausalInferencer, error) {
+	members := cfg.Ensemble
+	if len(members) == 0 {
+		members = []config.CausalEngineWeight{
+			{Engine: config.CausalEngineLLM},
+			{Engine: config.CausalEngineRules},
+			{Engine: config.CausalEngineHistorical},
+		}
+	}
+
+	e := ensembleInferencer{}
+	for _, member := range members {
+		name := normalizeEngine(member.Engine)
+		if name == config.CausalEngineEnsemble {
+			return nil, errors.New("ensemble engine cannot contain itself")
+		}
+		engine, err := r.build(s, name, cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "build ensemble member")
+		}
+		weight := member.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		e.members = append(e.members, weightedInferencer{engine: engine, weight: weight})
+	}
+	return e, nil
+}
+
+func normalizeEngine(name string) string {
+	return strings.TrimSpace(strings.ToLower(name))
+}
+
+// llmInferencer 调用 DIP 因果推理 Agent，失败时返回空列表。
+type llmInferencer struct {
+	s *Service
+}
+
+func (e llmInferencer) Name() string { return config.CausalEngineLLM }
+
+func (e llmInferencer) Infer(ctx context.Context, pair FaultPointPair, recallCtx *domain.GraphRecallContext) []domain.CausalCandidate {
+	return e.s.tryAgentCausalAnalysis(ctx, pair.FpA, pair.FpB, recallCtx)
+}
+
+// rulesInferencer 综合时间、持续时间、状态、严重程度、历史关系的本地规则评分。
+type rulesInferencer struct {
+	s *Service
+}
+
+func (e rulesInferencer) Name() string { return config.CausalEngineRules }
+
+func (e rulesInferencer) Infer(_ context.Context, pair FaultPointPair, recallCtx *domain.GraphRecallContext) []domain.CausalCandidate {
+	candidate := e.s.calculateCausalCandidate(pair.FpA, pair.FpB, recallCtx)
+	if candidate == nil {
+		return nil
+	}
+	return []domain.CausalCandidate{*candidate}
+}
+
+// tieredInferencer 优先级超过 minPriority 的故障点对使用 primary，primary 无结果或优先级较低时使用 fallback。
+type tieredInferencer struct {
+	primary     CausalInferencer
+	fallback    CausalInferencer
+	minPriority float64
+}
+
+func (e tieredInferencer) Name() string { return config.CausalEngineTiered }
+
+func (e tieredInferencer) Infer(ctx context.Context, pair FaultPointPair, recallCtx *domain.GraphRecallContext) []domain.CausalCandidate {
+	if pair.Priority > e.minPriority {
+		if candidates := e.primary.Infer(ctx, pair, recallCtx); len(candidates) > 0 {
+			log.Infof("%s 因果推理成功: 故障点A ID=%d, 故障点B ID=%d, 发现 %d 个因果关系, 优先级: %.2f",
+				e.primary.Name(), pair.FpA.FaultID, pair.FpB.FaultID, len(candidates), pair.Priority)
+			return candidates
+		}
+		log.Infof("%s 因果推理无结果，使用 %s: 故障点A ID=%d, 故障点B ID=%d, 优先级: %.2f",
+			e.primary.Name(), e.fallback.Name(), pair.FpA.FaultID, pair.FpB.FaultID, pair.Priority)
+	}
+	return e.fallback.Infer(ctx, pair, recallCtx)
+}
+
+// historicalInferencer 只依据图召回得到的历史因果关系判断方向与置信度，没有历史记录时不判断。
+type historicalInferencer struct{}
+
+func (historicalInferencer) Name() string { return config.CausalEngineHistorical }
+
+func (historicalInferencer) Infer(_ context.Context, pair FaultPointPair, recallCtx *domain.GraphRecallContext) []domain.CausalCandidate {
+	fpA, fpB := pair.FpA, pair.FpB
+	if fpA == nil || fpB == nil || fpA.EntityObjectID == fpB.EntityObjectID {
+		return nil
+	}
+
+	forward, hasForward := findHistoricalRelation(recallCtx, fpA.EntityObjectID, fpB.EntityObjectID)
+	backward, hasBackward := findHistoricalRelation(recallCtx, fpB.EntityObjectID, fpA.EntityObjectID)
+	// 两个方向都有历史记录时取出现次数多的方向
+	cause, effect, relation := fpA, fpB, forward
+	if !hasForward || (hasBackward && backward.OccurrenceCount > forward.OccurrenceCount) {
+		if !hasBackward {
+			return nil
+		}
+		cause, effect, relation = fpB, fpA, backward
+	}
+
+	confidence := relation.Confidence
+	if confidence <= 0 {
+		confidence = baseConfidence
+	}
+	boost := float64(relation.OccurrenceCount) * confidencePerHistoricalOccurrence
+	if boost > maxHistoricalBoost {
+		boost = maxHistoricalBoost
+	}
+	confidence += boost
+	if confidence > maxConfidence {
+		confidence = maxConfidence
+	}
+
+	return []domain.CausalCandidate{{
+		Cause:      cause,
+		Effect:     effect,
+		Confidence: confidence,
+		Reason: fmt.Sprintf("故障点ID：%d → 故障点ID：%d: 历史因果关系（出现%d次）",
+			cause.FaultID, effect.FaultID, relation.OccurrenceCount),
+		IsNew: false,
+	}}
+}
+
+// findHistoricalRelation 查找 cause -> effect 的历史因果关系。
+func findHistoricalRelation(recallCtx *domain.GraphRecallContext, causeObjectID, effectObjectID string) (domain.CausalRelation, bool) {
+	if recallCtx == nil || causeObjectID == "" || effectObjectID == "" {
+		return domain.CausalRelation{}, false
+	}
+	for _, h := range recallCtx.HistoricalCausality[causeObjectID] {
+		if h.EffectObjectID == effectObjectID {
+			return h, true
+		}
+	}
+	return domain.CausalRelation{}, false
+}
+
+// weightedInferencer ensemble 成员。
+type weightedInferencer struct {
+	engine CausalInferencer
+	weight float64
+}
+
+// ensembleInferencer 依次调用各成员，按权重对因果方向投票。
+// 方向得分 = Σ(成员权重 × 成员置信度) / 给出结果的成员权重之和，无结果的成员视为弃权；
+// 只返回得分最高且不低于 minConfidence 的方向。
+type ensembleInferencer struct {
+	members []weightedInferencer
+}
+
+func (e ensembleInferencer) Name() string { return config.CausalEngineEnsemble }
+
+func (e ensembleInferencer) Infer(ctx context.Context, pair FaultPointPair, recallCtx *domain.GraphRecallContext) []domain.CausalCandidate {
+	type vote struct {
+		candidate domain.CausalCandidate
+		score     float64
+		reasons   []string
+	}
+	votes := make(map[string]*vote)
+	var order []string
+	totalWeight := 0.0
+
+	for _, member := range e.members {
+		if ctx.Err() != nil {
+			return nil
+		}
+		// 同一成员对同一方向只计最高置信度
+		best := make(map[string]domain.CausalCandidate)
+		for _, c := range member.engine.Infer(ctx, pair, recallCtx) {
+			if c.Cause == nil || c.Effect == nil {
+				continue
+			}
+			key := fmt.Sprintf("%d->%d", c.Cause.FaultID, c.Effect.FaultID)
+			if prev, ok := best[key]; !ok || c.Confidence > prev.Confidence {
+				best[key] = c
+			}
+		}
+		if len(best) == 0 {
+			continue
+		}
+		totalWeight += member.weight
+
+		keys := make([]string, 0, len(best))
+		for key := range best {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			c := best[key]
+			v, ok := votes[key]
+			if !ok {
+				v = &vote{candidate: c}
+				votes[key] = v
+				order = append(order, key)
+			}
+			v.score += member.weight * c.Confidence
+			v.reasons = append(v.reasons, fmt.Sprintf("%s %.2f", member.engine.Name(), c.Confidence))
+		}
+	}
+	if totalWeight == 0 {
+		return nil
+	}
+
+	var winner *vote
+	for _, key := range order {
+		if v := votes[key]; winner == nil || v.score > winner.score {
+			winner = v
+		}
+	}
+	confidence := winner.score / totalWeight
+	if confidence < minConfidence {
+		return nil
+	}
+
+	result := winner.candidate
+	result.Confidence = confidence
+	result.Reason = fmt.Sprintf("%s（集成投票: %s）", result.Reason, strings.Join(winner.reasons, ", "))
+	return []domain.CausalCandidate{result}
+}
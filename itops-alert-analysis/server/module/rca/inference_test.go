@@ -0,0 +1,220 @@
+package rca
+
+import (
+	"context"
+	"testing"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// stubInferencer 返回固定结果的推理引擎。
+type stubInferencer struct {
+	name       string
+	candidates []domain.CausalCandidate
+	calls      int
+}
+
+func (e *stubInferencer) Name() string { return e.name }
+
+func (e *stubInferencer) Infer(context.Context, FaultPointPair, *domain.GraphRecallContext) []domain.CausalCandidate {
+	e.calls++
+	return e.candidates
+}
+
+func TestInferencerRegistry_Build(t *testing.T) {
+	Convey("TestInferencerRegistry_Build", t, func() {
+		s := &Service{}
+		registry := defaultInferencerRegistry()
+
+		Convey("未配置时使用 tiered", func() {
+			engine, err := registry.Build(s, config.CausalInferenceConfig{})
+
+			So(err, ShouldBeNil)
+			So(engine.Name(), ShouldEqual, config.CausalEngineTiered)
+			So(engine.(tieredInferencer).minPriority, ShouldEqual, defaultLLMMinPriority)
+		})
+
+		Convey("引擎名称忽略大小写", func() {
+			engine, err := registry.Build(s, config.CausalInferenceConfig{Engine: " Historical "})
+
+			So(err, ShouldBeNil)
+			So(engine.Name(), ShouldEqual, config.CausalEngineHistorical)
+		})
+
+		Convey("未注册的引擎返回错误", func() {
+			_, err := registry.Build(s, config.CausalInferenceConfig{Engine: "bayes"})
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "bayes")
+		})
+
+		Convey("ensemble 未配置成员时等权使用内置引擎", func() {
+			engine, err := registry.Build(s, config.CausalInferenceConfig{Engine: config.CausalEngineEnsemble})
+
+			So(err, ShouldBeNil)
+			members := engine.(ensembleInferencer).members
+			So(members, ShouldHaveLength, 3)
+			So(members[0].engine.Name(), ShouldEqual, config.CausalEngineLLM)
+			So(members[2].weight, ShouldEqual, 1)
+		})
+
+		Convey("ensemble 不能包含自身或未注册的引擎", func() {
+			_, err := registry.Build(s, config.CausalInferenceConfig{
+				Engine:   config.CausalEngineEnsemble,
+				Ensemble: []config.CausalEngineWeight{{Engine: config.CausalEngineEnsemble}},
+			})
+			So(err, ShouldNotBeNil)
+
+			_, err = registry.Build(s, config.CausalInferenceConfig{
+				Engine:   config.CausalEngineEnsemble,
+				Ensemble: []config.CausalEngineWeight{{Engine: "bayes", Weight: 2}},
+			})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("可注册自定义引擎", func() {
+			registry.Register("stub", func(*Service, config.CausalInferenceConfig) (CausalInferencer, error) {
+				return &stubInferencer{name: "stub"}, nil
+			})
+
+			engine, err := registry.Build(s, config.CausalInferenceConfig{Engine: "stub"})
+
+			So(err, ShouldBeNil)
+			So(engine.Name(), ShouldEqual, "stub")
+		})
+	})
+}
+
+func TestTieredInferencer(t *testing.T) {
+	Convey("TestTieredInferencer", t, func() {
+		ctx := context.Background()
+		fpA := &domain.FaultPointObject{FaultID: 1}
+		fpB := &domain.FaultPointObject{FaultID: 2}
+		primary := &stubInferencer{name: "primary"}
+		fallback := &stubInferencer{name: "fallback", candidates: []domain.CausalCandidate{{Cause: fpA, Effect: fpB, Confidence: 0.4}}}
+		engine := tieredInferencer{primary: primary, fallback: fallback, minPriority: 10}
+
+		Convey("低优先级直接使用 fallback", func() {
+			got := engine.Infer(ctx, FaultPointPair{FpA: fpA, FpB: fpB, Priority: 5}, nil)
+
+			So(got, ShouldHaveLength, 1)
+			So(primary.calls, ShouldEqual, 0)
+		})
+
+		Convey("高优先级 primary 无结果时使用 fallback", func() {
+			got := engine.Infer(ctx, FaultPointPair{FpA: fpA, FpB: fpB, Priority: 20}, nil)
+
+			So(got, ShouldHaveLength, 1)
+			So(primary.calls, ShouldEqual, 1)
+			So(fallback.calls, ShouldEqual, 1)
+		})
+
+		Convey("高优先级 primary 有结果时不使用 fallback", func() {
+			primary.candidates = []domain.CausalCandidate{{Cause: fpB, Effect: fpA, Confidence: 0.8}}
+
+			got := engine.Infer(ctx, FaultPointPair{FpA: fpA, FpB: fpB, Priority: 20}, nil)
+
+			So(got[0].Cause, ShouldEqual, fpB)
+			So(fallback.calls, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestHistoricalInferencer(t *testing.T) {
+	Convey("TestHistoricalInferencer", t, func() {
+		ctx := context.Background()
+		fpA := &domain.FaultPointObject{FaultID: 1, EntityObjectID: "host-a"}
+		fpB := &domain.FaultPointObject{FaultID: 2, EntityObjectID: "service-b"}
+		pair := FaultPointPair{FpA: fpA, FpB: fpB}
+
+		Convey("没有历史记录时不判断", func() {
+			So(historicalInferencer{}.Infer(ctx, pair, nil), ShouldBeEmpty)
+			So(historicalInferencer{}.Infer(ctx, pair, &domain.GraphRecallContext{}), ShouldBeEmpty)
+		})
+
+		Convey("按历史方向给出因果关系，出现次数提高置信度", func() {
+			recallCtx := &domain.GraphRecallContext{HistoricalCausality: map[string][]domain.CausalRelation{
+				"service-b": {{CauseObjectID: "service-b", EffectObjectID: "host-a", Confidence: 0.5, OccurrenceCount: 2}},
+			}}
+
+			got := historicalInferencer{}.Infer(ctx, pair, recallCtx)
+
+			So(got, ShouldHaveLength, 1)
+			So(got[0].Cause, ShouldEqual, fpB)
+			So(got[0].Effect, ShouldEqual, fpA)
+			So(got[0].Confidence, ShouldAlmostEqual, 0.6)
+			So(got[0].IsNew, ShouldBeFalse)
+		})
+
+		Convey("两个方向都有历史记录时取出现次数多的方向", func() {
+			recallCtx := &domain.GraphRecallContext{HistoricalCausality: map[string][]domain.CausalRelation{
+				"host-a":    {{EffectObjectID: "service-b", OccurrenceCount: 5}},
+				"service-b": {{EffectObjectID: "host-a", OccurrenceCount: 1}},
+			}}
+
+			got := historicalInferencer{}.Infer(ctx, pair, recallCtx)
+
+			So(got[0].Cause, ShouldEqual, fpA)
+			So(got[0].Confidence, ShouldAlmostEqual, baseConfidence+maxHistoricalBoost)
+		})
+	})
+}
+
+func TestEnsembleInferencer(t *testing.T) {
+	Convey("TestEnsembleInferencer", t, func() {
+		ctx := context.Background()
+		fpA := &domain.FaultPointObject{FaultID: 1}
+		fpB := &domain.FaultPointObject{FaultID: 2}
+		pair := FaultPointPair{FpA: fpA, FpB: fpB}
+		forward := func(confidence float64) []domain.CausalCandidate {
+			return []domain.CausalCandidate{{Cause: fpA, Effect: fpB, Confidence: confidence, Reason: "A→B"}}
+		}
+		backward := func(confidence float64) []domain.CausalCandidate {
+			return []domain.CausalCandidate{{Cause: fpB, Effect: fpA, Confidence: confidence, Reason: "B→A"}}
+		}
+
+		Convey("按权重投票，无结果的成员弃权", func() {
+			engine := ensembleInferencer{members: []weightedInferencer{
+				{engine: &stubInferencer{name: "llm", candidates: forward(0.8)}, weight: 2},
+				{engine: &stubInferencer{name: "rules", candidates: backward(0.5)}, weight: 1},
+				{engine: &stubInferencer{name: "historical"}, weight: 1},
+			}}
+
+			got := engine.Infer(ctx, pair, nil)
+
+			So(got, ShouldHaveLength, 1)
+			So(got[0].Cause, ShouldEqual, fpA)
+			// 2*0.8 / (2+1)
+			So(got[0].Confidence, ShouldAlmostEqual, 1.6/3)
+			So(got[0].Reason, ShouldContainSubstring, "llm 0.80")
+		})
+
+		Convey("多个成员支持同一方向时得分累加", func() {
+			engine := ensembleInferencer{members: []weightedInferencer{
+				{engine: &stubInferencer{name: "llm", candidates: backward(0.6)}, weight: 1},
+				{engine: &stubInferencer{name: "rules", candidates: backward(0.4)}, weight: 1},
+				{engine: &stubInferencer{name: "historical", candidates: forward(0.9)}, weight: 1},
+			}}
+
+			got := engine.Infer(ctx, pair, nil)
+
+			So(got[0].Cause, ShouldEqual, fpB)
+			So(got[0].Confidence, ShouldAlmostEqual, 1.0/3)
+		})
+
+		Convey("所有成员都无结果或得分过低时不返回", func() {
+			engine := ensembleInferencer{members: []weightedInferencer{
+				{engine: &stubInferencer{name: "llm"}, weight: 1},
+			}}
+			So(engine.Infer(ctx, pair, nil), ShouldBeEmpty)
+
+			engine = ensembleInferencer{members: []weightedInferencer{
+				{engine: &stubInferencer{name: "llm", candidates: forward(0.15)}, weight: 1},
+				{engine: &stubInferencer{name: "rules", candidates: backward(0.15)}, weight: 1},
+			}}
+			So(engine.Infer(ctx, pair, nil), ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,215 @@
+package rca
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/core"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultMaxAttempts     = 3
+	defaultRetryBackoff    = time.Minute
+	defaultMaxRetryBackoff = 30 * time.Minute
+	defaultRecoveryWindow  = 24 * time.Hour
+)
+
+// jobTracker 维护每个问题当前未结束的 RCA 任务并持久化任务状态。
+// 同一问题同时只有一个未结束的任务，失败重试复用该任务；任务结束后下次变化创建新任务。
+type jobTracker struct {
+	jobs        core.RCAJobRepository
+	problems    core.ProblemRepository
+	nextID      func() uint64
+	now         func() time.Time
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+
+	mu     sync.Mutex
+	active map[uint64]domain.RCAJob // problem_id -> 未结束的任务
+}
+
+func newJobTracker(cfg config.RCAConfig, jobs core.RCAJobRepository, problems core.ProblemRepository, nextID func() uint64) *jobTracker {
+	t := &jobTracker{
+		jobs:        jobs,
+		problems:    problems,
+		nextID:      nextID,
+		now:         time.Now,
+		maxAttempts: cfg.MaxAttempts,
+		backoff:     cfg.RetryBackoff,
+		maxBackoff:  cfg.MaxRetryBackoff,
+		active:      make(map[uint64]domain.RCAJob),
+	}
+	if t.maxAttempts <= 0 {
+		t.maxAttempts = defaultMaxAttempts
+	}
+	if t.backoff <= 0 {
+		t.backoff = defaultRetryBackoff
+	}
+	if t.maxBackoff <= 0 {
+		t.maxBackoff = defaultMaxRetryBackoff
+	}
+	if t.maxBackoff < t.backoff {
+		t.maxBackoff = t.backoff
+	}
+	return t
+}
+
+// restore 载入存储中未结束的任务，返回需要重新调度的任务。
+func (t *jobTracker) restore(ctx context.Context) ([]domain.RCAJob, error) {
+	jobs, err := t.jobs.FindActive(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询未完成的 RCA 任务失败")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	restored := make([]domain.RCAJob, 0, len(jobs))
+	for _, job := range jobs {
+		if _, ok := t.active[job.ProblemID]; ok {
+			continue
+		}
+		t.active[job.ProblemID] = job
+		restored = append(restored, job)
+	}
+	return restored, nil
+}
+
+// enqueue 为问题登记排队中的任务，已有未结束的任务时直接返回该任务。
+func (t *jobTracker) enqueue(ctx context.Context, problemID uint64, trigger domain.RCAJobTrigger, by string) (domain.RCAJob, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if job, ok := t.active[problemID]; ok {
+		return job, nil
+	}
+	now := t.now()
+	job := domain.RCAJob{
+		JobID:       t.nextID(),
+		ProblemID:   problemID,
+		Status:      domain.RCAJobStatusQueued,
+		Trigger:     trigger,
+		OperatedBy:  by,
+		MaxAttempts: t.maxAttempts,
+		CreateTime:  now,
+		UpdateTime:  now,
+	}
+	if err := t.jobs.Upsert(ctx, job); err != nil {
+		return job, errors.Wrap(err, "保存 RCA 任务失败")
+	}
+	t.active[problemID] = job
+	return job, nil
+}
+
+// running 返回问题是否有运行中的任务。
+func (t *jobTracker) running(problemID uint64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job, ok := t.active[problemID]
+	return ok && job.Status == domain.RCAJobStatusRunning
+}
+
+// start 标记任务开始执行，没有排队中的任务时按事件触发创建。
+func (t *jobTracker) start(ctx context.Context, problemID uint64) (domain.RCAJob, error) {
+	job, err := t.enqueue(ctx, problemID, domain.RCAJobTriggerEvent, "")
+	if err != nil {
+		log.Warnf("RCA 任务登记失败，问题 %d 的分析不会被持久化: %v", problemID, err)
+	}
+
+	t.mu.Lock()
+	now := t.now()
+	job.Status = domain.RCAJobStatusRunning
+	job.Attempt++
+	job.StartTime = &now
+	job.NextRunTime = nil
+	job.UpdateTime = now
+	t.active[problemID] = job
+	t.mu.Unlock()
+
+	if err := t.jobs.Upsert(ctx, job); err != nil {
+		return job, errors.Wrap(err, "更新 RCA 任务状态失败")
+	}
+	if err := t.problems.UpdateRCAStatus(ctx, problemID, domain.RcaStatusRunning); err != nil {
+		return job, errors.Wrap(err, "更新问题 RCA 状态失败")
+	}
+	return job, nil
+}
+
+// finish 记录任务执行结果。失败且未达到最大次数时任务回到排队状态，返回下次重试时间。
+func (t *jobTracker) finish(ctx context.Context, problemID uint64, runErr error) (retryAt time.Time, retry bool) {
+	t.mu.Lock()
+	job, ok := t.active[problemID]
+	if !ok {
+		t.mu.Unlock()
+		return time.Time{}, false
+	}
+	now := t.now()
+	job.UpdateTime = now
+	switch {
+	case runErr == nil:
+		job.Status = domain.RCAJobStatusSucceeded
+		job.Error = ""
+		job.EndTime = &now
+		delete(t.active, problemID)
+	case job.Attempt < job.MaxAttempts:
+		retryAt = now.Add(t.retryDelay(job.Attempt))
+		retry = true
+		job.Status = domain.RCAJobStatusQueued
+		job.Error = runErr.Error()
+		job.NextRunTime = &retryAt
+		t.active[problemID] = job
+	default:
+		job.Status = domain.RCAJobStatusFailed
+		job.Error = runErr.Error()
+		job.EndTime = &now
+		delete(t.active, problemID)
+	}
+	t.mu.Unlock()
+
+	if err := t.jobs.Upsert(ctx, job); err != nil {
+		log.Warnf("更新 RCA 任务 %d 状态失败: %v", job.JobID, err)
+	}
+	if job.Status == domain.RCAJobStatusFailed {
+		if err := t.problems.UpdateRCAStatus(ctx, problemID, domain.RcaStatusFailed); err != nil {
+			log.Warnf("更新问题 %d RCA 状态失败: %v", problemID, err)
+		}
+	}
+	return retryAt, retry
+}
+
+// cancel 取消问题排队中的任务。
+func (t *jobTracker) cancel(ctx context.Context, problemID uint64, reason string) {
+	t.mu.Lock()
+	job, ok := t.active[problemID]
+	if !ok || job.Status != domain.RCAJobStatusQueued {
+		t.mu.Unlock()
+		return
+	}
+	now := t.now()
+	job.Status = domain.RCAJobStatusCancelled
+	job.Error = reason
+	job.EndTime = &now
+	job.NextRunTime = nil
+	job.UpdateTime = now
+	delete(t.active, problemID)
+	t.mu.Unlock()
+
+	if err := t.jobs.Upsert(ctx, job); err != nil {
+		log.Warnf("更新 RCA 任务 %d 状态失败: %v", job.JobID, err)
+	}
+}
+
+// retryDelay 第 attempt 次执行失败后的等待时间，按 backoff 翻倍，不超过 maxBackoff。
+func (t *jobTracker) retryDelay(attempt int) time.Duration {
+	delay := t.backoff
+	for i := 1; i < attempt && delay < t.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > t.maxBackoff {
+		return t.maxBackoff
+	}
+	return delay
+}
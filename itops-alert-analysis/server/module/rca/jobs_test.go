@@ -0,0 +1,185 @@
+package rca
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/core"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryJobRepository 内存中的 RCA 任务存储。
+type memoryJobRepository struct {
+	mu      sync.Mutex
+	jobs    map[uint64]domain.RCAJob
+	upserts int
+}
+
+func newMemoryJobRepository(jobs ...domain.RCAJob) *memoryJobRepository {
+	r := &memoryJobRepository{jobs: make(map[uint64]domain.RCAJob)}
+	for _, job := range jobs {
+		r.jobs[job.JobID] = job
+	}
+	return r
+}
+
+func (r *memoryJobRepository) Upsert(_ context.Context, job domain.RCAJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.JobID] = job
+	r.upserts++
+	return nil
+}
+
+func (r *memoryJobRepository) List(_ context.Context, q domain.RCAJobQuery) ([]domain.RCAJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var jobs []domain.RCAJob
+	for _, job := range r.jobs {
+		if q.ProblemID != 0 && job.ProblemID != q.ProblemID {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func (r *memoryJobRepository) FindActive(_ context.Context) ([]domain.RCAJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var jobs []domain.RCAJob
+	for _, job := range r.jobs {
+		if job.Active() {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+func (r *memoryJobRepository) get(jobID uint64) domain.RCAJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.jobs[jobID]
+}
+
+// rcaStatusRecorder 记录问题 RCA 状态更新，其余方法不会被调用。
+type rcaStatusRecorder struct {
+	core.ProblemRepository
+	statuses map[uint64]domain.RcaStatus
+}
+
+func (r *rcaStatusRecorder) UpdateRCAStatus(_ context.Context, problemID uint64, status domain.RcaStatus) error {
+	r.statuses[problemID] = status
+	return nil
+}
+
+func newTestJobTracker(jobs *memoryJobRepository, problems *rcaStatusRecorder, clock *time.Time) *jobTracker {
+	var id uint64
+	t := newJobTracker(config.RCAConfig{MaxAttempts: 3, RetryBackoff: time.Minute, MaxRetryBackoff: 90 * time.Second},
+		jobs, problems, func() uint64 { id++; return id })
+	t.now = func() time.Time { return *clock }
+	return t
+}
+
+func TestJobTracker(t *testing.T) {
+	Convey("TestJobTracker", t, func() {
+		ctx := context.Background()
+		clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		jobs := newMemoryJobRepository()
+		problems := &rcaStatusRecorder{statuses: map[uint64]domain.RcaStatus{}}
+		tracker := newTestJobTracker(jobs, problems, &clock)
+
+		Convey("同一问题只登记一个未结束的任务", func() {
+			job, err := tracker.enqueue(ctx, 1, domain.RCAJobTriggerEvent, "")
+			So(err, ShouldBeNil)
+			again, err := tracker.enqueue(ctx, 1, domain.RCAJobTriggerManual, "admin")
+			So(err, ShouldBeNil)
+
+			So(again.JobID, ShouldEqual, job.JobID)
+			So(jobs.upserts, ShouldEqual, 1)
+			So(jobs.get(job.JobID).Status, ShouldEqual, domain.RCAJobStatusQueued)
+			So(jobs.get(job.JobID).MaxAttempts, ShouldEqual, 3)
+		})
+
+		Convey("成功后任务结束，下次变化创建新任务", func() {
+			job, _ := tracker.enqueue(ctx, 1, domain.RCAJobTriggerEvent, "")
+			started, err := tracker.start(ctx, 1)
+			So(err, ShouldBeNil)
+			So(started.JobID, ShouldEqual, job.JobID)
+			So(started.Attempt, ShouldEqual, 1)
+			So(tracker.running(1), ShouldBeTrue)
+			So(problems.statuses[1], ShouldEqual, domain.RcaStatusRunning)
+
+			_, retry := tracker.finish(ctx, 1, nil)
+
+			So(retry, ShouldBeFalse)
+			So(tracker.running(1), ShouldBeFalse)
+			So(jobs.get(job.JobID).Status, ShouldEqual, domain.RCAJobStatusSucceeded)
+			So(jobs.get(job.JobID).EndTime, ShouldNotBeNil)
+			next, _ := tracker.enqueue(ctx, 1, domain.RCAJobTriggerEvent, "")
+			So(next.JobID, ShouldNotEqual, job.JobID)
+		})
+
+		Convey("失败按退避时间重试，次数用尽后标记失败", func() {
+			job, _ := tracker.enqueue(ctx, 1, domain.RCAJobTriggerEvent, "")
+			failed := errors.New("agent unavailable")
+
+			_, _ = tracker.start(ctx, 1)
+			retryAt, retry := tracker.finish(ctx, 1, failed)
+			So(retry, ShouldBeTrue)
+			So(retryAt, ShouldEqual, clock.Add(time.Minute))
+			stored := jobs.get(job.JobID)
+			So(stored.Status, ShouldEqual, domain.RCAJobStatusQueued)
+			So(stored.Error, ShouldEqual, "agent unavailable")
+			So(*stored.NextRunTime, ShouldEqual, retryAt)
+
+			_, _ = tracker.start(ctx, 1)
+			retryAt, retry = tracker.finish(ctx, 1, failed)
+			So(retry, ShouldBeTrue)
+			So(retryAt, ShouldEqual, clock.Add(90*time.Second))
+
+			_, _ = tracker.start(ctx, 1)
+			_, retry = tracker.finish(ctx, 1, failed)
+			So(retry, ShouldBeFalse)
+			stored = jobs.get(job.JobID)
+			So(stored.Status, ShouldEqual, domain.RCAJobStatusFailed)
+			So(stored.Attempt, ShouldEqual, 3)
+			So(stored.NextRunTime, ShouldBeNil)
+			So(problems.statuses[1], ShouldEqual, domain.RcaStatusFailed)
+		})
+
+		Convey("只取消排队中的任务", func() {
+			queued, _ := tracker.enqueue(ctx, 1, domain.RCAJobTriggerEvent, "")
+			tracker.cancel(ctx, 1, "故障点与等级未变化")
+			So(jobs.get(queued.JobID).Status, ShouldEqual, domain.RCAJobStatusCancelled)
+			So(jobs.get(queued.JobID).Error, ShouldEqual, "故障点与等级未变化")
+
+			running, _ := tracker.start(ctx, 2)
+			tracker.cancel(ctx, 2, "问题不存在")
+			So(jobs.get(running.JobID).Status, ShouldEqual, domain.RCAJobStatusRunning)
+		})
+
+		Convey("启动时载入未结束的任务", func() {
+			jobs := newMemoryJobRepository(
+				domain.RCAJob{JobID: 100, ProblemID: 1, Status: domain.RCAJobStatusRunning, Attempt: 1, MaxAttempts: 3},
+				domain.RCAJob{JobID: 101, ProblemID: 2, Status: domain.RCAJobStatusSucceeded},
+			)
+			tracker := newTestJobTracker(jobs, problems, &clock)
+
+			restored, err := tracker.restore(ctx)
+
+			So(err, ShouldBeNil)
+			So(restored, ShouldHaveLength, 1)
+			So(restored[0].JobID, ShouldEqual, 100)
+			// 中断的任务继续使用原任务，按新一次执行记录
+			started, _ := tracker.start(ctx, 1)
+			So(started.JobID, ShouldEqual, 100)
+			So(started.Attempt, ShouldEqual, 2)
+		})
+	})
+}
@@ -0,0 +1,139 @@
+package rca
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+)
+
+// progressPairInterval 故障点对分析进度的最小上报间隔，全部分析完成时立即上报
+const progressPairInterval = 5 * time.Second
+
+// 各步骤完成时的总体进度；故障点对分析在 graph_recalled 与 root_cause_chosen 之间按比例推进
+var stepPercent = map[domain.RCAStep]int{
+	domain.RCAStepFaultPointsLoaded: 10,
+	domain.RCAStepGraphRecalled:     20,
+	domain.RCAStepPairsAnalyzing:    20,
+	domain.RCAStepRootCauseChosen:   90,
+	domain.RCAStepSummaryGenerated:  100,
+}
+
+// progressReporter 记录单次分析的进度，并以进行中回调通知 Problem 模块。
+// 回调在锁外发送，不阻塞并发分析的故障点对；按生成顺序编号，已发送更新的进度时丢弃过期的回调。
+// 上报失败只记录日志，不影响分析；nil 时不上报。
+type progressReporter struct {
+	problemID uint64
+	startTime time.Time
+	notify    func(ctx context.Context, cb domain.RCACallback) error
+	now       func() time.Time
+
+	mu         sync.Mutex
+	progress   domain.RCAProgress
+	lastReport time.Time
+	seq        uint64 // 最近一次生成的回调序号
+
+	sendMu sync.Mutex
+	sent   uint64 // 最近一次发送的回调序号
+}
+
+func newProgressReporter(problemID uint64, startTime time.Time, notify func(ctx context.Context, cb domain.RCACallback) error) *progressReporter {
+	return &progressReporter{
+		problemID: problemID,
+		startTime: startTime,
+		notify:    notify,
+		now:       time.Now,
+	}
+}
+
+// step 进入下一步骤，update 补充该步骤的阶段性结果。
+func (r *progressReporter) step(ctx context.Context, step domain.RCAStep, update func(p *domain.RCAProgress)) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.progress.Step = step
+	r.progress.Percent = stepPercent[step]
+	if update != nil {
+		update(&r.progress)
+	}
+	seq, cb := r.snapshot()
+	r.mu.Unlock()
+	r.send(ctx, seq, cb)
+}
+
+// pairsStarted 开始分析故障点对。
+func (r *progressReporter) pairsStarted(ctx context.Context, total int) {
+	r.step(ctx, domain.RCAStepPairsAnalyzing, func(p *domain.RCAProgress) {
+		p.TotalPairs = total
+		p.AnalyzedPairs = 0
+		p.CausalRelations = 0
+	})
+}
+
+// pairsAnalyzed count 个故障点对分析完成，relations 为发现的因果关系数；按 progressPairInterval 节流上报。
+func (r *progressReporter) pairsAnalyzed(ctx context.Context, count, relations int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.progress.AnalyzedPairs += count
+	r.progress.CausalRelations += relations
+	if total := r.progress.TotalPairs; total > 0 {
+		from, to := stepPercent[domain.RCAStepPairsAnalyzing], stepPercent[domain.RCAStepRootCauseChosen]
+		r.progress.Percent = from + (to-from)*r.progress.AnalyzedPairs/total
+	}
+	if r.progress.AnalyzedPairs < r.progress.TotalPairs && r.now().Sub(r.lastReport) < progressPairInterval {
+		r.mu.Unlock()
+		return
+	}
+	seq, cb := r.snapshot()
+	r.mu.Unlock()
+	r.send(ctx, seq, cb)
+}
+
+// complete 标记分析完成并返回最终进度，随完成回调一并写入，不单独上报。
+func (r *progressReporter) complete() *domain.RCAProgress {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress.Step = domain.RCAStepSummaryGenerated
+	r.progress.Percent = stepPercent[domain.RCAStepSummaryGenerated]
+	r.progress.UpdateTime = r.now()
+	p := r.progress
+	return &p
+}
+
+// snapshot 生成当前进度的进行中回调及其序号，需持有锁。
+func (r *progressReporter) snapshot() (uint64, domain.RCACallback) {
+	now := r.now()
+	r.lastReport = now
+	r.progress.UpdateTime = now
+	r.seq++
+	progress := r.progress
+	return r.seq, domain.RCACallback{
+		ProblemID:    r.problemID,
+		RcaStartTime: r.startTime,
+		RcaStatus:    domain.RcaStatusRunning,
+		InProgress:   true,
+		Progress:     &progress,
+	}
+}
+
+// send 发送进行中回调，已发送序号更大的回调时丢弃，保证进度不回退。
+func (r *progressReporter) send(ctx context.Context, seq uint64, cb domain.RCACallback) {
+	r.sendMu.Lock()
+	defer r.sendMu.Unlock()
+	if seq <= r.sent {
+		log.Debugf("RCA 问题 %d 的分析进度已有更新，丢弃过期进度，步骤: %s", r.problemID, cb.Progress.Step)
+		return
+	}
+	r.sent = seq
+	if err := r.notify(ctx, cb); err != nil {
+		log.Warnf("RCA 上报问题 %d 分析进度失败，步骤: %s, 错误: %v", r.problemID, cb.Progress.Step, err)
+	}
+}
@@ -0,0 +1,112 @@
+package rca
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProgressReporter(t *testing.T) {
+	Convey("TestProgressReporter", t, func() {
+		ctx := context.Background()
+		clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		var reported []domain.RCACallback
+		reporter := newProgressReporter(1, clock, func(_ context.Context, cb domain.RCACallback) error {
+			reported = append(reported, cb)
+			return nil
+		})
+		reporter.now = func() time.Time { return clock }
+
+		Convey("每个步骤完成时上报进行中回调", func() {
+			reporter.step(ctx, domain.RCAStepFaultPointsLoaded, func(p *domain.RCAProgress) {
+				p.FaultPointCount = 3
+			})
+			reporter.step(ctx, domain.RCAStepGraphRecalled, nil)
+
+			So(reported, ShouldHaveLength, 2)
+			So(reported[1].InProgress, ShouldBeTrue)
+			So(reported[1].RcaStatus, ShouldEqual, domain.RcaStatusRunning)
+			So(reported[1].Progress.Step, ShouldEqual, domain.RCAStepGraphRecalled)
+			So(reported[1].Progress.Percent, ShouldEqual, 20)
+			So(reported[1].Progress.FaultPointCount, ShouldEqual, 3)
+		})
+
+		Convey("故障点对分析按比例推进并节流上报", func() {
+			reporter.pairsStarted(ctx, 4)
+			reporter.pairsAnalyzed(ctx, 1, 1)
+			reporter.pairsAnalyzed(ctx, 1, 0)
+			So(reported, ShouldHaveLength, 1)
+
+			clock = clock.Add(progressPairInterval)
+			reporter.pairsAnalyzed(ctx, 1, 1)
+			So(reported, ShouldHaveLength, 2)
+			So(reported[1].Progress.AnalyzedPairs, ShouldEqual, 3)
+			So(reported[1].Progress.CausalRelations, ShouldEqual, 2)
+			So(reported[1].Progress.Percent, ShouldEqual, 72)
+
+			// 全部分析完成时立即上报
+			reporter.pairsAnalyzed(ctx, 1, 0)
+			So(reported, ShouldHaveLength, 3)
+			So(reported[2].Progress.AnalyzedPairs, ShouldEqual, 4)
+			So(reported[2].Progress.Percent, ShouldEqual, 90)
+		})
+
+		Convey("整问题推理一次完成多个故障点对", func() {
+			reporter.pairsStarted(ctx, 4)
+			reporter.pairsAnalyzed(ctx, 4, 2)
+
+			So(reported, ShouldHaveLength, 2)
+			So(reported[1].Progress.AnalyzedPairs, ShouldEqual, 4)
+			So(reported[1].Progress.CausalRelations, ShouldEqual, 2)
+		})
+
+		Convey("完成时返回最终进度且不单独上报", func() {
+			reporter.step(ctx, domain.RCAStepRootCauseChosen, func(p *domain.RCAProgress) {
+				p.RootCauseFaultID = 10
+			})
+
+			progress := reporter.complete()
+
+			So(reported, ShouldHaveLength, 1)
+			So(progress.Step, ShouldEqual, domain.RCAStepSummaryGenerated)
+			So(progress.Percent, ShouldEqual, 100)
+			So(progress.RootCauseFaultID, ShouldEqual, 10)
+		})
+
+		Convey("上报时不持有进度锁，过期的进度不再发送", func() {
+			seq, stale := reporter.snapshot()
+			reporter.notify = func(_ context.Context, cb domain.RCACallback) error {
+				// 发送过程中仍可更新进度
+				reporter.pairsAnalyzed(ctx, 0, 0)
+				reported = append(reported, cb)
+				return nil
+			}
+			reporter.pairsStarted(ctx, 2)
+			reporter.send(ctx, seq, stale)
+
+			So(reported, ShouldHaveLength, 1)
+			So(reported[0].Progress.Step, ShouldEqual, domain.RCAStepPairsAnalyzing)
+		})
+
+		Convey("上报失败不影响分析", func() {
+			reporter.notify = func(context.Context, domain.RCACallback) error { return errors.New("callback unavailable") }
+
+			So(func() { reporter.step(ctx, domain.RCAStepGraphRecalled, nil) }, ShouldNotPanic)
+		})
+
+		Convey("nil 时不上报", func() {
+			var nilReporter *progressReporter
+
+			So(func() {
+				nilReporter.step(ctx, domain.RCAStepGraphRecalled, nil)
+				nilReporter.pairsStarted(ctx, 1)
+				nilReporter.pairsAnalyzed(ctx, 1, 1)
+			}, ShouldNotPanic)
+			So(nilReporter.complete(), ShouldBeNil)
+		})
+	})
+}
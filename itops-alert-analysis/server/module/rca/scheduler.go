@@ -0,0 +1,278 @@
+package rca
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/log"
+)
+
+const (
+	defaultQuietPeriod = 30 * time.Second
+	defaultMaxDelay    = 5 * time.Minute
+	schedulerTick      = time.Second
+	analyzedRetention  = 24 * time.Hour // 分析记录保留时间，超过后再次变化按首次分析处理
+	analyzedPruneEvery = 10 * time.Minute
+)
+
+// pendingProblem 等待分析的问题：first 为本轮首次变化时间，last 为最近一次变化时间；
+// at 为指定的最早分析时间（失败重试、手动触发、启动恢复），force 表示无论是否变化都要分析。
+type pendingProblem struct {
+	first time.Time
+	last  time.Time
+	at    time.Time
+	force bool
+}
+
+// due 静默 quiet 后到期，持续变化时最迟在首次变化 maxDelay 后到期，且不早于指定时间。
+func (p pendingProblem) due(quiet, maxDelay time.Duration) time.Time {
+	if p.last.IsZero() {
+		return p.at
+	}
+	due := p.last.Add(quiet)
+	if limit := p.first.Add(maxDelay); limit.Before(due) {
+		due = limit
+	}
+	if p.at.After(due) {
+		return p.at
+	}
+	return due
+}
+
+// analyzedProblem 问题最近一次成功分析时的状态。
+type analyzedProblem struct {
+	signature string
+	at        time.Time
+}
+
+// scheduleHandler 调度器的执行方。
+type scheduleHandler interface {
+	// loadProblems 加载到期问题的当前状态
+	loadProblems(ctx context.Context, ids []uint64) ([]domain.Problem, error)
+	// analyze 分析问题，返回是否成功
+	analyze(ctx context.Context, problem domain.Problem) bool
+	// skip 到期的问题无需分析（问题不存在、故障点与等级未变化）
+	skip(ctx context.Context, problemID uint64, reason string)
+}
+
+// scheduler 按问题去抖的 RCA 调度器。
+// 问题变化时登记为待分析，到期后按等级从高到低、在并发上限内启动分析；
+// 分析中的问题再次变化时不取消当前分析，待其完成后按新状态重新评估；
+// 故障点集合与等级均未变化的问题不重复分析。
+type scheduler struct {
+	quiet       time.Duration
+	maxDelay    time.Duration
+	concurrency int
+	handler     scheduleHandler
+	now         func() time.Time
+
+	mu       sync.Mutex
+	pending  map[uint64]pendingProblem
+	running  map[uint64]struct{}
+	analyzed map[uint64]analyzedProblem
+	pruned   time.Time
+	wg       sync.WaitGroup
+}
+
+func newScheduler(cfg config.RCAConfig, handler scheduleHandler) *scheduler {
+	s := &scheduler{
+		quiet:       cfg.QuietPeriod,
+		maxDelay:    cfg.MaxDelay,
+		concurrency: cfg.Concurrency,
+		handler:     handler,
+		now:         time.Now,
+		pending:     make(map[uint64]pendingProblem),
+		running:     make(map[uint64]struct{}),
+		analyzed:    make(map[uint64]analyzedProblem),
+	}
+	if s.quiet <= 0 {
+		s.quiet = defaultQuietPeriod
+	}
+	if s.maxDelay <= 0 {
+		s.maxDelay = defaultMaxDelay
+	}
+	if s.maxDelay < s.quiet {
+		s.maxDelay = s.quiet
+	}
+	if s.concurrency <= 0 {
+		s.concurrency = MaxConcurrentRCA
+	}
+	return s
+}
+
+// schedule 登记问题变化，已在等待中的问题重新计算静默时间。
+func (s *scheduler) schedule(problemID uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	p := s.pending[problemID]
+	if p.first.IsZero() {
+		p.first = now
+	}
+	p.last = now
+	s.pending[problemID] = p
+}
+
+// scheduleAt 指定问题最早在 at 分析；force 为 true 时即使故障点与等级未变化也重新分析。
+func (s *scheduler) scheduleAt(problemID uint64, at time.Time, force bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.pending[problemID]
+	if p.at.IsZero() || at.Before(p.at) {
+		p.at = at
+	}
+	p.force = p.force || force
+	s.pending[problemID] = p
+}
+
+// run 周期性调度到期的问题，退出时等待分析中的任务结束。
+func (s *scheduler) run(ctx context.Context) {
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+	defer s.wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.dispatch(ctx)
+		}
+	}
+}
+
+// dispatch 加载到期的问题，跳过未实质变化的问题，按等级从高到低启动分析直到并发上限。
+func (s *scheduler) dispatch(ctx context.Context) {
+	ids := s.dueProblems()
+	if len(ids) == 0 {
+		return
+	}
+	problems, err := s.handler.loadProblems(ctx, ids)
+	if err != nil {
+		// 留在等待队列中，下次调度重试
+		log.Warnf("RCA 调度加载问题失败: %v", err)
+		return
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problemPriority(problems[i]) < problemPriority(problems[j])
+	})
+
+	skipped := map[uint64]string{}
+	defer func() {
+		// 在锁外通知，执行方可能需要写入存储
+		for id, reason := range skipped {
+			s.handler.skip(ctx, id, reason)
+		}
+	}()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := make(map[uint64]struct{}, len(problems))
+	for _, problem := range problems {
+		found[problem.ProblemID] = struct{}{}
+	}
+	for _, id := range ids {
+		if _, ok := found[id]; !ok {
+			log.Infof("RCA 调度: 问题 %d 不存在，移出等待队列", id)
+			delete(s.pending, id)
+			skipped[id] = "问题不存在"
+		}
+	}
+
+	for _, problem := range problems {
+		problemID := problem.ProblemID
+		p, ok := s.pending[problemID]
+		if !ok {
+			continue
+		}
+		if _, ok := s.running[problemID]; ok {
+			continue
+		}
+		signature := problemSignature(problem)
+		if last, ok := s.analyzed[problemID]; ok && last.signature == signature && !p.force {
+			log.Debugf("RCA 调度: 问题 %d 故障点与等级未变化，跳过重新分析", problemID)
+			delete(s.pending, problemID)
+			skipped[problemID] = "故障点与等级未变化"
+			continue
+		}
+		if len(s.running) >= s.concurrency {
+			break
+		}
+
+		delete(s.pending, problemID)
+		s.running[problemID] = struct{}{}
+		s.wg.Add(1)
+		go func(problem domain.Problem, signature string) {
+			defer s.wg.Done()
+			ok := s.handler.analyze(ctx, problem)
+
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.running, problem.ProblemID)
+			if ok {
+				s.analyzed[problem.ProblemID] = analyzedProblem{signature: signature, at: s.now()}
+			}
+		}(problem, signature)
+	}
+}
+
+// dueProblems 返回已到期且未在分析中的问题，并发已满时不加载。
+func (s *scheduler) dueProblems() []uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.pruneAnalyzed(now)
+	if len(s.running) >= s.concurrency {
+		return nil
+	}
+	var ids []uint64
+	for id, p := range s.pending {
+		if _, ok := s.running[id]; ok {
+			continue
+		}
+		if !p.due(s.quiet, s.maxDelay).After(now) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return s.pending[ids[i]].first.Before(s.pending[ids[j]].first)
+	})
+	return ids
+}
+
+// pruneAnalyzed 定期清理过期的分析记录，需持有锁。
+func (s *scheduler) pruneAnalyzed(now time.Time) {
+	if now.Sub(s.pruned) < analyzedPruneEvery {
+		return
+	}
+	s.pruned = now
+	for id, a := range s.analyzed {
+		if now.Sub(a.at) > analyzedRetention {
+			delete(s.analyzed, id)
+		}
+	}
+}
+
+// problemPriority 调度优先级，值越小越先分析；等级值越小等级越高，未知等级排在最后。
+func problemPriority(problem domain.Problem) int {
+	if problem.ProblemLevel <= 0 {
+		return int(domain.SeverityNormal) + 1
+	}
+	return int(problem.ProblemLevel)
+}
+
+// problemSignature 问题中影响分析结果的状态：故障点集合与等级。
+func problemSignature(problem domain.Problem) string {
+	ids := append([]uint64(nil), problem.RelationIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, fmt.Sprint(id))
+	}
+	return fmt.Sprintf("%d|%s", problem.ProblemLevel, strings.Join(parts, ","))
+}
@@ -0,0 +1,269 @@
+package rca
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// schedulerFixture 可控时间与问题状态的调度器。
+type schedulerFixture struct {
+	*scheduler
+
+	mu       sync.Mutex
+	clock    time.Time
+	problems map[uint64]domain.Problem
+	loadErr  error
+	analyzed []uint64
+	skipped  map[uint64]string
+	release  chan struct{}
+}
+
+func newSchedulerFixture(cfg config.RCAConfig) *schedulerFixture {
+	f := &schedulerFixture{
+		clock:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		problems: make(map[uint64]domain.Problem),
+		skipped:  make(map[uint64]string),
+	}
+	f.scheduler = newScheduler(cfg, f)
+	f.scheduler.now = func() time.Time {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.clock
+	}
+	return f
+}
+
+func (f *schedulerFixture) loadProblems(_ context.Context, ids []uint64) ([]domain.Problem, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.loadErr != nil {
+		return nil, f.loadErr
+	}
+	var problems []domain.Problem
+	for _, id := range ids {
+		if p, ok := f.problems[id]; ok {
+			problems = append(problems, p)
+		}
+	}
+	return problems, nil
+}
+
+func (f *schedulerFixture) analyze(_ context.Context, problem domain.Problem) bool {
+	f.mu.Lock()
+	f.analyzed = append(f.analyzed, problem.ProblemID)
+	release := f.release
+	f.mu.Unlock()
+	if release != nil {
+		<-release
+	}
+	return true
+}
+
+func (f *schedulerFixture) skip(_ context.Context, problemID uint64, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.skipped[problemID] = reason
+}
+
+func (f *schedulerFixture) advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clock = f.clock.Add(d)
+}
+
+func (f *schedulerFixture) put(problem domain.Problem) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.problems[problem.ProblemID] = problem
+}
+
+// dispatchAndWait 调度一次并等待启动的分析结束。
+func (f *schedulerFixture) dispatchAndWait() []uint64 {
+	f.dispatch(context.Background())
+	f.wg.Wait()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	analyzed := f.analyzed
+	f.analyzed = nil
+	return analyzed
+}
+
+func TestNewScheduler(t *testing.T) {
+	Convey("TestNewScheduler", t, func() {
+		Convey("未配置时使用默认值", func() {
+			s := newScheduler(config.RCAConfig{}, nil)
+
+			So(s.quiet, ShouldEqual, defaultQuietPeriod)
+			So(s.maxDelay, ShouldEqual, defaultMaxDelay)
+			So(s.concurrency, ShouldEqual, MaxConcurrentRCA)
+		})
+
+		Convey("最长等待不小于静默时间", func() {
+			s := newScheduler(config.RCAConfig{QuietPeriod: time.Minute, MaxDelay: time.Second, Concurrency: 2}, nil)
+
+			So(s.maxDelay, ShouldEqual, time.Minute)
+			So(s.concurrency, ShouldEqual, 2)
+		})
+	})
+}
+
+func TestScheduler_Dispatch(t *testing.T) {
+	Convey("TestScheduler_Dispatch", t, func() {
+		f := newSchedulerFixture(config.RCAConfig{QuietPeriod: 30 * time.Second, MaxDelay: 2 * time.Minute, Concurrency: 10})
+		f.put(domain.Problem{ProblemID: 1, ProblemLevel: domain.SeverityMajor, RelationIDs: []uint64{10}})
+
+		Convey("静默时间内的变化重新计时", func() {
+			f.schedule(1)
+			f.advance(20 * time.Second)
+			So(f.dispatchAndWait(), ShouldBeEmpty)
+
+			f.schedule(1)
+			f.advance(20 * time.Second)
+			So(f.dispatchAndWait(), ShouldBeEmpty)
+
+			f.advance(10 * time.Second)
+			So(f.dispatchAndWait(), ShouldResemble, []uint64{1})
+			So(f.pending, ShouldBeEmpty)
+		})
+
+		Convey("持续变化的问题最迟在最长等待后分析", func() {
+			f.schedule(1)
+			for i := 0; i < 5; i++ {
+				f.advance(20 * time.Second)
+				f.schedule(1)
+				So(f.dispatchAndWait(), ShouldBeEmpty)
+			}
+			f.advance(20 * time.Second)
+
+			So(f.dispatchAndWait(), ShouldResemble, []uint64{1})
+		})
+
+		Convey("故障点与等级未变化时不重复分析", func() {
+			f.schedule(1)
+			f.advance(30 * time.Second)
+			So(f.dispatchAndWait(), ShouldResemble, []uint64{1})
+
+			f.schedule(1)
+			f.advance(30 * time.Second)
+			So(f.dispatchAndWait(), ShouldBeEmpty)
+			So(f.pending, ShouldBeEmpty)
+			So(f.skipped, ShouldContainKey, uint64(1))
+		})
+
+		Convey("指定时间强制分析时不检查是否变化", func() {
+			f.schedule(1)
+			f.advance(30 * time.Second)
+			So(f.dispatchAndWait(), ShouldResemble, []uint64{1})
+
+			f.scheduleAt(1, f.now().Add(time.Minute), true)
+			f.advance(30 * time.Second)
+			So(f.dispatchAndWait(), ShouldBeEmpty)
+
+			f.advance(30 * time.Second)
+			So(f.dispatchAndWait(), ShouldResemble, []uint64{1})
+			So(f.skipped, ShouldBeEmpty)
+		})
+
+		Convey("新增故障点或等级变化时重新分析", func() {
+			f.schedule(1)
+			f.advance(30 * time.Second)
+			So(f.dispatchAndWait(), ShouldResemble, []uint64{1})
+
+			f.put(domain.Problem{ProblemID: 1, ProblemLevel: domain.SeverityMajor, RelationIDs: []uint64{11, 10}})
+			f.schedule(1)
+			f.advance(30 * time.Second)
+			So(f.dispatchAndWait(), ShouldResemble, []uint64{1})
+
+			f.put(domain.Problem{ProblemID: 1, ProblemLevel: domain.SeverityCritical, RelationIDs: []uint64{10, 11}})
+			f.schedule(1)
+			f.advance(30 * time.Second)
+			So(f.dispatchAndWait(), ShouldResemble, []uint64{1})
+		})
+
+		Convey("并发已满时按等级从高到低分析", func() {
+			f.scheduler.concurrency = 1
+			f.put(domain.Problem{ProblemID: 2, ProblemLevel: domain.SeverityEmergency, RelationIDs: []uint64{20}})
+			f.put(domain.Problem{ProblemID: 3, RelationIDs: []uint64{30}})
+			f.schedule(3)
+			f.schedule(1)
+			f.schedule(2)
+			f.advance(30 * time.Second)
+
+			So(f.dispatchAndWait(), ShouldResemble, []uint64{2})
+			So(f.dispatchAndWait(), ShouldResemble, []uint64{1})
+			So(f.dispatchAndWait(), ShouldResemble, []uint64{3})
+		})
+
+		Convey("分析中的问题再次变化时等待当前分析完成", func() {
+			f.mu.Lock()
+			f.release = make(chan struct{})
+			f.mu.Unlock()
+			f.schedule(1)
+			f.advance(30 * time.Second)
+			f.dispatch(context.Background())
+
+			f.put(domain.Problem{ProblemID: 1, ProblemLevel: domain.SeverityMajor, RelationIDs: []uint64{10, 11}})
+			f.schedule(1)
+			f.advance(30 * time.Second)
+			f.dispatch(context.Background())
+			So(f.pending, ShouldContainKey, uint64(1))
+
+			close(f.release)
+			f.wg.Wait()
+
+			// 当前分析完成后按新的故障点集合重新分析
+			So(f.dispatchAndWait(), ShouldResemble, []uint64{1, 1})
+			So(f.pending, ShouldBeEmpty)
+		})
+
+		Convey("加载失败时保留在等待队列", func() {
+			f.loadErr = errors.New("opensearch unavailable")
+			f.schedule(1)
+			f.advance(30 * time.Second)
+			So(f.dispatchAndWait(), ShouldBeEmpty)
+			So(f.pending, ShouldContainKey, uint64(1))
+
+			f.loadErr = nil
+			So(f.dispatchAndWait(), ShouldResemble, []uint64{1})
+		})
+
+		Convey("不存在的问题移出等待队列", func() {
+			f.schedule(404)
+			f.advance(30 * time.Second)
+
+			So(f.dispatchAndWait(), ShouldBeEmpty)
+			So(f.pending, ShouldBeEmpty)
+			So(f.skipped[404], ShouldEqual, "问题不存在")
+		})
+	})
+}
+
+func TestPendingProblem_Due(t *testing.T) {
+	Convey("TestPendingProblem_Due", t, func() {
+		base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		So(pendingProblem{at: base}.due(30*time.Second, time.Minute), ShouldEqual, base)
+		So(pendingProblem{first: base, last: base}.due(30*time.Second, time.Minute), ShouldEqual, base.Add(30*time.Second))
+		So(pendingProblem{first: base, last: base.Add(50 * time.Second)}.due(30*time.Second, time.Minute), ShouldEqual, base.Add(time.Minute))
+		// 重试时间晚于静默到期时间时，以重试时间为准
+		So(pendingProblem{first: base, last: base, at: base.Add(5 * time.Minute)}.due(30*time.Second, time.Minute), ShouldEqual, base.Add(5*time.Minute))
+	})
+}
+
+func TestProblemSignature(t *testing.T) {
+	Convey("TestProblemSignature", t, func() {
+		a := domain.Problem{ProblemLevel: domain.SeverityMajor, RelationIDs: []uint64{2, 1}}
+		b := domain.Problem{ProblemLevel: domain.SeverityMajor, RelationIDs: []uint64{1, 2}}
+
+		So(problemSignature(a), ShouldEqual, problemSignature(b))
+		So(a.RelationIDs, ShouldResemble, []uint64{2, 1})
+		So(problemSignature(domain.Problem{ProblemLevel: domain.SeverityCritical, RelationIDs: []uint64{1, 2}}), ShouldNotEqual, problemSignature(b))
+	})
+}
@@ -4,8 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
-	"sync/atomic"
 	"time"
 
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/config"
@@ -19,16 +17,18 @@ import (
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/idgen"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/timex"
 	"github.com/pkg/errors"
-	"golang.org/x/sync/errgroup"
 )
 
 // ProblemEventMessage 问题事件消息结构
 type ProblemEventMessage struct {
-	ProblemID uint64 `json:"problem_id"`
+	ProblemID uint64                  `json:"problem_id"`
+	EventType domain.ProblemEventType `json:"event_type,omitempty"`
 }
 
 // Service 接收 ProblemID 做 RCA，并通过回调异步返回结果。
-// 采用批次处理模式：每 5 分钟收集一批 problem_id，去重后并发处理。
+// 问题事件由调度器按问题去抖：静默一段时间后分析，等级高的问题优先，
+// 故障点集合与等级未变化时不重复分析。
+// 每次分析记录为 itops_rca_job 中的任务，失败按指数退避重试，服务重启后恢复未完成的任务。
 type Service struct {
 	config        config.Config
 	dipClient     *dip.Client
@@ -36,14 +36,9 @@ type Service struct {
 	callback      core.ProblemHandler
 	kafkaConsumer core.KafkaConsumer
 	repoFactory   *opensearch.RepositoryFactory
-
-	// 批次处理配置
-	batchWindow   time.Duration //批次处理窗口时间
-	maxConcurrent int           //处理并发数
-
-	mu           sync.Mutex                    // 保护 collected 和 runningTasks（只使用写锁，改为 Mutex）
-	collected    map[uint64]struct{}           // 收集到的问题 ID
-	runningTasks map[uint64]context.CancelFunc // 正在运行的任务
+	scheduler     *scheduler
+	jobs          *jobTracker
+	inferencer    CausalInferencer // 故障点对因果推理引擎
 }
 
 func New(
@@ -68,18 +63,21 @@ func New(
 		return nil, errors.Wrap(err, "初始化 RCA Kafka Consumer 失败")
 	}
 
-	return &Service{
+	s := &Service{
 		config:        config,
 		dipClient:     dipClient,
 		idGenerator:   idGenerator,
 		callback:      callback,
 		kafkaConsumer: rcaConsumer,
 		repoFactory:   repoFactory,
-		batchWindow:   5 * time.Minute,
-		maxConcurrent: MaxConcurrentRCA,
-		collected:     make(map[uint64]struct{}),
-		runningTasks:  make(map[uint64]context.CancelFunc),
-	}, nil
+	}
+	s.inferencer, err = defaultInferencerRegistry().Build(s, config.RCA.Inference)
+	if err != nil {
+		return nil, errors.Wrap(err, "初始化因果推理引擎失败")
+	}
+	s.jobs = newJobTracker(config.RCA, repoFactory.RCAJobs(), repoFactory.Problems(), idGenerator.NextID)
+	s.scheduler = newScheduler(config.RCA, s)
+	return s, nil
 }
 
 // Notify 模拟 RCA 异步回调 Problem 模块。
@@ -90,16 +88,17 @@ func (s *Service) Notify(ctx context.Context, cb domain.RCACallback) error {
 	return s.callback.HandleRCACallback(ctx, cb)
 }
 
-// Start 启动 RCA 服务，从 Kafka 消费问题事件并处理
+// Start 启动 RCA 服务，从 Kafka 消费问题事件并交给调度器
 func (s *Service) Start(ctx context.Context) error {
 	if s.kafkaConsumer == nil {
 		return errors.New("kafka consumer not configured")
 	}
 
-	log.Infof("RCA Service 启动 - 批次窗口: %v, 最大并发: %d", s.batchWindow, s.maxConcurrent)
+	log.Infof("RCA Service 启动 - 静默时间: %v, 最长等待: %v, 最大并发: %d, 最大执行次数: %d, 因果推理引擎: %s",
+		s.scheduler.quiet, s.scheduler.maxDelay, s.scheduler.concurrency, s.jobs.maxAttempts, s.inferencer.Name())
 
-	ticker := time.NewTicker(s.batchWindow)
-	defer ticker.Stop()
+	// 恢复重启前未完成的分析
+	s.recover(ctx)
 
 	// 启动 Kafka 消费协程
 	go func() {
@@ -113,11 +112,10 @@ func (s *Service) Start(ctx context.Context) error {
 			if event.ProblemID == 0 {
 				return errors.New(fmt.Sprintf("RCA 消息内容不合法: %+v", utils.JsonEncode(event)))
 			}
-			s.mu.Lock()
-			s.collected[event.ProblemID] = struct{}{}
-			collectedCount := len(s.collected)
-			s.mu.Unlock()
-			log.Debugf("====== RCA收到问题事件 ProblemID: %d, 当前收集数: %d ======", event.ProblemID, collectedCount)
+			// 合并故障点与等级变化都可能改变分析结果，是否重新分析由调度器按问题状态判断；
+			// 任务在调度器实际开始分析时创建，被去抖合并或跳过的事件不产生任务
+			s.scheduler.schedule(event.ProblemID)
+			log.Debugf("====== RCA收到问题事件 ProblemID: %d, 类型: %s ======", event.ProblemID, event.EventType)
 			return nil
 		}
 		if err := s.kafkaConsumer.ConsumeRawEvents(ctx, handler); err != nil {
@@ -125,39 +123,9 @@ func (s *Service) Start(ctx context.Context) error {
 		}
 	}()
 
-	// 批次处理循环
-	for {
-		select {
-		case <-ctx.Done():
-			log.Infof("RCA Service 收到停止信号")
-			return nil
-
-		case <-ticker.C:
-			s.mu.Lock()
-			collectedCount := len(s.collected)
-			log.Infof("RCA 窗口时间到，当前收集到 %d 个问题", collectedCount)
-			if len(s.collected) == 0 {
-				log.Debugf("RCA 本批次无问题需要处理，跳过")
-				s.mu.Unlock()
-				continue
-			}
-
-			// 转为切片
-			problemIDs := make([]uint64, 0, len(s.collected))
-			for pid := range s.collected {
-				problemIDs = append(problemIDs, pid)
-			}
-			s.collected = make(map[uint64]struct{})
-			s.mu.Unlock()
-
-			log.Infof("RCA 批次窗口触发，开始处理 %d 个问题，问题 IDs: %v", len(problemIDs), problemIDs)
-
-			// 并发处理
-			batchStart := time.Now()
-			s.processBatch(ctx, problemIDs)
-			log.Infof("RCA 批次处理完成，耗时: %v", time.Since(batchStart))
-		}
-	}
+	s.scheduler.run(ctx)
+	log.Infof("RCA Service 收到停止信号")
+	return nil
 }
 
 func (s *Service) Close() error {
@@ -182,83 +150,131 @@ func (s *Service) Close() error {
 	return nil
 }
 
-// processBatch 并发处理一批问题
-// 如果某个 problemID 已经在运行中，会先取消旧任务再启动新任务
-func (s *Service) processBatch(ctx context.Context, problemIDs []uint64) {
-	log.Debugf("RCA 初始化 errgroup，最大并发数: %d", s.maxConcurrent)
-	g, gctx := errgroup.WithContext(ctx)
-	g.SetLimit(s.maxConcurrent)
-
-	// 使用 atomic 操作计数，避免额外的锁
-	var successCount, failCount, canceledCount int64
-
-	for _, pid := range problemIDs {
-		problemID := pid
-
-		// 检查是否已有该 problemID 在运行（使用统一的锁）
-		s.mu.Lock()
-		if cancelFunc, exists := s.runningTasks[problemID]; exists {
-			log.Infof("RCA 问题 %d 已在运行中，取消旧任务并启动新任务", problemID)
-			cancelFunc() // 取消旧任务
-			atomic.AddInt64(&canceledCount, 1)
+// recover 恢复未完成的分析：存储中排队或运行中的任务，以及 RCA 等待中、分析中的问题。
+// 运行中的任务是重启时被中断的，按新一次执行重新分析。
+func (s *Service) recover(ctx context.Context) {
+	now := time.Now()
+	resumed := make(map[uint64]struct{})
+	jobs, err := s.jobs.restore(ctx)
+	if err != nil {
+		log.Warnf("RCA 恢复任务失败: %v", err)
+	}
+	for _, job := range jobs {
+		at := now
+		if job.NextRunTime != nil && job.NextRunTime.After(now) {
+			at = *job.NextRunTime
 		}
-		s.mu.Unlock()
-
-		g.Go(func() error {
-			log.Debugf("RCA 启动 goroutine 处理问题 %d", problemID)
-
-			// 为该任务创建独立的 context，可以单独取消
-			rcaCtx, cancel := context.WithTimeout(gctx, RCAProblemProcessingTimeout)
-
-			// 注册到运行中任务（使用统一的锁）
-			s.mu.Lock()
-			s.runningTasks[problemID] = cancel
-			s.mu.Unlock()
-
-			// 任务完成后清理
-			defer func() {
-				cancel()
-				s.mu.Lock()
-				delete(s.runningTasks, problemID)
-				s.mu.Unlock()
-			}()
-			analysisCallback, err := s.Submit(rcaCtx, domain.RCARequest{ProblemID: problemID})
-			if err != nil {
-				log.Errorf("RCA Service: 处理失败 problem_id=%d: %v", problemID, err)
-				if errors.Is(err, context.Canceled) {
-					log.Infof("RCA 问题 %d 任务被取消", problemID)
-					return nil
-				}
-				atomic.AddInt64(&failCount, 1)
-				return nil // 错误已记录，继续处理其他任务
-			}
-			// 记录处理结果
-			if analysisCallback != nil {
-				log.Infof("RCA Service: 发送  RCA 回调请求 problem_id=%d, 状态: %d", problemID, analysisCallback.RcaStatus)
-				// Step5: 发送回调给 Problem 模块（使用 rcaCtx 保持上下文一致性）
-				if err := s.Notify(rcaCtx, *analysisCallback); err != nil {
-					log.Errorf("RCA Service: 发送 RCA 回调失败，问题 ID: %d, 状态: %d, 错误: %v", problemID, analysisCallback.RcaStatus, err)
-					return nil
-				}
-				atomic.AddInt64(&successCount, 1)
-			} else {
-				log.Warnf("RCA Service: RCA 请求返回空结果 problem_id=%d", problemID)
-				atomic.AddInt64(&failCount, 1)
-			}
-			return nil
-		})
+		s.scheduler.scheduleAt(job.ProblemID, at, true)
+		resumed[job.ProblemID] = struct{}{}
+	}
+
+	problems, err := s.repoFactory.Problems().FindPendingRCA(ctx, s.recoveryWindow())
+	if err != nil {
+		log.Warnf("RCA 查询待分析的问题失败: %v", err)
+	}
+	for _, problem := range problems {
+		if _, ok := resumed[problem.ProblemID]; ok {
+			continue
+		}
+		if _, err := s.jobs.enqueue(ctx, problem.ProblemID, domain.RCAJobTriggerRecovery, ""); err != nil {
+			log.Warnf("RCA 登记问题 %d 的恢复任务失败: %v", problem.ProblemID, err)
+		}
+		s.scheduler.scheduleAt(problem.ProblemID, now, true)
+		resumed[problem.ProblemID] = struct{}{}
+	}
+	if len(resumed) > 0 {
+		log.Infof("RCA 恢复未完成的分析 %d 个（任务 %d 个）", len(resumed), len(jobs))
+	}
+}
+
+func (s *Service) recoveryWindow() time.Duration {
+	if s.config.RCA.RecoveryWindow > 0 {
+		return s.config.RCA.RecoveryWindow
+	}
+	return defaultRecoveryWindow
+}
+
+// RerunRCA 手动重新分析问题，忽略故障点与等级是否变化，立即进入调度。
+func (s *Service) RerunRCA(ctx context.Context, problemID uint64, by string) (domain.RCAJob, error) {
+	problems, err := s.repoFactory.Problems().QueryByIDs(ctx, []uint64{problemID})
+	if err != nil {
+		return domain.RCAJob{}, errors.Wrap(err, "查询问题失败")
+	}
+	if len(problems) == 0 {
+		return domain.RCAJob{}, errors.Wrapf(domain.ErrProblemNotFound, "问题 %d", problemID)
+	}
+	if s.jobs.running(problemID) {
+		return domain.RCAJob{}, errors.Wrapf(domain.ErrRCAJobRunning, "问题 %d", problemID)
+	}
+
+	job, err := s.jobs.enqueue(ctx, problemID, domain.RCAJobTriggerManual, by)
+	if err != nil {
+		return job, err
+	}
+	s.scheduler.scheduleAt(problemID, time.Now(), true)
+	log.Infof("RCA 手动重新分析问题 %d，任务 %d，操作人: %s", problemID, job.JobID, by)
+	return job, nil
+}
+
+// loadProblems 调度器加载到期问题的当前状态。
+func (s *Service) loadProblems(ctx context.Context, ids []uint64) ([]domain.Problem, error) {
+	return s.repoFactory.Problems().QueryByIDs(ctx, ids)
+}
+
+// skip 到期的问题无需分析，取消排队中的任务。
+func (s *Service) skip(ctx context.Context, problemID uint64, reason string) {
+	s.jobs.cancel(ctx, problemID, reason)
+}
+
+// analyze 执行问题的 RCA 任务，失败时按退避时间重新调度，返回是否分析成功。
+// 服务退出导致的中断不记录结果，任务保持运行中状态，重启后恢复。
+func (s *Service) analyze(ctx context.Context, problem domain.Problem) bool {
+	problemID := problem.ProblemID
+	if _, err := s.jobs.start(ctx, problemID); err != nil {
+		log.Warnf("RCA 问题 %d 任务状态记录失败: %v", problemID, err)
+	}
+
+	err := s.run(ctx, problemID)
+	if ctx.Err() != nil {
+		log.Infof("RCA 问题 %d 任务被取消", problemID)
+		return false
 	}
-	// 等待所有任务完成
-	if err := g.Wait(); err != nil {
-		log.Errorf("RCA 批次处理过程中发生错误: %v", err)
+	if retryAt, retry := s.jobs.finish(ctx, problemID, err); retry {
+		log.Infof("RCA 问题 %d 分析失败，%s 后重试", problemID, retryAt.Format(time.DateTime))
+		s.scheduler.scheduleAt(problemID, retryAt, true)
 	}
+	return err == nil
+}
 
-	// 记录批次处理统计信息
-	log.Infof("RCA 批次处理统计 - 成功: %d, 失败: %d, 取消: %d",
-		atomic.LoadInt64(&successCount),
-		atomic.LoadInt64(&failCount),
-		atomic.LoadInt64(&canceledCount))
+// run 分析单个问题并回调 Problem 模块。
+func (s *Service) run(ctx context.Context, problemID uint64) error {
+	rcaCtx, cancel := context.WithTimeout(ctx, RCAProblemProcessingTimeout)
+	defer cancel()
 
+	start := time.Now()
+	analysisCallback, err := s.Submit(rcaCtx, domain.RCARequest{ProblemID: problemID})
+	if err != nil {
+		log.Errorf("RCA Service: 处理失败 problem_id=%d: %v", problemID, err)
+		// 每次执行失败都回调失败状态，重试开始时重新标记为分析中；服务退出导致的中断不回调
+		if ctx.Err() == nil && analysisCallback != nil {
+			if notifyErr := s.Notify(ctx, *analysisCallback); notifyErr != nil {
+				log.Errorf("RCA Service: 发送 RCA 失败回调失败，问题 ID: %d, 错误: %v", problemID, notifyErr)
+			}
+		}
+		return err
+	}
+	if analysisCallback == nil {
+		log.Warnf("RCA Service: RCA 请求返回空结果 problem_id=%d", problemID)
+		return errors.New("RCA 请求返回空结果")
+	}
+
+	log.Infof("RCA Service: 发送  RCA 回调请求 problem_id=%d, 状态: %d, 耗时: %v", problemID, analysisCallback.RcaStatus, time.Since(start))
+	// Step5: 发送回调给 Problem 模块（使用 rcaCtx 保持上下文一致性）
+	if err := s.Notify(rcaCtx, *analysisCallback); err != nil {
+		log.Errorf("RCA Service: 发送 RCA 回调失败，问题 ID: %d, 状态: %d, 错误: %v", problemID, analysisCallback.RcaStatus, err)
+		return errors.Wrap(err, "发送 RCA 回调失败")
+	}
+	return nil
 }
 
 // Submit 接收 ProblemID，触发 RCA 处理
@@ -271,6 +287,7 @@ func (s *Service) Submit(ctx context.Context, req domain.RCARequest) (*domain.RC
 	}
 
 	log.Infof("========== RCA 开始分析, 问题 ID: %d，开始时间: %s ==========", problemID, startTime.Format(time.RFC3339))
+	progress := newProgressReporter(problemID, startTime, s.Notify)
 	// 检查必要的依赖
 	if s.repoFactory.Problems() == nil {
 		return s.createFailedCallback(problemID, startTime), errors.New("问题数据仓库未配置")
@@ -292,6 +309,9 @@ func (s *Service) Submit(ctx context.Context, req domain.RCARequest) (*domain.RC
 	if len(faultPointObjects) == 0 {
 		return s.createFailedCallback(problemID, startTime), errors.New("无关联故障点")
 	}
+	progress.step(ctx, domain.RCAStepFaultPointsLoaded, func(p *domain.RCAProgress) {
+		p.FaultPointCount = len(faultPointObjects)
+	})
 
 	// Step2: 故障点关联图召回
 	recallCtx, err := s.GraphRecall(ctx, faultPointObjects, problemObject)
@@ -299,9 +319,10 @@ func (s *Service) Submit(ctx context.Context, req domain.RCARequest) (*domain.RC
 		return s.createFailedCallback(problemID, startTime), errors.Wrapf(err, "图召回失败")
 	}
 	log.Infof("RCA 图召回完成，问题 ID: %d", problemID)
+	progress.step(ctx, domain.RCAStepGraphRecalled, nil)
 
 	// Step3: 因果分析推理
-	result, err := s.CausalAnalysis(ctx, faultPointObjects, recallCtx)
+	result, err := s.CausalAnalysis(ctx, faultPointObjects, recallCtx, progress)
 	if err != nil {
 
 		return s.createFailedCallback(problemID, startTime), errors.Wrapf(err, "因果分析失败")
@@ -312,6 +333,11 @@ func (s *Service) Submit(ctx context.Context, req domain.RCARequest) (*domain.RC
 
 	log.Infof("RCA 因果分析完成，问题 ID: %d, 根因对象 ID: %s, 根因故障 ID: %d",
 		problemID, result.RootCauseObjectID, result.RootCauseFaultID)
+	progress.step(ctx, domain.RCAStepRootCauseChosen, func(p *domain.RCAProgress) {
+		p.CausalRelations = len(result.CausalRelations)
+		p.RootCauseObjectID = result.RootCauseObjectID
+		p.RootCauseFaultID = result.RootCauseFaultID
+	})
 
 	// Step4: 构建故障溯源分析展示数据
 	analysisCallback, err := s.BuildAnalysisCallback(ctx, problemObject, faultPointObjects, recallCtx, result, startTime)
@@ -319,6 +345,7 @@ func (s *Service) Submit(ctx context.Context, req domain.RCARequest) (*domain.RC
 		// 构建回调失败
 		return s.createFailedCallback(problemID, startTime), errors.Wrapf(err, "构建分析回调数据失败")
 	}
+	analysisCallback.Progress = progress.complete()
 
 	log.Infof("========== RCA 分析完成，问题 ID: %d，状态: %d ==========", problemID, analysisCallback.RcaStatus)
 
@@ -426,7 +453,8 @@ func (s *Service) GraphRecall(ctx context.Context, faultPointObjects []domain.Fa
 
 // ----- Step3: 因果分析推理 -----
 // CausalAnalysis 故障点之间的因果分析推理
-func (s *Service) CausalAnalysis(ctx context.Context, faultPointInfos []domain.FaultPointObject, recallCtx *domain.GraphRecallContext) (*domain.CausalAnalysisResults, error) {
+// progress 为 nil 时不上报故障点对的分析进度。
+func (s *Service) CausalAnalysis(ctx context.Context, faultPointInfos []domain.FaultPointObject, recallCtx *domain.GraphRecallContext, progress *progressReporter) (*domain.CausalAnalysisResults, error) {
 	// 参数验证
 	if len(faultPointInfos) == 0 {
 		return &domain.CausalAnalysisResults{
@@ -443,7 +471,7 @@ func (s *Service) CausalAnalysis(ctx context.Context, faultPointInfos []domain.F
 	}
 
 	// 3.1 判断是否应建立新的因果关系（AI Agent 实现）
-	candidates := s.findCausalCandidates(ctx, faultPointInfos, recallCtx)
+	candidates := s.findCausalCandidates(ctx, faultPointInfos, recallCtx, progress)
 
 	// 3.2 根据因果关系，转换为"因果推理实体"和关系
 	// 将逻辑关系（AI Agent返回的故障点A -> 故障点B）转换为物理结构（实体A -> "因果推理实体" -> 实体B）
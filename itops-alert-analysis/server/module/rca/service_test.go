@@ -0,0 +1,55 @@
+package rca
+
+import (
+	"context"
+	"testing"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/core"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/domain"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/infra/opensearch"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-analysis/utils/idgen"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// callbackRecorder 记录 RCA 回调的问题处理器桩
+type callbackRecorder struct {
+	core.ProblemHandler
+	callbacks []domain.RCACallback
+}
+
+func (r *callbackRecorder) HandleRCACallback(_ context.Context, cb domain.RCACallback) error {
+	r.callbacks = append(r.callbacks, cb)
+	return nil
+}
+
+func TestService_Run(t *testing.T) {
+	Convey("TestService_Run", t, func() {
+		recorder := &callbackRecorder{}
+		// 未初始化 OpenSearch 客户端，查询问题失败
+		s := &Service{
+			idGenerator: idgen.New(),
+			callback:    recorder,
+			repoFactory: opensearch.NewRepositoryFactory(nil),
+		}
+
+		Convey("分析失败时每次执行都回调失败状态", func() {
+			ctx := context.Background()
+
+			So(s.run(ctx, 1), ShouldNotBeNil)
+			So(s.run(ctx, 1), ShouldNotBeNil)
+
+			So(recorder.callbacks, ShouldHaveLength, 2)
+			So(recorder.callbacks[0].ProblemID, ShouldEqual, 1)
+			So(recorder.callbacks[0].RcaStatus, ShouldEqual, domain.RcaStatusFailed)
+			So(recorder.callbacks[0].InProgress, ShouldBeFalse)
+		})
+
+		Convey("服务退出导致的中断不回调", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			So(s.run(ctx, 1), ShouldNotBeNil)
+			So(recorder.callbacks, ShouldBeEmpty)
+		})
+	})
+}
@@ -61,6 +61,9 @@ const (
 	agentRequestFieldEntityAID        = "entity_a_id"       // 对象实体A ID字段名
 	agentRequestFieldEntityBID        = "entity_b_id"       // 对象实体B ID字段名
 	agentRequestFieldTopologySubgraph = "topology_subgraph" // 拓扑子图字段名
+	// 整问题因果推理请求字段名
+	agentRequestFieldFaultTimeline     = "fault_timeline"     // 按发生时间排序的故障点列表字段名
+	agentRequestFieldTopologyRelations = "topology_relations" // 精简拓扑关系字段名
 )
 
 // ========== build_rcadata 相关常量定义 ==========
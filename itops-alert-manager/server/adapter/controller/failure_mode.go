@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"net/http"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/common"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/common/log"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/domain/service"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/domain/vo"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/kweaver-ai/kweaver-go-lib/rest"
+)
+
+type FailureModeController interface {
+	List(c *gin.Context)
+	Get(c *gin.Context)
+	Create(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+type failureModeController struct {
+	failureModeService service.FailureModeService
+	authVerifyService  service.AuthVerifyService
+	validate           *validator.Validate
+}
+
+// List 查询故障模式目录
+func (m *failureModeController) List(c *gin.Context) {
+	ctx := rest.GetLanguageCtx(c)
+	// token鉴权
+	_, errAuth := m.authVerifyService.TokenVerify(ctx, c)
+	if errAuth != nil {
+		httpErr := HandDomainError(ctx, errAuth)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	result, err := m.failureModeService.ListModes(ctx)
+	if err != nil {
+		log.Errorf("failure mode list failed err:%s", err.Error())
+		httpErr := HandDomainError(ctx, err)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	rest.ReplyOK(c, http.StatusOK, result)
+}
+
+// Get 查询单个故障模式
+func (m *failureModeController) Get(c *gin.Context) {
+	ctx := rest.GetLanguageCtx(c)
+	// token鉴权
+	_, errAuth := m.authVerifyService.TokenVerify(ctx, c)
+	if errAuth != nil {
+		httpErr := HandDomainError(ctx, errAuth)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	result, err := m.failureModeService.GetMode(ctx, c.Param("mode"))
+	if err != nil {
+		log.Errorf("failure mode get failed err:%s", err.Error())
+		httpErr := HandDomainError(ctx, err)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	rest.ReplyOK(c, http.StatusOK, result)
+}
+
+// Create 创建故障模式
+func (m *failureModeController) Create(c *gin.Context) {
+	ctx := rest.GetLanguageCtx(c)
+	// token鉴权
+	visitor, errAuth := m.authVerifyService.TokenVerify(ctx, c)
+	if errAuth != nil {
+		httpErr := HandDomainError(ctx, errAuth)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	req := vo.FailureMode{}
+	if err := c.ShouldBind(&req); err != nil {
+		httpErr := NewRestHTTPError(ctx, InvalidParameter).WithErrorDetails(common.ErrorDetailBind + err.Error())
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	log.Debugf("request create failure mode from host:%s ,req:%+v", c.Request.Host, req)
+	// 参数检验
+	if !m.validateMode(c, &req) {
+		return
+	}
+	err := m.failureModeService.CreateMode(ctx, &req, visitor.ID)
+	if err != nil {
+		log.Errorf("failure mode create failed err:%s", err.Error())
+		httpErr := HandDomainError(ctx, err)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	resp := vo.BaseResp{Success: 1}
+	rest.ReplyOK(c, http.StatusCreated, resp)
+}
+
+// Update 更新故障模式
+func (m *failureModeController) Update(c *gin.Context) {
+	ctx := rest.GetLanguageCtx(c)
+	// token鉴权
+	visitor, errAuth := m.authVerifyService.TokenVerify(ctx, c)
+	if errAuth != nil {
+		httpErr := HandDomainError(ctx, errAuth)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	req := vo.FailureMode{}
+	if err := c.ShouldBind(&req); err != nil {
+		httpErr := NewRestHTTPError(ctx, InvalidParameter).WithErrorDetails(common.ErrorDetailBind + err.Error())
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	log.Debugf("request update failure mode from host:%s ,req:%+v", c.Request.Host, req)
+	// 故障模式编码以路径参数为准，不可修改
+	req.Mode = c.Param("mode")
+	// 参数检验
+	if !m.validateMode(c, &req) {
+		return
+	}
+	err := m.failureModeService.UpdateMode(ctx, req.Mode, &req, visitor.ID)
+	if err != nil {
+		log.Errorf("failure mode update failed err:%s", err.Error())
+		httpErr := HandDomainError(ctx, err)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	resp := vo.BaseResp{Success: 1}
+	rest.ReplyOK(c, http.StatusAccepted, resp)
+}
+
+// Delete 删除故障模式
+func (m *failureModeController) Delete(c *gin.Context) {
+	ctx := rest.GetLanguageCtx(c)
+	// token鉴权
+	_, errAuth := m.authVerifyService.TokenVerify(ctx, c)
+	if errAuth != nil {
+		httpErr := HandDomainError(ctx, errAuth)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	err := m.failureModeService.DeleteMode(ctx, c.Param("mode"))
+	if err != nil {
+		log.Errorf("failure mode delete failed err:%s", err.Error())
+		httpErr := HandDomainError(ctx, err)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	resp := vo.BaseResp{Success: 1}
+	rest.ReplyOK(c, http.StatusOK, resp)
+}
+
+// validateMode 校验故障模式参数，校验失败时直接返回错误响应
+func (m *failureModeController) validateMode(c *gin.Context, req *vo.FailureMode) bool {
+	ctx := rest.GetLanguageCtx(c)
+	if err := m.validate.Struct(req); err != nil {
+		httpErr := HandleValidateError(ctx, err)
+		log.Errorf("failure mode validate err:%s", err.Error())
+		rest.ReplyError(c, httpErr)
+		return false
+	}
+	if err := m.failureModeService.ValidateMode(req); err != nil {
+		log.Errorf("failure mode validate err:%s", err.Error())
+		httpErr := NewRestHTTPError(ctx, InvalidParameter).WithErrorDetails(err.Error())
+		rest.ReplyError(c, httpErr)
+		return false
+	}
+	return true
+}
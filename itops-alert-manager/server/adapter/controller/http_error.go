@@ -47,6 +47,11 @@ var (
 			httpCode:  http.StatusUnauthorized,
 			errorCode: ModuleName + ".BadRequest.Unauthorized",
 		},
+		// http错误 409
+		"ConcurrentModification": {
+			httpCode:  http.StatusConflict,
+			errorCode: ModuleName + ".Conflict.ConcurrentModification",
+		},
 		// http错误 404
 		"NotFound": {
 			httpCode:  http.StatusNotFound,
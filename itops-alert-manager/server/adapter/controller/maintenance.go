@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"net/http"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/common"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/common/log"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/domain/service"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/domain/vo"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/kweaver-ai/kweaver-go-lib/rest"
+)
+
+type MaintenanceController interface {
+	List(c *gin.Context)
+	Get(c *gin.Context)
+	Create(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+type maintenanceController struct {
+	maintenanceService service.MaintenanceService
+	authVerifyService  service.AuthVerifyService
+	validate           *validator.Validate
+}
+
+// List 查询维护窗口
+func (m *maintenanceController) List(c *gin.Context) {
+	ctx := rest.GetLanguageCtx(c)
+	// token鉴权
+	_, errAuth := m.authVerifyService.TokenVerify(ctx, c)
+	if errAuth != nil {
+		httpErr := HandDomainError(ctx, errAuth)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	result, err := m.maintenanceService.ListWindows(ctx)
+	if err != nil {
+		log.Errorf("maintenance window list failed err:%s", err.Error())
+		httpErr := HandDomainError(ctx, err)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	rest.ReplyOK(c, http.StatusOK, result)
+}
+
+// Get 查询单个维护窗口
+func (m *maintenanceController) Get(c *gin.Context) {
+	ctx := rest.GetLanguageCtx(c)
+	// token鉴权
+	_, errAuth := m.authVerifyService.TokenVerify(ctx, c)
+	if errAuth != nil {
+		httpErr := HandDomainError(ctx, errAuth)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	result, err := m.maintenanceService.GetWindow(ctx, c.Param("id"))
+	if err != nil {
+		log.Errorf("maintenance window get failed err:%s", err.Error())
+		httpErr := HandDomainError(ctx, err)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	rest.ReplyOK(c, http.StatusOK, result)
+}
+
+// Create 创建维护窗口
+func (m *maintenanceController) Create(c *gin.Context) {
+	ctx := rest.GetLanguageCtx(c)
+	// token鉴权
+	visitor, errAuth := m.authVerifyService.TokenVerify(ctx, c)
+	if errAuth != nil {
+		httpErr := HandDomainError(ctx, errAuth)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	req := vo.MaintenanceWindow{}
+	if err := c.ShouldBind(&req); err != nil {
+		httpErr := NewRestHTTPError(ctx, InvalidParameter).WithErrorDetails(common.ErrorDetailBind + err.Error())
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	log.Debugf("request create maintenance window from host:%s ,req:%+v", c.Request.Host, req)
+	// 参数检验
+	if !m.validateWindow(c, &req) {
+		return
+	}
+	id, err := m.maintenanceService.CreateWindow(ctx, &req, visitor.ID)
+	if err != nil {
+		log.Errorf("maintenance window create failed err:%s", err.Error())
+		httpErr := HandDomainError(ctx, err)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	rest.ReplyOK(c, http.StatusCreated, map[string]string{"id": id})
+}
+
+// Update 更新维护窗口
+func (m *maintenanceController) Update(c *gin.Context) {
+	ctx := rest.GetLanguageCtx(c)
+	// token鉴权
+	visitor, errAuth := m.authVerifyService.TokenVerify(ctx, c)
+	if errAuth != nil {
+		httpErr := HandDomainError(ctx, errAuth)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	req := vo.MaintenanceWindow{}
+	if err := c.ShouldBind(&req); err != nil {
+		httpErr := NewRestHTTPError(ctx, InvalidParameter).WithErrorDetails(common.ErrorDetailBind + err.Error())
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	log.Debugf("request update maintenance window from host:%s ,req:%+v", c.Request.Host, req)
+	// 参数检验
+	if !m.validateWindow(c, &req) {
+		return
+	}
+	err := m.maintenanceService.UpdateWindow(ctx, c.Param("id"), &req, visitor.ID)
+	if err != nil {
+		log.Errorf("maintenance window update failed err:%s", err.Error())
+		httpErr := HandDomainError(ctx, err)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	resp := vo.BaseResp{Success: 1}
+	rest.ReplyOK(c, http.StatusAccepted, resp)
+}
+
+// Delete 删除维护窗口
+func (m *maintenanceController) Delete(c *gin.Context) {
+	ctx := rest.GetLanguageCtx(c)
+	// token鉴权
+	_, errAuth := m.authVerifyService.TokenVerify(ctx, c)
+	if errAuth != nil {
+		httpErr := HandDomainError(ctx, errAuth)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	err := m.maintenanceService.DeleteWindow(ctx, c.Param("id"))
+	if err != nil {
+		log.Errorf("maintenance window delete failed err:%s", err.Error())
+		httpErr := HandDomainError(ctx, err)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	resp := vo.BaseResp{Success: 1}
+	rest.ReplyOK(c, http.StatusOK, resp)
+}
+
+// validateWindow 校验维护窗口参数，校验失败时直接返回错误响应
+func (m *maintenanceController) validateWindow(c *gin.Context, req *vo.MaintenanceWindow) bool {
+	ctx := rest.GetLanguageCtx(c)
+	if err := m.validate.Struct(req); err != nil {
+		httpErr := HandleValidateError(ctx, err)
+		log.Errorf("maintenance window validate err:%s", err.Error())
+		rest.ReplyError(c, httpErr)
+		return false
+	}
+	if err := m.maintenanceService.ValidateWindow(req); err != nil {
+		log.Errorf("maintenance window validate err:%s", err.Error())
+		httpErr := NewRestHTTPError(ctx, InvalidParameter).WithErrorDetails(err.Error())
+		rest.ReplyError(c, httpErr)
+		return false
+	}
+	return true
+}
@@ -17,6 +17,8 @@ type ProblemController interface {
 	List(c *gin.Context)
 	Close(c *gin.Context)
 	SetRootCause(c *gin.Context)
+	Split(c *gin.Context)
+	Merge(c *gin.Context)
 	GetSubGraphByProblemId(c *gin.Context)
 }
 
@@ -120,6 +122,78 @@ func (p *problemController) SetRootCause(c *gin.Context) {
 	rest.ReplyOK(c, http.StatusCreated, resp)
 }
 
+// Split 将选定故障点拆分到新问题
+func (p *problemController) Split(c *gin.Context) {
+	ctx := rest.GetLanguageCtx(c)
+	// token鉴权
+	visitor, errAuth := p.authVerifyService.TokenVerify(ctx, c)
+	if errAuth != nil {
+		httpErr := HandDomainError(ctx, errAuth)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	problemId := c.Param("problem_id")
+	req := vo.SplitProblemParams{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpErr := NewRestHTTPError(ctx, InvalidParameter).WithErrorDetails(common.ErrorDetailBind + err.Error())
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	log.Debugf("request Split problem from host:%s ,req:%+v", c.Request.Host, req)
+	// 参数检验
+	if err := p.validate.Struct(&req); err != nil {
+		httpErr := HandleValidateError(ctx, err)
+		log.Errorf("Split problem request validate err:%s", err.Error())
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	err := p.problemService.Split(ctx, problemId, visitor.ID, req)
+	if err != nil {
+		log.Errorf("Split problem request failed err:%s", err.Error())
+		httpErr := dependency.NewClientRequestError(err)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	resp := vo.BaseResp{Success: 1}
+	rest.ReplyOK(c, http.StatusCreated, resp)
+}
+
+// Merge 将另一个问题合并到当前问题
+func (p *problemController) Merge(c *gin.Context) {
+	ctx := rest.GetLanguageCtx(c)
+	// token鉴权
+	visitor, errAuth := p.authVerifyService.TokenVerify(ctx, c)
+	if errAuth != nil {
+		httpErr := HandDomainError(ctx, errAuth)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	problemId := c.Param("problem_id")
+	req := vo.MergeProblemParams{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpErr := NewRestHTTPError(ctx, InvalidParameter).WithErrorDetails(common.ErrorDetailBind + err.Error())
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	log.Debugf("request Merge problem from host:%s ,req:%+v", c.Request.Host, req)
+	// 参数检验
+	if err := p.validate.Struct(&req); err != nil {
+		httpErr := HandleValidateError(ctx, err)
+		log.Errorf("Merge problem request validate err:%s", err.Error())
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	err := p.problemService.Merge(ctx, problemId, visitor.ID, req)
+	if err != nil {
+		log.Errorf("Merge problem request failed err:%s", err.Error())
+		httpErr := dependency.NewClientRequestError(err)
+		rest.ReplyError(c, httpErr)
+		return
+	}
+	resp := vo.BaseResp{Success: 1}
+	rest.ReplyOK(c, http.StatusCreated, resp)
+}
+
 // ListByExt 更新配置
 func (p *problemController) GetSubGraphByProblemId(c *gin.Context) {
 	ctx := rest.GetLanguageCtx(c)
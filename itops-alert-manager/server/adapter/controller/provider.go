@@ -7,7 +7,7 @@ import (
 	"github.com/google/wire"
 )
 
-var ProviderSet = wire.NewSet(NewValidator, NewProblemController, NewConfigController, NewHandlerRoute, NewRouterQuote)
+var ProviderSet = wire.NewSet(NewValidator, NewProblemController, NewConfigController, NewMaintenanceController, NewFailureModeController, NewHandlerRoute, NewRouterQuote)
 
 func NewValidator() *validator.Validate {
 	va := validator.New()
@@ -15,10 +15,13 @@ func NewValidator() *validator.Validate {
 }
 
 // NewHandlerRoute 返回模板的路由
-func NewHandlerRoute(problemController ProblemController, configController ConfigController) core.HttpRouter {
+func NewHandlerRoute(problemController ProblemController, configController ConfigController, maintenanceController MaintenanceController,
+	failureModeController FailureModeController) core.HttpRouter {
 	return &HandlerRoute{
 		pc: problemController,
 		cf: configController,
+		mw: maintenanceController,
+		fm: failureModeController,
 	}
 }
 
@@ -46,3 +49,21 @@ func NewConfigController(validate *validator.Validate, authVerifyService service
 		validate:          validate,
 	}
 }
+
+// NewMaintenanceController 返回维护窗口控制器
+func NewMaintenanceController(validate *validator.Validate, authVerifyService service.AuthVerifyService, maintenanceService service.MaintenanceService) MaintenanceController {
+	return &maintenanceController{
+		maintenanceService: maintenanceService,
+		authVerifyService:  authVerifyService,
+		validate:           validate,
+	}
+}
+
+// NewFailureModeController 返回故障模式目录控制器
+func NewFailureModeController(validate *validator.Validate, authVerifyService service.AuthVerifyService, failureModeService service.FailureModeService) FailureModeController {
+	return &failureModeController{
+		failureModeService: failureModeService,
+		authVerifyService:  authVerifyService,
+		validate:           validate,
+	}
+}
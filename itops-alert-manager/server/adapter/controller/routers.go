@@ -10,6 +10,8 @@ import (
 type HandlerRoute struct {
 	pc ProblemController
 	cf ConfigController
+	mw MaintenanceController
+	fm FailureModeController
 }
 
 func (r *HandlerRoute) SetRouter(app *gin.Engine) {
@@ -20,10 +22,22 @@ func (r *HandlerRoute) SetRouter(app *gin.Engine) {
 	group.POST("problem", r.pc.List)
 	group.PUT("problem/:problem_id/close", r.pc.Close)
 	group.PUT("problem/:problem_id/root_cause", r.pc.SetRootCause)
+	group.PUT("problem/:problem_id/split", r.pc.Split)
+	group.PUT("problem/:problem_id/merge", r.pc.Merge)
 	group.GET("problem/:problem_id/sub-graph", r.pc.GetSubGraphByProblemId)
 	group.POST("config", r.cf.Create)
 	group.PUT("config", r.cf.Update)
 	group.GET("config", r.cf.ListByExt)
+	group.GET("maintenance_window", r.mw.List)
+	group.POST("maintenance_window", r.mw.Create)
+	group.GET("maintenance_window/:id", r.mw.Get)
+	group.PUT("maintenance_window/:id", r.mw.Update)
+	group.DELETE("maintenance_window/:id", r.mw.Delete)
+	group.GET("failure_mode", r.fm.List)
+	group.POST("failure_mode", r.fm.Create)
+	group.GET("failure_mode/:mode", r.fm.Get)
+	group.PUT("failure_mode/:mode", r.fm.Update)
+	group.DELETE("failure_mode/:mode", r.fm.Delete)
 
 	inGroup := app.Group("/api/itops_alert_manager/v1/in/")
 	inGroup.GET("config", r.cf.ListByIn)
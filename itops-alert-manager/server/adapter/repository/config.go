@@ -4,6 +4,8 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/common/log"
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/core"
@@ -60,6 +62,91 @@ func (repo *configRepo) Update(ctx context.Context, config *entity.Config) core.
 	return nil
 }
 
+// Upsert 配置项存在时更新，不存在时创建（用于后续版本新增的配置项）
+func (repo *configRepo) Upsert(ctx context.Context, config *entity.Config) core.RepoError {
+	query := squirrel.Select("COUNT(1)").From(repo.TableName).
+		Where("f_config_key = ?", config.ConfigKey)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		log.Errorf("Failed to build SQL for count config: %v", err)
+		return dependency.NewRepoExecuteSqlError(err)
+	}
+
+	var count int
+	if err := repo.DB.QueryRowContext(ctx, sqlStr, args...).Scan(&count); err != nil {
+		log.Errorf("Failed to count config: %v", err)
+		return dependency.NewRepoExecuteSqlError(err)
+	}
+
+	if count == 0 {
+		return repo.Create(ctx, config)
+	}
+	return repo.Update(ctx, config)
+}
+
+// CompareAndSwap 乐观更新：配置项仍为 old 时写入 config，被其他请求修改过时不写入并返回 false。
+// old 为 nil 表示读取时配置项不存在，此时插入，主键冲突说明已被其他请求创建。
+func (repo *configRepo) CompareAndSwap(ctx context.Context, old *entity.Config, config *entity.Config) (bool, core.RepoError) {
+	if old == nil {
+		if err := repo.Create(ctx, config); err != nil {
+			existing, getErr := repo.GetByKey(ctx, config.ConfigKey)
+			if getErr == nil && existing != nil {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	}
+
+	query := squirrel.Update(repo.TableName).
+		SetMap(map[string]interface{}{
+			"f_config_value": config.ConfigValue,
+		}).
+		Where("f_config_key = ? AND f_config_value = ?", config.ConfigKey, old.ConfigValue)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		log.Errorf("Failed to build SQL for compare and swap config: %v", err)
+		return false, dependency.NewRepoExecuteSqlError(err)
+	}
+
+	result, err := repo.DB.ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		log.Errorf("Failed to compare and swap config: %v", err)
+		return false, dependency.NewRepoExecuteSqlError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		log.Errorf("Failed to get affected rows of config update: %v", err)
+		return false, dependency.NewRepoExecuteSqlError(err)
+	}
+	return affected > 0, nil
+}
+
+// GetByKey 按 ConfigKey 获取配置项，不存在时返回 nil
+func (repo *configRepo) GetByKey(ctx context.Context, key string) (*entity.Config, core.RepoError) {
+	query := squirrel.Select("f_config_key", "f_config_value").From(repo.TableName).
+		Where("f_config_key = ?", key)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		log.Errorf("Failed to build SQL for get config: %v", err)
+		return nil, dependency.NewRepoExecuteSqlError(err)
+	}
+
+	var config entity.Config
+	err = repo.DB.QueryRowContext(ctx, sqlStr, args...).Scan(&config.ConfigKey, &config.ConfigValue)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Errorf("Failed to get config: %v", err)
+		return nil, dependency.NewRepoExecuteSqlError(err)
+	}
+	return &config, nil
+}
+
 // ListAll 获取所有配置项
 func (repo *configRepo) ListAll(ctx context.Context) ([]*entity.Config, core.RepoError) {
 	query := squirrel.Select("*").From(repo.TableName)
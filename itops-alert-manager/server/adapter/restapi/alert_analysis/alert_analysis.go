@@ -5,8 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/domain/dependency"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/domain/vo"
 
 	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/common/log"
 	"github.com/kweaver-ai/kweaver-go-lib/rest"
@@ -70,3 +72,67 @@ func (uc *alertAnalysisClient) SetRootCause(ctx context.Context, problemId, root
 	}
 	return nil
 }
+
+func (uc *alertAnalysisClient) ListCorrelationRecords(ctx context.Context, problemId string) ([]vo.CorrelationRecord, error) {
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	reqUrl := fmt.Sprint(uc.domain, "/api/itops-alert-analysis/v1/problems/", problemId, "/correlations")
+	respCode, respData, err := uc.httpClient.Get(ctx, reqUrl, url.Values{}, headers)
+	if err != nil {
+		log.Errorf("List Correlation Records request methodError: %v , request url:%v\n", err, reqUrl)
+		return nil, err
+	}
+	if respCode != 200 {
+		log.Errorf("List Correlation Records request failed, request url:%v, respCode: %v, resp data: %v\n", reqUrl, respCode, respData)
+		return nil, fmt.Errorf("Get request method failed,request url:%v, respCode: %v, resp data: %v \n", reqUrl, respCode, respData)
+	}
+	respJson, err := json.Marshal(respData)
+	if err != nil {
+		log.Errorf("json Marshal, error: %v \n", err)
+		return nil, err
+	}
+	result := dependency.CorrelationRecordsResp{}
+	if err := json.Unmarshal(respJson, &result); err != nil {
+		log.Errorf("json Unmarshal, error: %v \n", err)
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+func (uc *alertAnalysisClient) Split(ctx context.Context, problemId string, faultIDs []uint64, operatedBy string) error {
+	return uc.postProblemEdit(ctx, problemId, "/split", dependency.ProblemSplitBody{
+		FaultIDs:   faultIDs,
+		OperatedBy: operatedBy,
+	})
+}
+
+func (uc *alertAnalysisClient) Merge(ctx context.Context, problemId string, sourceProblemID uint64, operatedBy string) error {
+	return uc.postProblemEdit(ctx, problemId, "/merge", dependency.ProblemMergeBody{
+		SourceProblemID: sourceProblemID,
+		OperatedBy:      operatedBy,
+	})
+}
+
+// postProblemEdit 调用告警分析的问题拆分/合并接口
+func (uc *alertAnalysisClient) postProblemEdit(ctx context.Context, problemId, action string, body any) error {
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		log.Errorf("json Marshal Error: %v", err)
+		return err
+	}
+	url := fmt.Sprint(uc.domain, "/api/itops-alert-analysis/v1/problems/", problemId, action)
+	respCode, respData, err := uc.httpClient.Post(ctx, url, headers, jsonData)
+	if err != nil {
+		log.Errorf("Edit Problem post request methodError: %v , request url:%v,params: %v,post data: %v\n", err, url, bytes.NewBuffer(jsonData), respData)
+		return err
+	}
+	if respCode != 200 {
+		log.Errorf("Edit Problem post request failed, request url:%v, respCode: %v,params: %v,post data: %v\n", url, respCode, bytes.NewBuffer(jsonData), respData)
+		return fmt.Errorf("Post request method failed,request url:%v, respCode: %v,params: %v,post data: %v \n", url, respCode, bytes.NewBuffer(jsonData), respData)
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package dependency
 
 import (
 	"context"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/domain/vo"
 )
 
 type ProblemCloseBody struct {
@@ -15,8 +17,28 @@ type RootCauseObjectIdParams struct {
 	RootCauseFaultID  uint64 `json:"root_cause_fault_id"`
 }
 
+// ProblemSplitBody 拆分问题请求体
+type ProblemSplitBody struct {
+	FaultIDs   []uint64 `json:"fault_ids"`
+	OperatedBy string   `json:"operated_by"`
+}
+
+// ProblemMergeBody 合并问题请求体
+type ProblemMergeBody struct {
+	SourceProblemID uint64 `json:"source_problem_id"`
+	OperatedBy      string `json:"operated_by"`
+}
+
 //go:generate mockgen -source ./uniquery_restapi.go -destination ../../mock/adapter/restapi/mock_uniquery_restapi.go -package mock
 type AlertAnalysisClient interface {
 	Close(ctx context.Context, problemId, closeBy string) error
 	SetRootCause(ctx context.Context, problemId, rootCauseObjectId string, rootCauseFaultID uint64) error
+	ListCorrelationRecords(ctx context.Context, problemId string) ([]vo.CorrelationRecord, error)
+	Split(ctx context.Context, problemId string, faultIDs []uint64, operatedBy string) error
+	Merge(ctx context.Context, problemId string, sourceProblemID uint64, operatedBy string) error
+}
+
+// CorrelationRecordsResp 问题关联记录查询结果
+type CorrelationRecordsResp struct {
+	Items []vo.CorrelationRecord `json:"items"`
 }
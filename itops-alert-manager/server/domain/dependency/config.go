@@ -10,5 +10,9 @@ import (
 type ConfigRepo interface {
 	Create(ctx context.Context, config *entity.Config) core.RepoError
 	Update(ctx context.Context, config *entity.Config) core.RepoError
+	Upsert(ctx context.Context, config *entity.Config) core.RepoError
+	GetByKey(ctx context.Context, key string) (*entity.Config, core.RepoError)
+	// CompareAndSwap 配置项仍为 old 时写入 config（old 为 nil 表示配置项不存在），返回是否写入
+	CompareAndSwap(ctx context.Context, old *entity.Config, config *entity.Config) (bool, core.RepoError)
 	ListAll(ctx context.Context) ([]*entity.Config, core.RepoError)
 }
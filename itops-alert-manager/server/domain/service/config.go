@@ -42,6 +42,7 @@ func (s *configService) CreateConfig(ctx context.Context, req *vo.ConfigReq) cor
 		"knowledge_network":  req.KnowledgeNetwork,
 		"fault_point_policy": req.FaultPointPolicy,
 		"problem_policy":     req.ProblemPolicy,
+		"ingest":             req.Ingest,
 	}
 	for key, value := range reqData {
 		valueByte, err := json.Marshal(value)
@@ -67,6 +68,7 @@ func (s *configService) UpdateConfig(ctx context.Context, req *vo.ConfigReq) cor
 		"knowledge_network":  req.KnowledgeNetwork,
 		"fault_point_policy": req.FaultPointPolicy,
 		"problem_policy":     req.ProblemPolicy,
+		"ingest":             req.Ingest,
 	}
 	if req.Platform.AuthToken != "" {
 		encrypted, err := s.aes.AESEncrypt([]byte(req.Platform.AuthToken))
@@ -89,7 +91,8 @@ func (s *configService) UpdateConfig(ctx context.Context, req *vo.ConfigReq) cor
 			ConfigValue: string(valueByte),
 		}
 
-		if err := s.configRepo.Update(ctx, config); err != nil {
+		// 使用 Upsert 兼容升级前未初始化的配置项（如 ingest）
+		if err := s.configRepo.Upsert(ctx, config); err != nil {
 			log.Errorf("Failed to update config: %v", err)
 			return NewSvcInternalError(err)
 		}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/common/log"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/core"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/domain/dependency"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/domain/entity"
+)
+
+// loadConfigList 读取 t_config 中以 JSON 数组保存的配置项（如维护窗口、故障模式目录），不存在时返回空列表
+func loadConfigList[T any](ctx context.Context, configRepo dependency.ConfigRepo, key string) ([]T, core.ServiceError) {
+	config, err := configRepo.GetByKey(ctx, key)
+	if err != nil {
+		log.Errorf("Failed to get config %s: %v", key, err)
+		return nil, NewSvcInternalError(err)
+	}
+	items := []T{}
+	if config == nil || config.ConfigValue == "" {
+		return items, nil
+	}
+	if err := json.Unmarshal([]byte(config.ConfigValue), &items); err != nil {
+		log.Errorf("Failed to unmarshal config %s: %v", key, err)
+		return nil, NewSvcInternalError(nil)
+	}
+	return items, nil
+}
+
+// saveConfigList 以 JSON 数组整体保存配置项
+func saveConfigList[T any](ctx context.Context, configRepo dependency.ConfigRepo, key string, items []T) core.ServiceError {
+	valueByte, err := json.Marshal(items)
+	if err != nil {
+		log.Errorf("Failed to marshal config %s: %v", key, err)
+		return NewSvcInternalError(nil)
+	}
+	config := &entity.Config{
+		ConfigKey:   key,
+		ConfigValue: string(valueByte),
+	}
+	if err := configRepo.Upsert(ctx, config); err != nil {
+		log.Errorf("Failed to save config %s: %v", key, err)
+		return NewSvcInternalError(err)
+	}
+	return nil
+}
+
+// maxConfigUpdateRetries 乐观更新配置项被其他请求修改时的最大重试次数
+const maxConfigUpdateRetries = 3
+
+// updateConfigList 读取-修改-写回 JSON 数组配置项。写回时比较读取到的原值，
+// 期间被其他请求（包括其他实例）修改过则重新读取并执行 mutate，重试耗尽返回并发修改错误。
+// mutate 可能被调用多次，不能依赖上一次调用的副作用。
+func updateConfigList[T any](ctx context.Context, configRepo dependency.ConfigRepo, key string,
+	mutate func(items []T) ([]T, core.ServiceError)) core.ServiceError {
+	for attempt := 0; attempt < maxConfigUpdateRetries; attempt++ {
+		old, err := configRepo.GetByKey(ctx, key)
+		if err != nil {
+			log.Errorf("Failed to get config %s: %v", key, err)
+			return NewSvcInternalError(err)
+		}
+		items := []T{}
+		if old != nil && old.ConfigValue != "" {
+			if err := json.Unmarshal([]byte(old.ConfigValue), &items); err != nil {
+				log.Errorf("Failed to unmarshal config %s: %v", key, err)
+				return NewSvcInternalError(nil)
+			}
+		}
+
+		items, svcErr := mutate(items)
+		if svcErr != nil {
+			return svcErr
+		}
+		valueByte, err := json.Marshal(items)
+		if err != nil {
+			log.Errorf("Failed to marshal config %s: %v", key, err)
+			return NewSvcInternalError(nil)
+		}
+
+		// 内容未变化时不写回：MySQL 对未变化的行返回 0 影响行数，会被误判为冲突
+		if old != nil && old.ConfigValue == string(valueByte) {
+			return nil
+		}
+
+		swapped, repoErr := configRepo.CompareAndSwap(ctx, old, &entity.Config{
+			ConfigKey:   key,
+			ConfigValue: string(valueByte),
+		})
+		if repoErr != nil {
+			log.Errorf("Failed to save config %s: %v", key, repoErr)
+			return NewSvcInternalError(repoErr)
+		}
+		if swapped {
+			return nil
+		}
+		log.Warnf("Config %s was modified concurrently, retrying (%d/%d)", key, attempt+1, maxConfigUpdateRetries)
+	}
+	return NewSvcConcurrentModificationError(nil)
+}
+
+// newConfigItemID 生成配置项 ID
+func newConfigItemID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -63,3 +63,10 @@ func NewSvcGenerateIDFailedError(err core.RepoError) core.ServiceError {
 		ErrType: "GenerateIDFailed",
 	}
 }
+
+func NewSvcConcurrentModificationError(err core.RepoError) core.ServiceError {
+	return &serviceError{
+		err:     err,
+		ErrType: "ConcurrentModification",
+	}
+}
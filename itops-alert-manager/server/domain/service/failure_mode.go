@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/core"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/domain/dependency"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/domain/vo"
+	"github.com/pkg/errors"
+)
+
+// failureModeConfigKey 故障模式目录在 t_config 中的配置键，随配置接口下发给 itops-alert-analysis
+const failureModeConfigKey = "failure_modes"
+
+var failureModeCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+//go:generate mockgen -source ./failure_mode.go -destination ../../mock/service/mock_failure_mode_service.go -package mock
+type FailureModeService interface {
+	ValidateMode(mode *vo.FailureMode) error
+	ListModes(ctx context.Context) ([]vo.FailureMode, core.ServiceError)
+	GetMode(ctx context.Context, mode string) (vo.FailureMode, core.ServiceError)
+	CreateMode(ctx context.Context, mode *vo.FailureMode, by string) core.ServiceError
+	UpdateMode(ctx context.Context, code string, mode *vo.FailureMode, by string) core.ServiceError
+	DeleteMode(ctx context.Context, mode string) core.ServiceError
+}
+
+type failureModeService struct {
+	configRepo dependency.ConfigRepo
+}
+
+// ValidateMode 校验故障模式编码与匹配规则
+func (s *failureModeService) ValidateMode(mode *vo.FailureMode) error {
+	if !failureModeCodePattern.MatchString(mode.Mode) {
+		return errors.New("mode 只能包含小写字母、数字和下划线，且以字母开头")
+	}
+	for i, rule := range mode.Rules {
+		if rule.Match != vo.FailureModeMatchRegex {
+			continue
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return errors.Wrapf(err, "rules[%d].pattern 正则表达式非法", i)
+		}
+	}
+	return nil
+}
+
+// ListModes 获取故障模式目录
+func (s *failureModeService) ListModes(ctx context.Context) ([]vo.FailureMode, core.ServiceError) {
+	return loadConfigList[vo.FailureMode](ctx, s.configRepo, failureModeConfigKey)
+}
+
+// GetMode 获取单个故障模式
+func (s *failureModeService) GetMode(ctx context.Context, code string) (vo.FailureMode, core.ServiceError) {
+	modes, svcErr := s.ListModes(ctx)
+	if svcErr != nil {
+		return vo.FailureMode{}, svcErr
+	}
+	for _, m := range modes {
+		if m.Mode == code {
+			return m, nil
+		}
+	}
+	return vo.FailureMode{}, NewSvcNotFoundError(nil)
+}
+
+// CreateMode 创建故障模式，编码不可重复
+func (s *failureModeService) CreateMode(ctx context.Context, mode *vo.FailureMode, by string) core.ServiceError {
+	modes, svcErr := s.ListModes(ctx)
+	if svcErr != nil {
+		return svcErr
+	}
+	for _, m := range modes {
+		if m.Mode == mode.Mode {
+			return NewSvcNameSameError(nil)
+		}
+	}
+	mode.UpdateTime = time.Now().Format(time.RFC3339)
+	mode.UpdateBy = by
+	modes = append(modes, *mode)
+	return saveConfigList(ctx, s.configRepo, failureModeConfigKey, modes)
+}
+
+// UpdateMode 更新故障模式，编码不可修改
+func (s *failureModeService) UpdateMode(ctx context.Context, code string, mode *vo.FailureMode, by string) core.ServiceError {
+	modes, svcErr := s.ListModes(ctx)
+	if svcErr != nil {
+		return svcErr
+	}
+	for i, m := range modes {
+		if m.Mode != code {
+			continue
+		}
+		mode.Mode = code
+		mode.UpdateTime = time.Now().Format(time.RFC3339)
+		mode.UpdateBy = by
+		modes[i] = *mode
+		return saveConfigList(ctx, s.configRepo, failureModeConfigKey, modes)
+	}
+	return NewSvcNotFoundError(nil)
+}
+
+// DeleteMode 删除故障模式
+func (s *failureModeService) DeleteMode(ctx context.Context, code string) core.ServiceError {
+	modes, svcErr := s.ListModes(ctx)
+	if svcErr != nil {
+		return svcErr
+	}
+	kept := make([]vo.FailureMode, 0, len(modes))
+	for _, m := range modes {
+		if m.Mode != code {
+			kept = append(kept, m)
+		}
+	}
+	if len(kept) == len(modes) {
+		return NewSvcNotFoundError(nil)
+	}
+	return saveConfigList(ctx, s.configRepo, failureModeConfigKey, kept)
+}
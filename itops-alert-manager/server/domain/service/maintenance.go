@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/common/log"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/core"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/domain/dependency"
+	"devops.aishu.cn/AISHUDevOps/AnyRobot/_git/itops-alert-manager/server/domain/vo"
+	"github.com/pkg/errors"
+)
+
+// maintenanceConfigKey 维护窗口在 t_config 中的配置键，随配置接口下发给 itops-alert-analysis
+const maintenanceConfigKey = "maintenance_windows"
+
+//go:generate mockgen -source ./maintenance.go -destination ../../mock/service/mock_maintenance_service.go -package mock
+type MaintenanceService interface {
+	ValidateWindow(window *vo.MaintenanceWindow) error
+	ListWindows(ctx context.Context) ([]vo.MaintenanceWindow, core.ServiceError)
+	GetWindow(ctx context.Context, id string) (vo.MaintenanceWindow, core.ServiceError)
+	CreateWindow(ctx context.Context, window *vo.MaintenanceWindow, by string) (string, core.ServiceError)
+	UpdateWindow(ctx context.Context, id string, window *vo.MaintenanceWindow, by string) core.ServiceError
+	DeleteWindow(ctx context.Context, id string) core.ServiceError
+}
+
+type maintenanceService struct {
+	// mu 串行化维护窗口的读取-修改-写回
+	mu         sync.Mutex
+	configRepo dependency.ConfigRepo
+}
+
+// ValidateWindow 校验维护窗口的时间范围与选择器
+func (s *maintenanceService) ValidateWindow(window *vo.MaintenanceWindow) error {
+	start, err := time.Parse(time.RFC3339, window.StartTime)
+	if err != nil {
+		return errors.Wrap(err, "start_time 格式错误")
+	}
+	end, err := time.Parse(time.RFC3339, window.EndTime)
+	if err != nil {
+		return errors.Wrap(err, "end_time 格式错误")
+	}
+	if !end.After(start) {
+		return errors.New("end_time 必须晚于 start_time")
+	}
+	if window.Recurrence.Until != "" {
+		until, err := time.Parse(time.RFC3339, window.Recurrence.Until)
+		if err != nil {
+			return errors.Wrap(err, "recurrence.until 格式错误")
+		}
+		if until.Before(start) {
+			return errors.New("recurrence.until 不能早于 start_time")
+		}
+	}
+	selector := window.Selector
+	if len(selector.EntityIDs) == 0 && len(selector.ObjectClasses) == 0 && len(selector.Subgraphs) == 0 {
+		return errors.New("selector 至少需要指定对象 ID、对象类或子图之一")
+	}
+	return nil
+}
+
+// ListWindows 获取所有维护窗口
+func (s *maintenanceService) ListWindows(ctx context.Context) ([]vo.MaintenanceWindow, core.ServiceError) {
+	return loadConfigList[vo.MaintenanceWindow](ctx, s.configRepo, maintenanceConfigKey)
+}
+
+// GetWindow 获取单个维护窗口
+func (s *maintenanceService) GetWindow(ctx context.Context, id string) (vo.MaintenanceWindow, core.ServiceError) {
+	windows, svcErr := s.ListWindows(ctx)
+	if svcErr != nil {
+		return vo.MaintenanceWindow{}, svcErr
+	}
+	for _, w := range windows {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return vo.MaintenanceWindow{}, NewSvcNotFoundError(nil)
+}
+
+// CreateWindow 创建维护窗口，返回窗口 ID
+func (s *maintenanceService) CreateWindow(ctx context.Context, window *vo.MaintenanceWindow, by string) (string, core.ServiceError) {
+	id, err := newConfigItemID()
+	if err != nil {
+		log.Errorf("Failed to generate maintenance window id: %v", err)
+		return "", NewSvcGenerateIDFailedError(nil)
+	}
+	now := time.Now().Format(time.RFC3339)
+	window.ID = id
+	window.CreateTime = now
+	window.UpdateTime = now
+	window.UpdateBy = by
+
+	svcErr := s.updateWindows(ctx, func(windows []vo.MaintenanceWindow) ([]vo.MaintenanceWindow, core.ServiceError) {
+		for _, w := range windows {
+			if w.Name == window.Name {
+				return nil, NewSvcNameSameError(nil)
+			}
+		}
+		return append(windows, *window), nil
+	})
+	if svcErr != nil {
+		return "", svcErr
+	}
+	return id, nil
+}
+
+// UpdateWindow 更新维护窗口
+func (s *maintenanceService) UpdateWindow(ctx context.Context, id string, window *vo.MaintenanceWindow, by string) core.ServiceError {
+	window.ID = id
+	window.UpdateTime = time.Now().Format(time.RFC3339)
+	window.UpdateBy = by
+
+	return s.updateWindows(ctx, func(windows []vo.MaintenanceWindow) ([]vo.MaintenanceWindow, core.ServiceError) {
+		idx := -1
+		for i, w := range windows {
+			if w.ID == id {
+				idx = i
+				continue
+			}
+			if w.Name == window.Name {
+				return nil, NewSvcNameSameError(nil)
+			}
+		}
+		if idx < 0 {
+			return nil, NewSvcNotFoundError(nil)
+		}
+		window.CreateTime = windows[idx].CreateTime
+		windows[idx] = *window
+		return windows, nil
+	})
+}
+
+// DeleteWindow 删除维护窗口
+func (s *maintenanceService) DeleteWindow(ctx context.Context, id string) core.ServiceError {
+	return s.updateWindows(ctx, func(windows []vo.MaintenanceWindow) ([]vo.MaintenanceWindow, core.ServiceError) {
+		kept := make([]vo.MaintenanceWindow, 0, len(windows))
+		for _, w := range windows {
+			if w.ID != id {
+				kept = append(kept, w)
+			}
+		}
+		if len(kept) == len(windows) {
+			return nil, NewSvcNotFoundError(nil)
+		}
+		return kept, nil
+	})
+}
+
+// updateWindows 读取-修改-写回维护窗口列表。互斥锁串行化本实例内的修改，
+// 写回时比较原值防止多实例并发修改互相覆盖。
+func (s *maintenanceService) updateWindows(ctx context.Context, mutate func([]vo.MaintenanceWindow) ([]vo.MaintenanceWindow, core.ServiceError)) core.ServiceError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return updateConfigList(ctx, s.configRepo, maintenanceConfigKey, mutate)
+}
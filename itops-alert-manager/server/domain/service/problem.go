@@ -16,6 +16,8 @@ type ProblemService interface {
 	List(ctx context.Context, req vo.DataViewQueryV2, accout_id string) (vo.ViewUniResponseV2, core.RestAPIError)
 	Close(ctx context.Context, problemId, accountId string) core.RestAPIError
 	SetRootCause(ctx context.Context, problemId string, req vo.RootCauseObjectIdParams) core.RestAPIError
+	Split(ctx context.Context, problemId, accountId string, req vo.SplitProblemParams) core.RestAPIError
+	Merge(ctx context.Context, problemId, accountId string, req vo.MergeProblemParams) core.RestAPIError
 	GetSubGraphByProblemId(ctx context.Context, problemId, accountId string) (vo.RcaContextResp, core.RestAPIError)
 	SubGraphQuery(ctx context.Context, faultObjectResp []map[string]any, result *vo.RcaContextResp) core.RestAPIError
 	GetRelationInfo(faultObjectResp []map[string]any) (map[string][]any, map[string][]float64, map[string]float64)
@@ -93,6 +95,30 @@ func (svc *problemService) SetRootCause(ctx context.Context, problemId string, r
 	return nil
 }
 
+// Split 将选定故障点拆分到新问题
+func (svc *problemService) Split(ctx context.Context, problemId, accountId string, req vo.SplitProblemParams) core.RestAPIError {
+	accountInfo, err := svc.userManagementClient.GetUserInfo(ctx, accountId)
+	if err != nil {
+		return dependency.NewClientRequestError(err)
+	}
+	if err := svc.alertAnalysisClient.Split(ctx, problemId, req.FaultIDs, accountInfo.Account); err != nil {
+		return dependency.NewClientRequestError(err)
+	}
+	return nil
+}
+
+// Merge 将另一个问题合并到当前问题
+func (svc *problemService) Merge(ctx context.Context, problemId, accountId string, req vo.MergeProblemParams) core.RestAPIError {
+	accountInfo, err := svc.userManagementClient.GetUserInfo(ctx, accountId)
+	if err != nil {
+		return dependency.NewClientRequestError(err)
+	}
+	if err := svc.alertAnalysisClient.Merge(ctx, problemId, req.SourceProblemID, accountInfo.Account); err != nil {
+		return dependency.NewClientRequestError(err)
+	}
+	return nil
+}
+
 func (svc *problemService) GetSubGraphByProblemId(ctx context.Context, problemId, accountId string) (vo.RcaContextResp, core.RestAPIError) {
 	resp := vo.RcaContextResp{}
 	req := vo.DataViewQueryV2{
@@ -166,6 +192,12 @@ func (svc *problemService) GetSubGraphByProblemId(ctx context.Context, problemId
 		return resp, errApi
 	}
 	resp.BackTrace = faultObjectResp
+	// 关联记录仅用于解释，查询失败不影响问题详情
+	correlations, err := svc.alertAnalysisClient.ListCorrelationRecords(ctx, problemId)
+	if err != nil {
+		log.Errorf("List correlation records of problem %s failed: %v", problemId, err)
+	}
+	resp.Correlations = correlations
 	return resp, nil
 }
 
@@ -6,7 +6,7 @@ import (
 	"github.com/google/wire"
 )
 
-var ProviderSet = wire.NewSet(NewAesService, NewConfigService, NewProblemService, NewAuthVerifyService)
+var ProviderSet = wire.NewSet(NewAesService, NewConfigService, NewProblemService, NewAuthVerifyService, NewMaintenanceService, NewFailureModeService)
 
 func NewProblemService(uniQueryClient dependency.UniQueryClient, alertAnalysisClient dependency.AlertAnalysisClient,
 	userManagementClient dependency.UserManagementClient, knowledgeNetworkClient dependency.KnowledgeNetworkClient,
@@ -33,3 +33,11 @@ func NewConfigService(configRepo dependency.ConfigRepo, aes AesService) ConfigSe
 func NewAesService() AesService {
 	return &aesService{Key: []byte("b279d!4zbne4ut*5")}
 }
+
+func NewMaintenanceService(configRepo dependency.ConfigRepo) MaintenanceService {
+	return &maintenanceService{configRepo: configRepo}
+}
+
+func NewFailureModeService(configRepo dependency.ConfigRepo) FailureModeService {
+	return &failureModeService{configRepo: configRepo}
+}
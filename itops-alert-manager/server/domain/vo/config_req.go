@@ -4,8 +4,13 @@ package vo
 type ConfigReq struct {
 	Platform         PlatformConfig   `mapstructure:"platform" form:"platform" json:"platform" `
 	KnowledgeNetwork KnowledgeNetwork `mapstructure:"knowledge_network" form:"knowledge_network" json:"knowledge_network"`
-	FaultPointPolicy Policy           `mapstructure:"fault_point_policy" form:"fault_point_policy" json:"fault_point_policy" validate:"required"`
-	ProblemPolicy    Policy           `mapstructure:"problem_policy" form:"problem_policy" json:"problem_policy" validate:"required"`
+	FaultPointPolicy FaultPointPolicy `mapstructure:"fault_point_policy" form:"fault_point_policy" json:"fault_point_policy" validate:"required"`
+	ProblemPolicy    ProblemPolicy    `mapstructure:"problem_policy" form:"problem_policy" json:"problem_policy" validate:"required"`
+	Ingest           Ingest           `mapstructure:"ingest" form:"ingest" json:"ingest"`
+	// 维护窗口通过 /maintenance_window 接口单独维护，配置保存时忽略
+	MaintenanceWindows []MaintenanceWindow `mapstructure:"maintenance_windows" form:"-" json:"maintenance_windows" validate:"-"`
+	// 故障模式目录通过 /failure_mode 接口单独维护，配置保存时忽略
+	FailureModes []FailureMode `mapstructure:"failure_modes" form:"-" json:"failure_modes" validate:"-"`
 }
 
 // PlatformConfig 平台连接配置
@@ -18,9 +23,149 @@ type KnowledgeNetwork struct {
 	KnowledgeID string `mapstructure:"knowledge_id" form:"knowledge_id" json:"knowledge_id"`
 }
 
-// Policy 通用策略结构（用于故障点和问题策略）
-type Policy struct {
-	Expiration Expiration `mapstructure:"expiration" form:"expiration" json:"expiration" validate:"required"`
+// Ingest 数据摄取配置
+type Ingest struct {
+	DefaultSource    string            `mapstructure:"default_source" form:"default_source" json:"default_source"`                        // 默认数据源，无法识别来源时使用
+	Sources          []string          `mapstructure:"sources" form:"sources" json:"sources" validate:"omitempty,dive,required"`          // 启用的数据源，多于一个时为多数据源模式
+	JSONMappings     []JSONMappingRule `mapstructure:"json_mappings" form:"json_mappings" json:"json_mappings" validate:"omitempty,dive"` // json_mapping 声明式映射规则
+	UnresolvedEntity UnresolvedEntity  `mapstructure:"unresolved_entity" form:"unresolved_entity" json:"unresolved_entity"`               // 未解析实体兜底
+	Dedup            Dedup             `mapstructure:"dedup" form:"dedup" json:"dedup"`                                                   // 重复事件去重
+	Flapping         Flapping          `mapstructure:"flapping" form:"flapping" json:"flapping"`                                          // 告警抖动抑制
+}
+
+// Dedup 重复事件去重配置，窗口内同一数据源、同一上游事件 ID、同一状态的事件不再重复入库
+type Dedup struct {
+	Enabled       bool `mapstructure:"enabled" json:"enabled"`
+	WindowSeconds int  `mapstructure:"window_seconds" json:"window_seconds" validate:"omitempty,gte=1"` // 去重窗口（秒）
+}
+
+// Flapping 告警抖动抑制配置，同一实体、同一事件类型在窗口内状态切换达到阈值即标记故障点为抖动
+type Flapping struct {
+	Enabled       bool `mapstructure:"enabled" json:"enabled"`
+	Threshold     int  `mapstructure:"threshold" json:"threshold" validate:"omitempty,gte=2"`           // 状态切换次数阈值
+	WindowMinutes int  `mapstructure:"window_minutes" json:"window_minutes" validate:"omitempty,gte=1"` // 统计窗口（分钟）
+}
+
+// UnresolvedEntity 未解析实体兜底配置，启用后知识网络中查不到对象的告警按主机名/IP 关联到占位对象
+type UnresolvedEntity struct {
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+}
+
+// JSONMappingRule json_mapping 映射规则，字段表达式为 JSON 路径（如 $.alerts[0].host）或 ${路径} 模板
+type JSONMappingRule struct {
+	Name        string            `mapstructure:"name" json:"name" validate:"required"` // 数据源名称
+	Match       map[string]string `mapstructure:"match" json:"match"`                   // 嗅探条件：路径 -> 期望值
+	ItemsPath   string            `mapstructure:"items_path" json:"items_path"`         // 告警数组路径
+	TimeLayout  string            `mapstructure:"time_layout" json:"time_layout"`       // 时间格式
+	Fields      JSONMappingFields `mapstructure:"fields" json:"fields"`                 // 字段映射
+	SeverityMap map[string]int    `mapstructure:"severity_map" json:"severity_map"`     // 级别值映射：原始值 -> 1-5
+	StatusMap   map[string]string `mapstructure:"status_map" json:"status_map"`         // 状态值映射：原始值 -> occurred / recovered
+}
+
+// JSONMappingFields json_mapping 字段表达式
+type JSONMappingFields struct {
+	ProviderID   string `mapstructure:"provider_id" json:"provider_id"`
+	Title        string `mapstructure:"title" json:"title"`
+	Content      string `mapstructure:"content" json:"content"`
+	Severity     string `mapstructure:"severity" json:"severity"`
+	Status       string `mapstructure:"status" json:"status"`
+	OccurTime    string `mapstructure:"occur_time" json:"occur_time"`
+	RecoveryTime string `mapstructure:"recovery_time" json:"recovery_time"`
+	EntityName   string `mapstructure:"entity_name" json:"entity_name" validate:"required"`
+	EntityIP     string `mapstructure:"entity_ip" json:"entity_ip"`
+	EntityMAC    string `mapstructure:"entity_mac" json:"entity_mac"`
+	ItemKey      string `mapstructure:"item_key" json:"item_key"`
+	RecoveryID   string `mapstructure:"recovery_id" json:"recovery_id"`
+}
+
+// ProblemPolicy 问题策略
+type ProblemPolicy struct {
+	Expiration  Expiration         `mapstructure:"expiration" form:"expiration" json:"expiration" validate:"required"`
+	Spatial     SpatialCorrelation `mapstructure:"spatial" form:"spatial" json:"spatial"`             // 空间相关性策略
+	Correlation Correlation        `mapstructure:"correlation" form:"correlation" json:"correlation"` // 问题合并策略组合
+	Reopen      Reopen             `mapstructure:"reopen" form:"reopen" json:"reopen"`                // 问题重开与复发关联
+}
+
+// Reopen 问题重开与复发关联：相同对象 + 故障模式再次发生时，窗口内重开已关闭问题，否则新问题关联为复发
+type Reopen struct {
+	Enabled       bool `mapstructure:"enabled" json:"enabled"`
+	WindowMinutes int  `mapstructure:"window_minutes" json:"window_minutes" validate:"omitempty,gte=1,lte=1440"` // 重开窗口（分钟），默认 30
+	LookbackHours int  `mapstructure:"lookback_hours" json:"lookback_hours" validate:"omitempty,gte=1,lte=720"`  // 复发回溯时间（小时），默认 168
+}
+
+// Correlation 问题合并策略组合，strategies 为空时只启用空间相关性
+type Correlation struct {
+	Strategies []string            `mapstructure:"strategies" json:"strategies" validate:"omitempty,dive,oneof=spatial temporal semantic"`
+	Combine    string              `mapstructure:"combine" json:"combine" validate:"omitempty,oneof=any all"` // 默认 any
+	Temporal   TemporalCorrelation `mapstructure:"temporal" json:"temporal"`
+	Semantic   SemanticCorrelation `mapstructure:"semantic" json:"semantic"`
+}
+
+// TemporalCorrelation 时间突发相关性：同一对象组内的故障点在窗口内相继发生时相关
+type TemporalCorrelation struct {
+	WindowSeconds int           `mapstructure:"window_seconds" json:"window_seconds" validate:"omitempty,gte=1,lte=3600"` // 时间窗口（秒），默认 60
+	MinScore      float64       `mapstructure:"min_score" json:"min_score" validate:"omitempty,gte=0,lte=1"`
+	Groups        []ObjectGroup `mapstructure:"groups" json:"groups" validate:"omitempty,dive"`
+}
+
+// ObjectGroup 对象组，按对象类或对象 ID 圈定
+type ObjectGroup struct {
+	Name          string   `mapstructure:"name" json:"name" validate:"required"`
+	ObjectClasses []string `mapstructure:"object_classes" json:"object_classes"`
+	EntityIDs     []string `mapstructure:"entity_ids" json:"entity_ids"`
+}
+
+// SemanticCorrelation 语义相关性：按故障名称与描述的文本相似度判断
+type SemanticCorrelation struct {
+	MinScore float64 `mapstructure:"min_score" json:"min_score" validate:"omitempty,gte=0,lte=1"` // 默认 0.6
+}
+
+// SpatialCorrelation 空间相关性配置，规则按故障点对象类匹配，未命中时使用默认规则
+type SpatialCorrelation struct {
+	Default SpatialRule   `mapstructure:"default" json:"default"`
+	Rules   []SpatialRule `mapstructure:"rules" json:"rules" validate:"omitempty,dive"`
+}
+
+// SpatialRule 空间相关性规则
+type SpatialRule struct {
+	ObjectClass string            `mapstructure:"object_class" json:"object_class"`
+	MaxHops     int               `mapstructure:"max_hops" json:"max_hops" validate:"omitempty,gte=1,lte=5"`           // 最大跳数，默认 1
+	Relations   []SpatialRelation `mapstructure:"relations" json:"relations" validate:"omitempty,dive"`                // 允许传播的关系，为空表示不限
+	HopWeights  []float64         `mapstructure:"hop_weights" json:"hop_weights" validate:"omitempty,dive,gt=0,lte=1"` // 第 N 跳的权重，默认 1
+	MinScore    float64           `mapstructure:"min_score" json:"min_score" validate:"omitempty,gte=0,lte=1"`         // 合并所需的最低得分
+}
+
+// SpatialRelation 允许传播的关系类型与方向
+type SpatialRelation struct {
+	Type      string `mapstructure:"type" json:"type" validate:"required"`
+	Direction string `mapstructure:"direction" json:"direction" validate:"omitempty,oneof=forward backward bidirectional"` // 默认 bidirectional
+}
+
+// FaultPointPolicy 故障点策略
+type FaultPointPolicy struct {
+	Expiration   Expiration   `mapstructure:"expiration" form:"expiration" json:"expiration" validate:"required"`
+	Convergence  Convergence  `mapstructure:"convergence" form:"convergence" json:"convergence"`    // 收敛键策略
+	Deescalation Deescalation `mapstructure:"deescalation" form:"deescalation" json:"deescalation"` // 故障等级回落策略
+}
+
+// Deescalation 故障等级回落配置，窗口内未再收到当前等级的事件时，故障点等级回落到最新事件的等级
+type Deescalation struct {
+	Enabled       bool `mapstructure:"enabled" json:"enabled"`
+	WindowMinutes int  `mapstructure:"window_minutes" json:"window_minutes" validate:"omitempty,gte=1"` // 回落窗口（分钟）
+}
+
+// Convergence 故障点收敛键配置，规则按数据源、对象类匹配，同时指定两者的规则优先，均未命中时使用默认规则
+type Convergence struct {
+	Default ConvergenceRule   `mapstructure:"default" json:"default"`
+	Rules   []ConvergenceRule `mapstructure:"rules" json:"rules" validate:"omitempty,dive"`
+}
+
+// ConvergenceRule 收敛键规则，Source/ObjectClass 为空表示不限
+type ConvergenceRule struct {
+	Source      string   `mapstructure:"source" json:"source"`
+	ObjectClass string   `mapstructure:"object_class" json:"object_class"`
+	Strategy    string   `mapstructure:"strategy" json:"strategy" validate:"omitempty,oneof=item_key trigger_name normalized_title labels"` // 默认 item_key
+	Labels      []string `mapstructure:"labels" json:"labels" validate:"required_if=Strategy labels"`                                       // labels 策略使用的标签名
 }
 
 // Expiration 过期时间配置
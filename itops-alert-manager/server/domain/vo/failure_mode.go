@@ -0,0 +1,27 @@
+package vo
+
+// 故障模式规则匹配字段与方式
+const (
+	FailureModeFieldItemKey = "item_key"
+	FailureModeFieldTitle   = "title"
+	FailureModeMatchGlob    = "glob"
+	FailureModeMatchRegex   = "regex"
+)
+
+// FailureMode 故障模式目录项，将各监控工具的监控项 key / 告警标题归一为统一的故障模式（如 cpu_saturation）
+type FailureMode struct {
+	Mode        string            `mapstructure:"mode" json:"mode" validate:"required,max=64"` // 故障模式编码，小写字母、数字、下划线
+	Name        string            `mapstructure:"name" json:"name" validate:"required"`
+	Description string            `mapstructure:"description" json:"description"`
+	Rules       []FailureModeRule `mapstructure:"rules" json:"rules" validate:"required,min=1,dive"`
+	UpdateTime  string            `mapstructure:"update_time" json:"update_time"`
+	UpdateBy    string            `mapstructure:"update_by" json:"update_by"`
+}
+
+// FailureModeRule 故障模式匹配规则，指定对象类的规则优先于不限对象类的规则
+type FailureModeRule struct {
+	ObjectClass string `mapstructure:"object_class" json:"object_class"`                             // 为空表示不限对象类
+	Field       string `mapstructure:"field" json:"field" validate:"omitempty,oneof=item_key title"` // 匹配字段，默认 item_key
+	Match       string `mapstructure:"match" json:"match" validate:"omitempty,oneof=glob regex"`     // 匹配方式，默认 glob
+	Pattern     string `mapstructure:"pattern" json:"pattern" validate:"required"`                   // 匹配表达式
+}
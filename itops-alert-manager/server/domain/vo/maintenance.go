@@ -0,0 +1,46 @@
+package vo
+
+// 维护窗口重复周期
+const (
+	RecurrenceNone    = "none"
+	RecurrenceDaily   = "daily"
+	RecurrenceWeekly  = "weekly"
+	RecurrenceMonthly = "monthly"
+)
+
+// MaintenanceWindow 维护窗口，窗口内命中选择器的告警照常入库但不参与故障点与问题收敛
+type MaintenanceWindow struct {
+	ID          string                `mapstructure:"id" json:"id"`
+	Name        string                `mapstructure:"name" json:"name" validate:"required"`
+	Description string                `mapstructure:"description" json:"description"`
+	Enabled     bool                  `mapstructure:"enabled" json:"enabled"`
+	Selector    MaintenanceSelector   `mapstructure:"selector" json:"selector"`
+	StartTime   string                `mapstructure:"start_time" json:"start_time" validate:"required"` // 首次开始时间（RFC3339）
+	EndTime     string                `mapstructure:"end_time" json:"end_time" validate:"required"`     // 首次结束时间（RFC3339），与开始时间之差为每次维护时长
+	Recurrence  MaintenanceRecurrence `mapstructure:"recurrence" json:"recurrence"`
+	CreateTime  string                `mapstructure:"create_time" json:"create_time"`
+	UpdateTime  string                `mapstructure:"update_time" json:"update_time"`
+	UpdateBy    string                `mapstructure:"update_by" json:"update_by"`
+}
+
+// MaintenanceSelector 维护对象选择器，三类条件任一命中即视为维护对象
+type MaintenanceSelector struct {
+	EntityIDs     []string           `mapstructure:"entity_ids" json:"entity_ids"`                         // 对象 ID（s_id）
+	ObjectClasses []string           `mapstructure:"object_classes" json:"object_classes"`                 // 对象类
+	Subgraphs     []SubgraphSelector `mapstructure:"subgraphs" json:"subgraphs" validate:"omitempty,dive"` // 知识网络子图：起点对象及其 N 跳内的对象
+}
+
+// SubgraphSelector 知识网络子图选择器
+type SubgraphSelector struct {
+	ObjectClass string `mapstructure:"object_class" json:"object_class" validate:"required"`                                 // 起点对象类
+	EntityID    string `mapstructure:"entity_id" json:"entity_id" validate:"required"`                                       // 起点对象 ID
+	Direction   string `mapstructure:"direction" json:"direction" validate:"omitempty,oneof=forward backward bidirectional"` // 查询方向，默认 bidirectional
+	PathLength  int    `mapstructure:"path_length" json:"path_length" validate:"omitempty,gte=1,lte=5"`                      // 跳数，默认 1
+}
+
+// MaintenanceRecurrence 维护窗口重复规则
+type MaintenanceRecurrence struct {
+	Type     string `mapstructure:"type" json:"type" validate:"omitempty,oneof=none daily weekly monthly"`
+	Weekdays []int  `mapstructure:"weekdays" json:"weekdays" validate:"omitempty,dive,gte=0,lte=6"` // weekly 时生效，0-6 表示周日至周六，为空时取开始时间所在星期
+	Until    string `mapstructure:"until" json:"until,omitempty"`                                   // 重复截止时间（RFC3339），为空表示不截止
+}
@@ -110,3 +110,13 @@ type RootCauseObjectIdParams struct {
 	RootCauseObjectId string `form:"root_cause_object_id" json:"root_cause_object_id" validate:"required"`
 	RootCauseFaultID  uint64 `form:"root_cause_fault_id" json:"root_cause_fault_id" validate:"required"`
 }
+
+// SplitProblemParams 拆分问题参数
+type SplitProblemParams struct {
+	FaultIDs []uint64 `json:"fault_ids" validate:"required,min=1"`
+}
+
+// MergeProblemParams 合并问题参数
+type MergeProblemParams struct {
+	SourceProblemID uint64 `json:"source_problem_id" validate:"required"`
+}
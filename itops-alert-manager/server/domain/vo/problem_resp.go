@@ -88,8 +88,34 @@ type BaseResp struct {
 // RcaContext 分析上下文
 // 包含问题现象描述、故障回溯和分析网络
 type RcaContextResp struct {
-	BackTrace []map[string]any `json:"backtrace"`         // 故障回溯（按故障ID索引）
-	Network   RcaNetworkData   `json:"network,omitempty"` // 分析网络（JSON 格式，可选）
+	BackTrace    []map[string]any    `json:"backtrace"`              // 故障回溯（按故障ID索引）
+	Network      RcaNetworkData      `json:"network,omitempty"`      // 分析网络（JSON 格式，可选）
+	Correlations []CorrelationRecord `json:"correlations,omitempty"` // 故障点关联、问题合并依据
+}
+
+// CorrelationRecord 问题关联记录，说明故障点为什么在该问题中
+type CorrelationRecord struct {
+	RecordID        uint64             `json:"record_id"`
+	RecordType      string             `json:"record_type"`                 // created/fault_point/problem_merge
+	ProblemID       uint64             `json:"problem_id"`                  // 关联到的问题（合并时为主问题）
+	FaultID         uint64             `json:"fault_id"`                    // 触发关联的故障点
+	SourceProblemID uint64             `json:"source_problem_id,omitempty"` // 被合并的问题
+	Strategy        string             `json:"strategy,omitempty"`          // 命中策略：spatial/temporal/semantic
+	Score           float64            `json:"score"`
+	EntityPath      []string           `json:"entity_path,omitempty"` // 空间策略命中的对象路径
+	Combine         string             `json:"combine,omitempty"`
+	Scores          []CorrelationScore `json:"scores,omitempty"`
+	CreateTime      time.Time          `json:"create_time"`
+}
+
+// CorrelationScore 单个相关性策略的得分
+type CorrelationScore struct {
+	Strategy   string   `json:"strategy"`
+	Score      float64  `json:"score"`
+	Threshold  float64  `json:"threshold"`
+	Matched    bool     `json:"matched"`
+	Detail     string   `json:"detail,omitempty"`
+	EntityPath []string `json:"entity_path,omitempty"`
 }
 
 // 用于 FaultTrace，记录故障点信息
@@ -110,6 +136,7 @@ type FaultData struct {
 	FaultMode         string    `json:"fault_mode"`                   // 故障模式
 	FaultLevel        int       `json:"fault_level"`                  // 故障级别（1-5：紧急/严重/重要/警告/正常）
 	FaultDescription  string    `json:"fault_description"`            // 故障描述
+	FaultFlapping     bool      `json:"fault_flapping"`               // 是否处于抖动状态（频繁发生/恢复）
 }
 
 // RcaNetwork 分析网络
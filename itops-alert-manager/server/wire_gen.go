@@ -35,7 +35,11 @@ func initServer() *core.RouterQuote {
 	authVerifyService := service.NewAuthVerifyService()
 	problemController := controller.NewProblemController(validate, problemService, authVerifyService)
 	configController := controller.NewConfigController(validate,authVerifyService, configService)
-	httpRouter := controller.NewHandlerRoute(problemController,configController)
+	maintenanceService := service.NewMaintenanceService(configRepo)
+	maintenanceController := controller.NewMaintenanceController(validate, authVerifyService, maintenanceService)
+	failureModeService := service.NewFailureModeService(configRepo)
+	failureModeController := controller.NewFailureModeController(validate, authVerifyService, failureModeService)
+	httpRouter := controller.NewHandlerRoute(problemController,configController, maintenanceController, failureModeController)
 	routerQuote := controller.NewRouterQuote(httpRouter)
 	return routerQuote
 }
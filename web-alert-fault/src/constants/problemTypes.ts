@@ -39,6 +39,8 @@ export interface Problem {
   affected_entity_ids: string[];
   relation_fp_ids: string[];
   relation_event_ids: string[];
+  recurrence_of?: string;
+  recurrence_count?: number;
   root_cause_entity_object_name: string;
   root_cause_fault_description: string;
 }
@@ -208,6 +208,13 @@ const ProblemPage = () => {
       render: (text: string[], record: Problem) =>
         record.relation_event_ids?.length ?? 0
     },
+    {
+      title: intl.get('recurrence_count'),
+      dataIndex: 'recurrence_count',
+      key: 'recurrence_count',
+      width: 80,
+      render: (text: number) => text ?? 0
+    },
     {
       title: intl.get('root_cause_objects'),
       dataIndex: 'root_cause_entity_object_name',